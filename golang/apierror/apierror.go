@@ -0,0 +1,238 @@
+// Package apierror defines the error envelope returned by every API endpoint:
+//
+//	{"error": {"code": "PROPERTY_NOT_FOUND", "message": "Property not found", "details": ..., "request_id": "..."}}
+//
+// Handlers report failures with c.Error(apierror.X(...)) and return; the
+// middleware.ErrorHandler middleware renders the envelope. Codes per endpoint
+// are documented in docs/errors.md.
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// Machine-readable error codes
+const (
+	CodeInvalidRequest   = "INVALID_REQUEST"    // malformed body or query parameters
+	CodeValidationFailed = "VALIDATION_FAILED"  // well-formed request violating a business rule
+	CodeInvalidDate      = "INVALID_DATE"       // date not in YYYY-MM-DD format
+	CodeInvalidDateRange = "INVALID_DATE_RANGE" // end before start, or range too long
+	CodeUnauthorized     = "UNAUTHORIZED"       // missing credentials
+	CodeForbidden        = "FORBIDDEN"          // invalid credentials
+	CodeNotFound         = "NOT_FOUND"          // generic not found; resources use <RESOURCE>_NOT_FOUND
+	CodeAlreadyExists    = "ALREADY_EXISTS"     // unique constraint would be violated
+	CodeInvalidState     = "INVALID_STATE"      // resource state does not allow the operation
+	CodeUnprocessable    = "UNPROCESSABLE"      // request understood but cannot be carried out
+	CodeNotAvailable     = "NOT_AVAILABLE"      // requested nights cannot be booked
+	CodeUpstreamError    = "UPSTREAM_ERROR"     // a channel or other external service failed
+	CodeRateLimited      = "RATE_LIMITED"       // too many attempts; retry later
+	CodeMaintenance      = "MAINTENANCE"        // API paused for maintenance
+	CodeTimeout          = "TIMEOUT"            // request ran past its route's timeout
+	CodeInternal         = "INTERNAL_ERROR"     // unexpected server-side failure
+
+	CodeIdempotencyInProgress = "IDEMPOTENCY_KEY_IN_PROGRESS" // same key is being processed by another request
+	CodeIdempotencyKeyReused  = "IDEMPOTENCY_KEY_REUSED"      // same key sent with a different body
+)
+
+// APIError is the error body returned to API clients
+type APIError struct {
+	Status    int         `json:"-"`
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// WithDetails returns a copy of the error carrying additional details
+func (e *APIError) WithDetails(details interface{}) *APIError {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+// New creates an API error
+func New(status int, code, message string) *APIError {
+	return &APIError{Status: status, Code: code, Message: message}
+}
+
+// InvalidRequest reports a malformed request body or parameter
+func InvalidRequest(message string) *APIError {
+	return New(http.StatusBadRequest, CodeInvalidRequest, message)
+}
+
+// InvalidID reports a path parameter that is not a valid ID, e.g. InvalidID("property")
+func InvalidID(resource string) *APIError {
+	return New(http.StatusBadRequest, "INVALID_"+codeName(resource)+"_ID", "Invalid "+resource+" ID")
+}
+
+// Validation reports a request that breaks a business rule
+func Validation(message string) *APIError {
+	return New(http.StatusBadRequest, CodeValidationFailed, message)
+}
+
+// InvalidDate reports a date parameter in the wrong format
+func InvalidDate(message string) *APIError {
+	return New(http.StatusBadRequest, CodeInvalidDate, message)
+}
+
+// InvalidDateRange reports an inconsistent or oversized date range
+func InvalidDateRange(message string) *APIError {
+	return New(http.StatusBadRequest, CodeInvalidDateRange, message)
+}
+
+// NotFound reports a missing resource, e.g. NotFound("Property") -> PROPERTY_NOT_FOUND
+func NotFound(resource string) *APIError {
+	return New(http.StatusNotFound, codeName(resource)+"_NOT_FOUND", resource+" not found")
+}
+
+// AlreadyExists reports a uniqueness conflict
+func AlreadyExists(message string) *APIError {
+	return New(http.StatusConflict, CodeAlreadyExists, message)
+}
+
+// InvalidState reports an operation the resource's current state does not allow
+func InvalidState(message string) *APIError {
+	return New(http.StatusConflict, CodeInvalidState, message)
+}
+
+// Unprocessable reports a request that is valid but cannot be carried out
+func Unprocessable(message string) *APIError {
+	return New(http.StatusUnprocessableEntity, CodeUnprocessable, message)
+}
+
+// Upstream reports a failure of a channel or other external service
+func Upstream(message string) *APIError {
+	return New(http.StatusBadGateway, CodeUpstreamError, message)
+}
+
+// Timeout reports a request that ran past its route's timeout
+func Timeout() *APIError {
+	return New(http.StatusGatewayTimeout, CodeTimeout, "Request timed out")
+}
+
+// Internal reports an unexpected server-side failure
+func Internal(message string) *APIError {
+	return New(http.StatusInternalServerError, CodeInternal, message)
+}
+
+// From converts any error into an API error. Record-not-found errors become 404s;
+// anything else that is not already an APIError becomes an internal error.
+func From(err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return New(http.StatusNotFound, CodeNotFound, "Resource not found")
+	}
+	return Internal("Internal server error")
+}
+
+// codeName converts a resource name to its code form ("tax rule" -> "TAX_RULE")
+func codeName(resource string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(resource), " ", "_"))
+}
+
+// FieldError describes a single invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// FromBinding converts a request binding error into an API error. Validation
+// failures list every invalid field in Details; other errors (malformed JSON,
+// wrong types) are reported as INVALID_REQUEST.
+func FromBinding(err error) *APIError {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return InvalidRequest(err.Error())
+	}
+
+	fields := make([]FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields = append(fields, FieldError{
+			Field:   fieldPath(fe.Namespace()),
+			Rule:    fe.Tag(),
+			Message: fieldMessage(fe),
+		})
+	}
+	return Validation("Request validation failed").WithDetails(fields)
+}
+
+// InvalidField reports a single invalid field found by a handler-level check
+func InvalidField(field, rule, message string) *APIError {
+	return Validation("Request validation failed").WithDetails([]FieldError{{Field: field, Rule: rule, Message: message}})
+}
+
+// fieldPath drops the struct name from a validator namespace ("SeasonRequest.start_date" -> "start_date")
+func fieldPath(namespace string) string {
+	if i := strings.IndexByte(namespace, '.'); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// fieldMessage describes a failed validation rule in plain words
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_with":
+		return "is required when " + snakeCase(fe.Param()) + " is set"
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be at least " + fe.Param()
+	case "lt":
+		return "must be less than " + fe.Param()
+	case "lte":
+		return "must be at most " + fe.Param()
+	case "len":
+		return "must have length " + fe.Param()
+	case "oneof":
+		return "must be one of: " + fe.Param()
+	case "gtfield":
+		return "must be after " + snakeCase(fe.Param())
+	case "gtefield":
+		return "must not be less than " + snakeCase(fe.Param())
+	case "datetime":
+		return "must be a date in YYYY-MM-DD format"
+	case "notpast":
+		return "must not be in the past"
+	case "email":
+		return "must be a valid email address"
+	default:
+		return "failed the " + fe.Tag() + " rule"
+	}
+}
+
+// snakeCase converts a Go field name referenced by a rule to its JSON key ("CheckinDate" -> "checkin_date")
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
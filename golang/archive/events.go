@@ -0,0 +1,107 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"channelmanager/database"
+	"channelmanager/models"
+	"channelmanager/storage"
+
+	"gorm.io/gorm"
+)
+
+// eventBatchSize is the number of events written to one archive object
+const eventBatchSize = 5000
+
+// Config holds event archival configuration
+type Config struct {
+	EventRetentionDays int    // processed events older than this are archived; zero disables archival
+	Schedule           string // cron schedule of the archival job
+}
+
+// EventArchiver moves processed change events past their retention to object storage
+// as gzipped newline-delimited JSON and deletes them from the events table
+type EventArchiver struct {
+	config    Config
+	eventRepo *database.EventRepository
+	store     storage.ObjectStore
+}
+
+// NewEventArchiver creates a new event archiver
+func NewEventArchiver(db *gorm.DB, store storage.ObjectStore, config Config) *EventArchiver {
+	return &EventArchiver{
+		config:    config,
+		eventRepo: database.NewEventRepository(db),
+		store:     store,
+	}
+}
+
+// Key returns the object storage key of an archived batch of events
+func Key(first, last models.Event) string {
+	return fmt.Sprintf("archive/events/%s/events-%d-%d.jsonl.gz",
+		first.CreatedAt.UTC().Format("2006/01/02"), first.ID, last.ID)
+}
+
+// Archive archives and deletes the processed events older than the retention, batch
+// by batch, and returns the number of events archived. A batch is only deleted once
+// it is stored, so an interrupted run archives the remainder next time.
+func (a *EventArchiver) Archive(ctx context.Context) (int, error) {
+	cutoff := time.Now().AddDate(0, 0, -a.config.EventRetentionDays)
+
+	archived := 0
+	for ctx.Err() == nil {
+		events, err := a.eventRepo.GetProcessedEventsBefore(cutoff, eventBatchSize)
+		if err != nil {
+			return archived, fmt.Errorf("failed to load events: %w", err)
+		}
+		if len(events) == 0 {
+			break
+		}
+
+		data, err := encode(events)
+		if err != nil {
+			return archived, fmt.Errorf("failed to encode events: %w", err)
+		}
+		key := Key(events[0], events[len(events)-1])
+		if err := a.store.Put(ctx, key, data, "application/gzip"); err != nil {
+			return archived, fmt.Errorf("failed to store %s: %w", key, err)
+		}
+
+		ids := make([]uint, len(events))
+		for i, event := range events {
+			ids[i] = event.ID
+		}
+		if err := a.eventRepo.DeleteEvents(ids); err != nil {
+			return archived, fmt.Errorf("failed to delete events archived to %s: %w", key, err)
+		}
+
+		archived += len(events)
+		log.Printf("Archived %d events to %s", len(events), key)
+		if len(events) < eventBatchSize {
+			break
+		}
+	}
+	return archived, ctx.Err()
+}
+
+// encode writes events as gzipped newline-delimited JSON
+func encode(events []models.Event) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(writer)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
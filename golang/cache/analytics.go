@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"channelmanager/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ANALYTICS CACHE OPERATIONS
+
+// GetPropertyAnalyticsCache retrieves cached analytics for a property and date range
+func (rc *RedisClient) GetPropertyAnalyticsCache(ctx context.Context, propertyID uint, startDate, endDate string) (*models.PropertyAnalytics, error) {
+	key := fmt.Sprintf("analytics:property:%d:%s:%s", propertyID, startDate, endDate)
+	val, err := rc.client.Get(ctx, key).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil // Cache miss
+		}
+		return nil, err
+	}
+
+	var analytics models.PropertyAnalytics
+	if err := json.Unmarshal([]byte(val), &analytics); err != nil {
+		return nil, err
+	}
+
+	return &analytics, nil
+}
+
+// SetPropertyAnalyticsCache sets analytics for a property and date range in cache
+func (rc *RedisClient) SetPropertyAnalyticsCache(ctx context.Context, analytics *models.PropertyAnalytics, ttl time.Duration) error {
+	key := fmt.Sprintf("analytics:property:%d:%s:%s", analytics.PropertyID, analytics.StartDate, analytics.EndDate)
+	data, err := json.Marshal(analytics)
+	if err != nil {
+		return err
+	}
+
+	return rc.client.Set(ctx, key, data, ttl).Err()
+}
+
+// InvalidatePropertyAnalyticsCache invalidates all cached analytics for a property
+func (rc *RedisClient) InvalidatePropertyAnalyticsCache(ctx context.Context, propertyID uint) error {
+	pattern := fmt.Sprintf("analytics:property:%d:*", propertyID)
+	return rc.deleteByPattern(ctx, pattern)
+}
+
+// GetChannelPerformanceCache retrieves a cached channel performance report
+func (rc *RedisClient) GetChannelPerformanceCache(ctx context.Context, propertyID uint, startDate, endDate string) (*models.ChannelPerformanceReport, error) {
+	key := fmt.Sprintf("analytics:channels:%d:%s:%s", propertyID, startDate, endDate)
+	val, err := rc.client.Get(ctx, key).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil // Cache miss
+		}
+		return nil, err
+	}
+
+	var report models.ChannelPerformanceReport
+	if err := json.Unmarshal([]byte(val), &report); err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// SetChannelPerformanceCache sets a channel performance report in cache
+func (rc *RedisClient) SetChannelPerformanceCache(ctx context.Context, report *models.ChannelPerformanceReport, ttl time.Duration) error {
+	key := fmt.Sprintf("analytics:channels:%d:%s:%s", report.PropertyID, report.StartDate, report.EndDate)
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	return rc.client.Set(ctx, key, data, ttl).Err()
+}
+
+// InvalidateChannelPerformanceCache invalidates all cached channel performance reports
+func (rc *RedisClient) InvalidateChannelPerformanceCache(ctx context.Context) error {
+	return rc.deleteByPattern(ctx, "analytics:channels:*")
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	"channelmanager/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// CHANGE NOTIFICATION OPERATIONS
+
+// ChangeSubscription receives property change notifications published by any instance
+type ChangeSubscription struct {
+	pubsub  *redis.PubSub
+	changes chan models.PropertyChange
+	done    chan struct{}
+	once    sync.Once
+}
+
+// propertyChangesChannel returns the pub/sub channel for a property's changes
+func propertyChangesChannel(propertyID uint) string {
+	return fmt.Sprintf("changes:property:%d", propertyID)
+}
+
+// changeChannels returns the pub/sub channels for several properties
+func changeChannels(propertyIDs []uint) []string {
+	channels := make([]string, len(propertyIDs))
+	for i, propertyID := range propertyIDs {
+		channels[i] = propertyChangesChannel(propertyID)
+	}
+	return channels
+}
+
+// PublishPropertyChange publishes a change notification for a property
+func (rc *RedisClient) PublishPropertyChange(ctx context.Context, change *models.PropertyChange) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	return rc.client.Publish(ctx, propertyChangesChannel(change.PropertyID), data).Err()
+}
+
+// SubscribePropertyChanges subscribes to change notifications for the given properties;
+// more can be added later with Add
+func (rc *RedisClient) SubscribePropertyChanges(ctx context.Context, propertyIDs ...uint) (*ChangeSubscription, error) {
+	pubsub := rc.client.Subscribe(ctx, changeChannels(propertyIDs)...)
+	// Wait for the subscription to be confirmed so no change is missed afterwards
+	if len(propertyIDs) > 0 {
+		if _, err := pubsub.Receive(ctx); err != nil {
+			pubsub.Close()
+			return nil, err
+		}
+	}
+
+	sub := &ChangeSubscription{
+		pubsub:  pubsub,
+		changes: make(chan models.PropertyChange, 16),
+		done:    make(chan struct{}),
+	}
+	go sub.forward()
+	return sub, nil
+}
+
+// Add subscribes to changes of further properties
+func (s *ChangeSubscription) Add(ctx context.Context, propertyIDs ...uint) error {
+	return s.pubsub.Subscribe(ctx, changeChannels(propertyIDs)...)
+}
+
+// Remove stops receiving changes of the given properties
+func (s *ChangeSubscription) Remove(ctx context.Context, propertyIDs ...uint) error {
+	return s.pubsub.Unsubscribe(ctx, changeChannels(propertyIDs)...)
+}
+
+// Changes returns the channel delivering notifications; it is closed when the subscription ends
+func (s *ChangeSubscription) Changes() <-chan models.PropertyChange {
+	return s.changes
+}
+
+// Close ends the subscription
+func (s *ChangeSubscription) Close() error {
+	s.once.Do(func() { close(s.done) })
+	return s.pubsub.Close()
+}
+
+// forward decodes pub/sub messages until the subscription is closed
+func (s *ChangeSubscription) forward() {
+	defer close(s.changes)
+	for msg := range s.pubsub.Channel() {
+		var change models.PropertyChange
+		if err := json.Unmarshal([]byte(msg.Payload), &change); err != nil {
+			log.Printf("Failed to decode property change: %v", err)
+			continue
+		}
+		select {
+		case s.changes <- change:
+		case <-s.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"channelmanager/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// consistencyReportKey holds the latest cache consistency report
+const consistencyReportKey = "consistency:report"
+
+// sampleKeys returns up to n keys matching pattern, each equally likely to be chosen
+func (rc *RedisClient) sampleKeys(ctx context.Context, pattern string, n int) ([]string, error) {
+	sample := make([]string, 0, n)
+	seen := 0
+	iter := rc.client.Scan(ctx, 0, pattern, 500).Iterator()
+	for iter.Next(ctx) {
+		seen++
+		if len(sample) < n {
+			sample = append(sample, iter.Val())
+		} else if i := rand.Intn(seen); i < n {
+			sample[i] = iter.Val()
+		}
+	}
+	return sample, iter.Err()
+}
+
+// SampleCachedPropertyIDs returns up to n properties whose details are cached, chosen at
+// random
+func (rc *RedisClient) SampleCachedPropertyIDs(ctx context.Context, n int) ([]uint, error) {
+	keys, err := rc.sampleKeys(ctx, "property:*", n)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(keys))
+	for _, key := range keys {
+		id, err := strconv.ParseUint(strings.TrimPrefix(key, "property:"), 10, 32)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
+// SampleSearchCacheKeys returns up to n cached search result keys, chosen at random
+func (rc *RedisClient) SampleSearchCacheKeys(ctx context.Context, n int) ([]string, error) {
+	return rc.sampleKeys(ctx, "search:*", n)
+}
+
+// DeleteSearchResultsCache drops one cached search
+func (rc *RedisClient) DeleteSearchResultsCache(ctx context.Context, cacheKey string) error {
+	return rc.client.Del(ctx, cacheKey).Err()
+}
+
+// GetConsistencyReport retrieves the latest cache consistency report, nil before the
+// first check
+func (rc *RedisClient) GetConsistencyReport(ctx context.Context) (*models.CacheConsistencyReport, error) {
+	val, err := rc.client.Get(ctx, consistencyReportKey).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var report models.CacheConsistencyReport
+	if err := json.Unmarshal(val, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// SetConsistencyReport stores the latest cache consistency report
+func (rc *RedisClient) SetConsistencyReport(ctx context.Context, report *models.CacheConsistencyReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	return rc.client.Set(ctx, consistencyReportKey, data, 0).Err()
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// favoritesSentinel is stored in every favorites set so that a user without favorites
+// is cached as well; property IDs start at 1
+const favoritesSentinel = "0"
+
+func favoritesKey(userID string) string {
+	return fmt.Sprintf("favorites:user:%s", userID)
+}
+
+// GetFavoritesCache retrieves a user's cached favorite property IDs. The second
+// result is false on a cache miss.
+func (rc *RedisClient) GetFavoritesCache(ctx context.Context, userID string) ([]uint, bool, error) {
+	members, err := rc.client.SMembers(ctx, favoritesKey(userID)).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(members) == 0 {
+		return nil, false, nil // Cache miss
+	}
+
+	ids := make([]uint, 0, len(members)-1)
+	for _, member := range members {
+		if member == favoritesSentinel {
+			continue
+		}
+		id, err := strconv.ParseUint(member, 10, 32)
+		if err != nil {
+			return nil, false, err
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, true, nil
+}
+
+// SetFavoritesCache caches a user's favorite property IDs as a set with TTL
+func (rc *RedisClient) SetFavoritesCache(ctx context.Context, userID string, propertyIDs []uint, ttl time.Duration) error {
+	members := make([]interface{}, 0, len(propertyIDs)+1)
+	members = append(members, favoritesSentinel)
+	for _, id := range propertyIDs {
+		members = append(members, strconv.FormatUint(uint64(id), 10))
+	}
+
+	key := favoritesKey(userID)
+	pipe := rc.client.TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.SAdd(ctx, key, members...)
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// InvalidateFavoritesCache invalidates a user's cached favorites
+func (rc *RedisClient) InvalidateFavoritesCache(ctx context.Context, userID string) error {
+	return rc.client.Del(ctx, favoritesKey(userID)).Err()
+}
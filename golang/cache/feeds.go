@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+)
+
+// FEED OPERATIONS
+
+// staleFeedKey is the set of properties whose entries in an incrementally updated feed
+// are out of date
+func staleFeedKey(feed string) string {
+	return "feeds:stale:" + feed
+}
+
+// MarkFeedStale records that properties changed since their feed entries were rendered
+func (rc *RedisClient) MarkFeedStale(ctx context.Context, feed string, propertyIDs ...uint) error {
+	if len(propertyIDs) == 0 {
+		return nil
+	}
+	members := make([]interface{}, len(propertyIDs))
+	for i, id := range propertyIDs {
+		members[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	return rc.client.SAdd(ctx, staleFeedKey(feed), members...).Err()
+}
+
+// ClaimStaleFeedProperties removes and returns up to max properties marked stale for a
+// feed. Mark them stale again when their entries could not be rendered.
+func (rc *RedisClient) ClaimStaleFeedProperties(ctx context.Context, feed string, max int) ([]uint, error) {
+	members, err := rc.client.SPopN(ctx, staleFeedKey(feed), int64(max)).Result()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseUint(member, 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
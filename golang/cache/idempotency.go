@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"channelmanager/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// IDEMPOTENCY OPERATIONS
+
+// GetIdempotentResponse retrieves the stored response for an idempotency key
+func (rc *RedisClient) GetIdempotentResponse(ctx context.Context, key string) (*models.IdempotentResponse, error) {
+	val, err := rc.client.Get(ctx, "idempotency:response:"+key).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil // Cache miss
+		}
+		return nil, err
+	}
+
+	var response models.IdempotentResponse
+	if err := json.Unmarshal([]byte(val), &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// SetIdempotentResponse stores the response for an idempotency key
+func (rc *RedisClient) SetIdempotentResponse(ctx context.Context, key string, response *models.IdempotentResponse, ttl time.Duration) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	return rc.client.Set(ctx, "idempotency:response:"+key, data, ttl).Err()
+}
+
+// AcquireIdempotencyLock marks an idempotency key as in progress; it returns false
+// if another request holds the key
+func (rc *RedisClient) AcquireIdempotencyLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return rc.client.SetNX(ctx, "idempotency:lock:"+key, 1, ttl).Result()
+}
+
+// ReleaseIdempotencyLock releases an idempotency key held by AcquireIdempotencyLock
+func (rc *RedisClient) ReleaseIdempotencyLock(ctx context.Context, key string) error {
+	return rc.client.Del(ctx, "idempotency:lock:"+key).Err()
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// releaseLockScript deletes a lock only when it is still held by the caller
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// extendLockScript renews a lock's TTL only when it is still held by the caller
+var extendLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+func lockKey(name string) string {
+	return "lock:" + name
+}
+
+// AcquireLock takes a named lock for owner with a TTL. It returns false when the lock
+// is held by someone else.
+func (rc *RedisClient) AcquireLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	return rc.client.SetNX(ctx, lockKey(name), owner, ttl).Result()
+}
+
+// ExtendLock renews the TTL of a lock held by owner. It returns false when the lock
+// expired or was taken over.
+func (rc *RedisClient) ExtendLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	extended, err := extendLockScript.Run(ctx, rc.client, []string{lockKey(name)}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return extended == 1, nil
+}
+
+// ReleaseLock releases a lock if it is still held by owner
+func (rc *RedisClient) ReleaseLock(ctx context.Context, name, owner string) error {
+	return releaseLockScript.Run(ctx, rc.client, []string{lockKey(name)}, owner).Err()
+}
+
+// LockOwner returns the current owner of a lock, or an empty string when it is free
+func (rc *RedisClient) LockOwner(ctx context.Context, name string) (string, error) {
+	owner, err := rc.client.Get(ctx, lockKey(name)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return owner, err
+}
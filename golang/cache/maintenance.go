@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"channelmanager/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// maintenanceKey holds the active maintenance window shared by all replicas
+const maintenanceKey = "maintenance"
+
+// GetMaintenanceMode retrieves the active maintenance window, or nil when the API is up
+func (rc *RedisClient) GetMaintenanceMode(ctx context.Context) (*models.MaintenanceMode, error) {
+	val, err := rc.client.Get(ctx, maintenanceKey).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var mode models.MaintenanceMode
+	if err := json.Unmarshal([]byte(val), &mode); err != nil {
+		return nil, err
+	}
+	return &mode, nil
+}
+
+// SetMaintenanceMode starts maintenance; a positive ttl ends it automatically
+func (rc *RedisClient) SetMaintenanceMode(ctx context.Context, mode *models.MaintenanceMode, ttl time.Duration) error {
+	data, err := json.Marshal(mode)
+	if err != nil {
+		return err
+	}
+	return rc.client.Set(ctx, maintenanceKey, data, ttl).Err()
+}
+
+// ClearMaintenanceMode ends maintenance
+func (rc *RedisClient) ClearMaintenanceMode(ctx context.Context) error {
+	return rc.client.Del(ctx, maintenanceKey).Err()
+}
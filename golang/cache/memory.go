@@ -0,0 +1,215 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"channelmanager/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Cached searches are indexed by expiry, with their sizes and total size, so their
+// footprint can be capped
+const (
+	searchIndexKey = "searchcache:expiry"
+	searchSizesKey = "searchcache:sizes"
+	searchBytesKey = "searchcache:bytes"
+)
+
+// maxMemorySamples bounds the keys sampled by one memory report
+const maxMemorySamples = 1000
+
+// storeSearchScript stores a cached search, forgets the expired ones and, while the
+// cached searches exceed the cap in ARGV[4] (0 for none), drops the oldest others. It
+// returns the number of searches dropped.
+var storeSearchScript = redis.NewScript(`
+local now = tonumber(ARGV[3])
+for _, key in ipairs(redis.call("ZRANGEBYSCORE", KEYS[2], "-inf", now)) do
+	redis.call("DECRBY", KEYS[4], tonumber(redis.call("HGET", KEYS[3], key) or 0))
+	redis.call("HDEL", KEYS[3], key)
+end
+redis.call("ZREMRANGEBYSCORE", KEYS[2], "-inf", now)
+
+local previous = tonumber(redis.call("HGET", KEYS[3], KEYS[1]) or 0)
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+redis.call("ZADD", KEYS[2], now + tonumber(ARGV[2]), KEYS[1])
+redis.call("HSET", KEYS[3], KEYS[1], string.len(ARGV[1]))
+local total = redis.call("INCRBY", KEYS[4], string.len(ARGV[1]) - previous)
+
+local max = tonumber(ARGV[4])
+local dropped = 0
+while max > 0 and total > max do
+	local oldest = redis.call("ZRANGE", KEYS[2], 0, 0)[1]
+	if oldest == nil or oldest == KEYS[1] then
+		break
+	end
+	total = redis.call("DECRBY", KEYS[4], tonumber(redis.call("HGET", KEYS[3], oldest) or 0))
+	redis.call("DEL", oldest)
+	redis.call("ZREM", KEYS[2], oldest)
+	redis.call("HDEL", KEYS[3], oldest)
+	dropped = dropped + 1
+end
+return dropped`)
+
+// storeSearchResults stores a cached search within the configured search cache cap
+func (rc *RedisClient) storeSearchResults(ctx context.Context, cacheKey string, data []byte, ttl time.Duration) error {
+	keys := []string{cacheKey, searchIndexKey, searchSizesKey, searchBytesKey}
+	return storeSearchScript.Run(ctx, rc.client, keys, data, ttl.Milliseconds(), time.Now().UnixMilli(), rc.config.SearchCacheMaxBytes).Err()
+}
+
+// forgetSearchResults clears the index of cached searches once they are all dropped
+func (rc *RedisClient) forgetSearchResults(ctx context.Context) error {
+	return rc.client.Del(ctx, searchIndexKey, searchSizesKey, searchBytesKey).Err()
+}
+
+// MemoryPolicy retrieves Redis's memory use, limit and eviction policy
+func (rc *RedisClient) MemoryPolicy(ctx context.Context) (*models.RedisMemoryReport, error) {
+	info, err := rc.client.Info(ctx, "memory").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.RedisMemoryReport{KeyTypes: []models.KeyTypeMemory{}}
+	for _, line := range strings.Split(info, "\r\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "used_memory":
+			report.UsedBytes, _ = strconv.ParseInt(value, 10, 64)
+		case "maxmemory":
+			report.MaxBytes, _ = strconv.ParseInt(value, 10, 64)
+		case "maxmemory_policy":
+			report.Policy = value
+		}
+	}
+	if report.MaxBytes > 0 {
+		report.UsedRatio = float64(report.UsedBytes) / float64(report.MaxBytes)
+	}
+	report.SearchCacheMaxBytes = rc.config.SearchCacheMaxBytes
+	report.Warnings = memoryWarnings(report)
+	return report, nil
+}
+
+// CheckMemoryPolicy logs what in Redis's memory limit and eviction policy puts the
+// caches at risk, and fails when RequireEviction is set and Redis would not evict keys
+// once full
+func (rc *RedisClient) CheckMemoryPolicy(ctx context.Context) error {
+	report, err := rc.MemoryPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	for _, warning := range report.Warnings {
+		log.Printf("Redis memory warning: %s", warning)
+	}
+	if rc.config.RequireEviction && (report.MaxBytes == 0 || report.Policy == "noeviction") {
+		return fmt.Errorf("redis must have maxmemory set and evict keys (maxmemory %d, policy %q)", report.MaxBytes, report.Policy)
+	}
+	return nil
+}
+
+// MemoryReport estimates the memory used by each type of key, named by the prefix
+// before its first colon, from the MEMORY USAGE of up to samples random keys
+func (rc *RedisClient) MemoryReport(ctx context.Context, samples int) (*models.RedisMemoryReport, error) {
+	if samples > maxMemorySamples {
+		samples = maxMemorySamples
+	}
+
+	report, err := rc.MemoryPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if report.Keys, err = rc.client.DBSize(ctx).Result(); err != nil {
+		return nil, err
+	}
+	if report.SearchCacheBytes, err = rc.client.Get(ctx, searchBytesKey).Int64(); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	if report.Keys == 0 || samples <= 0 {
+		return report, nil
+	}
+
+	pipe := rc.client.Pipeline()
+	randomKeys := make([]*redis.StringCmd, samples)
+	for i := range randomKeys {
+		randomKeys[i] = pipe.RandomKey(ctx)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	pipe = rc.client.Pipeline()
+	sampled := make([]string, 0, samples)
+	usages := make([]*redis.IntCmd, 0, samples)
+	for _, cmd := range randomKeys {
+		key, err := cmd.Result()
+		if err != nil {
+			continue
+		}
+		sampled = append(sampled, key)
+		usages = append(usages, pipe.MemoryUsage(ctx, key))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	byType := make(map[string]*models.KeyTypeMemory)
+	totalSampled := 0
+	for i, key := range sampled {
+		bytes, err := usages[i].Result()
+		if err != nil {
+			continue // expired since it was drawn
+		}
+		keyType, _, _ := strings.Cut(key, ":")
+		memory, ok := byType[keyType]
+		if !ok {
+			memory = &models.KeyTypeMemory{Type: keyType}
+			byType[keyType] = memory
+		}
+		memory.SampledKeys++
+		memory.EstimatedBytes += bytes
+		totalSampled++
+	}
+	report.SampledKeys = totalSampled
+	if totalSampled == 0 {
+		return report, nil
+	}
+
+	// Each sampled key stands for its share of all keys
+	for _, memory := range byType {
+		memory.AverageBytes = memory.EstimatedBytes / int64(memory.SampledKeys)
+		memory.EstimatedKeys = report.Keys * int64(memory.SampledKeys) / int64(totalSampled)
+		memory.EstimatedBytes = memory.EstimatedKeys * memory.AverageBytes
+		report.KeyTypes = append(report.KeyTypes, *memory)
+	}
+	sort.Slice(report.KeyTypes, func(i, j int) bool {
+		return report.KeyTypes[i].EstimatedBytes > report.KeyTypes[j].EstimatedBytes
+	})
+	return report, nil
+}
+
+// memoryWarnings describes what in a memory limit and eviction policy puts the caches
+// at risk
+func memoryWarnings(report *models.RedisMemoryReport) []string {
+	warnings := []string{}
+	if report.MaxBytes == 0 {
+		warnings = append(warnings, "maxmemory is not set, so Redis grows until the host runs out of memory")
+	}
+	switch {
+	case report.Policy == "noeviction":
+		warnings = append(warnings, "maxmemory-policy is noeviction, so cache writes fail once Redis is full")
+	case strings.HasPrefix(report.Policy, "volatile-"):
+		warnings = append(warnings, "maxmemory-policy "+report.Policy+" only evicts keys with a TTL; locks, counters and reports have none")
+	}
+	if report.MaxBytes > 0 && report.SearchCacheMaxBytes > report.MaxBytes/2 {
+		warnings = append(warnings, "the search cache may use more than half of maxmemory")
+	}
+	return warnings
+}
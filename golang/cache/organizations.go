@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"channelmanager/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ORGANIZATION SETTINGS OPERATIONS
+
+// organizationSettingsKey identifies the cached settings of an organization
+func organizationSettingsKey(organizationID uint) string {
+	return fmt.Sprintf("organization:%d:settings", organizationID)
+}
+
+// GetOrganizationSettingsCache retrieves the cached settings of an organization
+func (rc *RedisClient) GetOrganizationSettingsCache(ctx context.Context, organizationID uint) (*models.OrganizationSettings, error) {
+	val, err := rc.client.Get(ctx, organizationSettingsKey(organizationID)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil // Cache miss
+		}
+		return nil, err
+	}
+
+	var settings models.OrganizationSettings
+	if err := json.Unmarshal([]byte(val), &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SetOrganizationSettingsCache caches the settings of an organization with TTL
+func (rc *RedisClient) SetOrganizationSettingsCache(ctx context.Context, settings *models.OrganizationSettings, ttl time.Duration) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return rc.client.Set(ctx, organizationSettingsKey(settings.OrganizationID), data, ttl).Err()
+}
+
+// InvalidateOrganizationSettingsCache drops the cached settings of an organization
+func (rc *RedisClient) InvalidateOrganizationSettingsCache(ctx context.Context, organizationID uint) error {
+	return rc.client.Del(ctx, organizationSettingsKey(organizationID)).Err()
+}
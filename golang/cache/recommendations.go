@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"channelmanager/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func similarKey(propertyID uint) string {
+	return fmt.Sprintf("similar:property:%d", propertyID)
+}
+
+// GetSimilarCache retrieves the cached properties similar to a property. The second
+// result is false on a cache miss.
+func (rc *RedisClient) GetSimilarCache(ctx context.Context, propertyID uint) ([]models.SimilarProperty, bool, error) {
+	val, err := rc.client.Get(ctx, similarKey(propertyID)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, false, nil // Cache miss
+		}
+		return nil, false, err
+	}
+
+	var similar []models.SimilarProperty
+	if err := json.Unmarshal([]byte(val), &similar); err != nil {
+		return nil, false, err
+	}
+	return similar, true, nil
+}
+
+// SetSimilarCache caches the properties similar to a property with TTL
+func (rc *RedisClient) SetSimilarCache(ctx context.Context, propertyID uint, similar []models.SimilarProperty, ttl time.Duration) error {
+	data, err := json.Marshal(similar)
+	if err != nil {
+		return err
+	}
+	return rc.client.Set(ctx, similarKey(propertyID), data, ttl).Err()
+}
+
+func recentlyViewedKey(session string) string {
+	return "recent:session:" + session
+}
+
+// RecordRecentlyViewed puts a property at the front of a session's recently viewed
+// properties, keeping the newest max and forgetting the session after retention
+func (rc *RedisClient) RecordRecentlyViewed(ctx context.Context, session string, propertyID uint, max int, retention time.Duration) error {
+	key := recentlyViewedKey(session)
+	member := strconv.FormatUint(uint64(propertyID), 10)
+	pipe := rc.client.TxPipeline()
+	pipe.LRem(ctx, key, 0, member)
+	pipe.LPush(ctx, key, member)
+	pipe.LTrim(ctx, key, 0, int64(max-1))
+	pipe.Expire(ctx, key, retention)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetRecentlyViewed retrieves a session's recently viewed property IDs, newest first
+func (rc *RedisClient) GetRecentlyViewed(ctx context.Context, session string) ([]uint, error) {
+	members, err := rc.client.LRange(ctx, recentlyViewedKey(session), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseUint(member, 10, 32)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
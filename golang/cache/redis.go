@@ -3,8 +3,10 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"channelmanager/models"
@@ -15,6 +17,7 @@ import (
 // RedisClient holds the Redis client instance
 type RedisClient struct {
 	client *redis.Client
+	config Config
 }
 
 // Config holds Redis configuration
@@ -23,6 +26,18 @@ type Config struct {
 	Port     int
 	Password string
 	DB       int
+
+	// Property details are cached for about the interval between the property's updates
+	// over VolatilityWindow, within PropertyMinTTL and PropertyMaxTTL
+	PropertyMinTTL   time.Duration
+	PropertyMaxTTL   time.Duration
+	VolatilityWindow time.Duration
+
+	// SearchCacheMaxBytes caps the size of cached searches, the oldest being dropped
+	// first, so they do not push property entries out of Redis; 0 leaves them uncapped
+	SearchCacheMaxBytes int64
+	// RequireEviction refuses to start unless Redis has maxmemory set and evicts keys
+	RequireEviction bool
 }
 
 // NewRedisClient creates a new Redis client
@@ -42,7 +57,7 @@ func NewRedisClient(config Config) (*RedisClient, error) {
 	}
 
 	log.Println("Redis connected successfully")
-	return &RedisClient{client: client}, nil
+	return &RedisClient{client: client, config: config}, nil
 }
 
 // Close closes the Redis connection
@@ -55,58 +70,11 @@ func (rc *RedisClient) GetClient() *redis.Client {
 	return rc.client
 }
 
-// AVAILABILITY CACHE OPERATIONS
-
-// GetAvailabilityCache retrieves availability from cache
-func (rc *RedisClient) GetAvailabilityCache(ctx context.Context, propertyID uint, date string) (*models.PropertyAvailabilityCache, error) {
-	key := fmt.Sprintf("availability:%d:%s", propertyID, date)
-	val, err := rc.client.Get(ctx, key).Result()
-	if err != nil {
-		if err == redis.Nil {
-			return nil, nil // Cache miss
-		}
-		return nil, err
-	}
-
-	var availability models.PropertyAvailabilityCache
-	if err := json.Unmarshal([]byte(val), &availability); err != nil {
-		return nil, err
-	}
-
-	return &availability, nil
-}
-
-// SetAvailabilityCache sets availability in cache with TTL
-func (rc *RedisClient) SetAvailabilityCache(ctx context.Context, propertyID uint, date string, availability *models.PropertyAvailabilityCache, ttl time.Duration) error {
-	key := fmt.Sprintf("availability:%d:%s", propertyID, date)
-	data, err := json.Marshal(availability)
-	if err != nil {
-		return err
-	}
-
-	return rc.client.Set(ctx, key, data, ttl).Err()
-}
-
-// InvalidateAvailabilityCache invalidates availability cache for a property
-func (rc *RedisClient) InvalidateAvailabilityCache(ctx context.Context, propertyID uint) error {
-	pattern := fmt.Sprintf("availability:%d:*", propertyID)
-	return rc.deleteByPattern(ctx, pattern)
-}
-
-// InvalidateAvailabilityDateRange invalidates availability cache for a date range
-func (rc *RedisClient) InvalidateAvailabilityDateRange(ctx context.Context, propertyID uint, startDate, endDate string) error {
-	pattern := fmt.Sprintf("availability:%d:*", propertyID)
-	iter := rc.client.Scan(ctx, 0, pattern, 0).Iterator()
+// CALENDAR CACHE OPERATIONS
 
-	var keys []string
-	for iter.Next(ctx) {
-		keys = append(keys, iter.Val())
-	}
-
-	if len(keys) > 0 {
-		return rc.client.Del(ctx, keys...).Err()
-	}
-	return nil
+// InvalidateCalendarCache drops the cached calendar views of a property
+func (rc *RedisClient) InvalidateCalendarCache(ctx context.Context, propertyID uint) error {
+	return rc.InvalidateResponses(ctx, CalendarTag(propertyID))
 }
 
 // SEARCH RESULTS CACHE OPERATIONS
@@ -146,7 +114,7 @@ func (rc *RedisClient) SetSearchResultsCache(ctx context.Context, cacheKey strin
 		return err
 	}
 
-	return rc.client.Set(ctx, cacheKey, data, ttl).Err()
+	return rc.storeSearchResults(ctx, cacheKey, data, ttl)
 }
 
 // InvalidateSearchCache invalidates search cache by pattern
@@ -163,7 +131,7 @@ func (rc *RedisClient) InvalidateSearchCache(ctx context.Context, location strin
 		}
 	}
 
-	return nil
+	return rc.forgetSearchResults(ctx)
 }
 
 // PROPERTY CACHE OPERATIONS
@@ -198,18 +166,38 @@ func (rc *RedisClient) SetPropertyCache(ctx context.Context, propertyID uint, pr
 	return rc.client.Set(ctx, key, data, ttl).Err()
 }
 
-// InvalidatePropertyCache invalidates property cache
+// InvalidatePropertyCache invalidates property cache, including its similar properties
 func (rc *RedisClient) InvalidatePropertyCache(ctx context.Context, propertyID uint) error {
 	key := fmt.Sprintf("property:%d", propertyID)
-	return rc.client.Del(ctx, key).Err()
+	return rc.client.Del(ctx, key, similarKey(propertyID)).Err()
 }
 
 // AMENITIES & CONDITIONS CACHE OPERATIONS
 
-// GetAmenitiesCache retrieves all amenities from cache
-func (rc *RedisClient) GetAmenitiesCache(ctx context.Context) ([]models.Amenity, error) {
-	key := "amenities:all"
-	val, err := rc.client.Get(ctx, key).Result()
+// amenitiesKey is the cache key of all amenities named in a locale, or as entered when
+// the locale is empty
+func amenitiesKey(locale string) string {
+	if locale == "" {
+		return "amenities:all"
+	}
+	return "amenities:locale:" + locale
+}
+
+// conditionsKey is the cache key of all conditions named in a locale, or as entered
+// when the locale is empty
+func conditionsKey(locale string) string {
+	if locale == "" {
+		return "conditions:all"
+	}
+	return "conditions:locale:" + locale
+}
+
+// referenceLocalesKey caches the locales amenity and condition names are translated to
+const referenceLocalesKey = "reference:locales"
+
+// GetAmenitiesCache retrieves all amenities named in a locale from cache
+func (rc *RedisClient) GetAmenitiesCache(ctx context.Context, locale string) ([]models.Amenity, error) {
+	val, err := rc.client.Get(ctx, amenitiesKey(locale)).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return nil, nil // Cache miss
@@ -225,18 +213,18 @@ func (rc *RedisClient) GetAmenitiesCache(ctx context.Context) ([]models.Amenity,
 	return amenities, nil
 }
 
-// SetAmenitiesCache sets all amenities in cache
-func (rc *RedisClient) SetAmenitiesCache(ctx context.Context, amenities []models.Amenity, ttl time.Duration) error {
-	key := "amenities:all"
+// SetAmenitiesCache sets all amenities named in a locale in cache
+func (rc *RedisClient) SetAmenitiesCache(ctx context.Context, locale string, amenities []models.Amenity, ttl time.Duration) error {
 	data, err := json.Marshal(amenities)
 	if err != nil {
 		return err
 	}
 
-	return rc.client.Set(ctx, key, data, ttl).Err()
+	return rc.client.Set(ctx, amenitiesKey(locale), data, ttl).Err()
 }
 
-// InvalidateAmenitiesCache invalidates amenities cache
+// InvalidateAmenitiesCache invalidates amenities cache and cached amenity responses in
+// every locale
 func (rc *RedisClient) InvalidateAmenitiesCache(ctx context.Context) error {
 	keys := []string{"amenities:all", "amenities:*"}
 	for _, key := range keys {
@@ -244,13 +232,12 @@ func (rc *RedisClient) InvalidateAmenitiesCache(ctx context.Context) error {
 			return err
 		}
 	}
-	return nil
+	return rc.InvalidateResponses(ctx, AmenitiesTag)
 }
 
-// GetConditionsCache retrieves all conditions from cache
-func (rc *RedisClient) GetConditionsCache(ctx context.Context) ([]models.Condition, error) {
-	key := "conditions:all"
-	val, err := rc.client.Get(ctx, key).Result()
+// GetConditionsCache retrieves all conditions named in a locale from cache
+func (rc *RedisClient) GetConditionsCache(ctx context.Context, locale string) ([]models.Condition, error) {
+	val, err := rc.client.Get(ctx, conditionsKey(locale)).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return nil, nil // Cache miss
@@ -266,18 +253,18 @@ func (rc *RedisClient) GetConditionsCache(ctx context.Context) ([]models.Conditi
 	return conditions, nil
 }
 
-// SetConditionsCache sets all conditions in cache
-func (rc *RedisClient) SetConditionsCache(ctx context.Context, conditions []models.Condition, ttl time.Duration) error {
-	key := "conditions:all"
+// SetConditionsCache sets all conditions named in a locale in cache
+func (rc *RedisClient) SetConditionsCache(ctx context.Context, locale string, conditions []models.Condition, ttl time.Duration) error {
 	data, err := json.Marshal(conditions)
 	if err != nil {
 		return err
 	}
 
-	return rc.client.Set(ctx, key, data, ttl).Err()
+	return rc.client.Set(ctx, conditionsKey(locale), data, ttl).Err()
 }
 
-// InvalidateConditionsCache invalidates conditions cache
+// InvalidateConditionsCache invalidates conditions cache and cached condition responses
+// in every locale
 func (rc *RedisClient) InvalidateConditionsCache(ctx context.Context) error {
 	keys := []string{"conditions:all", "conditions:*"}
 	for _, key := range keys {
@@ -285,7 +272,39 @@ func (rc *RedisClient) InvalidateConditionsCache(ctx context.Context) error {
 			return err
 		}
 	}
-	return nil
+	return rc.InvalidateResponses(ctx, ConditionsTag)
+}
+
+// GetReferenceLocalesCache retrieves the locales amenity and condition names are
+// translated to. The second result is false on a cache miss.
+func (rc *RedisClient) GetReferenceLocalesCache(ctx context.Context) ([]string, bool, error) {
+	val, err := rc.client.Get(ctx, referenceLocalesKey).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	var locales []string
+	if err := json.Unmarshal([]byte(val), &locales); err != nil {
+		return nil, false, err
+	}
+	return locales, true, nil
+}
+
+// SetReferenceLocalesCache caches the locales amenity and condition names are translated to
+func (rc *RedisClient) SetReferenceLocalesCache(ctx context.Context, locales []string, ttl time.Duration) error {
+	data, err := json.Marshal(locales)
+	if err != nil {
+		return err
+	}
+	return rc.client.Set(ctx, referenceLocalesKey, data, ttl).Err()
+}
+
+// InvalidateReferenceLocalesCache invalidates the cached translation locales
+func (rc *RedisClient) InvalidateReferenceLocalesCache(ctx context.Context) error {
+	return rc.client.Del(ctx, referenceLocalesKey).Err()
 }
 
 // UTILITY METHODS
@@ -315,6 +334,35 @@ func (rc *RedisClient) Flush(ctx context.Context) error {
 	return rc.client.FlushDB(ctx).Err()
 }
 
+// testMarkerKey marks a Redis database as emptied by FlushTestDB
+const testMarkerKey = "test:marker"
+
+// ErrNotTestDB is returned by FlushTestDB for a database it did not empty before
+var ErrNotTestDB = errors.New("redis database holds data and is not marked as a test database")
+
+// FlushTestDB empties the Redis database for test mode. It refuses a database holding
+// keys unless it was emptied by FlushTestDB before, so a shared or production Redis is
+// never flushed by mistake, and marks the database once empty.
+func (rc *RedisClient) FlushTestDB(ctx context.Context) error {
+	size, err := rc.client.DBSize(ctx).Result()
+	if err != nil {
+		return err
+	}
+	if size > 0 {
+		marked, err := rc.client.Exists(ctx, testMarkerKey).Result()
+		if err != nil {
+			return err
+		}
+		if marked == 0 {
+			return fmt.Errorf("%w (db %d)", ErrNotTestDB, rc.config.DB)
+		}
+	}
+	if err := rc.client.FlushDB(ctx).Err(); err != nil {
+		return err
+	}
+	return rc.client.Set(ctx, testMarkerKey, "1", 0).Err()
+}
+
 // HealthCheck checks Redis connection health
 func (rc *RedisClient) HealthCheck(ctx context.Context) error {
 	return rc.client.Ping(ctx).Err()
@@ -322,7 +370,21 @@ func (rc *RedisClient) HealthCheck(ctx context.Context) error {
 
 // GetCacheStats returns cache statistics
 func (rc *RedisClient) GetCacheStats(ctx context.Context) (map[string]string, error) {
-	return rc.client.Info(ctx, "stats").Val(), nil
+	info, err := rc.client.Info(ctx, "stats").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]string)
+	for _, line := range strings.Split(info, "\r\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			stats[key] = value
+		}
+	}
+	return stats, nil
 }
 
 // SetWithExpiry sets a value with expiry time
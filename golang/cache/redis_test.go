@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"channelmanager/testenv"
+)
+
+// testRedis connects to a Redis container started for the test, skipping the test when
+// Docker is not available
+func testRedis(t *testing.T) *RedisClient {
+	t.Helper()
+	env, err := testenv.Start()
+	if errors.Is(err, testenv.ErrDockerUnavailable) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("failed to start test containers: %v", err)
+	}
+	t.Cleanup(func() { env.Close() })
+
+	client, err := NewRedisClient(Config{Host: env.RedisHost, Port: env.RedisPort})
+	if err != nil {
+		t.Fatalf("failed to connect to Redis: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestFlushTestDB(t *testing.T) {
+	client := testRedis(t)
+	ctx := context.Background()
+	redis := client.GetClient()
+
+	if err := redis.Set(ctx, "kept", "1", 0).Err(); err != nil {
+		t.Fatalf("failed to set key: %v", err)
+	}
+	if err := client.FlushTestDB(ctx); !errors.Is(err, ErrNotTestDB) {
+		t.Fatalf("FlushTestDB() on unmarked data error = %v, want ErrNotTestDB", err)
+	}
+	if n, _ := redis.Exists(ctx, "kept").Result(); n != 1 {
+		t.Fatal("FlushTestDB() deleted keys of an unmarked database")
+	}
+
+	redis.Del(ctx, "kept")
+	if err := client.FlushTestDB(ctx); err != nil {
+		t.Fatalf("FlushTestDB() on an empty database error = %v", err)
+	}
+	redis.Set(ctx, "fixture", "1", 0)
+	if err := client.FlushTestDB(ctx); err != nil {
+		t.Fatalf("FlushTestDB() on a marked database error = %v", err)
+	}
+	if n, _ := redis.Exists(ctx, "fixture").Result(); n != 0 {
+		t.Error("FlushTestDB() kept keys of a marked database")
+	}
+}
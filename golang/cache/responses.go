@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"channelmanager/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RESPONSE CACHE OPERATIONS
+
+// Tags of cached responses, invalidated when the entities they show change
+const (
+	AmenitiesTag  = "amenities"
+	ConditionsTag = "conditions"
+)
+
+// CalendarTag tags the cached calendar views of a property
+func CalendarTag(propertyID uint) string {
+	return fmt.Sprintf("calendar:%d", propertyID)
+}
+
+// responseGenerationKey holds the generation of a tag, moved on by each invalidation
+func responseGenerationKey(tag string) string {
+	return "responses:generation:" + tag
+}
+
+// responseKey identifies a response cached under a generation of its tag
+func responseKey(tag string, generation int64, fingerprint string) string {
+	return fmt.Sprintf("responses:%s:%d:%s", tag, generation, fingerprint)
+}
+
+// ResponseGeneration returns the current generation of a tag. Responses are cached
+// under the generation they were rendered in, so those of earlier generations are no
+// longer found once the tag is invalidated and expire on their own.
+func (rc *RedisClient) ResponseGeneration(ctx context.Context, tag string) (int64, error) {
+	generation, err := rc.client.Get(ctx, responseGenerationKey(tag)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return generation, err
+}
+
+// GetCachedResponse retrieves the response cached for a request fingerprint under a
+// generation of its tag
+func (rc *RedisClient) GetCachedResponse(ctx context.Context, tag string, generation int64, fingerprint string) (*models.CachedResponse, error) {
+	val, err := rc.client.Get(ctx, responseKey(tag, generation, fingerprint)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil // Cache miss
+		}
+		return nil, err
+	}
+
+	var response models.CachedResponse
+	if err := json.Unmarshal([]byte(val), &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// SetCachedResponse caches the response to a request fingerprint under a generation of
+// its tag with TTL
+func (rc *RedisClient) SetCachedResponse(ctx context.Context, tag string, generation int64, fingerprint string, response *models.CachedResponse, ttl time.Duration) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return rc.client.Set(ctx, responseKey(tag, generation, fingerprint), data, ttl).Err()
+}
+
+// InvalidateResponses drops every response cached under the tags
+func (rc *RedisClient) InvalidateResponses(ctx context.Context, tags ...string) error {
+	pipe := rc.client.Pipeline()
+	for _, tag := range tags {
+		pipe.Incr(ctx, responseGenerationKey(tag))
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
@@ -0,0 +1,185 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Property statistics counted in Redis
+const (
+	StatViews       = "views"       // property detail views
+	StatImpressions = "impressions" // appearances on a search results page
+)
+
+// statsCounterTTL bounds how long counters survive when they are not flushed
+const statsCounterTTL = 7 * 24 * time.Hour
+
+// claimStatsScript adds the counters of a live hash to its pending hash and deletes the
+// live one, so counting continues in a fresh hash while the pending one is flushed
+var claimStatsScript = redis.NewScript(`
+local counts = redis.call("HGETALL", KEYS[1])
+for i = 1, #counts, 2 do
+	redis.call("HINCRBY", KEYS[2], counts[i], counts[i + 1])
+end
+redis.call("DEL", KEYS[1])
+redis.call("EXPIRE", KEYS[2], ARGV[1])
+return #counts / 2`)
+
+// statsKey is the hash of a day's counters of one statistic, by property ID
+func statsKey(stat string, date time.Time) string {
+	return fmt.Sprintf("stats:%s:%s", stat, date.UTC().Format("2006-01-02"))
+}
+
+// StatCounters are the claimed counters of one statistic on one day, by property
+type StatCounters struct {
+	Stat   string
+	Date   time.Time
+	Counts map[uint]int64
+
+	key string
+}
+
+// RecordPropertyView counts a detail view of a property
+func (rc *RedisClient) RecordPropertyView(ctx context.Context, propertyID uint) error {
+	return rc.incrementStats(ctx, StatViews, []uint{propertyID})
+}
+
+// RecordSearchImpressions counts an appearance in search results for each property
+func (rc *RedisClient) RecordSearchImpressions(ctx context.Context, propertyIDs []uint) error {
+	if len(propertyIDs) == 0 {
+		return nil
+	}
+	return rc.incrementStats(ctx, StatImpressions, propertyIDs)
+}
+
+func (rc *RedisClient) incrementStats(ctx context.Context, stat string, propertyIDs []uint) error {
+	key := statsKey(stat, time.Now())
+	pipe := rc.client.Pipeline()
+	for _, id := range propertyIDs {
+		pipe.HIncrBy(ctx, key, strconv.FormatUint(uint64(id), 10), 1)
+	}
+	pipe.Expire(ctx, key, statsCounterTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// ClaimStatCounters moves the counted statistics to pending hashes and returns every
+// pending hash, including those of earlier flushes that failed. Call
+// ReleaseStatCounters once the counters are stored.
+func (rc *RedisClient) ClaimStatCounters(ctx context.Context) ([]StatCounters, error) {
+	for _, stat := range []string{StatViews, StatImpressions} {
+		iter := rc.client.Scan(ctx, 0, "stats:"+stat+":*", 0).Iterator()
+		for iter.Next(ctx) {
+			live := iter.Val()
+			pending := "stats:pending:" + strings.TrimPrefix(live, "stats:")
+			if err := claimStatsScript.Run(ctx, rc.client, []string{live, pending}, int(statsCounterTTL.Seconds())).Err(); err != nil {
+				return nil, err
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var claimed []StatCounters
+	iter := rc.client.Scan(ctx, 0, "stats:pending:*", 0).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		parts := strings.Split(key, ":")
+		if len(parts) != 4 {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", parts[3])
+		if err != nil {
+			continue
+		}
+
+		values, err := rc.client.HGetAll(ctx, key).Result()
+		if err != nil {
+			return nil, err
+		}
+		counters := StatCounters{Stat: parts[2], Date: date, Counts: make(map[uint]int64, len(values)), key: key}
+		for field, value := range values {
+			id, err := strconv.ParseUint(field, 10, 32)
+			if err != nil {
+				continue
+			}
+			count, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			counters.Counts[uint(id)] = count
+		}
+		claimed = append(claimed, counters)
+	}
+	return claimed, iter.Err()
+}
+
+// ReleaseStatCounters deletes claimed counters once they are stored
+func (rc *RedisClient) ReleaseStatCounters(ctx context.Context, counters StatCounters) error {
+	return rc.client.Del(ctx, counters.key).Err()
+}
+
+// trendingSentinel is stored in every trending set so that a city without trending
+// properties is cached as well; property IDs start at 1
+const trendingSentinel = "0"
+
+func trendingKey(city string) string {
+	return "trending:" + strings.ToLower(strings.TrimSpace(city))
+}
+
+// TrendingEntry is a property in a cached trending ranking
+type TrendingEntry struct {
+	PropertyID uint
+	Score      float64
+}
+
+// GetTrendingCache retrieves the top limit entries of a city's cached trending ranking,
+// or of all cities for an empty city. The second result is false on a cache miss.
+func (rc *RedisClient) GetTrendingCache(ctx context.Context, city string, limit int) ([]TrendingEntry, bool, error) {
+	members, err := rc.client.ZRevRangeWithScores(ctx, trendingKey(city), 0, int64(limit)).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(members) == 0 {
+		return nil, false, nil // Cache miss
+	}
+
+	entries := make([]TrendingEntry, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseUint(fmt.Sprint(member.Member), 10, 32)
+		if err != nil {
+			return nil, false, err
+		}
+		if id == 0 {
+			continue
+		}
+		entries = append(entries, TrendingEntry{PropertyID: uint(id), Score: member.Score})
+	}
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, true, nil
+}
+
+// SetTrendingCache caches a city's trending ranking as a sorted set with TTL
+func (rc *RedisClient) SetTrendingCache(ctx context.Context, city string, entries []TrendingEntry, ttl time.Duration) error {
+	members := make([]redis.Z, 0, len(entries)+1)
+	members = append(members, redis.Z{Score: -1, Member: trendingSentinel})
+	for _, entry := range entries {
+		members = append(members, redis.Z{Score: entry.Score, Member: strconv.FormatUint(uint64(entry.PropertyID), 10)})
+	}
+
+	key := trendingKey(city)
+	pipe := rc.client.TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.ZAdd(ctx, key, members...)
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
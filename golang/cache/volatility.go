@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultPropertyTTL is how long property details are cached without volatility bounds
+const defaultPropertyTTL = time.Hour
+
+// volatilityBurst is the span in which a property's updates count once, so a bulk edit
+// of many nights does not pass for many separate updates
+const volatilityBurst = time.Minute
+
+// volatilityKey is the counter of a property's updates in one volatility window
+func volatilityKey(propertyID uint, window int64) string {
+	return fmt.Sprintf("volatility:property:%d:%d", propertyID, window)
+}
+
+// volatilityBurstKey marks a property updated in the current burst
+func volatilityBurstKey(propertyID uint) string {
+	return fmt.Sprintf("volatility:property:%d:burst", propertyID)
+}
+
+// RecordPropertyUpdate counts an update of a property towards its volatility. Updates
+// within a minute of a counted one are not counted again.
+func (rc *RedisClient) RecordPropertyUpdate(ctx context.Context, propertyID uint) error {
+	window := rc.config.VolatilityWindow
+	if window <= 0 {
+		return nil
+	}
+
+	first, err := rc.client.SetNX(ctx, volatilityBurstKey(propertyID), 1, volatilityBurst).Result()
+	if err != nil || !first {
+		return err
+	}
+
+	key := volatilityKey(propertyID, time.Now().UnixNano()/int64(window))
+	pipe := rc.client.TxPipeline()
+	pipe.Incr(ctx, key)
+	// The counter is read as the previous window during the next one
+	pipe.Expire(ctx, key, 2*window)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// PropertyUpdateRate estimates how many times a property was updated over the last
+// volatility window, weighting the previous window's count by how much of it is still
+// within the last window
+func (rc *RedisClient) PropertyUpdateRate(ctx context.Context, propertyID uint) (float64, error) {
+	window := rc.config.VolatilityWindow
+	if window <= 0 {
+		return 0, nil
+	}
+
+	now := time.Now().UnixNano()
+	current := now / int64(window)
+	elapsed := float64(now%int64(window)) / float64(window)
+
+	counts, err := rc.client.MGet(ctx, volatilityKey(propertyID, current), volatilityKey(propertyID, current-1)).Result()
+	if err != nil && err != redis.Nil {
+		return 0, err
+	}
+	return counterValue(counts[0]) + counterValue(counts[1])*(1-elapsed), nil
+}
+
+// PropertyCacheTTL returns how long to cache a property's details: about the interval
+// between its updates over the volatility window, so rarely changed listings stay
+// cached longer, bounded by the configured minimum and maximum
+func (rc *RedisClient) PropertyCacheTTL(ctx context.Context, propertyID uint) (time.Duration, error) {
+	minTTL, maxTTL, window := rc.config.PropertyMinTTL, rc.config.PropertyMaxTTL, rc.config.VolatilityWindow
+	if maxTTL <= 0 {
+		maxTTL = defaultPropertyTTL
+	}
+	if minTTL <= 0 || minTTL > maxTTL {
+		minTTL = maxTTL
+	}
+	if window <= 0 {
+		return maxTTL, nil
+	}
+
+	updates, err := rc.PropertyUpdateRate(ctx, propertyID)
+	if err != nil {
+		return minTTL, err
+	}
+	return adaptiveTTL(window, updates, minTTL, maxTTL), nil
+}
+
+// adaptiveTTL divides a window by the updates made over it, plus one, and bounds the
+// result by minTTL and maxTTL
+func adaptiveTTL(window time.Duration, updates float64, minTTL, maxTTL time.Duration) time.Duration {
+	ttl := time.Duration(float64(window) / (updates + 1))
+	if ttl < minTTL {
+		return minTTL
+	}
+	if ttl > maxTTL {
+		return maxTTL
+	}
+	return ttl
+}
+
+// counterValue reads a counter returned by MGET, zero when it does not exist
+func counterValue(value interface{}) float64 {
+	s, ok := value.(string)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.ParseFloat(s, 64)
+	return n
+}
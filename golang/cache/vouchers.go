@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// VOUCHER OPERATIONS
+
+// voucherFailuresKey counts a client's attempts with voucher codes that do not exist
+func voucherFailuresKey(client string) string {
+	return "vouchers:failures:" + client
+}
+
+// RecordVoucherFailure counts an attempt by a client with an unknown voucher code and
+// returns its attempts within the window, which starts at the first of them
+func (rc *RedisClient) RecordVoucherFailure(ctx context.Context, client string, window time.Duration) (int64, error) {
+	key := voucherFailuresKey(client)
+	count, err := rc.client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if err := rc.client.Expire(ctx, key, window).Err(); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// GetVoucherFailures returns a client's attempts with unknown voucher codes in the
+// current window
+func (rc *RedisClient) GetVoucherFailures(ctx context.Context, client string) (int64, error) {
+	count, err := rc.client.Get(ctx, voucherFailuresKey(client)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
+}
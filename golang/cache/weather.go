@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"channelmanager/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// WEATHER OPERATIONS
+
+// weatherKey identifies the cached forecast of a city
+func weatherKey(city, country string) string {
+	return "weather:" + strings.ToLower(strings.TrimSpace(country)) + ":" + strings.ToLower(strings.TrimSpace(city))
+}
+
+// GetWeatherCache retrieves the cached forecast of a city. The second result is false
+// on a cache miss.
+func (rc *RedisClient) GetWeatherCache(ctx context.Context, city, country string) (*models.WeatherForecast, bool, error) {
+	val, err := rc.client.Get(ctx, weatherKey(city, country)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, false, nil // Cache miss
+		}
+		return nil, false, err
+	}
+
+	var forecast models.WeatherForecast
+	if err := json.Unmarshal([]byte(val), &forecast); err != nil {
+		return nil, false, err
+	}
+	return &forecast, true, nil
+}
+
+// SetWeatherCache caches the forecast of a city with TTL
+func (rc *RedisClient) SetWeatherCache(ctx context.Context, forecast models.WeatherForecast, ttl time.Duration) error {
+	data, err := json.Marshal(forecast)
+	if err != nil {
+		return err
+	}
+	return rc.client.Set(ctx, weatherKey(forecast.City, forecast.Country), data, ttl).Err()
+}
@@ -0,0 +1,98 @@
+package channels
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"channelmanager/models"
+)
+
+// ARIUpdate holds availability, rates and inventory for a single night as sent to a channel
+type ARIUpdate struct {
+	Date      time.Time `json:"date"`
+	Available bool      `json:"available"`
+	MinStay   int       `json:"min_stay"`
+	MaxGuests int       `json:"max_guests"`
+	Rate      float64   `json:"rate"`
+	Currency  string    `json:"currency"`
+}
+
+// ChannelAdapter pushes data to a single OTA using its own API
+type ChannelAdapter interface {
+	// ChannelID returns the code of the channel this adapter talks to
+	ChannelID() string
+	// PushARI sends nightly availability and rates for a mapped property
+	PushARI(ctx context.Context, mapping models.ChannelMapping, updates []ARIUpdate) error
+	// PushContent sends descriptive content with amenities and conditions in the channel's codes
+	PushContent(ctx context.Context, mapping models.ChannelMapping, content PropertyContent) error
+}
+
+// Registry resolves the adapter for a channel
+type Registry struct {
+	adapters map[string]ChannelAdapter
+	fallback ChannelAdapter
+}
+
+// NewRegistry creates a registry; channels without a dedicated adapter use the logging adapter
+func NewRegistry(adapters ...ChannelAdapter) *Registry {
+	registry := &Registry{
+		adapters: make(map[string]ChannelAdapter),
+		fallback: &LoggingAdapter{},
+	}
+	for _, adapter := range adapters {
+		registry.Register(adapter)
+	}
+	return registry
+}
+
+// Register adds or replaces the adapter for its channel
+func (r *Registry) Register(adapter ChannelAdapter) {
+	r.adapters[adapter.ChannelID()] = adapter
+}
+
+// SetFallback replaces the adapter used for channels without a dedicated adapter
+func (r *Registry) SetFallback(adapter ChannelAdapter) {
+	r.fallback = adapter
+}
+
+// Get returns the adapter for a channel
+func (r *Registry) Get(channelID string) ChannelAdapter {
+	if adapter, ok := r.adapters[channelID]; ok {
+		return adapter
+	}
+	return r.fallback
+}
+
+// Adapters returns the registered adapters
+func (r *Registry) Adapters() []ChannelAdapter {
+	adapters := make([]ChannelAdapter, 0, len(r.adapters))
+	for _, adapter := range r.adapters {
+		adapters = append(adapters, adapter)
+	}
+	sort.Slice(adapters, func(i, j int) bool { return adapters[i].ChannelID() < adapters[j].ChannelID() })
+	return adapters
+}
+
+// LoggingAdapter is used for channels without an integration; it only logs the push
+type LoggingAdapter struct{}
+
+// ChannelID returns an empty code since the logging adapter serves any channel
+func (a *LoggingAdapter) ChannelID() string {
+	return ""
+}
+
+// PushARI logs the updates that would have been sent
+func (a *LoggingAdapter) PushARI(ctx context.Context, mapping models.ChannelMapping, updates []ARIUpdate) error {
+	log.Printf("No adapter for channel %s, skipping push of %d ARI updates for property %d",
+		mapping.ChannelID, len(updates), mapping.PropertyID)
+	return nil
+}
+
+// PushContent logs the content that would have been sent
+func (a *LoggingAdapter) PushContent(ctx context.Context, mapping models.ChannelMapping, content PropertyContent) error {
+	log.Printf("No adapter for channel %s, skipping content push for property %d (%d amenities, %d conditions)",
+		mapping.ChannelID, mapping.PropertyID, len(content.Amenities), len(content.Conditions))
+	return nil
+}
@@ -0,0 +1,189 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"channelmanager/database"
+	"channelmanager/fx"
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// ARIPushService pushes availability and channel-adjusted rates to every mapped channel
+type ARIPushService struct {
+	registry         *Registry
+	channelRepo      *database.ChannelRepository
+	propertyRepo     *database.PropertyRepository
+	availabilityRepo *database.AvailabilityRepository
+	pricingRepo      *database.PricingRepository
+	parityRepo       *database.ParityRepository
+	syncLogs         *database.SyncLogRepository
+	dryRuns          *database.DryRunRepository
+	exchangeRates    *fx.Service
+}
+
+// NewARIPushService creates a new ARI push service
+func NewARIPushService(db *gorm.DB, registry *Registry, exchangeRates *fx.Service) *ARIPushService {
+	return &ARIPushService{
+		registry:         registry,
+		exchangeRates:    exchangeRates,
+		channelRepo:      database.NewChannelRepository(db),
+		propertyRepo:     database.NewPropertyRepository(db),
+		availabilityRepo: database.NewAvailabilityRepository(db),
+		pricingRepo:      database.NewPricingRepository(db),
+		parityRepo:       database.NewParityRepository(db),
+		syncLogs:         database.NewSyncLogRepository(db),
+		dryRuns:          database.NewDryRunRepository(db),
+	}
+}
+
+// PushProperty pushes ARI for [startDate, endDate] of a property to all of its active channels
+func (s *ARIPushService) PushProperty(ctx context.Context, propertyID uint, startDate, endDate time.Time) error {
+	mappings, err := s.channelRepo.GetActiveMappingsForProperty(propertyID)
+	if err != nil {
+		return fmt.Errorf("failed to load channel mappings: %w", err)
+	}
+	if len(mappings) == 0 {
+		return nil
+	}
+
+	var firstErr error
+	for _, mapping := range mappings {
+		if err := s.PushMapping(ctx, mapping, startDate, endDate); err != nil {
+			log.Printf("ARI push to %s for property %d failed: %v", mapping.ChannelID, propertyID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// PushMapping builds and sends the ARI updates for [startDate, endDate] to a single
+// channel mapping, whose Channel must be loaded. Channels in dry-run mode get the
+// payload rendered and stored instead.
+func (s *ARIPushService) PushMapping(ctx context.Context, mapping models.ChannelMapping, startDate, endDate time.Time) error {
+	if mapping.Channel == nil {
+		return fmt.Errorf("mapping %d has no channel loaded", mapping.ID)
+	}
+
+	updates, err := s.BuildUpdates(*mapping.Channel, mapping.PropertyID, startDate, endDate)
+	if err != nil {
+		return err
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	adapter := s.registry.Get(mapping.ChannelID)
+	if isDryRun(mapping) {
+		payload, err := renderARI(adapter, mapping, updates)
+		if err != nil {
+			return fmt.Errorf("failed to render ARI payload: %w", err)
+		}
+		return recordDryRun(s.dryRuns, mapping, models.SyncOperationARI, payload)
+	}
+
+	started := time.Now()
+	err = adapter.PushARI(ctx, mapping, updates)
+	RecordSync(s.syncLogs, models.SyncLog{
+		ChannelID:  mapping.ChannelID,
+		PropertyID: mapping.PropertyID,
+		Direction:  models.SyncOutbound,
+		Operation:  models.SyncOperationARI,
+		Summary:    ariSummary(updates),
+		Items:      len(updates),
+	}, started, err)
+	if err != nil {
+		return err
+	}
+
+	// Record what the channel now shows so parity monitoring can compare it
+	pushedAt := time.Now()
+	rates := make([]models.ChannelRate, 0, len(updates))
+	for _, update := range updates {
+		rates = append(rates, models.ChannelRate{
+			ChannelID:  mapping.ChannelID,
+			PropertyID: mapping.PropertyID,
+			Date:       update.Date,
+			Rate:       update.Rate,
+			Currency:   update.Currency,
+			PushedAt:   pushedAt,
+		})
+	}
+	return s.parityRepo.RecordChannelRates(rates)
+}
+
+// BuildUpdates merges availability and pricing for a date range and applies the channel's
+// pricing rules. Prices are converted to the channel's currency first, so its fixed fee
+// is in that currency too. Every night of a property that is not active is closed.
+func (s *ARIPushService) BuildUpdates(channel models.Channel, propertyID uint, startDate, endDate time.Time) ([]ARIUpdate, error) {
+	start := startDate.Format("2006-01-02")
+	end := endDate.Format("2006-01-02")
+
+	status, err := s.propertyRepo.GetPropertyStatus(propertyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load property: %w", err)
+	}
+	listed := status == models.PropertyStatusActive
+
+	availabilities, err := s.availabilityRepo.GetAvailabilityForDateRange(propertyID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load availability: %w", err)
+	}
+	pricing, err := s.pricingRepo.GetPricingForDateRange(propertyID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pricing: %w", err)
+	}
+
+	updatesByDate := make(map[string]*ARIUpdate)
+	var order []string
+	updateFor := func(date time.Time) *ARIUpdate {
+		key := date.Format("2006-01-02")
+		if update, ok := updatesByDate[key]; ok {
+			return update
+		}
+		update := &ARIUpdate{Date: date, Currency: channel.Currency}
+		updatesByDate[key] = update
+		order = append(order, key)
+		return update
+	}
+
+	for _, a := range availabilities {
+		update := updateFor(a.Date)
+		update.Available = a.Available && listed
+		update.MinStay = a.MinStay
+		update.MaxGuests = a.MaxGuests
+	}
+	for _, p := range pricing {
+		price, err := s.convert(p.TotalPrice, channel.Currency)
+		if err != nil {
+			return nil, err
+		}
+		updateFor(p.Date).Rate = channel.ApplyPricingRules(price)
+	}
+
+	sort.Strings(order)
+	updates := make([]ARIUpdate, 0, len(order))
+	for _, key := range order {
+		updates = append(updates, *updatesByDate[key])
+	}
+	return updates, nil
+}
+
+// convert converts a base price to a channel's currency
+func (s *ARIPushService) convert(price float64, currency string) (float64, error) {
+	if currency == "" || currency == fx.BaseCurrency {
+		return price, nil
+	}
+	converted, err := s.exchangeRates.Convert(price, fx.BaseCurrency, currency)
+	if err != nil {
+		return 0, fmt.Errorf("failed to convert rates to %s: %w", currency, err)
+	}
+	return converted, nil
+}
@@ -0,0 +1,226 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// ContentCode is an amenity or condition expressed in a channel's code table
+type ContentCode struct {
+	RecordID uint   `json:"record_id"`
+	Name     string `json:"name"`
+	CodeList string `json:"code_list"`
+	Code     string `json:"code"`
+	Standard bool   `json:"standard"` // true when the OTA standard code is used
+}
+
+// ContentPhoto is a property photo as sent to a channel, in display order
+type ContentPhoto struct {
+	URL     string `json:"url"`
+	Caption string `json:"caption,omitempty"`
+}
+
+// PropertyContent holds the descriptive content of a property as sent to a channel
+type PropertyContent struct {
+	PropertyID  uint           `json:"property_id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	City        string         `json:"city"`
+	Country     string         `json:"country"`
+	Latitude    float64        `json:"latitude"`
+	Longitude   float64        `json:"longitude"`
+	MaxGuests   int            `json:"max_guests"`
+	Bedrooms    int            `json:"bedrooms"`
+	Bathrooms   int            `json:"bathrooms"`
+	Amenities   []ContentCode  `json:"amenities"`
+	Conditions  []ContentCode  `json:"conditions"`
+	Photos      []ContentPhoto `json:"photos"`
+	Unmapped    []string       `json:"unmapped,omitempty"` // amenities and conditions without a code, not sent
+
+	// Localized content; the fields above are in DefaultLocale
+	DefaultLocale string                       `json:"default_locale"`
+	HouseRules    string                       `json:"house_rules"`
+	Translations  []models.PropertyTranslation `json:"translations"`
+
+	CheckinTimes models.CheckinTimes `json:"checkin_times"`
+	Rules        models.HouseRules   `json:"rules"`
+}
+
+// ContentPushService pushes property content with channel amenity/condition codes
+type ContentPushService struct {
+	registry     *Registry
+	channelRepo  *database.ChannelRepository
+	propertyRepo *database.PropertyRepository
+	codeRepo     *database.ContentCodeRepository
+	translations *database.TranslationRepository
+	syncLogs     *database.SyncLogRepository
+	dryRuns      *database.DryRunRepository
+}
+
+// NewContentPushService creates a new content push service
+func NewContentPushService(db *gorm.DB, registry *Registry) *ContentPushService {
+	return &ContentPushService{
+		registry:     registry,
+		channelRepo:  database.NewChannelRepository(db),
+		propertyRepo: database.NewPropertyRepository(db),
+		codeRepo:     database.NewContentCodeRepository(db),
+		translations: database.NewTranslationRepository(db),
+		syncLogs:     database.NewSyncLogRepository(db),
+		dryRuns:      database.NewDryRunRepository(db),
+	}
+}
+
+// PushProperty pushes a property's content to all of its active channels
+func (s *ContentPushService) PushProperty(ctx context.Context, propertyID uint) error {
+	mappings, err := s.channelRepo.GetActiveMappingsForProperty(propertyID)
+	if err != nil {
+		return fmt.Errorf("failed to load channel mappings: %w", err)
+	}
+	if len(mappings) == 0 {
+		return nil
+	}
+
+	property, err := s.propertyRepo.GetPropertyByID(propertyID)
+	if err != nil {
+		return fmt.Errorf("failed to load property: %w", err)
+	}
+
+	var firstErr error
+	for _, mapping := range mappings {
+		if err := s.PushMapping(ctx, mapping, *property); err != nil {
+			log.Printf("Content push to %s for property %d failed: %v", mapping.ChannelID, propertyID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// PushMapping builds and sends a property's content for a single channel mapping;
+// channels in dry-run mode get the payload rendered and stored instead
+func (s *ContentPushService) PushMapping(ctx context.Context, mapping models.ChannelMapping, property models.Property) error {
+	content, err := s.BuildContent(mapping.ChannelID, property)
+	if err != nil {
+		return err
+	}
+
+	adapter := s.registry.Get(mapping.ChannelID)
+	if isDryRun(mapping) {
+		payload, err := renderContent(adapter, mapping, content)
+		if errors.Is(err, ErrUnsupported) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to render content payload: %w", err)
+		}
+		return recordDryRun(s.dryRuns, mapping, models.SyncOperationContent, payload)
+	}
+
+	started := time.Now()
+	err = adapter.PushContent(ctx, mapping, content)
+	if errors.Is(err, ErrUnsupported) {
+		// Content of the channel is managed on its own extranet
+		return nil
+	}
+	RecordSync(s.syncLogs, models.SyncLog{
+		ChannelID:  mapping.ChannelID,
+		PropertyID: mapping.PropertyID,
+		Direction:  models.SyncOutbound,
+		Operation:  models.SyncOperationContent,
+		Summary: fmt.Sprintf("%d amenities, %d conditions, %d photos",
+			len(content.Amenities), len(content.Conditions), len(content.Photos)),
+		Items: 1,
+	}, started, err)
+	return err
+}
+
+// BuildContent resolves a property's amenities and conditions to a channel's codes,
+// preferring the channel's own code over the OTA standard code
+func (s *ContentPushService) BuildContent(channelID string, property models.Property) (PropertyContent, error) {
+	mappings, err := s.codeRepo.GetMappingsForChannel(channelID)
+	if err != nil {
+		return PropertyContent{}, fmt.Errorf("failed to load content codes: %w", err)
+	}
+	translations, err := s.translations.GetTranslationsForProperty(property.ID)
+	if err != nil {
+		return PropertyContent{}, fmt.Errorf("failed to load translations: %w", err)
+	}
+
+	type recordKey struct {
+		recordType string
+		recordID   uint
+	}
+	codes := make(map[recordKey]models.ContentCodeMapping, len(mappings))
+	for _, mapping := range mappings {
+		key := recordKey{mapping.RecordType, mapping.RecordID}
+		if existing, ok := codes[key]; ok && !existing.IsStandard() {
+			continue
+		}
+		codes[key] = mapping
+	}
+
+	content := PropertyContent{
+		PropertyID:  property.ID,
+		Name:        property.Name,
+		Description: property.Description,
+		City:        property.City,
+		Country:     property.Country,
+		Latitude:    property.Latitude,
+		Longitude:   property.Longitude,
+		MaxGuests:   property.MaxGuests,
+		Bedrooms:    property.Bedrooms,
+		Bathrooms:   property.Bathrooms,
+		Amenities:   []ContentCode{},
+		Conditions:  []ContentCode{},
+		Photos:      make([]ContentPhoto, 0, len(property.Photos)),
+
+		DefaultLocale: property.DefaultLocale,
+		HouseRules:    property.HouseRules,
+		Translations:  translations,
+
+		CheckinTimes: property.CheckinTimes(),
+		Rules:        property.EffectiveRules(),
+	}
+
+	for _, photo := range property.Photos {
+		content.Photos = append(content.Photos, ContentPhoto{URL: photo.URL, Caption: photo.Caption})
+	}
+	for _, amenity := range property.Amenities {
+		mapping, ok := codes[recordKey{models.ContentRecordAmenity, amenity.ID}]
+		if !ok {
+			content.Unmapped = append(content.Unmapped, "amenity:"+amenity.Name)
+			continue
+		}
+		content.Amenities = append(content.Amenities, contentCode(mapping, amenity.Name))
+	}
+	for _, condition := range property.Conditions {
+		mapping, ok := codes[recordKey{models.ContentRecordCondition, condition.ID}]
+		if !ok {
+			content.Unmapped = append(content.Unmapped, "condition:"+condition.Name)
+			continue
+		}
+		content.Conditions = append(content.Conditions, contentCode(mapping, condition.Name))
+	}
+
+	return content, nil
+}
+
+// contentCode converts a code mapping into the code sent to the channel
+func contentCode(mapping models.ContentCodeMapping, name string) ContentCode {
+	return ContentCode{
+		RecordID: mapping.RecordID,
+		Name:     name,
+		CodeList: mapping.CodeList,
+		Code:     mapping.Code,
+		Standard: mapping.IsStandard(),
+	}
+}
@@ -0,0 +1,233 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// CredentialConfig holds channel credential configuration
+type CredentialConfig struct {
+	RotationGrace   time.Duration // how long a rotated-out credential stays accepted
+	RefreshMargin   time.Duration // OAuth tokens expiring within this margin are refreshed
+	RefreshInterval time.Duration // how often expiring tokens are refreshed in the background
+}
+
+var (
+	// ErrNoCredential is returned for a channel without stored credentials
+	ErrNoCredential = errors.New("channel has no credentials")
+	// ErrTokenRejected is returned when a channel's token endpoint does not issue an
+	// access token for new credentials
+	ErrTokenRejected = errors.New("token endpoint rejected the credentials")
+)
+
+// CredentialVault stores channel API credentials and keeps OAuth access tokens fresh.
+// Adapters ask the vault for a channel's credential on every request, so a rotation
+// takes effect without restarting, and may fall back to the previous credential while
+// the channel has not yet accepted the new one.
+type CredentialVault struct {
+	repo   *database.CredentialRepository
+	client *http.Client
+	cfg    CredentialConfig
+}
+
+// NewCredentialVault creates a new credential vault
+func NewCredentialVault(db *gorm.DB, cfg CredentialConfig) *CredentialVault {
+	return &CredentialVault{
+		repo:   database.NewCredentialRepository(db),
+		client: &http.Client{Timeout: 15 * time.Second},
+		cfg:    cfg,
+	}
+}
+
+// Get returns a channel's active credential, refreshing its OAuth access token first
+// when it is about to expire
+func (v *CredentialVault) Get(ctx context.Context, channelID string) (*models.ChannelCredential, error) {
+	credentials, err := v.Candidates(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	return &credentials[0], nil
+}
+
+// Candidates returns the credentials a channel accepts, the active one first followed
+// by the one it replaced during the rotation grace period, with fresh access tokens
+func (v *CredentialVault) Candidates(ctx context.Context, channelID string) ([]models.ChannelCredential, error) {
+	credentials, err := v.repo.GetCredentials(channelID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credentials: %w", err)
+	}
+	if len(credentials) == 0 {
+		return nil, ErrNoCredential
+	}
+
+	for i := range credentials {
+		if !credentials[i].TokenExpiring(time.Now(), v.cfg.RefreshMargin) {
+			continue
+		}
+		refreshed, err := v.refresh(ctx, credentials[i].ID)
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			// A previous credential that can no longer be refreshed is simply not offered
+			credentials = credentials[:i]
+			break
+		}
+		credentials[i] = *refreshed
+	}
+	return credentials, nil
+}
+
+// Do calls fn with a channel's active credential and, when the channel rejects it as
+// an authentication failure, with the credential it replaced, if still in its grace
+// period, so requests keep working while a rotation propagates on the channel side
+func (v *CredentialVault) Do(ctx context.Context, channelID string, fn func(credential models.ChannelCredential) error) error {
+	credentials, err := v.Candidates(ctx, channelID)
+	if errors.Is(err, ErrNoCredential) {
+		return &ChannelError{Code: models.SyncErrorAuthentication, Message: err.Error()}
+	}
+	if err != nil {
+		return err
+	}
+	for _, credential := range credentials {
+		err = fn(credential)
+		var channelErr *ChannelError
+		if !errors.As(err, &channelErr) || channelErr.Code != models.SyncErrorAuthentication {
+			return err
+		}
+	}
+	return err
+}
+
+// Rotate replaces a channel's active credential. OAuth credentials must obtain an access
+// token first, so a wrong secret is rejected before anything changes; the replaced
+// credential stays accepted for the rotation grace period.
+func (v *CredentialVault) Rotate(ctx context.Context, credential *models.ChannelCredential) error {
+	if credential.Type == models.CredentialOAuth2 {
+		if err := v.requestToken(ctx, credential); err != nil {
+			return fmt.Errorf("%w: %v", ErrTokenRejected, err)
+		}
+	}
+	return v.repo.RotateCredential(credential, time.Now().Add(v.cfg.RotationGrace))
+}
+
+// RefreshExpiring refreshes the OAuth tokens expiring within the refresh interval and
+// margin, and deletes the credentials past their rotation grace period. It returns the
+// number of tokens refreshed.
+func (v *CredentialVault) RefreshExpiring(ctx context.Context) (int, error) {
+	if _, err := v.repo.DeleteRetiredCredentials(time.Now()); err != nil {
+		return 0, fmt.Errorf("failed to delete retired credentials: %w", err)
+	}
+
+	expiring, err := v.repo.GetExpiringCredentials(time.Now().Add(v.cfg.RefreshInterval + v.cfg.RefreshMargin))
+	if err != nil {
+		return 0, err
+	}
+	refreshed := 0
+	var errs []error
+	for _, credential := range expiring {
+		if ctx.Err() != nil {
+			break
+		}
+		if _, err := v.refresh(ctx, credential.ID); err != nil {
+			errs = append(errs, fmt.Errorf("channel %s credential v%d: %w", credential.ChannelID, credential.Version, err))
+			continue
+		}
+		refreshed++
+	}
+	return refreshed, errors.Join(errs...)
+}
+
+// refresh obtains a new access token for a credential unless another request or
+// replica refreshed it while this one waited for the lock
+func (v *CredentialVault) refresh(ctx context.Context, id uint) (*models.ChannelCredential, error) {
+	return v.repo.RefreshCredential(id, func(credential *models.ChannelCredential) error {
+		if !credential.TokenExpiring(time.Now(), v.cfg.RefreshMargin) {
+			return nil
+		}
+		if err := v.requestToken(ctx, credential); err != nil {
+			credential.RefreshError = err.Error()
+			return err
+		}
+		return nil
+	})
+}
+
+// tokenResponse is an OAuth 2.0 token endpoint response
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// requestToken obtains an access token from the credential's token endpoint with its
+// refresh token, or with the client credentials grant when it has none
+func (v *CredentialVault) requestToken(ctx context.Context, credential *models.ChannelCredential) error {
+	form := url.Values{}
+	if credential.RefreshToken != "" {
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", credential.RefreshToken)
+	} else {
+		form.Set("grant_type", "client_credentials")
+	}
+	if credential.Scope != "" {
+		form.Set("scope", credential.Scope)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, credential.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("invalid token URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(credential.ClientID), url.QueryEscape(credential.ClientSecret))
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var token tokenResponse
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read token response: %w", err)
+	}
+	if err := json.Unmarshal(body, &token); err != nil && resp.StatusCode == http.StatusOK {
+		return fmt.Errorf("invalid token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || token.AccessToken == "" {
+		if token.Error != "" {
+			return fmt.Errorf("token endpoint returned %d: %s %s", resp.StatusCode, token.Error, token.ErrorDescription)
+		}
+		return fmt.Errorf("token endpoint returned %d without an access token", resp.StatusCode)
+	}
+
+	now := time.Now()
+	credential.AccessToken = token.AccessToken
+	if token.RefreshToken != "" {
+		credential.RefreshToken = token.RefreshToken
+	}
+	credential.TokenExpiresAt = nil
+	if token.ExpiresIn > 0 {
+		expiresAt := now.Add(time.Duration(token.ExpiresIn) * time.Second)
+		credential.TokenExpiresAt = &expiresAt
+	}
+	credential.LastRefreshedAt = &now
+	credential.RefreshError = ""
+	return nil
+}
@@ -0,0 +1,87 @@
+package channels
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"channelmanager/database"
+	"channelmanager/models"
+)
+
+// PayloadRenderer is implemented by adapters that can render the exact request body
+// they would send, so dry runs show what the OTA would receive. Payloads of adapters
+// without it are rendered as the channel-neutral JSON.
+type PayloadRenderer interface {
+	RenderARI(mapping models.ChannelMapping, updates []ARIUpdate) ([]byte, error)
+	RenderContent(mapping models.ChannelMapping, content PropertyContent) ([]byte, error)
+}
+
+// isDryRun reports whether pushes for the mapping must be rendered instead of sent
+func isDryRun(mapping models.ChannelMapping) bool {
+	return mapping.Channel != nil && mapping.Channel.DryRun
+}
+
+// recordDryRun logs and stores a payload rendered instead of being sent to the channel
+func recordDryRun(repo *database.DryRunRepository, mapping models.ChannelMapping, operation string, payload []byte) error {
+	if !json.Valid(payload) {
+		// Non-JSON payloads (e.g. XML) are stored as a JSON string
+		quoted, err := json.Marshal(string(payload))
+		if err != nil {
+			return err
+		}
+		payload = quoted
+	}
+
+	log.Printf("Dry run: %s payload for channel %s, property %d: %s",
+		operation, mapping.ChannelID, mapping.PropertyID, payload)
+
+	entry := models.DryRunPayload{
+		ChannelID:  mapping.ChannelID,
+		PropertyID: mapping.PropertyID,
+		Operation:  operation,
+		Payload:    payload,
+	}
+	if err := repo.CreatePayload(&entry); err != nil {
+		return fmt.Errorf("failed to store dry-run payload: %w", err)
+	}
+	return nil
+}
+
+// neutralPayload is the channel-neutral payload rendered for adapters that are not a PayloadRenderer
+type neutralPayload struct {
+	ExternalPropertyID string           `json:"external_property_id"`
+	ExternalRoomID     string           `json:"external_room_id,omitempty"`
+	ExternalRatePlanID string           `json:"external_rate_plan_id,omitempty"`
+	Updates            []ARIUpdate      `json:"updates,omitempty"`
+	Content            *PropertyContent `json:"content,omitempty"`
+}
+
+// neutral creates the channel-neutral payload of a mapping
+func neutral(mapping models.ChannelMapping) neutralPayload {
+	return neutralPayload{
+		ExternalPropertyID: mapping.ExternalPropertyID,
+		ExternalRoomID:     mapping.ExternalRoomID,
+		ExternalRatePlanID: mapping.ExternalRatePlanID,
+	}
+}
+
+// renderARI renders the ARI payload an adapter would send for a mapping
+func renderARI(adapter ChannelAdapter, mapping models.ChannelMapping, updates []ARIUpdate) ([]byte, error) {
+	if renderer, ok := adapter.(PayloadRenderer); ok {
+		return renderer.RenderARI(mapping, updates)
+	}
+	payload := neutral(mapping)
+	payload.Updates = updates
+	return json.Marshal(payload)
+}
+
+// renderContent renders the content payload an adapter would send for a mapping
+func renderContent(adapter ChannelAdapter, mapping models.ChannelMapping, content PropertyContent) ([]byte, error) {
+	if renderer, ok := adapter.(PayloadRenderer); ok {
+		return renderer.RenderContent(mapping, content)
+	}
+	payload := neutral(mapping)
+	payload.Content = &content
+	return json.Marshal(payload)
+}
@@ -0,0 +1,451 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"channelmanager/models"
+)
+
+// ExpediaConfig holds the Expedia QuickConnect (EQC) integration configuration
+type ExpediaConfig struct {
+	Enabled   bool
+	ChannelID string // code of the Expedia channel
+	BaseURL   string // EQC services root; the AR, BR and BC APIs are below it
+}
+
+// ExpediaAdapter pushes availability and rates to Expedia with the EQC AR API and
+// retrieves reservations with the BR and BC APIs. Credentials are the EQC username and
+// password stored as a basic credential of the channel. Content is managed on Expedia
+// Partner Central and not pushed.
+//
+// Mappings name the Expedia hotel as the external property, the room type as the
+// external room, and one or more rate plans as a comma-separated external rate plan,
+// e.g. "2000123A,2000123B" to update a standalone and a package rate plan together.
+type ExpediaAdapter struct {
+	cfg         ExpediaConfig
+	credentials *CredentialVault
+	client      *http.Client
+}
+
+// NewExpediaAdapter creates an Expedia adapter
+func NewExpediaAdapter(cfg ExpediaConfig, credentials *CredentialVault) *ExpediaAdapter {
+	return &ExpediaAdapter{
+		cfg:         cfg,
+		credentials: credentials,
+		client:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ChannelID returns the code of the Expedia channel
+func (a *ExpediaAdapter) ChannelID() string {
+	return a.cfg.ChannelID
+}
+
+// EQC XML namespaces
+const (
+	eqcARNamespace = "http://www.expediaconnect.com/EQC/AR/2011/06"
+	eqcBRNamespace = "http://www.expediaconnect.com/EQC/BR/2014/01"
+	eqcBCNamespace = "http://www.expediaconnect.com/EQC/BC/2007/09"
+)
+
+type eqcAuthentication struct {
+	Username string `xml:"username,attr"`
+	Password string `xml:"password,attr"`
+}
+
+type eqcHotel struct {
+	ID string `xml:"id,attr"`
+}
+
+// eqcError is an error element of any EQC response
+type eqcError struct {
+	Code    string `xml:"code,attr"`
+	Message string `xml:",chardata"`
+}
+
+type eqcAvailRateUpdateRQ struct {
+	XMLName         xml.Name             `xml:"AvailRateUpdateRQ"`
+	Namespace       string               `xml:"xmlns,attr"`
+	Authentication  *eqcAuthentication   `xml:"Authentication,omitempty"`
+	Hotel           eqcHotel             `xml:"Hotel"`
+	AvailRateUpdate []eqcAvailRateUpdate `xml:"AvailRateUpdate"`
+}
+
+type eqcAvailRateUpdate struct {
+	DateRange struct {
+		From string `xml:"from,attr"`
+		To   string `xml:"to,attr"`
+	} `xml:"DateRange"`
+	RoomType eqcRoomType `xml:"RoomType"`
+}
+
+type eqcRoomType struct {
+	ID        string `xml:"id,attr"`
+	Closed    bool   `xml:"closed,attr"`
+	Inventory struct {
+		TotalInventoryAvailable int `xml:"totalInventoryAvailable,attr"`
+	} `xml:"Inventory"`
+	RatePlans []eqcRatePlan `xml:"RatePlan"`
+}
+
+type eqcRatePlan struct {
+	ID           string           `xml:"id,attr"`
+	Closed       bool             `xml:"closed,attr"`
+	Rate         *eqcRate         `xml:"Rate,omitempty"`
+	Restrictions *eqcRestrictions `xml:"Restrictions,omitempty"`
+}
+
+type eqcRate struct {
+	Currency string `xml:"currency,attr"`
+	PerDay   struct {
+		Rate string `xml:"rate,attr"`
+	} `xml:"PerDay"`
+}
+
+type eqcRestrictions struct {
+	MinLOS int `xml:"minLOS,attr"`
+}
+
+type eqcAvailRateUpdateRS struct {
+	Errors []eqcError `xml:"Error"`
+}
+
+// expediaRatePlans returns the Expedia room type and rate plan IDs of a mapping
+func expediaRatePlans(mapping models.ChannelMapping) (string, []string, error) {
+	invalid := func(message string) error {
+		return &ChannelError{Code: models.SyncErrorMapping, Message: message}
+	}
+	if mapping.ExternalPropertyID == "" || mapping.ExternalRoomID == "" {
+		return "", nil, invalid("Expedia mappings need the hotel as external property and the room type as external room")
+	}
+	var ratePlans []string
+	for _, id := range strings.Split(mapping.ExternalRatePlanID, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ratePlans = append(ratePlans, id)
+		}
+	}
+	if len(ratePlans) == 0 {
+		return "", nil, invalid("Expedia mappings need at least one rate plan as external rate plan")
+	}
+	return mapping.ExternalRoomID, ratePlans, nil
+}
+
+// buildARI builds the AR request of a mapping, one update per run of consecutive
+// nights with the same availability, rate and minimum stay. Nights without a rate close
+// the rate plans rather than sell at the rate Expedia last had.
+func (a *ExpediaAdapter) buildARI(mapping models.ChannelMapping, updates []ARIUpdate) (*eqcAvailRateUpdateRQ, error) {
+	roomType, ratePlans, err := expediaRatePlans(mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &eqcAvailRateUpdateRQ{Namespace: eqcARNamespace, Hotel: eqcHotel{ID: mapping.ExternalPropertyID}}
+	sameAs := func(u, v ARIUpdate) bool {
+		return u.Available == v.Available && u.Rate == v.Rate && u.Currency == v.Currency && u.MinStay == v.MinStay &&
+			v.Date.Sub(u.Date) == 24*time.Hour
+	}
+	for start := 0; start < len(updates); {
+		end := start
+		for end+1 < len(updates) && sameAs(updates[end], updates[end+1]) {
+			end++
+		}
+		night := updates[start]
+
+		update := eqcAvailRateUpdate{RoomType: eqcRoomType{ID: roomType, Closed: !night.Available}}
+		update.DateRange.From = night.Date.Format("2006-01-02")
+		update.DateRange.To = updates[end].Date.Format("2006-01-02")
+		if night.Available {
+			update.RoomType.Inventory.TotalInventoryAvailable = 1
+		}
+		for _, id := range ratePlans {
+			ratePlan := eqcRatePlan{ID: id, Closed: !night.Available || night.Rate <= 0}
+			if night.Rate > 0 {
+				ratePlan.Rate = &eqcRate{Currency: night.Currency}
+				ratePlan.Rate.PerDay.Rate = strconv.FormatFloat(night.Rate, 'f', 2, 64)
+			}
+			if night.MinStay > 0 {
+				ratePlan.Restrictions = &eqcRestrictions{MinLOS: night.MinStay}
+			}
+			update.RoomType.RatePlans = append(update.RoomType.RatePlans, ratePlan)
+		}
+		request.AvailRateUpdate = append(request.AvailRateUpdate, update)
+		start = end + 1
+	}
+	return request, nil
+}
+
+// PushARI sends availability, rates and minimum stays with the AR API
+func (a *ExpediaAdapter) PushARI(ctx context.Context, mapping models.ChannelMapping, updates []ARIUpdate) error {
+	request, err := a.buildARI(mapping, updates)
+	if err != nil {
+		return err
+	}
+	return a.credentials.Do(ctx, a.cfg.ChannelID, func(credential models.ChannelCredential) error {
+		request.Authentication = &eqcAuthentication{Username: credential.Username, Password: credential.Secret}
+		var response eqcAvailRateUpdateRS
+		if err := a.post(ctx, "/ar", request, &response); err != nil {
+			return err
+		}
+		return expediaError(response.Errors)
+	})
+}
+
+// PushContent is not offered by EQC
+func (a *ExpediaAdapter) PushContent(ctx context.Context, mapping models.ChannelMapping, content PropertyContent) error {
+	return ErrUnsupported
+}
+
+// RenderARI renders the AR request without the credentials
+func (a *ExpediaAdapter) RenderARI(mapping models.ChannelMapping, updates []ARIUpdate) ([]byte, error) {
+	request, err := a.buildARI(mapping, updates)
+	if err != nil {
+		return nil, err
+	}
+	return xml.MarshalIndent(request, "", "  ")
+}
+
+// RenderContent is not offered by EQC
+func (a *ExpediaAdapter) RenderContent(mapping models.ChannelMapping, content PropertyContent) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+type eqcBookingRetrievalRQ struct {
+	XMLName        xml.Name           `xml:"BookingRetrievalRQ"`
+	Namespace      string             `xml:"xmlns,attr"`
+	Authentication *eqcAuthentication `xml:"Authentication"`
+	ParamSet       struct {
+		Status struct {
+			Value string `xml:"value,attr"`
+		} `xml:"Status"`
+	} `xml:"ParamSet"`
+}
+
+type eqcBookingRetrievalRS struct {
+	Errors   []eqcError   `xml:"Error"`
+	Bookings []eqcBooking `xml:"Bookings>Booking"`
+}
+
+type eqcBooking struct {
+	ID       string   `xml:"id,attr"`
+	Type     string   `xml:"type,attr"` // Book, Modify or Cancel
+	Hotel    eqcHotel `xml:"Hotel"`
+	RoomStay struct {
+		RoomTypeID string `xml:"roomTypeID,attr"`
+		RatePlanID string `xml:"ratePlanID,attr"`
+		StayDate   struct {
+			Arrival   string `xml:"arrival,attr"`
+			Departure string `xml:"departure,attr"`
+		} `xml:"StayDate"`
+		GuestCount struct {
+			Adult int `xml:"adult,attr"`
+			Child int `xml:"child,attr"`
+		} `xml:"GuestCount"`
+		Total struct {
+			AmountAfterTaxes float64 `xml:"amountAfterTaxes,attr"`
+			Currency         string  `xml:"currency,attr"`
+		} `xml:"Total"`
+	} `xml:"RoomStay"`
+	PrimaryGuest struct {
+		Name struct {
+			GivenName string `xml:"givenName,attr"`
+			Surname   string `xml:"surname,attr"`
+		} `xml:"Name"`
+		Phone struct {
+			CountryCode  string `xml:"countryCode,attr"`
+			CityAreaCode string `xml:"cityAreaCode,attr"`
+			Number       string `xml:"number,attr"`
+		} `xml:"Phone"`
+		Email string `xml:"Email"`
+	} `xml:"PrimaryGuest"`
+}
+
+// expediaBookingTypes maps EQC booking types to reservation types and back
+var expediaBookingTypes = map[string]string{
+	"Book":   ReservationNew,
+	"Modify": ReservationModified,
+	"Cancel": ReservationCancelled,
+}
+
+// RetrieveBookings returns the pending reservations of every hotel of the EQC account
+func (a *ExpediaAdapter) RetrieveBookings(ctx context.Context) ([]ChannelReservation, error) {
+	var response eqcBookingRetrievalRS
+	err := a.credentials.Do(ctx, a.cfg.ChannelID, func(credential models.ChannelCredential) error {
+		request := eqcBookingRetrievalRQ{
+			Namespace:      eqcBRNamespace,
+			Authentication: &eqcAuthentication{Username: credential.Username, Password: credential.Secret},
+		}
+		request.ParamSet.Status.Value = "pending"
+		response = eqcBookingRetrievalRS{}
+		if err := a.post(ctx, "/br", request, &response); err != nil {
+			return err
+		}
+		return expediaError(response.Errors)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reservations := make([]ChannelReservation, 0, len(response.Bookings))
+	for _, booking := range response.Bookings {
+		checkin, err := time.Parse("2006-01-02", booking.RoomStay.StayDate.Arrival)
+		if err != nil {
+			return nil, fmt.Errorf("booking %s has an invalid arrival date: %w", booking.ID, err)
+		}
+		checkout, err := time.Parse("2006-01-02", booking.RoomStay.StayDate.Departure)
+		if err != nil {
+			return nil, fmt.Errorf("booking %s has an invalid departure date: %w", booking.ID, err)
+		}
+		guest := booking.PrimaryGuest
+		reservations = append(reservations, ChannelReservation{
+			Type:               expediaBookingTypes[booking.Type],
+			ExternalReference:  booking.ID,
+			ExternalPropertyID: booking.Hotel.ID,
+			ExternalRoomID:     booking.RoomStay.RoomTypeID,
+			ExternalRatePlanID: booking.RoomStay.RatePlanID,
+			GuestName:          strings.TrimSpace(guest.Name.GivenName + " " + guest.Name.Surname),
+			GuestEmail:         strings.TrimSpace(guest.Email),
+			GuestPhone:         strings.TrimSpace(strings.Join([]string{guest.Phone.CountryCode, guest.Phone.CityAreaCode, guest.Phone.Number}, " ")),
+			CheckinDate:        checkin,
+			CheckoutDate:       checkout,
+			Adults:             booking.RoomStay.GuestCount.Adult,
+			Children:           booking.RoomStay.GuestCount.Child,
+			TotalPrice:         booking.RoomStay.Total.AmountAfterTaxes,
+			Currency:           booking.RoomStay.Total.Currency,
+		})
+	}
+	return reservations, nil
+}
+
+type eqcBookingConfirmRQ struct {
+	XMLName        xml.Name                 `xml:"BookingConfirmRQ"`
+	Namespace      string                   `xml:"xmlns,attr"`
+	Authentication *eqcAuthentication       `xml:"Authentication"`
+	Hotel          eqcHotel                 `xml:"Hotel"`
+	Numbers        []eqcBookingConfirmation `xml:"BookingConfirmNumbers>BookingConfirmNumber"`
+}
+
+type eqcBookingConfirmation struct {
+	BookingID     string `xml:"bookingID,attr"`
+	BookingType   string `xml:"bookingType,attr"`
+	ConfirmNumber string `xml:"confirmNumber,attr"`
+	ConfirmTime   string `xml:"confirmTime,attr"`
+}
+
+type eqcBookingConfirmRS struct {
+	Errors []eqcError `xml:"Error"`
+}
+
+// ConfirmBookings sends the booking IDs of stored reservations with the BC API, one
+// request per hotel
+func (a *ExpediaAdapter) ConfirmBookings(ctx context.Context, confirmations []Confirmation) error {
+	byHotel := make(map[string][]eqcBookingConfirmation)
+	var hotels []string
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, confirmation := range confirmations {
+		hotel := confirmation.Reservation.ExternalPropertyID
+		if _, ok := byHotel[hotel]; !ok {
+			hotels = append(hotels, hotel)
+		}
+		bookingType := "Book"
+		for eqcType, reservationType := range expediaBookingTypes {
+			if reservationType == confirmation.Reservation.Type {
+				bookingType = eqcType
+			}
+		}
+		byHotel[hotel] = append(byHotel[hotel], eqcBookingConfirmation{
+			BookingID:     confirmation.Reservation.ExternalReference,
+			BookingType:   bookingType,
+			ConfirmNumber: confirmationNumber(confirmation.BookingID),
+			ConfirmTime:   now,
+		})
+	}
+
+	for _, hotel := range hotels {
+		err := a.credentials.Do(ctx, a.cfg.ChannelID, func(credential models.ChannelCredential) error {
+			request := eqcBookingConfirmRQ{
+				Namespace:      eqcBCNamespace,
+				Authentication: &eqcAuthentication{Username: credential.Username, Password: credential.Secret},
+				Hotel:          eqcHotel{ID: hotel},
+				Numbers:        byHotel[hotel],
+			}
+			var response eqcBookingConfirmRS
+			if err := a.post(ctx, "/bc", request, &response); err != nil {
+				return err
+			}
+			return expediaError(response.Errors)
+		})
+		if err != nil {
+			return fmt.Errorf("hotel %s: %w", hotel, err)
+		}
+	}
+	return nil
+}
+
+// post sends an EQC request and decodes its response
+func (a *ExpediaAdapter) post(ctx context.Context, path string, request, response interface{}) error {
+	body, err := xml.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(a.cfg.BaseURL, "/")+path,
+		bytes.NewReader(append([]byte(xml.Header), body...)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return &ChannelError{Code: models.SyncErrorUnavailable, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return &ChannelError{Code: models.SyncErrorUnavailable, Message: err.Error()}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return httpStatusError(resp.StatusCode, data)
+	}
+	if err := xml.Unmarshal(data, response); err != nil {
+		return &ChannelError{Code: models.SyncErrorUnavailable, Message: "invalid response: " + err.Error()}
+	}
+	return nil
+}
+
+// expediaError translates the first error of an EQC response. EQC groups its codes by
+// range: 1xxx authentication and authorization, 2xxx malformed requests, 3xxx unknown or
+// inactive hotels, room types and rate plans, 4xxx business rule violations such as
+// rates outside the allowed range, and 5xxx or above internal errors worth retrying.
+func expediaError(errs []eqcError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	first := errs[0]
+	code := models.SyncErrorUnavailable
+	if n, err := strconv.Atoi(first.Code); err == nil {
+		switch {
+		case n < 2000:
+			code = models.SyncErrorAuthentication
+		case n < 3000:
+			code = models.SyncErrorValidation
+		case n < 4000:
+			code = models.SyncErrorMapping
+		case n < 5000:
+			code = models.SyncErrorRejected
+		}
+	}
+	message := strings.TrimSpace(first.Message)
+	if len(errs) > 1 {
+		message += fmt.Sprintf(" (and %d more errors)", len(errs)-1)
+	}
+	return &ChannelError{Code: code, ChannelCode: first.Code, Message: message}
+}
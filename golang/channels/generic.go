@@ -0,0 +1,592 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// GenericSpec declares how the generic adapter calls a channel's REST API: the
+// endpoints, the JSON bodies fields are mapped into and how failures are reported.
+// Templates refer to fields as {{name}}; a placeholder that is a whole JSON string is
+// replaced by the field's value with its type, e.g. a number or an array.
+type GenericSpec struct {
+	BaseURL string `json:"base_url"`
+	// APIKeyHeader carries api_key credentials, e.g. X-Api-Key; by default they are
+	// sent as a bearer token like OAuth access tokens
+	APIKeyHeader string            `json:"api_key_header,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"` // sent with every request
+	ARI          *GenericEndpoint  `json:"ari"`
+	Content      *GenericEndpoint  `json:"content,omitempty"` // content is managed on the channel when absent
+	NoShow       *GenericEndpoint  `json:"no_show,omitempty"` // no-shows are not reported when absent
+	Errors       GenericErrors     `json:"errors"`
+}
+
+// GenericEndpoint is a request the generic adapter makes
+type GenericEndpoint struct {
+	Method string          `json:"method"` // PUT, POST or PATCH
+	Path   string          `json:"path"`   // appended to the base URL; placeholders are path-escaped
+	Body   json.RawMessage `json:"body"`
+	// ARI only: with PerNight a request is made for every night; otherwise {{nights}} in
+	// the body is the list of nights, each rendered from Item
+	PerNight bool            `json:"per_night,omitempty"`
+	Item     json.RawMessage `json:"item,omitempty"`
+}
+
+// GenericErrors locates the channel's error code and message in failure bodies and
+// translates the codes; failures without a known code are translated by HTTP status
+type GenericErrors struct {
+	CodeField    string            `json:"code_field,omitempty"`    // dotted path, e.g. error.code
+	MessageField string            `json:"message_field,omitempty"` // dotted path, e.g. error.message
+	Codes        map[string]string `json:"codes,omitempty"`         // channel code to a SyncError* value
+}
+
+// ParseGenericSpec decodes a generic adapter definition and checks that it can render
+// every request
+func ParseGenericSpec(data []byte) (*GenericSpec, error) {
+	var spec GenericSpec
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("invalid adapter definition: %w", err)
+	}
+
+	if u, err := url.Parse(spec.BaseURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, errors.New("base_url must be an http or https URL")
+	}
+	if spec.ARI == nil {
+		return nil, errors.New("ari endpoint is required")
+	}
+	endpoints := map[string]*GenericEndpoint{"ari": spec.ARI, "content": spec.Content, "no_show": spec.NoShow}
+	for name, endpoint := range endpoints {
+		if endpoint == nil {
+			continue
+		}
+		switch endpoint.Method {
+		case http.MethodPut, http.MethodPost, http.MethodPatch:
+		default:
+			return nil, fmt.Errorf("%s.method must be PUT, POST or PATCH", name)
+		}
+		if !strings.HasPrefix(endpoint.Path, "/") {
+			return nil, fmt.Errorf("%s.path must start with /", name)
+		}
+		if len(endpoint.Body) == 0 {
+			return nil, fmt.Errorf("%s.body is required", name)
+		}
+	}
+	if spec.ARI.PerNight && len(spec.ARI.Item) > 0 {
+		return nil, errors.New("ari.item does not apply to per_night requests")
+	}
+	if spec.Content != nil && (spec.Content.PerNight || len(spec.Content.Item) > 0) {
+		return nil, errors.New("content.per_night and content.item only apply to ari")
+	}
+	if spec.NoShow != nil && (spec.NoShow.PerNight || len(spec.NoShow.Item) > 0) {
+		return nil, errors.New("no_show.per_night and no_show.item only apply to ari")
+	}
+	for code, syncCode := range spec.Errors.Codes {
+		if !validSyncErrorCode(syncCode) {
+			return nil, fmt.Errorf("errors.codes.%s must be one of authentication, mapping, validation, rejected, rate_limited or unavailable", code)
+		}
+	}
+
+	// Render sample requests so unknown placeholders and malformed templates are
+	// reported now rather than on the first push
+	sample := models.ChannelMapping{ChannelID: "sample", PropertyID: 1, ExternalPropertyID: "P1", ExternalRoomID: "R1", ExternalRatePlanID: "RP1"}
+	update := ARIUpdate{Date: time.Now(), Available: true, MinStay: 1, MaxGuests: 2, Rate: 100, Currency: "USD"}
+	if _, err := spec.ariRequests(sample, []ARIUpdate{update}); err != nil {
+		return nil, fmt.Errorf("ari: %w", err)
+	}
+	if spec.Content != nil {
+		if _, err := spec.contentRequest(sample, PropertyContent{}); err != nil {
+			return nil, fmt.Errorf("content: %w", err)
+		}
+	}
+	if spec.NoShow != nil {
+		booking := models.Booking{ID: 1, PropertyID: 1, ChannelID: "sample", ExternalReference: "R1",
+			CheckinDate: update.Date, CheckoutDate: update.Date.AddDate(0, 0, 1), Currency: "USD"}
+		if _, err := spec.NoShow.render(bookingVars(booking)); err != nil {
+			return nil, fmt.Errorf("no_show: %w", err)
+		}
+	}
+	return &spec, nil
+}
+
+// validSyncErrorCode reports whether code is a SyncError* value
+func validSyncErrorCode(code string) bool {
+	switch code {
+	case models.SyncErrorAuthentication, models.SyncErrorMapping, models.SyncErrorValidation,
+		models.SyncErrorRejected, models.SyncErrorRateLimited, models.SyncErrorUnavailable:
+		return true
+	}
+	return false
+}
+
+// genericRequest is a rendered request of the generic adapter
+type genericRequest struct {
+	Method string
+	Path   string
+	Body   json.RawMessage
+}
+
+// ariRequests renders the requests of an ARI push, one or one per night
+func (s *GenericSpec) ariRequests(mapping models.ChannelMapping, updates []ARIUpdate) ([]genericRequest, error) {
+	if s.ARI.PerNight {
+		requests := make([]genericRequest, 0, len(updates))
+		for _, update := range updates {
+			vars := mappingVars(mapping)
+			vars.add(nightVars(update))
+			request, err := s.ARI.render(vars)
+			if err != nil {
+				return nil, err
+			}
+			requests = append(requests, *request)
+		}
+		return requests, nil
+	}
+
+	nights := make([]interface{}, 0, len(updates))
+	for _, update := range updates {
+		if len(s.ARI.Item) == 0 {
+			nights = append(nights, update)
+			continue
+		}
+		vars := mappingVars(mapping)
+		vars.add(nightVars(update))
+		night, err := vars.render(s.ARI.Item)
+		if err != nil {
+			return nil, fmt.Errorf("item: %w", err)
+		}
+		nights = append(nights, night)
+	}
+
+	vars := mappingVars(mapping)
+	vars["nights"] = nights
+	vars["currency"], vars["start_date"], vars["end_date"] = "", "", ""
+	if len(updates) > 0 {
+		vars["currency"] = updates[0].Currency
+		vars["start_date"] = updates[0].Date.Format("2006-01-02")
+		vars["end_date"] = updates[len(updates)-1].Date.Format("2006-01-02")
+	}
+	request, err := s.ARI.render(vars)
+	if err != nil {
+		return nil, err
+	}
+	return []genericRequest{*request}, nil
+}
+
+// contentRequest renders the request of a content push
+func (s *GenericSpec) contentRequest(mapping models.ChannelMapping, content PropertyContent) (*genericRequest, error) {
+	amenities := make([]string, 0, len(content.Amenities))
+	for _, code := range content.Amenities {
+		amenities = append(amenities, code.Code)
+	}
+	conditions := make([]string, 0, len(content.Conditions))
+	for _, code := range content.Conditions {
+		conditions = append(conditions, code.Code)
+	}
+	photos := make([]string, 0, len(content.Photos))
+	for _, photo := range content.Photos {
+		photos = append(photos, photo.URL)
+	}
+
+	vars := mappingVars(mapping)
+	vars.add(templateVars{
+		"name":           content.Name,
+		"description":    content.Description,
+		"locale":         content.DefaultLocale,
+		"city":           content.City,
+		"country":        content.Country,
+		"latitude":       content.Latitude,
+		"longitude":      content.Longitude,
+		"max_guests":     content.MaxGuests,
+		"bedrooms":       content.Bedrooms,
+		"bathrooms":      content.Bathrooms,
+		"amenities":      amenities,
+		"conditions":     conditions,
+		"photos":         photos,
+		"house_rules":    content.HouseRules,
+		"checkin_from":   content.CheckinTimes.CheckinFrom,
+		"checkin_until":  content.CheckinTimes.CheckinUntil,
+		"checkout_until": content.CheckinTimes.CheckoutUntil,
+	})
+	return s.Content.render(vars)
+}
+
+// render fills an endpoint's path and body
+func (e *GenericEndpoint) render(vars templateVars) (*genericRequest, error) {
+	path, err := vars.interpolate(e.Path, url.PathEscape)
+	if err != nil {
+		return nil, fmt.Errorf("path: %w", err)
+	}
+	body, err := vars.render(e.Body)
+	if err != nil {
+		return nil, fmt.Errorf("body: %w", err)
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return &genericRequest{Method: e.Method, Path: path, Body: data}, nil
+}
+
+// channelError translates a failed request with the spec's error codes
+func (s *GenericSpec) channelError(status int, body []byte) *ChannelError {
+	channelErr := httpStatusError(status, body)
+	if s.Errors.CodeField == "" {
+		return channelErr
+	}
+	var tree interface{}
+	if json.Unmarshal(body, &tree) != nil {
+		return channelErr
+	}
+	code := lookupField(tree, s.Errors.CodeField)
+	if code == "" {
+		return channelErr
+	}
+	channelErr.ChannelCode = code
+	if message := lookupField(tree, s.Errors.MessageField); message != "" {
+		channelErr.Message = message
+	}
+	if syncCode, ok := s.Errors.Codes[code]; ok {
+		channelErr.Code = syncCode
+	}
+	return channelErr
+}
+
+// lookupField returns the scalar at a dotted path of a decoded JSON document as a
+// string, or "" when there is none
+func lookupField(tree interface{}, path string) string {
+	if path == "" {
+		return ""
+	}
+	for _, key := range strings.Split(path, ".") {
+		object, ok := tree.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		tree = object[key]
+	}
+	switch value := tree.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return ""
+}
+
+// placeholder matches a {{name}} field reference in a template
+var placeholder = regexp.MustCompile(`\{\{\s*([a-z_]+)\s*\}\}`)
+
+// templateVars are the field values a template is filled with
+type templateVars map[string]interface{}
+
+// mappingVars returns the fields of a channel mapping
+func mappingVars(mapping models.ChannelMapping) templateVars {
+	return templateVars{
+		"channel_id":            mapping.ChannelID,
+		"property_id":           mapping.PropertyID,
+		"external_property_id":  mapping.ExternalPropertyID,
+		"external_room_id":      mapping.ExternalRoomID,
+		"external_rate_plan_id": mapping.ExternalRatePlanID,
+	}
+}
+
+// bookingVars returns the fields of a booking
+func bookingVars(booking models.Booking) templateVars {
+	return templateVars{
+		"channel_id":         booking.ChannelID,
+		"booking_id":         booking.ID,
+		"property_id":        booking.PropertyID,
+		"external_reference": booking.ExternalReference,
+		"checkin_date":       booking.CheckinDate.Format("2006-01-02"),
+		"checkout_date":      booking.CheckoutDate.Format("2006-01-02"),
+		"total_price":        booking.TotalPrice,
+		"currency":           booking.Currency,
+	}
+}
+
+// nightVars returns the fields of a night's ARI update
+func nightVars(update ARIUpdate) templateVars {
+	return templateVars{
+		"date":       update.Date.Format("2006-01-02"),
+		"available":  update.Available,
+		"min_stay":   update.MinStay,
+		"max_guests": update.MaxGuests,
+		"rate":       update.Rate,
+		"currency":   update.Currency,
+	}
+}
+
+// add copies fields into vars
+func (v templateVars) add(fields templateVars) {
+	for name, value := range fields {
+		v[name] = value
+	}
+}
+
+// render decodes a JSON template and fills its placeholders
+func (v templateVars) render(template json.RawMessage) (interface{}, error) {
+	var tree interface{}
+	decoder := json.NewDecoder(bytes.NewReader(template))
+	decoder.UseNumber()
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return v.fill(tree)
+}
+
+// fill replaces the placeholders of a decoded template
+func (v templateVars) fill(node interface{}) (interface{}, error) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(n))
+		for key, child := range n {
+			filled, err := v.fill(child)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = filled
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(n))
+		for i, child := range n {
+			filled, err := v.fill(child)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = filled
+		}
+		return out, nil
+	case string:
+		// A placeholder on its own keeps the type of the value
+		if match := placeholder.FindStringSubmatch(n); match != nil && match[0] == n {
+			value, ok := v[match[1]]
+			if !ok {
+				return nil, fmt.Errorf("unknown field {{%s}}", match[1])
+			}
+			return value, nil
+		}
+		return v.interpolate(n, func(s string) string { return s })
+	}
+	return node, nil
+}
+
+// interpolate replaces the placeholders within a string, escaping their values
+func (v templateVars) interpolate(s string, escape func(string) string) (string, error) {
+	var unknown string
+	out := placeholder.ReplaceAllStringFunc(s, func(match string) string {
+		name := placeholder.FindStringSubmatch(match)[1]
+		value, ok := v[name]
+		if !ok {
+			unknown = name
+			return match
+		}
+		if list, ok := value.([]string); ok {
+			return escape(strings.Join(list, ","))
+		}
+		return escape(fmt.Sprint(value))
+	})
+	if unknown != "" {
+		return "", fmt.Errorf("unknown field {{%s}}", unknown)
+	}
+	return out, nil
+}
+
+// GenericAdapter connects channels through a declarative definition of their REST API
+// stored per channel, so a channel can be added without an adapter of its own. It is
+// the registry's fallback: channels without a definition only have their pushes logged.
+type GenericAdapter struct {
+	channelRepo *database.ChannelRepository
+	credentials *CredentialVault
+	client      *http.Client
+	fallback    ChannelAdapter
+}
+
+// NewGenericAdapter creates a generic REST adapter
+func NewGenericAdapter(db *gorm.DB, credentials *CredentialVault) *GenericAdapter {
+	return &GenericAdapter{
+		channelRepo: database.NewChannelRepository(db),
+		credentials: credentials,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		fallback:    &LoggingAdapter{},
+	}
+}
+
+// ChannelID returns an empty code since the generic adapter serves any channel
+func (a *GenericAdapter) ChannelID() string {
+	return ""
+}
+
+// spec loads a channel's definition, nil when it has none
+func (a *GenericAdapter) spec(channelID string) (*GenericSpec, error) {
+	stored, err := a.channelRepo.GetAdapterSpec(channelID)
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load adapter definition: %w", err)
+	}
+	spec, err := ParseGenericSpec(stored.Spec)
+	if err != nil {
+		return nil, &ChannelError{Code: models.SyncErrorValidation, Message: err.Error()}
+	}
+	return spec, nil
+}
+
+// PushARI sends nightly availability and rates as the channel's definition declares
+func (a *GenericAdapter) PushARI(ctx context.Context, mapping models.ChannelMapping, updates []ARIUpdate) error {
+	spec, err := a.spec(mapping.ChannelID)
+	if err != nil || spec == nil {
+		if err == nil {
+			err = a.fallback.PushARI(ctx, mapping, updates)
+		}
+		return err
+	}
+	requests, err := spec.ariRequests(mapping, updates)
+	if err != nil {
+		return &ChannelError{Code: models.SyncErrorValidation, Message: err.Error()}
+	}
+	for _, request := range requests {
+		if err := a.send(ctx, mapping.ChannelID, spec, request); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PushContent sends descriptive content as the channel's definition declares
+func (a *GenericAdapter) PushContent(ctx context.Context, mapping models.ChannelMapping, content PropertyContent) error {
+	spec, err := a.spec(mapping.ChannelID)
+	if err != nil || spec == nil {
+		if err == nil {
+			err = a.fallback.PushContent(ctx, mapping, content)
+		}
+		return err
+	}
+	if spec.Content == nil {
+		return ErrUnsupported
+	}
+	request, err := spec.contentRequest(mapping, content)
+	if err != nil {
+		return &ChannelError{Code: models.SyncErrorValidation, Message: err.Error()}
+	}
+	return a.send(ctx, mapping.ChannelID, spec, *request)
+}
+
+// ReportNoShow tells the channel a guest never arrived, when its definition declares a
+// no_show endpoint
+func (a *GenericAdapter) ReportNoShow(ctx context.Context, booking models.Booking) error {
+	spec, err := a.spec(booking.ChannelID)
+	if err != nil {
+		return err
+	}
+	if spec == nil || spec.NoShow == nil {
+		return ErrUnsupported
+	}
+	request, err := spec.NoShow.render(bookingVars(booking))
+	if err != nil {
+		return &ChannelError{Code: models.SyncErrorValidation, Message: err.Error()}
+	}
+	return a.send(ctx, booking.ChannelID, spec, *request)
+}
+
+// RenderARI renders the ARI request body, or the list of bodies with per-night requests
+func (a *GenericAdapter) RenderARI(mapping models.ChannelMapping, updates []ARIUpdate) ([]byte, error) {
+	spec, err := a.spec(mapping.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	if spec == nil {
+		payload := neutral(mapping)
+		payload.Updates = updates
+		return json.Marshal(payload)
+	}
+	requests, err := spec.ariRequests(mapping, updates)
+	if err != nil {
+		return nil, err
+	}
+	if !spec.ARI.PerNight {
+		return requests[0].Body, nil
+	}
+	bodies := make([]json.RawMessage, len(requests))
+	for i, request := range requests {
+		bodies[i] = request.Body
+	}
+	return json.Marshal(bodies)
+}
+
+// RenderContent renders the content request body
+func (a *GenericAdapter) RenderContent(mapping models.ChannelMapping, content PropertyContent) ([]byte, error) {
+	spec, err := a.spec(mapping.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	if spec == nil {
+		payload := neutral(mapping)
+		payload.Content = &content
+		return json.Marshal(payload)
+	}
+	if spec.Content == nil {
+		return nil, ErrUnsupported
+	}
+	request, err := spec.contentRequest(mapping, content)
+	if err != nil {
+		return nil, err
+	}
+	return request.Body, nil
+}
+
+// send makes an authenticated request with the channel's credential
+func (a *GenericAdapter) send(ctx context.Context, channelID string, spec *GenericSpec, request genericRequest) error {
+	endpoint := strings.TrimRight(spec.BaseURL, "/") + request.Path
+	return a.credentials.Do(ctx, channelID, func(credential models.ChannelCredential) error {
+		req, err := http.NewRequestWithContext(ctx, request.Method, endpoint, bytes.NewReader(request.Body))
+		if err != nil {
+			return err
+		}
+		for name, value := range spec.Headers {
+			req.Header.Set(name, value)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		switch credential.Type {
+		case models.CredentialBasic:
+			req.SetBasicAuth(credential.Username, credential.Secret)
+		case models.CredentialAPIKey:
+			if spec.APIKeyHeader != "" {
+				req.Header.Set(spec.APIKeyHeader, credential.Secret)
+			} else {
+				req.Header.Set("Authorization", "Bearer "+credential.Secret)
+			}
+		default:
+			req.Header.Set("Authorization", "Bearer "+credential.AccessToken)
+		}
+
+		resp, err := a.client.Do(req)
+		if err != nil {
+			return &ChannelError{Code: models.SyncErrorUnavailable, Message: err.Error()}
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return spec.channelError(resp.StatusCode, data)
+	})
+}
@@ -0,0 +1,304 @@
+package channels
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// Channel reservation types
+const (
+	ReservationNew       = "new"
+	ReservationModified  = "modified"
+	ReservationCancelled = "cancelled"
+)
+
+var (
+	// ErrUnknownListing is returned for a reservation of a listing without an active mapping
+	ErrUnknownListing = errors.New("reservation is for a listing that is not mapped")
+	// ErrReservationType is returned for reservations of an unknown type
+	ErrReservationType = errors.New("reservation type not supported")
+	// ErrUnsupported is returned by adapters for operations their channel does not offer
+	ErrUnsupported = errors.New("operation not supported by the channel")
+)
+
+// ChannelReservation is a reservation reported by a channel, in channel-neutral form
+type ChannelReservation struct {
+	Type               string
+	ExternalReference  string
+	ExternalPropertyID string
+	ExternalRoomID     string
+	ExternalRatePlanID string
+	GuestName          string
+	GuestEmail         string
+	GuestPhone         string
+	CheckinDate        time.Time
+	CheckoutDate       time.Time
+	Adults             int
+	Children           int
+	TotalPrice         float64 // what the guest pays, as priced by the channel
+	Currency           string
+}
+
+// ContentHash fingerprints what the channel reported about a reservation, so a
+// redelivery can be told apart from a change
+func (r ChannelReservation) ContentHash() string {
+	return ContentHash(r)
+}
+
+// ContentHash fingerprints the JSON encoding of a reservation as received
+func ContentHash(v interface{}) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Confirmation tells a channel the booking a reservation was stored as
+type Confirmation struct {
+	Reservation ChannelReservation
+	BookingID   uint
+}
+
+// BookingRetriever is implemented by adapters of channels whose reservations are
+// fetched rather than sent to a webhook
+type BookingRetriever interface {
+	// RetrieveBookings returns the reservations the channel has not had confirmed yet
+	RetrieveBookings(ctx context.Context) ([]ChannelReservation, error)
+	// ConfirmBookings acknowledges stored reservations so they are not retrieved again
+	ConfirmBookings(ctx context.Context, confirmations []Confirmation) error
+}
+
+// NoShowReporter is implemented by adapters of channels that take no-show reports
+type NoShowReporter interface {
+	// ReportNoShow tells the channel the guest of a booking never arrived; it returns
+	// ErrUnsupported when the channel's configuration has no way to
+	ReportNoShow(ctx context.Context, booking models.Booking) error
+}
+
+// ReservationConfig holds channel reservation configuration
+type ReservationConfig struct {
+	PollInterval time.Duration // how often reservations are retrieved from channels that need polling
+}
+
+// ReservationService stores the reservations channels report as bookings
+type ReservationService struct {
+	registry     *Registry
+	channelRepo  *database.ChannelRepository
+	propertyRepo *database.PropertyRepository
+	bookingRepo  *database.BookingRepository
+	syncLogs     *database.SyncLogRepository
+}
+
+// NewReservationService creates a new reservation service
+func NewReservationService(db *gorm.DB, registry *Registry) *ReservationService {
+	return &ReservationService{
+		registry:     registry,
+		channelRepo:  database.NewChannelRepository(db),
+		propertyRepo: database.NewPropertyRepository(db),
+		bookingRepo:  database.NewBookingRepository(db),
+		syncLogs:     database.NewSyncLogRepository(db),
+	}
+}
+
+// Import stores a channel's reservation as a booking. New reservations are booked at
+// the channel's price and close their nights; modifications move the booking's nights
+// when its dates or property changed and update its party, price and guest details;
+// cancellations cancel it and reopen its nights. Reservations are stored once per
+// channel and reference: a redelivery with the same content returns the stored booking
+// unchanged, and a modification of a reservation never received is booked as new.
+func (s *ReservationService) Import(channelID string, reservation ChannelReservation) (*models.Booking, error) {
+	switch reservation.Type {
+	case ReservationNew, ReservationModified, ReservationCancelled:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrReservationType, reservation.Type)
+	}
+
+	started := time.Now()
+	entry := models.SyncLog{
+		ChannelID: channelID,
+		Direction: models.SyncInbound,
+		Operation: models.SyncOperationReservation,
+		Summary:   fmt.Sprintf("%s reservation %s", reservation.Type, reservation.ExternalReference),
+	}
+	if !reservation.CheckinDate.IsZero() {
+		entry.Summary += fmt.Sprintf(", %s..%s",
+			reservation.CheckinDate.Format("2006-01-02"), reservation.CheckoutDate.Format("2006-01-02"))
+	}
+
+	booking, outcome, err := s.store(channelID, reservation)
+	if booking != nil {
+		entry.PropertyID = booking.PropertyID
+		entry.Items = booking.Nights()
+	}
+	if outcome != "" {
+		entry.Summary += ", " + outcome
+	}
+	RecordSync(s.syncLogs, entry, started, err)
+	if err != nil {
+		return nil, err
+	}
+	return booking, nil
+}
+
+func (s *ReservationService) store(channelID string, reservation ChannelReservation) (*models.Booking, string, error) {
+	if reservation.ExternalReference == "" {
+		return nil, "", errors.New("reservation has no reference")
+	}
+	if reservation.Type == ReservationCancelled {
+		// A cancellation only needs to name the reservation; its listing may be unmapped by now
+		booking := &models.Booking{ChannelID: channelID, ExternalReference: reservation.ExternalReference}
+		outcome, err := s.bookingRepo.StoreInboundReservation(booking, reservation.ContentHash(), true, 0)
+		if err != nil {
+			return nil, "", err
+		}
+		return booking, outcome, nil
+	}
+	if !reservation.CheckoutDate.After(reservation.CheckinDate) {
+		return nil, "", errors.New("checkout date must be after checkin date")
+	}
+
+	mapping, err := s.channelRepo.GetMappingByExternalID(channelID, reservation.ExternalPropertyID, reservation.ExternalRoomID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, "", fmt.Errorf("%w: %s %s", ErrUnknownListing, reservation.ExternalPropertyID, reservation.ExternalRoomID)
+		}
+		return nil, "", fmt.Errorf("failed to load channel mapping: %w", err)
+	}
+	property, err := s.propertyRepo.GetPropertyByID(mapping.PropertyID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load property: %w", err)
+	}
+
+	guests := reservation.Adults + reservation.Children
+	if guests < 1 {
+		guests = 1
+	}
+	booking := &models.Booking{
+		PropertyID:        property.ID,
+		ChannelID:         channelID,
+		ExternalReference: reservation.ExternalReference,
+		GuestName:         strings.TrimSpace(reservation.GuestName),
+		GuestEmail:        reservation.GuestEmail,
+		GuestPhone:        reservation.GuestPhone,
+		CheckinDate:       reservation.CheckinDate,
+		CheckoutDate:      reservation.CheckoutDate,
+		NumberOfGuests:    guests,
+		NumberOfChildren:  reservation.Children,
+		Status:            models.BookingStatusConfirmed,
+		Currency:          reservation.Currency,
+		TotalPrice:        reservation.TotalPrice,
+	}
+	outcome, err := s.bookingRepo.StoreInboundReservation(booking, reservation.ContentHash(), false, property.TurnoverNights())
+	if err != nil {
+		return booking, "", err
+	}
+	return booking, outcome, nil
+}
+
+// ReportNoShow tells a booking's channel that the guest never arrived and records the
+// exchange. It returns ErrUnsupported for channels that do not take no-show reports or
+// are not live.
+func (s *ReservationService) ReportNoShow(ctx context.Context, booking models.Booking) error {
+	reporter, ok := s.registry.Get(booking.ChannelID).(NoShowReporter)
+	if !ok {
+		return ErrUnsupported
+	}
+	channel, err := s.channelRepo.GetChannelByID(booking.ChannelID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrUnsupported
+		}
+		return fmt.Errorf("failed to load channel: %w", err)
+	}
+	if !channel.Active || channel.DryRun {
+		return ErrUnsupported
+	}
+
+	started := time.Now()
+	err = reporter.ReportNoShow(ctx, booking)
+	if errors.Is(err, ErrUnsupported) {
+		return err
+	}
+	RecordSync(s.syncLogs, models.SyncLog{
+		ChannelID:  booking.ChannelID,
+		PropertyID: booking.PropertyID,
+		Direction:  models.SyncOutbound,
+		Operation:  models.SyncOperationNoShow,
+		Summary:    fmt.Sprintf("no-show of reservation %s", booking.ExternalReference),
+		Items:      1,
+	}, started, err)
+	return err
+}
+
+// Poll retrieves the pending reservations of every active channel whose adapter is a
+// BookingRetriever, stores them and confirms those stored. It returns the number of
+// reservations confirmed.
+func (s *ReservationService) Poll(ctx context.Context) (int, error) {
+	confirmed := 0
+	var errs []error
+	for _, adapter := range s.registry.Adapters() {
+		retriever, ok := adapter.(BookingRetriever)
+		if !ok {
+			continue
+		}
+		channel, err := s.channelRepo.GetChannelByID(adapter.ChannelID())
+		if err != nil {
+			if err != gorm.ErrRecordNotFound {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if !channel.Active || channel.DryRun {
+			continue
+		}
+
+		count, err := s.pollChannel(ctx, channel.ID, retriever)
+		confirmed += count
+		if err != nil {
+			errs = append(errs, fmt.Errorf("channel %s: %w", channel.ID, err))
+		}
+	}
+	return confirmed, errors.Join(errs...)
+}
+
+func (s *ReservationService) pollChannel(ctx context.Context, channelID string, retriever BookingRetriever) (int, error) {
+	reservations, err := retriever.RetrieveBookings(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve reservations: %w", err)
+	}
+
+	var confirmations []Confirmation
+	for _, reservation := range reservations {
+		booking, err := s.Import(channelID, reservation)
+		if err != nil {
+			// Left unconfirmed, the channel reports the reservation again on the next poll
+			log.Printf("Failed to store reservation %s of channel %s: %v", reservation.ExternalReference, channelID, err)
+			continue
+		}
+		confirmations = append(confirmations, Confirmation{Reservation: reservation, BookingID: booking.ID})
+	}
+	if len(confirmations) == 0 {
+		return 0, nil
+	}
+	if err := retriever.ConfirmBookings(ctx, confirmations); err != nil {
+		return 0, fmt.Errorf("failed to confirm reservations: %w", err)
+	}
+	return len(confirmations), nil
+}
+
+// confirmationNumber is the booking reference given to channels
+func confirmationNumber(bookingID uint) string {
+	return strconv.FormatUint(uint64(bookingID), 10)
+}
@@ -0,0 +1,131 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// ResyncHorizonDays is how many nights from today a resync pushes
+const ResyncHorizonDays = 365
+
+// ErrNoMappings is returned when a resync has no active mapped property to push
+var ErrNoMappings = errors.New("no active mapped properties to resync")
+
+// ResyncService pushes the full ARI and content of a channel's mapped properties in the
+// background, to recover from data drift on the channel side
+type ResyncService struct {
+	ariPush      *ARIPushService
+	contentPush  *ContentPushService
+	channelRepo  *database.ChannelRepository
+	propertyRepo *database.PropertyRepository
+	jobs         *database.ResyncRepository
+}
+
+// NewResyncService creates a new resync service
+func NewResyncService(db *gorm.DB, ariPush *ARIPushService, contentPush *ContentPushService) *ResyncService {
+	return &ResyncService{
+		ariPush:      ariPush,
+		contentPush:  contentPush,
+		channelRepo:  database.NewChannelRepository(db),
+		propertyRepo: database.NewPropertyRepository(db),
+		jobs:         database.NewResyncRepository(db),
+	}
+}
+
+// Enqueue creates a resync job for the channel's active mappings, or only the given
+// property's when propertyID is not zero, and runs it in the background
+func (s *ResyncService) Enqueue(channel models.Channel, propertyID uint) (*models.ResyncJob, error) {
+	all, err := s.channelRepo.GetMappingsForChannel(channel.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load channel mappings: %w", err)
+	}
+
+	var mappings []models.ChannelMapping
+	for _, mapping := range all {
+		if mapping.Active && (propertyID == 0 || mapping.PropertyID == propertyID) {
+			mapping.Channel = &channel
+			mappings = append(mappings, mapping)
+		}
+	}
+	if len(mappings) == 0 {
+		return nil, ErrNoMappings
+	}
+
+	job := &models.ResyncJob{
+		ChannelID: channel.ID,
+		Status:    models.ResyncStatusQueued,
+		Total:     len(mappings),
+	}
+	if propertyID != 0 {
+		job.PropertyID = &propertyID
+	}
+	if err := s.jobs.CreateJob(job); err != nil {
+		return nil, err
+	}
+
+	queued := *job
+	go s.run(&queued, mappings)
+	return job, nil
+}
+
+// run pushes each mapping and records progress after every property
+func (s *ResyncService) run(job *models.ResyncJob, mappings []models.ChannelMapping) {
+	ctx := context.Background()
+	started := time.Now()
+	job.Status = models.ResyncStatusRunning
+	job.StartedAt = &started
+	s.saveProgress(job)
+
+	for _, mapping := range mappings {
+		if err := s.pushMapping(ctx, mapping); err != nil {
+			log.Printf("Resync %d of property %d to %s failed: %v", job.ID, mapping.PropertyID, job.ChannelID, err)
+			job.Failed++
+			job.LastError = fmt.Sprintf("property %d: %v", mapping.PropertyID, err)
+		} else {
+			job.Completed++
+		}
+		s.saveProgress(job)
+	}
+
+	finished := time.Now()
+	job.FinishedAt = &finished
+	job.Status = models.ResyncStatusCompleted
+	if job.Failed > 0 {
+		job.Status = models.ResyncStatusFailed
+	}
+	s.saveProgress(job)
+	log.Printf("Resync %d to %s finished: %d pushed, %d failed", job.ID, job.ChannelID, job.Completed, job.Failed)
+}
+
+// pushMapping pushes the ARI of the horizon from the property's today and the content of
+// one mapped property
+func (s *ResyncService) pushMapping(ctx context.Context, mapping models.ChannelMapping) error {
+	property, err := s.propertyRepo.GetPropertyByID(mapping.PropertyID)
+	if err != nil {
+		return fmt.Errorf("failed to load property: %w", err)
+	}
+
+	start := property.Today()
+	if err := s.ariPush.PushMapping(ctx, mapping, start, start.AddDate(0, 0, ResyncHorizonDays-1)); err != nil {
+		return fmt.Errorf("ARI push: %w", err)
+	}
+	if err := s.contentPush.PushMapping(ctx, mapping, *property); err != nil {
+		return fmt.Errorf("content push: %w", err)
+	}
+	return nil
+}
+
+// saveProgress stores the job's progress; a failure only delays what status readers see
+func (s *ResyncService) saveProgress(job *models.ResyncJob) {
+	if err := s.jobs.SaveProgress(job); err != nil {
+		log.Printf("Failed to save progress of resync %d: %v", job.ID, err)
+	}
+}
@@ -0,0 +1,79 @@
+package channels
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"channelmanager/database"
+	"channelmanager/models"
+)
+
+// RecordSync logs an exchange with a channel that started at started and ended with err;
+// failing to log does not fail the exchange
+func RecordSync(repo *database.SyncLogRepository, entry models.SyncLog, started time.Time, err error) {
+	entry.DurationMs = time.Since(started).Milliseconds()
+	entry.Result = models.SyncResultSuccess
+	if err != nil {
+		entry.Result = models.SyncResultFailure
+		entry.Error = err.Error()
+		var channelErr *ChannelError
+		if errors.As(err, &channelErr) {
+			entry.ErrorCode = channelErr.Code
+		}
+	}
+	if logErr := repo.CreateSyncLog(&entry); logErr != nil {
+		log.Printf("Failed to record %s sync log for channel %s: %v", entry.Operation, entry.ChannelID, logErr)
+	}
+}
+
+// ChannelError is an error reported by a channel's API, with the channel's own code
+// translated to one of the sync error codes
+type ChannelError struct {
+	Code        string // sync error code, e.g. models.SyncErrorMapping
+	ChannelCode string // the channel's own error code
+	Message     string
+}
+
+func (e *ChannelError) Error() string {
+	if e.ChannelCode == "" {
+		return fmt.Sprintf("%s: %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s (channel code %s): %s", e.Code, e.ChannelCode, e.Message)
+}
+
+// Retryable reports whether the same request may succeed later
+func (e *ChannelError) Retryable() bool {
+	return e.Code == models.SyncErrorRateLimited || e.Code == models.SyncErrorUnavailable
+}
+
+// httpStatusError translates an unexpected HTTP status of a channel's API
+func httpStatusError(status int, body []byte) *ChannelError {
+	code := models.SyncErrorValidation
+	switch {
+	case status == 401 || status == 403:
+		code = models.SyncErrorAuthentication
+	case status == 404:
+		code = models.SyncErrorMapping
+	case status == 429:
+		code = models.SyncErrorRateLimited
+	case status >= 500:
+		code = models.SyncErrorUnavailable
+	}
+	message := strings.TrimSpace(string(body))
+	if len(message) > 500 {
+		message = message[:500]
+	}
+	return &ChannelError{Code: code, ChannelCode: fmt.Sprintf("HTTP %d", status), Message: message}
+}
+
+// ariSummary describes the nights of an ARI push
+func ariSummary(updates []ARIUpdate) string {
+	if len(updates) == 0 {
+		return "no nights"
+	}
+	return fmt.Sprintf("%d nights %s..%s", len(updates),
+		updates[0].Date.Format("2006-01-02"), updates[len(updates)-1].Date.Format("2006-01-02"))
+}
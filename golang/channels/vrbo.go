@@ -0,0 +1,346 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"channelmanager/models"
+)
+
+// VrboConfig holds the Vrbo integration configuration
+type VrboConfig struct {
+	Enabled   bool
+	ChannelID string // code of the Vrbo channel
+	BaseURL   string // root of the Vrbo partner API
+}
+
+// VrboAdapter pushes listing content, the availability calendar and nightly rates to
+// Vrbo's JSON partner API with the channel's OAuth credential; reservations arrive on
+// the Vrbo webhook. Mappings name the Vrbo listing as the external property and, for
+// listings with several units, the unit as the external room.
+type VrboAdapter struct {
+	cfg         VrboConfig
+	credentials *CredentialVault
+	client      *http.Client
+}
+
+// NewVrboAdapter creates a Vrbo adapter
+func NewVrboAdapter(cfg VrboConfig, credentials *CredentialVault) *VrboAdapter {
+	return &VrboAdapter{
+		cfg:         cfg,
+		credentials: credentials,
+		client:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ChannelID returns the code of the Vrbo channel
+func (a *VrboAdapter) ChannelID() string {
+	return a.cfg.ChannelID
+}
+
+// vrboCalendar is the availability calendar of a listing
+type vrboCalendar struct {
+	Days []vrboCalendarDay `json:"days"`
+}
+
+type vrboCalendarDay struct {
+	Date      string `json:"date"`
+	Available bool   `json:"available"`
+	MinStay   int    `json:"min_stay,omitempty"`
+	MaxGuests int    `json:"max_guests,omitempty"`
+}
+
+// vrboRates are the nightly rates of a listing
+type vrboRates struct {
+	Currency string          `json:"currency"`
+	Nightly  []vrboNightRate `json:"nightly"`
+}
+
+type vrboNightRate struct {
+	Date   string  `json:"date"`
+	Amount float64 `json:"amount"`
+}
+
+// vrboARI is the rendered calendar and rates push
+type vrboARI struct {
+	Calendar vrboCalendar `json:"calendar"`
+	Rates    vrboRates    `json:"rates"`
+}
+
+// vrboContent is the content of a listing
+type vrboContent struct {
+	Headline     string              `json:"headline"`
+	Description  string              `json:"description"`
+	Locale       string              `json:"locale"`
+	Translations []vrboTranslation   `json:"translations,omitempty"`
+	City         string              `json:"city"`
+	Country      string              `json:"country"`
+	Latitude     float64             `json:"latitude"`
+	Longitude    float64             `json:"longitude"`
+	Sleeps       int                 `json:"sleeps"`
+	Bedrooms     int                 `json:"bedrooms"`
+	Bathrooms    int                 `json:"bathrooms"`
+	Amenities    []string            `json:"amenities"`
+	Policies     []string            `json:"policies"`
+	Photos       []ContentPhoto      `json:"photos"`
+	HouseRules   string              `json:"house_rules,omitempty"`
+	Rules        models.HouseRules   `json:"rules"`
+	CheckinTimes models.CheckinTimes `json:"checkin_times"`
+}
+
+type vrboTranslation struct {
+	Locale      string `json:"locale"`
+	Headline    string `json:"headline"`
+	Description string `json:"description"`
+	HouseRules  string `json:"house_rules,omitempty"`
+}
+
+// listingPath is the API path of a mapping's listing or unit
+func (a *VrboAdapter) listingPath(mapping models.ChannelMapping) (string, error) {
+	if mapping.ExternalPropertyID == "" {
+		return "", &ChannelError{Code: models.SyncErrorMapping, Message: "Vrbo mappings need the listing as external property"}
+	}
+	path := "/listings/" + url.PathEscape(mapping.ExternalPropertyID)
+	if mapping.ExternalRoomID != "" {
+		path += "/units/" + url.PathEscape(mapping.ExternalRoomID)
+	}
+	return path, nil
+}
+
+// buildVrboARI splits ARI updates into the calendar and the rates; nights without a rate
+// are left out of the rates and closed on the calendar
+func buildVrboARI(updates []ARIUpdate) vrboARI {
+	ari := vrboARI{
+		Calendar: vrboCalendar{Days: make([]vrboCalendarDay, 0, len(updates))},
+		Rates:    vrboRates{Nightly: make([]vrboNightRate, 0, len(updates))},
+	}
+	for _, update := range updates {
+		date := update.Date.Format("2006-01-02")
+		ari.Calendar.Days = append(ari.Calendar.Days, vrboCalendarDay{
+			Date:      date,
+			Available: update.Available && update.Rate > 0,
+			MinStay:   update.MinStay,
+			MaxGuests: update.MaxGuests,
+		})
+		if update.Rate > 0 {
+			ari.Rates.Currency = update.Currency
+			ari.Rates.Nightly = append(ari.Rates.Nightly, vrboNightRate{Date: date, Amount: update.Rate})
+		}
+	}
+	return ari
+}
+
+// PushARI sends the rates and then the calendar, so nights never open before their
+// rate is known
+func (a *VrboAdapter) PushARI(ctx context.Context, mapping models.ChannelMapping, updates []ARIUpdate) error {
+	path, err := a.listingPath(mapping)
+	if err != nil {
+		return err
+	}
+	ari := buildVrboARI(updates)
+	if len(ari.Rates.Nightly) > 0 {
+		if err := a.send(ctx, http.MethodPut, path+"/rates", ari.Rates); err != nil {
+			return fmt.Errorf("rates: %w", err)
+		}
+	}
+	if err := a.send(ctx, http.MethodPut, path+"/calendar", ari.Calendar); err != nil {
+		return fmt.Errorf("calendar: %w", err)
+	}
+	return nil
+}
+
+// buildVrboContent converts content to a Vrbo listing; amenities and conditions are sent
+// as their Vrbo codes
+func buildVrboContent(content PropertyContent) vrboContent {
+	listing := vrboContent{
+		Headline:     content.Name,
+		Description:  content.Description,
+		Locale:       content.DefaultLocale,
+		City:         content.City,
+		Country:      content.Country,
+		Latitude:     content.Latitude,
+		Longitude:    content.Longitude,
+		Sleeps:       content.MaxGuests,
+		Bedrooms:     content.Bedrooms,
+		Bathrooms:    content.Bathrooms,
+		Amenities:    make([]string, 0, len(content.Amenities)),
+		Policies:     make([]string, 0, len(content.Conditions)),
+		Photos:       content.Photos,
+		HouseRules:   content.HouseRules,
+		Rules:        content.Rules,
+		CheckinTimes: content.CheckinTimes,
+	}
+	for _, amenity := range content.Amenities {
+		listing.Amenities = append(listing.Amenities, amenity.Code)
+	}
+	for _, condition := range content.Conditions {
+		listing.Policies = append(listing.Policies, condition.Code)
+	}
+	for _, translation := range content.Translations {
+		listing.Translations = append(listing.Translations, vrboTranslation{
+			Locale:      translation.Locale,
+			Headline:    translation.Name,
+			Description: translation.Description,
+			HouseRules:  translation.HouseRules,
+		})
+	}
+	return listing
+}
+
+// PushContent sends the listing's descriptive content, amenities, policies and photos
+func (a *VrboAdapter) PushContent(ctx context.Context, mapping models.ChannelMapping, content PropertyContent) error {
+	path, err := a.listingPath(mapping)
+	if err != nil {
+		return err
+	}
+	return a.send(ctx, http.MethodPut, path+"/content", buildVrboContent(content))
+}
+
+// RenderARI renders the rates and calendar requests
+func (a *VrboAdapter) RenderARI(mapping models.ChannelMapping, updates []ARIUpdate) ([]byte, error) {
+	if _, err := a.listingPath(mapping); err != nil {
+		return nil, err
+	}
+	return json.Marshal(buildVrboARI(updates))
+}
+
+// RenderContent renders the content request
+func (a *VrboAdapter) RenderContent(mapping models.ChannelMapping, content PropertyContent) ([]byte, error) {
+	if _, err := a.listingPath(mapping); err != nil {
+		return nil, err
+	}
+	return json.Marshal(buildVrboContent(content))
+}
+
+// vrboErrorResponse is the body of a failed Vrbo API request
+type vrboErrorResponse struct {
+	Errors []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// vrboErrorCodes translates Vrbo error codes; others are translated by HTTP status
+var vrboErrorCodes = map[string]string{
+	"UNAUTHORIZED":        models.SyncErrorAuthentication,
+	"INVALID_TOKEN":       models.SyncErrorAuthentication,
+	"FORBIDDEN":           models.SyncErrorAuthentication,
+	"LISTING_NOT_FOUND":   models.SyncErrorMapping,
+	"UNIT_NOT_FOUND":      models.SyncErrorMapping,
+	"VALIDATION_ERROR":    models.SyncErrorValidation,
+	"INVALID_DATE":        models.SyncErrorValidation,
+	"INVALID_AMOUNT":      models.SyncErrorValidation,
+	"RATE_BELOW_MINIMUM":  models.SyncErrorRejected,
+	"LISTING_DEACTIVATED": models.SyncErrorRejected,
+	"RATE_LIMIT_EXCEEDED": models.SyncErrorRateLimited,
+}
+
+// send makes an authenticated JSON request to the Vrbo API
+func (a *VrboAdapter) send(ctx context.Context, method, path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return a.credentials.Do(ctx, a.cfg.ChannelID, func(credential models.ChannelCredential) error {
+		req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(a.cfg.BaseURL, "/")+path, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		token := credential.AccessToken
+		if credential.Type != models.CredentialOAuth2 {
+			token = credential.Secret
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := a.client.Do(req)
+		if err != nil {
+			return &ChannelError{Code: models.SyncErrorUnavailable, Message: err.Error()}
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		var failure vrboErrorResponse
+		if json.Unmarshal(data, &failure) == nil && len(failure.Errors) > 0 {
+			first := failure.Errors[0]
+			if code, ok := vrboErrorCodes[first.Code]; ok {
+				return &ChannelError{Code: code, ChannelCode: first.Code, Message: first.Message}
+			}
+			channelErr := httpStatusError(resp.StatusCode, nil)
+			channelErr.ChannelCode, channelErr.Message = first.Code, first.Message
+			return channelErr
+		}
+		return httpStatusError(resp.StatusCode, data)
+	})
+}
+
+// Vrbo reservation webhook event types
+const (
+	VrboReservationCreated   = "reservation.created"
+	VrboReservationUpdated   = "reservation.updated"
+	VrboReservationCancelled = "reservation.cancelled"
+)
+
+// VrboReservationEvent is the body of a Vrbo reservation webhook
+type VrboReservationEvent struct {
+	EventType   string `json:"event_type" binding:"required,oneof=reservation.created reservation.updated reservation.cancelled"`
+	Reservation struct {
+		ReservationID string `json:"reservation_id" binding:"required,max=100"`
+		ListingID     string `json:"listing_id" binding:"required,max=100"`
+		UnitID        string `json:"unit_id" binding:"max=100"`
+		ArrivalDate   string `json:"arrival_date" binding:"required,datetime=2006-01-02"`
+		DepartureDate string `json:"departure_date" binding:"required,datetime=2006-01-02"`
+		Adults        int    `json:"adults" binding:"min=0,max=50"`
+		Children      int    `json:"children" binding:"min=0,max=50"`
+		Traveler      struct {
+			FirstName string `json:"first_name" binding:"max=100"`
+			LastName  string `json:"last_name" binding:"max=100"`
+			Email     string `json:"email" binding:"omitempty,email"`
+			Phone     string `json:"phone" binding:"max=50"`
+		} `json:"traveler"`
+		TotalAmount struct {
+			Amount   float64 `json:"amount" binding:"min=0"`
+			Currency string  `json:"currency" binding:"omitempty,len=3"`
+		} `json:"total_amount"`
+	} `json:"reservation" binding:"required"`
+}
+
+// vrboReservationTypes maps webhook event types to reservation types
+var vrboReservationTypes = map[string]string{
+	VrboReservationCreated:   ReservationNew,
+	VrboReservationUpdated:   ReservationModified,
+	VrboReservationCancelled: ReservationCancelled,
+}
+
+// ChannelReservation converts a validated webhook event to a channel reservation
+func (e VrboReservationEvent) ChannelReservation() ChannelReservation {
+	r := e.Reservation
+	checkin, _ := time.Parse("2006-01-02", r.ArrivalDate)
+	checkout, _ := time.Parse("2006-01-02", r.DepartureDate)
+	return ChannelReservation{
+		Type:               vrboReservationTypes[e.EventType],
+		ExternalReference:  r.ReservationID,
+		ExternalPropertyID: r.ListingID,
+		ExternalRoomID:     r.UnitID,
+		GuestName:          strings.TrimSpace(r.Traveler.FirstName + " " + r.Traveler.LastName),
+		GuestEmail:         r.Traveler.Email,
+		GuestPhone:         r.Traveler.Phone,
+		CheckinDate:        checkin,
+		CheckoutDate:       checkout,
+		Adults:             r.Adults,
+		Children:           r.Children,
+		TotalPrice:         r.TotalAmount.Amount,
+		Currency:           strings.ToUpper(r.TotalAmount.Currency),
+	}
+}
@@ -0,0 +1,57 @@
+package coalesce
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrLoadPanicked is returned to callers waiting on a load that panicked
+var ErrLoadPanicked = errors.New("coalesced load panicked")
+
+// call is a load in flight
+type call[T any] struct {
+	done  chan struct{}
+	val   T
+	err   error
+	waits int
+}
+
+// Group coalesces concurrent loads of the same key: while a load runs, callers asking
+// for the same key wait for it and share its result instead of starting their own. The
+// zero Group is ready to use.
+type Group[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*call[T]
+}
+
+// Do runs load for key unless a load of key is already running, in which case it waits
+// for that one. It returns the load's result and whether it was shared with other
+// callers; shared values must be copied before being modified.
+func (g *Group[T]) Do(key string, load func() (T, error)) (val T, shared bool, err error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call[T])
+	}
+	if c, ok := g.calls[key]; ok {
+		c.waits++
+		g.mu.Unlock()
+		<-c.done
+		return c.val, true, c.err
+	}
+
+	c := &call[T]{done: make(chan struct{}), err: ErrLoadPanicked}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	// Waiters may join until the call is removed, so whether it was shared is only
+	// known then
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		shared = c.waits > 0
+		g.mu.Unlock()
+		close(c.done)
+	}()
+	c.val, c.err = load()
+	return c.val, false, c.err
+}
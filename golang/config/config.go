@@ -1,18 +1,72 @@
 package config
 
 import (
+	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	"channelmanager/archive"
 	"channelmanager/cache"
+	"channelmanager/channels"
+	"channelmanager/consistency"
 	"channelmanager/database"
+	"channelmanager/feed"
+	"channelmanager/fx"
+	"channelmanager/handlers"
+	"channelmanager/jobs"
+	"channelmanager/ledger"
+	"channelmanager/middleware"
+	"channelmanager/parity"
+	"channelmanager/payments"
+	"channelmanager/pii"
+	"channelmanager/ranking"
+	"channelmanager/reconcile"
+	"channelmanager/stats"
+	"channelmanager/storage"
+	"channelmanager/weather"
 )
 
 // Config holds all application configuration
 type Config struct {
-	Server   ServerConfig
-	Database database.Config
-	Redis    cache.Config
+	Server    ServerConfig
+	Database  database.Config
+	Redis     cache.Config
+	Storage   storage.Config
+	Ledger    ledger.Config
+	Parity    parity.Config
+	Reconcile reconcile.Config
+	Auth      middleware.Config
+	Search    handlers.SearchConfig
+	Feed      feed.Config
+	// Google Vacation Rentals feed of listings, prices and availability
+	GoogleFeed feed.GoogleConfig
+	Jobs       jobs.Config
+	Archive    archive.Config
+	PII        pii.Config
+	Payments   payments.Config
+	Stats      stats.Config
+	// Credentials of the channel APIs, stored encrypted with the PII keys
+	Credentials channels.CredentialConfig
+	// Reservations retrieved from channels without webhooks
+	Reservations channels.ReservationConfig
+	Expedia      channels.ExpediaConfig
+	Vrbo         channels.VrboConfig
+	// Limits protecting gift cards and vouchers from fraud
+	Vouchers handlers.VoucherConfig
+	// Booking requests of properties that approve each booking, and host calendar feeds
+	Bookings handlers.BookingConfig
+	// Exchange rates converting prices to the currencies of channels
+	FX fx.Config
+	// Optional forecasts attached to search responses
+	Weather weather.Config
+	// Likely duplicate listings flagged for review
+	Duplicates database.DuplicateConfig
+	// Retention of soft-deleted properties, amenities and conditions
+	Purge database.PurgeConfig
+	// Sampling of cached properties and searches for missed invalidations
+	Consistency consistency.Config
 }
 
 // ServerConfig holds server configuration
@@ -20,6 +74,25 @@ type ServerConfig struct {
 	Host string
 	Port string
 	Env  string
+	// TestContainers runs test mode against PostgreSQL and Redis started in Docker for
+	// the run instead of the configured ones
+	TestContainers bool
+	// TLSCertFile and TLSKeyFile enable HTTPS when both are set
+	TLSCertFile string
+	TLSKeyFile  string
+	// ReadHeaderTimeout, ReadTimeout, WriteTimeout and IdleTimeout bound each connection;
+	// zero disables the timeout. WriteTimeout also covers streaming responses, so it is
+	// off by default and handlers are bounded by the request timeout instead
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	// TrustedProxies lists the proxy IPs or CIDRs whose X-Forwarded-For header is
+	// trusted for the client IP; with none the connection's address is used
+	TrustedProxies []string
+	// CORS lets browser-based frontends on the allowed origins call the API directly
+	CORS middleware.CORSConfig
 }
 
 // LoadConfig loads configuration from environment variables
@@ -29,6 +102,25 @@ func LoadConfig() *Config {
 			Host: getEnv("SERVER_HOST", "0.0.0.0"),
 			Port: getEnv("SERVER_PORT", "8080"),
 			Env:  getEnv("ENV", "development"),
+
+			TestContainers: getEnvBool("TEST_CONTAINERS", false),
+
+			TLSCertFile:       getEnv("TLS_CERT_FILE", ""),
+			TLSKeyFile:        getEnv("TLS_KEY_FILE", ""),
+			ReadHeaderTimeout: time.Duration(getEnvInt("SERVER_READ_HEADER_TIMEOUT_SECONDS", 10)) * time.Second,
+			ReadTimeout:       time.Duration(getEnvInt("SERVER_READ_TIMEOUT_SECONDS", 60)) * time.Second,
+			WriteTimeout:      time.Duration(getEnvInt("SERVER_WRITE_TIMEOUT_SECONDS", 0)) * time.Second,
+			IdleTimeout:       time.Duration(getEnvInt("SERVER_IDLE_TIMEOUT_SECONDS", 120)) * time.Second,
+			MaxHeaderBytes:    getEnvInt("SERVER_MAX_HEADER_BYTES", 1<<20),
+			TrustedProxies:    getEnvList("TRUSTED_PROXIES"),
+			CORS: middleware.CORSConfig{
+				AllowedOrigins:   getEnvList("CORS_ALLOWED_ORIGINS"),
+				AllowedMethods:   getEnvListDefault("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE,OPTIONS"),
+				AllowedHeaders:   getEnvListDefault("CORS_ALLOWED_HEADERS", "Accept,Accept-Language,Authorization,Content-Type,Idempotency-Key,If-None-Match,X-API-Key,X-Request-ID,X-Session-Token"),
+				ExposedHeaders:   getEnvListDefault("CORS_EXPOSED_HEADERS", "ETag,Idempotent-Replayed,Retry-After,X-Request-ID,X-Session-Token"),
+				AllowCredentials: getEnvBool("CORS_ALLOW_CREDENTIALS", false),
+				MaxAge:           time.Duration(getEnvInt("CORS_MAX_AGE_SECONDS", 600)) * time.Second,
+			},
 		},
 		Database: database.Config{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -43,6 +135,164 @@ func LoadConfig() *Config {
 			Port:     getEnvInt("REDIS_PORT", 6379),
 			Password: getEnv("REDIS_PASSWORD", ""),
 			DB:       getEnvInt("REDIS_DB", 0),
+
+			PropertyMinTTL:   time.Duration(getEnvInt("PROPERTY_CACHE_MIN_TTL_MINUTES", 5)) * time.Minute,
+			PropertyMaxTTL:   time.Duration(getEnvInt("PROPERTY_CACHE_MAX_TTL_MINUTES", 360)) * time.Minute,
+			VolatilityWindow: time.Duration(getEnvInt("PROPERTY_VOLATILITY_WINDOW_HOURS", 24)) * time.Hour,
+
+			SearchCacheMaxBytes: int64(getEnvInt("SEARCH_CACHE_MAX_MB", 64)) << 20,
+			RequireEviction:     getEnvBool("REDIS_REQUIRE_EVICTION", false),
+		},
+		Storage: storage.Config{
+			Driver:    getEnv("STORAGE_DRIVER", "local"),
+			LocalPath: getEnv("STORAGE_LOCAL_PATH", "./data/objects"),
+		},
+		Ledger: ledger.Config{
+			PlatformFeePercent:       getEnvFloat("LEDGER_PLATFORM_FEE_PERCENT", 3),
+			ChannelCommissionPercent: getEnvFloat("LEDGER_CHANNEL_COMMISSION_PERCENT", 15),
+		},
+		Parity: parity.Config{
+			CheckInterval:    time.Duration(getEnvInt("PARITY_CHECK_INTERVAL_MINUTES", 60)) * time.Minute,
+			LookaheadDays:    getEnvInt("PARITY_LOOKAHEAD_DAYS", 90),
+			TolerancePercent: getEnvFloat("PARITY_TOLERANCE_PERCENT", 0.5),
+			MajorPercent:     getEnvFloat("PARITY_MAJOR_PERCENT", 5),
+			CriticalPercent:  getEnvFloat("PARITY_CRITICAL_PERCENT", 15),
+		},
+		Reconcile: reconcile.Config{
+			Interval:      time.Duration(getEnvInt("RECONCILE_INTERVAL_MINUTES", 15)) * time.Minute,
+			LookaheadDays: getEnvInt("RECONCILE_LOOKAHEAD_DAYS", 365),
+		},
+		Auth: middleware.Config{
+			AdminAPIKeys:     getEnvList("ADMIN_API_KEYS"),
+			IdempotencyTTL:   time.Duration(getEnvInt("IDEMPOTENCY_TTL_HOURS", 24)) * time.Hour,
+			GzipLevel:        getEnvInt("GZIP_LEVEL", -1),
+			PartnerAPIKeys:   getPartnerKeys("PARTNER_API_KEYS"),
+			WebhookSecrets:   getKeyPairs("WEBHOOK_SECRETS"),
+			WebhookTolerance: time.Duration(getEnvInt("WEBHOOK_TOLERANCE_SECONDS", 300)) * time.Second,
+			RequestTimeout:   time.Duration(getEnvInt("REQUEST_TIMEOUT_SECONDS", 30)) * time.Second,
+			RouteTimeouts:    getRouteTimeouts("ROUTE_TIMEOUTS"),
+		},
+		Feed: feed.Config{
+			Interval: time.Duration(getEnvInt("FEED_INTERVAL_MINUTES", 60)) * time.Minute,
+		},
+		GoogleFeed: feed.GoogleConfig{
+			Enabled:     getEnvBool("GOOGLE_FEED_ENABLED", false),
+			LandingURL:  getEnv("GOOGLE_LANDING_URL", ""),
+			Currency:    getEnv("GOOGLE_FEED_CURRENCY", "USD"),
+			HorizonDays: getEnvInt("GOOGLE_FEED_HORIZON_DAYS", 180),
+			Interval:    time.Duration(getEnvInt("GOOGLE_FEED_REFRESH_SECONDS", 60)) * time.Second,
+		},
+		Jobs: jobs.Config{
+			Instance: getEnv("JOBS_INSTANCE_ID", jobs.DefaultInstance()),
+			LockTTL:  time.Duration(getEnvInt("JOBS_LOCK_TTL_SECONDS", 300)) * time.Second,
+		},
+		Archive: archive.Config{
+			EventRetentionDays: getEnvInt("EVENT_RETENTION_DAYS", 30),
+			Schedule:           getEnv("EVENT_ARCHIVE_SCHEDULE", "0 3 * * *"),
+		},
+		PII: pii.Config{
+			Keys:         getKeyPairs("PII_ENCRYPTION_KEYS"),
+			CurrentKeyID: getEnv("PII_CURRENT_KEY_ID", ""),
+			IndexKey:     getEnv("PII_INDEX_KEY", ""),
+			Schedule:     getEnv("PII_REENCRYPT_SCHEDULE", "30 3 * * *"),
+		},
+		Payments: payments.Config{
+			Provider:         getEnv("PAYMENT_PROVIDER", "simulated"),
+			HoldLeadDays:     getEnvInt("DEPOSIT_HOLD_LEAD_DAYS", 1),
+			ReleaseAfterDays: getEnvInt("DEPOSIT_RELEASE_AFTER_DAYS", 2),
+			DepositSchedule:  getEnv("DEPOSIT_SCHEDULE", "15 * * * *"),
+		},
+		Stats: stats.Config{
+			FlushInterval: time.Duration(getEnvInt("STATS_FLUSH_INTERVAL_SECONDS", 60)) * time.Second,
+		},
+		Credentials: channels.CredentialConfig{
+			RotationGrace:   time.Duration(getEnvInt("CREDENTIAL_ROTATION_GRACE_MINUTES", 60)) * time.Minute,
+			RefreshMargin:   time.Duration(getEnvInt("CREDENTIAL_REFRESH_MARGIN_SECONDS", 300)) * time.Second,
+			RefreshInterval: time.Duration(getEnvInt("CREDENTIAL_REFRESH_INTERVAL_MINUTES", 5)) * time.Minute,
+		},
+		Reservations: channels.ReservationConfig{
+			PollInterval: time.Duration(getEnvInt("CHANNEL_BOOKING_POLL_SECONDS", 120)) * time.Second,
+		},
+		Expedia: channels.ExpediaConfig{
+			Enabled:   getEnvBool("EXPEDIA_ENABLED", false),
+			ChannelID: getEnv("EXPEDIA_CHANNEL_ID", "expedia"),
+			BaseURL:   getEnv("EXPEDIA_EQC_URL", "https://services.expediapartnercentral.com/eqc"),
+		},
+		Vrbo: channels.VrboConfig{
+			Enabled:   getEnvBool("VRBO_ENABLED", false),
+			ChannelID: getEnv("VRBO_CHANNEL_ID", "vrbo"),
+			BaseURL:   getEnv("VRBO_API_URL", "https://api.vrbo.com/partner/v1"),
+		},
+		Search: handlers.SearchConfig{
+			MaxPageSize:      getEnvInt("SEARCH_MAX_PAGE_SIZE", 100),
+			MaxResponseBytes: getEnvInt("SEARCH_MAX_RESPONSE_BYTES", 1<<20),
+			Relevance: ranking.Weights{
+				Rating:   getEnvFloat("RELEVANCE_WEIGHT_RATING", 0.4),
+				Reviews:  getEnvFloat("RELEVANCE_WEIGHT_REVIEWS", 0.2),
+				Price:    getEnvFloat("RELEVANCE_WEIGHT_PRICE", 0.25),
+				Distance: getEnvFloat("RELEVANCE_WEIGHT_DISTANCE", 0.15),
+			},
+			MaxRankedCandidates: getEnvInt("RELEVANCE_MAX_CANDIDATES", 500),
+			LocationSimilarity:  getEnvFloat("SEARCH_SIMILARITY_THRESHOLD", 0.3),
+			CachedPages:         getEnvInt("SEARCH_CACHED_PAGES", 5),
+
+			TrendingWindowDays:    getEnvInt("TRENDING_WINDOW_DAYS", 14),
+			TrendingHalfLifeDays:  getEnvFloat("TRENDING_HALF_LIFE_DAYS", 3),
+			TrendingBookingWeight: getEnvFloat("TRENDING_BOOKING_WEIGHT", 20),
+			TrendingTTL:           time.Duration(getEnvInt("TRENDING_CACHE_TTL_MINUTES", 15)) * time.Minute,
+
+			SimilarRadiusKm: getEnvFloat("SIMILAR_RADIUS_KM", 25),
+			SimilarTTL:      time.Duration(getEnvInt("SIMILAR_CACHE_TTL_MINUTES", 60)) * time.Minute,
+
+			NearbyRadiusKm: getEnvFloat("NEARBY_RADIUS_KM", 50),
+			NearbyPerType:  getEnvInt("NEARBY_PER_TYPE", 3),
+
+			RecentlyViewedMax:       getEnvInt("RECENTLY_VIEWED_MAX", 20),
+			RecentlyViewedRetention: time.Duration(getEnvInt("RECENTLY_VIEWED_RETENTION_DAYS", 30)) * 24 * time.Hour,
+		},
+		Vouchers: handlers.VoucherConfig{
+			MaxPerBooking:       getEnvInt("VOUCHER_MAX_PER_BOOKING", 3),
+			MaxAmountPerBooking: getEnvFloat("VOUCHER_MAX_AMOUNT_PER_BOOKING", 2000),
+			MaxAmount:           getEnvFloat("VOUCHER_MAX_AMOUNT", 5000),
+			MaxFailedAttempts:   getEnvInt("VOUCHER_MAX_FAILED_ATTEMPTS", 10),
+			FailureWindow:       time.Duration(getEnvInt("VOUCHER_FAILURE_WINDOW_MINUTES", 15)) * time.Minute,
+		},
+		Bookings: handlers.BookingConfig{
+			RequestTTL:     time.Duration(getEnvInt("BOOKING_REQUEST_TTL_HOURS", 24)) * time.Hour,
+			ExpiryInterval: time.Duration(getEnvInt("BOOKING_REQUEST_EXPIRY_INTERVAL_MINUTES", 5)) * time.Minute,
+
+			CalendarFeedKey:     getEnv("CALENDAR_FEED_KEY", ""),
+			CalendarFeedBaseURL: getEnv("PUBLIC_BASE_URL", ""),
+		},
+		FX: fx.Config{
+			Providers:              getEnvListDefault("EXCHANGE_RATE_PROVIDERS", "ecb,openexchangerates"),
+			ECBURL:                 getEnv("ECB_RATES_URL", "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"),
+			OpenExchangeRatesURL:   getEnv("OPENEXCHANGERATES_URL", "https://openexchangerates.org/api/latest.json"),
+			OpenExchangeRatesAppID: getEnv("OPENEXCHANGERATES_APP_ID", ""),
+			Timeout:                time.Duration(getEnvInt("EXCHANGE_RATE_TIMEOUT_SECONDS", 15)) * time.Second,
+			Schedule:               getEnv("EXCHANGE_RATE_SCHEDULE", "0 */6 * * *"),
+			MaxAge:                 time.Duration(getEnvInt("EXCHANGE_RATE_MAX_AGE_HOURS", 96)) * time.Hour,
+		},
+		Weather: weather.Config{
+			Provider:     getEnv("WEATHER_PROVIDER", ""),
+			ForecastURL:  getEnv("WEATHER_FORECAST_URL", "https://api.open-meteo.com/v1/forecast"),
+			GeocodingURL: getEnv("WEATHER_GEOCODING_URL", "https://geocoding-api.open-meteo.com/v1/search"),
+			Timeout:      time.Duration(getEnvInt("WEATHER_TIMEOUT_SECONDS", 3)) * time.Second,
+			TTL:          time.Duration(getEnvInt("WEATHER_CACHE_TTL_MINUTES", 180)) * time.Minute,
+			Days:         getEnvInt("WEATHER_FORECAST_DAYS", 7),
+		},
+		Duplicates: database.DuplicateConfig{
+			Schedule:          getEnv("DUPLICATE_DETECTION_SCHEDULE", "30 3 * * *"),
+			MaxDistanceMeters: getEnvFloat("DUPLICATE_MAX_DISTANCE_METERS", 100),
+			MinNameSimilarity: getEnvFloat("DUPLICATE_MIN_NAME_SIMILARITY", 0.5),
+		},
+		Purge: database.PurgeConfig{
+			Schedule:      getEnv("PURGE_SCHEDULE", "0 4 * * *"),
+			RetentionDays: getEnvInt("DELETED_RECORD_RETENTION_DAYS", 90),
+		},
+		Consistency: consistency.Config{
+			Interval:   time.Duration(getEnvInt("CACHE_CHECK_INTERVAL_MINUTES", 10)) * time.Minute,
+			SampleSize: getEnvInt("CACHE_CHECK_SAMPLE_SIZE", 50),
 		},
 	}
 }
@@ -63,3 +313,88 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, exists := os.LookupEnv(key); exists {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvList(key string) []string {
+	return splitList(os.Getenv(key))
+}
+
+// splitList splits a comma-separated list, dropping blank entries
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvListDefault is getEnvList with a comma-separated default for an unset variable
+func getEnvListDefault(key string, defaultValue string) []string {
+	return splitList(getEnv(key, defaultValue))
+}
+
+// getPartnerKeys parses comma-separated channel_id:api_key pairs into a key -> channel map
+func getPartnerKeys(key string) map[string]string {
+	keys := make(map[string]string)
+	for _, pair := range getEnvList(key) {
+		channelID, apiKey, ok := strings.Cut(pair, ":")
+		channelID, apiKey = strings.TrimSpace(channelID), strings.TrimSpace(apiKey)
+		if !ok || channelID == "" || apiKey == "" {
+			log.Printf("Ignoring malformed %s entry, expected channel_id:api_key", key)
+			continue
+		}
+		keys[apiKey] = channelID
+	}
+	return keys
+}
+
+// getKeyPairs parses comma-separated id:key pairs, such as key IDs or webhook partners
+// with their secret, into an ID -> key map
+func getKeyPairs(key string) map[string]string {
+	keys := make(map[string]string)
+	for _, pair := range getEnvList(key) {
+		id, value, ok := strings.Cut(pair, ":")
+		id, value = strings.TrimSpace(id), strings.TrimSpace(value)
+		if !ok || id == "" || value == "" {
+			log.Printf("Ignoring malformed %s entry, expected id:key", key)
+			continue
+		}
+		keys[id] = value
+	}
+	return keys
+}
+
+// getRouteTimeouts parses comma-separated "METHOD /path=duration" entries, e.g.
+// "POST /api/v1/properties/search=5s", into a route -> timeout map
+func getRouteTimeouts(key string) map[string]time.Duration {
+	timeouts := make(map[string]time.Duration)
+	for _, entry := range getEnvList(key) {
+		route, value, ok := strings.Cut(entry, "=")
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if !ok || err != nil || !strings.Contains(route, " /") {
+			log.Printf("Ignoring malformed %s entry, expected METHOD /path=duration", key)
+			continue
+		}
+		timeouts[strings.Join(strings.Fields(route), " ")] = timeout
+	}
+	return timeouts
+}
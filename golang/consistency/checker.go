@@ -0,0 +1,278 @@
+package consistency
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"channelmanager/cache"
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// maxReportedMismatches is the number of mismatches a report lists
+const maxReportedMismatches = 50
+
+// Config holds cache consistency check configuration
+type Config struct {
+	Interval   time.Duration // how often cache entries are sampled; zero disables the check
+	SampleSize int           // property entries and search entries sampled by each check
+}
+
+// Checker samples cached property details and search results, compares them with the
+// database and repairs the stale ones, so missed invalidations are noticed and do not
+// linger until the entries expire
+type Checker struct {
+	redis        *cache.RedisClient
+	propertyRepo *database.PropertyRepository
+	eventRepo    *database.EventRepository
+	config       Config
+}
+
+// NewChecker creates a new cache consistency checker
+func NewChecker(db *gorm.DB, redis *cache.RedisClient, config Config) *Checker {
+	return &Checker{
+		redis:        redis,
+		propertyRepo: database.NewPropertyRepository(db),
+		eventRepo:    database.NewEventRepository(db),
+		config:       config,
+	}
+}
+
+// Check compares a sample of cache entries with the database, repairs the stale ones
+// and stores the report. Entries of properties with change events still to process
+// are skipped, as the event listener has yet to invalidate them.
+func (c *Checker) Check(ctx context.Context) (*models.CacheConsistencyReport, error) {
+	report := &models.CacheConsistencyReport{CheckedAt: time.Now(), Mismatches: []models.CacheMismatch{}}
+
+	if err := c.checkProperties(ctx, report); err != nil {
+		return nil, err
+	}
+	if err := c.checkSearches(ctx, report); err != nil {
+		return nil, err
+	}
+
+	checked := report.PropertiesChecked + report.SearchesChecked
+	stale := report.PropertiesStale + report.SearchesStale
+	if checked > 0 {
+		report.StaleRatio = float64(stale) / float64(checked)
+	}
+	previous, err := c.redis.GetConsistencyReport(ctx)
+	if err != nil {
+		log.Printf("Failed to load previous cache consistency report: %v", err)
+	}
+	report.TotalChecked, report.TotalStale = int64(checked), int64(stale)
+	if previous != nil {
+		report.TotalChecked += previous.TotalChecked
+		report.TotalStale += previous.TotalStale
+	}
+
+	if err := c.redis.SetConsistencyReport(ctx, report); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// checkProperties compares sampled cached property details with the database and
+// replaces the stale ones
+func (c *Checker) checkProperties(ctx context.Context, report *models.CacheConsistencyReport) error {
+	ids, err := c.redis.SampleCachedPropertyIDs(ctx, c.config.SampleSize)
+	if err != nil {
+		return err
+	}
+	pending, err := c.pendingProperties(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if pending[id] {
+			report.Skipped++
+			continue
+		}
+
+		cached, err := c.redis.GetPropertyCache(ctx, id)
+		if err != nil {
+			return err
+		}
+		if cached == nil {
+			continue // expired or invalidated since sampled
+		}
+		report.PropertiesChecked++
+
+		property, err := c.propertyRepo.GetPropertyByID(id)
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		reason, err := propertyMismatch(cached, property)
+		if err != nil {
+			return err
+		}
+		if reason == "" {
+			continue
+		}
+
+		// The entry may have been invalidated or refreshed since it was read
+		if current, err := c.redis.GetPropertyCache(ctx, id); err != nil || current == nil {
+			continue
+		} else if same, err := sameJSON(current, cached); err != nil || !same {
+			continue
+		}
+
+		report.PropertiesStale++
+		c.recordMismatch(report, fmt.Sprintf("property:%d", id), id, reason)
+		if err := c.repairProperty(ctx, id, property); err != nil {
+			log.Printf("Failed to repair cache of property %d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// checkSearches compares the properties listed by sampled cached searches with the
+// database and drops the searches listing stale ones
+func (c *Checker) checkSearches(ctx context.Context, report *models.CacheConsistencyReport) error {
+	keys, err := c.redis.SampleSearchCacheKeys(ctx, c.config.SampleSize)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		cached, err := c.redis.GetSearchResultsCache(ctx, key)
+		if err != nil {
+			log.Printf("Failed to read cached search %s: %v", key, err)
+			continue
+		}
+		if cached == nil || len(cached.Results) == 0 {
+			continue
+		}
+
+		ids := make([]uint, 0, len(cached.Results))
+		for _, result := range cached.Results {
+			ids = append(ids, result.ID)
+		}
+		pending, err := c.pendingProperties(ids)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			report.Skipped++
+			continue
+		}
+		report.SearchesChecked++
+
+		properties, err := c.propertyRepo.GetPropertiesWithContent(ids)
+		if err != nil {
+			return err
+		}
+		byID := make(map[uint]*models.Property, len(properties))
+		for i := range properties {
+			byID[properties[i].ID] = &properties[i]
+		}
+
+		for _, result := range cached.Results {
+			reason := searchResultMismatch(result, byID[result.ID])
+			if reason == "" {
+				continue
+			}
+
+			report.SearchesStale++
+			c.recordMismatch(report, key, result.ID, reason)
+			if err := c.redis.DeleteSearchResultsCache(ctx, key); err != nil {
+				log.Printf("Failed to drop stale cached search %s: %v", key, err)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// pendingProperties returns which of the given properties have change events still to
+// process
+func (c *Checker) pendingProperties(ids []uint) (map[uint]bool, error) {
+	pendingIDs, err := c.eventRepo.GetPendingPropertyIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	pending := make(map[uint]bool, len(pendingIDs))
+	for _, id := range pendingIDs {
+		pending[id] = true
+	}
+	return pending, nil
+}
+
+// repairProperty replaces a stale cached property with its current details, or drops it
+// once the property is gone
+func (c *Checker) repairProperty(ctx context.Context, id uint, property *models.Property) error {
+	if property == nil {
+		return c.redis.InvalidatePropertyCache(ctx, id)
+	}
+	ttl, err := c.redis.PropertyCacheTTL(ctx, id)
+	if err != nil {
+		log.Printf("Failed to read volatility of property %d: %v", id, err)
+	}
+	return c.redis.SetPropertyCache(ctx, id, property, ttl)
+}
+
+// recordMismatch logs a mismatch and lists it in the report
+func (c *Checker) recordMismatch(report *models.CacheConsistencyReport, key string, propertyID uint, reason string) {
+	log.Printf("Stale cache entry %s of property %d: %s", key, propertyID, reason)
+	if len(report.Mismatches) < maxReportedMismatches {
+		report.Mismatches = append(report.Mismatches, models.CacheMismatch{Key: key, PropertyID: propertyID, Reason: reason})
+	}
+}
+
+// propertyMismatch describes how cached property details differ from the database, or
+// returns "" when they agree; property is nil when it no longer exists
+func propertyMismatch(cached, property *models.Property) (string, error) {
+	if property == nil {
+		return "property no longer exists", nil
+	}
+	same, err := sameJSON(cached, property)
+	if err != nil || same {
+		return "", err
+	}
+	if cached.UpdatedAt.Before(property.UpdatedAt) {
+		return fmt.Sprintf("cached details predate the update at %s", property.UpdatedAt.Format(time.RFC3339)), nil
+	}
+	return "cached details differ", nil
+}
+
+// searchResultMismatch describes how a cached search result differs from its property,
+// or returns "" when they agree; property is nil when it no longer exists
+func searchResultMismatch(result models.SearchResult, property *models.Property) string {
+	switch {
+	case property == nil:
+		return "property no longer exists"
+	case property.Status != models.PropertyStatusActive:
+		return "property is " + property.Status
+	case result.Name != property.Name || result.Description != property.Description:
+		return "name or description changed"
+	case result.Location != property.Location || result.City != property.City ||
+		result.State != property.State || result.Country != property.Country:
+		return "location changed"
+	case result.MaxGuests != property.MaxGuests || result.Bedrooms != property.Bedrooms ||
+		result.Bathrooms != property.Bathrooms:
+		return "capacity changed"
+	case result.Rating != property.Rating || result.ReviewCount != property.ReviewCount:
+		return "rating changed"
+	}
+	return ""
+}
+
+// sameJSON reports whether two values encode to the same JSON, as cache entries do
+func sameJSON(a, b interface{}) (bool, error) {
+	encodedA, err := json.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+	encodedB, err := json.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(encodedA, encodedB), nil
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// BookingAggregate holds aggregated booking figures for a stay-date range
+type BookingAggregate struct {
+	BookedNights int
+	BookingCount int
+	Revenue      float64
+	NoShowNights int
+}
+
+// AnalyticsRepository handles analytics aggregate queries
+type AnalyticsRepository struct {
+	db *gorm.DB
+}
+
+// NewAnalyticsRepository creates a new analytics repository
+func NewAnalyticsRepository(db *gorm.DB) *AnalyticsRepository {
+	return &AnalyticsRepository{db: db}
+}
+
+// AggregateBookedNights sums booked nights and prorated revenue of the accepted stays
+// overlapping [start, end), and the nights of no-shows separately. Revenue is split evenly
+// across a booking's nights so stays crossing the range boundary only contribute their
+// nights inside it.
+func (r *AnalyticsRepository) AggregateBookedNights(propertyID uint, start, end time.Time) (BookingAggregate, error) {
+	var aggregate BookingAggregate
+	err := r.db.Model(&models.Booking{}).
+		Select(`COALESCE(SUM(LEAST(checkout_date, ?::date) - GREATEST(checkin_date, ?::date)) FILTER (WHERE status <> ?), 0) AS booked_nights,
+			COUNT(*) FILTER (WHERE status <> ?) AS booking_count,
+			COALESCE(SUM(total_price * (LEAST(checkout_date, ?::date) - GREATEST(checkin_date, ?::date))
+				/ NULLIF(checkout_date - checkin_date, 0)) FILTER (WHERE status <> ?), 0) AS revenue,
+			COALESCE(SUM(LEAST(checkout_date, ?::date) - GREATEST(checkin_date, ?::date)) FILTER (WHERE status = ?), 0) AS no_show_nights`,
+			end, start, models.BookingStatusNoShow, models.BookingStatusNoShow, end, start, models.BookingStatusNoShow,
+			end, start, models.BookingStatusNoShow).
+		Where("property_id = ? AND status IN ? AND checkin_date < ? AND checkout_date > ?",
+			propertyID, models.BookingAcceptedStatuses, end, start).
+		Scan(&aggregate).Error
+	return aggregate, err
+}
+
+// CountInventoryNights counts the calendar nights in [start, end) with an availability record
+func (r *AnalyticsRepository) CountInventoryNights(propertyID uint, start, end time.Time) (int, error) {
+	var count int64
+	err := r.db.Model(&models.Availability{}).
+		Where("property_id = ? AND date >= ? AND date < ?", propertyID, start, end).
+		Count(&count).Error
+	return int(count), err
+}
+
+// AverageListedRate returns the mean base price listed in [start, end)
+func (r *AnalyticsRepository) AverageListedRate(propertyID uint, start, end time.Time) (float64, error) {
+	var avg float64
+	err := r.db.Model(&models.Pricing{}).
+		Select("COALESCE(AVG(base_price), 0)").
+		Where("property_id = ? AND date >= ? AND date < ?", propertyID, start, end).
+		Scan(&avg).Error
+	return avg, err
+}
+
+// AggregateChannelPerformance groups bookings created in [start, end) by channel.
+// Revenue counts the bookings the host accepted, not pending requests nor cancelled,
+// declined or expired bookings; lead time is measured from booking to check-in.
+func (r *AnalyticsRepository) AggregateChannelPerformance(start, end time.Time, propertyID uint) ([]models.ChannelPerformance, error) {
+	query := r.db.Model(&models.Booking{}).
+		Select(`channel_id,
+			COUNT(*) AS bookings,
+			COUNT(*) FILTER (WHERE status = ?) AS cancelled_count,
+			COUNT(*) FILTER (WHERE status = ?) AS no_show_count,
+			COALESCE(SUM(total_price) FILTER (WHERE status IN ?), 0) AS revenue,
+			COALESCE(AVG(checkin_date - created_at::date), 0) AS average_lead_time`,
+			models.BookingStatusCancelled, models.BookingStatusNoShow, models.BookingAcceptedStatuses).
+		Where("created_at >= ? AND created_at < ?", start, end)
+
+	if propertyID > 0 {
+		query = query.Where("property_id = ?", propertyID)
+	}
+
+	var performance []models.ChannelPerformance
+	err := query.Group("channel_id").Order("revenue DESC").Scan(&performance).Error
+	return performance, err
+}
+
+// BookedNight is one stay night of an accepted booking and when the booking was
+// placed
+type BookedNight struct {
+	StayDate time.Time
+	BookedAt time.Time
+}
+
+// GetBookedNights lists the nights in [start, end) of accepted bookings placed before
+// asOf, one row per night
+func (r *AnalyticsRepository) GetBookedNights(propertyID uint, start, end, asOf time.Time) ([]BookedNight, error) {
+	var nights []BookedNight
+	err := r.db.Raw(`
+		SELECT night::date AS stay_date, b.created_at AS booked_at
+		FROM bookings b,
+			generate_series(GREATEST(b.checkin_date, ?::date), LEAST(b.checkout_date, ?::date) - 1, interval '1 day') AS night
+		WHERE b.property_id = ? AND b.status IN ? AND b.deleted_at IS NULL
+			AND b.checkin_date < ? AND b.checkout_date > ? AND b.created_at < ?
+		ORDER BY stay_date`,
+		start, end, propertyID, models.BookingAcceptedStatuses, end, start, asOf,
+	).Scan(&nights).Error
+	return nights, err
+}
+
+// GetClosedDates lists the nights in [start, end) whose availability is closed
+func (r *AnalyticsRepository) GetClosedDates(propertyID uint, start, end time.Time) ([]time.Time, error) {
+	var dates []time.Time
+	err := r.db.Model(&models.Availability{}).
+		Where("property_id = ? AND date >= ? AND date < ? AND available = ?", propertyID, start, end, false).
+		Order("date").
+		Pluck("date", &dates).Error
+	return dates, err
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"channelmanager/models"
+)
+
+// TestPendingRequestsExcludedFromRevenue books a property once confirmed and once on
+// request, and checks that only the confirmed stay counts in analytics and payouts
+func TestPendingRequestsExcludedFromRevenue(t *testing.T) {
+	db := openTestDB(t)
+	start := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 60)
+	property := seedBookableProperty(t, db, start, 6)
+	channel := "analytics-test"
+
+	bookings := []models.Booking{
+		{PropertyID: property.ID, ChannelID: channel, Status: models.BookingStatusConfirmed, CheckinDate: start,
+			CheckoutDate: start.AddDate(0, 0, 2), NumberOfGuests: 2, Currency: "USD", TotalPrice: 200},
+		{PropertyID: property.ID, ChannelID: channel, Status: models.BookingStatusPending, CheckinDate: start.AddDate(0, 0, 3),
+			CheckoutDate: start.AddDate(0, 0, 6), NumberOfGuests: 2, Currency: "USD", TotalPrice: 450},
+	}
+	if err := db.Create(&bookings).Error; err != nil {
+		t.Fatalf("failed to create bookings: %v", err)
+	}
+	end := start.AddDate(0, 0, 6)
+
+	analytics := NewAnalyticsRepository(db)
+	aggregate, err := analytics.AggregateBookedNights(property.ID, start, end)
+	if err != nil {
+		t.Fatalf("AggregateBookedNights() error = %v", err)
+	}
+	if aggregate.BookedNights != 2 || aggregate.BookingCount != 1 || aggregate.Revenue != 200 {
+		t.Errorf("AggregateBookedNights() = %+v, want 2 nights of 1 booking for 200", aggregate)
+	}
+
+	nights, err := analytics.GetBookedNights(property.ID, start, end, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("GetBookedNights() error = %v", err)
+	}
+	if len(nights) != 2 {
+		t.Errorf("GetBookedNights() returned %d nights, want 2", len(nights))
+	}
+
+	performance, err := analytics.AggregateChannelPerformance(time.Now().Add(-time.Hour), time.Now().Add(time.Hour), property.ID)
+	if err != nil {
+		t.Fatalf("AggregateChannelPerformance() error = %v", err)
+	}
+	if len(performance) != 1 || performance[0].Revenue != 200 {
+		t.Errorf("AggregateChannelPerformance() = %+v, want revenue 200 for %s", performance, channel)
+	}
+
+	checkingOut, err := NewLedgerRepository(db).GetBookingsCheckingOutBetween(start, end.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("GetBookingsCheckingOutBetween() error = %v", err)
+	}
+	var ids []uint
+	for _, booking := range checkingOut {
+		if booking.PropertyID == property.ID {
+			ids = append(ids, booking.ID)
+		}
+	}
+	if len(ids) != 1 || ids[0] != bookings[0].ID {
+		t.Errorf("GetBookingsCheckingOutBetween() returned bookings %v of the property, want [%d]", ids, bookings[0].ID)
+	}
+}
@@ -0,0 +1,168 @@
+package database
+
+import (
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// ARIChange sets availability and rate fields for every night of an inclusive date
+// range; nil fields are left unchanged
+type ARIChange struct {
+	StartDate time.Time
+	EndDate   time.Time
+	Available *bool
+	MinStay   *int
+	MaxGuests *int
+	BasePrice *float64
+}
+
+// ARIRepository handles bulk availability and rate updates
+type ARIRepository struct {
+	db *gorm.DB
+}
+
+// NewARIRepository creates a new ARI repository
+func NewARIRepository(db *gorm.DB) *ARIRepository {
+	return &ARIRepository{db: db}
+}
+
+// PropertyARIChanges groups ARI changes for one property within a batch
+type PropertyARIChanges struct {
+	PropertyID uint
+	Changes    []ARIChange
+}
+
+// ApplyChanges applies ARI changes to a property in one transaction, creating missing
+// availability and pricing rows and recording an event for every row written.
+// Later changes win where ranges overlap, and events carry source, one of the
+// EventSource constants. It returns the number of rows written.
+func (r *ARIRepository) ApplyChanges(propertyID uint, changes []ARIChange, source string) (int, error) {
+	written := 0
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		written, err = applyARIChanges(tx, propertyID, changes, source)
+		return err
+	})
+	return written, err
+}
+
+// ApplyBatch applies ARI changes to several properties in a single transaction, so
+// either every change is written or none is. It returns the rows written per item.
+func (r *ARIRepository) ApplyBatch(batch []PropertyARIChanges, source string) ([]int, error) {
+	written := make([]int, len(batch))
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for i, item := range batch {
+			n, err := applyARIChanges(tx, item.PropertyID, item.Changes, source)
+			if err != nil {
+				return err
+			}
+			written[i] = n
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return written, nil
+}
+
+// applyARIChanges writes ARI changes for a property within a transaction
+func applyARIChanges(tx *gorm.DB, propertyID uint, changes []ARIChange, source string) (int, error) {
+	if len(changes) == 0 {
+		return 0, nil
+	}
+
+	start, end := changes[0].StartDate, changes[0].EndDate
+	for _, change := range changes[1:] {
+		if change.StartDate.Before(start) {
+			start = change.StartDate
+		}
+		if change.EndDate.After(end) {
+			end = change.EndDate
+		}
+	}
+
+	var availabilities []models.Availability
+	if err := tx.Where("property_id = ? AND date BETWEEN ? AND ?", propertyID, start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Find(&availabilities).Error; err != nil {
+		return 0, err
+	}
+	var pricing []models.Pricing
+	if err := tx.Where("property_id = ? AND date BETWEEN ? AND ?", propertyID, start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Find(&pricing).Error; err != nil {
+		return 0, err
+	}
+
+	availabilityByDate := make(map[string]*models.Availability, len(availabilities))
+	for i := range availabilities {
+		availabilityByDate[availabilities[i].Date.Format("2006-01-02")] = &availabilities[i]
+	}
+	pricingByDate := make(map[string]*models.Pricing, len(pricing))
+	for i := range pricing {
+		pricingByDate[pricing[i].Date.Format("2006-01-02")] = &pricing[i]
+	}
+
+	changedAvailability := make(map[string]*models.Availability)
+	changedPricing := make(map[string]*models.Pricing)
+	for _, change := range changes {
+		for d := change.StartDate; !d.After(change.EndDate); d = d.AddDate(0, 0, 1) {
+			key := d.Format("2006-01-02")
+
+			if change.Available != nil || change.MinStay != nil || change.MaxGuests != nil {
+				row, ok := availabilityByDate[key]
+				if !ok {
+					row = &models.Availability{PropertyID: propertyID, Date: d, MinStay: 1}
+					availabilityByDate[key] = row
+				}
+				if change.Available != nil {
+					row.Available = *change.Available
+				}
+				if change.MinStay != nil {
+					row.MinStay = *change.MinStay
+				}
+				if change.MaxGuests != nil {
+					row.MaxGuests = *change.MaxGuests
+				}
+				changedAvailability[key] = row
+			}
+
+			if change.BasePrice != nil {
+				row, ok := pricingByDate[key]
+				if !ok {
+					row = &models.Pricing{PropertyID: propertyID, Date: d}
+					pricingByDate[key] = row
+				}
+				row.BasePrice = *change.BasePrice
+				changedPricing[key] = row
+			}
+		}
+	}
+
+	events := make([]models.Event, 0, len(changedAvailability)+len(changedPricing))
+	for _, row := range changedAvailability {
+		if err := tx.Save(row).Error; err != nil {
+			return 0, err
+		}
+		events = append(events, changeEvent("UPDATE", "availabilities", row.ID, row))
+	}
+	for _, row := range changedPricing {
+		if err := tx.Save(row).Error; err != nil {
+			return 0, err
+		}
+		events = append(events, changeEvent("UPDATE", "pricing", row.ID, row))
+	}
+
+	if len(events) == 0 {
+		return 0, nil
+	}
+	for i := range events {
+		events[i].Source = source
+	}
+	if err := tx.Create(&events).Error; err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrBlockOverlap is returned when a calendar block overlaps another block of the property
+var ErrBlockOverlap = errors.New("dates overlap an existing block")
+
+// BlockRepository handles calendar block database operations
+type BlockRepository struct {
+	db *gorm.DB
+}
+
+// NewBlockRepository creates a new calendar block repository
+func NewBlockRepository(db *gorm.DB) *BlockRepository {
+	return &BlockRepository{db: db}
+}
+
+// GetBlocksForProperty retrieves a property's blocks overlapping [start, end], or all
+// of them when the range is zero
+func (r *BlockRepository) GetBlocksForProperty(propertyID uint, start, end time.Time) ([]models.CalendarBlock, error) {
+	query := r.db.Where("property_id = ?", propertyID)
+	if !start.IsZero() && !end.IsZero() {
+		query = query.Where("start_date <= ? AND end_date >= ?", end, start)
+	}
+
+	var blocks []models.CalendarBlock
+	if err := query.Order("start_date").Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// CreateBlock creates a block and closes its open nights in one transaction, recording
+// availability events so the closed dates are pushed to channels. It returns
+// ErrNotAvailable if a night is booked and ErrBlockOverlap if another block covers one.
+func (r *BlockRepository) CreateBlock(block *models.CalendarBlock) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var overlapping int64
+		if err := tx.Model(&models.CalendarBlock{}).
+			Where("property_id = ? AND start_date <= ? AND end_date >= ?", block.PropertyID, block.EndDate, block.StartDate).
+			Count(&overlapping).Error; err != nil {
+			return err
+		}
+		if overlapping > 0 {
+			return ErrBlockOverlap
+		}
+
+		var nights []models.Availability
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("property_id = ? AND date BETWEEN ? AND ?", block.PropertyID, block.StartDate, block.EndDate).
+			Order("date").
+			Find(&nights).Error; err != nil {
+			return err
+		}
+		for _, night := range nights {
+			if night.BookingID != nil {
+				return ErrNotAvailable
+			}
+		}
+
+		if err := tx.Create(block).Error; err != nil {
+			return err
+		}
+
+		// Nights already closed stay closed when the block is removed
+		events := []models.Event{changeEvent("CREATE", "calendar_blocks", block.ID, block)}
+		for i := range nights {
+			if !nights[i].Available {
+				continue
+			}
+			nights[i].Available = false
+			nights[i].BlockID = &block.ID
+			if err := tx.Save(&nights[i]).Error; err != nil {
+				return err
+			}
+			event := changeEvent("UPDATE", "availabilities", nights[i].ID, nights[i])
+			event.Source = fmt.Sprintf("%s:%d", models.EventSourceBlock, block.ID)
+			events = append(events, event)
+		}
+
+		return tx.Create(&events).Error
+	})
+}
+
+// DeleteBlock deletes a property's block and reopens the nights it closed
+func (r *BlockRepository) DeleteBlock(propertyID, blockID uint) (bool, error) {
+	deleted := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var block models.CalendarBlock
+		if err := tx.Where("id = ? AND property_id = ?", blockID, propertyID).First(&block).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil
+			}
+			return err
+		}
+
+		var nights []models.Availability
+		if err := tx.Where("block_id = ?", block.ID).Find(&nights).Error; err != nil {
+			return err
+		}
+
+		events := []models.Event{changeEvent("DELETE", "calendar_blocks", block.ID, block)}
+		for i := range nights {
+			nights[i].Available = true
+			nights[i].BlockID = nil
+			if err := tx.Save(&nights[i]).Error; err != nil {
+				return err
+			}
+			event := changeEvent("UPDATE", "availabilities", nights[i].ID, nights[i])
+			event.Source = fmt.Sprintf("%s:%d", models.EventSourceBlock, block.ID)
+			events = append(events, event)
+		}
+
+		if err := tx.Delete(&block).Error; err != nil {
+			return err
+		}
+		deleted = true
+		return tx.Create(&events).Error
+	})
+	return deleted, err
+}
+
+// CountBlockedNights counts the nights in [start, end) covered by blocks, by reason
+func (r *BlockRepository) CountBlockedNights(propertyID uint, start, end time.Time) (map[string]int, error) {
+	var rows []struct {
+		Reason string
+		Nights int
+	}
+	err := r.db.Raw(`
+		SELECT reason, COALESCE(SUM(LEAST(end_date + 1, ?::date) - GREATEST(start_date, ?::date)), 0) AS nights
+		FROM calendar_blocks
+		WHERE property_id = ? AND start_date < ? AND end_date >= ?
+		GROUP BY reason`,
+		end, start, propertyID, end, start,
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	nights := make(map[string]int, len(rows))
+	for _, row := range rows {
+		nights[row.Reason] = row.Nights
+	}
+	return nights, nil
+}
@@ -0,0 +1,626 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"channelmanager/models"
+	"channelmanager/pii"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNotAvailable is returned when a stay includes nights that cannot be booked
+var ErrNotAvailable = errors.New("property is not available for the requested dates")
+
+// ErrNotConfirmed is returned when a booking that must be confirmed no longer is
+var ErrNotConfirmed = errors.New("booking is not confirmed")
+
+// ErrStatusChanged is returned when a booking's status changed before it could be moved on
+var ErrStatusChanged = errors.New("booking status changed")
+
+// BookingRepository handles booking database operations
+type BookingRepository struct {
+	db *gorm.DB
+}
+
+// NewBookingRepository creates a new booking repository
+func NewBookingRepository(db *gorm.DB) *BookingRepository {
+	return &BookingRepository{db: db}
+}
+
+// GetBookingByID retrieves a booking by ID
+func (r *BookingRepository) GetBookingByID(id uint) (*models.Booking, error) {
+	var booking models.Booking
+	if err := r.db.First(&booking, id).Error; err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// GetBookingWithModifications retrieves a booking with the changes its channel made to
+// it, oldest first
+func (r *BookingRepository) GetBookingWithModifications(id uint) (*models.Booking, error) {
+	var booking models.Booking
+	if err := r.db.Preload("Modifications", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at, id")
+	}).First(&booking, id).Error; err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// GetBookingByExternalReference retrieves the booking of a channel's reservation
+func (r *BookingRepository) GetBookingByExternalReference(channelID, reference string) (*models.Booking, error) {
+	var booking models.Booking
+	if err := r.db.Where("channel_id = ? AND external_reference = ?", channelID, reference).
+		Order("id").First(&booking).Error; err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// CreateBooking creates a new booking
+func (r *BookingRepository) CreateBooking(booking *models.Booking) error {
+	return r.db.Create(booking).Error
+}
+
+// CreateBookingWithInventory creates a booking and closes availability for each of its
+// nights in one transaction, recording booking and availability events. It returns
+// ErrNotAvailable if a night has no availability row, is already closed, or is taken
+// by an overlapping booking.
+//
+// The turnoverDays nights after checkout are closed as well to leave time to prepare
+// the property; it is ErrNotAvailable if another stay begins within them.
+//
+// The availability rows of the stay and its turnover are locked with SELECT ... FOR
+// UPDATE in date order, so concurrent bookings of overlapping nights run one after the
+// other and all but the first are rejected.
+func (r *BookingRepository) CreateBookingWithInventory(booking *models.Booking, turnoverDays int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		rows, err := reserveNights(tx, booking, turnoverDays)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Create(booking).Error; err != nil {
+			return err
+		}
+
+		events, err := closeNights(tx, booking.ID, rows)
+		if err != nil {
+			return err
+		}
+		events = append(events, changeEvent("CREATE", "bookings", booking.ID, booking.WithoutGuestDetails()))
+
+		return tx.Create(&events).Error
+	})
+}
+
+// Redemption is what a guest spends on a booking besides its total price
+type Redemption struct {
+	Points   int                     // loyalty points
+	Vouchers []models.AppliedVoucher // voucher balances
+}
+
+// CreateBookingRedeeming books a stay like CreateBookingWithInventory and spends the
+// guest's points and vouchers on it in the same transaction. It returns
+// ErrInsufficientPoints or ErrVoucherUnavailable if a balance no longer covers them.
+func (r *BookingRepository) CreateBookingRedeeming(booking *models.Booking, turnoverDays int, redemption Redemption) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := (&BookingRepository{db: tx}).CreateBookingWithInventory(booking, turnoverDays); err != nil {
+			return err
+		}
+		if redemption.Points > 0 {
+			if err := redeemPoints(tx, booking, redemption.Points); err != nil {
+				return err
+			}
+		}
+		return redeemVouchers(tx, booking, redemption.Vouchers)
+	})
+}
+
+// CancelBookingWithInventory cancels a booking and reopens the nights it closed, its
+// stay and turnover nights, in one transaction, recording booking and availability events.
+// It returns ErrNotConfirmed if the booking is no longer confirmed, e.g. when cancelled
+// by a concurrent request.
+func (r *BookingRepository) CancelBookingWithInventory(booking *models.Booking) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return cancelWithInventory(tx, booking)
+	})
+}
+
+// cancelWithInventory cancels a confirmed booking, reopens its nights and refunds the
+// loyalty points and vouchers spent on it within tx
+func cancelWithInventory(tx *gorm.DB, booking *models.Booking) error {
+	result := tx.Model(booking).
+		Where("status = ?", models.BookingStatusConfirmed).
+		Update("status", models.BookingStatusCancelled)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotConfirmed
+	}
+	return releaseInventory(tx, booking)
+}
+
+// releaseInventory reopens the nights of a booking that was just cancelled, declined or
+// expired, refunds the loyalty points and vouchers spent on it and records the change
+// within tx
+func releaseInventory(tx *gorm.DB, booking *models.Booking) error {
+	events, err := reopenNights(tx, booking.ID)
+	if err != nil {
+		return err
+	}
+	if err := refundPoints(tx, booking); err != nil {
+		return err
+	}
+	if err := refundVouchers(tx, booking); err != nil {
+		return err
+	}
+	events = append(events, changeEvent("UPDATE", "bookings", booking.ID, booking.WithoutGuestDetails()))
+
+	return tx.Create(&events).Error
+}
+
+// TransitionBooking moves a booking from its current status to another, stamping when
+// the guest checked in, checked out or was a no-show, and records the change. A
+// checked-out booking earns the guest its loyalty points. It returns ErrStatusChanged
+// if the booking's status changed since it was loaded.
+func (r *BookingRepository) TransitionBooking(booking *models.Booking, to string, at time.Time) error {
+	updates := map[string]interface{}{"status": to}
+	switch to {
+	case models.BookingStatusCheckedIn:
+		updates["checked_in_at"] = at
+	case models.BookingStatusCheckedOut:
+		updates["checked_out_at"] = at
+	case models.BookingStatusNoShow:
+		updates["no_show_at"] = at
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Booking{}).
+			Where("id = ? AND status = ?", booking.ID, booking.Status).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrStatusChanged
+		}
+
+		booking.Status = to
+		switch to {
+		case models.BookingStatusCheckedIn:
+			booking.CheckedInAt = &at
+		case models.BookingStatusCheckedOut:
+			booking.CheckedOutAt = &at
+		case models.BookingStatusNoShow:
+			booking.NoShowAt = &at
+		}
+		if to == models.BookingStatusCheckedOut {
+			if err := accruePoints(tx, booking); err != nil {
+				return err
+			}
+		}
+		event := changeEvent("UPDATE", "bookings", booking.ID, booking.WithoutGuestDetails())
+		return tx.Create(&event).Error
+	})
+}
+
+// ApproveBooking confirms a pending booking request, keeping the nights it holds, and
+// records the change. It returns ErrStatusChanged if the request is no longer pending
+// or expired before at.
+func (r *BookingRepository) ApproveBooking(booking *models.Booking, at time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Booking{}).
+			Where("id = ? AND status = ? AND approval_deadline > ?", booking.ID, models.BookingStatusPending, at).
+			Updates(map[string]interface{}{"status": models.BookingStatusConfirmed, "decided_at": at})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrStatusChanged
+		}
+
+		booking.Status = models.BookingStatusConfirmed
+		booking.DecidedAt = &at
+		event := changeEvent("UPDATE", "bookings", booking.ID, booking.WithoutGuestDetails())
+		return tx.Create(&event).Error
+	})
+}
+
+// ReleaseBookingRequest declines or expires a pending booking request, reopening the
+// nights it held and refunding the loyalty points and vouchers spent on it in one
+// transaction. It returns ErrStatusChanged if the request is no longer pending.
+func (r *BookingRepository) ReleaseBookingRequest(booking *models.Booking, to, reason string, at time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"status": to, "decline_reason": reason}
+		if to == models.BookingStatusDeclined {
+			updates["decided_at"] = at
+		}
+		result := tx.Model(&models.Booking{}).
+			Where("id = ? AND status = ?", booking.ID, models.BookingStatusPending).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrStatusChanged
+		}
+
+		booking.Status = to
+		booking.DeclineReason = reason
+		if to == models.BookingStatusDeclined {
+			booking.DecidedAt = &at
+		}
+		return releaseInventory(tx, booking)
+	})
+}
+
+// ExpireBookingRequests expires up to limit pending booking requests whose approval
+// deadline passed by now, reopening their nights, and returns how many it expired.
+// Requests the host answered meanwhile are left as they are.
+func (r *BookingRepository) ExpireBookingRequests(now time.Time, limit int) (int, error) {
+	var bookings []models.Booking
+	if err := r.db.Where("status = ? AND approval_deadline <= ?", models.BookingStatusPending, now).
+		Order("approval_deadline, id").Limit(limit).Find(&bookings).Error; err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range bookings {
+		err := r.ReleaseBookingRequest(&bookings[i], models.BookingStatusExpired, "", now)
+		if errors.Is(err, ErrStatusChanged) {
+			continue
+		}
+		if err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// RelocateBooking moves a confirmed booking to another property for the same dates,
+// reopening the nights it held and closing the target's nights in one transaction.
+// It returns ErrNotAvailable if the target cannot take the stay.
+func (r *BookingRepository) RelocateBooking(booking *models.Booking, targetPropertyID uint, turnoverDays int) error {
+	originalPropertyID := booking.PropertyID
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if booking.Status != models.BookingStatusConfirmed {
+			return ErrNotConfirmed
+		}
+
+		events, err := reopenNights(tx, booking.ID)
+		if err != nil {
+			return err
+		}
+
+		booking.PropertyID = targetPropertyID
+		rows, err := reserveNights(tx, booking, turnoverDays)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(booking).Update("property_id", targetPropertyID).Error; err != nil {
+			return err
+		}
+
+		closed, err := closeNights(tx, booking.ID, rows)
+		if err != nil {
+			return err
+		}
+		events = append(events, closed...)
+		events = append(events, changeEvent("UPDATE", "bookings", booking.ID, booking.WithoutGuestDetails()))
+
+		return tx.Create(&events).Error
+	})
+	if err != nil {
+		booking.PropertyID = originalPropertyID
+	}
+	return err
+}
+
+// ClaimNights closes a confirmed booking's stay nights that are still open or not tagged
+// with a booking, e.g. after another booking of the same nights was cancelled, and
+// returns how many nights changed
+func (r *BookingRepository) ClaimNights(booking *models.Booking) (int, error) {
+	claimed := 0
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var rows []models.Availability
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("property_id = ? AND date >= ? AND date < ? AND (available = ? OR booking_id IS NULL)",
+				booking.PropertyID, booking.CheckinDate.Format("2006-01-02"), booking.CheckoutDate.Format("2006-01-02"), true).
+			Order("date").
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		events, err := closeNights(tx, booking.ID, rows)
+		if err != nil {
+			return err
+		}
+		claimed = len(rows)
+		return tx.Create(&events).Error
+	})
+	return claimed, err
+}
+
+// reserveNights locks the availability rows of a booking's stay and turnover with
+// SELECT ... FOR UPDATE in date order and returns the rows to close: every stay night
+// and the turnover nights that are still open. It returns ErrNotAvailable if a stay
+// night is missing or closed, or another confirmed booking overlaps the stay or its
+// turnover.
+func reserveNights(tx *gorm.DB, booking *models.Booking, turnoverDays int) ([]models.Availability, error) {
+	stayEnd := booking.CheckoutDate.Format("2006-01-02")
+	bufferEnd := booking.CheckoutDate.AddDate(0, 0, turnoverDays).Format("2006-01-02")
+
+	var rows []models.Availability
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("property_id = ? AND date >= ? AND date < ?",
+			booking.PropertyID, booking.CheckinDate.Format("2006-01-02"), bufferEnd).
+		Order("date").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var nights, turnover []models.Availability
+	for _, row := range rows {
+		if row.Date.Format("2006-01-02") < stayEnd {
+			if !row.Available {
+				return nil, ErrNotAvailable
+			}
+			nights = append(nights, row)
+		} else if row.Available {
+			// Turnover nights that are already closed stay as they are
+			turnover = append(turnover, row)
+		}
+	}
+	if len(nights) != booking.Nights() {
+		return nil, ErrNotAvailable
+	}
+
+	// With the nights locked, no other booking can be written for them until we
+	// commit; this catches bookings whose nights were reopened by hand
+	var overlapping int64
+	if err := tx.Model(&models.Booking{}).
+		Where("property_id = ? AND id <> ? AND status IN ? AND checkin_date < ? AND checkout_date > ?",
+			booking.PropertyID, booking.ID, models.BookingHoldingStatuses, bufferEnd, booking.CheckinDate).
+		Count(&overlapping).Error; err != nil {
+		return nil, err
+	}
+	if overlapping > 0 {
+		return nil, ErrNotAvailable
+	}
+
+	return append(nights, turnover...), nil
+}
+
+// closeNights closes availability rows on behalf of a booking and returns their events
+func closeNights(tx *gorm.DB, bookingID uint, rows []models.Availability) ([]models.Event, error) {
+	events := make([]models.Event, 0, len(rows)+1)
+	for i := range rows {
+		rows[i].Available = false
+		rows[i].BookingID = &bookingID
+		rows[i].BlockID = nil // a booked night no longer reopens with its block
+		if err := tx.Save(&rows[i]).Error; err != nil {
+			return nil, err
+		}
+		event := changeEvent("UPDATE", "availabilities", rows[i].ID, rows[i])
+		event.Source = fmt.Sprintf("%s:%d", models.EventSourceBooking, bookingID)
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// reopenNights reopens the availability rows a booking closed and returns their events
+func reopenNights(tx *gorm.DB, bookingID uint) ([]models.Event, error) {
+	var rows []models.Availability
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("booking_id = ?", bookingID).Order("date").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	events := make([]models.Event, 0, len(rows)+1)
+	for i := range rows {
+		rows[i].Available = true
+		rows[i].BookingID = nil
+		if err := tx.Save(&rows[i]).Error; err != nil {
+			return nil, err
+		}
+		event := changeEvent("UPDATE", "availabilities", rows[i].ID, rows[i])
+		event.Source = fmt.Sprintf("%s:%d", models.EventSourceBooking, bookingID)
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// BookingFilter narrows a list of bookings; zero fields match every booking
+type BookingFilter struct {
+	PropertyID uint
+	ChannelID  string
+	Status     string
+	StayStart  *time.Time // only stays with a night on or after this date
+	StayEnd    *time.Time // only stays with a night on or before this date
+	GuestName  string     // part of the guest's name, in any case
+}
+
+// BookingCursor is the position of a booking in a list of bookings, newest first
+type BookingCursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// ListBookings returns one page of the bookings matching filter, newest first, with
+// the number of matching bookings
+func (r *BookingRepository) ListBookings(filter BookingFilter, limit, offset int) ([]models.Booking, int64, error) {
+	query := r.filterBookings(filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var bookings []models.Booking
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&bookings).Error
+	return bookings, total, err
+}
+
+// ListBookingsAfter returns up to limit bookings matching filter that come after the
+// cursor, newest first, or the newest ones without a cursor. Unlike pages, the position
+// holds while bookings are added.
+func (r *BookingRepository) ListBookingsAfter(filter BookingFilter, after *BookingCursor, limit int) ([]models.Booking, error) {
+	query := r.filterBookings(filter)
+	if after != nil {
+		query = query.Where("(created_at, id) < (?, ?)", after.CreatedAt, after.ID)
+	}
+
+	var bookings []models.Booking
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&bookings).Error
+	return bookings, err
+}
+
+// filterBookings builds the query of the bookings matching filter
+func (r *BookingRepository) filterBookings(filter BookingFilter) *gorm.DB {
+	query := r.db.Model(&models.Booking{})
+	if filter.PropertyID != 0 {
+		query = query.Where("property_id = ?", filter.PropertyID)
+	}
+	if filter.ChannelID != "" {
+		query = query.Where("channel_id = ?", filter.ChannelID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.StayStart != nil {
+		query = query.Where("checkout_date > ?", filter.StayStart.Format("2006-01-02"))
+	}
+	if filter.StayEnd != nil {
+		query = query.Where("checkin_date <= ?", filter.StayEnd.Format("2006-01-02"))
+	}
+	if filter.GuestName != "" {
+		query = textMatch(query, "guest_name", filter.GuestName, 0)
+	}
+	return query
+}
+
+// UpdateBooking updates a booking
+func (r *BookingRepository) UpdateBooking(booking *models.Booking) error {
+	return r.db.Save(booking).Error
+}
+
+// InvoiceRepository handles invoice database operations
+type InvoiceRepository struct {
+	db *gorm.DB
+}
+
+// NewInvoiceRepository creates a new invoice repository
+func NewInvoiceRepository(db *gorm.DB) *InvoiceRepository {
+	return &InvoiceRepository{db: db}
+}
+
+// GetInvoiceForBooking retrieves the invoice of the given type for a booking
+func (r *InvoiceRepository) GetInvoiceForBooking(bookingID uint, invoiceType string) (*models.Invoice, error) {
+	var invoice models.Invoice
+	if err := r.db.Where("booking_id = ? AND type = ?", bookingID, invoiceType).First(&invoice).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// CreateInvoice creates a new invoice record
+func (r *InvoiceRepository) CreateInvoice(invoice *models.Invoice) error {
+	return r.db.Create(invoice).Error
+}
+
+// UpdateStorageKey records where a regenerated invoice document is stored
+func (r *InvoiceRepository) UpdateStorageKey(invoice *models.Invoice, storageKey string) error {
+	return r.db.Model(invoice).Update("storage_key", storageKey).Error
+}
+
+// EncryptGuestDetails re-saves up to limit bookings, including deleted ones, whose guest
+// email or phone is not sealed with the current key or whose email has no blind index,
+// so they are encrypted with the current key. It returns the number of bookings saved.
+func (r *BookingRepository) EncryptGuestDetails(limit int) (int, error) {
+	prefix := pii.CurrentPrefix()
+	var bookings []models.Booking
+	if err := r.db.Unscoped().
+		Where("(guest_email <> '' AND LEFT(guest_email, ?) <> ?) OR (guest_phone <> '' AND LEFT(guest_phone, ?) <> ?)",
+			len(prefix), prefix, len(prefix), prefix).
+		Or("guest_email <> '' AND COALESCE(guest_email_hash, '') = ''").
+		Order("id").Limit(limit).Find(&bookings).Error; err != nil {
+		return 0, err
+	}
+
+	for i := range bookings {
+		if err := r.db.Unscoped().Model(&bookings[i]).
+			Select("guest_email", "guest_email_hash", "guest_phone").
+			Updates(&bookings[i]).Error; err != nil {
+			return i, fmt.Errorf("failed to encrypt booking %d: %w", bookings[i].ID, err)
+		}
+	}
+	return len(bookings), nil
+}
+
+// GetCalendarBookings retrieves the bookings of a property holding nights that check out
+// on or after from and check in before until, in stay order
+func (r *BookingRepository) GetCalendarBookings(propertyID uint, from, until time.Time) ([]models.Booking, error) {
+	var bookings []models.Booking
+	if err := r.db.Where("property_id = ? AND status IN ? AND checkout_date >= ? AND checkin_date < ?",
+		propertyID, models.BookingHoldingStatuses, from, until).
+		Order("checkin_date, id").Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// GetDepositsToPlace retrieves up to limit confirmed bookings with a pending security
+// deposit that check in on or before the given day and have not checked out yet
+func (r *BookingRepository) GetDepositsToPlace(checkinBy, today time.Time, limit int) ([]models.Booking, error) {
+	var bookings []models.Booking
+	if err := r.db.Where("status = ? AND deposit_status = ? AND checkin_date <= ? AND checkout_date > ?",
+		models.BookingStatusConfirmed, models.DepositStatusPending, checkinBy, today).
+		Order("checkin_date, id").Limit(limit).Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// GetDepositsToRelease retrieves up to limit bookings whose security deposit is held and
+// that checked out on or before the given day or gave their nights back
+func (r *BookingRepository) GetDepositsToRelease(checkedOutBy time.Time, limit int) ([]models.Booking, error) {
+	var bookings []models.Booking
+	if err := r.db.Where("deposit_status = ? AND (checkout_date <= ? OR status IN ?)",
+		models.DepositStatusHeld, checkedOutBy, models.BookingReleasedStatuses).
+		Order("checkout_date, id").Limit(limit).Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// CancelPendingDeposits marks the pending deposits of cancelled, declined and expired
+// bookings as never placed and returns how many there were
+func (r *BookingRepository) CancelPendingDeposits() (int64, error) {
+	result := r.db.Model(&models.Booking{}).
+		Where("status IN ? AND deposit_status = ?", models.BookingReleasedStatuses, models.DepositStatusPending).
+		Update("deposit_status", models.DepositStatusCancelled)
+	return result.RowsAffected, result.Error
+}
+
+// UpdateDeposit moves a booking's security deposit from one state to another with the
+// given changes. It returns false if the deposit was no longer in the expected state.
+func (r *BookingRepository) UpdateDeposit(id uint, from string, deposit models.BookingDeposit) (bool, error) {
+	result := r.db.Model(&models.Booking{}).
+		Where("id = ? AND deposit_status = ?", id, from).
+		Select("deposit_status", "deposit_reference", "deposit_error", "deposit_placed_at", "deposit_released_at").
+		Updates(&models.Booking{BookingDeposit: deposit})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,152 @@
+package database
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// seedBookableProperty creates a property open for the given nights from start and
+// removes it with its bookings and availability when the test ends
+func seedBookableProperty(t *testing.T, db *gorm.DB, start time.Time, nights int) *models.Property {
+	t.Helper()
+	property := &models.Property{Name: "Concurrent booking test", MaxGuests: 4}
+	if err := db.Create(property).Error; err != nil {
+		t.Fatalf("failed to create property: %v", err)
+	}
+	rows := make([]models.Availability, nights)
+	for i := range rows {
+		rows[i] = models.Availability{PropertyID: property.ID, Date: start.AddDate(0, 0, i), Available: true, MaxGuests: 4}
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatalf("failed to create availability: %v", err)
+	}
+
+	t.Cleanup(func() {
+		db.Unscoped().Where("property_id = ?", property.ID).Delete(&models.Booking{})
+		db.Unscoped().Where("property_id = ?", property.ID).Delete(&models.Availability{})
+		db.Unscoped().Delete(property)
+	})
+	return property
+}
+
+// TestCreateBookingWithInventoryConcurrent books overlapping stays of one property at
+// once and checks that exactly one of them is taken
+func TestCreateBookingWithInventoryConcurrent(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewBookingRepository(db)
+
+	start := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 30)
+	property := seedBookableProperty(t, db, start, 10)
+
+	// Every stay includes the third night
+	stays := [][2]int{{0, 3}, {1, 4}, {2, 5}, {2, 3}, {1, 3}}
+	const attempts = 25
+
+	var wg sync.WaitGroup
+	ready := make(chan struct{})
+	errs := make([]error, attempts)
+	bookings := make([]*models.Booking, attempts)
+	for i := 0; i < attempts; i++ {
+		stay := stays[i%len(stays)]
+		bookings[i] = &models.Booking{
+			PropertyID:     property.ID,
+			GuestName:      "Concurrent Guest",
+			CheckinDate:    start.AddDate(0, 0, stay[0]),
+			CheckoutDate:   start.AddDate(0, 0, stay[1]),
+			NumberOfGuests: 2,
+			Status:         models.BookingStatusConfirmed,
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-ready
+			errs[i] = repo.CreateBookingWithInventory(bookings[i], 1)
+		}(i)
+	}
+	close(ready)
+	wg.Wait()
+
+	var winner *models.Booking
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			if winner != nil {
+				t.Fatalf("bookings %d and %d of overlapping stays both succeeded", winner.ID, bookings[i].ID)
+			}
+			winner = bookings[i]
+		case !errors.Is(err, ErrNotAvailable):
+			t.Errorf("attempt %d failed with %v, want ErrNotAvailable", i, err)
+		}
+	}
+	if winner == nil {
+		t.Fatal("no booking succeeded")
+	}
+
+	var count int64
+	if err := db.Model(&models.Booking{}).Where("property_id = ?", property.ID).Count(&count).Error; err != nil {
+		t.Fatalf("failed to count bookings: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("%d bookings were stored, want 1", count)
+	}
+
+	// The stay and its turnover night are closed for the winner, the rest stay open
+	var closed []models.Availability
+	if err := db.Where("property_id = ? AND available = ?", property.ID, false).Order("date").Find(&closed).Error; err != nil {
+		t.Fatalf("failed to load availability: %v", err)
+	}
+	if want := winner.Nights() + 1; len(closed) != want {
+		t.Errorf("%d nights were closed, want %d", len(closed), want)
+	}
+	for _, row := range closed {
+		if row.BookingID == nil || *row.BookingID != winner.ID {
+			t.Errorf("night %s was closed for booking %v, want %d", row.Date.Format("2006-01-02"), row.BookingID, winner.ID)
+		}
+	}
+}
+
+// TestCreateBookingWithInventoryConcurrentDisjoint books stays that do not overlap at
+// once and checks that the locks let all of them through
+func TestCreateBookingWithInventoryConcurrentDisjoint(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewBookingRepository(db)
+
+	start := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 30)
+	property := seedBookableProperty(t, db, start, 12)
+
+	// Two-night stays with a turnover night between them
+	const stays = 4
+	var wg sync.WaitGroup
+	ready := make(chan struct{})
+	errs := make([]error, stays)
+	for i := 0; i < stays; i++ {
+		booking := &models.Booking{
+			PropertyID:     property.ID,
+			GuestName:      "Disjoint Guest",
+			CheckinDate:    start.AddDate(0, 0, 3*i),
+			CheckoutDate:   start.AddDate(0, 0, 3*i+2),
+			NumberOfGuests: 2,
+			Status:         models.BookingStatusConfirmed,
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-ready
+			errs[i] = repo.CreateBookingWithInventory(booking, 1)
+		}(i)
+	}
+	close(ready)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("stay %d failed with %v, want success", i, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// CalendarFeedRepository handles host calendar feed database operations
+type CalendarFeedRepository struct {
+	db *gorm.DB
+}
+
+// NewCalendarFeedRepository creates a new calendar feed repository
+func NewCalendarFeedRepository(db *gorm.DB) *CalendarFeedRepository {
+	return &CalendarFeedRepository{db: db}
+}
+
+// CreateFeed creates a calendar feed
+func (r *CalendarFeedRepository) CreateFeed(feed *models.CalendarFeed) error {
+	return r.db.Create(feed).Error
+}
+
+// GetFeedByID retrieves a calendar feed, revoked or not
+func (r *CalendarFeedRepository) GetFeedByID(id uint) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	if err := r.db.First(&feed, id).Error; err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// ListFeeds retrieves the calendar feeds of a property, newest first
+func (r *CalendarFeedRepository) ListFeeds(propertyID uint) ([]models.CalendarFeed, error) {
+	var feeds []models.CalendarFeed
+	if err := r.db.Where("property_id = ?", propertyID).Order("id DESC").Find(&feeds).Error; err != nil {
+		return nil, err
+	}
+	return feeds, nil
+}
+
+// RevokeFeed stops a calendar feed's URL from working
+func (r *CalendarFeedRepository) RevokeFeed(feed *models.CalendarFeed, at time.Time) error {
+	if err := r.db.Model(feed).Update("revoked_at", at).Error; err != nil {
+		return err
+	}
+	feed.RevokedAt = &at
+	return nil
+}
+
+// TouchFeed records that a calendar feed was fetched
+func (r *CalendarFeedRepository) TouchFeed(id uint, at time.Time) error {
+	return r.db.Model(&models.CalendarFeed{}).Where("id = ?", id).UpdateColumn("last_fetched_at", at).Error
+}
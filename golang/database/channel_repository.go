@@ -0,0 +1,121 @@
+package database
+
+import (
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// ChannelRepository handles channel and channel mapping database operations
+type ChannelRepository struct {
+	db *gorm.DB
+}
+
+// NewChannelRepository creates a new channel repository
+func NewChannelRepository(db *gorm.DB) *ChannelRepository {
+	return &ChannelRepository{db: db}
+}
+
+// GetAllChannels retrieves all channels
+func (r *ChannelRepository) GetAllChannels() ([]models.Channel, error) {
+	var channels []models.Channel
+	if err := r.db.Order("id").Find(&channels).Error; err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+// GetChannelByID retrieves a channel by its code
+func (r *ChannelRepository) GetChannelByID(id string) (*models.Channel, error) {
+	var channel models.Channel
+	if err := r.db.Where("id = ?", id).First(&channel).Error; err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// GetChannelsByID retrieves channels keyed by code
+func (r *ChannelRepository) GetChannelsByID() (map[string]models.Channel, error) {
+	channels, err := r.GetAllChannels()
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]models.Channel, len(channels))
+	for _, channel := range channels {
+		byID[channel.ID] = channel
+	}
+	return byID, nil
+}
+
+// CreateChannel creates a new channel
+func (r *ChannelRepository) CreateChannel(channel *models.Channel) error {
+	return r.db.Create(channel).Error
+}
+
+// UpdateChannel updates a channel
+func (r *ChannelRepository) UpdateChannel(channel *models.Channel) error {
+	return r.db.Save(channel).Error
+}
+
+// GetMappingsForChannel retrieves all property mappings of a channel
+func (r *ChannelRepository) GetMappingsForChannel(channelID string) ([]models.ChannelMapping, error) {
+	var mappings []models.ChannelMapping
+	if err := r.db.Where("channel_id = ?", channelID).Order("property_id").Find(&mappings).Error; err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
+
+// GetActiveMappingsForProperty retrieves the active channel mappings of a property
+func (r *ChannelRepository) GetActiveMappingsForProperty(propertyID uint) ([]models.ChannelMapping, error) {
+	var mappings []models.ChannelMapping
+	if err := r.db.Preload("Channel").
+		Joins("JOIN channels ON channels.id = channel_mappings.channel_id AND channels.active = ? AND channels.deleted_at IS NULL", true).
+		Where("channel_mappings.property_id = ? AND channel_mappings.active = ?", propertyID, true).
+		Find(&mappings).Error; err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
+
+// GetMappingByExternalID retrieves the active mapping of a channel's listing, identified
+// by its external property ID and, for channels with room types, external room ID
+func (r *ChannelRepository) GetMappingByExternalID(channelID, externalPropertyID, externalRoomID string) (*models.ChannelMapping, error) {
+	var mapping models.ChannelMapping
+	query := r.db.Preload("Channel").
+		Where("channel_id = ? AND external_property_id = ? AND active = ?", channelID, externalPropertyID, true)
+	if externalRoomID != "" {
+		query = query.Where("external_room_id IN ?", []string{externalRoomID, ""}).
+			Order("external_room_id DESC")
+	}
+	if err := query.First(&mapping).Error; err != nil {
+		return nil, err
+	}
+	return &mapping, nil
+}
+
+// SaveMapping creates or updates a channel mapping
+func (r *ChannelRepository) SaveMapping(mapping *models.ChannelMapping) error {
+	return r.db.Save(mapping).Error
+}
+
+// GetAdapterSpec retrieves the generic adapter definition of a channel
+func (r *ChannelRepository) GetAdapterSpec(channelID string) (*models.ChannelAdapterSpec, error) {
+	var spec models.ChannelAdapterSpec
+	if err := r.db.Where("channel_id = ?", channelID).First(&spec).Error; err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// SaveAdapterSpec creates or replaces the generic adapter definition of a channel
+func (r *ChannelRepository) SaveAdapterSpec(spec *models.ChannelAdapterSpec) error {
+	return r.db.Save(spec).Error
+}
+
+// DeleteAdapterSpec removes the generic adapter definition of a channel
+func (r *ChannelRepository) DeleteAdapterSpec(channelID string) (bool, error) {
+	result := r.db.Where("channel_id = ?", channelID).Delete(&models.ChannelAdapterSpec{})
+	return result.RowsAffected > 0, result.Error
+}
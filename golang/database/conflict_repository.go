@@ -0,0 +1,144 @@
+package database
+
+import (
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ConflictFilter holds optional filters for listing inventory conflicts
+type ConflictFilter struct {
+	PropertyID      uint
+	Type            string
+	IncludeResolved bool
+	Limit           int
+	Offset          int
+}
+
+// ConflictRepository handles inventory conflict database operations
+type ConflictRepository struct {
+	db *gorm.DB
+}
+
+// NewConflictRepository creates a new conflict repository
+func NewConflictRepository(db *gorm.DB) *ConflictRepository {
+	return &ConflictRepository{db: db}
+}
+
+// FindOverbookings returns pairs of bookings of the same property that hold their nights
+// and whose stays overlap within [start, end), the later-created booking second
+func (r *ConflictRepository) FindOverbookings(start, end time.Time) ([]models.Conflict, error) {
+	var conflicts []models.Conflict
+	err := r.db.Raw(`
+		SELECT a.property_id, a.id AS booking_id, b.id AS other_booking_id, b.channel_id,
+			GREATEST(a.checkin_date, b.checkin_date, ?::date) AS date,
+			LEAST(a.checkout_date, b.checkout_date, ?::date) - GREATEST(a.checkin_date, b.checkin_date, ?::date) AS nights
+		FROM bookings a
+		JOIN bookings b ON b.property_id = a.property_id AND b.id > a.id
+			AND b.checkin_date < a.checkout_date AND b.checkout_date > a.checkin_date
+		WHERE a.status IN ? AND b.status IN ? AND a.deleted_at IS NULL AND b.deleted_at IS NULL
+			AND GREATEST(a.checkin_date, b.checkin_date) < ? AND LEAST(a.checkout_date, b.checkout_date) > ?
+		ORDER BY a.property_id, date`,
+		start, end, start, models.BookingHoldingStatuses, models.BookingHoldingStatuses, end, start,
+	).Scan(&conflicts).Error
+	return conflicts, err
+}
+
+// FindOpenBookedNights returns bookings holding nights in [start, end) that are still
+// open for sale
+func (r *ConflictRepository) FindOpenBookedNights(start, end time.Time) ([]models.Conflict, error) {
+	var conflicts []models.Conflict
+	err := r.db.Raw(`
+		SELECT b.property_id, b.id AS booking_id, b.channel_id, MIN(av.date) AS date, COUNT(*) AS nights
+		FROM bookings b
+		JOIN availabilities av ON av.property_id = b.property_id AND av.deleted_at IS NULL
+			AND av.date >= b.checkin_date AND av.date < b.checkout_date
+		WHERE b.status IN ? AND b.deleted_at IS NULL AND av.available = ?
+			AND av.date >= ? AND av.date < ?
+		GROUP BY b.property_id, b.id, b.channel_id
+		ORDER BY b.property_id, date`,
+		models.BookingHoldingStatuses, true, start, end,
+	).Scan(&conflicts).Error
+	return conflicts, err
+}
+
+// UpsertConflict records a conflict or refreshes it, reopening it if it was resolved
+func (r *ConflictRepository) UpsertConflict(conflict *models.Conflict) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "type"}, {Name: "booking_id"}, {Name: "other_booking_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"property_id":     conflict.PropertyID,
+			"channel_id":      conflict.ChannelID,
+			"date":            conflict.Date,
+			"nights":          conflict.Nights,
+			"status":          models.ConflictStatusOpen,
+			"resolution":      "",
+			"last_checked_at": conflict.LastCheckedAt,
+			"resolved_at":     nil,
+		}),
+	}).Create(conflict).Error
+}
+
+// ClearStaleConflicts resolves open conflicts not seen by a check that started at checkedAt
+func (r *ConflictRepository) ClearStaleConflicts(checkedAt time.Time) (int64, error) {
+	result := r.db.Model(&models.Conflict{}).
+		Where("status = ? AND last_checked_at < ?", models.ConflictStatusOpen, checkedAt).
+		Updates(map[string]interface{}{
+			"status":      models.ConflictStatusResolved,
+			"resolution":  models.ConflictResolutionCleared,
+			"resolved_at": checkedAt,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// GetConflictByID retrieves a conflict by ID
+func (r *ConflictRepository) GetConflictByID(id uint) (*models.Conflict, error) {
+	var conflict models.Conflict
+	if err := r.db.First(&conflict, id).Error; err != nil {
+		return nil, err
+	}
+	return &conflict, nil
+}
+
+// ResolveConflict marks a conflict as resolved with the given resolution
+func (r *ConflictRepository) ResolveConflict(conflict *models.Conflict, resolution string) error {
+	now := time.Now()
+	conflict.Status = models.ConflictStatusResolved
+	conflict.Resolution = resolution
+	conflict.ResolvedAt = &now
+	return r.db.Model(conflict).Updates(map[string]interface{}{
+		"status":      conflict.Status,
+		"resolution":  conflict.Resolution,
+		"resolved_at": conflict.ResolvedAt,
+	}).Error
+}
+
+// ListConflicts lists conflicts matching the filter, earliest affected night first
+func (r *ConflictRepository) ListConflicts(filter ConflictFilter) ([]models.Conflict, int64, error) {
+	query := r.db.Model(&models.Conflict{})
+	if filter.PropertyID > 0 {
+		query = query.Where("property_id = ?", filter.PropertyID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if !filter.IncludeResolved {
+		query = query.Where("status = ?", models.ConflictStatusOpen)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var conflicts []models.Conflict
+	if err := query.Order("date, id").
+		Limit(filter.Limit).Offset(filter.Offset).
+		Find(&conflicts).Error; err != nil {
+		return nil, 0, err
+	}
+	return conflicts, total, nil
+}
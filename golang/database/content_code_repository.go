@@ -0,0 +1,73 @@
+package database
+
+import (
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ContentCodeFilter holds filters for listing content code mappings
+type ContentCodeFilter struct {
+	RecordType string
+	ChannelID  *string // nil for all channels, empty for OTA standard codes only
+}
+
+// ContentCodeRepository handles amenity/condition code mapping database operations
+type ContentCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewContentCodeRepository creates a new content code repository
+func NewContentCodeRepository(db *gorm.DB) *ContentCodeRepository {
+	return &ContentCodeRepository{db: db}
+}
+
+// ListMappings retrieves content code mappings matching the filter
+func (r *ContentCodeRepository) ListMappings(filter ContentCodeFilter) ([]models.ContentCodeMapping, error) {
+	query := r.db.Model(&models.ContentCodeMapping{})
+	if filter.RecordType != "" {
+		query = query.Where("record_type = ?", filter.RecordType)
+	}
+	if filter.ChannelID != nil {
+		query = query.Where("channel_id = ?", *filter.ChannelID)
+	}
+
+	var mappings []models.ContentCodeMapping
+	if err := query.Order("record_type, record_id, channel_id").Find(&mappings).Error; err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
+
+// GetMappingsForChannel retrieves the OTA standard mappings together with the
+// channel's own overrides
+func (r *ContentCodeRepository) GetMappingsForChannel(channelID string) ([]models.ContentCodeMapping, error) {
+	var mappings []models.ContentCodeMapping
+	if err := r.db.Where("channel_id IN ?", []string{"", channelID}).Find(&mappings).Error; err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
+
+// GetMappingByID retrieves a content code mapping by ID
+func (r *ContentCodeRepository) GetMappingByID(id uint) (*models.ContentCodeMapping, error) {
+	var mapping models.ContentCodeMapping
+	if err := r.db.First(&mapping, id).Error; err != nil {
+		return nil, err
+	}
+	return &mapping, nil
+}
+
+// UpsertMapping creates or replaces the code of a record for a channel
+func (r *ContentCodeRepository) UpsertMapping(mapping *models.ContentCodeMapping) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "record_type"}, {Name: "record_id"}, {Name: "channel_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"code_list", "code", "updated_at"}),
+	}).Create(mapping).Error
+}
+
+// DeleteMapping deletes a content code mapping
+func (r *ContentCodeRepository) DeleteMapping(id uint) error {
+	return r.db.Delete(&models.ContentCodeMapping{}, id).Error
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CredentialRepository handles channel credential database operations
+type CredentialRepository struct {
+	db *gorm.DB
+}
+
+// NewCredentialRepository creates a new credential repository
+func NewCredentialRepository(db *gorm.DB) *CredentialRepository {
+	return &CredentialRepository{db: db}
+}
+
+// GetCredentials retrieves a channel's credentials that are still accepted at now, the
+// active one first
+func (r *CredentialRepository) GetCredentials(channelID string, now time.Time) ([]models.ChannelCredential, error) {
+	var credentials []models.ChannelCredential
+	err := r.db.Where("channel_id = ? AND (status = ? OR (status = ? AND retires_at > ?))",
+		channelID, models.CredentialStatusActive, models.CredentialStatusPrevious, now).
+		Order("version DESC").
+		Find(&credentials).Error
+	return credentials, err
+}
+
+// RotateCredential stores credential as the channel's active credential. The credential
+// it replaces stays accepted until retiresAt, so requests in flight and replicas still
+// holding it keep working.
+func (r *CredentialRepository) RotateCredential(credential *models.ChannelCredential, retiresAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var current []models.ChannelCredential
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("channel_id = ?", credential.ChannelID).
+			Order("version DESC").
+			Find(&current).Error; err != nil {
+			return err
+		}
+
+		credential.Version = 1
+		if len(current) > 0 {
+			credential.Version = current[0].Version + 1
+		}
+		credential.Status = models.CredentialStatusActive
+
+		if err := tx.Model(&models.ChannelCredential{}).
+			Where("channel_id = ? AND status = ?", credential.ChannelID, models.CredentialStatusActive).
+			Updates(map[string]interface{}{"status": models.CredentialStatusPrevious, "retires_at": retiresAt}).Error; err != nil {
+			return err
+		}
+		return tx.Create(credential).Error
+	})
+}
+
+// RefreshCredential locks a credential and calls refresh with it, saving its token
+// fields and the error of a failed refresh when refresh returns. The lock keeps replicas
+// from refreshing the same token at once, which channels rotating refresh tokens reject.
+func (r *CredentialRepository) RefreshCredential(id uint, refresh func(credential *models.ChannelCredential) error) (*models.ChannelCredential, error) {
+	var credential models.ChannelCredential
+	var refreshErr error
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&credential, id).Error; err != nil {
+			return err
+		}
+		refreshErr = refresh(&credential)
+		return tx.Select("access_token", "refresh_token", "token_expires_at", "last_refreshed_at", "refresh_error", "updated_at").
+			Save(&credential).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &credential, refreshErr
+}
+
+// GetExpiringCredentials retrieves the accepted OAuth credentials whose access token
+// is missing or expires before the given time
+func (r *CredentialRepository) GetExpiringCredentials(before time.Time) ([]models.ChannelCredential, error) {
+	var credentials []models.ChannelCredential
+	err := r.db.Where("type = ? AND (status = ? OR (status = ? AND retires_at > ?))",
+		models.CredentialOAuth2, models.CredentialStatusActive, models.CredentialStatusPrevious, time.Now()).
+		Where("access_token = '' OR access_token IS NULL OR token_expires_at < ?", before).
+		Order("id").
+		Find(&credentials).Error
+	return credentials, err
+}
+
+// DeleteRetiredCredentials deletes the rotated-out credentials retired before the
+// given time and returns how many were deleted
+func (r *CredentialRepository) DeleteRetiredCredentials(before time.Time) (int64, error) {
+	result := r.db.Where("status = ? AND retires_at <= ?", models.CredentialStatusPrevious, before).
+		Delete(&models.ChannelCredential{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"channelmanager/testenv"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// containers is the PostgreSQL started in Docker for the package's tests when no
+// TEST_DATABASE_URL is set, shared by them and removed once they finish
+var containers struct {
+	once sync.Once
+	env  *testenv.Env
+	err  error
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if containers.env != nil {
+		containers.env.Close()
+	}
+	os.Exit(code)
+}
+
+// testDSN returns the connection string of the test database: TEST_DATABASE_URL, or a
+// PostgreSQL container started on first use. The test is skipped when neither is
+// available.
+func testDSN(t *testing.T) string {
+	t.Helper()
+	if dsn := os.Getenv("TEST_DATABASE_URL"); dsn != "" {
+		return dsn
+	}
+	containers.once.Do(func() {
+		containers.env, containers.err = testenv.Start()
+	})
+	if errors.Is(containers.err, testenv.ErrDockerUnavailable) {
+		t.Skipf("TEST_DATABASE_URL is not set and %v", containers.err)
+	}
+	if containers.err != nil {
+		t.Fatalf("failed to start test containers: %v", containers.err)
+	}
+	return containers.env.PostgresDSN()
+}
+
+// connectTestDB connects to a PostgreSQL database and closes it when the test ends
+func connectTestDB(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// openTestDB connects to the test database and migrates it. The database of
+// TEST_DATABASE_URL must be dedicated to tests; without one, a container is used.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := connectTestDB(t, testDSN(t))
+	if err := runMigrations(db); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	return db
+}
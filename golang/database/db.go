@@ -1,13 +1,18 @@
 package database
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"time"
 
 	"channelmanager/models"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -49,6 +54,7 @@ func InitializeDatabase(config Config) (*gorm.DB, error) {
 	if err := runMigrations(db); err != nil {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
+	enableTrigramSearch(db)
 
 	log.Println("Database initialized successfully")
 	return db, nil
@@ -64,9 +70,86 @@ func runMigrations(db *gorm.DB) error {
 		&models.Availability{},
 		&models.Pricing{},
 		&models.Event{},
+		&models.Organization{},
+		&models.Booking{},
+		&models.Invoice{},
+		&models.LedgerEntry{},
+		&models.PayoutStatement{},
+		&models.ChannelRate{},
+		&models.RateParityViolation{},
+		&models.Channel{},
+		&models.ChannelMapping{},
+		&models.LengthOfStayDiscount{},
+		&models.Fee{},
+		&models.TaxRule{},
+		&models.Season{},
+		&models.ContentCodeMapping{},
+		&models.PropertyTranslation{},
+		&models.ReferenceTranslation{},
+		&models.PropertyPhoto{},
+		&models.Favorite{},
+		&models.CalendarBlock{},
+		&models.Conflict{},
+		&models.SyncLog{},
+		&models.ResyncJob{},
+		&models.DryRunPayload{},
+		&models.JobRun{},
+		&models.GuestErasure{},
+		&models.PropertyStatDay{},
+		&models.PricingRevision{},
+		&models.AvailabilityRevision{},
+		&models.ChannelCredential{},
+		&models.ChannelAdapterSpec{},
+		&models.InboundReservation{},
+		&models.BookingModification{},
+		&models.HostNotification{},
+		&models.LoyaltyProgram{},
+		&models.LoyaltyAccount{},
+		&models.LoyaltyEntry{},
+		&models.Voucher{},
+		&models.VoucherEntry{},
+		&models.ExchangeRate{},
+		&models.DestinationEvent{},
+		&models.PointOfInterest{},
+		&models.DuplicateCandidate{},
+		&models.Owner{},
+		&models.TeamMember{},
+		&models.TeamGrant{},
+		&models.OrganizationSettings{},
+		&models.CalendarFeed{},
 	)
 }
 
+// propertyToday returns the SQL expression of the current calendar day in the time zone
+// of the properties row named table
+func propertyToday(table string) string {
+	return "(NOW() AT TIME ZONE COALESCE(NULLIF(" + table + ".timezone, ''), 'UTC'))::date"
+}
+
+// trigramSearch is set when pg_trgm is installed, enabling fuzzy location matching
+var trigramSearch bool
+
+// enableTrigramSearch installs pg_trgm and indexes location and city for similarity
+// matching. Without the extension, searches fall back to substring matching.
+func enableTrigramSearch(db *gorm.DB) {
+	statements := []string{
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		"CREATE INDEX IF NOT EXISTS idx_properties_location_trgm ON properties USING gin (location gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_properties_city_trgm ON properties USING gin (city gin_trgm_ops)",
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			log.Printf("Warning: fuzzy location search disabled: %v", err)
+			return
+		}
+	}
+	trigramSearch = true
+}
+
+// ErrPropertyStatusChanged is returned when a property's status changed before it could be
+// moved on
+var ErrPropertyStatusChanged = errors.New("property status changed")
+
 // PropertyRepository handles property database operations
 type PropertyRepository struct {
 	db *gorm.DB
@@ -80,12 +163,156 @@ func NewPropertyRepository(db *gorm.DB) *PropertyRepository {
 // GetPropertyByID retrieves a property by ID
 func (r *PropertyRepository) GetPropertyByID(id uint) (*models.Property, error) {
 	var property models.Property
-	if err := r.db.Preload("Amenities").Preload("Conditions").First(&property, id).Error; err != nil {
+	if err := r.db.Preload("Amenities").Preload("Conditions").Preload("Photos", orderPhotos).Preload("StarRating").First(&property, id).Error; err != nil {
 		return nil, err
 	}
 	return &property, nil
 }
 
+// GetPropertyStatus retrieves the lifecycle status of a property
+func (r *PropertyRepository) GetPropertyStatus(id uint) (string, error) {
+	var property models.Property
+	if err := r.db.Select("id", "status").First(&property, id).Error; err != nil {
+		return "", err
+	}
+	return property.Status, nil
+}
+
+// GetPropertiesWithContent retrieves properties with amenities, conditions and photos,
+// restricted to the given IDs unless ids is nil
+func (r *PropertyRepository) GetPropertiesWithContent(ids []uint) ([]models.Property, error) {
+	query := r.db.Preload("Amenities").Preload("Conditions").Preload("Photos", orderPhotos).Preload("StarRating").Order("id")
+	if ids != nil {
+		query = query.Where("id IN ?", ids)
+	}
+
+	var properties []models.Property
+	if err := query.Find(&properties).Error; err != nil {
+		return nil, err
+	}
+	return properties, nil
+}
+
+// GetPropertyIDs retrieves the IDs of every property in ascending order
+func (r *PropertyRepository) GetPropertyIDs() ([]uint, error) {
+	var ids []uint
+	if err := r.db.Model(&models.Property{}).Order("id").Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetRatedPropertyIDsInCity retrieves the IDs of the properties of a city whose pricing is
+// materialized from a base nightly rate; an empty country matches every country
+func (r *PropertyRepository) GetRatedPropertyIDsInCity(city, country string) ([]uint, error) {
+	query := r.db.Model(&models.Property{}).Where("LOWER(city) = LOWER(?) AND base_nightly_rate > 0", city)
+	if country != "" {
+		query = query.Where("LOWER(country) = LOWER(?)", country)
+	}
+
+	var ids []uint
+	if err := query.Order("id").Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// orderPhotos preloads photos in display order
+func orderPhotos(db *gorm.DB) *gorm.DB {
+	return db.Order("sort_order, id")
+}
+
+// UpdatePropertyRates updates the default rates used to materialize pricing
+func (r *PropertyRepository) UpdatePropertyRates(id uint, baseNightlyRate, weekendMultiplier float64) error {
+	return r.db.Model(&models.Property{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"base_nightly_rate":  baseNightlyRate,
+		"weekend_multiplier": weekendMultiplier,
+	}).Error
+}
+
+// UpdateTurnoverDays sets the nights closed after each checkout
+func (r *PropertyRepository) UpdateTurnoverDays(id uint, days int) error {
+	return r.db.Model(&models.Property{}).Where("id = ?", id).Update("turnover_days", days).Error
+}
+
+// UpdateCheckinTimes sets the arrival and departure times and the same-day turnover rule
+func (r *PropertyRepository) UpdateCheckinTimes(id uint, times models.CheckinTimes) error {
+	return r.db.Model(&models.Property{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"checkin_from":      times.CheckinFrom,
+		"checkin_until":     times.CheckinUntil,
+		"checkout_until":    times.CheckoutUntil,
+		"same_day_turnover": times.SameDayTurnover,
+	}).Error
+}
+
+// UpdateHouseRules sets the structured house rules
+func (r *PropertyRepository) UpdateHouseRules(id uint, rules models.HouseRules) error {
+	// Updating from a struct runs the value through the column's JSON serializer
+	return r.db.Model(&models.Property{ID: id}).Select("rules").Updates(&models.Property{Rules: &rules}).Error
+}
+
+// UpdateOccupancyPricing sets the base occupancy and extra guest fees
+func (r *PropertyRepository) UpdateOccupancyPricing(id uint, pricing models.OccupancyPricing) error {
+	return r.db.Model(&models.Property{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"base_occupancy":  pricing.BaseOccupancy,
+		"extra_adult_fee": pricing.ExtraAdultFee,
+		"extra_child_fee": pricing.ExtraChildFee,
+	}).Error
+}
+
+// UpdateDeposit sets the security deposit required of new bookings
+func (r *PropertyRepository) UpdateDeposit(id uint, amount float64, mode string) error {
+	return r.db.Model(&models.Property{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"deposit_amount": amount,
+		"deposit_mode":   mode,
+	}).Error
+}
+
+// UpdateBookingMode sets whether the property's bookings are instant or requests
+func (r *PropertyRepository) UpdateBookingMode(id uint, mode string) error {
+	return r.db.Model(&models.Property{}).Where("id = ?", id).Update("booking_mode", mode).Error
+}
+
+// UpdateTimezone sets the property's IANA time zone
+func (r *PropertyRepository) UpdateTimezone(id uint, timezone string) error {
+	return r.db.Model(&models.Property{}).Where("id = ?", id).Update("timezone", timezone).Error
+}
+
+// UpdateBookingWindow sets how far ahead a property can be booked
+func (r *PropertyRepository) UpdateBookingWindow(id uint, minAdvanceDays, maxAdvanceDays int) error {
+	return r.db.Model(&models.Property{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"min_advance_days": minAdvanceDays,
+		"max_advance_days": maxAdvanceDays,
+	}).Error
+}
+
+// TransitionProperty moves a property to another lifecycle status with the reason given,
+// if it still has the status it was loaded with, and records the change. It returns
+// ErrPropertyStatusChanged otherwise.
+func (r *PropertyRepository) TransitionProperty(property *models.Property, to, reason string, at time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Property{}).
+			Where("id = ? AND status = ?", property.ID, property.Status).
+			Updates(map[string]interface{}{
+				"status":            to,
+				"status_reason":     reason,
+				"status_changed_at": at,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPropertyStatusChanged
+		}
+
+		property.Status = to
+		property.StatusReason = reason
+		property.StatusChangedAt = &at
+		event := changeEvent("UPDATE", "properties", property.ID, property)
+		return tx.Create(&event).Error
+	})
+}
+
 // GetPropertiesByLocation retrieves properties by location with filtering
 func (r *PropertyRepository) GetPropertiesByLocation(location string, limit int, offset int) ([]models.Property, int64, error) {
 	var properties []models.Property
@@ -122,113 +349,380 @@ func (r *PropertyRepository) GetPropertiesByCity(city string, limit int, offset
 
 // SearchProperties performs a complex search with multiple filters
 func (r *PropertyRepository) SearchProperties(filter models.SearchFilter) ([]models.Property, int64, error) {
-	query := r.db
+	query := r.searchQuery(filter)
 
-	// Location filter
-	if filter.Location != "" {
-		query = query.Where("location ILIKE ?", "%"+filter.Location+"%")
+	// Count total
+	var total int64
+	if err := query.Model(&models.Property{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Sorting
+	query = searchOrder(query, filter)
+
+	// Pagination
+	page := filter.Page
+	if page < 1 {
+		page = 1
+	}
+	limit := filter.Limit
+	if limit < 1 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	// Execute query
+	var properties []models.Property
+	if err := query.
+		Preload("Amenities").
+		Preload("Conditions").
+		Preload("StarRating").
+		Limit(limit).
+		Offset(offset).
+		Find(&properties).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return properties, total, nil
+}
+
+// SearchCandidates retrieves up to max properties matching a search, best rated first,
+// for searches ranked after loading such as sort_by=relevance. It also returns the
+// total number of matches.
+func (r *PropertyRepository) SearchCandidates(filter models.SearchFilter, max int) ([]models.Property, int64, error) {
+	query := r.searchQuery(filter)
+
+	var total int64
+	if err := query.Model(&models.Property{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var properties []models.Property
+	if err := query.
+		Order("rating DESC, id").
+		Preload("Amenities").
+		Preload("Conditions").
+		Preload("StarRating").
+		Limit(max).
+		Find(&properties).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return properties, total, nil
+}
+
+// textMatch filters column by a case-insensitive substring match, or by trigram
+// similarity of at least threshold when fuzzy matching is available
+func textMatch(query *gorm.DB, column, value string, threshold float64) *gorm.DB {
+	pattern := "%" + value + "%"
+	if !trigramSearch || threshold <= 0 {
+		return query.Where(column+" ILIKE ?", pattern)
+	}
+	return query.Where("("+column+" ILIKE ? OR similarity("+column+", ?) >= ?)", pattern, value, threshold)
+}
+
+// searchQuery applies the filters of a search
+func (r *PropertyRepository) searchQuery(filter models.SearchFilter) *gorm.DB {
+	return applyClauses(r.db, searchClauses(r.db, filter), "")
+}
+
+// MinStayExclusions returns up to limit properties matching every other filter of a
+// search whose arrival night requires a longer stay than the one searched
+func (r *PropertyRepository) MinStayExclusions(filter models.SearchFilter, limit int) ([]models.SearchExclusion, error) {
+	nights := filter.Nights()
+	if nights == 0 {
+		return nil, nil
+	}
+
+	var rows []struct {
+		PropertyID uint
+		MinStay    int
+	}
+	if err := applyClauses(r.db, searchClauses(r.db, filter), clauseMinStay).Model(&models.Property{}).
+		Joins("JOIN availabilities arrival ON arrival.property_id = properties.id AND arrival.date = ?::date AND arrival.deleted_at IS NULL",
+			filter.CheckinDate).
+		Where("arrival.min_stay > ?", nights).
+		Select("properties.id AS property_id, arrival.min_stay").
+		Group("properties.id, arrival.min_stay").
+		Order("properties.id").
+		Limit(limit).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	exclusions := make([]models.SearchExclusion, 0, len(rows))
+	for _, row := range rows {
+		exclusions = append(exclusions, models.SearchExclusion{
+			PropertyID: row.PropertyID,
+			Reason:     models.ExclusionMinStay,
+			Message: fmt.Sprintf("stays arriving on %s must be at least %d nights",
+				filter.CheckinDate.Format("2006-01-02"), row.MinStay),
+			Details: map[string]interface{}{"min_stay": row.MinStay, "nights": nights},
+		})
+	}
+	return exclusions, nil
+}
+
+// PriceRange aggregates the nightly prices of the properties matching a search, other
+// than its price range, in one query: for a stay, each property's average total price
+// over its nights, leaving out properties with unpriced nights; without dates, the base
+// nightly rates. It returns nil when no matching property is priced.
+func (r *PropertyRepository) PriceRange(filter models.SearchFilter) (*models.PriceRange, error) {
+	matching := applyClauses(r.db.Model(&models.Property{}), searchClauses(r.db, filter), clausePrice).
+		Select("properties.id")
+
+	var prices *gorm.DB
+	if nights := filter.Nights(); nights > 0 {
+		prices = r.db.Model(&models.Pricing{}).
+			Select("AVG(total_price) AS price").
+			Where("property_id IN (?) AND date >= ?::date AND date < ?::date", matching, filter.CheckinDate, filter.CheckoutDate).
+			Group("property_id").
+			Having("COUNT(DISTINCT date) = ?", nights)
+	} else {
+		prices = r.db.Model(&models.Property{}).
+			Select("base_nightly_rate AS price").
+			Where("id IN (?)", matching)
+	}
+
+	var priceRange models.PriceRange
+	if err := r.db.Table("(?) AS prices", prices).
+		Select("COALESCE(MIN(price), 0) AS min, COALESCE(MAX(price), 0) AS max, COALESCE(AVG(price), 0) AS average, COUNT(*) AS properties").
+		Scan(&priceRange).Error; err != nil {
+		return nil, err
+	}
+	if priceRange.Properties == 0 {
+		return nil, nil
+	}
+	return &priceRange, nil
+}
+
+// searchClause is one filter of a search, named so explained searches can tell which
+// filters a property fails
+type searchClause struct {
+	name  string
+	apply func(query *gorm.DB) *gorm.DB
+}
+
+// Search clause names
+const (
+	clauseStatus          = "status"
+	clauseLocation        = "location"
+	clauseCity            = "city"
+	clauseFavorites       = "favorites"
+	clauseGuests          = "guests"
+	clausePrice           = "price"
+	clauseRating          = "min_rating"
+	clauseStars           = "star_ratings"
+	clauseAmenities       = "amenities"
+	clauseConditions      = "conditions"
+	clausePetFriendly     = "pet_friendly"
+	clauseSmokingFriendly = "smoking_friendly"
+	clauseAvailability    = "availability"
+	clauseBookingWindow   = "booking_window"
+	clauseTurnover        = "turnover"
+	clauseMinStay         = "min_stay"
+	clauseDistance        = "distance"
+)
+
+// applyClauses applies search clauses to query, leaving out the one named skip
+func applyClauses(query *gorm.DB, clauses []searchClause, skip string) *gorm.DB {
+	for _, clause := range clauses {
+		if clause.name != skip {
+			query = clause.apply(query)
+		}
+	}
+	return query
+}
+
+// searchClauses returns the filters a search applies, in order
+func searchClauses(db *gorm.DB, filter models.SearchFilter) []searchClause {
+	var clauses []searchClause
+	add := func(name string, apply func(query *gorm.DB) *gorm.DB) {
+		clauses = append(clauses, searchClause{name: name, apply: apply})
 	}
 
-	// City filter
+	// Only listed properties are searchable
+	add(clauseStatus, func(query *gorm.DB) *gorm.DB {
+		return query.Where("properties.status = ?", models.PropertyStatusActive)
+	})
+
+	// Location and city filters; substring matches always qualify, and with pg_trgm
+	// so do values similar enough to tolerate typos
+	if filter.Location != "" {
+		add(clauseLocation, func(query *gorm.DB) *gorm.DB {
+			return textMatch(query, "location", filter.Location, filter.LocationSimilarity)
+		})
+	}
 	if filter.City != "" {
-		query = query.Where("city ILIKE ?", "%"+filter.City+"%")
+		add(clauseCity, func(query *gorm.DB) *gorm.DB {
+			return textMatch(query, "city", filter.City, filter.LocationSimilarity)
+		})
+	}
+
+	// Favorites filter
+	if filter.OnlyFavorites {
+		add(clauseFavorites, func(query *gorm.DB) *gorm.DB {
+			return query.Where("properties.id IN ?", filter.FavoriteIDs)
+		})
 	}
 
 	// Guest count filter
 	if filter.NumberOfGuests > 0 {
-		query = query.Where("max_guests >= ?", filter.NumberOfGuests)
+		add(clauseGuests, func(query *gorm.DB) *gorm.DB {
+			return query.Where("properties.max_guests >= ?", filter.NumberOfGuests)
+		})
 	}
 
 	// Price range filter
 	if filter.MinPrice > 0 || filter.MaxPrice > 0 {
-		query = query.Joins("LEFT JOIN pricing ON pricing.property_id = properties.id").
-			Where("pricing.total_price BETWEEN ? AND ?", filter.MinPrice, filter.MaxPrice)
+		add(clausePrice, func(query *gorm.DB) *gorm.DB {
+			return query.Joins("LEFT JOIN pricing ON pricing.property_id = properties.id").
+				Where("pricing.total_price BETWEEN ? AND ?", filter.MinPrice, filter.MaxPrice)
+		})
 	}
 
 	// Rating filter
 	if filter.MinRating > 0 {
-		query = query.Where("rating >= ?", filter.MinRating)
+		add(clauseRating, func(query *gorm.DB) *gorm.DB {
+			return query.Where("rating >= ?", filter.MinRating)
+		})
 	}
 
-	// Amenities filter
-	if len(filter.AmenityIDs) > 0 {
-		query = query.Joins("LEFT JOIN property_amenities ON property_amenities.property_id = properties.id").
-			Where("property_amenities.amenity_id IN ?", filter.AmenityIDs).
-			Distinct()
+	// Star class filter
+	if len(filter.StarRatings) > 0 {
+		add(clauseStars, func(query *gorm.DB) *gorm.DB {
+			return query.Where("rating_id IN (?)", db.Model(&models.PropertyRating{}).
+				Select("id").
+				Where("stars IN ?", filter.StarRatings))
+		})
+	}
+
+	// Amenities filter: any of the amenities, or all of them with match_all_amenities
+	if len(filter.AmenityIDs) > 0 && filter.MatchAllAmenities {
+		amenityIDs := uniqueIDs(filter.AmenityIDs)
+		add(clauseAmenities, func(query *gorm.DB) *gorm.DB {
+			return query.Where("properties.id IN (?)", db.Table("property_amenities").
+				Select("property_id").
+				Where("amenity_id IN ?", amenityIDs).
+				Group("property_id").
+				Having("COUNT(DISTINCT amenity_id) = ?", len(amenityIDs)))
+		})
+	} else if len(filter.AmenityIDs) > 0 {
+		add(clauseAmenities, func(query *gorm.DB) *gorm.DB {
+			return query.Joins("LEFT JOIN property_amenities ON property_amenities.property_id = properties.id").
+				Where("property_amenities.amenity_id IN ?", filter.AmenityIDs).
+				Distinct()
+		})
 	}
 
 	// Conditions filter (pet-friendly, smoking-friendly, etc.)
 	if len(filter.ConditionIDs) > 0 {
-		query = query.Joins("LEFT JOIN property_conditions ON property_conditions.property_id = properties.id").
-			Where("property_conditions.condition_id IN ?", filter.ConditionIDs).
-			Distinct()
+		add(clauseConditions, func(query *gorm.DB) *gorm.DB {
+			return query.Joins("LEFT JOIN property_conditions ON property_conditions.property_id = properties.id").
+				Where("property_conditions.condition_id IN ?", filter.ConditionIDs).
+				Distinct()
+		})
 	}
 
 	// Specific condition filters
 	if filter.PetFriendly != nil && *filter.PetFriendly {
-		query = query.Joins("LEFT JOIN property_conditions pc ON pc.property_id = properties.id").
-			Joins("LEFT JOIN conditions c ON c.id = pc.condition_id").
-			Where("c.type = ? AND c.name ILIKE ?", "pets", "%friendly%")
+		add(clausePetFriendly, func(query *gorm.DB) *gorm.DB {
+			return query.Joins("LEFT JOIN property_conditions pet_pc ON pet_pc.property_id = properties.id").
+				Joins("LEFT JOIN conditions pet_c ON pet_c.id = pet_pc.condition_id").
+				Where("pet_c.type = ? AND pet_c.name ILIKE ? AND pet_c.deleted_at IS NULL", "pets", "%friendly%")
+		})
 	}
 
 	if filter.SmokingFriendly != nil && *filter.SmokingFriendly {
-		query = query.Joins("LEFT JOIN property_conditions pc ON pc.property_id = properties.id").
-			Joins("LEFT JOIN conditions c ON c.id = pc.condition_id").
-			Where("c.type = ? AND c.name ILIKE ?", "smoking", "%friendly%")
+		add(clauseSmokingFriendly, func(query *gorm.DB) *gorm.DB {
+			return query.Joins("LEFT JOIN property_conditions smoking_pc ON smoking_pc.property_id = properties.id").
+				Joins("LEFT JOIN conditions smoking_c ON smoking_c.id = smoking_pc.condition_id").
+				Where("smoking_c.type = ? AND smoking_c.name ILIKE ? AND smoking_c.deleted_at IS NULL", "smoking", "%friendly%")
+		})
 	}
 
 	// Availability filter for date range
 	if !filter.CheckinDate.IsZero() && !filter.CheckoutDate.IsZero() {
-		query = query.Joins("LEFT JOIN availabilities ON availabilities.property_id = properties.id").
-			Where("availabilities.date BETWEEN ? AND ? AND availabilities.available = ?",
-				filter.CheckinDate, filter.CheckoutDate, true)
+		add(clauseAvailability, func(query *gorm.DB) *gorm.DB {
+			return query.Joins("LEFT JOIN availabilities ON availabilities.property_id = properties.id").
+				Where("availabilities.date BETWEEN ? AND ? AND availabilities.available = ?",
+					filter.CheckinDate, filter.CheckoutDate, true)
+		})
+
+		// Check-in must not have passed and must fall within the booking window, both
+		// counted from the property's today
+		add(clauseBookingWindow, func(query *gorm.DB) *gorm.DB {
+			today := propertyToday("properties")
+			return query.Where(`?::date >= `+today+`
+			AND (properties.min_advance_days = 0 OR ?::date >= `+today+` + properties.min_advance_days)
+			AND (properties.max_advance_days = 0 OR ?::date <= `+today+` + properties.max_advance_days)`,
+				filter.CheckinDate, filter.CheckinDate, filter.CheckinDate)
+		})
+
+		// The stay's turnover nights must not run into the next arrival
+		add(clauseTurnover, func(query *gorm.DB) *gorm.DB {
+			return query.Where(`NOT EXISTS (
+			SELECT 1 FROM bookings b
+			WHERE b.property_id = properties.id AND b.status IN ? AND b.deleted_at IS NULL
+				AND b.checkin_date >= ?::date AND b.checkin_date < ?::date + GREATEST(properties.turnover_days,
+					CASE WHEN properties.same_day_turnover THEN 0 ELSE 1 END))`,
+				models.BookingHoldingStatuses, filter.CheckoutDate, filter.CheckoutDate)
+		})
+	}
+
+	// The stay must last at least the minimum stay of its arrival night
+	if nights := filter.Nights(); nights > 0 {
+		add(clauseMinStay, func(query *gorm.DB) *gorm.DB {
+			return query.Where(`NOT EXISTS (
+			SELECT 1 FROM availabilities arrival
+			WHERE arrival.property_id = properties.id AND arrival.date = ?::date
+				AND arrival.deleted_at IS NULL AND arrival.min_stay > ?)`,
+				filter.CheckinDate, nights)
+		})
 	}
 
 	// Distance filter (if coordinates provided)
 	if filter.Latitude != nil && filter.Longitude != nil && filter.RadiusKm > 0 {
 		// Using PostgreSQL PostGIS distance calculation
-		query = query.Where(
-			"earth_distance(ll_to_earth(latitude, longitude), ll_to_earth(?, ?)) / 1000 <= ?",
-			*filter.Latitude, *filter.Longitude, filter.RadiusKm,
-		)
-	}
-
-	// Count total
-	var total int64
-	if err := query.Model(&models.Property{}).Count(&total).Error; err != nil {
-		return nil, 0, err
+		add(clauseDistance, func(query *gorm.DB) *gorm.DB {
+			return query.Where(
+				"earth_distance(ll_to_earth(latitude, longitude), ll_to_earth(?, ?)) / 1000 <= ?",
+				*filter.Latitude, *filter.Longitude, filter.RadiusKm,
+			)
+		})
 	}
 
-	// Sorting
-	sortBy := "rating"
-	if filter.SortBy != "" {
-		sortBy = filter.SortBy
-	}
-	query = query.Order(sortBy + " DESC")
+	return clauses
+}
 
-	// Pagination
-	page := filter.Page
-	if page < 1 {
-		page = 1
+// uniqueIDs returns the IDs without duplicates, in their original order
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	unique := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
 	}
-	limit := filter.Limit
-	if limit < 1 {
-		limit = 20
-	}
-	offset := (page - 1) * limit
+	return unique
+}
 
-	// Execute query
-	var properties []models.Property
-	if err := query.
-		Preload("Amenities").
-		Preload("Conditions").
-		Limit(limit).
-		Offset(offset).
-		Find(&properties).Error; err != nil {
-		return nil, 0, err
+// searchOrder applies the sort order of a search
+func searchOrder(query *gorm.DB, filter models.SearchFilter) *gorm.DB {
+	switch filter.SortBy {
+	case "price":
+		return query.Order("base_nightly_rate ASC")
+	case "distance":
+		if filter.Latitude != nil && filter.Longitude != nil {
+			return query.Clauses(clause.OrderBy{Expression: clause.Expr{
+				SQL:                "earth_distance(ll_to_earth(latitude, longitude), ll_to_earth(?, ?)) ASC",
+				Vars:               []interface{}{*filter.Latitude, *filter.Longitude},
+				WithoutParentheses: true,
+			}})
+		}
 	}
-
-	return properties, total, nil
+	return query.Order("rating DESC")
 }
 
 // AvailabilityRepository handles availability database operations
@@ -251,6 +745,58 @@ func (r *AvailabilityRepository) GetAvailabilityForDateRange(propertyID uint, st
 	return availabilities, nil
 }
 
+// GetAvailabilityForProperties retrieves in one query the availability of several
+// properties for a date range, keyed by property. Properties that do not exist are left
+// out, and properties without availability rows in the range map to no nights.
+func (r *AvailabilityRepository) GetAvailabilityForProperties(propertyIDs []uint, startDate, endDate string) (map[uint][]models.Availability, error) {
+	var rows []struct {
+		PropertyID uint
+		Date       *time.Time
+		Available  *bool
+		MinStay    *int
+	}
+	if err := r.db.Table("properties").
+		Select("properties.id AS property_id, availabilities.date, availabilities.available, availabilities.min_stay").
+		Joins("LEFT JOIN availabilities ON availabilities.property_id = properties.id AND availabilities.deleted_at IS NULL AND availabilities.date BETWEEN ? AND ?", startDate, endDate).
+		Where("properties.id IN ? AND properties.deleted_at IS NULL", propertyIDs).
+		Order("properties.id, availabilities.date").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	availability := make(map[uint][]models.Availability)
+	for _, row := range rows {
+		nights := availability[row.PropertyID]
+		if row.Date != nil {
+			night := models.Availability{PropertyID: row.PropertyID, Date: *row.Date}
+			if row.Available != nil {
+				night.Available = *row.Available
+			}
+			if row.MinStay != nil {
+				night.MinStay = *row.MinStay
+			}
+			nights = append(nights, night)
+		}
+		availability[row.PropertyID] = nights
+	}
+	return availability, nil
+}
+
+// GetMinStay returns the minimum stay of stays arriving on date, 0 when the night has no
+// availability row
+func (r *AvailabilityRepository) GetMinStay(propertyID uint, date time.Time) (int, error) {
+	var minStay []int
+	if err := r.db.Model(&models.Availability{}).
+		Where("property_id = ? AND date = ?", propertyID, date.Format("2006-01-02")).
+		Limit(1).Pluck("min_stay", &minStay).Error; err != nil {
+		return 0, err
+	}
+	if len(minStay) == 0 {
+		return 0, nil
+	}
+	return minStay[0], nil
+}
+
 // UpdateAvailability updates availability for a property
 func (r *AvailabilityRepository) UpdateAvailability(availability *models.Availability) error {
 	return r.db.Save(availability).Error
@@ -258,7 +804,7 @@ func (r *AvailabilityRepository) UpdateAvailability(availability *models.Availab
 
 // BulkUpdateAvailability updates multiple availabilities
 func (r *AvailabilityRepository) BulkUpdateAvailability(availabilities []models.Availability) error {
-	return r.db.SaveInBatches(availabilities, 100).Error
+	return r.db.Save(&availabilities).Error
 }
 
 // PricingRepository handles pricing database operations
@@ -286,6 +832,27 @@ func (r *PricingRepository) UpdatePricing(pricing *models.Pricing) error {
 	return r.db.Save(pricing).Error
 }
 
+// SavePricing creates or updates multiple pricing rows in a transaction
+func (r *PricingRepository) SavePricing(pricing []models.Pricing) error {
+	if len(pricing) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range pricing {
+			if err := tx.Save(&pricing[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ReferenceGroup is an amenity category or condition type with its member count
+type ReferenceGroup struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
 // AmenityRepository handles amenity database operations
 type AmenityRepository struct {
 	db *gorm.DB
@@ -314,6 +881,72 @@ func (r *AmenityRepository) GetAmenitiesByCategory(category string) ([]models.Am
 	return amenities, nil
 }
 
+// GetAmenityByID retrieves an amenity by ID
+func (r *AmenityRepository) GetAmenityByID(id uint) (*models.Amenity, error) {
+	var amenity models.Amenity
+	if err := r.db.First(&amenity, id).Error; err != nil {
+		return nil, err
+	}
+	return &amenity, nil
+}
+
+// AmenityNameTaken reports whether another amenity already uses the name (case-insensitive)
+func (r *AmenityRepository) AmenityNameTaken(name string, excludeID uint) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Amenity{}).
+		Where("LOWER(name) = LOWER(?) AND id <> ?", name, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CreateAmenity creates a new amenity
+func (r *AmenityRepository) CreateAmenity(amenity *models.Amenity) error {
+	return r.db.Create(amenity).Error
+}
+
+// UpdateAmenity saves changes to an amenity
+func (r *AmenityRepository) UpdateAmenity(amenity *models.Amenity) error {
+	return r.db.Save(amenity).Error
+}
+
+// GetPropertyIDsForAmenity retrieves the IDs of properties offering an amenity
+func (r *AmenityRepository) GetPropertyIDsForAmenity(id uint) ([]uint, error) {
+	var propertyIDs []uint
+	err := r.db.Table("property_amenities").Where("amenity_id = ?", id).Pluck("property_id", &propertyIDs).Error
+	return propertyIDs, err
+}
+
+// DeleteAmenity soft-deletes an amenity, returning the IDs of the properties it is
+// attached to. Properties stop showing it but keep the link, so restoring the amenity
+// brings it back on them; purging it removes the links.
+func (r *AmenityRepository) DeleteAmenity(id uint) ([]uint, error) {
+	var propertyIDs []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("property_amenities").Where("amenity_id = ?", id).Pluck("property_id", &propertyIDs).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Amenity{}, id).Error
+	})
+	return propertyIDs, err
+}
+
+// GetAmenityCategories retrieves amenity categories with the number of amenities in each
+func (r *AmenityRepository) GetAmenityCategories() ([]ReferenceGroup, error) {
+	var groups []ReferenceGroup
+	err := r.db.Model(&models.Amenity{}).
+		Select("category AS name, COUNT(*) AS count").
+		Group("category").
+		Order("category").
+		Scan(&groups).Error
+	return groups, err
+}
+
+// RenameAmenityCategory moves every amenity in a category to a new category name
+func (r *AmenityRepository) RenameAmenityCategory(from, to string) (int64, error) {
+	result := r.db.Model(&models.Amenity{}).Where("category = ?", from).Update("category", to)
+	return result.RowsAffected, result.Error
+}
+
 // ConditionRepository handles condition database operations
 type ConditionRepository struct {
 	db *gorm.DB
@@ -342,6 +975,72 @@ func (r *ConditionRepository) GetConditionsByType(condType string) ([]models.Con
 	return conditions, nil
 }
 
+// GetConditionByID retrieves a condition by ID
+func (r *ConditionRepository) GetConditionByID(id uint) (*models.Condition, error) {
+	var condition models.Condition
+	if err := r.db.First(&condition, id).Error; err != nil {
+		return nil, err
+	}
+	return &condition, nil
+}
+
+// ConditionNameTaken reports whether another condition already uses the name (case-insensitive)
+func (r *ConditionRepository) ConditionNameTaken(name string, excludeID uint) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Condition{}).
+		Where("LOWER(name) = LOWER(?) AND id <> ?", name, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CreateCondition creates a new condition
+func (r *ConditionRepository) CreateCondition(condition *models.Condition) error {
+	return r.db.Create(condition).Error
+}
+
+// UpdateCondition saves changes to a condition
+func (r *ConditionRepository) UpdateCondition(condition *models.Condition) error {
+	return r.db.Save(condition).Error
+}
+
+// GetPropertyIDsForCondition retrieves the IDs of properties carrying a condition
+func (r *ConditionRepository) GetPropertyIDsForCondition(id uint) ([]uint, error) {
+	var propertyIDs []uint
+	err := r.db.Table("property_conditions").Where("condition_id = ?", id).Pluck("property_id", &propertyIDs).Error
+	return propertyIDs, err
+}
+
+// DeleteCondition soft-deletes a condition, returning the IDs of the properties it is
+// attached to. Properties stop showing it but keep the link, so restoring the condition
+// brings it back on them; purging it removes the links.
+func (r *ConditionRepository) DeleteCondition(id uint) ([]uint, error) {
+	var propertyIDs []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("property_conditions").Where("condition_id = ?", id).Pluck("property_id", &propertyIDs).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Condition{}, id).Error
+	})
+	return propertyIDs, err
+}
+
+// GetConditionTypes retrieves condition types with the number of conditions of each
+func (r *ConditionRepository) GetConditionTypes() ([]ReferenceGroup, error) {
+	var groups []ReferenceGroup
+	err := r.db.Model(&models.Condition{}).
+		Select("type AS name, COUNT(*) AS count").
+		Group("type").
+		Order("type").
+		Scan(&groups).Error
+	return groups, err
+}
+
+// RenameConditionType moves every condition of a type to a new type name
+func (r *ConditionRepository) RenameConditionType(from, to string) (int64, error) {
+	result := r.db.Model(&models.Condition{}).Where("type = ?", from).Update("type", to)
+	return result.RowsAffected, result.Error
+}
+
 // EventRepository handles event database operations
 type EventRepository struct {
 	db *gorm.DB
@@ -352,20 +1051,96 @@ func NewEventRepository(db *gorm.DB) *EventRepository {
 	return &EventRepository{db: db}
 }
 
+// changeEvent builds an event carrying a JSON snapshot of the changed record
+func changeEvent(eventType, table string, recordID uint, record interface{}) models.Event {
+	data, _ := json.Marshal(record)
+	return models.Event{EventType: eventType, TableName: table, RecordID: recordID, Data: data}
+}
+
 // CreateEvent creates a new event
 func (r *EventRepository) CreateEvent(event *models.Event) error {
 	return r.db.Create(event).Error
 }
 
-// GetUnprocessedEvents retrieves unprocessed events
-func (r *EventRepository) GetUnprocessedEvents(limit int) ([]models.Event, error) {
+// CreateEvents creates multiple events
+func (r *EventRepository) CreateEvents(events []models.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(events, 100).Error
+}
+
+// ClaimUnprocessedEvents claims up to limit unprocessed events, oldest first, for owner
+// until the lease ends. Events claimed by another replica are skipped without waiting,
+// so replicas process disjoint batches; a claim that expires, e.g. because its replica
+// stopped, is taken over.
+func (r *EventRepository) ClaimUnprocessedEvents(owner string, limit int, lease time.Duration) ([]models.Event, error) {
+	var events []models.Event
+	err := r.db.Raw(`
+		UPDATE events SET claimed_by = ?, claimed_until = NOW() + ? * INTERVAL '1 second'
+		WHERE id IN (
+			SELECT id FROM events
+			WHERE processed = false AND (claimed_until IS NULL OR claimed_until < NOW())
+			ORDER BY id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, owner, lease.Seconds(), limit).Scan(&events).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
+}
+
+// ReplayEvents resets the events created in [from, to), of the given tables or all tables,
+// to unprocessed so the event listeners handle them again, and returns their count
+func (r *EventRepository) ReplayEvents(from, to time.Time, tables []string) (int64, error) {
+	query := r.db.Model(&models.Event{}).Where("created_at >= ? AND created_at < ?", from, to)
+	if len(tables) > 0 {
+		query = query.Where("table_name IN ?", tables)
+	}
+	result := query.Updates(map[string]interface{}{
+		"processed":     false,
+		"claimed_by":    "",
+		"claimed_until": nil,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// GetProcessedEventsBefore retrieves up to limit processed events created before cutoff, oldest first
+func (r *EventRepository) GetProcessedEventsBefore(cutoff time.Time, limit int) ([]models.Event, error) {
 	var events []models.Event
-	if err := r.db.Where("processed = ?", false).Limit(limit).Find(&events).Error; err != nil {
+	if err := r.db.Where("processed = ? AND created_at < ?", true, cutoff).
+		Order("id").Limit(limit).Find(&events).Error; err != nil {
 		return nil, err
 	}
 	return events, nil
 }
 
+// DeleteEvents permanently deletes events by ID
+func (r *EventRepository) DeleteEvents(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Where("id IN ?", ids).Delete(&models.Event{}).Error
+}
+
+// GetPendingPropertyIDs retrieves which of the given properties have unprocessed
+// property, amenity or condition change events, whose caches may not be invalidated yet
+func (r *EventRepository) GetPendingPropertyIDs(propertyIDs []uint) ([]uint, error) {
+	var pending []uint
+	if len(propertyIDs) == 0 {
+		return pending, nil
+	}
+	err := r.db.Model(&models.Event{}).
+		Where("processed = ? AND table_name IN ? AND record_id IN ?", false,
+			[]string{"properties", "property_amenities", "property_conditions"}, propertyIDs).
+		Distinct().Pluck("record_id", &pending).Error
+	return pending, err
+}
+
 // MarkEventAsProcessed marks an event as processed
 func (r *EventRepository) MarkEventAsProcessed(eventID uint) error {
 	return r.db.Model(&models.Event{}).Where("id = ?", eventID).Update("processed", true).Error
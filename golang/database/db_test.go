@@ -0,0 +1,48 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// BenchmarkSearchQuery measures building the SQL of searches with the filters used most,
+// without a database: the statements are generated in dry-run mode
+func BenchmarkSearchQuery(b *testing.B) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=bench"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		b.Fatalf("failed to open dry-run database: %v", err)
+	}
+	repo := NewPropertyRepository(db)
+
+	checkin := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 14)
+	latitude, longitude := 40.7128, -74.0060
+	yes := true
+	filters := []models.SearchFilter{
+		{City: "New York", NumberOfGuests: 2, SortBy: "rating"},
+		{City: "New York", CheckinDate: checkin, CheckoutDate: checkin.AddDate(0, 0, 3), NumberOfGuests: 2, SortBy: "price"},
+		{Location: "Malibu, CA", CheckinDate: checkin, CheckoutDate: checkin.AddDate(0, 0, 5), NumberOfGuests: 4,
+			PetFriendly: &yes, StarRatings: []int{4, 5}, MinPrice: 100, MaxPrice: 400, SortBy: "rating"},
+		{Latitude: &latitude, Longitude: &longitude, RadiusKm: 5, CheckinDate: checkin, CheckoutDate: checkin.AddDate(0, 0, 2),
+			NumberOfGuests: 2, AmenityIDs: []int64{1, 3, 7}, MatchAllAmenities: true, MinRating: 4, SortBy: "distance"},
+	}
+	for i := range filters {
+		filters[i].Page, filters[i].Limit = 1, 20
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var properties []models.Property
+		repo.searchQuery(filters[i%len(filters)]).Find(&properties)
+	}
+}
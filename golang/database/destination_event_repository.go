@@ -0,0 +1,148 @@
+package database
+
+import (
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// DestinationEventRepository handles destination event database operations
+type DestinationEventRepository struct {
+	db *gorm.DB
+}
+
+// NewDestinationEventRepository creates a new destination event repository
+func NewDestinationEventRepository(db *gorm.DB) *DestinationEventRepository {
+	return &DestinationEventRepository{db: db}
+}
+
+// DestinationEventImport summarizes the changes an import made to a city's events
+type DestinationEventImport struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Removed int `json:"removed"`
+
+	// Start and End span every night whose events changed; both are zero without changes
+	Start time.Time `json:"-"`
+	End   time.Time `json:"-"`
+}
+
+// cover extends the changed span to the nights of an event
+func (i *DestinationEventImport) cover(event models.DestinationEvent) {
+	if i.Start.IsZero() || event.StartDate.Before(i.Start) {
+		i.Start = event.StartDate
+	}
+	if i.End.IsZero() || event.EndDate.After(i.End) {
+		i.End = event.EndDate
+	}
+}
+
+// ListDestinationEvents retrieves the events of a city overlapping [start, end], by start
+// date; a non-empty eventType restricts them to holidays or events
+func (r *DestinationEventRepository) ListDestinationEvents(city, eventType string, start, end time.Time) ([]models.DestinationEvent, error) {
+	query := r.db.Where("LOWER(city) = LOWER(?) AND start_date <= ? AND end_date >= ?", city, end, start)
+	if eventType != "" {
+		query = query.Where("type = ?", eventType)
+	}
+
+	var events []models.DestinationEvent
+	if err := query.Order("start_date, id").Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetDestinationEventsForProperty retrieves the events overlapping [start, end] in the
+// city of a property
+func (r *DestinationEventRepository) GetDestinationEventsForProperty(property models.Property, start, end time.Time) ([]models.DestinationEvent, error) {
+	var events []models.DestinationEvent
+	if err := r.db.Where("LOWER(city) = LOWER(?) AND start_date <= ? AND end_date >= ?", property.City, end, start).
+		Where("(country = '' OR LOWER(country) = LOWER(?))", property.Country).
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetDestinationEventByID retrieves a destination event by ID
+func (r *DestinationEventRepository) GetDestinationEventByID(id uint) (*models.DestinationEvent, error) {
+	var event models.DestinationEvent
+	if err := r.db.First(&event, id).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// CreateDestinationEvent creates a new destination event
+func (r *DestinationEventRepository) CreateDestinationEvent(event *models.DestinationEvent) error {
+	return r.db.Create(event).Error
+}
+
+// UpdateDestinationEvent updates a destination event
+func (r *DestinationEventRepository) UpdateDestinationEvent(event *models.DestinationEvent) error {
+	return r.db.Save(event).Error
+}
+
+// DeleteDestinationEvent deletes a destination event
+func (r *DestinationEventRepository) DeleteDestinationEvent(id uint) error {
+	return r.db.Delete(&models.DestinationEvent{}, id).Error
+}
+
+// ImportDestinationEvents makes a city's events from a calendar match the calendar's
+// current events, matched by UID. Names and dates of known events are refreshed while
+// their type and demand multiplier, which admins may have tuned, are kept; events gone
+// from the calendar are removed.
+func (r *DestinationEventRepository) ImportDestinationEvents(city, sourceURL string, events []models.DestinationEvent) (DestinationEventImport, error) {
+	var result DestinationEventImport
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var existing []models.DestinationEvent
+		if err := tx.Where("LOWER(city) = LOWER(?) AND source_url = ?", city, sourceURL).
+			Find(&existing).Error; err != nil {
+			return err
+		}
+		byUID := make(map[string]models.DestinationEvent, len(existing))
+		for _, event := range existing {
+			byUID[event.ExternalUID] = event
+		}
+
+		for _, event := range events {
+			current, ok := byUID[event.ExternalUID]
+			delete(byUID, event.ExternalUID)
+			if !ok {
+				if err := tx.Create(&event).Error; err != nil {
+					return err
+				}
+				result.Created++
+				result.cover(event)
+				continue
+			}
+
+			if current.Name == event.Name && current.Country == event.Country &&
+				current.StartDate.Equal(event.StartDate) && current.EndDate.Equal(event.EndDate) {
+				continue
+			}
+			result.cover(current)
+			current.Name = event.Name
+			current.Country = event.Country
+			current.StartDate = event.StartDate
+			current.EndDate = event.EndDate
+			if err := tx.Save(&current).Error; err != nil {
+				return err
+			}
+			result.Updated++
+			result.cover(current)
+		}
+
+		for _, gone := range byUID {
+			if err := tx.Delete(&models.DestinationEvent{}, gone.ID).Error; err != nil {
+				return err
+			}
+			result.Removed++
+			result.cover(gone)
+		}
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// DiscountRepository handles length-of-stay discount database operations
+type DiscountRepository struct {
+	db *gorm.DB
+}
+
+// NewDiscountRepository creates a new discount repository
+func NewDiscountRepository(db *gorm.DB) *DiscountRepository {
+	return &DiscountRepository{db: db}
+}
+
+// GetDiscountsForProperty retrieves a property's discount tiers ordered by minimum nights
+func (r *DiscountRepository) GetDiscountsForProperty(propertyID uint) ([]models.LengthOfStayDiscount, error) {
+	var discounts []models.LengthOfStayDiscount
+	if err := r.db.Where("property_id = ?", propertyID).Order("min_nights").Find(&discounts).Error; err != nil {
+		return nil, err
+	}
+	return discounts, nil
+}
+
+// ReplaceDiscountsForProperty replaces all discount tiers of a property in a transaction
+func (r *DiscountRepository) ReplaceDiscountsForProperty(propertyID uint, discounts []models.LengthOfStayDiscount) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("property_id = ?", propertyID).Delete(&models.LengthOfStayDiscount{}).Error; err != nil {
+			return err
+		}
+		if len(discounts) == 0 {
+			return nil
+		}
+		for i := range discounts {
+			discounts[i].ID = 0
+			discounts[i].PropertyID = propertyID
+		}
+		return tx.Create(&discounts).Error
+	})
+}
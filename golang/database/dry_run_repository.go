@@ -0,0 +1,53 @@
+package database
+
+import (
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// DryRunRepository handles dry-run payload database operations
+type DryRunRepository struct {
+	db *gorm.DB
+}
+
+// NewDryRunRepository creates a new dry-run payload repository
+func NewDryRunRepository(db *gorm.DB) *DryRunRepository {
+	return &DryRunRepository{db: db}
+}
+
+// CreatePayload stores a rendered payload
+func (r *DryRunRepository) CreatePayload(payload *models.DryRunPayload) error {
+	return r.db.Create(payload).Error
+}
+
+// DryRunFilter narrows the dry-run payloads listed for a channel
+type DryRunFilter struct {
+	PropertyID uint
+	Operation  string
+	Limit      int
+	Offset     int
+}
+
+// ListPayloads retrieves a channel's rendered payloads, newest first, with the total count
+func (r *DryRunRepository) ListPayloads(channelID string, filter DryRunFilter) ([]models.DryRunPayload, int64, error) {
+	query := r.db.Model(&models.DryRunPayload{}).Where("channel_id = ?", channelID)
+	if filter.PropertyID != 0 {
+		query = query.Where("property_id = ?", filter.PropertyID)
+	}
+	if filter.Operation != "" {
+		query = query.Where("operation = ?", filter.Operation)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var payloads []models.DryRunPayload
+	if err := query.Order("created_at DESC, id DESC").Limit(filter.Limit).Offset(filter.Offset).
+		Find(&payloads).Error; err != nil {
+		return nil, 0, err
+	}
+	return payloads, total, nil
+}
@@ -0,0 +1,262 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrMergeConflict is returned when merging properties whose bookings overlap
+var ErrMergeConflict = errors.New("bookings of the properties overlap")
+
+// DuplicateConfig holds duplicate listing detection configuration
+type DuplicateConfig struct {
+	Schedule          string  // when likely duplicates are flagged
+	MaxDistanceMeters float64 // listings further apart are distinct
+	MinNameSimilarity float64 // trigram similarity of the names, between 0 and 1
+}
+
+// metersPerDegree is the length of a degree of latitude, used to narrow candidate pairs
+const metersPerDegree = 111320.0
+
+// DuplicateRepository handles duplicate property detection and merging
+type DuplicateRepository struct {
+	db *gorm.DB
+}
+
+// NewDuplicateRepository creates a new duplicate repository
+func NewDuplicateRepository(db *gorm.DB) *DuplicateRepository {
+	return &DuplicateRepository{db: db}
+}
+
+// FlagDuplicates queues the pairs of located properties from different channels within
+// maxDistanceMeters of each other whose names are at least minNameSimilarity alike, and
+// returns the number of new pairs. Pairs already queued, whatever their status, are left
+// as they are. Without pg_trgm names must match exactly.
+func (r *DuplicateRepository) FlagDuplicates(maxDistanceMeters, minNameSimilarity float64) (int64, error) {
+	nameScore := "CASE WHEN LOWER(a.name) = LOWER(b.name) THEN 1 ELSE 0 END"
+	if trigramSearch {
+		nameScore = "similarity(LOWER(a.name), LOWER(b.name))"
+	}
+
+	result := r.db.Exec(`
+		INSERT INTO duplicate_candidates (property_id, duplicate_id, distance_meters, name_similarity, status, created_at, updated_at)
+		SELECT property_id, duplicate_id, ROUND(distance::numeric, 1)::float8, ROUND(score::numeric, 4)::float8, ?, NOW(), NOW()
+		FROM (
+			SELECT a.id AS property_id, b.id AS duplicate_id,
+				earth_distance(ll_to_earth(a.latitude, a.longitude), ll_to_earth(b.latitude, b.longitude)) AS distance,
+				`+nameScore+` AS score
+			FROM properties a
+			JOIN properties b ON b.id > a.id AND b.deleted_at IS NULL
+				AND COALESCE(b.channel_id, '') <> COALESCE(a.channel_id, '')
+				AND b.latitude BETWEEN a.latitude - ? AND a.latitude + ?
+				AND (b.latitude <> 0 OR b.longitude <> 0)
+			WHERE a.deleted_at IS NULL AND (a.latitude <> 0 OR a.longitude <> 0)
+		) pairs
+		WHERE distance <= ? AND score >= ?
+		ON CONFLICT (property_id, duplicate_id) DO NOTHING`,
+		models.DuplicateStatusPending, maxDistanceMeters/metersPerDegree, maxDistanceMeters/metersPerDegree,
+		maxDistanceMeters, minNameSimilarity)
+	return result.RowsAffected, result.Error
+}
+
+// ListDuplicateCandidates retrieves queued pairs with both properties, most alike names
+// first, optionally of one status
+func (r *DuplicateRepository) ListDuplicateCandidates(status string, limit, offset int) ([]models.DuplicateCandidate, int64, error) {
+	query := r.db.Model(&models.DuplicateCandidate{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var candidates []models.DuplicateCandidate
+	if err := query.Preload("Property").Preload("Duplicate").
+		Order("name_similarity DESC, distance_meters, id").
+		Limit(limit).Offset(offset).
+		Find(&candidates).Error; err != nil {
+		return nil, 0, err
+	}
+	return candidates, total, nil
+}
+
+// GetDuplicateCandidateByID retrieves a queued pair by ID
+func (r *DuplicateRepository) GetDuplicateCandidateByID(id uint) (*models.DuplicateCandidate, error) {
+	var candidate models.DuplicateCandidate
+	if err := r.db.First(&candidate, id).Error; err != nil {
+		return nil, err
+	}
+	return &candidate, nil
+}
+
+// ResolveDuplicateCandidate records an admin's decision on a queued pair
+func (r *DuplicateRepository) ResolveDuplicateCandidate(candidate *models.DuplicateCandidate, status string) error {
+	now := time.Now()
+	candidate.Status = status
+	candidate.ResolvedAt = &now
+	return r.db.Model(candidate).Updates(map[string]interface{}{
+		"status":      status,
+		"resolved_at": now,
+	}).Error
+}
+
+// MergeProperties consolidates a duplicate listing onto a surviving property in one
+// transaction and soft-deletes the duplicate:
+//   - its bookings and their ledger entries move to the survivor, whose nights they
+//     occupy are closed; it is ErrMergeConflict if they overlap the survivor's bookings
+//   - its channel mappings move to the survivor, except for channels the survivor is
+//     already mapped to, whose mappings are dropped
+//   - its reviews count towards the survivor's rating
+//
+// Queued pairs of the two are marked merged; other pairs of the duplicate are removed.
+func (r *DuplicateRepository) MergeProperties(survivorID, duplicateID uint) (*models.PropertyMerge, error) {
+	merge := &models.PropertyMerge{SurvivorID: survivorID, DuplicateID: duplicateID}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Both properties are locked in ID order so concurrent merges do not deadlock
+		var properties []models.Property
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", []uint{survivorID, duplicateID}).Order("id").
+			Find(&properties).Error; err != nil {
+			return err
+		}
+		if len(properties) != 2 {
+			return gorm.ErrRecordNotFound
+		}
+		survivor, duplicate := properties[0], properties[1]
+		if survivor.ID != survivorID {
+			survivor, duplicate = duplicate, survivor
+		}
+
+		var overlapping int64
+		if err := tx.Table("bookings d").
+			Joins("JOIN bookings s ON s.property_id = ? AND s.status IN ? AND s.deleted_at IS NULL AND s.checkin_date < d.checkout_date AND s.checkout_date > d.checkin_date",
+				survivorID, models.BookingHoldingStatuses).
+			Where("d.property_id = ? AND d.status IN ? AND d.deleted_at IS NULL", duplicateID, models.BookingHoldingStatuses).
+			Count(&overlapping).Error; err != nil {
+			return err
+		}
+		if overlapping > 0 {
+			return ErrMergeConflict
+		}
+
+		events, err := closeMergedNights(tx, survivorID, duplicateID)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if event.TableName == "availabilities" {
+				merge.NightsClosed++
+			}
+		}
+
+		moved := tx.Model(&models.Booking{}).Where("property_id = ?", duplicateID).Update("property_id", survivorID)
+		if moved.Error != nil {
+			return moved.Error
+		}
+		merge.BookingsMoved = moved.RowsAffected
+		if err := tx.Model(&models.LedgerEntry{}).Where("property_id = ?", duplicateID).
+			Update("property_id", survivorID).Error; err != nil {
+			return err
+		}
+
+		// The unique index on channel and property covers soft-deleted mappings as well
+		moved = tx.Model(&models.ChannelMapping{}).
+			Where("property_id = ? AND channel_id NOT IN (?)", duplicateID,
+				tx.Unscoped().Model(&models.ChannelMapping{}).Select("channel_id").Where("property_id = ?", survivorID)).
+			Update("property_id", survivorID)
+		if moved.Error != nil {
+			return moved.Error
+		}
+		merge.MappingsMoved = moved.RowsAffected
+		dropped := tx.Where("property_id = ?", duplicateID).Delete(&models.ChannelMapping{})
+		if dropped.Error != nil {
+			return dropped.Error
+		}
+		merge.MappingsDropped = dropped.RowsAffected
+
+		merge.ReviewCount = survivor.ReviewCount + duplicate.ReviewCount
+		merge.Rating = survivor.Rating
+		if merge.ReviewCount > 0 {
+			weighted := float64(survivor.Rating)*float64(survivor.ReviewCount) + float64(duplicate.Rating)*float64(duplicate.ReviewCount)
+			merge.Rating = float32(math.Round(weighted/float64(merge.ReviewCount)*100) / 100)
+		}
+		if err := tx.Model(&models.Property{}).Where("id = ?", survivorID).Updates(map[string]interface{}{
+			"rating":       merge.Rating,
+			"review_count": merge.ReviewCount,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Property{}, duplicateID).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		low, high := survivorID, duplicateID
+		if low > high {
+			low, high = high, low
+		}
+		if err := tx.Model(&models.DuplicateCandidate{}).
+			Where("property_id = ? AND duplicate_id = ?", low, high).
+			Updates(map[string]interface{}{"status": models.DuplicateStatusMerged, "resolved_at": now}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("(property_id = ? OR duplicate_id = ?) AND status = ?", duplicateID, duplicateID, models.DuplicateStatusPending).
+			Delete(&models.DuplicateCandidate{}).Error; err != nil {
+			return err
+		}
+
+		survivor.Rating, survivor.ReviewCount = merge.Rating, merge.ReviewCount
+		events = append(events,
+			changeEvent("UPDATE", "properties", survivorID, survivor),
+			changeEvent("DELETE", "properties", duplicateID, map[string]uint{"merged_into": survivorID}),
+		)
+		return tx.Create(&events).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return merge, nil
+}
+
+// closeMergedNights closes the survivor's nights occupied by the duplicate's bookings,
+// stay and turnover alike, and returns their events
+func closeMergedNights(tx *gorm.DB, survivorID, duplicateID uint) ([]models.Event, error) {
+	var booked []models.Availability
+	if err := tx.Where("property_id = ? AND booking_id IS NOT NULL", duplicateID).
+		Order("date").Find(&booked).Error; err != nil {
+		return nil, err
+	}
+	nightsByBooking := make(map[uint][]string)
+	var bookingIDs []uint
+	for _, row := range booked {
+		if _, ok := nightsByBooking[*row.BookingID]; !ok {
+			bookingIDs = append(bookingIDs, *row.BookingID)
+		}
+		nightsByBooking[*row.BookingID] = append(nightsByBooking[*row.BookingID], row.Date.Format("2006-01-02"))
+	}
+
+	var events []models.Event
+	for _, bookingID := range bookingIDs {
+		var rows []models.Availability
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("property_id = ? AND booking_id IS NULL AND date IN ?", survivorID, nightsByBooking[bookingID]).
+			Order("date").Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		closed, err := closeNights(tx, bookingID, rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to close nights of booking %d: %w", bookingID, err)
+		}
+		events = append(events, closed...)
+	}
+	return events, nil
+}
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ExchangeRateRepository handles exchange rate database operations
+type ExchangeRateRepository struct {
+	db *gorm.DB
+}
+
+// NewExchangeRateRepository creates a new exchange rate repository
+func NewExchangeRateRepository(db *gorm.DB) *ExchangeRateRepository {
+	return &ExchangeRateRepository{db: db}
+}
+
+// SaveExchangeRates replaces the rates of the given currencies; rates of other
+// currencies are kept
+func (r *ExchangeRateRepository) SaveExchangeRates(rates []models.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "as_of", "fetched_at"}),
+	}).Create(&rates).Error
+}
+
+// ListExchangeRates returns the latest rate of every currency, by currency code
+func (r *ExchangeRateRepository) ListExchangeRates() ([]models.ExchangeRate, error) {
+	var rates []models.ExchangeRate
+	err := r.db.Order("currency").Find(&rates).Error
+	return rates, err
+}
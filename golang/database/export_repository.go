@@ -0,0 +1,62 @@
+package database
+
+import (
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// ExportFilter selects one chunk of an export; records are returned in ID order
+type ExportFilter struct {
+	AfterID      uint       // cursor: only records with a greater ID
+	UpdatedSince *time.Time // only records updated at or after this time
+	Limit        int
+}
+
+// ExportRepository handles chunked reads for data exports
+type ExportRepository struct {
+	db *gorm.DB
+}
+
+// NewExportRepository creates a new export repository
+func NewExportRepository(db *gorm.DB) *ExportRepository {
+	return &ExportRepository{db: db}
+}
+
+// ExportProperties retrieves a chunk of properties with their amenities and conditions
+func (r *ExportRepository) ExportProperties(filter ExportFilter) ([]models.Property, error) {
+	var properties []models.Property
+	if err := r.chunk(filter).Preload("Amenities").Preload("Conditions").Find(&properties).Error; err != nil {
+		return nil, err
+	}
+	return properties, nil
+}
+
+// ExportBookings retrieves a chunk of bookings
+func (r *ExportRepository) ExportBookings(filter ExportFilter) ([]models.Booking, error) {
+	var bookings []models.Booking
+	if err := r.chunk(filter).Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// ExportPricing retrieves a chunk of nightly pricing rows
+func (r *ExportRepository) ExportPricing(filter ExportFilter) ([]models.Pricing, error) {
+	var pricing []models.Pricing
+	if err := r.chunk(filter).Find(&pricing).Error; err != nil {
+		return nil, err
+	}
+	return pricing, nil
+}
+
+// chunk builds the cursor query shared by all exports
+func (r *ExportRepository) chunk(filter ExportFilter) *gorm.DB {
+	query := r.db.Where("id > ?", filter.AfterID)
+	if filter.UpdatedSince != nil {
+		query = query.Where("updated_at >= ?", *filter.UpdatedSince)
+	}
+	return query.Order("id").Limit(filter.Limit)
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FavoriteRepository handles user favorite database operations
+type FavoriteRepository struct {
+	db *gorm.DB
+}
+
+// NewFavoriteRepository creates a new favorite repository
+func NewFavoriteRepository(db *gorm.DB) *FavoriteRepository {
+	return &FavoriteRepository{db: db}
+}
+
+// GetFavoritePropertyIDs retrieves the IDs of a user's favorite properties
+func (r *FavoriteRepository) GetFavoritePropertyIDs(userID string) ([]uint, error) {
+	var ids []uint
+	if err := r.db.Model(&models.Favorite{}).
+		Where("user_id = ?", userID).
+		Order("property_id").
+		Pluck("property_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// AddFavorite adds a property to a user's favorites; adding it again has no effect
+func (r *FavoriteRepository) AddFavorite(userID string, propertyID uint) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.Favorite{UserID: userID, PropertyID: propertyID}).Error
+}
+
+// RemoveFavorite removes a property from a user's favorites
+func (r *FavoriteRepository) RemoveFavorite(userID string, propertyID uint) (bool, error) {
+	result := r.db.Where("user_id = ? AND property_id = ?", userID, propertyID).Delete(&models.Favorite{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// FeeRepository handles property fee database operations
+type FeeRepository struct {
+	db *gorm.DB
+}
+
+// NewFeeRepository creates a new fee repository
+func NewFeeRepository(db *gorm.DB) *FeeRepository {
+	return &FeeRepository{db: db}
+}
+
+// GetFeesForProperty retrieves a property's fees in the order they were set
+func (r *FeeRepository) GetFeesForProperty(propertyID uint) ([]models.Fee, error) {
+	var fees []models.Fee
+	if err := r.db.Where("property_id = ?", propertyID).Order("id").Find(&fees).Error; err != nil {
+		return nil, err
+	}
+	return fees, nil
+}
+
+// ReplaceFeesForProperty replaces all fees of a property in a transaction
+func (r *FeeRepository) ReplaceFeesForProperty(propertyID uint, fees []models.Fee) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("property_id = ?", propertyID).Delete(&models.Fee{}).Error; err != nil {
+			return err
+		}
+		if len(fees) == 0 {
+			return nil
+		}
+		for i := range fees {
+			fees[i].ID = 0
+			fees[i].PropertyID = propertyID
+		}
+		return tx.Create(&fees).Error
+	})
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"time"
+
+	"channelmanager/models"
+	"channelmanager/pii"
+
+	"gorm.io/gorm"
+)
+
+// GuestRepository handles guest personal data database operations. Guests have no
+// account of their own and are identified by the email address on their bookings.
+type GuestRepository struct {
+	db *gorm.DB
+}
+
+// NewGuestRepository creates a new guest repository
+func NewGuestRepository(db *gorm.DB) *GuestRepository {
+	return &GuestRepository{db: db}
+}
+
+// guestBookingIDs returns the IDs of all bookings made with an email address,
+// including deleted ones
+func guestBookingIDs(tx *gorm.DB, email string) ([]uint, error) {
+	var ids []uint
+	// Bookings not yet encrypted have no blind index and are matched on the plaintext email
+	err := tx.Unscoped().Model(&models.Booking{}).
+		Where("guest_email_hash = ? OR (COALESCE(guest_email_hash, '') = '' AND LOWER(guest_email) = LOWER(?))",
+			pii.BlindIndex(email), email).
+		Order("id").Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ExportGuestData collects every record holding personal data of a guest. It returns
+// nil when nothing is stored for the email address.
+func (r *GuestRepository) ExportGuestData(email string) (*models.GuestDataExport, error) {
+	export := &models.GuestDataExport{Email: email, ExportedAt: time.Now()}
+
+	if err := r.db.Where("subject_hash = ?", models.GuestSubjectHash(email)).
+		Order("created_at").Find(&export.Erasures).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("guest_hash = ?", pii.BlindIndex(email)).
+		Order("id").Find(&export.Loyalty).Error; err != nil {
+		return nil, err
+	}
+
+	ids, err := guestBookingIDs(r.db, email)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		if len(export.Erasures) == 0 && len(export.Loyalty) == 0 {
+			return nil, nil
+		}
+		return export, nil
+	}
+
+	if err := r.db.Unscoped().Where("id IN ?", ids).Order("id").Find(&export.Bookings).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("booking_id IN ?", ids).Order("id").Find(&export.Invoices).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("table_name = ? AND record_id IN ?", "bookings", ids).
+		Order("id").Find(&export.ChangeEvents).Error; err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// EraseGuest anonymizes the bookings of a guest and strips the guest's details from
+// the booking snapshots of change events, keeping dates, prices and statuses so
+// reports are unchanged, closes the guest's loyalty account and records the erasure. It returns nil when no booking
+// was made with the email address.
+func (r *GuestRepository) EraseGuest(email, reason string) (*models.GuestErasure, error) {
+	var erasure *models.GuestErasure
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		ids, err := guestBookingIDs(tx, email)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		result := tx.Unscoped().Model(&models.Booking{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"guest_name":       models.ErasedGuestName,
+				"guest_email":      "",
+				"guest_email_hash": "",
+				"guest_phone":      "",
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		anonymized := result.RowsAffected
+
+		result = tx.Exec(`
+			UPDATE events SET data = data - 'guest_name' - 'guest_email' - 'guest_phone'
+			WHERE table_name = ? AND record_id IN ?`, "bookings", ids)
+		if result.Error != nil {
+			return result.Error
+		}
+		scrubbed := result.RowsAffected
+
+		// Loyalty records are keyed by the email's blind index, so they go entirely
+		guestHash := pii.BlindIndex(email)
+		if err := tx.Where("guest_hash = ?", guestHash).Delete(&models.LoyaltyEntry{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("guest_hash = ?", guestHash).Delete(&models.LoyaltyAccount{}).Error; err != nil {
+			return err
+		}
+
+		var invoices int64
+		if err := tx.Model(&models.Invoice{}).Where("booking_id IN ?", ids).Count(&invoices).Error; err != nil {
+			return err
+		}
+
+		erasure = &models.GuestErasure{
+			SubjectHash:        models.GuestSubjectHash(email),
+			Reason:             reason,
+			BookingsAnonymized: int(anonymized),
+			EventsScrubbed:     int(scrubbed),
+			InvoicesRetained:   int(invoices),
+		}
+		return tx.Create(erasure).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return erasure, nil
+}
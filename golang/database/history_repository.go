@@ -0,0 +1,86 @@
+package database
+
+import (
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// HistoryRepository handles the change history of nightly rates and availability
+type HistoryRepository struct {
+	db *gorm.DB
+}
+
+// NewHistoryRepository creates a new history repository
+func NewHistoryRepository(db *gorm.DB) *HistoryRepository {
+	return &HistoryRepository{db: db}
+}
+
+// RecordPricingRevision stores a pricing revision; a revision of an event already
+// recorded, e.g. when an event is processed again after its claim expired, is ignored
+func (r *HistoryRepository) RecordPricingRevision(revision *models.PricingRevision) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "event_id"}},
+		DoNothing: true,
+	}).Create(revision).Error
+}
+
+// GetPriceHistory returns the revisions of a property's night, oldest first, each with
+// the base price before it
+func (r *HistoryRepository) GetPriceHistory(propertyID uint, date time.Time) ([]models.PricingRevision, error) {
+	var rows []struct {
+		models.PricingRevision
+		Previous *float64
+	}
+	err := r.db.Model(&models.PricingRevision{}).
+		Select("pricing_history.*, LAG(base_price) OVER (ORDER BY changed_at, event_id) AS previous").
+		Where("property_id = ? AND date = ?", propertyID, date.Format("2006-01-02")).
+		Order("changed_at, event_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]models.PricingRevision, len(rows))
+	for i, row := range rows {
+		revisions[i] = row.PricingRevision
+		revisions[i].PreviousBasePrice = row.Previous
+	}
+	return revisions, nil
+}
+
+// RecordAvailabilityRevision stores an availability revision; a revision of an event
+// already recorded is ignored
+func (r *HistoryRepository) RecordAvailabilityRevision(revision *models.AvailabilityRevision) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "event_id"}},
+		DoNothing: true,
+	}).Create(revision).Error
+}
+
+// GetAvailabilityHistory returns the revisions of a property's night, oldest first, each
+// with whether the night was available before it
+func (r *HistoryRepository) GetAvailabilityHistory(propertyID uint, date time.Time) ([]models.AvailabilityRevision, error) {
+	var rows []struct {
+		models.AvailabilityRevision
+		Previous *bool
+	}
+	err := r.db.Model(&models.AvailabilityRevision{}).
+		Select("availability_history.*, LAG(available) OVER (ORDER BY changed_at, event_id) AS previous").
+		Where("property_id = ? AND date = ?", propertyID, date.Format("2006-01-02")).
+		Order("changed_at, event_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]models.AvailabilityRevision, len(rows))
+	for i, row := range rows {
+		revisions[i] = row.AvailabilityRevision
+		revisions[i].PreviouslyAvailable = row.Previous
+	}
+	return revisions, nil
+}
@@ -0,0 +1,265 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrUnknownReservation is returned when a channel cancels a reservation it never
+// delivered
+var ErrUnknownReservation = errors.New("reservation was never received")
+
+// Inbound reservation outcomes
+const (
+	InboundCreated   = "created"   // first delivery, booked
+	InboundUnchanged = "unchanged" // redelivered with the same content
+	InboundModified  = "modified"  // redelivered with other dates, party, price or guest details
+	InboundCancelled = "cancelled" // cancelled by the channel
+)
+
+// StoreInboundReservation stores a channel's reservation once per channel and external
+// reference. Deliveries of the same reservation are serialized on its inbound record:
+// the first books it with inventory and a redelivery with the same content hash loads
+// the booking into booking. A delivery with another hash modifies the stored booking,
+// moving its nights when the dates or property changed (ErrNotAvailable when the new
+// stay cannot be taken), and one with cancel set cancels it and reopens its nights.
+// Modifications and cancellations are recorded on the booking and notified to the host.
+// Nothing is stored when the delivery fails, so it can be retried.
+func (r *BookingRepository) StoreInboundReservation(booking *models.Booking, contentHash string, cancel bool, turnoverDays int) (string, error) {
+	outcome := InboundCreated
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		record := models.InboundReservation{ChannelID: booking.ChannelID, ExternalReference: booking.ExternalReference}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&record).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("channel_id = ? AND external_reference = ?", record.ChannelID, record.ExternalReference).
+			First(&record).Error; err != nil {
+			return err
+		}
+
+		stored, err := storedReservation(tx, record)
+		if err != nil {
+			return err
+		}
+		switch {
+		case stored == nil && cancel:
+			return ErrUnknownReservation
+		case stored == nil:
+			if err := (&BookingRepository{db: tx}).CreateBookingWithInventory(booking, turnoverDays); err != nil {
+				return err
+			}
+		case record.ContentHash == contentHash,
+			cancel && stored.Status == models.BookingStatusCancelled:
+			outcome = InboundUnchanged
+			*booking = *stored
+		case cancel:
+			outcome = InboundCancelled
+			if err := cancelReservation(tx, stored); err != nil {
+				return err
+			}
+			*booking = *stored
+		case stored.Status != models.BookingStatusConfirmed:
+			return ErrNotConfirmed
+		default:
+			outcome = InboundModified
+			changed, err := modifyReservation(tx, stored, booking, turnoverDays)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				outcome = InboundUnchanged
+			}
+			*booking = *stored
+		}
+
+		record.BookingID = &booking.ID
+		record.ContentHash = contentHash
+		record.Deliveries++
+		record.LastReceivedAt = time.Now()
+		return tx.Save(&record).Error
+	})
+	return outcome, err
+}
+
+// storedReservation returns the booking an inbound reservation was stored as, including
+// bookings imported before reservations were recorded, or nil for a new reservation
+func storedReservation(tx *gorm.DB, record models.InboundReservation) (*models.Booking, error) {
+	var booking models.Booking
+	query := tx.Where("channel_id = ? AND external_reference = ?", record.ChannelID, record.ExternalReference)
+	if record.BookingID != nil {
+		query = tx.Where("id = ?", *record.BookingID)
+	}
+	err := query.Order("id").First(&booking).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// cancelReservation cancels a stored reservation on behalf of its channel
+func cancelReservation(tx *gorm.DB, stored *models.Booking) error {
+	if err := cancelWithInventory(tx, stored); err != nil {
+		return err
+	}
+	changes := map[string]models.FieldChange{
+		"status": {From: models.BookingStatusConfirmed, To: models.BookingStatusCancelled},
+	}
+	message := fmt.Sprintf("Reservation %s from %s for %s to %s was cancelled", stored.ExternalReference,
+		stored.ChannelID, stored.CheckinDate.Format("2006-01-02"), stored.CheckoutDate.Format("2006-01-02"))
+	return recordModification(tx, stored, models.ModificationCancelled, changes,
+		models.NotificationReservationCancelled, message)
+}
+
+// modifyReservation copies a redelivered reservation onto the stored booking, moving
+// its nights when the stay changed, and reports whether anything changed
+func modifyReservation(tx *gorm.DB, stored, delivered *models.Booking, turnoverDays int) (bool, error) {
+	changes, names := reservationChanges(stored, delivered)
+	if len(changes) == 0 {
+		return false, nil
+	}
+
+	var events []models.Event
+	if stored.PropertyID != delivered.PropertyID ||
+		!stored.CheckinDate.Equal(delivered.CheckinDate) || !stored.CheckoutDate.Equal(delivered.CheckoutDate) {
+		reopened, err := reopenNights(tx, stored.ID)
+		if err != nil {
+			return false, err
+		}
+		moved := *stored
+		moved.PropertyID = delivered.PropertyID
+		moved.CheckinDate = delivered.CheckinDate
+		moved.CheckoutDate = delivered.CheckoutDate
+		rows, err := reserveNights(tx, &moved, turnoverDays)
+		if err != nil {
+			return false, err
+		}
+		closed, err := closeNights(tx, stored.ID, rows)
+		if err != nil {
+			return false, err
+		}
+		events = append(reopened, closed...)
+	}
+
+	stored.PropertyID = delivered.PropertyID
+	stored.CheckinDate = delivered.CheckinDate
+	stored.CheckoutDate = delivered.CheckoutDate
+	stored.GuestName = delivered.GuestName
+	stored.GuestEmail = delivered.GuestEmail
+	stored.GuestPhone = delivered.GuestPhone
+	stored.NumberOfGuests = delivered.NumberOfGuests
+	stored.NumberOfChildren = delivered.NumberOfChildren
+	stored.NumberOfInfants = delivered.NumberOfInfants
+	stored.Currency = delivered.Currency
+	stored.TotalPrice = delivered.TotalPrice
+	stored.Pricing = delivered.Pricing
+	if err := tx.Select("property_id", "checkin_date", "checkout_date", "guest_name", "guest_email",
+		"guest_email_hash", "guest_phone", "number_of_guests", "number_of_children", "number_of_infants", "currency",
+		"total_price", "pricing").Save(stored).Error; err != nil {
+		return false, err
+	}
+	events = append(events, changeEvent("UPDATE", "bookings", stored.ID, stored.WithoutGuestDetails()))
+	if err := tx.Create(&events).Error; err != nil {
+		return false, err
+	}
+
+	message := fmt.Sprintf("Reservation %s from %s was modified: %s", stored.ExternalReference,
+		stored.ChannelID, strings.Join(names, ", "))
+	return true, recordModification(tx, stored, models.ModificationModified, changes,
+		models.NotificationReservationModified, message)
+}
+
+// reservationChanges compares a stored booking with a redelivery of its reservation and
+// returns the changed fields, and their names in booking order
+func reservationChanges(stored, delivered *models.Booking) (map[string]models.FieldChange, []string) {
+	changes := make(map[string]models.FieldChange)
+	var names []string
+	add := func(name string, from, to interface{}) {
+		changes[name] = models.FieldChange{From: from, To: to}
+		names = append(names, name)
+	}
+
+	if stored.PropertyID != delivered.PropertyID {
+		add("property_id", stored.PropertyID, delivered.PropertyID)
+	}
+	if !stored.CheckinDate.Equal(delivered.CheckinDate) {
+		add("checkin_date", stored.CheckinDate.Format("2006-01-02"), delivered.CheckinDate.Format("2006-01-02"))
+	}
+	if !stored.CheckoutDate.Equal(delivered.CheckoutDate) {
+		add("checkout_date", stored.CheckoutDate.Format("2006-01-02"), delivered.CheckoutDate.Format("2006-01-02"))
+	}
+	if stored.NumberOfGuests != delivered.NumberOfGuests {
+		add("number_of_guests", stored.NumberOfGuests, delivered.NumberOfGuests)
+	}
+	if stored.NumberOfChildren != delivered.NumberOfChildren {
+		add("number_of_children", stored.NumberOfChildren, delivered.NumberOfChildren)
+	}
+	if stored.NumberOfInfants != delivered.NumberOfInfants {
+		add("number_of_infants", stored.NumberOfInfants, delivered.NumberOfInfants)
+	}
+	if stored.TotalPrice != delivered.TotalPrice {
+		add("total_price", stored.TotalPrice, delivered.TotalPrice)
+	}
+	if stored.Currency != delivered.Currency {
+		add("currency", stored.Currency, delivered.Currency)
+	}
+	// Contact details are recorded as changed without their values
+	if stored.GuestName != delivered.GuestName {
+		add("guest_name", nil, nil)
+	}
+	if stored.GuestEmail != delivered.GuestEmail {
+		add("guest_email", nil, nil)
+	}
+	if stored.GuestPhone != delivered.GuestPhone {
+		add("guest_phone", nil, nil)
+	}
+	return changes, names
+}
+
+// recordModification stores a channel's change to a booking in its modification history
+// and notifies the property's host, unless its organization turned such notifications off
+func recordModification(tx *gorm.DB, booking *models.Booking, modificationType string,
+	changes map[string]models.FieldChange, notificationType, message string) error {
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	modification := models.BookingModification{
+		BookingID: booking.ID,
+		ChannelID: booking.ChannelID,
+		Type:      modificationType,
+		Changes:   data,
+	}
+	if err := tx.Create(&modification).Error; err != nil {
+		return err
+	}
+
+	preferences, err := propertyNotificationPreferences(tx, booking.PropertyID)
+	if err != nil {
+		return err
+	}
+	if !preferences.Allows(notificationType) {
+		return nil
+	}
+
+	bookingID := booking.ID
+	notification := models.HostNotification{
+		PropertyID: booking.PropertyID,
+		BookingID:  &bookingID,
+		ChannelID:  booking.ChannelID,
+		Type:       notificationType,
+		Message:    message,
+	}
+	return tx.Create(&notification).Error
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// JobRunRepository handles background job run history database operations
+type JobRunRepository struct {
+	db *gorm.DB
+}
+
+// NewJobRunRepository creates a new job run repository
+func NewJobRunRepository(db *gorm.DB) *JobRunRepository {
+	return &JobRunRepository{db: db}
+}
+
+// CreateRun records the start of a job run
+func (r *JobRunRepository) CreateRun(run *models.JobRun) error {
+	return r.db.Create(run).Error
+}
+
+// FinishRun stores the outcome of a job run
+func (r *JobRunRepository) FinishRun(run *models.JobRun) error {
+	return r.db.Model(run).Select("status", "error", "finished_at", "duration_ms").Updates(run).Error
+}
+
+// AbandonRuns fails the runs an instance left running, e.g. when it was killed mid-run
+func (r *JobRunRepository) AbandonRuns(instance string) (int64, error) {
+	result := r.db.Model(&models.JobRun{}).
+		Where("instance = ? AND status = ?", instance, models.JobRunRunning).
+		Updates(map[string]interface{}{"status": models.JobRunFailed, "error": "interrupted by shutdown"})
+	return result.RowsAffected, result.Error
+}
+
+// GetLastRuns retrieves the latest run of every job, keyed by job name
+func (r *JobRunRepository) GetLastRuns() (map[string]models.JobRun, error) {
+	var runs []models.JobRun
+	if err := r.db.Raw(`
+		SELECT DISTINCT ON (job) *
+		FROM job_runs
+		ORDER BY job, started_at DESC, id DESC`).Scan(&runs).Error; err != nil {
+		return nil, err
+	}
+
+	byJob := make(map[string]models.JobRun, len(runs))
+	for _, run := range runs {
+		byJob[run.Job] = run
+	}
+	return byJob, nil
+}
+
+// ListRuns retrieves a job's runs, newest first, with the total count
+func (r *JobRunRepository) ListRuns(job string, limit, offset int) ([]models.JobRun, int64, error) {
+	query := r.db.Model(&models.JobRun{}).Where("job = ?", job)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var runs []models.JobRun
+	if err := query.Order("started_at DESC, id DESC").Limit(limit).Offset(offset).Find(&runs).Error; err != nil {
+		return nil, 0, err
+	}
+	return runs, total, nil
+}
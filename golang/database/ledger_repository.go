@@ -0,0 +1,167 @@
+package database
+
+import (
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PayoutSummary holds aggregated ledger totals for an organization and period
+type PayoutSummary struct {
+	OrganizationID     uint
+	Currency           string
+	GrossRevenue       float64
+	ChannelCommissions float64
+	PlatformFees       float64
+	BookingCount       int
+}
+
+// PayoutStatementFilter holds optional filters for listing payout statements
+type PayoutStatementFilter struct {
+	OrganizationID uint
+	Period         string
+	Status         string
+	Limit          int
+	Offset         int
+}
+
+// LedgerRepository handles ledger and payout database operations
+type LedgerRepository struct {
+	db *gorm.DB
+}
+
+// NewLedgerRepository creates a new ledger repository
+func NewLedgerRepository(db *gorm.DB) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+// GetBookingsCheckingOutBetween retrieves the bookings with checkout in [start, end) that
+// the host accepted and that kept their nights, no-shows included
+func (r *LedgerRepository) GetBookingsCheckingOutBetween(start, end time.Time) ([]models.Booking, error) {
+	var bookings []models.Booking
+	if err := r.db.Preload("Property").
+		Where("status IN ? AND checkout_date >= ? AND checkout_date < ?", models.BookingAcceptedStatuses, start, end).
+		Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// CreateEntries inserts ledger entries, skipping entries already recorded for a booking
+func (r *LedgerRepository) CreateEntries(entries []models.LedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&entries).Error
+}
+
+// SummarizePeriod aggregates ledger entries per organization for a period
+func (r *LedgerRepository) SummarizePeriod(period string) ([]PayoutSummary, error) {
+	var summaries []PayoutSummary
+	err := r.db.Model(&models.LedgerEntry{}).
+		Select(`organization_id,
+			MAX(currency) AS currency,
+			COALESCE(SUM(CASE WHEN entry_type = ? THEN amount END), 0) AS gross_revenue,
+			COALESCE(SUM(CASE WHEN entry_type = ? THEN amount END), 0) AS channel_commissions,
+			COALESCE(SUM(CASE WHEN entry_type = ? THEN amount END), 0) AS platform_fees,
+			COUNT(DISTINCT booking_id) AS booking_count`,
+			models.LedgerEntryBookingRevenue,
+			models.LedgerEntryChannelCommission,
+			models.LedgerEntryPlatformFee,
+		).
+		Where("period = ?", period).
+		Group("organization_id").
+		Scan(&summaries).Error
+	return summaries, err
+}
+
+// GetEntries retrieves the ledger entries of an organization for a period
+func (r *LedgerRepository) GetEntries(organizationID uint, period string) ([]models.LedgerEntry, error) {
+	var entries []models.LedgerEntry
+	if err := r.db.Where("organization_id = ? AND period = ?", organizationID, period).
+		Order("booking_id, entry_type").
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// SaveStatement creates or refreshes a pending payout statement.
+// Executed statements are never modified.
+func (r *LedgerRepository) SaveStatement(statement *models.PayoutStatement) error {
+	var existing models.PayoutStatement
+	err := r.db.Where("organization_id = ? AND period = ?", statement.OrganizationID, statement.Period).
+		First(&existing).Error
+	if err == gorm.ErrRecordNotFound {
+		statement.Status = models.PayoutStatusPending
+		return r.db.Create(statement).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	if existing.Status == models.PayoutStatusExecuted {
+		*statement = existing
+		return nil
+	}
+
+	statement.ID = existing.ID
+	statement.Status = existing.Status
+	statement.CreatedAt = existing.CreatedAt
+	return r.db.Save(statement).Error
+}
+
+// ListStatements lists payout statements matching the filter
+func (r *LedgerRepository) ListStatements(filter PayoutStatementFilter) ([]models.PayoutStatement, int64, error) {
+	query := r.db.Model(&models.PayoutStatement{})
+	if filter.OrganizationID > 0 {
+		query = query.Where("organization_id = ?", filter.OrganizationID)
+	}
+	if filter.Period != "" {
+		query = query.Where("period = ?", filter.Period)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var statements []models.PayoutStatement
+	if err := query.Order("period DESC, organization_id").
+		Limit(filter.Limit).Offset(filter.Offset).
+		Find(&statements).Error; err != nil {
+		return nil, 0, err
+	}
+	return statements, total, nil
+}
+
+// GetStatementByID retrieves a payout statement by ID
+func (r *LedgerRepository) GetStatementByID(id uint) (*models.PayoutStatement, error) {
+	var statement models.PayoutStatement
+	if err := r.db.First(&statement, id).Error; err != nil {
+		return nil, err
+	}
+	return &statement, nil
+}
+
+// MarkStatementExecuted marks a pending statement as paid out.
+// It returns false if the statement was not pending.
+func (r *LedgerRepository) MarkStatementExecuted(id uint, reference string, executedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.PayoutStatement{}).
+		Where("id = ? AND status = ?", id, models.PayoutStatusPending).
+		Updates(map[string]interface{}{
+			"status":           models.PayoutStatusExecuted,
+			"payout_reference": reference,
+			"executed_at":      executedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,169 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInsufficientPoints is returned when a guest's balance does not cover the points spent
+var ErrInsufficientPoints = errors.New("not enough loyalty points")
+
+// LoyaltyRepository handles guest loyalty database operations
+type LoyaltyRepository struct {
+	db *gorm.DB
+}
+
+// NewLoyaltyRepository creates a new loyalty repository
+func NewLoyaltyRepository(db *gorm.DB) *LoyaltyRepository {
+	return &LoyaltyRepository{db: db}
+}
+
+// GetLoyaltyProgram retrieves the loyalty program, disabled when none is configured
+func (r *LoyaltyRepository) GetLoyaltyProgram() (*models.LoyaltyProgram, error) {
+	return loyaltyProgram(r.db)
+}
+
+// SaveLoyaltyProgram replaces the loyalty program
+func (r *LoyaltyRepository) SaveLoyaltyProgram(program *models.LoyaltyProgram) error {
+	program.ID = 1
+	return r.db.Save(program).Error
+}
+
+// GetPointsBalance returns a guest's points balance
+func (r *LoyaltyRepository) GetPointsBalance(guestHash string) (int, error) {
+	var account models.LoyaltyAccount
+	err := r.db.Where("guest_hash = ?", guestHash).First(&account).Error
+	if err == gorm.ErrRecordNotFound {
+		return 0, nil
+	}
+	return account.Points, err
+}
+
+// ListLoyaltyEntries returns one page of a guest's balance changes, newest first, with
+// the number of changes
+func (r *LoyaltyRepository) ListLoyaltyEntries(guestHash string, limit, offset int) ([]models.LoyaltyEntry, int64, error) {
+	query := r.db.Model(&models.LoyaltyEntry{}).Where("guest_hash = ?", guestHash)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var entries []models.LoyaltyEntry
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&entries).Error
+	return entries, total, err
+}
+
+// AdjustPoints grants or, with negative points, withdraws a guest's points by hand. It
+// returns ErrInsufficientPoints if the balance would drop below zero.
+func (r *LoyaltyRepository) AdjustPoints(guestHash string, points int, description string) (*models.LoyaltyEntry, error) {
+	entry := &models.LoyaltyEntry{GuestHash: guestHash, Type: models.LoyaltyAdjustment, Points: points, Description: description}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return postLoyaltyEntry(tx, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// loyaltyProgram loads the loyalty program within tx
+func loyaltyProgram(tx *gorm.DB) (*models.LoyaltyProgram, error) {
+	var program models.LoyaltyProgram
+	err := tx.First(&program, 1).Error
+	if err == gorm.ErrRecordNotFound {
+		return &models.LoyaltyProgram{Currency: "USD"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &program, nil
+}
+
+// accruePoints credits the points a completed booking earns, once per booking
+func accruePoints(tx *gorm.DB, booking *models.Booking) error {
+	if booking.GuestEmailHash == "" {
+		return nil
+	}
+	program, err := loyaltyProgram(tx)
+	if err != nil {
+		return err
+	}
+	points := program.PointsFor(*booking)
+	if points <= 0 {
+		return nil
+	}
+
+	bookingID := booking.ID
+	return postLoyaltyEntry(tx, &models.LoyaltyEntry{
+		GuestHash:   booking.GuestEmailHash,
+		BookingID:   &bookingID,
+		Type:        models.LoyaltyAccrual,
+		Points:      points,
+		Description: fmt.Sprintf("Stay of booking %d", booking.ID),
+	})
+}
+
+// redeemPoints spends a guest's points on a booking. It returns ErrInsufficientPoints if
+// the guest's balance does not cover them.
+func redeemPoints(tx *gorm.DB, booking *models.Booking, points int) error {
+	bookingID := booking.ID
+	return postLoyaltyEntry(tx, &models.LoyaltyEntry{
+		GuestHash:   booking.GuestEmailHash,
+		BookingID:   &bookingID,
+		Type:        models.LoyaltyRedemption,
+		Points:      -points,
+		Description: fmt.Sprintf("Credit on booking %d", booking.ID),
+	})
+}
+
+// refundPoints returns the points spent on a cancelled booking, once per booking
+func refundPoints(tx *gorm.DB, booking *models.Booking) error {
+	var redemption models.LoyaltyEntry
+	err := tx.Where("booking_id = ? AND type = ?", booking.ID, models.LoyaltyRedemption).First(&redemption).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return postLoyaltyEntry(tx, &models.LoyaltyEntry{
+		GuestHash:   redemption.GuestHash,
+		BookingID:   redemption.BookingID,
+		Type:        models.LoyaltyRefund,
+		Points:      -redemption.Points,
+		Description: fmt.Sprintf("Cancellation of booking %d", booking.ID),
+	})
+}
+
+// postLoyaltyEntry records a balance change and applies it to the guest's account,
+// which is locked so concurrent changes are serialized. An entry for a booking that
+// already has one of its type is skipped. It returns ErrInsufficientPoints if the
+// balance would drop below zero.
+func postLoyaltyEntry(tx *gorm.DB, entry *models.LoyaltyEntry) error {
+	account := models.LoyaltyAccount{GuestHash: entry.GuestHash}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&account).Error; err != nil {
+		return err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("guest_hash = ?", entry.GuestHash).First(&account).Error; err != nil {
+		return err
+	}
+	if account.Points+entry.Points < 0 {
+		return ErrInsufficientPoints
+	}
+
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(entry)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	return tx.Model(&account).Update("points", account.Points+entry.Points).Error
+}
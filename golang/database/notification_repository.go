@@ -0,0 +1,57 @@
+package database
+
+import (
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// NotificationRepository handles host notification database operations
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// ListNotifications returns one page of a property's notifications, newest first, with
+// the number of matching notifications
+func (r *NotificationRepository) ListNotifications(propertyID uint, unreadOnly bool, limit, offset int) ([]models.HostNotification, int64, error) {
+	query := r.db.Model(&models.HostNotification{}).Where("property_id = ?", propertyID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var notifications []models.HostNotification
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
+
+// MarkNotificationRead marks a property's notification read; marking it again keeps
+// the time it was first read
+func (r *NotificationRepository) MarkNotificationRead(propertyID, id uint) (*models.HostNotification, error) {
+	var notification models.HostNotification
+	if err := r.db.Where("id = ? AND property_id = ?", id, propertyID).First(&notification).Error; err != nil {
+		return nil, err
+	}
+	if notification.ReadAt != nil {
+		return &notification, nil
+	}
+
+	now := time.Now()
+	if err := r.db.Model(&notification).Update("read_at", now).Error; err != nil {
+		return nil, err
+	}
+	notification.ReadAt = &now
+	return &notification, nil
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OrganizationRepository handles organization database operations
+type OrganizationRepository struct {
+	db *gorm.DB
+}
+
+// NewOrganizationRepository creates a new organization repository
+func NewOrganizationRepository(db *gorm.DB) *OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+// GetOrganizationByID retrieves an organization by ID
+func (r *OrganizationRepository) GetOrganizationByID(id uint) (*models.Organization, error) {
+	var organization models.Organization
+	if err := r.db.First(&organization, id).Error; err != nil {
+		return nil, err
+	}
+	return &organization, nil
+}
+
+// UpdateSellerDetails updates the invoice seller details of an organization
+func (r *OrganizationRepository) UpdateSellerDetails(id uint, details models.SellerDetails) error {
+	return r.db.Model(&models.Organization{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"seller_legal_name":    details.SellerLegalName,
+		"seller_tax_id":        details.SellerTaxID,
+		"seller_address":       details.SellerAddress,
+		"seller_email":         details.SellerEmail,
+		"seller_phone":         details.SellerPhone,
+		"invoice_prefix":       details.InvoicePrefix,
+		"invoice_footer_notes": details.InvoiceFooterNotes,
+	}).Error
+}
+
+// GetSettings retrieves the settings of an organization, or the defaults when it saved
+// none
+func (r *OrganizationRepository) GetSettings(organizationID uint) (*models.OrganizationSettings, error) {
+	settings, err := organizationSettings(r.db, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SaveSettings creates or replaces the settings of an organization
+func (r *OrganizationRepository) SaveSettings(settings *models.OrganizationSettings) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "organization_id"}},
+		UpdateAll: true,
+	}).Create(settings).Error
+}
+
+// organizationSettings loads the settings of an organization, or the defaults when it
+// saved none
+func organizationSettings(db *gorm.DB, organizationID uint) (models.OrganizationSettings, error) {
+	var settings models.OrganizationSettings
+	err := db.Where("organization_id = ?", organizationID).Take(&settings).Error
+	if err == gorm.ErrRecordNotFound {
+		return models.DefaultOrganizationSettings(organizationID), nil
+	}
+	return settings, err
+}
+
+// propertyNotificationPreferences loads the host notification preferences of the
+// organization owning a property; properties without one get every notification
+func propertyNotificationPreferences(db *gorm.DB, propertyID uint) (models.NotificationPreferences, error) {
+	var property models.Property
+	if err := db.Unscoped().Select("id", "organization_id").First(&property, propertyID).Error; err != nil {
+		return models.NotificationPreferences{}, err
+	}
+	if property.OrganizationID == nil {
+		return models.DefaultOrganizationSettings(0).Notifications, nil
+	}
+
+	settings, err := organizationSettings(db, *property.OrganizationID)
+	return settings.Notifications, err
+}
@@ -0,0 +1,131 @@
+package database
+
+import (
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ParityComparison pairs the latest rate pushed to a channel with the base price for that night
+type ParityComparison struct {
+	ChannelID   string
+	PropertyID  uint
+	Date        time.Time
+	ChannelRate float64
+	Currency    string // of ChannelRate
+	BaseRate    float64
+
+	// Pricing rules of the channel at the time of the check
+	MarkupPercent     float64
+	FixedFee          float64
+	CommissionPercent float64
+	GrossUpCommission bool
+}
+
+// ParityViolationFilter holds optional filters for listing parity violations
+type ParityViolationFilter struct {
+	ChannelID       string
+	PropertyID      uint
+	Severity        string
+	IncludeResolved bool
+	Limit           int
+	Offset          int
+}
+
+// ParityRepository handles channel rate and parity database operations
+type ParityRepository struct {
+	db *gorm.DB
+}
+
+// NewParityRepository creates a new parity repository
+func NewParityRepository(db *gorm.DB) *ParityRepository {
+	return &ParityRepository{db: db}
+}
+
+// RecordChannelRates stores rates that were pushed to a channel
+func (r *ParityRepository) RecordChannelRates(rates []models.ChannelRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(rates, 500).Error
+}
+
+// GetParityComparisons returns the most recently pushed rate per channel, property and
+// night in [start, end] alongside the base price of that night
+func (r *ParityRepository) GetParityComparisons(start, end time.Time) ([]ParityComparison, error) {
+	var comparisons []ParityComparison
+	err := r.db.Raw(`
+		SELECT DISTINCT ON (cr.channel_id, cr.property_id, cr.date)
+			cr.channel_id, cr.property_id, cr.date, cr.rate AS channel_rate, COALESCE(cr.currency, '') AS currency,
+			p.total_price AS base_rate,
+			COALESCE(ch.markup_percent, 0) AS markup_percent,
+			COALESCE(ch.fixed_fee, 0) AS fixed_fee,
+			COALESCE(ch.commission_percent, 0) AS commission_percent,
+			COALESCE(ch.gross_up_commission, false) AS gross_up_commission
+		FROM channel_rates cr
+		JOIN pricing p ON p.property_id = cr.property_id AND p.date = cr.date AND p.deleted_at IS NULL
+		LEFT JOIN channels ch ON ch.id = cr.channel_id
+		WHERE cr.date BETWEEN ? AND ?
+		ORDER BY cr.channel_id, cr.property_id, cr.date, cr.pushed_at DESC`,
+		start, end,
+	).Scan(&comparisons).Error
+	return comparisons, err
+}
+
+// UpsertViolation records or refreshes an open parity violation
+func (r *ParityRepository) UpsertViolation(violation *models.RateParityViolation) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "channel_id"}, {Name: "property_id"}, {Name: "date"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"expected_rate":      violation.ExpectedRate,
+			"channel_rate":       violation.ChannelRate,
+			"difference_percent": violation.DifferencePercent,
+			"severity":           violation.Severity,
+			"last_checked_at":    violation.LastCheckedAt,
+			"resolved_at":        nil,
+		}),
+	}).Create(violation).Error
+}
+
+// ResolveViolation marks an open violation as resolved once rates are back in parity
+func (r *ParityRepository) ResolveViolation(channelID string, propertyID uint, date time.Time, resolvedAt time.Time) error {
+	return r.db.Model(&models.RateParityViolation{}).
+		Where("channel_id = ? AND property_id = ? AND date = ? AND resolved_at IS NULL", channelID, propertyID, date).
+		Updates(map[string]interface{}{
+			"resolved_at":     resolvedAt,
+			"last_checked_at": resolvedAt,
+		}).Error
+}
+
+// ListViolations lists parity violations matching the filter, most severe first
+func (r *ParityRepository) ListViolations(filter ParityViolationFilter) ([]models.RateParityViolation, int64, error) {
+	query := r.db.Model(&models.RateParityViolation{})
+	if filter.ChannelID != "" {
+		query = query.Where("channel_id = ?", filter.ChannelID)
+	}
+	if filter.PropertyID > 0 {
+		query = query.Where("property_id = ?", filter.PropertyID)
+	}
+	if filter.Severity != "" {
+		query = query.Where("severity = ?", filter.Severity)
+	}
+	if !filter.IncludeResolved {
+		query = query.Where("resolved_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var violations []models.RateParityViolation
+	if err := query.Order("ABS(difference_percent) DESC, date").
+		Limit(filter.Limit).Offset(filter.Offset).
+		Find(&violations).Error; err != nil {
+		return nil, 0, err
+	}
+	return violations, total, nil
+}
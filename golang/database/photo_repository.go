@@ -0,0 +1,43 @@
+package database
+
+import (
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// PhotoRepository handles property photo database operations
+type PhotoRepository struct {
+	db *gorm.DB
+}
+
+// NewPhotoRepository creates a new photo repository
+func NewPhotoRepository(db *gorm.DB) *PhotoRepository {
+	return &PhotoRepository{db: db}
+}
+
+// GetPhotosForProperty retrieves a property's photos in display order
+func (r *PhotoRepository) GetPhotosForProperty(propertyID uint) ([]models.PropertyPhoto, error) {
+	var photos []models.PropertyPhoto
+	if err := r.db.Where("property_id = ?", propertyID).Order("sort_order, id").Find(&photos).Error; err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+// ReplacePhotos replaces a property's photos and records a property update event
+func (r *PhotoRepository) ReplacePhotos(propertyID uint, photos []models.PropertyPhoto) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("property_id = ?", propertyID).Delete(&models.PropertyPhoto{}).Error; err != nil {
+			return err
+		}
+		if len(photos) > 0 {
+			if err := tx.Create(&photos).Error; err != nil {
+				return err
+			}
+		}
+
+		event := changeEvent("UPDATE", "properties", propertyID, map[string]interface{}{"photos": photos})
+		return tx.Create(&event).Error
+	})
+}
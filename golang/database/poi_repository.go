@@ -0,0 +1,88 @@
+package database
+
+import (
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// POIRepository handles point of interest database operations
+type POIRepository struct {
+	db *gorm.DB
+}
+
+// NewPOIRepository creates a new point of interest repository
+func NewPOIRepository(db *gorm.DB) *POIRepository {
+	return &POIRepository{db: db}
+}
+
+// ListPointsOfInterest retrieves points of interest by name, optionally of one type or
+// in one city
+func (r *POIRepository) ListPointsOfInterest(poiType, city string, limit, offset int) ([]models.PointOfInterest, int64, error) {
+	query := r.db.Model(&models.PointOfInterest{})
+	if poiType != "" {
+		query = query.Where("type = ?", poiType)
+	}
+	if city != "" {
+		query = query.Where("LOWER(city) = LOWER(?)", city)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var pois []models.PointOfInterest
+	if err := query.Order("name, id").Limit(limit).Offset(offset).Find(&pois).Error; err != nil {
+		return nil, 0, err
+	}
+	return pois, total, nil
+}
+
+// GetPointOfInterestByID retrieves a point of interest by ID
+func (r *POIRepository) GetPointOfInterestByID(id uint) (*models.PointOfInterest, error) {
+	var poi models.PointOfInterest
+	if err := r.db.First(&poi, id).Error; err != nil {
+		return nil, err
+	}
+	return &poi, nil
+}
+
+// CreatePointOfInterest creates a new point of interest
+func (r *POIRepository) CreatePointOfInterest(poi *models.PointOfInterest) error {
+	return r.db.Create(poi).Error
+}
+
+// UpdatePointOfInterest updates a point of interest
+func (r *POIRepository) UpdatePointOfInterest(poi *models.PointOfInterest) error {
+	return r.db.Save(poi).Error
+}
+
+// DeletePointOfInterest soft-deletes a point of interest
+func (r *POIRepository) DeletePointOfInterest(id uint) error {
+	return r.db.Delete(&models.PointOfInterest{}, id).Error
+}
+
+// NearbyPointsOfInterest retrieves the perType closest points of interest of each type
+// within radiusKm of a location, by type and then distance
+func (r *POIRepository) NearbyPointsOfInterest(latitude, longitude, radiusKm float64, perType int) ([]models.NearbyPointOfInterest, error) {
+	var nearby []models.NearbyPointOfInterest
+	err := r.db.Raw(`
+		SELECT id, name, type, latitude, longitude, ROUND(distance_km::numeric, 2)::float8 AS distance_km
+		FROM (
+			SELECT id, name, type, latitude, longitude, distance_km,
+				ROW_NUMBER() OVER (PARTITION BY type ORDER BY distance_km, id) AS rank
+			FROM (
+				SELECT id, name, type, latitude, longitude,
+					earth_distance(ll_to_earth(latitude, longitude), ll_to_earth(?, ?)) / 1000 AS distance_km
+				FROM points_of_interest
+				WHERE deleted_at IS NULL
+			) measured
+			WHERE distance_km <= ?
+		) ranked
+		WHERE rank <= ?
+		ORDER BY type, distance_km, id`,
+		latitude, longitude, radiusKm, perType).
+		Scan(&nearby).Error
+	return nearby, err
+}
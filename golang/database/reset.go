@@ -0,0 +1,43 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// testDatabaseSuffix ends the name of every database ResetDatabase may empty
+const testDatabaseSuffix = "_test"
+
+// ErrNotTestDatabase is returned by ResetDatabase for a database not named as a test one
+var ErrNotTestDatabase = errors.New("database name does not end in " + testDatabaseSuffix)
+
+// ResetDatabase empties every table of the current schema and restarts their ID
+// sequences, so fixtures loaded afterwards get the same IDs every time. It is only
+// meant for test mode and refuses any database whose name does not end in _test.
+func ResetDatabase(db *gorm.DB) error {
+	var name string
+	if err := db.Raw("SELECT current_database()").Scan(&name).Error; err != nil {
+		return err
+	}
+	if !strings.HasSuffix(name, testDatabaseSuffix) {
+		return fmt.Errorf("%w: %q", ErrNotTestDatabase, name)
+	}
+
+	var tables []string
+	if err := db.Raw("SELECT tablename FROM pg_tables WHERE schemaname = current_schema() ORDER BY tablename").
+		Scan(&tables).Error; err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+
+	quoted := make([]string, len(tables))
+	for i, table := range tables {
+		quoted[i] = `"` + strings.ReplaceAll(table, `"`, `""`) + `"`
+	}
+	return db.Exec(fmt.Sprintf("TRUNCATE %s RESTART IDENTITY CASCADE", strings.Join(quoted, ", "))).Error
+}
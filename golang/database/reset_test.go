@@ -0,0 +1,88 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"channelmanager/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// TestResetDatabase empties the test database and checks that IDs start over
+func TestResetDatabase(t *testing.T) {
+	db := openTestDB(t)
+	var name string
+	if err := db.Raw("SELECT current_database()").Scan(&name).Error; err != nil {
+		t.Fatalf("failed to read database name: %v", err)
+	}
+	if !strings.HasSuffix(name, testDatabaseSuffix) {
+		t.Skipf("test database %q does not end in %s", name, testDatabaseSuffix)
+	}
+
+	seedBookableProperty(t, db, time.Now().UTC().Truncate(24*time.Hour), 3)
+	if err := ResetDatabase(db); err != nil {
+		t.Fatalf("ResetDatabase() error = %v", err)
+	}
+
+	var properties, availability int64
+	db.Model(&models.Property{}).Count(&properties)
+	db.Model(&models.Availability{}).Count(&availability)
+	if properties != 0 || availability != 0 {
+		t.Fatalf("after reset: %d properties and %d availability rows, want none", properties, availability)
+	}
+	property := seedBookableProperty(t, db, time.Now().UTC().Truncate(24*time.Hour), 1)
+	if property.ID != 1 {
+		t.Errorf("first property after reset has ID %d, want 1", property.ID)
+	}
+}
+
+// TestResetDatabaseRefusesOtherDatabases checks that a database not named as a test one
+// is left alone
+func TestResetDatabaseRefusesOtherDatabases(t *testing.T) {
+	dsn := testDSN(t)
+	admin := connectTestDB(t, dsn)
+	const name = "channel_manager_reset_guard"
+	admin.Exec("DROP DATABASE IF EXISTS " + name)
+	if err := admin.Exec("CREATE DATABASE " + name).Error; err != nil {
+		t.Skipf("cannot create a database next to the test database: %v", err)
+	}
+
+	config, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("failed to parse test database DSN: %v", err)
+	}
+	config.Database = name
+	sqlDB, err := sql.Open("pgx", stdlib.RegisterConnConfig(config))
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %v", name, err)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		sqlDB.Close()
+		admin.Exec("DROP DATABASE IF EXISTS " + name)
+	})
+
+	if err := db.Exec("CREATE TABLE kept (id int)").Error; err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	db.Exec("INSERT INTO kept VALUES (1)")
+	if err := ResetDatabase(db); !errors.Is(err, ErrNotTestDatabase) {
+		t.Fatalf("ResetDatabase() error = %v, want ErrNotTestDatabase", err)
+	}
+	var rows int64
+	db.Raw("SELECT count(*) FROM kept").Scan(&rows)
+	if rows != 1 {
+		t.Errorf("kept has %d rows after a refused reset, want 1", rows)
+	}
+}
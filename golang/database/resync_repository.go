@@ -0,0 +1,37 @@
+package database
+
+import (
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// ResyncRepository handles channel resync job database operations
+type ResyncRepository struct {
+	db *gorm.DB
+}
+
+// NewResyncRepository creates a new resync job repository
+func NewResyncRepository(db *gorm.DB) *ResyncRepository {
+	return &ResyncRepository{db: db}
+}
+
+// CreateJob creates a resync job
+func (r *ResyncRepository) CreateJob(job *models.ResyncJob) error {
+	return r.db.Create(job).Error
+}
+
+// GetJob retrieves a channel's resync job by ID
+func (r *ResyncRepository) GetJob(channelID string, id uint) (*models.ResyncJob, error) {
+	var job models.ResyncJob
+	if err := r.db.Where("channel_id = ?", channelID).First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// SaveProgress stores a job's status and counters
+func (r *ResyncRepository) SaveProgress(job *models.ResyncJob) error {
+	return r.db.Model(job).Select("status", "completed", "failed", "last_error", "started_at", "finished_at").
+		Updates(job).Error
+}
@@ -0,0 +1,155 @@
+package database
+
+import (
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// ExplainSearch reports how a search treats up to limit candidate properties: the given
+// IDs, or else the properties matching its location and city. Each filter is evaluated
+// on its own with the same SQL the search runs, so a candidate lists every filter it
+// fails, along with the nights of the stay it cannot book and those priced outside the
+// price range.
+func (r *PropertyRepository) ExplainSearch(filter models.SearchFilter, candidateIDs []uint, limit int) (*models.SearchExplanation, error) {
+	clauses := searchClauses(r.db, filter)
+	explanation := &models.SearchExplanation{Filters: make([]string, 0, len(clauses))}
+	for _, clause := range clauses {
+		explanation.Filters = append(explanation.Filters, clause.name)
+	}
+
+	explanation.SQL = r.db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		query := applyClauses(tx, searchClauses(tx, filter), "")
+		page, limit := max(filter.Page, 1), filter.Limit
+		if limit < 1 {
+			limit = 20
+		}
+		return searchOrder(query, filter).Limit(limit).Offset((page - 1) * limit).Find(&[]models.Property{})
+	})
+
+	// Candidates
+	var candidates []models.Property
+	query := r.db.Select("id", "name").Order("id").Limit(limit)
+	if len(candidateIDs) > 0 {
+		query = query.Where("id IN ?", candidateIDs)
+	} else {
+		for _, clause := range clauses {
+			if clause.name == clauseLocation || clause.name == clauseCity {
+				query = clause.apply(query)
+			}
+		}
+	}
+	if err := query.Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		explanation.Candidates = []models.CandidateExplanation{}
+		return explanation, nil
+	}
+	ids := make([]uint, len(candidates))
+	for i, candidate := range candidates {
+		ids[i] = candidate.ID
+	}
+
+	// Candidates passing the whole search and each filter
+	included, err := r.matchingIDs(applyClauses(r.db, clauses, ""), ids)
+	if err != nil {
+		return nil, err
+	}
+	failed := make(map[uint][]string)
+	for _, clause := range clauses {
+		passing, err := r.matchingIDs(clause.apply(r.db), ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if !passing[id] {
+				failed[id] = append(failed[id], clause.name)
+			}
+		}
+	}
+
+	explanation.Candidates = make([]models.CandidateExplanation, len(candidates))
+	byID := make(map[uint]*models.CandidateExplanation, len(candidates))
+	for i, candidate := range candidates {
+		explanation.Candidates[i] = models.CandidateExplanation{
+			PropertyID:    candidate.ID,
+			Name:          candidate.Name,
+			Included:      included[candidate.ID],
+			FailedFilters: failed[candidate.ID],
+		}
+		byID[candidate.ID] = &explanation.Candidates[i]
+	}
+
+	if filter.Nights() > 0 {
+		if err := r.explainNights(filter, ids, byID); err != nil {
+			return nil, err
+		}
+	}
+	return explanation, nil
+}
+
+// matchingIDs returns which of ids the query matches
+func (r *PropertyRepository) matchingIDs(query *gorm.DB, ids []uint) (map[uint]bool, error) {
+	var matched []uint
+	if err := query.Model(&models.Property{}).
+		Where("properties.id IN ?", ids).
+		Pluck("properties.id", &matched).Error; err != nil {
+		return nil, err
+	}
+	set := make(map[uint]bool, len(matched))
+	for _, id := range matched {
+		set[id] = true
+	}
+	return set, nil
+}
+
+// explainNights adds the unbookable nights, the arrival night's minimum stay and the
+// nights priced out of range to the candidates of a search with dates
+func (r *PropertyRepository) explainNights(filter models.SearchFilter, ids []uint, byID map[uint]*models.CandidateExplanation) error {
+	lastNight := filter.CheckoutDate.AddDate(0, 0, -1)
+
+	var availabilities []models.Availability
+	if err := r.db.Where("property_id IN ? AND date BETWEEN ? AND ?", ids, filter.CheckinDate, lastNight).
+		Find(&availabilities).Error; err != nil {
+		return err
+	}
+	arrival := filter.CheckinDate.Format("2006-01-02")
+	open := make(map[uint]map[string]bool, len(ids))
+	for _, a := range availabilities {
+		date := a.Date.Format("2006-01-02")
+		if date == arrival {
+			byID[a.PropertyID].MinStay = a.MinStay
+		}
+		if !a.Available {
+			continue
+		}
+		if open[a.PropertyID] == nil {
+			open[a.PropertyID] = make(map[string]bool)
+		}
+		open[a.PropertyID][date] = true
+	}
+	for _, id := range ids {
+		for d := filter.CheckinDate; d.Before(filter.CheckoutDate); d = d.AddDate(0, 0, 1) {
+			if date := d.Format("2006-01-02"); !open[id][date] {
+				byID[id].UnavailableNights = append(byID[id].UnavailableNights, date)
+			}
+		}
+	}
+
+	if filter.MinPrice <= 0 && filter.MaxPrice <= 0 {
+		return nil
+	}
+	var pricing []models.Pricing
+	if err := r.db.Where("property_id IN ? AND date BETWEEN ? AND ?", ids, filter.CheckinDate, lastNight).
+		Order("date").Find(&pricing).Error; err != nil {
+		return err
+	}
+	for _, p := range pricing {
+		if p.TotalPrice < filter.MinPrice || p.TotalPrice > filter.MaxPrice {
+			candidate := byID[p.PropertyID]
+			candidate.PricesOutOfRange = append(candidate.PricesOutOfRange, p.Date.Format("2006-01-02"))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// SeasonRepository handles season database operations
+type SeasonRepository struct {
+	db *gorm.DB
+}
+
+// NewSeasonRepository creates a new season repository
+func NewSeasonRepository(db *gorm.DB) *SeasonRepository {
+	return &SeasonRepository{db: db}
+}
+
+// GetSeasonsForProperty retrieves all seasons of a property ordered by start date
+func (r *SeasonRepository) GetSeasonsForProperty(propertyID uint) ([]models.Season, error) {
+	var seasons []models.Season
+	if err := r.db.Where("property_id = ?", propertyID).Order("start_date").Find(&seasons).Error; err != nil {
+		return nil, err
+	}
+	return seasons, nil
+}
+
+// GetSeasonsOverlapping retrieves the seasons of a property overlapping [start, end]
+func (r *SeasonRepository) GetSeasonsOverlapping(propertyID uint, start, end time.Time) ([]models.Season, error) {
+	var seasons []models.Season
+	if err := r.db.Where("property_id = ? AND start_date <= ? AND end_date >= ?", propertyID, end, start).
+		Find(&seasons).Error; err != nil {
+		return nil, err
+	}
+	return seasons, nil
+}
+
+// GetSeasonByID retrieves a season of a property by ID
+func (r *SeasonRepository) GetSeasonByID(propertyID, id uint) (*models.Season, error) {
+	var season models.Season
+	if err := r.db.Where("property_id = ?", propertyID).First(&season, id).Error; err != nil {
+		return nil, err
+	}
+	return &season, nil
+}
+
+// CreateSeason creates a new season
+func (r *SeasonRepository) CreateSeason(season *models.Season) error {
+	return r.db.Create(season).Error
+}
+
+// UpdateSeason updates a season
+func (r *SeasonRepository) UpdateSeason(season *models.Season) error {
+	return r.db.Save(season).Error
+}
+
+// DeleteSeason soft-deletes a season
+func (r *SeasonRepository) DeleteSeason(id uint) error {
+	return r.db.Delete(&models.Season{}, id).Error
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"channelmanager/models"
+)
+
+// SimilarProperties scores the active properties near a property on how close they are,
+// the amenities they share, their capacity and their base price, and returns the limit
+// most similar, most similar first. Properties with coordinates are compared with those
+// within radiusKm, others with those of the same city.
+//
+// Each criterion scores between 0 and 1 and is weighted: distance 0.3 (0.15 without
+// coordinates), amenity overlap 0.3 as the Jaccard index of the amenity sets, capacity
+// 0.2 and price 0.2, both by their relative difference.
+func (r *PropertyRepository) SimilarProperties(propertyID uint, radiusKm float64, limit int) ([]models.SimilarProperty, error) {
+	var similar []models.SimilarProperty
+	err := r.db.Raw(`
+		WITH source AS (
+			SELECT id, latitude, longitude, city, max_guests, base_nightly_rate,
+				(latitude <> 0 OR longitude <> 0) AS located,
+				(SELECT COUNT(*) FROM property_amenities pa JOIN amenities a ON a.id = pa.amenity_id AND a.deleted_at IS NULL WHERE pa.property_id = properties.id) AS amenity_count
+			FROM properties
+			WHERE id = ? AND deleted_at IS NULL
+		),
+		candidates AS (
+			SELECT c.id, c.max_guests, c.base_nightly_rate, s.max_guests AS source_guests,
+				s.base_nightly_rate AS source_rate, s.amenity_count AS source_amenities,
+				CASE WHEN s.located THEN earth_distance(ll_to_earth(c.latitude, c.longitude), ll_to_earth(s.latitude, s.longitude)) / 1000 END AS distance_km,
+				(SELECT COUNT(*) FROM property_amenities pa
+					JOIN property_amenities spa ON spa.amenity_id = pa.amenity_id AND spa.property_id = s.id
+					JOIN amenities a ON a.id = pa.amenity_id AND a.deleted_at IS NULL
+					WHERE pa.property_id = c.id) AS shared_amenities,
+				(SELECT COUNT(*) FROM property_amenities pa JOIN amenities a ON a.id = pa.amenity_id AND a.deleted_at IS NULL WHERE pa.property_id = c.id) AS amenity_count
+			FROM properties c
+			CROSS JOIN source s
+			WHERE c.id <> s.id AND c.deleted_at IS NULL AND c.status = ?
+				AND CASE WHEN s.located
+					THEN earth_distance(ll_to_earth(c.latitude, c.longitude), ll_to_earth(s.latitude, s.longitude)) <= ? * 1000
+					ELSE LOWER(c.city) = LOWER(s.city) END
+		)
+		SELECT id AS property_id, distance_km, shared_amenities,
+			ROUND((
+				0.3 * COALESCE(GREATEST(1 - distance_km / ?, 0), 0.5)
+				+ 0.3 * COALESCE(shared_amenities::float8 / NULLIF(amenity_count + source_amenities - shared_amenities, 0), 0)
+				+ 0.2 * (1 - ABS(max_guests - source_guests)::float8 / GREATEST(max_guests, source_guests, 1))
+				+ 0.2 * CASE WHEN source_rate > 0 THEN GREATEST(1 - ABS(base_nightly_rate - source_rate) / source_rate, 0) ELSE 0 END
+			)::numeric, 4)::float8 AS score
+		FROM candidates
+		ORDER BY score DESC, id
+		LIMIT ?`,
+		propertyID, models.PropertyStatusActive, radiusKm, radiusKm, limit).
+		Scan(&similar).Error
+	return similar, err
+}
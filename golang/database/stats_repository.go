@@ -0,0 +1,115 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StatsRepository handles property statistics database operations
+type StatsRepository struct {
+	db *gorm.DB
+}
+
+// NewStatsRepository creates a new statistics repository
+func NewStatsRepository(db *gorm.DB) *StatsRepository {
+	return &StatsRepository{db: db}
+}
+
+// AddPropertyStats adds counts of one statistic column, views or impressions, to the
+// property rows of a day
+func (r *StatsRepository) AddPropertyStats(column string, date time.Time, counts map[uint]int64) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	rows := make([]models.PropertyStatDay, 0, len(counts))
+	for propertyID, count := range counts {
+		row := models.PropertyStatDay{PropertyID: propertyID, Date: date}
+		switch column {
+		case "views":
+			row.Views = count
+		case "impressions":
+			row.Impressions = count
+		default:
+			return fmt.Errorf("unknown property statistic %q", column)
+		}
+		rows = append(rows, row)
+	}
+
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "property_id"}, {Name: "date"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: column}, Value: gorm.Expr("property_stat_days." + column + " + excluded." + column)},
+			{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("excluded.updated_at")},
+		},
+	}).Create(&rows).Error
+}
+
+// GetPropertyStatDays returns a property's daily views and impressions in [start, end)
+func (r *StatsRepository) GetPropertyStatDays(propertyID uint, start, end time.Time) ([]models.PropertyStatDay, error) {
+	var days []models.PropertyStatDay
+	err := r.db.Where("property_id = ? AND date >= ? AND date < ?", propertyID, start, end).
+		Order("date").Find(&days).Error
+	return days, err
+}
+
+// CountBookingsByDay counts a property's bookings made in [start, end) per day they
+// were made, including those cancelled since
+func (r *StatsRepository) CountBookingsByDay(propertyID uint, start, end time.Time) (map[string]int64, error) {
+	var rows []struct {
+		Day      time.Time
+		Bookings int64
+	}
+	err := r.db.Model(&models.Booking{}).
+		Select("(created_at AT TIME ZONE 'UTC')::date AS day, COUNT(*) AS bookings").
+		Where("property_id = ? AND created_at >= ? AND created_at < ?", propertyID, start, end).
+		Group("day").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Day.Format("2006-01-02")] = row.Bookings
+	}
+	return counts, nil
+}
+
+// TrendingScore is how much recent attention a property had
+type TrendingScore struct {
+	PropertyID uint
+	Score      float64
+}
+
+// TrendingProperties scores active properties, optionally of one city, by their views and
+// bookings since a day. A booking counts as bookingWeight views, and activity loses half
+// its weight every halfLifeDays. It returns the limit highest scores, highest first.
+func (r *StatsRepository) TrendingProperties(city string, since time.Time, halfLifeDays, bookingWeight float64, limit int) ([]TrendingScore, error) {
+	var scores []TrendingScore
+	err := r.db.Raw(`
+		WITH activity AS (
+			SELECT property_id, date, views::float8 AS weight
+			FROM property_stat_days
+			WHERE date >= ?
+			UNION ALL
+			SELECT property_id, (created_at AT TIME ZONE 'UTC')::date, ?::float8
+			FROM bookings
+			WHERE created_at >= ? AND deleted_at IS NULL
+		)
+		SELECT activity.property_id, SUM(activity.weight * EXP(-LN(2) * (CURRENT_DATE - activity.date)::float8 / ?)) AS score
+		FROM activity
+		JOIN properties ON properties.id = activity.property_id AND properties.deleted_at IS NULL AND properties.status = ?
+		WHERE (? = '' OR LOWER(properties.city) = LOWER(?))
+		GROUP BY activity.property_id
+		HAVING SUM(activity.weight) > 0
+		ORDER BY score DESC, activity.property_id
+		LIMIT ?`,
+		since, bookingWeight, since, halfLifeDays, models.PropertyStatusActive, city, city, limit).
+		Scan(&scores).Error
+	return scores, err
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// SyncLogRepository handles channel sync log database operations
+type SyncLogRepository struct {
+	db *gorm.DB
+}
+
+// NewSyncLogRepository creates a new sync log repository
+func NewSyncLogRepository(db *gorm.DB) *SyncLogRepository {
+	return &SyncLogRepository{db: db}
+}
+
+// CreateSyncLog records an exchange with a channel
+func (r *SyncLogRepository) CreateSyncLog(entry *models.SyncLog) error {
+	return r.db.Create(entry).Error
+}
+
+// GetOperationStatus summarizes a channel's exchanges per direction and operation,
+// counting those since the given time
+func (r *SyncLogRepository) GetOperationStatus(channelID string, since time.Time) ([]models.SyncOperationStatus, error) {
+	var status []models.SyncOperationStatus
+	err := r.db.Model(&models.SyncLog{}).
+		Select(`direction, operation,
+			MAX(created_at) FILTER (WHERE result = ?) AS last_success_at,
+			MAX(created_at) FILTER (WHERE result = ?) AS last_failure_at,
+			COUNT(*) FILTER (WHERE result = ? AND created_at >= ?) AS successes24h,
+			COUNT(*) FILTER (WHERE result = ? AND created_at >= ?) AS failures24h`,
+			models.SyncResultSuccess, models.SyncResultFailure,
+			models.SyncResultSuccess, since, models.SyncResultFailure, since).
+		Where("channel_id = ?", channelID).
+		Group("direction, operation").
+		Order("direction, operation").
+		Scan(&status).Error
+	return status, err
+}
+
+// GetRecentFailures lists a channel's latest failed exchanges
+func (r *SyncLogRepository) GetRecentFailures(channelID string, limit int) ([]models.SyncLog, error) {
+	var failures []models.SyncLog
+	err := r.db.Where("channel_id = ? AND result = ?", channelID, models.SyncResultFailure).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&failures).Error
+	return failures, err
+}
+
+// GetPendingDeltas counts, per active mapping of a channel, the availability and pricing
+// rows from the property's today on that changed after the mapping's last successful ARI push
+func (r *SyncLogRepository) GetPendingDeltas(channelID string) ([]models.PendingDeltas, error) {
+	var deltas []models.PendingDeltas
+	err := r.db.Raw(`
+		WITH pushed AS (
+			SELECT m.property_id, COALESCE((
+				SELECT MAX(s.created_at) FROM sync_logs s
+				WHERE s.channel_id = m.channel_id AND s.property_id = m.property_id
+					AND s.operation = ? AND s.direction = ? AND s.result = ?
+			), '-infinity'::timestamptz) AS last_push, `+propertyToday("pr")+` AS today
+			FROM channel_mappings m
+			JOIN properties pr ON pr.id = m.property_id
+			WHERE m.channel_id = ? AND m.active AND m.deleted_at IS NULL
+		)
+		SELECT p.property_id,
+			(SELECT COUNT(*) FROM availabilities a
+				WHERE a.property_id = p.property_id AND a.date >= p.today
+					AND a.deleted_at IS NULL AND a.updated_at > p.last_push) AS availability,
+			(SELECT COUNT(*) FROM pricing pr
+				WHERE pr.property_id = p.property_id AND pr.date >= p.today
+					AND pr.deleted_at IS NULL AND pr.updated_at > p.last_push) AS pricing
+		FROM pushed p
+		ORDER BY p.property_id`,
+		models.SyncOperationARI, models.SyncOutbound, models.SyncResultSuccess, channelID,
+	).Scan(&deltas).Error
+	return deltas, err
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// TaxRuleRepository handles tax rule database operations
+type TaxRuleRepository struct {
+	db *gorm.DB
+}
+
+// NewTaxRuleRepository creates a new tax rule repository
+func NewTaxRuleRepository(db *gorm.DB) *TaxRuleRepository {
+	return &TaxRuleRepository{db: db}
+}
+
+// GetAllTaxRules retrieves tax rules, optionally restricted to a country
+func (r *TaxRuleRepository) GetAllTaxRules(country string) ([]models.TaxRule, error) {
+	query := r.db.Order("country, state, city, tax_type")
+	if country != "" {
+		query = query.Where("LOWER(country) = LOWER(?)", country)
+	}
+
+	var rules []models.TaxRule
+	if err := query.Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// GetTaxRuleByID retrieves a tax rule by ID
+func (r *TaxRuleRepository) GetTaxRuleByID(id uint) (*models.TaxRule, error) {
+	var rule models.TaxRule
+	if err := r.db.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// GetTaxRulesForLocation retrieves active rules whose jurisdiction contains the location
+func (r *TaxRuleRepository) GetTaxRulesForLocation(country, state, city string) ([]models.TaxRule, error) {
+	var rules []models.TaxRule
+	if err := r.db.Where("active = ? AND LOWER(country) = LOWER(?)", true, country).
+		Where("(state = '' OR LOWER(state) = LOWER(?))", state).
+		Where("(city = '' OR LOWER(city) = LOWER(?))", city).
+		Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// CreateTaxRule creates a new tax rule
+func (r *TaxRuleRepository) CreateTaxRule(rule *models.TaxRule) error {
+	return r.db.Create(rule).Error
+}
+
+// UpdateTaxRule updates a tax rule
+func (r *TaxRuleRepository) UpdateTaxRule(rule *models.TaxRule) error {
+	return r.db.Save(rule).Error
+}
+
+// DeleteTaxRule soft-deletes a tax rule
+func (r *TaxRuleRepository) DeleteTaxRule(id uint) error {
+	return r.db.Delete(&models.TaxRule{}, id).Error
+}
@@ -0,0 +1,240 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvitationExpired is returned when accepting an invitation past its expiry
+var ErrInvitationExpired = errors.New("invitation expired")
+
+// TeamRepository handles property owners, their team members and their permissions
+type TeamRepository struct {
+	db *gorm.DB
+}
+
+// NewTeamRepository creates a new team repository
+func NewTeamRepository(db *gorm.DB) *TeamRepository {
+	return &TeamRepository{db: db}
+}
+
+// ListOwners retrieves property owners by name
+func (r *TeamRepository) ListOwners(limit, offset int) ([]models.Owner, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.Owner{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var owners []models.Owner
+	if err := r.db.Order("name, id").Limit(limit).Offset(offset).Find(&owners).Error; err != nil {
+		return nil, 0, err
+	}
+	return owners, total, nil
+}
+
+// GetOwnerByID retrieves an owner by ID
+func (r *TeamRepository) GetOwnerByID(id uint) (*models.Owner, error) {
+	var owner models.Owner
+	if err := r.db.First(&owner, id).Error; err != nil {
+		return nil, err
+	}
+	return &owner, nil
+}
+
+// GetOwnerByTokenHash retrieves the owner whose API token has the given hash
+func (r *TeamRepository) GetOwnerByTokenHash(hash string) (*models.Owner, error) {
+	var owner models.Owner
+	if err := r.db.Where("token_hash = ?", hash).First(&owner).Error; err != nil {
+		return nil, err
+	}
+	return &owner, nil
+}
+
+// OwnerEmailTaken reports whether an owner already uses the email address
+func (r *TeamRepository) OwnerEmailTaken(email string) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Owner{}).Where("LOWER(email) = LOWER(?)", email).Count(&count).Error
+	return count > 0, err
+}
+
+// CreateOwner creates a new owner
+func (r *TeamRepository) CreateOwner(owner *models.Owner) error {
+	return r.db.Create(owner).Error
+}
+
+// UpdateOwnerToken replaces an owner's API token hash, revoking the previous token
+func (r *TeamRepository) UpdateOwnerToken(owner *models.Owner, hash string) error {
+	owner.TokenHash = hash
+	return r.db.Model(owner).Update("token_hash", hash).Error
+}
+
+// GetPropertyOwnerID retrieves the owner of a property, nil when it has none
+func (r *TeamRepository) GetPropertyOwnerID(propertyID uint) (*uint, error) {
+	var property models.Property
+	if err := r.db.Select("id", "owner_id").First(&property, propertyID).Error; err != nil {
+		return nil, err
+	}
+	return property.OwnerID, nil
+}
+
+// SetPropertyOwner assigns a property to an owner, or releases it with a nil ownerID,
+// and records the change. The grants the previous owner's team had on it are removed.
+func (r *TeamRepository) SetPropertyOwner(property *models.Property, ownerID *uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Property{}).Where("id = ?", property.ID).Update("owner_id", ownerID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("property_id = ?", property.ID).Delete(&models.TeamGrant{}).Error; err != nil {
+			return err
+		}
+
+		property.OwnerID = ownerID
+		event := changeEvent("UPDATE", "properties", property.ID, property)
+		return tx.Create(&event).Error
+	})
+}
+
+// GetOwnedPropertyIDs retrieves which of the given properties belong to an owner
+func (r *TeamRepository) GetOwnedPropertyIDs(ownerID uint, propertyIDs []uint) ([]uint, error) {
+	var owned []uint
+	err := r.db.Model(&models.Property{}).
+		Where("owner_id = ? AND id IN ?", ownerID, propertyIDs).
+		Pluck("id", &owned).Error
+	return owned, err
+}
+
+// GetMemberGrant retrieves the active team member whose API token has the given hash,
+// with its grant on a property preloaded when it has one
+func (r *TeamRepository) GetMemberGrant(hash string, propertyID uint) (*models.TeamMember, error) {
+	var member models.TeamMember
+	if err := r.db.Where("token_hash = ? AND status = ?", hash, models.TeamMemberActive).
+		Preload("Grants", "property_id = ?", propertyID).
+		First(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// ListTeamMembers retrieves an owner's team members with their grants, by email
+func (r *TeamRepository) ListTeamMembers(ownerID uint) ([]models.TeamMember, error) {
+	members := []models.TeamMember{}
+	err := r.db.Where("owner_id = ?", ownerID).
+		Preload("Grants", func(db *gorm.DB) *gorm.DB { return db.Order("property_id") }).
+		Order("email").Find(&members).Error
+	return members, err
+}
+
+// GetTeamMember retrieves one of an owner's team members with its grants
+func (r *TeamRepository) GetTeamMember(ownerID, id uint) (*models.TeamMember, error) {
+	var member models.TeamMember
+	if err := r.db.Where("owner_id = ?", ownerID).
+		Preload("Grants", func(db *gorm.DB) *gorm.DB { return db.Order("property_id") }).
+		First(&member, id).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetTeamMemberByEmail retrieves one of an owner's team members by email address
+func (r *TeamRepository) GetTeamMemberByEmail(ownerID uint, email string) (*models.TeamMember, error) {
+	var member models.TeamMember
+	if err := r.db.Where("owner_id = ? AND LOWER(email) = LOWER(?)", ownerID, email).First(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// SaveInvitation stores an invitation, new or reissued to a member who has not accepted
+// or was revoked, along with the grants it offers
+func (r *TeamRepository) SaveInvitation(member *models.TeamMember, grants []models.TeamGrant) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Grants").Save(member).Error; err != nil {
+			return err
+		}
+		return replaceGrants(tx, member, grants)
+	})
+}
+
+// UpdateGrants replaces the grants of a team member
+func (r *TeamRepository) UpdateGrants(member *models.TeamMember, grants []models.TeamGrant) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return replaceGrants(tx, member, grants)
+	})
+}
+
+// AcceptInvitation activates the invited member whose invitation token has the given
+// hash, giving it the API token hash. It returns gorm.ErrRecordNotFound for unknown or
+// already accepted invitations and ErrInvitationExpired for expired ones.
+func (r *TeamRepository) AcceptInvitation(invitationHash, tokenHash string, at time.Time) (*models.TeamMember, error) {
+	var member models.TeamMember
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("invitation_hash = ? AND status = ?", invitationHash, models.TeamMemberInvited).
+			Preload("Grants").First(&member).Error; err != nil {
+			return err
+		}
+		if member.InvitationExpiresAt != nil && at.After(*member.InvitationExpiresAt) {
+			return ErrInvitationExpired
+		}
+
+		result := tx.Model(&models.TeamMember{}).
+			Where("id = ? AND status = ?", member.ID, models.TeamMemberInvited).
+			Updates(map[string]interface{}{
+				"status":                models.TeamMemberActive,
+				"token_hash":            tokenHash,
+				"invitation_hash":       "",
+				"invitation_expires_at": nil,
+				"accepted_at":           at,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		member.Status = models.TeamMemberActive
+		member.InvitationExpiresAt = nil
+		member.AcceptedAt = &at
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// RevokeTeamMember revokes a team member's access and pending invitation; its grants
+// are kept so a new invitation can restore them
+func (r *TeamRepository) RevokeTeamMember(member *models.TeamMember, at time.Time) error {
+	member.Status = models.TeamMemberRevoked
+	member.RevokedAt = &at
+	member.InvitationExpiresAt = nil
+	return r.db.Model(member).Updates(map[string]interface{}{
+		"status":                models.TeamMemberRevoked,
+		"token_hash":            "",
+		"invitation_hash":       "",
+		"invitation_expires_at": nil,
+		"revoked_at":            at,
+	}).Error
+}
+
+// replaceGrants replaces the grants of a team member within a transaction
+func replaceGrants(tx *gorm.DB, member *models.TeamMember, grants []models.TeamGrant) error {
+	if err := tx.Where("team_member_id = ?", member.ID).Delete(&models.TeamGrant{}).Error; err != nil {
+		return err
+	}
+	for i := range grants {
+		grants[i].TeamMemberID = member.ID
+	}
+	if len(grants) > 0 {
+		if err := tx.Create(&grants).Error; err != nil {
+			return err
+		}
+	}
+	member.Grants = grants
+	return nil
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TranslationRepository handles property translation database operations
+type TranslationRepository struct {
+	db *gorm.DB
+}
+
+// NewTranslationRepository creates a new translation repository
+func NewTranslationRepository(db *gorm.DB) *TranslationRepository {
+	return &TranslationRepository{db: db}
+}
+
+// GetTranslationsForProperty retrieves all translations of a property
+func (r *TranslationRepository) GetTranslationsForProperty(propertyID uint) ([]models.PropertyTranslation, error) {
+	var translations []models.PropertyTranslation
+	if err := r.db.Where("property_id = ?", propertyID).Order("locale").Find(&translations).Error; err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
+
+// GetTranslationsForProperties retrieves the translations of several properties keyed by property ID
+func (r *TranslationRepository) GetTranslationsForProperties(propertyIDs []uint) (map[uint][]models.PropertyTranslation, error) {
+	byProperty := make(map[uint][]models.PropertyTranslation)
+	if len(propertyIDs) == 0 {
+		return byProperty, nil
+	}
+
+	var translations []models.PropertyTranslation
+	if err := r.db.Where("property_id IN ?", propertyIDs).Find(&translations).Error; err != nil {
+		return nil, err
+	}
+	for _, t := range translations {
+		byProperty[t.PropertyID] = append(byProperty[t.PropertyID], t)
+	}
+	return byProperty, nil
+}
+
+// UpsertTranslation creates or replaces the translation of a property for its locale
+func (r *TranslationRepository) UpsertTranslation(translation *models.PropertyTranslation) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "property_id"}, {Name: "locale"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "description", "house_rules", "updated_at"}),
+	}).Create(translation).Error
+}
+
+// DeleteTranslation deletes a property's translation for a locale
+func (r *TranslationRepository) DeleteTranslation(propertyID uint, locale string) (bool, error) {
+	result := r.db.Where("property_id = ? AND locale = ?", propertyID, locale).Delete(&models.PropertyTranslation{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetReferenceTranslations retrieves all translations of an amenity or condition's name
+func (r *TranslationRepository) GetReferenceTranslations(kind string, referenceID uint) ([]models.ReferenceTranslation, error) {
+	var translations []models.ReferenceTranslation
+	if err := r.db.Where("kind = ? AND reference_id = ?", kind, referenceID).Order("locale").Find(&translations).Error; err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
+
+// GetReferenceNames returns the names of amenities or conditions translated to a
+// locale, keyed by their ID
+func (r *TranslationRepository) GetReferenceNames(kind, locale string) (map[uint]string, error) {
+	var translations []models.ReferenceTranslation
+	if err := r.db.Where("kind = ? AND locale = ?", kind, locale).Find(&translations).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(translations))
+	for _, t := range translations {
+		names[t.ReferenceID] = t.Name
+	}
+	return names, nil
+}
+
+// GetReferenceLocales returns the locales amenity or condition names are translated to
+func (r *TranslationRepository) GetReferenceLocales() ([]string, error) {
+	var locales []string
+	err := r.db.Model(&models.ReferenceTranslation{}).Distinct("locale").Order("locale").Pluck("locale", &locales).Error
+	return locales, err
+}
+
+// UpsertReferenceTranslation creates or replaces the name of an amenity or condition
+// for its locale
+func (r *TranslationRepository) UpsertReferenceTranslation(translation *models.ReferenceTranslation) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "kind"}, {Name: "reference_id"}, {Name: "locale"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "updated_at"}),
+	}).Create(translation).Error
+}
+
+// DeleteReferenceTranslation deletes the name of an amenity or condition for a locale
+func (r *TranslationRepository) DeleteReferenceTranslation(kind string, referenceID uint, locale string) (bool, error) {
+	result := r.db.Where("kind = ? AND reference_id = ? AND locale = ?", kind, referenceID, locale).Delete(&models.ReferenceTranslation{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,215 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Kinds of soft-deleted records that can be restored or purged, named after their tables
+const (
+	DeletedProperties = "properties"
+	DeletedAmenities  = "amenities"
+	DeletedConditions = "conditions"
+)
+
+// ErrPurgeBlocked is returned when purging a property that bookings or ledger entries
+// still refer to
+var ErrPurgeBlocked = errors.New("property has bookings")
+
+// PurgeConfig holds the retention of soft-deleted records
+type PurgeConfig struct {
+	Schedule      string // when expired records are purged
+	RetentionDays int    // records deleted longer ago are purged; zero disables purging
+}
+
+// DeletedRecord is a soft-deleted property, amenity or condition
+type DeletedRecord struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// PurgeResult counts the records a purge removed for good
+type PurgeResult struct {
+	Properties int `json:"properties"`
+	Amenities  int `json:"amenities"`
+	Conditions int `json:"conditions"`
+	Skipped    int `json:"skipped"` // properties kept because bookings refer to them
+}
+
+// TrashRepository lists, restores and purges soft-deleted records
+type TrashRepository struct {
+	db *gorm.DB
+}
+
+// NewTrashRepository creates a new trash repository
+func NewTrashRepository(db *gorm.DB) *TrashRepository {
+	return &TrashRepository{db: db}
+}
+
+// ListDeleted retrieves soft-deleted records of a kind, most recently deleted first
+func (r *TrashRepository) ListDeleted(kind string, limit, offset int) ([]DeletedRecord, int64, error) {
+	query := r.db.Table(kind).Where("deleted_at IS NOT NULL")
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	records := []DeletedRecord{}
+	if err := query.Select("id", "name", "deleted_at").
+		Order("deleted_at DESC, id").
+		Limit(limit).Offset(offset).
+		Scan(&records).Error; err != nil {
+		return nil, 0, err
+	}
+	return records, total, nil
+}
+
+// Restore undeletes a soft-deleted record and records its return. It returns the IDs of
+// the properties the record belongs to: the property itself, or those an amenity or
+// condition is still attached to. It is gorm.ErrRecordNotFound if the record is not
+// soft-deleted.
+func (r *TrashRepository) Restore(kind string, id uint) ([]uint, error) {
+	var propertyIDs []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(kind).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		switch kind {
+		case DeletedProperties:
+			propertyIDs = []uint{id}
+			var property models.Property
+			if err := tx.First(&property, id).Error; err != nil {
+				return err
+			}
+			event := changeEvent("INSERT", "properties", id, property)
+			return tx.Create(&event).Error
+		case DeletedAmenities:
+			return tx.Table("property_amenities").Where("amenity_id = ?", id).Pluck("property_id", &propertyIDs).Error
+		default:
+			return tx.Table("property_conditions").Where("condition_id = ?", id).Pluck("property_id", &propertyIDs).Error
+		}
+	})
+	return propertyIDs, err
+}
+
+// Purge permanently deletes a soft-deleted record along with everything that refers to
+// it. It is gorm.ErrRecordNotFound if the record is not soft-deleted, and
+// ErrPurgeBlocked for a property that bookings or ledger entries refer to.
+func (r *TrashRepository) Purge(kind string, id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var found int64
+		if err := tx.Table(kind).Where("id = ? AND deleted_at IS NOT NULL", id).Count(&found).Error; err != nil {
+			return err
+		}
+		if found == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		switch kind {
+		case DeletedProperties:
+			return purgeProperty(tx, id)
+		case DeletedAmenities:
+			return purgeReference(tx, "property_amenities", "amenity_id", models.ContentRecordAmenity, models.ReferenceAmenity, &models.Amenity{}, id)
+		default:
+			return purgeReference(tx, "property_conditions", "condition_id", models.ContentRecordCondition, models.ReferenceCondition, &models.Condition{}, id)
+		}
+	})
+}
+
+// PurgeDeletedBefore permanently deletes the properties, amenities and conditions
+// soft-deleted before cutoff. Properties that bookings refer to are skipped.
+func (r *TrashRepository) PurgeDeletedBefore(cutoff time.Time) (PurgeResult, error) {
+	var result PurgeResult
+	for _, kind := range []string{DeletedProperties, DeletedAmenities, DeletedConditions} {
+		var ids []uint
+		if err := r.db.Table(kind).Where("deleted_at < ?", cutoff).Order("id").Pluck("id", &ids).Error; err != nil {
+			return result, err
+		}
+		for _, id := range ids {
+			err := r.Purge(kind, id)
+			switch {
+			case errors.Is(err, ErrPurgeBlocked):
+				result.Skipped++
+				continue
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				// Restored meanwhile
+				continue
+			case err != nil:
+				return result, err
+			}
+
+			switch kind {
+			case DeletedProperties:
+				result.Properties++
+			case DeletedAmenities:
+				result.Amenities++
+			default:
+				result.Conditions++
+			}
+		}
+	}
+	return result, nil
+}
+
+// purgeProperty deletes a property and the rows of every table keyed by its ID, unless
+// bookings or ledger entries refer to it
+func purgeProperty(tx *gorm.DB, id uint) error {
+	for _, table := range []string{"bookings", "ledger_entries"} {
+		var referring int64
+		if err := tx.Table(table).Where("property_id = ?", id).Count(&referring).Error; err != nil {
+			return err
+		}
+		if referring > 0 {
+			return ErrPurgeBlocked
+		}
+	}
+
+	var tables []string
+	if err := tx.Raw(`
+		SELECT c.table_name
+		FROM information_schema.columns c
+		JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = current_schema() AND c.column_name = 'property_id'
+			AND t.table_type = 'BASE TABLE' AND c.table_name <> 'properties'
+		ORDER BY c.table_name`).
+		Scan(&tables).Error; err != nil {
+		return err
+	}
+	for _, table := range tables {
+		query := tx.Exec("DELETE FROM ? WHERE property_id = ?", clause.Table{Name: table}, id)
+		if table == "duplicate_candidates" {
+			query = tx.Exec("DELETE FROM ? WHERE property_id = ? OR duplicate_id = ?", clause.Table{Name: table}, id, id)
+		}
+		if query.Error != nil {
+			return query.Error
+		}
+	}
+	return tx.Unscoped().Delete(&models.Property{}, id).Error
+}
+
+// purgeReference deletes an amenity or condition along with its property links, content
+// code mappings and translations
+func purgeReference(tx *gorm.DB, relationTable, relationColumn, contentRecord, translationKind string, model interface{}, id uint) error {
+	if err := tx.Exec("DELETE FROM "+relationTable+" WHERE "+relationColumn+" = ?", id).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("record_type = ? AND record_id = ?", contentRecord, id).Delete(&models.ContentCodeMapping{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("kind = ? AND reference_id = ?", translationKind, id).Delete(&models.ReferenceTranslation{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(model, id).Error
+}
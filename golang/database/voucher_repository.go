@@ -0,0 +1,182 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrVoucherUnavailable is returned when a voucher is void, expired or its balance does
+// not cover the amount spent
+var ErrVoucherUnavailable = errors.New("voucher cannot be redeemed")
+
+// VoucherRepository handles voucher database operations
+type VoucherRepository struct {
+	db *gorm.DB
+}
+
+// NewVoucherRepository creates a new voucher repository
+func NewVoucherRepository(db *gorm.DB) *VoucherRepository {
+	return &VoucherRepository{db: db}
+}
+
+// CreateVoucher issues a voucher, recording its initial balance
+func (r *VoucherRepository) CreateVoucher(voucher *models.Voucher) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		voucher.Balance = voucher.InitialAmount
+		voucher.Status = models.VoucherStatusActive
+		if err := tx.Create(voucher).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.VoucherEntry{
+			VoucherID:   voucher.ID,
+			Type:        models.VoucherIssue,
+			Amount:      voucher.InitialAmount,
+			Description: "Issued",
+		}).Error
+	})
+}
+
+// GetVoucherByID retrieves a voucher with its balance changes, oldest first
+func (r *VoucherRepository) GetVoucherByID(id uint) (*models.Voucher, error) {
+	var voucher models.Voucher
+	if err := r.db.Preload("Entries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at, id")
+	}).First(&voucher, id).Error; err != nil {
+		return nil, err
+	}
+	return &voucher, nil
+}
+
+// GetVoucherByCodeHash retrieves the voucher whose code has the given hash
+func (r *VoucherRepository) GetVoucherByCodeHash(codeHash string) (*models.Voucher, error) {
+	var voucher models.Voucher
+	if err := r.db.Where("code_hash = ?", codeHash).First(&voucher).Error; err != nil {
+		return nil, err
+	}
+	return &voucher, nil
+}
+
+// ListVouchers returns one page of vouchers, newest first, optionally of one status,
+// with the number of matching vouchers
+func (r *VoucherRepository) ListVouchers(status string, limit, offset int) ([]models.Voucher, int64, error) {
+	query := r.db.Model(&models.Voucher{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var vouchers []models.Voucher
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&vouchers).Error
+	return vouchers, total, err
+}
+
+// VoidVoucher withdraws a voucher and its remaining balance. Refunds of bookings it was
+// spent on still return their amount, but it can no longer be redeemed. It returns
+// ErrVoucherUnavailable if the voucher is already void.
+func (r *VoucherRepository) VoidVoucher(id uint) (*models.Voucher, error) {
+	var voucher models.Voucher
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&voucher, id).Error; err != nil {
+			return err
+		}
+		if voucher.Status == models.VoucherStatusVoid {
+			return ErrVoucherUnavailable
+		}
+		if voucher.Balance > 0 {
+			if err := tx.Create(&models.VoucherEntry{
+				VoucherID:   voucher.ID,
+				Type:        models.VoucherVoiding,
+				Amount:      -voucher.Balance,
+				Description: "Voided",
+			}).Error; err != nil {
+				return err
+			}
+		}
+		voucher.Balance = 0
+		voucher.Status = models.VoucherStatusVoid
+		return tx.Model(&voucher).Updates(map[string]interface{}{"balance": 0, "status": models.VoucherStatusVoid}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &voucher, nil
+}
+
+// redeemVouchers spends voucher balances on a booking. The vouchers are locked in ID
+// order so concurrent bookings spending the same vouchers cannot deadlock.
+func redeemVouchers(tx *gorm.DB, booking *models.Booking, vouchers []models.AppliedVoucher) error {
+	vouchers = append([]models.AppliedVoucher(nil), vouchers...)
+	sort.Slice(vouchers, func(i, j int) bool { return vouchers[i].VoucherID < vouchers[j].VoucherID })
+
+	bookingID := booking.ID
+	for _, applied := range vouchers {
+		if err := postVoucherEntry(tx, &models.VoucherEntry{
+			VoucherID:   applied.VoucherID,
+			BookingID:   &bookingID,
+			Type:        models.VoucherRedemption,
+			Amount:      -applied.Amount,
+			Description: fmt.Sprintf("Spent on booking %d", booking.ID),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refundVouchers returns the voucher balances spent on a cancelled booking, once per
+// booking
+func refundVouchers(tx *gorm.DB, booking *models.Booking) error {
+	var redemptions []models.VoucherEntry
+	if err := tx.Where("booking_id = ? AND type = ?", booking.ID, models.VoucherRedemption).
+		Order("voucher_id").Find(&redemptions).Error; err != nil {
+		return err
+	}
+
+	for _, redemption := range redemptions {
+		if err := postVoucherEntry(tx, &models.VoucherEntry{
+			VoucherID:   redemption.VoucherID,
+			BookingID:   redemption.BookingID,
+			Type:        models.VoucherRefund,
+			Amount:      -redemption.Amount,
+			Description: fmt.Sprintf("Cancellation of booking %d", booking.ID),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// postVoucherEntry records a balance change and applies it to the voucher, which is
+// locked so concurrent changes are serialized. An entry for a booking that already has
+// one of its type is skipped. Spending returns ErrVoucherUnavailable if the voucher is
+// no longer redeemable or its balance would drop below zero.
+func postVoucherEntry(tx *gorm.DB, entry *models.VoucherEntry) error {
+	var voucher models.Voucher
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&voucher, entry.VoucherID).Error; err != nil {
+		return err
+	}
+	balance := math.Round((voucher.Balance+entry.Amount)*100) / 100
+	if entry.Amount < 0 && (!voucher.Redeemable(time.Now()) || balance < 0) {
+		return ErrVoucherUnavailable
+	}
+
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(entry)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	return tx.Model(&voucher).Update("balance", balance).Error
+}
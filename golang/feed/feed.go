@@ -0,0 +1,288 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"channelmanager/channels"
+	"channelmanager/database"
+	"channelmanager/models"
+	"channelmanager/storage"
+
+	"gorm.io/gorm"
+)
+
+// DefaultVariant is the feed of all properties with OTA standard amenity codes
+const DefaultVariant = "default"
+
+// Formats in which every feed variant is rendered
+var Formats = []string{"json", "xml"}
+
+// Config holds catalog feed generation configuration
+type Config struct {
+	Interval time.Duration // how often feeds are regenerated; zero disables the schedule
+}
+
+// Info describes a generated feed variant
+type Info struct {
+	Variant     string    `json:"variant"` // DefaultVariant or a channel ID
+	Properties  int       `json:"properties"`
+	Keys        []string  `json:"keys"` // object storage keys of the rendered formats
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// Catalog is the JSON form of a feed
+type Catalog struct {
+	Variant     string                     `json:"variant"`
+	GeneratedAt time.Time                  `json:"generated_at"`
+	Properties  []channels.PropertyContent `json:"properties"`
+}
+
+// Generator renders property catalog feeds to object storage for metasearch and
+// advertising partners, one variant per active channel plus the default
+type Generator struct {
+	config       Config
+	content      *channels.ContentPushService
+	propertyRepo *database.PropertyRepository
+	channelRepo  *database.ChannelRepository
+	store        storage.ObjectStore
+}
+
+// NewGenerator creates a new feed generator
+func NewGenerator(db *gorm.DB, content *channels.ContentPushService, store storage.ObjectStore, config Config) *Generator {
+	return &Generator{
+		config:       config,
+		content:      content,
+		propertyRepo: database.NewPropertyRepository(db),
+		channelRepo:  database.NewChannelRepository(db),
+		store:        store,
+	}
+}
+
+// Key returns the object storage key of a feed variant in a format
+func Key(variant, format string) string {
+	return fmt.Sprintf("feeds/%s/properties.%s", variant, format)
+}
+
+// Generate renders the default feed and a feed for every active channel
+func (g *Generator) Generate(ctx context.Context) ([]Info, error) {
+	activeChannels, err := g.channelRepo.GetAllChannels()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load channels: %w", err)
+	}
+
+	info, err := g.GenerateVariant(ctx, DefaultVariant)
+	if err != nil {
+		return nil, err
+	}
+	feeds := []Info{*info}
+
+	for _, channel := range activeChannels {
+		if !channel.Active {
+			continue
+		}
+		info, err := g.GenerateVariant(ctx, channel.ID)
+		if err != nil {
+			return feeds, err
+		}
+		feeds = append(feeds, *info)
+	}
+	return feeds, nil
+}
+
+// GenerateVariant renders one feed variant: DefaultVariant lists every property with
+// standard codes, a channel ID lists the channel's mapped properties with its codes
+func (g *Generator) GenerateVariant(ctx context.Context, variant string) (*Info, error) {
+	var propertyIDs []uint
+	channelID := ""
+	if variant != DefaultVariant {
+		channelID = variant
+		mappings, err := g.channelRepo.GetMappingsForChannel(channelID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load mappings of %s: %w", channelID, err)
+		}
+		propertyIDs = []uint{}
+		for _, mapping := range mappings {
+			if mapping.Active {
+				propertyIDs = append(propertyIDs, mapping.PropertyID)
+			}
+		}
+	}
+
+	properties, err := g.propertyRepo.GetPropertiesWithContent(propertyIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load properties: %w", err)
+	}
+
+	catalog := Catalog{
+		Variant:     variant,
+		GeneratedAt: time.Now().UTC(),
+		Properties:  make([]channels.PropertyContent, 0, len(properties)),
+	}
+	for _, property := range properties {
+		content, err := g.content.BuildContent(channelID, property)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build content of property %d: %w", property.ID, err)
+		}
+		content.Unmapped = nil // internal mapping gaps are not published
+		catalog.Properties = append(catalog.Properties, content)
+	}
+
+	info := &Info{Variant: variant, Properties: len(catalog.Properties), GeneratedAt: catalog.GeneratedAt}
+	for _, format := range Formats {
+		data, contentType, err := render(catalog, format)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s feed: %w", format, err)
+		}
+		key := Key(variant, format)
+		if err := g.store.Put(ctx, key, data, contentType); err != nil {
+			return nil, fmt.Errorf("failed to store %s: %w", key, err)
+		}
+		info.Keys = append(info.Keys, key)
+	}
+	return info, nil
+}
+
+// ContentType returns the MIME type of a feed format
+func ContentType(format string) string {
+	if format == "xml" {
+		return "application/xml; charset=utf-8"
+	}
+	return "application/json; charset=utf-8"
+}
+
+// render encodes a catalog in a format
+func render(catalog Catalog, format string) ([]byte, string, error) {
+	if format == "json" {
+		data, err := json.Marshal(catalog)
+		return data, ContentType(format), err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(xmlCatalogFrom(catalog)); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), ContentType(format), nil
+}
+
+// xmlCatalog is the XML form of a feed
+type xmlCatalog struct {
+	XMLName     xml.Name     `xml:"listings"`
+	Variant     string       `xml:"variant,attr"`
+	GeneratedAt string       `xml:"generated_at,attr"`
+	Listings    []xmlListing `xml:"listing"`
+}
+
+type xmlListing struct {
+	ID           uint             `xml:"id,attr"`
+	Locale       string           `xml:"locale,attr"`
+	Name         string           `xml:"name"`
+	Description  string           `xml:"description"`
+	City         string           `xml:"address>city"`
+	Country      string           `xml:"address>country"`
+	Latitude     float64          `xml:"geo>latitude"`
+	Longitude    float64          `xml:"geo>longitude"`
+	MaxGuests    int              `xml:"max_guests"`
+	Bedrooms     int              `xml:"bedrooms"`
+	Bathrooms    int              `xml:"bathrooms"`
+	HouseRules   string           `xml:"house_rules,omitempty"`
+	Rules        xmlRules         `xml:"rules"`
+	Photos       []xmlPhoto       `xml:"photos>photo"`
+	Amenities    []xmlCode        `xml:"amenities>amenity"`
+	Conditions   []xmlCode        `xml:"conditions>condition"`
+	Translations []xmlTranslation `xml:"translations>translation"`
+}
+
+type xmlRules struct {
+	QuietHoursStart     string `xml:"quiet_hours>start,omitempty"`
+	QuietHoursEnd       string `xml:"quiet_hours>end,omitempty"`
+	PartiesAllowed      bool   `xml:"parties_allowed"`
+	AdditionalGuests    bool   `xml:"additional_guests>allowed"`
+	MaxAdditionalGuests int    `xml:"additional_guests>max,omitempty"`
+	ChildrenAllowed     bool   `xml:"children>allowed"`
+	MinChildAge         int    `xml:"children>min_age,omitempty"`
+	InfantsAllowed      bool   `xml:"children>infants_allowed"`
+	CribsAvailable      bool   `xml:"children>cribs_available"`
+}
+
+type xmlPhoto struct {
+	URL     string `xml:"url,attr"`
+	Caption string `xml:"caption,attr,omitempty"`
+}
+
+type xmlCode struct {
+	CodeList string `xml:"code_list,attr"`
+	Code     string `xml:"code,attr"`
+	Name     string `xml:",chardata"`
+}
+
+type xmlTranslation struct {
+	Locale      string `xml:"locale,attr"`
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	HouseRules  string `xml:"house_rules,omitempty"`
+}
+
+// xmlCatalogFrom converts a catalog to its XML form
+func xmlCatalogFrom(catalog Catalog) xmlCatalog {
+	out := xmlCatalog{
+		Variant:     catalog.Variant,
+		GeneratedAt: catalog.GeneratedAt.Format(time.RFC3339),
+		Listings:    make([]xmlListing, 0, len(catalog.Properties)),
+	}
+	for _, p := range catalog.Properties {
+		listing := xmlListing{
+			ID:          p.PropertyID,
+			Locale:      p.DefaultLocale,
+			Name:        p.Name,
+			Description: p.Description,
+			City:        p.City,
+			Country:     p.Country,
+			Latitude:    p.Latitude,
+			Longitude:   p.Longitude,
+			MaxGuests:   p.MaxGuests,
+			Bedrooms:    p.Bedrooms,
+			Bathrooms:   p.Bathrooms,
+			HouseRules:  p.HouseRules,
+			Rules: xmlRules{
+				QuietHoursStart:     p.Rules.QuietHoursStart,
+				QuietHoursEnd:       p.Rules.QuietHoursEnd,
+				PartiesAllowed:      p.Rules.PartiesAllowed,
+				AdditionalGuests:    p.Rules.AdditionalGuestsAllowed,
+				MaxAdditionalGuests: p.Rules.MaxAdditionalGuests,
+				ChildrenAllowed:     p.Rules.ChildrenAllowed,
+				MinChildAge:         p.Rules.MinChildAge,
+				InfantsAllowed:      p.Rules.InfantsAllowed,
+				CribsAvailable:      p.Rules.CribsAvailable,
+			},
+		}
+		for _, photo := range p.Photos {
+			listing.Photos = append(listing.Photos, xmlPhoto{URL: photo.URL, Caption: photo.Caption})
+		}
+		for _, code := range p.Amenities {
+			listing.Amenities = append(listing.Amenities, xmlCode{CodeList: code.CodeList, Code: code.Code, Name: code.Name})
+		}
+		for _, code := range p.Conditions {
+			listing.Conditions = append(listing.Conditions, xmlCode{CodeList: code.CodeList, Code: code.Code, Name: code.Name})
+		}
+		listing.Translations = xmlTranslations(p.Translations)
+		out.Listings = append(out.Listings, listing)
+	}
+	return out
+}
+
+// xmlTranslations converts property translations to their XML form
+func xmlTranslations(translations []models.PropertyTranslation) []xmlTranslation {
+	out := make([]xmlTranslation, 0, len(translations))
+	for _, t := range translations {
+		out = append(out, xmlTranslation{Locale: t.Locale, Name: t.Name, Description: t.Description, HouseRules: t.HouseRules})
+	}
+	return out
+}
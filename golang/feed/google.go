@@ -0,0 +1,561 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"channelmanager/cache"
+	"channelmanager/database"
+	"channelmanager/models"
+	"channelmanager/storage"
+
+	"gorm.io/gorm"
+)
+
+// GoogleVariant names the Google Vacation Rentals feed in storage keys and routes
+const GoogleVariant = "google"
+
+// Files of the Google feed, served at /feeds/google/<file>
+const (
+	GoogleHotelList   = "hotel_list.xml"  // listing content
+	GooglePointOfSale = "pos.xml"         // booking landing page
+	GoogleTransaction = "transaction.xml" // prices and availability by check-in date
+	googleReportFile  = "report.json"     // validation report, served to administrators only
+)
+
+// GoogleFiles are the feed files partners may fetch
+var GoogleFiles = []string{GoogleHotelList, GooglePointOfSale, GoogleTransaction}
+
+// googleRefreshBatch bounds the properties re-rendered by one incremental refresh
+const googleRefreshBatch = 500
+
+// Validation issue severities
+const (
+	GoogleIssueError   = "error"   // the listing is left out of the feed
+	GoogleIssueWarning = "warning" // the listing is published but may be rejected or shown without prices
+)
+
+// GoogleConfig holds Google Vacation Rentals feed configuration
+type GoogleConfig struct {
+	Enabled bool
+	// LandingURL is the booking page Google links to, with Google's placeholders such as
+	// (PARTNER-HOTEL-ID), (CHECKINYEAR), (CHECKINMONTH), (CHECKINDAY) and (LENGTH)
+	LandingURL  string
+	Currency    string
+	HorizonDays int           // check-in dates priced, from the property's today
+	Interval    time.Duration // how often properties changed by events are re-rendered
+}
+
+// GoogleIssue is a problem found while validating the feed
+type GoogleIssue struct {
+	Severity string `json:"severity"`
+	Field    string `json:"field"`
+	Message  string `json:"message"`
+}
+
+// GoogleListingReport describes how a property was rendered in the feed
+type GoogleListingReport struct {
+	PropertyID uint          `json:"property_id"`
+	Name       string        `json:"name"`
+	Listed     bool          `json:"listed"`
+	Prices     int           `json:"prices"` // check-in dates with a bookable price
+	Issues     []GoogleIssue `json:"issues"`
+}
+
+// GoogleReport is the validation report of the last rendered Google feed
+type GoogleReport struct {
+	GeneratedAt time.Time             `json:"generated_at"`
+	Properties  int                   `json:"properties"`
+	Listed      int                   `json:"listed"`
+	Prices      int                   `json:"prices"`
+	Errors      int                   `json:"errors"`
+	Warnings    int                   `json:"warnings"`
+	Issues      []GoogleIssue         `json:"issues"`   // feed-wide issues
+	Listings    []GoogleListingReport `json:"listings"` // properties with issues
+}
+
+// GoogleFeed renders the hotel list, point of sale and transaction files of Google
+// Vacation Rentals to object storage. Each property is rendered to an entry of its own;
+// the event pipeline marks changed properties stale so Refresh re-renders only those
+// entries before the files are assembled again.
+type GoogleFeed struct {
+	config           GoogleConfig
+	propertyRepo     *database.PropertyRepository
+	availabilityRepo *database.AvailabilityRepository
+	pricingRepo      *database.PricingRepository
+	redis            *cache.RedisClient
+	store            storage.ObjectStore
+	mu               sync.Mutex // serializes rendering within the instance
+}
+
+// NewGoogleFeed creates a new Google Vacation Rentals feed
+func NewGoogleFeed(db *gorm.DB, redis *cache.RedisClient, store storage.ObjectStore, config GoogleConfig) *GoogleFeed {
+	return &GoogleFeed{
+		config:           config,
+		propertyRepo:     database.NewPropertyRepository(db),
+		availabilityRepo: database.NewAvailabilityRepository(db),
+		pricingRepo:      database.NewPricingRepository(db),
+		redis:            redis,
+		store:            store,
+	}
+}
+
+// GoogleKey returns the object storage key of a Google feed file
+func GoogleKey(file string) string {
+	return fmt.Sprintf("feeds/%s/%s", GoogleVariant, file)
+}
+
+// googleEntryKey returns the object storage key of a property's rendered entry
+func googleEntryKey(propertyID uint) string {
+	return fmt.Sprintf("feeds/%s/entries/%d.json", GoogleVariant, propertyID)
+}
+
+// Generate re-renders the entry of every property and assembles the feed files
+func (g *GoogleFeed) Generate(ctx context.Context) (*GoogleReport, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ids, err := g.propertyRepo.GetPropertyIDs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load properties: %w", err)
+	}
+	if _, err := g.renderEntries(ctx, ids); err != nil {
+		return nil, err
+	}
+	return g.assemble(ctx, ids)
+}
+
+// Refresh re-renders the entries of the properties changed since the last refresh and
+// assembles the feed files again. It returns the number of properties re-rendered.
+func (g *GoogleFeed) Refresh(ctx context.Context) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	stale, err := g.redis.ClaimStaleFeedProperties(ctx, GoogleVariant, googleRefreshBatch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim changed properties: %w", err)
+	}
+	if len(stale) == 0 {
+		return 0, nil
+	}
+
+	if err := g.refreshEntries(ctx, stale); err != nil {
+		if markErr := g.redis.MarkFeedStale(context.Background(), GoogleVariant, stale...); markErr != nil {
+			log.Printf("Failed to mark %d properties stale again: %v", len(stale), markErr)
+		}
+		return 0, err
+	}
+	ids, err := g.propertyRepo.GetPropertyIDs()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load properties: %w", err)
+	}
+	if _, err := g.assemble(ctx, ids); err != nil {
+		return 0, err
+	}
+	return len(stale), nil
+}
+
+// Report returns the validation report of the last assembled feed
+func (g *GoogleFeed) Report(ctx context.Context) (*GoogleReport, error) {
+	data, err := g.store.Get(ctx, GoogleKey(googleReportFile))
+	if err != nil {
+		return nil, err
+	}
+	var report GoogleReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("invalid feed report: %w", err)
+	}
+	return &report, nil
+}
+
+// refreshEntries re-renders the entries of changed properties, deleting those of
+// properties that no longer exist
+func (g *GoogleFeed) refreshEntries(ctx context.Context, ids []uint) error {
+	rendered, err := g.renderEntries(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if rendered[id] {
+			continue
+		}
+		if err := g.store.Delete(ctx, googleEntryKey(id)); err != nil && !errors.Is(err, storage.ErrObjectNotFound) {
+			return fmt.Errorf("failed to delete feed entry of property %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// renderEntries renders and stores the entries of properties in batches, returning
+// the IDs of the properties found
+func (g *GoogleFeed) renderEntries(ctx context.Context, ids []uint) (map[uint]bool, error) {
+	const batchSize = 100
+	rendered := make(map[uint]bool, len(ids))
+	for start := 0; start < len(ids); start += batchSize {
+		end := min(start+batchSize, len(ids))
+		properties, err := g.propertyRepo.GetPropertiesWithContent(ids[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to load properties: %w", err)
+		}
+		for _, property := range properties {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			entry, err := g.renderEntry(property)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render property %d: %w", property.ID, err)
+			}
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return nil, err
+			}
+			if err := g.store.Put(ctx, googleEntryKey(property.ID), data, ContentType("json")); err != nil {
+				return nil, fmt.Errorf("failed to store feed entry of property %d: %w", property.ID, err)
+			}
+			rendered[property.ID] = true
+		}
+	}
+	return rendered, nil
+}
+
+// googleEntry is a property's part of the feed files and of the report
+type googleEntry struct {
+	Listing *googleListing      `json:"listing,omitempty"` // nil when the property has errors
+	Results []googleResult      `json:"results,omitempty"`
+	Report  GoogleListingReport `json:"report"`
+}
+
+// renderEntry validates a property and renders its listing and a result for every
+// check-in date in the horizon
+func (g *GoogleFeed) renderEntry(property models.Property) (*googleEntry, error) {
+	entry := &googleEntry{Report: GoogleListingReport{PropertyID: property.ID, Name: property.Name, Issues: []GoogleIssue{}}}
+	issue := func(severity, field, message string) {
+		entry.Report.Issues = append(entry.Report.Issues, GoogleIssue{Severity: severity, Field: field, Message: message})
+	}
+
+	if !property.IsActive() {
+		issue(GoogleIssueError, "status", fmt.Sprintf("is %s, only active properties are listed", property.Status))
+	}
+	if strings.TrimSpace(property.Name) == "" {
+		issue(GoogleIssueError, "name", "is required")
+	}
+	if property.Latitude == 0 && property.Longitude == 0 {
+		issue(GoogleIssueError, "latitude", "coordinates are required")
+	}
+	if strings.TrimSpace(property.Country) == "" {
+		issue(GoogleIssueError, "country", "is required")
+	}
+	if strings.TrimSpace(property.City) == "" {
+		issue(GoogleIssueWarning, "city", "is missing, Google matches the listing by coordinates only")
+	}
+	if len(property.Photos) == 0 {
+		issue(GoogleIssueWarning, "photos", "listings without photos are rarely shown")
+	}
+	for _, i := range entry.Report.Issues {
+		if i.Severity == GoogleIssueError {
+			return entry, nil
+		}
+	}
+
+	entry.Listing = googleListingFrom(property)
+	entry.Report.Listed = true
+
+	results, unpriced, err := g.results(property)
+	if err != nil {
+		return nil, err
+	}
+	entry.Results = results
+	for _, result := range results {
+		if result.Baserate != nil {
+			entry.Report.Prices++
+		}
+	}
+	if unpriced > 0 {
+		issue(GoogleIssueWarning, "pricing", fmt.Sprintf("%d available nights have no price and are sent as unavailable", unpriced))
+	}
+	if entry.Report.Prices == 0 {
+		issue(GoogleIssueWarning, "availability", fmt.Sprintf("no bookable check-in date in the next %d days", g.config.HorizonDays))
+	}
+	return entry, nil
+}
+
+// results prices a stay of the minimum length from every check-in date in the horizon,
+// or marks the date unavailable. It also returns the number of available nights
+// without a price.
+func (g *GoogleFeed) results(property models.Property) ([]googleResult, int, error) {
+	today := property.Today()
+	end := today.AddDate(0, 0, g.config.HorizonDays-1)
+	from, to := today.Format("2006-01-02"), end.Format("2006-01-02")
+
+	availabilities, err := g.availabilityRepo.GetAvailabilityForDateRange(property.ID, from, to)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load availability: %w", err)
+	}
+	pricing, err := g.pricingRepo.GetPricingForDateRange(property.ID, from, to)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load pricing: %w", err)
+	}
+	nights := make(map[string]models.Availability, len(availabilities))
+	for _, a := range availabilities {
+		nights[a.Date.Format("2006-01-02")] = a
+	}
+	prices := make(map[string]models.Pricing, len(pricing))
+	for _, p := range pricing {
+		prices[p.Date.Format("2006-01-02")] = p
+	}
+
+	// bookable reports whether a night can be sold, and whether it is open but unpriced
+	bookable := func(date string) (bool, bool) {
+		night, ok := nights[date]
+		if !ok || !night.Available {
+			return false, false
+		}
+		price, ok := prices[date]
+		if !ok || price.BasePrice <= 0 {
+			return false, true
+		}
+		return true, false
+	}
+
+	unpriced := 0
+	results := make([]googleResult, 0, g.config.HorizonDays)
+	for checkin := today; !checkin.After(end); checkin = checkin.AddDate(0, 0, 1) {
+		date := checkin.Format("2006-01-02")
+		if _, open := bookable(date); open {
+			unpriced++
+		}
+
+		length := max(nights[date].MinStay, 1)
+		result := googleResult{Property: property.ID, Checkin: date, Nights: length}
+		var base, tax, fees float64
+		sellable := property.BookingWindowViolation(checkin, today) == ""
+		for i := 0; sellable && i < length; i++ {
+			night := checkin.AddDate(0, 0, i).Format("2006-01-02")
+			if ok, _ := bookable(night); !ok {
+				sellable = false
+				break
+			}
+			price := prices[night]
+			base += price.BasePrice - price.Discount
+			tax += price.Taxes
+			fees += price.Fees
+		}
+
+		if sellable {
+			result.Baserate = g.amount(base)
+			result.Tax = g.amount(tax)
+			result.OtherFees = g.amount(fees)
+		} else {
+			result.Unavailable = &googleUnavailable{}
+		}
+		results = append(results, result)
+	}
+	return results, unpriced, nil
+}
+
+// amount formats a price in the feed currency
+func (g *GoogleFeed) amount(value float64) *googleAmount {
+	return &googleAmount{Currency: g.config.Currency, Value: fmt.Sprintf("%.2f", value)}
+}
+
+// assemble combines the entries of the given properties into the feed files and the
+// validation report
+func (g *GoogleFeed) assemble(ctx context.Context, ids []uint) (*GoogleReport, error) {
+	now := time.Now().UTC()
+	report := &GoogleReport{GeneratedAt: now, Issues: g.pointOfSaleIssues(), Listings: []GoogleListingReport{}}
+	hotels := googleHotelList{Language: "en", Listings: []googleListing{}}
+	transaction := googleTransaction{Timestamp: now.Format(time.RFC3339), ID: fmt.Sprintf("%d", now.UnixNano()), Results: []googleResult{}}
+
+	for _, id := range ids {
+		data, err := g.store.Get(ctx, googleEntryKey(id))
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			// Created after the last rendering; its event marks it stale for the next refresh
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load feed entry of property %d: %w", id, err)
+		}
+		var entry googleEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("invalid feed entry of property %d: %w", id, err)
+		}
+
+		report.Properties++
+		if entry.Listing != nil {
+			report.Listed++
+			hotels.Listings = append(hotels.Listings, *entry.Listing)
+			transaction.Results = append(transaction.Results, entry.Results...)
+		}
+		report.Prices += entry.Report.Prices
+		if len(entry.Report.Issues) > 0 {
+			report.Listings = append(report.Listings, entry.Report)
+			report.count(entry.Report.Issues)
+		}
+	}
+	report.count(report.Issues)
+
+	files := map[string]interface{}{GoogleHotelList: hotels, GoogleTransaction: transaction}
+	if g.config.LandingURL != "" {
+		files[GooglePointOfSale] = googlePointsOfSale{PointsOfSale: []googlePointOfSale{{ID: "direct", URL: g.config.LandingURL}}}
+	}
+	for file, document := range files {
+		data, err := encodeXML(document)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", file, err)
+		}
+		if err := g.store.Put(ctx, GoogleKey(file), data, ContentType("xml")); err != nil {
+			return nil, fmt.Errorf("failed to store %s: %w", file, err)
+		}
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.store.Put(ctx, GoogleKey(googleReportFile), data, ContentType("json")); err != nil {
+		return nil, fmt.Errorf("failed to store feed report: %w", err)
+	}
+	return report, nil
+}
+
+// pointOfSaleIssues validates the configured landing page
+func (g *GoogleFeed) pointOfSaleIssues() []GoogleIssue {
+	if g.config.LandingURL == "" {
+		return []GoogleIssue{{Severity: GoogleIssueError, Field: "landing_url",
+			Message: "GOOGLE_LANDING_URL is not set, so no point of sale is published and prices cannot link to a booking page"}}
+	}
+	if !strings.Contains(g.config.LandingURL, "(PARTNER-HOTEL-ID)") {
+		return []GoogleIssue{{Severity: GoogleIssueWarning, Field: "landing_url",
+			Message: "has no (PARTNER-HOTEL-ID) placeholder, so every listing links to the same page"}}
+	}
+	return []GoogleIssue{}
+}
+
+// count adds issues to the report's error and warning totals
+func (r *GoogleReport) count(issues []GoogleIssue) {
+	for _, i := range issues {
+		if i.Severity == GoogleIssueError {
+			r.Errors++
+		} else {
+			r.Warnings++
+		}
+	}
+}
+
+// encodeXML renders an XML document with its header
+func encodeXML(document interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// googleHotelList is the hotel list feed
+type googleHotelList struct {
+	XMLName  xml.Name        `xml:"listings"`
+	Language string          `xml:"language"`
+	Listings []googleListing `xml:"listing"`
+}
+
+type googleListing struct {
+	ID        uint          `xml:"id"`
+	Name      string        `xml:"name"`
+	Address   googleAddress `xml:"address"`
+	Country   string        `xml:"country"`
+	Latitude  float64       `xml:"latitude"`
+	Longitude float64       `xml:"longitude"`
+	Category  string        `xml:"category"`
+	Images    []googleImage `xml:"content>image,omitempty"`
+}
+
+type googleAddress struct {
+	Format     string            `xml:"format,attr"`
+	Components []googleComponent `xml:"component"`
+}
+
+type googleComponent struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+type googleImage struct {
+	Type  string `xml:"type,attr"`
+	URL   string `xml:"url,attr"`
+	Title string `xml:"title,omitempty"`
+}
+
+// googleListingFrom converts a property to its hotel list entry
+func googleListingFrom(property models.Property) *googleListing {
+	listing := &googleListing{
+		ID:        property.ID,
+		Name:      property.Name,
+		Address:   googleAddress{Format: "simple"},
+		Country:   property.Country,
+		Latitude:  property.Latitude,
+		Longitude: property.Longitude,
+		Category:  "vacation rental",
+	}
+	for _, component := range []googleComponent{
+		{Name: "addr1", Value: property.Location},
+		{Name: "city", Value: property.City},
+		{Name: "province", Value: property.State},
+	} {
+		if component.Value != "" {
+			listing.Address.Components = append(listing.Address.Components, component)
+		}
+	}
+	for _, photo := range property.Photos {
+		listing.Images = append(listing.Images, googleImage{Type: "ad", URL: photo.URL, Title: photo.Caption})
+	}
+	return listing
+}
+
+// googlePointsOfSale is the point of sale file
+type googlePointsOfSale struct {
+	XMLName      xml.Name            `xml:"PointsOfSale"`
+	PointsOfSale []googlePointOfSale `xml:"PointOfSale"`
+}
+
+type googlePointOfSale struct {
+	ID  string `xml:"id,attr"`
+	URL string `xml:"URL"`
+}
+
+// googleTransaction is the transaction message of prices by check-in date
+type googleTransaction struct {
+	XMLName   xml.Name       `xml:"Transaction"`
+	Timestamp string         `xml:"timestamp,attr"`
+	ID        string         `xml:"id,attr"`
+	Results   []googleResult `xml:"Result"`
+}
+
+type googleResult struct {
+	Property    uint               `xml:"Property"`
+	Checkin     string             `xml:"Checkin"`
+	Nights      int                `xml:"Nights"`
+	Baserate    *googleAmount      `xml:"Baserate,omitempty"`
+	Tax         *googleAmount      `xml:"Tax,omitempty"`
+	OtherFees   *googleAmount      `xml:"OtherFees,omitempty"`
+	Unavailable *googleUnavailable `xml:"Unavailable,omitempty"`
+}
+
+type googleAmount struct {
+	Currency string `xml:"currency,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type googleUnavailable struct {
+	NoVacancy struct{} `xml:"NoVacancy"`
+}
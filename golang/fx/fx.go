@@ -0,0 +1,238 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// BaseCurrency is the currency property prices are set in; rates are stored against it
+const BaseCurrency = "USD"
+
+// ratesTTL is how long converters reuse the rates they loaded
+const ratesTTL = time.Minute
+
+// ErrNoRate is returned when converting to or from a currency without a known rate
+var ErrNoRate = errors.New("no exchange rate")
+
+// Config holds exchange rate configuration
+type Config struct {
+	// Providers are tried in order until one returns rates: "ecb", "openexchangerates"
+	Providers              []string
+	ECBURL                 string
+	OpenExchangeRatesURL   string
+	OpenExchangeRatesAppID string // the provider is skipped without one
+	Timeout                time.Duration
+	Schedule               string        // when rates are fetched
+	MaxAge                 time.Duration // rates published longer ago than this are stale
+}
+
+// SyncResult summarizes one exchange rate sync
+type SyncResult struct {
+	Source     string   // provider the rates came from; empty when all failed
+	Currencies int      // rates stored
+	Stale      []string // currencies whose rates are stale after the sync
+}
+
+// Service fetches exchange rates into the exchange_rates table and converts amounts
+// with them. Without fresh rates from any provider, the last stored rates stay in use.
+type Service struct {
+	config    Config
+	providers []Provider
+	repo      *database.ExchangeRateRepository
+
+	mu       sync.Mutex
+	rates    map[string]models.ExchangeRate
+	loadedAt time.Time
+}
+
+// NewService creates an exchange rate service with the configured providers
+func NewService(db *gorm.DB, config Config) *Service {
+	var providers []Provider
+	for _, name := range config.Providers {
+		switch strings.TrimSpace(name) {
+		case "ecb":
+			providers = append(providers, NewECBProvider(config.ECBURL, config.Timeout))
+		case "openexchangerates":
+			if config.OpenExchangeRatesAppID == "" {
+				log.Println("Warning: OPENEXCHANGERATES_APP_ID is not set, Open Exchange Rates will not be used")
+				continue
+			}
+			providers = append(providers, NewOpenExchangeRatesProvider(config.OpenExchangeRatesURL, config.OpenExchangeRatesAppID, config.Timeout))
+		default:
+			log.Printf("Warning: unknown exchange rate provider %q", name)
+		}
+	}
+	return &Service{config: config, providers: providers, repo: database.NewExchangeRateRepository(db)}
+}
+
+// Sync stores the latest rates of the first provider that returns them. It returns an
+// error when no provider did or when rates are stale afterwards, so the job run records
+// the alert.
+func (s *Service) Sync(ctx context.Context) (SyncResult, error) {
+	var result SyncResult
+	var failures []string
+	for _, provider := range s.providers {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		fetched, err := provider.Latest(ctx)
+		if err == nil {
+			var rates []models.ExchangeRate
+			if rates, err = rebase(fetched, BaseCurrency, provider.Name()); err == nil {
+				err = s.repo.SaveExchangeRates(rates)
+			}
+			if err == nil {
+				result.Source = provider.Name()
+				result.Currencies = len(rates)
+				break
+			}
+		}
+		log.Printf("Exchange rate provider %s failed: %v", provider.Name(), err)
+		failures = append(failures, fmt.Sprintf("%s: %v", provider.Name(), err))
+	}
+	s.invalidate()
+
+	rates, err := s.Rates()
+	if err != nil {
+		return result, fmt.Errorf("failed to load exchange rates: %w", err)
+	}
+	for _, rate := range rates {
+		if rate.Stale {
+			result.Stale = append(result.Stale, rate.Currency)
+		}
+	}
+	if len(result.Stale) > 0 {
+		log.Printf("Warning: exchange rates of %d currencies are stale: %s", len(result.Stale), strings.Join(result.Stale, ", "))
+	}
+
+	switch {
+	case result.Source == "" && len(s.providers) == 0:
+		return result, fmt.Errorf("no exchange rate provider is configured")
+	case result.Source == "":
+		return result, fmt.Errorf("every exchange rate provider failed, keeping the last rates: %s", strings.Join(failures, "; "))
+	case len(result.Stale) > 0:
+		return result, fmt.Errorf("exchange rates of %s are stale", strings.Join(result.Stale, ", "))
+	}
+	return result, nil
+}
+
+// Rates returns the stored rates, flagging those that are stale
+func (s *Service) Rates() ([]models.ExchangeRate, error) {
+	rates, err := s.repo.ListExchangeRates()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range rates {
+		rates[i].Stale = s.config.MaxAge > 0 && now.Sub(rates[i].AsOf) > s.config.MaxAge
+	}
+	return rates, nil
+}
+
+// Convert converts an amount between currencies with the latest stored rates, rounded
+// to cents. It returns ErrNoRate when either currency has no rate.
+func (s *Service) Convert(amount float64, from, to string) (float64, error) {
+	if from == to {
+		return amount, nil
+	}
+	rate, err := s.Rate(from, to)
+	if err != nil {
+		return 0, err
+	}
+	return math.Round(amount*rate*100) / 100, nil
+}
+
+// Rate returns what one unit of from is worth in to with the latest stored rates. It
+// returns ErrNoRate when either currency has no rate.
+func (s *Service) Rate(from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	rates, err := s.loaded()
+	if err != nil {
+		return 0, err
+	}
+	fromRate, ok := rates[from]
+	if !ok || fromRate.Rate <= 0 {
+		return 0, fmt.Errorf("%w for %s", ErrNoRate, from)
+	}
+	toRate, ok := rates[to]
+	if !ok || toRate.Rate <= 0 {
+		return 0, fmt.Errorf("%w for %s", ErrNoRate, to)
+	}
+	return toRate.Rate / fromRate.Rate, nil
+}
+
+// loaded returns the rates by currency, reloading them once they are older than ratesTTL
+func (s *Service) loaded() (map[string]models.ExchangeRate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rates != nil && time.Since(s.loadedAt) < ratesTTL {
+		return s.rates, nil
+	}
+	list, err := s.repo.ListExchangeRates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exchange rates: %w", err)
+	}
+	s.rates = make(map[string]models.ExchangeRate, len(list))
+	for _, rate := range list {
+		s.rates[rate.Currency] = rate
+	}
+	s.loadedAt = time.Now()
+	return s.rates, nil
+}
+
+// invalidate makes the next conversion load the stored rates
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	s.rates = nil
+	s.mu.Unlock()
+}
+
+// rebase expresses a provider's rates against base, which must be among them unless it
+// is their base already
+func rebase(fetched Rates, base, source string) ([]models.ExchangeRate, error) {
+	baseRate := 1.0
+	if fetched.Base != base {
+		rate, ok := fetched.Rates[base]
+		if !ok || rate <= 0 {
+			return nil, fmt.Errorf("rates against %s do not include %s", fetched.Base, base)
+		}
+		baseRate = rate
+	}
+
+	fetchedAt := time.Now()
+	byCurrency := map[string]float64{fetched.Base: 1 / baseRate}
+	for currency, rate := range fetched.Rates {
+		if rate > 0 && len(currency) == 3 {
+			byCurrency[strings.ToUpper(currency)] = rate / baseRate
+		}
+	}
+	byCurrency[base] = 1
+
+	rates := make([]models.ExchangeRate, 0, len(byCurrency))
+	for currency, rate := range byCurrency {
+		rates = append(rates, models.ExchangeRate{
+			Currency:  currency,
+			Rate:      rate,
+			Source:    source,
+			AsOf:      fetched.AsOf,
+			FetchedAt: fetchedAt,
+		})
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].Currency < rates[j].Currency })
+	return rates, nil
+}
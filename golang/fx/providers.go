@@ -0,0 +1,158 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Rates is a set of exchange rates published by a provider
+type Rates struct {
+	Base  string
+	AsOf  time.Time
+	Rates map[string]float64 // units of each currency per unit of Base
+}
+
+// Provider is a source of exchange rates
+type Provider interface {
+	// Name identifies the provider in stored rates and logs
+	Name() string
+	// Latest fetches the provider's latest rates
+	Latest(ctx context.Context) (Rates, error)
+}
+
+// ECBProvider fetches the euro reference rates the European Central Bank publishes each
+// working day
+type ECBProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewECBProvider creates a provider of the ECB reference rates
+func NewECBProvider(url string, timeout time.Duration) *ECBProvider {
+	return &ECBProvider{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (p *ECBProvider) Name() string {
+	return "ecb"
+}
+
+// ecbEnvelope is the daily reference rates document
+type ecbEnvelope struct {
+	Cube struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string  `xml:"currency,attr"`
+				Rate     float64 `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+func (p *ECBProvider) Latest(ctx context.Context) (Rates, error) {
+	body, err := fetch(ctx, p.client, p.url)
+	if err != nil {
+		return Rates{}, err
+	}
+
+	var envelope ecbEnvelope
+	if err := xml.Unmarshal(body, &envelope); err != nil {
+		return Rates{}, fmt.Errorf("invalid reference rates: %w", err)
+	}
+	if len(envelope.Cube.Days) == 0 {
+		return Rates{}, fmt.Errorf("reference rates hold no day")
+	}
+	day := envelope.Cube.Days[0]
+	asOf, err := time.Parse("2006-01-02", day.Time)
+	if err != nil {
+		return Rates{}, fmt.Errorf("invalid reference rate date %q", day.Time)
+	}
+
+	rates := Rates{Base: "EUR", AsOf: asOf, Rates: make(map[string]float64, len(day.Rates))}
+	for _, rate := range day.Rates {
+		rates.Rates[rate.Currency] = rate.Rate
+	}
+	return rates, nil
+}
+
+// OpenExchangeRatesProvider fetches the latest rates of Open Exchange Rates
+type OpenExchangeRatesProvider struct {
+	url    string
+	appID  string
+	client *http.Client
+}
+
+// NewOpenExchangeRatesProvider creates a provider of Open Exchange Rates for an app ID
+func NewOpenExchangeRatesProvider(url, appID string, timeout time.Duration) *OpenExchangeRatesProvider {
+	return &OpenExchangeRatesProvider{url: url, appID: appID, client: &http.Client{Timeout: timeout}}
+}
+
+func (p *OpenExchangeRatesProvider) Name() string {
+	return "openexchangerates"
+}
+
+func (p *OpenExchangeRatesProvider) Latest(ctx context.Context) (Rates, error) {
+	endpoint, err := url.Parse(p.url)
+	if err != nil {
+		return Rates{}, err
+	}
+	query := endpoint.Query()
+	query.Set("app_id", p.appID)
+	endpoint.RawQuery = query.Encode()
+
+	body, err := fetch(ctx, p.client, endpoint.String())
+	if err != nil {
+		return Rates{}, err
+	}
+
+	var latest struct {
+		Timestamp int64              `json:"timestamp"`
+		Base      string             `json:"base"`
+		Rates     map[string]float64 `json:"rates"`
+	}
+	if err := json.Unmarshal(body, &latest); err != nil {
+		return Rates{}, fmt.Errorf("invalid latest rates: %w", err)
+	}
+	if latest.Base == "" || len(latest.Rates) == 0 {
+		return Rates{}, fmt.Errorf("latest rates hold no rate")
+	}
+	return Rates{Base: latest.Base, AsOf: time.Unix(latest.Timestamp, 0).UTC(), Rates: latest.Rates}, nil
+}
+
+// fetch retrieves a provider's document
+func fetch(ctx context.Context, client *http.Client, endpoint string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The error repeats the URL, which may carry an app ID
+		return nil, fmt.Errorf("request failed: %s", strings.ReplaceAll(err.Error(), endpoint, redact(endpoint)))
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("provider returned %d", resp.StatusCode)
+	}
+	return body, nil
+}
+
+// redact drops the query of a URL
+func redact(endpoint string) string {
+	if i := strings.IndexByte(endpoint, '?'); i >= 0 {
+		return endpoint[:i]
+	}
+	return endpoint
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"channelmanager/apierror"
+	"channelmanager/channels"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxAdapterSpecSize bounds a generic adapter definition
+const maxAdapterSpecSize = 64 << 10
+
+// GetChannelAdapterSpec returns the definition the generic REST adapter uses for a channel
+func (h *Handler) GetChannelAdapterSpec(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	spec, err := h.channelRepo.GetAdapterSpec(channel.ID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Adapter definition"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve adapter definition"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": spec})
+}
+
+// SaveChannelAdapterSpec connects a channel through the generic REST adapter with the
+// endpoints, templates and error codes in the request body. The definition is checked
+// by rendering sample requests before it replaces the current one; channels with an
+// adapter of their own ignore it.
+func (h *Handler) SaveChannelAdapterSpec(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	data, err := c.GetRawData()
+	if err != nil {
+		c.Error(apierror.InvalidRequest("Failed to read request body"))
+		return
+	}
+	if len(data) > maxAdapterSpecSize {
+		c.Error(apierror.Validation("Adapter definition must not exceed 64 KB"))
+		return
+	}
+	if !json.Valid(data) {
+		c.Error(apierror.InvalidRequest("Request body must be a JSON adapter definition"))
+		return
+	}
+	if _, err := channels.ParseGenericSpec(data); err != nil {
+		c.Error(apierror.Validation(err.Error()))
+		return
+	}
+
+	spec := &models.ChannelAdapterSpec{ChannelID: channel.ID, Spec: data}
+	if existing, err := h.channelRepo.GetAdapterSpec(channel.ID); err == nil {
+		spec.CreatedAt = existing.CreatedAt
+	}
+	if err := h.channelRepo.SaveAdapterSpec(spec); err != nil {
+		c.Error(apierror.Internal("Failed to store adapter definition"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": spec})
+}
+
+// DeleteChannelAdapterSpec disconnects a channel from the generic REST adapter; its
+// pushes are only logged afterwards
+func (h *Handler) DeleteChannelAdapterSpec(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.channelRepo.DeleteAdapterSpec(channel.ID)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to delete adapter definition"))
+		return
+	}
+	if !deleted {
+		c.Error(apierror.NotFound("Adapter definition"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
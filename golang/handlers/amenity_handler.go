@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"channelmanager/apierror"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AmenityRequest represents the payload for creating or updating an amenity
+type AmenityRequest struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	Category string `json:"category" binding:"required,max=50"`
+	Icon     string `json:"icon" binding:"max=50"`
+}
+
+// ConditionRequest represents the payload for creating or updating a condition
+type ConditionRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	Type string `json:"type" binding:"required,max=50"`
+}
+
+// RenameGroupRequest represents the payload for renaming an amenity category or condition type
+type RenameGroupRequest struct {
+	Name string `json:"name" binding:"required,max=50"`
+}
+
+// CreateAmenity creates an amenity with a unique name
+func (h *Handler) CreateAmenity(c *gin.Context) {
+	var req AmenityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	amenity := models.Amenity{}
+	if !h.applyAmenityRequest(c, &amenity, req) {
+		return
+	}
+
+	if err := h.amenityRepo.CreateAmenity(&amenity); err != nil {
+		c.Error(apierror.Internal("Failed to create amenity"))
+		return
+	}
+
+	h.recordReferenceChange(c.Request.Context(), "CREATE", "amenities", amenity.ID, amenity, nil)
+
+	c.JSON(http.StatusCreated, gin.H{"data": amenity})
+}
+
+// UpdateAmenity updates an amenity's name, category or icon
+func (h *Handler) UpdateAmenity(c *gin.Context) {
+	amenity, ok := h.loadAmenity(c)
+	if !ok {
+		return
+	}
+
+	var req AmenityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	if !h.applyAmenityRequest(c, amenity, req) {
+		return
+	}
+
+	if err := h.amenityRepo.UpdateAmenity(amenity); err != nil {
+		c.Error(apierror.Internal("Failed to update amenity"))
+		return
+	}
+
+	propertyIDs, err := h.amenityRepo.GetPropertyIDsForAmenity(amenity.ID)
+	if err != nil {
+		log.Printf("Failed to load properties for amenity %d: %v", amenity.ID, err)
+	}
+	h.recordReferenceChange(c.Request.Context(), "UPDATE", "amenities", amenity.ID, amenity, propertyIDs)
+
+	c.JSON(http.StatusOK, gin.H{"data": amenity})
+}
+
+// DeleteAmenity deletes an amenity, hiding it on every property until it is restored
+// or purged
+func (h *Handler) DeleteAmenity(c *gin.Context) {
+	amenity, ok := h.loadAmenity(c)
+	if !ok {
+		return
+	}
+
+	propertyIDs, err := h.amenityRepo.DeleteAmenity(amenity.ID)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to delete amenity"))
+		return
+	}
+
+	h.recordReferenceChange(c.Request.Context(), "DELETE", "amenities", amenity.ID, amenity, propertyIDs)
+
+	c.JSON(http.StatusOK, gin.H{
+		"deleted":             true,
+		"id":                  amenity.ID,
+		"properties_affected": len(propertyIDs),
+	})
+}
+
+// ListAmenityCategories lists amenity categories with their amenity counts
+func (h *Handler) ListAmenityCategories(c *gin.Context) {
+	categories, err := h.amenityRepo.GetAmenityCategories()
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve amenity categories"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": categories})
+}
+
+// RenameAmenityCategory moves every amenity in a category to a new category name
+func (h *Handler) RenameAmenityCategory(c *gin.Context) {
+	var req RenameGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	from := c.Param("category")
+	to := normalizeGroupName(req.Name)
+	if to == "" {
+		c.Error(apierror.Validation("name must not be empty"))
+		return
+	}
+
+	updated, err := h.amenityRepo.RenameAmenityCategory(from, to)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to rename amenity category"))
+		return
+	}
+	if updated == 0 {
+		c.Error(apierror.NotFound("Amenity category"))
+		return
+	}
+
+	h.recordReferenceChange(c.Request.Context(), "UPDATE", "amenities", 0, gin.H{"category": from, "renamed_to": to}, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"category":          to,
+		"amenities_updated": updated,
+	})
+}
+
+// CreateCondition creates a condition with a unique name
+func (h *Handler) CreateCondition(c *gin.Context) {
+	var req ConditionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	condition := models.Condition{}
+	if !h.applyConditionRequest(c, &condition, req) {
+		return
+	}
+
+	if err := h.conditionRepo.CreateCondition(&condition); err != nil {
+		c.Error(apierror.Internal("Failed to create condition"))
+		return
+	}
+
+	h.recordReferenceChange(c.Request.Context(), "CREATE", "conditions", condition.ID, condition, nil)
+
+	c.JSON(http.StatusCreated, gin.H{"data": condition})
+}
+
+// UpdateCondition updates a condition's name or type
+func (h *Handler) UpdateCondition(c *gin.Context) {
+	condition, ok := h.loadCondition(c)
+	if !ok {
+		return
+	}
+
+	var req ConditionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	if !h.applyConditionRequest(c, condition, req) {
+		return
+	}
+
+	if err := h.conditionRepo.UpdateCondition(condition); err != nil {
+		c.Error(apierror.Internal("Failed to update condition"))
+		return
+	}
+
+	propertyIDs, err := h.conditionRepo.GetPropertyIDsForCondition(condition.ID)
+	if err != nil {
+		log.Printf("Failed to load properties for condition %d: %v", condition.ID, err)
+	}
+	h.recordReferenceChange(c.Request.Context(), "UPDATE", "conditions", condition.ID, condition, propertyIDs)
+
+	c.JSON(http.StatusOK, gin.H{"data": condition})
+}
+
+// DeleteCondition deletes a condition, hiding it on every property until it is restored
+// or purged
+func (h *Handler) DeleteCondition(c *gin.Context) {
+	condition, ok := h.loadCondition(c)
+	if !ok {
+		return
+	}
+
+	propertyIDs, err := h.conditionRepo.DeleteCondition(condition.ID)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to delete condition"))
+		return
+	}
+
+	h.recordReferenceChange(c.Request.Context(), "DELETE", "conditions", condition.ID, condition, propertyIDs)
+
+	c.JSON(http.StatusOK, gin.H{
+		"deleted":             true,
+		"id":                  condition.ID,
+		"properties_affected": len(propertyIDs),
+	})
+}
+
+// ListConditionTypes lists condition types with their condition counts
+func (h *Handler) ListConditionTypes(c *gin.Context) {
+	types, err := h.conditionRepo.GetConditionTypes()
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve condition types"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": types})
+}
+
+// RenameConditionType moves every condition of a type to a new type name
+func (h *Handler) RenameConditionType(c *gin.Context) {
+	var req RenameGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	from := c.Param("type")
+	to := normalizeGroupName(req.Name)
+	if to == "" {
+		c.Error(apierror.Validation("name must not be empty"))
+		return
+	}
+
+	updated, err := h.conditionRepo.RenameConditionType(from, to)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to rename condition type"))
+		return
+	}
+	if updated == 0 {
+		c.Error(apierror.NotFound("Condition type"))
+		return
+	}
+
+	h.recordReferenceChange(c.Request.Context(), "UPDATE", "conditions", 0, gin.H{"type": from, "renamed_to": to}, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"type":               to,
+		"conditions_updated": updated,
+	})
+}
+
+// loadAmenity loads the amenity referenced by the :id path parameter,
+// writing an error response and returning false if it cannot be loaded
+func (h *Handler) loadAmenity(c *gin.Context) (*models.Amenity, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("amenity"))
+		return nil, false
+	}
+
+	amenity, err := h.amenityRepo.GetAmenityByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Amenity"))
+			return nil, false
+		}
+		c.Error(apierror.Internal("Failed to retrieve amenity"))
+		return nil, false
+	}
+	return amenity, true
+}
+
+// loadCondition loads the condition referenced by the :id path parameter,
+// writing an error response and returning false if it cannot be loaded
+func (h *Handler) loadCondition(c *gin.Context) (*models.Condition, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("condition"))
+		return nil, false
+	}
+
+	condition, err := h.conditionRepo.GetConditionByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Condition"))
+			return nil, false
+		}
+		c.Error(apierror.Internal("Failed to retrieve condition"))
+		return nil, false
+	}
+	return condition, true
+}
+
+// applyAmenityRequest validates an amenity payload and copies it onto the amenity,
+// writing an error response and returning false if it is invalid
+func (h *Handler) applyAmenityRequest(c *gin.Context, amenity *models.Amenity, req AmenityRequest) bool {
+	name := strings.TrimSpace(req.Name)
+	category := normalizeGroupName(req.Category)
+	if name == "" || len(name) > 100 {
+		c.Error(apierror.Validation("name must be between 1 and 100 characters"))
+		return false
+	}
+	if category == "" {
+		c.Error(apierror.Validation("category must not be empty"))
+		return false
+	}
+
+	taken, err := h.amenityRepo.AmenityNameTaken(name, amenity.ID)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to validate amenity name"))
+		return false
+	}
+	if taken {
+		c.Error(apierror.AlreadyExists("An amenity with this name already exists"))
+		return false
+	}
+
+	amenity.Name = name
+	amenity.Category = category
+	amenity.Icon = strings.TrimSpace(req.Icon)
+	return true
+}
+
+// applyConditionRequest validates a condition payload and copies it onto the condition,
+// writing an error response and returning false if it is invalid
+func (h *Handler) applyConditionRequest(c *gin.Context, condition *models.Condition, req ConditionRequest) bool {
+	name := strings.TrimSpace(req.Name)
+	condType := normalizeGroupName(req.Type)
+	if name == "" || len(name) > 100 {
+		c.Error(apierror.Validation("name must be between 1 and 100 characters"))
+		return false
+	}
+	if condType == "" {
+		c.Error(apierror.Validation("type must not be empty"))
+		return false
+	}
+
+	taken, err := h.conditionRepo.ConditionNameTaken(name, condition.ID)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to validate condition name"))
+		return false
+	}
+	if taken {
+		c.Error(apierror.AlreadyExists("A condition with this name already exists"))
+		return false
+	}
+
+	condition.Name = name
+	condition.Type = condType
+	return true
+}
+
+// recordReferenceChange drops the amenity or condition list cache right away and
+// records change events so the event listener invalidates search and the caches
+// of every property the record is attached to
+func (h *Handler) recordReferenceChange(ctx context.Context, eventType, table string, recordID uint, record interface{}, propertyIDs []uint) {
+	var err error
+	if table == "amenities" {
+		err = h.redis.InvalidateAmenitiesCache(ctx)
+	} else {
+		err = h.redis.InvalidateConditionsCache(ctx)
+	}
+	if err != nil {
+		log.Printf("Failed to invalidate %s cache: %v", table, err)
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("Failed to marshal %s event data: %v", table, err)
+		return
+	}
+
+	relationTable := "property_amenities"
+	if table == "conditions" {
+		relationTable = "property_conditions"
+	}
+
+	events := []models.Event{{EventType: eventType, TableName: table, RecordID: recordID, Data: data}}
+	for _, propertyID := range propertyIDs {
+		events = append(events, models.Event{EventType: eventType, TableName: relationTable, RecordID: propertyID, Data: data})
+	}
+
+	if err := h.eventRepo.CreateEvents(events); err != nil {
+		log.Printf("Failed to record %s events: %v", table, err)
+	}
+}
+
+// normalizeGroupName normalizes a category or type name to its lowercase snake_case form
+func normalizeGroupName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+}
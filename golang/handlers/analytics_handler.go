@@ -0,0 +1,457 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetPropertyAnalytics returns occupancy rate, ADR and RevPAR for a property over a date range
+func (h *Handler) GetPropertyAnalytics(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	startDate, endDate, ok := parseAnalyticsRange(c)
+	if !ok {
+		return
+	}
+	startStr := startDate.Format("2006-01-02")
+	endStr := endDate.Format("2006-01-02")
+
+	// Try to get from cache
+	cached, err := h.redis.GetPropertyAnalyticsCache(ctx, uint(propertyID), startStr, endStr)
+	if err != nil {
+		log.Printf("Cache retrieval error: %v", err)
+	}
+
+	if cached != nil {
+		log.Println("Cache HIT for property analytics")
+		c.JSON(http.StatusOK, gin.H{
+			"data":   cached,
+			"cached": true,
+		})
+		return
+	}
+
+	log.Println("Cache MISS for property analytics, computing from database")
+
+	// The end date is inclusive, so aggregate up to the following day
+	endExclusive := endDate.AddDate(0, 0, 1)
+
+	bookings, err := h.analyticsRepo.AggregateBookedNights(uint(propertyID), startDate, endExclusive)
+	if err != nil {
+		log.Printf("Failed to aggregate bookings: %v", err)
+		c.Error(apierror.Internal("Failed to compute analytics"))
+		return
+	}
+
+	inventoryNights, err := h.analyticsRepo.CountInventoryNights(uint(propertyID), startDate, endExclusive)
+	if err != nil {
+		log.Printf("Failed to count inventory nights: %v", err)
+		c.Error(apierror.Internal("Failed to compute analytics"))
+		return
+	}
+	if inventoryNights == 0 {
+		// No calendar loaded, fall back to every night in the range
+		inventoryNights = int(endExclusive.Sub(startDate).Hours() / 24)
+	}
+
+	listedRate, err := h.analyticsRepo.AverageListedRate(uint(propertyID), startDate, endExclusive)
+	if err != nil {
+		log.Printf("Failed to compute listed rate: %v", err)
+		c.Error(apierror.Internal("Failed to compute analytics"))
+		return
+	}
+
+	blocked, err := h.blockRepo.CountBlockedNights(uint(propertyID), startDate, endExclusive)
+	if err != nil {
+		log.Printf("Failed to count blocked nights: %v", err)
+		c.Error(apierror.Internal("Failed to compute analytics"))
+		return
+	}
+	blockedNights := 0
+	for _, nights := range blocked {
+		blockedNights += nights
+	}
+
+	analytics := &models.PropertyAnalytics{
+		PropertyID:        uint(propertyID),
+		StartDate:         startStr,
+		EndDate:           endStr,
+		AvailableNights:   inventoryNights,
+		BookedNights:      bookings.BookedNights,
+		BlockedNights:     blockedNights,
+		NoShowNights:      bookings.NoShowNights,
+		BookingCount:      bookings.BookingCount,
+		Revenue:           roundTo(bookings.Revenue, 2),
+		AverageListedRate: roundTo(listedRate, 2),
+		GeneratedAt:       time.Now(),
+	}
+	if blockedNights > 0 {
+		analytics.BlockedNightsByReason = blocked
+	}
+	if inventoryNights > 0 {
+		analytics.OccupancyRate = roundTo(float64(bookings.BookedNights)/float64(inventoryNights), 4)
+		analytics.RevPAR = roundTo(bookings.Revenue/float64(inventoryNights), 2)
+	}
+	if bookings.BookedNights > 0 {
+		analytics.ADR = roundTo(bookings.Revenue/float64(bookings.BookedNights), 2)
+	}
+
+	// Cache analytics (15 minute TTL)
+	if err := h.redis.SetPropertyAnalyticsCache(ctx, analytics, 15*time.Minute); err != nil {
+		log.Printf("Failed to cache property analytics: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   analytics,
+		"cached": false,
+	})
+}
+
+// GetChannelPerformance returns bookings, revenue, cancellation rate and lead time per channel
+func (h *Handler) GetChannelPerformance(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	startDate, endDate, ok := parseAnalyticsRange(c)
+	if !ok {
+		return
+	}
+	startStr := startDate.Format("2006-01-02")
+	endStr := endDate.Format("2006-01-02")
+
+	var propertyID uint
+	if param := c.Query("property_id"); param != "" {
+		id, err := strconv.ParseUint(param, 10, 32)
+		if err != nil {
+			c.Error(apierror.InvalidID("property"))
+			return
+		}
+		propertyID = uint(id)
+	}
+
+	// Try to get from cache
+	cached, err := h.redis.GetChannelPerformanceCache(ctx, propertyID, startStr, endStr)
+	if err != nil {
+		log.Printf("Cache retrieval error: %v", err)
+	}
+
+	if cached != nil {
+		log.Println("Cache HIT for channel performance")
+		c.JSON(http.StatusOK, gin.H{
+			"data":   cached,
+			"cached": true,
+		})
+		return
+	}
+
+	log.Println("Cache MISS for channel performance, computing from database")
+
+	channels, err := h.analyticsRepo.AggregateChannelPerformance(startDate, endDate.AddDate(0, 0, 1), propertyID)
+	if err != nil {
+		log.Printf("Failed to aggregate channel performance: %v", err)
+		c.Error(apierror.Internal("Failed to compute channel performance"))
+		return
+	}
+
+	totalRevenue := 0.0
+	for _, ch := range channels {
+		totalRevenue += ch.Revenue
+	}
+
+	for i := range channels {
+		if channels[i].ChannelID == "" {
+			channels[i].ChannelID = "direct"
+		}
+		if channels[i].Bookings > 0 {
+			channels[i].CancellationRate = roundTo(float64(channels[i].CancelledCount)/float64(channels[i].Bookings), 4)
+			channels[i].NoShowRate = roundTo(float64(channels[i].NoShowCount)/float64(channels[i].Bookings), 4)
+		}
+		if totalRevenue > 0 {
+			channels[i].RevenueShare = roundTo(channels[i].Revenue/totalRevenue, 4)
+		}
+		channels[i].Revenue = roundTo(channels[i].Revenue, 2)
+		channels[i].AverageLeadTime = roundTo(channels[i].AverageLeadTime, 1)
+	}
+
+	report := &models.ChannelPerformanceReport{
+		StartDate:   startStr,
+		EndDate:     endStr,
+		PropertyID:  propertyID,
+		Channels:    channels,
+		GeneratedAt: time.Now(),
+	}
+
+	// Cache report (15 minute TTL)
+	if err := h.redis.SetChannelPerformanceCache(ctx, report, 15*time.Minute); err != nil {
+		log.Printf("Failed to cache channel performance: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   report,
+		"cached": false,
+	})
+}
+
+// GetPropertyPickup reports how a property's stay dates have been booking up as of a day
+// (default today): which nights are on the books, which were picked up in the last
+// window_days (default 7), and the occupancy projected from the booking curve of the
+// year before, flagging open dates that are filling slower than usual
+func (h *Handler) GetPropertyPickup(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	startDate, endDate, ok := parseAnalyticsRange(c)
+	if !ok {
+		return
+	}
+
+	asOf := property.Today()
+	if param := c.Query("as_of"); param != "" {
+		if asOf, err = time.Parse("2006-01-02", param); err != nil {
+			c.Error(apierror.InvalidDate("as_of must be in YYYY-MM-DD format"))
+			return
+		}
+	}
+
+	windowDays, err := strconv.Atoi(c.DefaultQuery("window_days", "7"))
+	if err != nil || windowDays < 1 || windowDays > 90 {
+		c.Error(apierror.InvalidField("window_days", "range", "window_days must be between 1 and 90"))
+		return
+	}
+
+	// Bookings placed during the as-of day at the property count as on the books
+	bookedBefore := property.StartOfDay(asOf.AddDate(0, 0, 1))
+	endExclusive := endDate.AddDate(0, 0, 1)
+
+	nights, err := h.analyticsRepo.GetBookedNights(uint(propertyID), startDate, endExclusive, bookedBefore)
+	if err != nil {
+		log.Printf("Failed to load booked nights: %v", err)
+		c.Error(apierror.Internal("Failed to compute pickup"))
+		return
+	}
+	closed, err := h.analyticsRepo.GetClosedDates(uint(propertyID), startDate, endExclusive)
+	if err != nil {
+		log.Printf("Failed to load closed dates: %v", err)
+		c.Error(apierror.Internal("Failed to compute pickup"))
+		return
+	}
+
+	blocks, err := h.blockRepo.GetBlocksForProperty(uint(propertyID), startDate, endDate)
+	if err != nil {
+		log.Printf("Failed to load calendar blocks: %v", err)
+		c.Error(apierror.Internal("Failed to compute pickup"))
+		return
+	}
+
+	curve, err := h.historicBookingCurve(property, asOf)
+	if err != nil {
+		log.Printf("Failed to compute booking curve: %v", err)
+		c.Error(apierror.Internal("Failed to compute pickup"))
+		return
+	}
+
+	bookedAt := make(map[string]time.Time, len(nights))
+	for _, night := range nights {
+		bookedAt[night.StayDate.Format("2006-01-02")] = night.BookedAt
+	}
+	closedDates := make(map[string]bool, len(closed))
+	for _, date := range closed {
+		closedDates[date.Format("2006-01-02")] = true
+	}
+
+	report := &models.PickupReport{
+		PropertyID:         uint(propertyID),
+		StartDate:          startDate.Format("2006-01-02"),
+		EndDate:            endDate.Format("2006-01-02"),
+		AsOf:               asOf.Format("2006-01-02"),
+		WindowDays:         windowDays,
+		HistoricOccupancy:  roundTo(curve.occupancy, 4),
+		MedianLeadTimeDays: curve.medianLead,
+		GeneratedAt:        time.Now(),
+	}
+
+	windowStart := bookedBefore.AddDate(0, 0, -windowDays)
+	openNights := 0
+	expectedNights := 0.0
+	for date := startDate; date.Before(endExclusive); date = date.AddDate(0, 0, 1) {
+		key := date.Format("2006-01-02")
+		entry := models.PickupDate{
+			Date:    key,
+			DaysOut: int(date.Sub(asOf).Hours() / 24),
+		}
+
+		if at, ok := bookedAt[key]; ok {
+			entry.Booked = true
+			entry.BookedAt = &at
+			entry.PickedUp = !at.Before(windowStart)
+			entry.ProjectedOccupancy = 1
+			report.OnTheBooks++
+			if entry.PickedUp {
+				report.PickupNights++
+			}
+		} else if closedDates[key] {
+			entry.Closed = true
+			entry.Blocked = blockReason(blocks, key)
+		} else if entry.DaysOut >= 0 {
+			entry.ProjectedOccupancy = roundTo(curve.pickupProbability(entry.DaysOut), 4)
+			entry.Slow = curve.nights > 0 && entry.DaysOut < curve.medianLead
+		}
+
+		if !entry.Closed {
+			openNights++
+			expectedNights += entry.ProjectedOccupancy
+		}
+		if entry.Slow {
+			report.SlowDates++
+		}
+		report.Dates = append(report.Dates, entry)
+	}
+
+	if openNights > 0 {
+		report.OnTheBooksRate = roundTo(float64(report.OnTheBooks)/float64(openNights), 4)
+		report.ProjectedOccupancy = roundTo(expectedNights/float64(openNights), 4)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": report,
+	})
+}
+
+// blockReason returns the reason of the block covering a date, or "" when none does
+func blockReason(blocks []models.CalendarBlock, date string) string {
+	for _, block := range blocks {
+		if date >= block.StartDate.Format("2006-01-02") && date <= block.EndDate.Format("2006-01-02") {
+			return block.Reason
+		}
+	}
+	return ""
+}
+
+// bookingCurve summarizes how far ahead of the stay a property's nights were booked
+type bookingCurve struct {
+	occupancy  float64 // share of open nights that were booked
+	leads      []int   // lead time in days of every booked night, ascending
+	nights     int
+	medianLead int
+}
+
+// historicBookingCurve builds the booking curve of the year before asOf, with lead times
+// counted in calendar days at the property
+func (h *Handler) historicBookingCurve(property *models.Property, asOf time.Time) (bookingCurve, error) {
+	propertyID := property.ID
+	start := asOf.AddDate(-1, 0, 0)
+	nights, err := h.analyticsRepo.GetBookedNights(propertyID, start, asOf, property.StartOfDay(asOf))
+	if err != nil {
+		return bookingCurve{}, err
+	}
+	closed, err := h.analyticsRepo.GetClosedDates(propertyID, start, asOf)
+	if err != nil {
+		return bookingCurve{}, err
+	}
+
+	curve := bookingCurve{nights: len(nights)}
+	for _, night := range nights {
+		lead := int(night.StayDate.Sub(models.LocalDate(night.BookedAt, property.TimeLocation())).Hours() / 24)
+		if lead < 0 {
+			lead = 0
+		}
+		curve.leads = append(curve.leads, lead)
+	}
+	if curve.nights == 0 {
+		return curve, nil
+	}
+	sort.Ints(curve.leads)
+	curve.medianLead = curve.leads[len(curve.leads)/2]
+
+	// Booked nights are closed too, so only closed nights without a booking reduce the inventory
+	totalNights := int(asOf.Sub(start).Hours() / 24)
+	openNights := totalNights - len(closed) + curve.nights
+	if openNights > totalNights {
+		openNights = totalNights
+	}
+	if openNights > 0 {
+		curve.occupancy = math.Min(float64(curve.nights)/float64(openNights), 1)
+	}
+	return curve, nil
+}
+
+// pickupProbability returns the chance that an open night daysOut days away still gets
+// booked: the share of nights booked later than that, given it was not booked earlier
+func (curve bookingCurve) pickupProbability(daysOut int) float64 {
+	if curve.nights == 0 {
+		return 0
+	}
+	// Share of booked nights that were booked at least daysOut days ahead
+	early := len(curve.leads) - sort.SearchInts(curve.leads, daysOut)
+	bookedEarly := curve.occupancy * float64(early) / float64(curve.nights)
+	if bookedEarly >= 1 {
+		return 0
+	}
+	return (curve.occupancy - bookedEarly) / (1 - bookedEarly)
+}
+
+// parseAnalyticsRange parses the start_date/end_date query parameters (inclusive).
+// It writes an error response and returns false if they are missing or invalid.
+func parseAnalyticsRange(c *gin.Context) (time.Time, time.Time, bool) {
+	startParam := c.Query("start_date")
+	endParam := c.Query("end_date")
+	if startParam == "" || endParam == "" {
+		c.Error(apierror.Validation("start_date and end_date are required"))
+		return time.Time{}, time.Time{}, false
+	}
+
+	startDate, err := time.Parse("2006-01-02", startParam)
+	if err != nil {
+		c.Error(apierror.InvalidDate("start_date must be in YYYY-MM-DD format"))
+		return time.Time{}, time.Time{}, false
+	}
+	endDate, err := time.Parse("2006-01-02", endParam)
+	if err != nil {
+		c.Error(apierror.InvalidDate("end_date must be in YYYY-MM-DD format"))
+		return time.Time{}, time.Time{}, false
+	}
+	if endDate.Before(startDate) {
+		c.Error(apierror.InvalidDateRange("end_date must not be before start_date"))
+		return time.Time{}, time.Time{}, false
+	}
+	if endDate.Sub(startDate) > 366*24*time.Hour {
+		c.Error(apierror.InvalidDateRange("date range must not exceed one year"))
+		return time.Time{}, time.Time{}, false
+	}
+
+	return startDate, endDate, true
+}
+
+// roundTo rounds a value to the given number of decimal places
+func roundTo(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(value*factor) / factor
+}
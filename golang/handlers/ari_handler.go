@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ARIRangeUpdate sets availability and rate fields for an inclusive date range;
+// omitted fields are left unchanged
+type ARIRangeUpdate struct {
+	StartDate string   `json:"start_date" binding:"required,datetime=2006-01-02"`
+	EndDate   string   `json:"end_date" binding:"required,datetime=2006-01-02"`
+	Available *bool    `json:"available"`
+	MinStay   *int     `json:"min_stay" binding:"omitempty,min=1,max=365"`
+	MaxGuests *int     `json:"max_guests" binding:"omitempty,min=1,max=50"`
+	BasePrice *float64 `json:"base_price" binding:"omitempty,gt=0"`
+}
+
+// UpdateARIRequest represents the payload for a bulk availability and rate update
+type UpdateARIRequest struct {
+	Updates []ARIRangeUpdate `json:"updates" binding:"required,min=1,max=100,dive"`
+}
+
+// UpdatePropertyARI applies bulk availability and rate updates to a property in one
+// transaction; the recorded events refresh caches and push the changes to channels.
+// Send an Idempotency-Key header so retries do not apply the changes twice.
+func (h *Handler) UpdatePropertyARI(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var req UpdateARIRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	changes, apiErr := parseARIUpdates(req.Updates, "updates")
+	if apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+	for _, permission := range ariPermissions(changes) {
+		if apiErr := h.authorizeProperty(c, uint(propertyID), permission); apiErr != nil {
+			c.Error(apiErr)
+			return
+		}
+	}
+
+	if _, err := h.propertyRepo.GetPropertyByID(uint(propertyID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	written, err := h.ariRepo.ApplyChanges(uint(propertyID), changes, models.EventSourceARI)
+	if err != nil {
+		log.Printf("Failed to apply ARI updates for property %d: %v", propertyID, err)
+		c.Error(apierror.Internal("Failed to apply ARI updates"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id":  propertyID,
+		"rows_updated": written,
+	})
+}
+
+// parseARIUpdates validates date ranges of ARI updates and converts them to repository
+// changes; field is the JSON path of the updates used in error details
+func parseARIUpdates(updates []ARIRangeUpdate, field string) ([]database.ARIChange, *apierror.APIError) {
+	changes := make([]database.ARIChange, 0, len(updates))
+	for i, update := range updates {
+		start, _ := time.Parse("2006-01-02", update.StartDate)
+		end, _ := time.Parse("2006-01-02", update.EndDate)
+		item := field + "[" + strconv.Itoa(i) + "]"
+		if end.Before(start) {
+			return nil, apierror.InvalidField(item+".end_date", "gtefield", "must not be before start_date")
+		}
+		if end.Sub(start) > 366*24*time.Hour {
+			return nil, apierror.InvalidField(item+".end_date", "max", "range must not exceed one year")
+		}
+		if update.Available == nil && update.MinStay == nil && update.MaxGuests == nil && update.BasePrice == nil {
+			return nil, apierror.InvalidField(item, "required", "must set at least one of available, min_stay, max_guests or base_price")
+		}
+
+		changes = append(changes, database.ARIChange{
+			StartDate: start,
+			EndDate:   end,
+			Available: update.Available,
+			MinStay:   update.MinStay,
+			MaxGuests: update.MaxGuests,
+			BasePrice: update.BasePrice,
+		})
+	}
+	return changes, nil
+}
+
+// ariPermissions returns the team permissions ARI changes require: managing rates to set
+// prices, managing the calendar to set availability and stay rules
+func ariPermissions(changes []database.ARIChange) []string {
+	var rates, calendar bool
+	for _, change := range changes {
+		rates = rates || change.BasePrice != nil
+		calendar = calendar || change.Available != nil || change.MinStay != nil || change.MaxGuests != nil
+	}
+
+	var permissions []string
+	if rates {
+		permissions = append(permissions, models.PermissionManageRates)
+	}
+	if calendar {
+		permissions = append(permissions, models.PermissionManageCalendar)
+	}
+	return permissions
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"channelmanager/database"
+	"channelmanager/models"
+)
+
+func TestARIPermissions(t *testing.T) {
+	open, stay, price := true, 2, 120.0
+	tests := []struct {
+		name    string
+		changes []database.ARIChange
+		want    []string
+	}{
+		{name: "prices", changes: []database.ARIChange{{BasePrice: &price}}, want: []string{models.PermissionManageRates}},
+		{name: "availability", changes: []database.ARIChange{{Available: &open}}, want: []string{models.PermissionManageCalendar}},
+		{name: "stay rules", changes: []database.ARIChange{{MinStay: &stay}}, want: []string{models.PermissionManageCalendar}},
+		{
+			name:    "both across changes",
+			changes: []database.ARIChange{{Available: &open}, {BasePrice: &price}},
+			want:    []string{models.PermissionManageRates, models.PermissionManageCalendar},
+		},
+		{name: "nothing set", changes: []database.ARIChange{{}}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ariPermissions(tt.changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ariPermissions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
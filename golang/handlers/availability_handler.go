@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AvailabilityBatchRequest represents the payload requesting the availability of many
+// properties at once
+type AvailabilityBatchRequest struct {
+	PropertyIDs []uint `json:"property_ids" binding:"required,min=1,max=200,dive,min=1"`
+	StartDate   string `json:"start_date" binding:"required,datetime=2006-01-02"`
+	EndDate     string `json:"end_date" binding:"required,datetime=2006-01-02"`
+}
+
+// BatchNight is the availability of one night in an availability batch
+type BatchNight struct {
+	Available bool `json:"available"`
+	MinStay   int  `json:"min_stay"`
+}
+
+// GetAvailabilityBatch retrieves the availability of many properties for a date range in
+// one query, for channel partners syncing many listings. Each property maps its nights,
+// keyed by date, to whether they are available and their minimum stay; nights without
+// availability are left out.
+func (h *Handler) GetAvailabilityBatch(c *gin.Context) {
+	var req AvailabilityBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	start, _ := time.Parse("2006-01-02", req.StartDate)
+	end, _ := time.Parse("2006-01-02", req.EndDate)
+	if end.Before(start) {
+		c.Error(apierror.InvalidDateRange("end_date must not be before start_date"))
+		return
+	}
+	if end.Sub(start) > 366*24*time.Hour {
+		c.Error(apierror.InvalidDateRange("date range must not exceed one year"))
+		return
+	}
+
+	// Duplicate IDs are answered once
+	seen := make(map[uint]bool, len(req.PropertyIDs))
+	propertyIDs := make([]uint, 0, len(req.PropertyIDs))
+	for _, id := range req.PropertyIDs {
+		if !seen[id] {
+			seen[id] = true
+			propertyIDs = append(propertyIDs, id)
+		}
+	}
+
+	// Concurrent batches for the same properties and dates share one query; the result
+	// is only read
+	availability, _, err := h.batchLoads.Do(availabilityBatchKey(propertyIDs, req.StartDate, req.EndDate), func() (map[uint][]models.Availability, error) {
+		return h.availabilityRepo.GetAvailabilityForProperties(propertyIDs, req.StartDate, req.EndDate)
+	})
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve availability"))
+		return
+	}
+
+	properties := make(map[uint]map[string]BatchNight, len(availability))
+	notFound := []uint{}
+	for _, id := range propertyIDs {
+		rows, ok := availability[id]
+		if !ok {
+			notFound = append(notFound, id)
+			continue
+		}
+		nights := make(map[string]BatchNight, len(rows))
+		for _, row := range rows {
+			nights[row.Date.Format("2006-01-02")] = BatchNight{Available: row.Available, MinStay: row.MinStay}
+		}
+		properties[id] = nights
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"start_date": req.StartDate,
+		"end_date":   req.EndDate,
+		"data":       properties,
+		"not_found":  notFound,
+	})
+}
+
+// availabilityBatchKey identifies an availability batch regardless of the order its
+// properties were requested in
+func availabilityBatchKey(propertyIDs []uint, startDate, endDate string) string {
+	sorted := append([]uint(nil), propertyIDs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return fmt.Sprintf("%s:%s:%v", startDate, endDate, sorted)
+}
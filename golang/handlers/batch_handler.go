@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"channelmanager/apierror"
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+// BatchOperation is one sub-operation of a batch request
+type BatchOperation struct {
+	Ref        string           `json:"ref" binding:"max=100"` // client reference echoed in the result
+	Type       string           `json:"type" binding:"required,oneof=availability pricing"`
+	PropertyID uint             `json:"property_id" binding:"required"`
+	Updates    []ARIRangeUpdate `json:"updates" binding:"required,min=1,max=100,dive"`
+}
+
+// batchPermissions maps batch operation types to the team permission they require
+var batchPermissions = map[string]string{
+	"availability": models.PermissionManageCalendar,
+	"pricing":      models.PermissionManageRates,
+}
+
+// BatchRequest represents the payload of a batch of operations
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations" binding:"required,min=1,max=50"`
+}
+
+// BatchResult reports the outcome of one batch operation
+type BatchResult struct {
+	Index       int                `json:"index"`
+	Ref         string             `json:"ref,omitempty"`
+	Status      string             `json:"status"` // applied, failed or skipped
+	RowsUpdated int                `json:"rows_updated,omitempty"`
+	Error       *apierror.APIError `json:"error,omitempty"`
+}
+
+// ExecuteBatch applies availability and pricing updates across properties in a single
+// transaction. Every operation is validated first; if any fails, nothing is written and
+// the error details list the status of each operation. The whole batch is refused if the
+// API key may not change one of its owned properties.
+func (h *Handler) ExecuteBatch(c *gin.Context) {
+	var req BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	for _, op := range req.Operations {
+		permission, ok := batchPermissions[op.Type]
+		if !ok {
+			// Rejected by validation below
+			continue
+		}
+		if apiErr := h.authorizeProperty(c, op.PropertyID, permission); apiErr != nil {
+			c.Error(apiErr)
+			return
+		}
+	}
+
+	results := make([]BatchResult, len(req.Operations))
+	batch := make([]database.PropertyARIChanges, len(req.Operations))
+	failed := false
+	for i, op := range req.Operations {
+		results[i] = BatchResult{Index: i, Ref: op.Ref, Status: "skipped"}
+
+		changes, apiErr := h.validateBatchOperation(op)
+		if apiErr != nil {
+			results[i].Status = "failed"
+			results[i].Error = apiErr
+			failed = true
+			continue
+		}
+		batch[i] = database.PropertyARIChanges{PropertyID: op.PropertyID, Changes: changes}
+	}
+
+	if failed {
+		c.Error(apierror.Validation("Batch rejected, no operation was applied").WithDetails(results))
+		return
+	}
+
+	written, err := h.ariRepo.ApplyBatch(batch, models.EventSourceBatch)
+	if err != nil {
+		log.Printf("Failed to apply batch of %d operations: %v", len(batch), err)
+		c.Error(apierror.Internal("Failed to apply batch"))
+		return
+	}
+
+	for i := range results {
+		results[i].Status = "applied"
+		results[i].RowsUpdated = written[i]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"applied": len(results),
+		"results": results,
+	})
+}
+
+// validateBatchOperation validates one operation and converts it to repository changes
+func (h *Handler) validateBatchOperation(op BatchOperation) ([]database.ARIChange, *apierror.APIError) {
+	if err := binding.Validator.ValidateStruct(&op); err != nil {
+		return nil, apierror.FromBinding(err)
+	}
+
+	for i, update := range op.Updates {
+		field := "updates[" + strconv.Itoa(i) + "]"
+		switch op.Type {
+		case "availability":
+			if update.BasePrice != nil {
+				return nil, apierror.InvalidField(field+".base_price", "excluded", "must be sent in a pricing operation")
+			}
+		case "pricing":
+			if update.BasePrice == nil {
+				return nil, apierror.InvalidField(field+".base_price", "required", "is required in a pricing operation")
+			}
+			if update.Available != nil || update.MinStay != nil || update.MaxGuests != nil {
+				return nil, apierror.InvalidField(field, "excluded", "pricing operations only set base_price")
+			}
+		}
+	}
+
+	changes, apiErr := parseARIUpdates(op.Updates, "updates")
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if _, err := h.propertyRepo.GetPropertyByID(op.PropertyID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apierror.NotFound("Property")
+		}
+		return nil, apierror.Internal("Failed to retrieve property")
+	}
+
+	return changes, nil
+}
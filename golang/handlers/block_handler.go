@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CalendarBlockRequest represents the payload blocking a property's dates
+type CalendarBlockRequest struct {
+	StartDate string `json:"start_date" binding:"required,datetime=2006-01-02"`
+	EndDate   string `json:"end_date" binding:"required,datetime=2006-01-02"` // inclusive
+	Reason    string `json:"reason" binding:"required,oneof=maintenance owner_stay other"`
+	Note      string `json:"note" binding:"max=500"`
+}
+
+// maxBlockNights caps the length of a single block
+const maxBlockNights = 366
+
+// ListCalendarBlocks retrieves a property's calendar blocks, optionally those overlapping
+// start_date and end_date
+func (h *Handler) ListCalendarBlocks(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var start, end time.Time
+	if c.Query("start_date") != "" || c.Query("end_date") != "" {
+		var ok bool
+		if start, end, ok = parseAnalyticsRange(c); !ok {
+			return
+		}
+	}
+
+	blocks, err := h.blockRepo.GetBlocksForProperty(uint(propertyID), start, end)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve calendar blocks"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": propertyID,
+		"data":        blocks,
+	})
+}
+
+// CreateCalendarBlock blocks a property's dates, closing them for booking and on channels
+func (h *Handler) CreateCalendarBlock(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var req CalendarBlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	start, _ := time.Parse("2006-01-02", req.StartDate)
+	end, _ := time.Parse("2006-01-02", req.EndDate)
+	if end.Before(start) {
+		c.Error(apierror.InvalidDateRange("end_date must not be before start_date"))
+		return
+	}
+	if end.Sub(start) >= maxBlockNights*24*time.Hour {
+		c.Error(apierror.InvalidDateRange("a block must not exceed " + strconv.Itoa(maxBlockNights) + " nights"))
+		return
+	}
+
+	if _, err := h.propertyRepo.GetPropertyByID(uint(propertyID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	block := models.CalendarBlock{
+		PropertyID: uint(propertyID),
+		StartDate:  start,
+		EndDate:    end,
+		Reason:     req.Reason,
+		Note:       strings.TrimSpace(req.Note),
+	}
+	if err := h.blockRepo.CreateBlock(&block); err != nil {
+		switch {
+		case errors.Is(err, database.ErrNotAvailable):
+			c.Error(apierror.New(http.StatusConflict, apierror.CodeNotAvailable, "Some of the dates are booked"))
+		case errors.Is(err, database.ErrBlockOverlap):
+			c.Error(apierror.New(http.StatusConflict, apierror.CodeAlreadyExists, "Dates overlap an existing block"))
+		default:
+			log.Printf("Failed to create calendar block: %v", err)
+			c.Error(apierror.Internal("Failed to create calendar block"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data": block,
+	})
+}
+
+// DeleteCalendarBlock removes a block and reopens the nights it closed
+func (h *Handler) DeleteCalendarBlock(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+	blockID, err := strconv.ParseUint(c.Param("block_id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("block"))
+		return
+	}
+
+	deleted, err := h.blockRepo.DeleteBlock(uint(propertyID), uint(blockID))
+	if err != nil {
+		log.Printf("Failed to delete calendar block %d: %v", blockID, err)
+		c.Error(apierror.Internal("Failed to delete calendar block"))
+		return
+	}
+	if !deleted {
+		c.Error(apierror.NotFound("Block"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
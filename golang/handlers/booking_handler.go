@@ -0,0 +1,1090 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/channels"
+	"channelmanager/database"
+	"channelmanager/fx"
+	"channelmanager/middleware"
+	"channelmanager/models"
+	"channelmanager/quote"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateBookingRequest represents the payload for booking a stay
+type CreateBookingRequest struct {
+	PropertyID        uint   `json:"property_id" binding:"required"`
+	ChannelID         string `json:"channel_id" binding:"max=50"`
+	ExternalReference string `json:"external_reference" binding:"max=100"`
+	GuestName         string `json:"guest_name" binding:"required,max=255"`
+	GuestEmail        string `json:"guest_email" binding:"required,email"`
+	GuestPhone        string `json:"guest_phone" binding:"max=50"`
+	CheckinDate       string `json:"checkin_date" binding:"required,datetime=2006-01-02,notpast"`
+	CheckoutDate      string `json:"checkout_date" binding:"required,datetime=2006-01-02"`
+	NumberOfGuests    int    `json:"number_of_guests" binding:"required,min=1,max=50"`
+	Children          int    `json:"children" binding:"min=0,max=50"` // of number_of_guests
+	Infants           int    `json:"infants" binding:"min=0,max=50"`  // of number_of_guests
+	RedeemPoints      int    `json:"redeem_points" binding:"min=0"`   // loyalty points paying part of the stay
+	// Gift cards and vouchers paying part of the stay, after loyalty points
+	VoucherCodes []string `json:"voucher_codes" binding:"max=10,dive,required,max=40"`
+}
+
+// CreateBooking books a stay at its quoted price and closes the booked nights. At
+// properties booked on request the booking is pending until the host approves it, and
+// its nights reopen if the host declines it or does not answer by approval_deadline.
+// Send an Idempotency-Key header so retries do not create duplicate bookings.
+func (h *Handler) CreateBooking(c *gin.Context) {
+	var req CreateBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	h.createBooking(c, req)
+}
+
+// ReceiveChannelBooking books a reservation sent by a channel in a signed webhook; the
+// channel is the verified webhook partner, whatever the payload says. A redelivered
+// reservation returns the booking already stored, modified when its dates, guest
+// details or party changed.
+func (h *Handler) ReceiveChannelBooking(c *gin.Context) {
+	var req CreateBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+	if req.ExternalReference == "" {
+		c.Error(apierror.InvalidField("external_reference", "required", "is required for channel reservations"))
+		return
+	}
+
+	req.ChannelID = middleware.WebhookPartner(c)
+	if _, err := h.channelRepo.GetChannelByID(req.ChannelID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Channel"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve channel"))
+		return
+	}
+
+	h.createBooking(c, req)
+}
+
+// CancelChannelBooking cancels a reservation the webhook partner sent before and
+// reopens its nights
+func (h *Handler) CancelChannelBooking(c *gin.Context) {
+	channelID := middleware.WebhookPartner(c)
+	if _, err := h.channelRepo.GetChannelByID(channelID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Channel"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve channel"))
+		return
+	}
+
+	h.importReservation(c, channelID, channels.ChannelReservation{
+		Type:              channels.ReservationCancelled,
+		ExternalReference: c.Param("reference"),
+	})
+}
+
+// ReceiveVrboReservation stores a reservation event sent by Vrbo in a signed webhook at
+// the price Vrbo charged: new reservations are booked, updates modify the booking and
+// cancellations cancel it. A redelivered event returns the booking already stored.
+func (h *Handler) ReceiveVrboReservation(c *gin.Context) {
+	var event channels.VrboReservationEvent
+	if err := c.ShouldBindJSON(&event); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	channelID := middleware.WebhookPartner(c)
+	if _, err := h.channelRepo.GetChannelByID(channelID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Channel"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve channel"))
+		return
+	}
+
+	h.importReservation(c, channelID, event.ChannelReservation())
+}
+
+// importReservation stores a channel reservation and reports the booking
+func (h *Handler) importReservation(c *gin.Context, channelID string, reservation channels.ChannelReservation) {
+	if reservation.Type != channels.ReservationCancelled && !reservation.CheckoutDate.After(reservation.CheckinDate) {
+		c.Error(apierror.InvalidDateRange("departure must be after arrival"))
+		return
+	}
+
+	booking, err := h.reservations.Import(channelID, reservation)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrNotAvailable):
+			c.Error(apierror.New(http.StatusConflict, apierror.CodeNotAvailable, "Property is not available for the requested dates"))
+		case errors.Is(err, channels.ErrUnknownListing):
+			c.Error(apierror.Unprocessable("Reservation is for a listing that is not mapped to a property"))
+		case errors.Is(err, channels.ErrReservationType):
+			c.Error(apierror.Unprocessable("Reservation type is not supported"))
+		case errors.Is(err, database.ErrUnknownReservation):
+			c.Error(apierror.NotFound("Reservation"))
+		case errors.Is(err, database.ErrNotConfirmed):
+			c.Error(apierror.InvalidState("Reservation is no longer confirmed"))
+		default:
+			log.Printf("Failed to store reservation %s of channel %s: %v", reservation.ExternalReference, channelID, err)
+			c.Error(apierror.Internal("Failed to store reservation"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": booking})
+}
+
+// createBooking prices and books a validated booking request
+func (h *Handler) createBooking(c *gin.Context, req CreateBookingRequest) {
+	checkin, _ := time.Parse("2006-01-02", req.CheckinDate)
+	checkout, _ := time.Parse("2006-01-02", req.CheckoutDate)
+	if !checkout.After(checkin) {
+		c.Error(apierror.InvalidDateRange("checkout_date must be after checkin_date"))
+		return
+	}
+	if req.Children+req.Infants > req.NumberOfGuests {
+		c.Error(apierror.InvalidField("children", "ltefield", "children and infants must be counted in number_of_guests"))
+		return
+	}
+	if req.RedeemPoints > 0 && req.ChannelID != "" {
+		c.Error(apierror.InvalidField("redeem_points", "excluded_with", "channel reservations cannot redeem loyalty points"))
+		return
+	}
+	if len(req.VoucherCodes) > 0 && req.ChannelID != "" {
+		c.Error(apierror.InvalidField("voucher_codes", "excluded_with", "channel reservations cannot be paid with vouchers"))
+		return
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(req.PropertyID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+	// Channels may still deliver reservations made before the property's nights closed
+	if !property.IsActive() && req.ChannelID == "" {
+		c.Error(apierror.InvalidState("The property is not accepting bookings"))
+		return
+	}
+	if property.MaxGuests > 0 && req.NumberOfGuests > property.MaxGuests {
+		c.Error(apierror.InvalidField("number_of_guests", "max", "exceeds the property's maximum of guests"))
+		return
+	}
+	if apiErr := bookingWindowError(property, checkin); apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+	if apiErr, err := h.minStayError(property.ID, checkin, checkout); err != nil {
+		c.Error(apierror.Internal("Failed to retrieve availability"))
+		return
+	} else if apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+
+	settings, err := h.propertySettings(c.Request.Context(), property)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve organization settings"))
+		return
+	}
+
+	q, err := h.quoteEngine.Quote(quote.Request{
+		PropertyID:   property.ID,
+		Property:     property,
+		CheckinDate:  checkin,
+		CheckoutDate: checkout,
+		Guests:       req.NumberOfGuests,
+		Children:     req.Children,
+		Infants:      req.Infants,
+		Settings:     settings,
+	})
+	if errors.Is(err, fx.ErrNoRate) {
+		c.Error(apierror.Unprocessable(fmt.Sprintf("No exchange rate is known for %s", settings.DefaultCurrency)))
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to compute quote: %v", err)
+		c.Error(apierror.Internal("Failed to compute quote"))
+		return
+	}
+	if !q.Complete() {
+		c.Error(apierror.New(http.StatusConflict, apierror.CodeNotAvailable, "Some nights of the stay have no price").
+			WithDetails(gin.H{"missing_nights": q.MissingNights}))
+		return
+	}
+	if req.RedeemPoints > 0 && !h.redeemPoints(c, q, req.GuestEmail, req.RedeemPoints) {
+		return
+	}
+	if len(req.VoucherCodes) > 0 && !h.applyVouchers(c, q, req.VoucherCodes) {
+		return
+	}
+
+	booking := models.Booking{
+		PropertyID:        property.ID,
+		ChannelID:         req.ChannelID,
+		ExternalReference: req.ExternalReference,
+		GuestName:         strings.TrimSpace(req.GuestName),
+		GuestEmail:        req.GuestEmail,
+		GuestPhone:        req.GuestPhone,
+		CheckinDate:       checkin,
+		CheckoutDate:      checkout,
+		NumberOfGuests:    req.NumberOfGuests,
+		NumberOfChildren:  req.Children,
+		NumberOfInfants:   req.Infants,
+		Status:            models.BookingStatusConfirmed,
+		TotalPrice:        q.Total,
+		LoyaltyCredit:     q.Credit,
+		VoucherAmount:     q.VoucherAmount,
+		CancelPolicy:      q.Cancellation.Policy,
+		Pricing:           q.Breakdown(),
+	}
+	if q.Currency != "" {
+		booking.Currency = q.Currency
+	}
+	// Channels confirm their reservations themselves
+	if property.RequiresApproval() && booking.ChannelID == "" {
+		deadline := h.approvalDeadline(property, checkin, time.Now())
+		booking.Status = models.BookingStatusPending
+		booking.ApprovalDeadline = &deadline
+	}
+	if q.Deposit != nil {
+		booking.DepositAmount = q.Deposit.Amount
+		booking.DepositMode = q.Deposit.Mode
+		booking.DepositStatus = models.DepositStatusPending
+	}
+
+	started := time.Now()
+	outcome := database.InboundCreated
+	if booking.ChannelID != "" && booking.ExternalReference != "" {
+		// Channels redeliver reservations; each is stored once per channel and reference
+		outcome, err = h.bookingRepo.StoreInboundReservation(&booking, channels.ContentHash(req), false, property.TurnoverNights())
+	} else if q.PointsRedeemed > 0 || len(q.Vouchers) > 0 {
+		err = h.bookingRepo.CreateBookingRedeeming(&booking, property.TurnoverNights(), database.Redemption{
+			Points:   q.PointsRedeemed,
+			Vouchers: q.Vouchers,
+		})
+	} else {
+		err = h.bookingRepo.CreateBookingWithInventory(&booking, property.TurnoverNights())
+	}
+	if booking.ChannelID != "" {
+		summary := fmt.Sprintf("reservation %s, %s..%s", booking.ExternalReference, req.CheckinDate, req.CheckoutDate)
+		if err == nil {
+			summary += ", " + outcome
+		}
+		channels.RecordSync(h.syncLogRepo, models.SyncLog{
+			ChannelID:  booking.ChannelID,
+			PropertyID: booking.PropertyID,
+			Direction:  models.SyncInbound,
+			Operation:  models.SyncOperationReservation,
+			Summary:    summary,
+			Items:      booking.Nights(),
+		}, started, err)
+	}
+	if err != nil {
+		if errors.Is(err, database.ErrNotAvailable) {
+			c.Error(apierror.New(http.StatusConflict, apierror.CodeNotAvailable, "Property is not available for the requested dates"))
+			return
+		}
+		if errors.Is(err, database.ErrInsufficientPoints) {
+			c.Error(apierror.InvalidField("redeem_points", "max", "exceeds the guest's points balance"))
+			return
+		}
+		if errors.Is(err, database.ErrVoucherUnavailable) {
+			c.Error(apierror.Unprocessable("A voucher was spent or voided meanwhile, quote the stay again"))
+			return
+		}
+		if errors.Is(err, database.ErrNotConfirmed) {
+			c.Error(apierror.InvalidState("Reservation is no longer confirmed"))
+			return
+		}
+		log.Printf("Failed to create booking: %v", err)
+		c.Error(apierror.Internal("Failed to create booking"))
+		return
+	}
+
+	status := http.StatusCreated
+	if outcome != database.InboundCreated {
+		status = http.StatusOK
+	}
+	c.JSON(status, gin.H{
+		"data":  booking,
+		"quote": q,
+	})
+}
+
+// bookingCSVColumns is the header row of a booking export
+var bookingCSVColumns = []string{"id", "property_id", "channel_id", "external_reference", "status",
+	"checkin_date", "checkout_date", "nights", "guest_name", "guest_email", "guest_phone", "number_of_guests",
+	"number_of_children", "number_of_infants", "currency", "total_price", "created_at"}
+
+// ListBookings retrieves bookings, newest first, optionally of one property, channel or
+// status, with a night between start_date and end_date, or for a guest name. Bookings
+// come in numbered pages, or after a cursor when the cursor parameter is sent: empty
+// for the newest bookings, then the next_cursor of the previous response.
+func (h *Handler) ListBookings(c *gin.Context) {
+	filter, ok := parseBookingFilter(c)
+	if !ok || !h.authorizeBookingList(c, filter) {
+		return
+	}
+
+	param, keyset := c.GetQuery("cursor")
+	if !keyset {
+		page := parsePage(c, 20, 100)
+		bookings, total, err := h.bookingRepo.ListBookings(filter, page.Limit, page.Offset())
+		if err != nil {
+			c.Error(apierror.Internal("Failed to retrieve bookings"))
+			return
+		}
+
+		c.JSON(http.StatusOK, paginated(c, bookings, total, page))
+		return
+	}
+
+	var after *database.BookingCursor
+	if param != "" {
+		cursor, err := decodeBookingCursor(param)
+		if err != nil {
+			c.Error(apierror.InvalidField("cursor", "cursor", "must be the next_cursor of a previous response"))
+			return
+		}
+		after = &cursor
+	}
+	limit := parsePage(c, 20, 100).Limit
+
+	// One booking more than the limit tells whether there is a next page
+	bookings, err := h.bookingRepo.ListBookingsAfter(filter, after, limit+1)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve bookings"))
+		return
+	}
+	hasNext := len(bookings) > limit
+	if hasNext {
+		bookings = bookings[:limit]
+	}
+	nextCursor := ""
+	if hasNext {
+		nextCursor = encodeBookingCursor(bookings[len(bookings)-1])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        bookings,
+		"limit":       limit,
+		"has_next":    hasNext,
+		"next_cursor": nextCursor,
+	})
+}
+
+// ExportBookingsCSV downloads the bookings matching the ListBookings filters as CSV,
+// newest first, one row per booking
+func (h *Handler) ExportBookingsCSV(c *gin.Context) {
+	filter, ok := parseBookingFilter(c)
+	if !ok || !h.authorizeBookingList(c, filter) {
+		return
+	}
+
+	// Read the first chunk before committing to a 200 response
+	bookings, err := h.bookingRepo.ListBookingsAfter(filter, nil, exportChunkSize)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve bookings"))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=bookings-%s.csv", time.Now().UTC().Format("2006-01-02")))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write(bookingCSVColumns)
+	for len(bookings) > 0 {
+		for _, b := range bookings {
+			w.Write([]string{
+				strconv.FormatUint(uint64(b.ID), 10),
+				strconv.FormatUint(uint64(b.PropertyID), 10),
+				csvText(b.ChannelID),
+				csvText(b.ExternalReference),
+				b.Status,
+				b.CheckinDate.Format("2006-01-02"),
+				b.CheckoutDate.Format("2006-01-02"),
+				strconv.Itoa(b.Nights()),
+				csvText(b.GuestName),
+				csvText(b.GuestEmail),
+				csvText(b.GuestPhone),
+				strconv.Itoa(b.NumberOfGuests),
+				strconv.Itoa(b.NumberOfChildren),
+				strconv.Itoa(b.NumberOfInfants),
+				b.Currency,
+				strconv.FormatFloat(b.TotalPrice, 'f', 2, 64),
+				b.CreatedAt.UTC().Format(time.RFC3339),
+			})
+		}
+		w.Flush()
+		if len(bookings) < exportChunkSize {
+			break
+		}
+
+		last := bookings[len(bookings)-1]
+		if bookings, err = h.bookingRepo.ListBookingsAfter(filter,
+			&database.BookingCursor{CreatedAt: last.CreatedAt, ID: last.ID}, exportChunkSize); err != nil {
+			// The status is already sent; the truncated file is all the client gets
+			log.Printf("Failed to export bookings: %v", err)
+			return
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Printf("Failed to write bookings CSV: %v", err)
+	}
+}
+
+// authorizeBookingList checks that the request may read the bookings of a list: admin
+// API keys read every property's, other API keys only those of the property_id they may
+// view. It writes an error response and returns false otherwise.
+func (h *Handler) authorizeBookingList(c *gin.Context, filter database.BookingFilter) bool {
+	if !requireAPIKey(c) {
+		return false
+	}
+	if middleware.IsAdmin(c) {
+		return true
+	}
+	if filter.PropertyID == 0 {
+		c.Error(apierror.InvalidField("property_id", "required", "property_id is required without an admin API key"))
+		return false
+	}
+	if apiErr := h.authorizeProperty(c, filter.PropertyID, models.PermissionViewOnly); apiErr != nil {
+		c.Error(apiErr)
+		return false
+	}
+	return true
+}
+
+// parseBookingFilter reads the filters of a booking list
+func parseBookingFilter(c *gin.Context) (database.BookingFilter, bool) {
+	var filter database.BookingFilter
+	if raw := c.Query("property_id"); raw != "" {
+		propertyID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.Error(apierror.InvalidField("property_id", "numeric", "property_id must be a property ID"))
+			return filter, false
+		}
+		filter.PropertyID = uint(propertyID)
+	}
+	filter.ChannelID = c.Query("channel_id")
+	filter.Status = c.Query("status")
+	if filter.Status != "" && !models.ValidBookingStatus(filter.Status) {
+		c.Error(apierror.InvalidField("status", "oneof", "status must be pending, confirmed, cancelled, checked_in, checked_out, no_show, declined or expired"))
+		return filter, false
+	}
+	if c.Query("start_date") != "" || c.Query("end_date") != "" {
+		startDate, endDate, ok := parseAnalyticsRange(c)
+		if !ok {
+			return filter, false
+		}
+		filter.StayStart, filter.StayEnd = &startDate, &endDate
+	}
+	filter.GuestName = strings.TrimSpace(c.Query("guest_name"))
+	if len(filter.GuestName) > 255 {
+		c.Error(apierror.InvalidField("guest_name", "max", "guest_name must not exceed 255 characters"))
+		return filter, false
+	}
+	return filter, true
+}
+
+// encodeBookingCursor returns the cursor of the bookings after b
+func encodeBookingCursor(b models.Booking) string {
+	raw := fmt.Sprintf("%d:%d", b.CreatedAt.UnixNano(), b.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeBookingCursor reads a cursor made by encodeBookingCursor
+func decodeBookingCursor(cursor string) (database.BookingCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return database.BookingCursor{}, err
+	}
+	created, id, found := strings.Cut(string(raw), ":")
+	if !found {
+		return database.BookingCursor{}, errors.New("malformed booking cursor")
+	}
+	nanos, err := strconv.ParseInt(created, 10, 64)
+	if err != nil {
+		return database.BookingCursor{}, err
+	}
+	bookingID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return database.BookingCursor{}, err
+	}
+	return database.BookingCursor{CreatedAt: time.Unix(0, nanos), ID: uint(bookingID)}, nil
+}
+
+// csvText keeps free text such as guest names from being read as a formula by
+// spreadsheet applications
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// GetBooking retrieves a booking with the modifications and cancellation its channel
+// sent after booking it. It needs an API key permitted to view the booking's property.
+func (h *Handler) GetBooking(c *gin.Context) {
+	bookingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("booking"))
+		return
+	}
+	if !requireAPIKey(c) {
+		return
+	}
+
+	booking, err := h.bookingRepo.GetBookingWithModifications(uint(bookingID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Booking"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve booking"))
+		return
+	}
+	if apiErr := h.authorizeProperty(c, booking.PropertyID, models.PermissionViewOnly); apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": booking})
+}
+
+// CancelBooking cancels a confirmed booking and reopens its stay and turnover nights.
+// Bookings of owned properties need an API key permitted to manage their calendar, as do
+// the check-in, check-out and no-show transitions.
+func (h *Handler) CancelBooking(c *gin.Context) {
+	bookingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("booking"))
+		return
+	}
+
+	booking, err := h.bookingRepo.GetBookingByID(uint(bookingID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Booking"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve booking"))
+		return
+	}
+	if apiErr := h.authorizeProperty(c, booking.PropertyID, models.PermissionManageCalendar); apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+	if booking.Status != models.BookingStatusConfirmed {
+		c.Error(apierror.New(http.StatusConflict, apierror.CodeInvalidState, "Only confirmed bookings can be cancelled"))
+		return
+	}
+
+	if err := h.bookingRepo.CancelBookingWithInventory(booking); err != nil {
+		if errors.Is(err, database.ErrNotConfirmed) {
+			c.Error(apierror.New(http.StatusConflict, apierror.CodeInvalidState, "Only confirmed bookings can be cancelled"))
+			return
+		}
+		log.Printf("Failed to cancel booking %d: %v", booking.ID, err)
+		c.Error(apierror.Internal("Failed to cancel booking"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": booking,
+	})
+}
+
+// CheckInBooking records that the guest of a confirmed booking arrived. Guests check in
+// from the arrival day at the property until the day before departure.
+func (h *Handler) CheckInBooking(c *gin.Context) {
+	h.transitionBooking(c, models.BookingStatusCheckedIn)
+}
+
+// CheckOutBooking records that a checked-in guest left, early departures included
+func (h *Handler) CheckOutBooking(c *gin.Context) {
+	h.transitionBooking(c, models.BookingStatusCheckedOut)
+}
+
+// MarkBookingNoShow records that the guest of a confirmed booking never arrived, from the
+// arrival day at the property on. The nights stay booked; the booking's channel is told
+// when it takes no-show reports, and channel_report says whether it was.
+func (h *Handler) MarkBookingNoShow(c *gin.Context) {
+	h.transitionBooking(c, models.BookingStatusNoShow)
+}
+
+// transitionBooking moves a booking to another lifecycle status
+func (h *Handler) transitionBooking(c *gin.Context, to string) {
+	bookingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("booking"))
+		return
+	}
+
+	booking, err := h.bookingRepo.GetBookingByID(uint(bookingID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Booking"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve booking"))
+		return
+	}
+	if apiErr := h.authorizeProperty(c, booking.PropertyID, models.PermissionManageCalendar); apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+	if !models.CanTransitionBooking(booking.Status, to) {
+		c.Error(apierror.InvalidState(fmt.Sprintf("A %s booking cannot become %s", booking.Status, to)))
+		return
+	}
+	if to != models.BookingStatusCheckedOut {
+		property, err := h.propertyRepo.GetPropertyByID(booking.PropertyID)
+		if err != nil {
+			c.Error(apierror.Internal("Failed to retrieve property"))
+			return
+		}
+		today := property.Today()
+		if today.Before(booking.CheckinDate) {
+			c.Error(apierror.InvalidState("The stay has not started yet"))
+			return
+		}
+		if to == models.BookingStatusCheckedIn && !today.Before(booking.CheckoutDate) {
+			c.Error(apierror.InvalidState("The stay has already ended"))
+			return
+		}
+	}
+
+	if err := h.bookingRepo.TransitionBooking(booking, to, time.Now()); err != nil {
+		if errors.Is(err, database.ErrStatusChanged) {
+			c.Error(apierror.InvalidState("Booking status changed meanwhile"))
+			return
+		}
+		log.Printf("Failed to update booking %d to %s: %v", booking.ID, to, err)
+		c.Error(apierror.Internal("Failed to update booking"))
+		return
+	}
+
+	body := gin.H{"data": booking}
+	if to == models.BookingStatusNoShow && booking.ChannelID != "" {
+		report := "reported"
+		if err := h.reservations.ReportNoShow(c.Request.Context(), *booking); err != nil {
+			if errors.Is(err, channels.ErrUnsupported) {
+				report = "unsupported"
+			} else {
+				log.Printf("Failed to report no-show of booking %d to channel %s: %v", booking.ID, booking.ChannelID, err)
+				report = "failed"
+			}
+		}
+		body["channel_report"] = report
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// PropertyTurnoverRequest represents the payload setting a property's preparation time
+type PropertyTurnoverRequest struct {
+	TurnoverDays int `json:"turnover_days" binding:"min=0,max=30"`
+}
+
+// UpdatePropertyTurnover sets how many nights are closed after each checkout. It applies
+// to bookings made from now on.
+func (h *Handler) UpdatePropertyTurnover(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var req PropertyTurnoverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	if _, err := h.propertyRepo.GetPropertyByID(uint(propertyID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	if err := h.propertyRepo.UpdateTurnoverDays(uint(propertyID), req.TurnoverDays); err != nil {
+		c.Error(apierror.Internal("Failed to update turnover days"))
+		return
+	}
+
+	if err := h.redis.InvalidatePropertyCache(c.Request.Context(), uint(propertyID)); err != nil {
+		log.Printf("Failed to invalidate property cache: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id":   propertyID,
+		"turnover_days": req.TurnoverDays,
+	})
+}
+
+// BookingWindowRequest represents the payload setting how far ahead a property can be booked
+type BookingWindowRequest struct {
+	MinAdvanceDays int `json:"min_advance_days" binding:"min=0,max=365"`
+	MaxAdvanceDays int `json:"max_advance_days" binding:"min=0,max=1095"` // 0 for no horizon
+}
+
+// GetPropertyBookingWindow retrieves a property's booking window rules
+func (h *Handler) GetPropertyBookingWindow(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id":      property.ID,
+		"min_advance_days": property.MinAdvanceDays,
+		"max_advance_days": property.MaxAdvanceDays,
+	})
+}
+
+// UpdatePropertyBookingWindow sets how many days ahead check-in must at least and may at
+// most be booked
+func (h *Handler) UpdatePropertyBookingWindow(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var req BookingWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+	if req.MaxAdvanceDays > 0 && req.MaxAdvanceDays < req.MinAdvanceDays {
+		c.Error(apierror.InvalidField("max_advance_days", "gtefield", "must not be less than min_advance_days"))
+		return
+	}
+
+	if _, err := h.propertyRepo.GetPropertyByID(uint(propertyID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	if err := h.propertyRepo.UpdateBookingWindow(uint(propertyID), req.MinAdvanceDays, req.MaxAdvanceDays); err != nil {
+		c.Error(apierror.Internal("Failed to update booking window"))
+		return
+	}
+
+	if err := h.redis.InvalidatePropertyCache(c.Request.Context(), uint(propertyID)); err != nil {
+		log.Printf("Failed to invalidate property cache: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id":      propertyID,
+		"min_advance_days": req.MinAdvanceDays,
+		"max_advance_days": req.MaxAdvanceDays,
+	})
+}
+
+// PropertyTimezoneRequest represents the payload setting a property's time zone
+type PropertyTimezoneRequest struct {
+	Timezone string `json:"timezone" binding:"required,max=64"` // IANA name, e.g. America/New_York
+}
+
+// GetPropertyTimezone retrieves a property's time zone and its current calendar day
+func (h *Handler) GetPropertyTimezone(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": property.ID,
+		"timezone":    property.TimeLocation().String(),
+		"today":       property.Today().Format("2006-01-02"),
+	})
+}
+
+// UpdatePropertyTimezone sets the time zone check-in dates and "today" are interpreted
+// in. Stored nights are calendar days and keep their dates.
+func (h *Handler) UpdatePropertyTimezone(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var req PropertyTimezoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+	loc, err := time.LoadLocation(req.Timezone)
+	if err != nil || req.Timezone == "Local" {
+		c.Error(apierror.InvalidField("timezone", "timezone", "must be an IANA time zone such as Europe/Lisbon"))
+		return
+	}
+
+	if _, err := h.propertyRepo.GetPropertyByID(uint(propertyID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	if err := h.propertyRepo.UpdateTimezone(uint(propertyID), loc.String()); err != nil {
+		c.Error(apierror.Internal("Failed to update time zone"))
+		return
+	}
+
+	if err := h.redis.InvalidatePropertyCache(c.Request.Context(), uint(propertyID)); err != nil {
+		log.Printf("Failed to invalidate property cache: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": propertyID,
+		"timezone":    loc.String(),
+		"today":       models.LocalDate(time.Now(), loc).Format("2006-01-02"),
+	})
+}
+
+// CheckinTimesRequest represents the payload setting a property's arrival and departure
+// times, as HH:MM in its time zone
+type CheckinTimesRequest struct {
+	CheckinFrom     string `json:"checkin_from" binding:"required,datetime=15:04"`
+	CheckinUntil    string `json:"checkin_until" binding:"omitempty,datetime=15:04"` // empty for no latest check-in
+	CheckoutUntil   string `json:"checkout_until" binding:"required,datetime=15:04"`
+	SameDayTurnover *bool  `json:"same_day_turnover" binding:"required"`
+}
+
+// GetPropertyCheckinTimes retrieves a property's arrival and departure times
+func (h *Handler) GetPropertyCheckinTimes(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": property.ID,
+		"data":        property.CheckinTimes(),
+	})
+}
+
+// UpdatePropertyCheckinTimes sets a property's arrival and departure times and whether a
+// stay may arrive on the day the previous one leaves. Without same-day turnover, bookings
+// made from now on also keep their checkout night closed.
+func (h *Handler) UpdatePropertyCheckinTimes(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var req CheckinTimesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+	// HH:MM strings compare in time order
+	if req.CheckinUntil != "" && req.CheckinUntil <= req.CheckinFrom {
+		c.Error(apierror.InvalidField("checkin_until", "gtfield", "must be after checkin_from"))
+		return
+	}
+	if *req.SameDayTurnover && req.CheckoutUntil > req.CheckinFrom {
+		c.Error(apierror.InvalidField("checkout_until", "ltefield",
+			"must not be after checkin_from when same-day turnover is allowed"))
+		return
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	property.CheckinFrom = req.CheckinFrom
+	property.CheckinUntil = req.CheckinUntil
+	property.CheckoutUntil = req.CheckoutUntil
+	property.SameDayTurnover = *req.SameDayTurnover
+	times := property.CheckinTimes()
+	if err := h.propertyRepo.UpdateCheckinTimes(property.ID, times); err != nil {
+		c.Error(apierror.Internal("Failed to update check-in times"))
+		return
+	}
+
+	if err := h.redis.InvalidatePropertyCache(c.Request.Context(), property.ID); err != nil {
+		log.Printf("Failed to invalidate property cache: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": property.ID,
+		"data":        times,
+	})
+}
+
+// DepositRequest represents the payload setting a property's security deposit
+type DepositRequest struct {
+	Amount float64 `json:"amount" binding:"min=0"` // 0 for no deposit
+	Mode   string  `json:"mode" binding:"omitempty,oneof=hold charge"`
+}
+
+// GetPropertyDeposit retrieves the security deposit a property requires
+func (h *Handler) GetPropertyDeposit(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": property.ID,
+		"data":        property.Deposit(),
+	})
+}
+
+// UpdatePropertyDeposit sets the security deposit required of bookings made from now on,
+// held on the guest's card or charged before arrival
+func (h *Handler) UpdatePropertyDeposit(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var req DepositRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = models.DepositModeHold
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	if err := h.propertyRepo.UpdateDeposit(property.ID, req.Amount, req.Mode); err != nil {
+		c.Error(apierror.Internal("Failed to update deposit"))
+		return
+	}
+
+	if err := h.redis.InvalidatePropertyCache(c.Request.Context(), property.ID); err != nil {
+		log.Printf("Failed to invalidate property cache: %v", err)
+	}
+
+	property.DepositAmount = req.Amount
+	property.DepositMode = req.Mode
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": property.ID,
+		"data":        property.Deposit(),
+	})
+}
+
+// minStayError reports a stay shorter than the minimum stay of its arrival night
+func (h *Handler) minStayError(propertyID uint, checkin, checkout time.Time) (*apierror.APIError, error) {
+	minStay, err := h.availabilityRepo.GetMinStay(propertyID, checkin)
+	if err != nil {
+		return nil, err
+	}
+	nights := int(checkout.Sub(checkin).Hours() / 24)
+	if nights >= minStay {
+		return nil, nil
+	}
+	return apierror.New(http.StatusConflict, apierror.CodeNotAvailable,
+		fmt.Sprintf("Stays arriving on %s must be at least %d nights", checkin.Format("2006-01-02"), minStay)).
+		WithDetails(gin.H{"reason": models.ExclusionMinStay, "min_stay": minStay, "nights": nights}), nil
+}
+
+// bookingWindowError reports a check-in date that has passed or is outside the property's
+// booking window, both counted in the property's time zone
+func bookingWindowError(property *models.Property, checkin time.Time) *apierror.APIError {
+	if reason := property.BookingWindowViolation(checkin, property.Today()); reason != "" {
+		return apierror.InvalidField("checkin_date", "booking_window", reason)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"channelmanager/apierror"
+	"channelmanager/database"
+	"channelmanager/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthorizeBookingList(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	admin := middleware.Config{AdminAPIKeys: []string{"admin-key"}}
+	tests := []struct {
+		name   string
+		key    string
+		filter database.BookingFilter
+		status int // 0 when the list is allowed
+	}{
+		{name: "admin lists every property", key: "admin-key", status: 0},
+		{name: "admin lists one property", key: "admin-key", filter: database.BookingFilter{PropertyID: 7}, status: 0},
+		{name: "no API key", status: http.StatusUnauthorized},
+		{name: "no API key for one property", filter: database.BookingFilter{PropertyID: 7}, status: http.StatusUnauthorized},
+		{name: "other keys must name a property", key: "owner-key", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/bookings", nil)
+			if tt.key != "" {
+				c.Request.Header.Set("X-API-Key", tt.key)
+			}
+			middleware.RecognizeAdmin(admin)(c)
+
+			allowed := (&Handler{}).authorizeBookingList(c, tt.filter)
+			if tt.status == 0 {
+				if !allowed || len(c.Errors) > 0 {
+					t.Fatalf("authorizeBookingList() refused the list: %v", c.Errors)
+				}
+				return
+			}
+			var apiErr *apierror.APIError
+			if allowed || len(c.Errors) != 1 || !errors.As(c.Errors[0].Err, &apiErr) {
+				t.Fatalf("authorizeBookingList() = %v with errors %v, want an API error", allowed, c.Errors)
+			}
+			if apiErr.Status != tt.status {
+				t.Errorf("status = %d, want %d", apiErr.Status, tt.status)
+			}
+		})
+	}
+}
+
+func TestGetBookingAnonymous(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/bookings/1", nil)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+	(&Handler{}).GetBooking(c)
+
+	var apiErr *apierror.APIError
+	if len(c.Errors) != 1 || !errors.As(c.Errors[0].Err, &apiErr) || apiErr.Status != http.StatusUnauthorized {
+		t.Fatalf("GetBooking() errors = %v, want one 401", c.Errors)
+	}
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BookingConfig holds the settings of booking requests and booking calendar feeds
+type BookingConfig struct {
+	RequestTTL     time.Duration // how long the host has to answer a booking request
+	ExpiryInterval time.Duration // how often unanswered requests are expired; zero disables it
+
+	// Key signing the URLs of booking calendar feeds, and the public URL of the API the
+	// feed URLs are built on, e.g. "https://api.example.com"; feeds are disabled
+	// without both
+	CalendarFeedKey     string
+	CalendarFeedBaseURL string
+}
+
+// Validate rejects a calendar feed base URL that is not an absolute http or https URL
+func (cfg BookingConfig) Validate() error {
+	if cfg.CalendarFeedBaseURL == "" {
+		return nil
+	}
+	u, err := url.Parse(cfg.CalendarFeedBaseURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("calendar feed base URL %q must be an absolute http or https URL", cfg.CalendarFeedBaseURL)
+	}
+	return nil
+}
+
+// calendarFeedsEnabled reports whether booking calendar feed URLs can be handed out
+func (cfg BookingConfig) calendarFeedsEnabled() bool {
+	return cfg.CalendarFeedKey != "" && cfg.CalendarFeedBaseURL != ""
+}
+
+// BookingModeRequest represents the payload setting how a property is booked
+type BookingModeRequest struct {
+	BookingMode string `json:"booking_mode" binding:"required,oneof=instant request"`
+}
+
+// DeclineBookingRequest represents the payload declining a booking request
+type DeclineBookingRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// GetPropertyBookingMode retrieves whether a property is booked instantly or on request
+func (h *Handler) GetPropertyBookingMode(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id":       property.ID,
+		"booking_mode":      bookingMode(property),
+		"request_ttl_hours": int(h.bookings.RequestTTL.Hours()),
+	})
+}
+
+// UpdatePropertyBookingMode sets whether bookings made from now on are confirmed at once
+// or are requests the host approves or declines. Pending requests are not affected.
+func (h *Handler) UpdatePropertyBookingMode(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var req BookingModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	if _, err := h.propertyRepo.GetPropertyByID(uint(propertyID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	if err := h.propertyRepo.UpdateBookingMode(uint(propertyID), req.BookingMode); err != nil {
+		c.Error(apierror.Internal("Failed to update booking mode"))
+		return
+	}
+
+	if err := h.redis.InvalidatePropertyCache(c.Request.Context(), uint(propertyID)); err != nil {
+		log.Printf("Failed to invalidate property cache: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id":       propertyID,
+		"booking_mode":      req.BookingMode,
+		"request_ttl_hours": int(h.bookings.RequestTTL.Hours()),
+	})
+}
+
+// ApproveBooking confirms a pending booking request before its approval deadline. The
+// nights were held since the request, so approval cannot fail for lack of availability.
+func (h *Handler) ApproveBooking(c *gin.Context) {
+	booking, ok := h.loadBookingRequest(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	if booking.ApprovalDeadline != nil && !now.Before(*booking.ApprovalDeadline) {
+		c.Error(apierror.InvalidState("The booking request expired"))
+		return
+	}
+
+	if err := h.bookingRepo.ApproveBooking(booking, now); err != nil {
+		if errors.Is(err, database.ErrStatusChanged) {
+			c.Error(apierror.InvalidState("The booking request was answered or expired meanwhile"))
+			return
+		}
+		log.Printf("Failed to approve booking %d: %v", booking.ID, err)
+		c.Error(apierror.Internal("Failed to approve booking"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": booking})
+}
+
+// DeclineBooking turns down a pending booking request, reopening its nights and refunding
+// the points and vouchers spent on it
+func (h *Handler) DeclineBooking(c *gin.Context) {
+	var req DeclineBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	booking, ok := h.loadBookingRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.bookingRepo.ReleaseBookingRequest(booking, models.BookingStatusDeclined, req.Reason, time.Now()); err != nil {
+		if errors.Is(err, database.ErrStatusChanged) {
+			c.Error(apierror.InvalidState("The booking request was answered or expired meanwhile"))
+			return
+		}
+		log.Printf("Failed to decline booking %d: %v", booking.ID, err)
+		c.Error(apierror.Internal("Failed to decline booking"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": booking})
+}
+
+// loadBookingRequest loads the pending booking of the :id path parameter and checks that
+// the request may manage its property's calendar, writing an error response and returning
+// false otherwise
+func (h *Handler) loadBookingRequest(c *gin.Context) (*models.Booking, bool) {
+	bookingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("booking"))
+		return nil, false
+	}
+
+	booking, err := h.bookingRepo.GetBookingByID(uint(bookingID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Booking"))
+			return nil, false
+		}
+		c.Error(apierror.Internal("Failed to retrieve booking"))
+		return nil, false
+	}
+	if apiErr := h.authorizeProperty(c, booking.PropertyID, models.PermissionManageCalendar); apiErr != nil {
+		c.Error(apiErr)
+		return nil, false
+	}
+	if booking.Status != models.BookingStatusPending {
+		c.Error(apierror.InvalidState("Only pending booking requests can be approved or declined"))
+		return nil, false
+	}
+	return booking, true
+}
+
+// approvalDeadline returns when a booking request made at now expires: after the request
+// TTL, and at the latest when the arrival day ends at the property
+func (h *Handler) approvalDeadline(property *models.Property, checkin, now time.Time) time.Time {
+	deadline := now.Add(h.bookings.RequestTTL)
+	if arrivalEnd := property.StartOfDay(checkin.AddDate(0, 0, 1)); arrivalEnd.Before(deadline) {
+		return arrivalEnd
+	}
+	return deadline
+}
+
+// bookingMode returns a property's booking mode, instant when unset
+func bookingMode(property *models.Property) string {
+	if property.BookingMode == "" {
+		return models.BookingModeInstant
+	}
+	return property.BookingMode
+}
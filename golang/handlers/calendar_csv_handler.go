@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxCalendarCSVBytes = 2 << 20
+	maxCalendarCSVRows  = 2000
+)
+
+// calendarCSVColumns are the columns of a calendar CSV; only date is required on import
+var calendarCSVColumns = []string{"date", "available", "min_stay", "max_guests", "base_price"}
+
+// CalendarCSVRejection reports a CSV row that was not applied
+type CalendarCSVRejection struct {
+	Line  int    `json:"line"`
+	Date  string `json:"date,omitempty"`
+	Error string `json:"error"`
+}
+
+// ExportPropertyCalendarCSV downloads a property's availability and base prices for a
+// date range as CSV, one row per night; nights without data have empty cells
+func (h *Handler) ExportPropertyCalendarCSV(c *gin.Context) {
+	property, ok := h.loadCalendarProperty(c)
+	if !ok {
+		return
+	}
+	propertyID := property.ID
+
+	startDate, endDate, ok := parseAnalyticsRange(c)
+	if !ok {
+		return
+	}
+	start, end := startDate.Format("2006-01-02"), endDate.Format("2006-01-02")
+
+	availabilities, err := h.availabilityRepo.GetAvailabilityForDateRange(propertyID, start, end)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve availability"))
+		return
+	}
+	pricing, err := h.pricingRepo.GetPricingForDateRange(propertyID, start, end)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve pricing"))
+		return
+	}
+
+	rows := make(map[string][]string)
+	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
+		key := d.Format("2006-01-02")
+		rows[key] = []string{key, "", "", "", ""}
+	}
+	for _, a := range availabilities {
+		if row, ok := rows[a.Date.Format("2006-01-02")]; ok {
+			row[1] = strconv.FormatBool(a.Available)
+			row[2] = strconv.Itoa(a.MinStay)
+			row[3] = strconv.Itoa(a.MaxGuests)
+		}
+	}
+	for _, p := range pricing {
+		if row, ok := rows[p.Date.Format("2006-01-02")]; ok {
+			row[4] = strconv.FormatFloat(p.BasePrice, 'f', 2, 64)
+		}
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=property-%d-calendar-%s-%s.csv", propertyID, start, end))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write(calendarCSVColumns)
+	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
+		w.Write(rows[d.Format("2006-01-02")])
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Printf("Failed to write calendar CSV for property %d: %v", propertyID, err)
+	}
+}
+
+// ImportPropertyCalendarCSV applies a calendar CSV, sent as the "file" form field or as
+// the request body, as a bulk upsert. Rows are validated one by one: valid rows are
+// applied together and invalid ones are reported back. Empty cells leave values unchanged.
+func (h *Handler) ImportPropertyCalendarCSV(c *gin.Context) {
+	property, ok := h.loadCalendarProperty(c)
+	if !ok {
+		return
+	}
+	propertyID := property.ID
+
+	body := io.Reader(http.MaxBytesReader(c.Writer, c.Request.Body, maxCalendarCSVBytes))
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.Error(apierror.InvalidRequest("Missing CSV file in the file form field"))
+			return
+		}
+		if file.Size > maxCalendarCSVBytes {
+			c.Error(apierror.InvalidRequest("CSV file must not exceed 2 MB"))
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			c.Error(apierror.InvalidRequest("Failed to read CSV file"))
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		c.Error(apierror.InvalidRequest("CSV must start with a header row"))
+		return
+	}
+	columns, apiErr := parseCalendarCSVHeader(header)
+	if apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+
+	var changes []database.ARIChange
+	var rejected []CalendarCSVRejection
+	today := property.Today()
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rejected = append(rejected, CalendarCSVRejection{Line: line, Error: parseErr.Err.Error()})
+				continue
+			}
+			c.Error(apierror.InvalidRequest("Failed to read CSV: " + err.Error()))
+			return
+		}
+		if line-1 > maxCalendarCSVRows {
+			c.Error(apierror.Validation(fmt.Sprintf("CSV must not exceed %d rows", maxCalendarCSVRows)))
+			return
+		}
+
+		change, err := parseCalendarCSVRow(columns, record, today)
+		if err != nil {
+			rejection := CalendarCSVRejection{Line: line, Error: err.Error()}
+			if i, ok := columns["date"]; ok && i < len(record) {
+				rejection.Date = record[i]
+			}
+			rejected = append(rejected, rejection)
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	if len(changes) == 0 {
+		if len(rejected) == 0 {
+			c.Error(apierror.Validation("CSV contains no rows"))
+			return
+		}
+		c.Error(apierror.Validation("No CSV row could be applied").WithDetails(rejected))
+		return
+	}
+
+	written, err := h.ariRepo.ApplyChanges(propertyID, changes, models.EventSourceCalendarImport)
+	if err != nil {
+		log.Printf("Failed to import calendar CSV for property %d: %v", propertyID, err)
+		c.Error(apierror.Internal("Failed to apply CSV rows"))
+		return
+	}
+
+	if rejected == nil {
+		rejected = []CalendarCSVRejection{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"property_id":   propertyID,
+		"rows_applied":  len(changes),
+		"rows_rejected": len(rejected),
+		"rows_updated":  written,
+		"rejected":      rejected,
+	})
+}
+
+// loadCalendarProperty loads the property referenced by the :id path parameter,
+// writing an error response and returning false if it cannot be loaded
+func (h *Handler) loadCalendarProperty(c *gin.Context) (*models.Property, bool) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return nil, false
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return nil, false
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return nil, false
+	}
+	return property, true
+}
+
+// parseCalendarCSVHeader maps column names to their positions
+func parseCalendarCSVHeader(header []string) (map[string]int, *apierror.APIError) {
+	known := make(map[string]bool, len(calendarCSVColumns))
+	for _, name := range calendarCSVColumns {
+		known[name] = true
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !known[name] {
+			return nil, apierror.Validation(fmt.Sprintf("Unknown CSV column %q, expected %s", name, strings.Join(calendarCSVColumns, ", ")))
+		}
+		if _, dup := columns[name]; dup {
+			return nil, apierror.Validation(fmt.Sprintf("Duplicate CSV column %q", name))
+		}
+		columns[name] = i
+	}
+
+	if _, ok := columns["date"]; !ok {
+		return nil, apierror.Validation("CSV header must include a date column")
+	}
+	if len(columns) < 2 {
+		return nil, apierror.Validation("CSV header must include at least one of available, min_stay, max_guests or base_price")
+	}
+	return columns, nil
+}
+
+// parseCalendarCSVRow converts a CSV row to a single-night change
+func parseCalendarCSVRow(columns map[string]int, record []string, today time.Time) (database.ARIChange, error) {
+	cell := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var change database.ARIChange
+	date, err := time.Parse("2006-01-02", cell("date"))
+	if err != nil {
+		return change, errors.New("date must be in YYYY-MM-DD format")
+	}
+	if date.Before(today) {
+		return change, errors.New("date must not be in the past")
+	}
+	change.StartDate, change.EndDate = date, date
+
+	if value := cell("available"); value != "" {
+		available, err := parseCalendarBool(value)
+		if err != nil {
+			return change, err
+		}
+		change.Available = &available
+	}
+	if value := cell("min_stay"); value != "" {
+		minStay, err := strconv.Atoi(value)
+		if err != nil || minStay < 1 || minStay > 365 {
+			return change, errors.New("min_stay must be a whole number between 1 and 365")
+		}
+		change.MinStay = &minStay
+	}
+	if value := cell("max_guests"); value != "" {
+		maxGuests, err := strconv.Atoi(value)
+		if err != nil || maxGuests < 1 || maxGuests > 50 {
+			return change, errors.New("max_guests must be a whole number between 1 and 50")
+		}
+		change.MaxGuests = &maxGuests
+	}
+	if value := cell("base_price"); value != "" {
+		basePrice, err := strconv.ParseFloat(value, 64)
+		if err != nil || basePrice <= 0 {
+			return change, errors.New("base_price must be a positive number")
+		}
+		change.BasePrice = &basePrice
+	}
+
+	if change.Available == nil && change.MinStay == nil && change.MaxGuests == nil && change.BasePrice == nil {
+		return change, errors.New("row sets no value")
+	}
+	return change, nil
+}
+
+// parseCalendarBool parses the spreadsheet-friendly spellings of a boolean
+func parseCalendarBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "y", "1", "open":
+		return true, nil
+	case "false", "no", "n", "0", "closed":
+		return false, nil
+	}
+	return false, errors.New("available must be true or false")
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/ical"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// bookingCalendarPath is where booking calendar feeds are served, followed by
+// <token>.ics
+const bookingCalendarPath = "/api/v1/calendars/bookings/"
+
+// Booking calendar feeds list the stays from bookingCalendarPast ago until
+// bookingCalendarAhead from now
+const (
+	bookingCalendarPast  = 30 * 24 * time.Hour
+	bookingCalendarAhead = 2 * 365 * 24 * time.Hour
+)
+
+// CalendarFeedRequest represents the payload creating a booking calendar feed
+type CalendarFeedRequest struct {
+	Name string `json:"name" binding:"max=100"` // e.g. the calendar or person it is for
+}
+
+// CreateCalendarFeed creates a signed iCalendar feed of a property's bookings with guest
+// details, for hosts to subscribe to in Google Calendar or other calendar applications.
+// Anyone holding the URL can read the feed until it is revoked.
+func (h *Handler) CreateCalendarFeed(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var req CalendarFeedRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+	if !h.bookings.calendarFeedsEnabled() {
+		c.Error(apierror.Unprocessable("Booking calendar feeds are not enabled"))
+		return
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	feed := models.CalendarFeed{PropertyID: property.ID, Name: strings.TrimSpace(req.Name)}
+	if err := h.calendarFeedRepo.CreateFeed(&feed); err != nil {
+		c.Error(apierror.Internal("Failed to create calendar feed"))
+		return
+	}
+
+	feed.URL = h.calendarFeedURL(feed.ID)
+	c.JSON(http.StatusCreated, gin.H{"data": feed})
+}
+
+// ListCalendarFeeds retrieves a property's booking calendar feeds, newest first, with
+// the URLs of those not revoked
+func (h *Handler) ListCalendarFeeds(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	if _, err := h.propertyRepo.GetPropertyByID(uint(propertyID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	feeds, err := h.calendarFeedRepo.ListFeeds(uint(propertyID))
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve calendar feeds"))
+		return
+	}
+	for i := range feeds {
+		if feeds[i].RevokedAt == nil && h.bookings.calendarFeedsEnabled() {
+			feeds[i].URL = h.calendarFeedURL(feeds[i].ID)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": feeds})
+}
+
+// RevokeCalendarFeed stops a booking calendar feed's URL from working. Subscribers keep
+// the events they already fetched until their calendar application drops them.
+func (h *Handler) RevokeCalendarFeed(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+	feedID, err := strconv.ParseUint(c.Param("feed_id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("calendar feed"))
+		return
+	}
+
+	feed, err := h.calendarFeedRepo.GetFeedByID(uint(feedID))
+	if err != nil || feed.PropertyID != uint(propertyID) {
+		if err == nil || err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Calendar feed"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve calendar feed"))
+		return
+	}
+	if feed.RevokedAt != nil {
+		c.Error(apierror.InvalidState("The calendar feed is already revoked"))
+		return
+	}
+
+	if err := h.calendarFeedRepo.RevokeFeed(feed, time.Now()); err != nil {
+		c.Error(apierror.Internal("Failed to revoke calendar feed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": feed})
+}
+
+// GetBookingCalendar serves the iCalendar feed of a signed booking calendar URL: the
+// property's pending and booked stays with their guest, channel and party. Unknown,
+// tampered and revoked URLs are all not found.
+func (h *Handler) GetBookingCalendar(c *gin.Context) {
+	token, ok := strings.CutSuffix(c.Param("file"), ".ics")
+	feedID, valid := h.verifyCalendarFeedToken(token)
+	if !ok || !valid {
+		c.Error(apierror.NotFound("Calendar feed"))
+		return
+	}
+
+	feed, err := h.calendarFeedRepo.GetFeedByID(feedID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Calendar feed"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve calendar feed"))
+		return
+	}
+	if feed.RevokedAt != nil {
+		c.Error(apierror.NotFound("Calendar feed"))
+		return
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(feed.PropertyID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Calendar feed"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	now := time.Now()
+	bookings, err := h.bookingRepo.GetCalendarBookings(property.ID, now.Add(-bookingCalendarPast), now.Add(bookingCalendarAhead))
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve bookings"))
+		return
+	}
+
+	calendar := ical.Calendar{
+		ProductID: "-//Channel Manager//Bookings//EN",
+		Name:      property.Name + " bookings",
+		Events:    make([]ical.Event, 0, len(bookings)),
+	}
+	for _, booking := range bookings {
+		calendar.Events = append(calendar.Events, bookingEvent(booking))
+	}
+	var body bytes.Buffer
+	if err := ical.Write(&body, calendar); err != nil {
+		c.Error(apierror.Internal("Failed to write calendar"))
+		return
+	}
+
+	if err := h.calendarFeedRepo.TouchFeed(feed.ID, now); err != nil {
+		log.Printf("Failed to record fetch of calendar feed %d: %v", feed.ID, err)
+	}
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", body.Bytes())
+}
+
+// bookingEvent describes a booking as an all-day calendar event from arrival to
+// departure; pending requests are tentative
+func bookingEvent(booking models.Booking) ical.Event {
+	channel := booking.ChannelID
+	if channel == "" {
+		channel = "direct"
+	}
+	party := fmt.Sprintf("%d guests", booking.NumberOfGuests)
+	if booking.NumberOfChildren > 0 || booking.NumberOfInfants > 0 {
+		party += fmt.Sprintf(" (%d children, %d infants)", booking.NumberOfChildren, booking.NumberOfInfants)
+	}
+
+	summary := fmt.Sprintf("%s, %s", booking.GuestName, party)
+	status := "CONFIRMED"
+	switch booking.Status {
+	case models.BookingStatusPending:
+		summary = "Request: " + summary
+		status = "TENTATIVE"
+	case models.BookingStatusNoShow:
+		summary = "No-show: " + summary
+	}
+
+	description := []string{
+		"Guest: " + booking.GuestName,
+		"Party: " + party,
+		"Channel: " + channel,
+		fmt.Sprintf("Booking: %d (%s)", booking.ID, booking.Status),
+	}
+	if booking.ExternalReference != "" {
+		description = append(description, "Reference: "+booking.ExternalReference)
+	}
+	if booking.ApprovalDeadline != nil && booking.Status == models.BookingStatusPending {
+		description = append(description, "Answer by: "+booking.ApprovalDeadline.UTC().Format(time.RFC3339))
+	}
+
+	return ical.Event{
+		UID:         fmt.Sprintf("booking-%d@channelmanager", booking.ID),
+		Summary:     summary,
+		Description: strings.Join(description, "\n"),
+		Status:      status,
+		Start:       booking.CheckinDate,
+		End:         booking.CheckoutDate,
+		AllDay:      true,
+		Stamp:       booking.UpdatedAt,
+	}
+}
+
+// calendarFeedURL returns the signed URL of a booking calendar feed on the configured
+// public URL, never on the host or scheme a client claims
+func (h *Handler) calendarFeedURL(feedID uint) string {
+	base := strings.TrimRight(h.bookings.CalendarFeedBaseURL, "/")
+	return fmt.Sprintf("%s%s%s.ics", base, bookingCalendarPath, h.calendarFeedToken(feedID))
+}
+
+// calendarFeedToken returns the token of a feed's URL: its ID and a signature of it
+func (h *Handler) calendarFeedToken(feedID uint) string {
+	return fmt.Sprintf("%d-%s", feedID, h.calendarFeedSignature(feedID))
+}
+
+// verifyCalendarFeedToken returns the feed a token was signed for, and false for a
+// malformed or tampered token or when feeds are not enabled
+func (h *Handler) verifyCalendarFeedToken(token string) (uint, bool) {
+	if h.bookings.CalendarFeedKey == "" {
+		return 0, false
+	}
+	id, signature, found := strings.Cut(token, "-")
+	if !found {
+		return 0, false
+	}
+	feedID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	expected := h.calendarFeedSignature(uint(feedID))
+	return uint(feedID), hmac.Equal([]byte(signature), []byte(expected))
+}
+
+// calendarFeedSignature signs a feed ID with the calendar feed key
+func (h *Handler) calendarFeedSignature(feedID uint) string {
+	mac := hmac.New(sha256.New, []byte(h.bookings.CalendarFeedKey))
+	fmt.Fprintf(mac, "calendar-feed:%d", feedID)
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
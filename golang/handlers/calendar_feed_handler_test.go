@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCalendarFeedURL(t *testing.T) {
+	h := &Handler{bookings: BookingConfig{CalendarFeedKey: "secret", CalendarFeedBaseURL: "https://api.example.com/"}}
+
+	url := h.calendarFeedURL(7)
+	prefix := "https://api.example.com/api/v1/calendars/bookings/7-"
+	if !strings.HasPrefix(url, prefix) || !strings.HasSuffix(url, ".ics") {
+		t.Fatalf("calendarFeedURL() = %q, want %s<signature>.ics", url, prefix)
+	}
+	token := strings.TrimSuffix(strings.TrimPrefix(url, "https://api.example.com"+bookingCalendarPath), ".ics")
+	if feedID, ok := h.verifyCalendarFeedToken(token); !ok || feedID != 7 {
+		t.Errorf("verifyCalendarFeedToken(%q) = %d, %v, want 7, true", token, feedID, ok)
+	}
+}
+
+func TestBookingConfigValidate(t *testing.T) {
+	for baseURL, wantErr := range map[string]bool{
+		"":                        false,
+		"https://api.example.com": false,
+		"http://localhost:8080/":  false,
+		"api.example.com":         true,
+		"ftp://api.example.com":   true,
+		"https://":                true,
+	} {
+		if err := (BookingConfig{CalendarFeedBaseURL: baseURL}).Validate(); (err != nil) != wantErr {
+			t.Errorf("Validate() with %q error = %v, wantErr %v", baseURL, err, wantErr)
+		}
+	}
+}
@@ -0,0 +1,431 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/channels"
+	"channelmanager/database"
+	"channelmanager/fx"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ChannelRequest represents the payload for creating a channel
+type ChannelRequest struct {
+	ID       string `json:"id" binding:"required,max=50"`
+	Name     string `json:"name" binding:"required,max=100"`
+	Currency string `json:"currency" binding:"omitempty,len=3,uppercase"`
+	Active   *bool  `json:"active"`
+	DryRun   bool   `json:"dry_run"`
+}
+
+// ChannelDryRunRequest represents the payload for switching a channel's dry-run mode
+type ChannelDryRunRequest struct {
+	DryRun *bool `json:"dry_run" binding:"required"`
+}
+
+// ChannelPricingRulesRequest represents the payload for updating a channel's pricing rules
+type ChannelPricingRulesRequest struct {
+	MarkupPercent     float64 `json:"markup_percent" binding:"gt=-100"`
+	FixedFee          float64 `json:"fixed_fee" binding:"min=0"`
+	CommissionPercent float64 `json:"commission_percent" binding:"min=0,lt=100"`
+	GrossUpCommission bool    `json:"gross_up_commission"`
+}
+
+// ChannelMappingRequest represents the payload for mapping a property to a channel listing
+type ChannelMappingRequest struct {
+	PropertyID         uint   `json:"property_id" binding:"required"`
+	ExternalPropertyID string `json:"external_property_id" binding:"required,max=100"`
+	ExternalRoomID     string `json:"external_room_id" binding:"max=100"`
+	ExternalRatePlanID string `json:"external_rate_plan_id" binding:"max=100"`
+	Active             *bool  `json:"active"`
+}
+
+// ResyncRequest represents the optional payload restricting a resync to one property
+type ResyncRequest struct {
+	PropertyID uint `json:"property_id"`
+}
+
+// ListChannels retrieves a page of the channels with their pricing rules
+func (h *Handler) ListChannels(c *gin.Context) {
+	page := parsePage(c, 50, 200)
+	channels, err := h.channelRepo.GetAllChannels()
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve channels"))
+		return
+	}
+
+	c.JSON(http.StatusOK, paginated(c, pageOf(channels, page), int64(len(channels)), page))
+}
+
+// GetChannel retrieves a single channel
+func (h *Handler) GetChannel(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": channel})
+}
+
+// CreateChannel registers a new channel
+func (h *Handler) CreateChannel(c *gin.Context) {
+	var req ChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	channel := models.Channel{
+		ID:       req.ID,
+		Name:     req.Name,
+		Currency: req.Currency,
+		Active:   true,
+		DryRun:   req.DryRun,
+	}
+	if channel.Currency == "" {
+		channel.Currency = "USD"
+	}
+	if req.Active != nil {
+		channel.Active = *req.Active
+	}
+
+	if _, err := h.channelRepo.GetChannelByID(req.ID); err == nil {
+		c.Error(apierror.AlreadyExists("Channel already exists"))
+		return
+	}
+
+	if err := h.channelRepo.CreateChannel(&channel); err != nil {
+		c.Error(apierror.Internal("Failed to create channel"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": channel})
+}
+
+// UpdateChannelPricingRules updates the markup, fixed fee and commission of a channel
+func (h *Handler) UpdateChannelPricingRules(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	var req ChannelPricingRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	channel.MarkupPercent = req.MarkupPercent
+	channel.FixedFee = req.FixedFee
+	channel.CommissionPercent = req.CommissionPercent
+	channel.GrossUpCommission = req.GrossUpCommission
+
+	if err := h.channelRepo.UpdateChannel(channel); err != nil {
+		c.Error(apierror.Internal("Failed to update pricing rules"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": channel})
+}
+
+// UpdateChannelDryRun switches a channel's dry-run mode, in which pushes are rendered and
+// stored for review instead of being sent to the OTA
+func (h *Handler) UpdateChannelDryRun(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	var req ChannelDryRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	channel.DryRun = *req.DryRun
+	if err := h.channelRepo.UpdateChannel(channel); err != nil {
+		c.Error(apierror.Internal("Failed to update dry-run mode"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": channel})
+}
+
+// ListDryRunPayloads lists the payloads rendered for a channel in dry-run mode, newest first
+func (h *Handler) ListDryRunPayloads(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	page := parsePage(c, 20, 100)
+
+	filter := database.DryRunFilter{
+		Operation: c.Query("operation"),
+		Limit:     page.Limit,
+		Offset:    page.Offset(),
+	}
+	switch filter.Operation {
+	case "", models.SyncOperationARI, models.SyncOperationContent:
+	default:
+		c.Error(apierror.Validation("operation must be ari or content"))
+		return
+	}
+	if param := c.Query("property_id"); param != "" {
+		id, err := strconv.ParseUint(param, 10, 32)
+		if err != nil {
+			c.Error(apierror.InvalidID("property"))
+			return
+		}
+		filter.PropertyID = uint(id)
+	}
+
+	payloads, total, err := h.dryRunRepo.ListPayloads(channel.ID, filter)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve dry-run payloads"))
+		return
+	}
+
+	response := paginated(c, payloads, total, page)
+	response["dry_run"] = channel.DryRun
+	c.JSON(http.StatusOK, response)
+}
+
+// GetChannelRatePreview shows the rates a channel would receive for a property's pricing
+func (h *Handler) GetChannelRatePreview(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	propertyID, err := strconv.ParseUint(c.Query("property_id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	startDate, endDate, ok := parseAnalyticsRange(c)
+	if !ok {
+		return
+	}
+
+	updates, err := h.ariPush.BuildUpdates(*channel, uint(propertyID), startDate, endDate)
+	if err != nil {
+		if errors.Is(err, fx.ErrNoRate) {
+			c.Error(apierror.Unprocessable(fmt.Sprintf("No exchange rate is known for %s", channel.Currency)))
+			return
+		}
+		c.Error(apierror.Internal("Failed to build channel rates"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"channel_id":  channel.ID,
+		"property_id": propertyID,
+		"data":        updates,
+	})
+}
+
+// ListChannelMappings retrieves a page of the property mappings of a channel
+func (h *Handler) ListChannelMappings(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	page := parsePage(c, 50, 200)
+	mappings, err := h.channelRepo.GetMappingsForChannel(channel.ID)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve channel mappings"))
+		return
+	}
+
+	c.JSON(http.StatusOK, paginated(c, pageOf(mappings, page), int64(len(mappings)), page))
+}
+
+// SaveChannelMapping maps a property to its listing on a channel and pushes its upcoming
+// ARI. Owned properties are mapped with their owner's or an admin API key.
+func (h *Handler) SaveChannelMapping(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	var req ChannelMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(req.PropertyID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+	if apiErr := h.authorizeProperty(c, property.ID, models.PermissionOwner); apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+
+	mappings, err := h.channelRepo.GetMappingsForChannel(channel.ID)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve channel mappings"))
+		return
+	}
+
+	mapping := models.ChannelMapping{ChannelID: channel.ID, PropertyID: req.PropertyID, Active: true}
+	for _, existing := range mappings {
+		if existing.PropertyID == req.PropertyID {
+			mapping = existing
+		}
+	}
+	mapping.ExternalPropertyID = req.ExternalPropertyID
+	mapping.ExternalRoomID = req.ExternalRoomID
+	mapping.ExternalRatePlanID = req.ExternalRatePlanID
+	if req.Active != nil {
+		mapping.Active = *req.Active
+	}
+
+	if err := h.channelRepo.SaveMapping(&mapping); err != nil {
+		c.Error(apierror.Internal("Failed to save channel mapping"))
+		return
+	}
+
+	// Send the next 90 days to the newly mapped channel
+	if mapping.Active {
+		start := property.Today()
+		if err := h.ariPush.PushProperty(ctx, mapping.PropertyID, start, start.AddDate(0, 0, 90)); err != nil {
+			c.JSON(http.StatusAccepted, gin.H{"data": mapping, "warning": "Mapping saved but initial ARI push failed"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": mapping})
+}
+
+// loadChannel loads the channel referenced by the :id path parameter,
+// writing an error response and returning false if it cannot be loaded
+func (h *Handler) loadChannel(c *gin.Context) (*models.Channel, bool) {
+	channel, err := h.channelRepo.GetChannelByID(c.Param("id"))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Channel"))
+			return nil, false
+		}
+		c.Error(apierror.Internal("Failed to retrieve channel"))
+		return nil, false
+	}
+	return channel, true
+}
+
+// maxSyncErrors is the number of recent failures returned by the sync status
+const maxSyncErrors = 20
+
+// GetChannelSyncStatus reports a channel's last successful and failed exchanges per
+// operation, the changes not yet pushed to it, and its latest errors
+func (h *Handler) GetChannelSyncStatus(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	operations, err := h.syncLogRepo.GetOperationStatus(channel.ID, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve sync status"))
+		return
+	}
+
+	pending, err := h.syncLogRepo.GetPendingDeltas(channel.ID)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve pending changes"))
+		return
+	}
+	totalPending := 0
+	for _, p := range pending {
+		totalPending += p.Availability + p.Pricing
+	}
+
+	failures, err := h.syncLogRepo.GetRecentFailures(channel.ID, maxSyncErrors)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve sync errors"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"channel_id":           channel.ID,
+			"operations":           operations,
+			"pending_deltas":       totalPending,
+			"pending_per_property": pending,
+			"recent_errors":        failures,
+		},
+	})
+}
+
+// ResyncChannel enqueues a full ARI and content push of the channel's mapped properties,
+// or of one property, and returns the job to poll for progress
+func (h *Handler) ResyncChannel(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+	if !channel.Active {
+		c.Error(apierror.InvalidState("Channel is not active"))
+		return
+	}
+
+	var req ResyncRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(apierror.FromBinding(err))
+			return
+		}
+	}
+
+	job, err := h.resync.Enqueue(*channel, req.PropertyID)
+	if err != nil {
+		if errors.Is(err, channels.ErrNoMappings) {
+			c.Error(apierror.Validation("Channel has no active mapping to resync"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to enqueue resync"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": job})
+}
+
+// GetResyncJob reports the progress of a channel resync
+func (h *Handler) GetResyncJob(c *gin.Context) {
+	jobID, err := strconv.ParseUint(c.Param("job_id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("resync job"))
+		return
+	}
+
+	job, err := h.resyncRepo.GetJob(c.Param("id"), uint(jobID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Resync job"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve resync job"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"channelmanager/apierror"
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ResolveConflictRequest represents the action taken on an inventory conflict
+type ResolveConflictRequest struct {
+	Action           string `json:"action" binding:"required,oneof=relocate cancel force_sync"`
+	BookingID        uint   `json:"booking_id"`                                               // booking to relocate or cancel; required for overbookings
+	TargetPropertyID uint   `json:"target_property_id" binding:"required_if=Action relocate"` // property a relocated booking moves to
+}
+
+// ListConflicts lists inventory conflicts found by the reconciler, filtered by property and type
+func (h *Handler) ListConflicts(c *gin.Context) {
+	page := parsePage(c, 50, 200)
+
+	conflictType := c.Query("type")
+	switch conflictType {
+	case "", models.ConflictOverbooking, models.ConflictOpenInventory:
+	default:
+		c.Error(apierror.Validation("type must be overbooking or open_inventory"))
+		return
+	}
+
+	filter := database.ConflictFilter{
+		Type:            conflictType,
+		IncludeResolved: c.Query("include_resolved") == "true",
+		Limit:           page.Limit,
+		Offset:          page.Offset(),
+	}
+	if param := c.Query("property_id"); param != "" {
+		id, err := strconv.ParseUint(param, 10, 32)
+		if err != nil {
+			c.Error(apierror.InvalidID("property"))
+			return
+		}
+		filter.PropertyID = uint(id)
+	}
+
+	conflicts, total, err := h.conflictRepo.ListConflicts(filter)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve conflicts"))
+		return
+	}
+
+	c.JSON(http.StatusOK, paginated(c, conflicts, total, page))
+}
+
+// ResolveConflict resolves an open conflict by relocating or cancelling one of its
+// bookings, or by closing the booked nights and pushing them to channels (force_sync)
+func (h *Handler) ResolveConflict(c *gin.Context) {
+	conflictID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("conflict"))
+		return
+	}
+
+	var req ResolveConflictRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	conflict, err := h.conflictRepo.GetConflictByID(uint(conflictID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Conflict"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve conflict"))
+		return
+	}
+	if conflict.Status != models.ConflictStatusOpen {
+		c.Error(apierror.New(http.StatusConflict, apierror.CodeInvalidState, "Conflict is already resolved"))
+		return
+	}
+
+	// The booking acted on, and for overbookings the one that keeps the nights
+	target, remaining := conflict.BookingID, uint(0)
+	if conflict.Type == models.ConflictOverbooking {
+		switch {
+		case req.Action == models.ConflictResolutionForceSync:
+			c.Error(apierror.InvalidField("action", "oneof", "an overbooking must be resolved by relocating or cancelling a booking"))
+			return
+		case req.BookingID == conflict.BookingID:
+			target, remaining = conflict.BookingID, conflict.OtherBookingID
+		case req.BookingID == conflict.OtherBookingID:
+			target, remaining = conflict.OtherBookingID, conflict.BookingID
+		default:
+			c.Error(apierror.InvalidField("booking_id", "oneof", "must be one of the conflicting bookings"))
+			return
+		}
+	} else if req.BookingID != 0 && req.BookingID != conflict.BookingID {
+		c.Error(apierror.InvalidField("booking_id", "oneof", "must be the conflicting booking"))
+		return
+	}
+
+	booking, err := h.bookingRepo.GetBookingByID(target)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Booking"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve booking"))
+		return
+	}
+
+	switch req.Action {
+	case models.ConflictResolutionCancel:
+		err = h.bookingRepo.CancelBookingWithInventory(booking)
+	case models.ConflictResolutionRelocate:
+		if req.TargetPropertyID == booking.PropertyID {
+			c.Error(apierror.InvalidField("target_property_id", "ne", "must differ from the booked property"))
+			return
+		}
+		property, lookupErr := h.propertyRepo.GetPropertyByID(req.TargetPropertyID)
+		if lookupErr != nil {
+			if lookupErr == gorm.ErrRecordNotFound {
+				c.Error(apierror.NotFound("Property"))
+				return
+			}
+			c.Error(apierror.Internal("Failed to retrieve property"))
+			return
+		}
+		if property.MaxGuests > 0 && booking.NumberOfGuests > property.MaxGuests {
+			c.Error(apierror.InvalidField("target_property_id", "max_guests", "the property cannot host the booking's guests"))
+			return
+		}
+		err = h.bookingRepo.RelocateBooking(booking, property.ID, property.TurnoverNights())
+	case models.ConflictResolutionForceSync:
+		_, err = h.bookingRepo.ClaimNights(booking)
+		if err == nil {
+			lastNight := booking.CheckoutDate.AddDate(0, 0, -1)
+			if pushErr := h.ariPush.PushProperty(c.Request.Context(), booking.PropertyID, booking.CheckinDate, lastNight); pushErr != nil {
+				c.Error(apierror.New(http.StatusBadGateway, apierror.CodeUpstreamError, "Nights were closed but the channel push failed: "+pushErr.Error()))
+				return
+			}
+		}
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrNotConfirmed):
+			c.Error(apierror.New(http.StatusConflict, apierror.CodeInvalidState, "Booking is no longer confirmed"))
+		case errors.Is(err, database.ErrNotAvailable):
+			c.Error(apierror.New(http.StatusConflict, apierror.CodeNotAvailable, "Target property is not available for the booking's dates"))
+		default:
+			log.Printf("Failed to resolve conflict %d: %v", conflict.ID, err)
+			c.Error(apierror.Internal("Failed to resolve conflict"))
+		}
+		return
+	}
+
+	// The booking that stays takes over the nights the other one released
+	if remaining != 0 {
+		if other, err := h.bookingRepo.GetBookingByID(remaining); err == nil {
+			if _, err := h.bookingRepo.ClaimNights(other); err != nil {
+				log.Printf("Failed to close nights of booking %d: %v", other.ID, err)
+			}
+		}
+	}
+
+	if err := h.conflictRepo.ResolveConflict(conflict, req.Action); err != nil {
+		c.Error(apierror.Internal("Failed to resolve conflict"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    conflict,
+		"booking": booking,
+	})
+}
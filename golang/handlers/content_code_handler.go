@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"channelmanager/apierror"
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ContentCodeRequest represents the payload for mapping an amenity or condition to a code
+type ContentCodeRequest struct {
+	RecordType string `json:"record_type" binding:"required,oneof=amenity condition"`
+	RecordID   uint   `json:"record_id" binding:"required"`
+	ChannelID  string `json:"channel_id" binding:"max=50"` // empty for the OTA standard code
+	CodeList   string `json:"code_list" binding:"max=20"`
+	Code       string `json:"code" binding:"required,max=50"`
+}
+
+// ListContentCodes lists amenity/condition code mappings, optionally filtered by
+// record_type and channel_id (an empty channel_id selects OTA standard codes)
+func (h *Handler) ListContentCodes(c *gin.Context) {
+	filter := database.ContentCodeFilter{RecordType: c.Query("record_type")}
+	if channelID, ok := c.GetQuery("channel_id"); ok {
+		filter.ChannelID = &channelID
+	}
+
+	mappings, err := h.contentCodeRepo.ListMappings(filter)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve content codes"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": mappings})
+}
+
+// SaveContentCode creates or replaces the code of an amenity or condition for a channel
+func (h *Handler) SaveContentCode(c *gin.Context) {
+	var req ContentCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	var err error
+	switch req.RecordType {
+	case models.ContentRecordAmenity:
+		_, err = h.amenityRepo.GetAmenityByID(req.RecordID)
+	case models.ContentRecordCondition:
+		_, err = h.conditionRepo.GetConditionByID(req.RecordID)
+	default:
+		c.Error(apierror.Validation("record_type must be amenity or condition"))
+		return
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Record"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve record"))
+		return
+	}
+
+	if req.ChannelID != "" {
+		if _, err := h.channelRepo.GetChannelByID(req.ChannelID); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.Error(apierror.NotFound("Channel"))
+				return
+			}
+			c.Error(apierror.Internal("Failed to retrieve channel"))
+			return
+		}
+	}
+
+	mapping := models.ContentCodeMapping{
+		RecordType: req.RecordType,
+		RecordID:   req.RecordID,
+		ChannelID:  req.ChannelID,
+		CodeList:   strings.ToUpper(strings.TrimSpace(req.CodeList)),
+		Code:       strings.TrimSpace(req.Code),
+	}
+	if err := h.contentCodeRepo.UpsertMapping(&mapping); err != nil {
+		c.Error(apierror.Internal("Failed to save content code"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": mapping})
+}
+
+// DeleteContentCode deletes a code mapping
+func (h *Handler) DeleteContentCode(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("content code"))
+		return
+	}
+
+	if _, err := h.contentCodeRepo.GetMappingByID(uint(id)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Content code"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve content code"))
+		return
+	}
+
+	if err := h.contentCodeRepo.DeleteMapping(uint(id)); err != nil {
+		c.Error(apierror.Internal("Failed to delete content code"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": true, "id": id})
+}
+
+// GetChannelContentPreview shows the content of a property as it would be pushed to a channel
+func (h *Handler) GetChannelContentPreview(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	propertyID, err := strconv.ParseUint(c.Query("property_id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.Validation("property_id is required"))
+		return
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	content, err := h.contentPush.BuildContent(channel.ID, *property)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to build channel content"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"channel_id": channel.ID,
+		"data":       content,
+	})
+}
+
+// PushPropertyContent pushes a property's content to every mapped channel
+func (h *Handler) PushPropertyContent(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	if err := h.contentPush.PushProperty(c.Request.Context(), uint(propertyID)); err != nil {
+		log.Printf("Content push for property %d failed: %v", propertyID, err)
+		c.Error(apierror.Upstream("Failed to push content to one or more channels"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": propertyID,
+		"pushed":      true,
+	})
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/channels"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RotateCredentialRequest represents the new API credentials of a channel
+type RotateCredentialRequest struct {
+	Type         string `json:"type" binding:"required,oneof=oauth2 basic api_key"`
+	Username     string `json:"username" binding:"max=200"`
+	Secret       string `json:"secret" binding:"max=4096"`
+	ClientID     string `json:"client_id" binding:"max=200"`
+	ClientSecret string `json:"client_secret" binding:"max=4096"`
+	TokenURL     string `json:"token_url" binding:"max=500"`
+	Scope        string `json:"scope" binding:"max=500"`
+	// RefreshToken is used instead of the client credentials grant when given, e.g. for
+	// channels authorized by a host through a consent screen
+	RefreshToken string `json:"refresh_token" binding:"max=4096"`
+}
+
+// requiredFields returns the names and values of the fields a credential type needs
+func (r RotateCredentialRequest) requiredFields() [][2]string {
+	switch r.Type {
+	case models.CredentialBasic:
+		return [][2]string{{"username", r.Username}, {"secret", r.Secret}}
+	case models.CredentialAPIKey:
+		return [][2]string{{"secret", r.Secret}}
+	default:
+		return [][2]string{{"client_id", r.ClientID}, {"client_secret", r.ClientSecret}, {"token_url", r.TokenURL}}
+	}
+}
+
+// GetChannelCredentials describes a channel's accepted credentials, the active one first,
+// without their secrets
+func (h *Handler) GetChannelCredentials(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	credentials, err := h.credentialRepo.GetCredentials(channel.ID, time.Now())
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve credentials"))
+		return
+	}
+	if credentials == nil {
+		credentials = []models.ChannelCredential{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": credentials})
+}
+
+// RotateChannelCredentials replaces a channel's API credentials. OAuth credentials are
+// checked by obtaining an access token before they replace the current ones, which stay
+// accepted for the rotation grace period so pushes in flight are not interrupted.
+func (h *Handler) RotateChannelCredentials(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	var req RotateCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+	for _, field := range req.requiredFields() {
+		if strings.TrimSpace(field[1]) == "" {
+			c.Error(apierror.InvalidField(field[0], "required", "is required for "+req.Type+" credentials"))
+			return
+		}
+	}
+	if req.Type == models.CredentialOAuth2 {
+		if u, err := url.Parse(req.TokenURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			c.Error(apierror.InvalidField("token_url", "url", "must be an http or https URL"))
+			return
+		}
+	}
+
+	credential := &models.ChannelCredential{
+		ChannelID:    channel.ID,
+		Type:         req.Type,
+		Username:     req.Username,
+		Secret:       req.Secret,
+		ClientID:     req.ClientID,
+		ClientSecret: req.ClientSecret,
+		TokenURL:     req.TokenURL,
+		Scope:        req.Scope,
+		RefreshToken: req.RefreshToken,
+	}
+	if err := h.credentials.Rotate(c.Request.Context(), credential); err != nil {
+		if errors.Is(err, channels.ErrTokenRejected) {
+			c.Error(apierror.Upstream(err.Error()))
+			return
+		}
+		log.Printf("Failed to rotate credentials of channel %s: %v", channel.ID, err)
+		c.Error(apierror.Internal("Failed to store credentials"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": credential})
+}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/ical"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// calendarClient fetches the public calendars destination events are imported from
+var calendarClient = &http.Client{Timeout: 30 * time.Second}
+
+// destinationMaterializeDays bounds how far ahead event changes are priced in
+const destinationMaterializeDays = 730
+
+// DestinationEventRequest represents the payload for creating or updating a destination event
+type DestinationEventRequest struct {
+	Name             string  `json:"name" binding:"required,max=255"`
+	Country          string  `json:"country" binding:"max=100"`
+	Type             string  `json:"type" binding:"required,oneof=holiday event"`
+	StartDate        string  `json:"start_date" binding:"required,datetime=2006-01-02"`
+	EndDate          string  `json:"end_date" binding:"required,datetime=2006-01-02"`
+	DemandMultiplier float64 `json:"demand_multiplier" binding:"omitempty,gt=0,lte=10"` // default 1
+}
+
+// ImportDestinationEventsRequest represents the payload for importing a city's events
+// from a public iCalendar feed
+type ImportDestinationEventsRequest struct {
+	URL              string  `json:"url" binding:"required,url,max=2048"`
+	Country          string  `json:"country" binding:"max=100"`
+	Type             string  `json:"type" binding:"omitempty,oneof=holiday event"`      // default holiday
+	DemandMultiplier float64 `json:"demand_multiplier" binding:"omitempty,gt=0,lte=10"` // of new events, default 1
+}
+
+// ListDestinationEvents retrieves the holidays and events of a city overlapping a date
+// range, by default the coming year, so hosts can plan around them
+func (h *Handler) ListDestinationEvents(c *gin.Context) {
+	city := strings.TrimSpace(c.Param("city"))
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	start, end := today, today.AddDate(0, 0, 364)
+	if c.Query("start_date") != "" || c.Query("end_date") != "" {
+		var ok bool
+		if start, end, ok = parseSeasonDates(c, c.Query("start_date"), c.Query("end_date")); !ok {
+			return
+		}
+	}
+
+	eventType := c.Query("type")
+	switch eventType {
+	case "", models.DestinationEventHoliday, models.DestinationEventEvent:
+	default:
+		c.Error(apierror.Validation("type must be holiday or event"))
+		return
+	}
+
+	events, err := h.destinationRepo.ListDestinationEvents(city, eventType, start, end)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve destination events"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"city":       city,
+		"start_date": start.Format("2006-01-02"),
+		"end_date":   end.Format("2006-01-02"),
+		"data":       events,
+	})
+}
+
+// CreateDestinationEvent adds a holiday or event to a city and prices its nights in for
+// the city's properties
+func (h *Handler) CreateDestinationEvent(c *gin.Context) {
+	var req DestinationEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	event := models.DestinationEvent{
+		City:   strings.TrimSpace(c.Param("city")),
+		Source: models.DestinationEventSourceManual,
+	}
+	if !applyDestinationEventRequest(c, &event, req) {
+		return
+	}
+
+	if err := h.destinationRepo.CreateDestinationEvent(&event); err != nil {
+		c.Error(apierror.Internal("Failed to create destination event"))
+		return
+	}
+
+	response := gin.H{"data": event}
+	h.materializeDestination(response, event.City, event.Country, event.StartDate, event.EndDate)
+	c.JSON(http.StatusCreated, response)
+}
+
+// UpdateDestinationEvent updates a destination event and reprices its old and new nights
+func (h *Handler) UpdateDestinationEvent(c *gin.Context) {
+	event, ok := h.loadDestinationEvent(c)
+	if !ok {
+		return
+	}
+
+	var req DestinationEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	previous := *event
+	if !applyDestinationEventRequest(c, event, req) {
+		return
+	}
+
+	if err := h.destinationRepo.UpdateDestinationEvent(event); err != nil {
+		c.Error(apierror.Internal("Failed to update destination event"))
+		return
+	}
+
+	// Nights that left the event, or properties it no longer applies to, lose its multiplier
+	start, end := event.StartDate, event.EndDate
+	if previous.StartDate.Before(start) {
+		start = previous.StartDate
+	}
+	if previous.EndDate.After(end) {
+		end = previous.EndDate
+	}
+	country := event.Country
+	if previous.Country != event.Country {
+		country = ""
+	}
+
+	response := gin.H{"data": event}
+	h.materializeDestination(response, event.City, country, start, end)
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteDestinationEvent deletes a destination event and reprices its nights without it
+func (h *Handler) DeleteDestinationEvent(c *gin.Context) {
+	event, ok := h.loadDestinationEvent(c)
+	if !ok {
+		return
+	}
+
+	if err := h.destinationRepo.DeleteDestinationEvent(event.ID); err != nil {
+		c.Error(apierror.Internal("Failed to delete destination event"))
+		return
+	}
+
+	response := gin.H{"deleted": true, "id": event.ID}
+	h.materializeDestination(response, event.City, event.Country, event.StartDate, event.EndDate)
+	c.JSON(http.StatusOK, response)
+}
+
+// ImportDestinationEvents imports a city's holidays or events from a public iCalendar
+// feed. Importing the same feed again refreshes its events and removes those it dropped.
+func (h *Handler) ImportDestinationEvents(c *gin.Context) {
+	var req ImportDestinationEventsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+	if req.Type == "" {
+		req.Type = models.DestinationEventHoliday
+	}
+	if req.DemandMultiplier == 0 {
+		req.DemandMultiplier = 1
+	}
+	city := strings.TrimSpace(c.Param("city"))
+
+	fetched, err := ical.Fetch(c.Request.Context(), calendarClient, req.URL)
+	if err != nil {
+		log.Printf("Failed to fetch calendar of %s: %v", city, err)
+		c.Error(apierror.Unprocessable(fmt.Sprintf("Failed to import calendar: %v", err)))
+		return
+	}
+
+	events := make([]models.DestinationEvent, 0, len(fetched))
+	seen := make(map[string]bool, len(fetched))
+	for _, e := range fetched {
+		if e.Cancelled() || e.UID == "" || seen[e.UID] {
+			continue
+		}
+		seen[e.UID] = true
+
+		first, last := e.Days()
+		events = append(events, models.DestinationEvent{
+			City:             city,
+			Country:          req.Country,
+			Name:             strings.TrimSpace(e.Summary),
+			Type:             req.Type,
+			StartDate:        first,
+			EndDate:          last,
+			DemandMultiplier: req.DemandMultiplier,
+			Source:           models.DestinationEventSourceICal,
+			SourceURL:        req.URL,
+			ExternalUID:      e.UID,
+		})
+	}
+
+	result, err := h.destinationRepo.ImportDestinationEvents(city, req.URL, events)
+	if err != nil {
+		log.Printf("Failed to import destination events of %s: %v", city, err)
+		c.Error(apierror.Internal("Failed to import destination events"))
+		return
+	}
+
+	response := gin.H{"city": city, "data": result}
+	if !result.Start.IsZero() {
+		h.materializeDestination(response, city, "", result.Start, result.End)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// materializeDestination reprices the upcoming nights in [start, end] of a city's rated
+// properties, reporting the rows updated or a warning in the response
+func (h *Handler) materializeDestination(response gin.H, city, country string, start, end time.Time) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if start.Before(today) {
+		start = today
+	}
+	if horizon := today.AddDate(0, 0, destinationMaterializeDays-1); end.After(horizon) {
+		end = horizon
+	}
+	if end.Before(start) {
+		response["pricing_rows_updated"] = 0
+		return
+	}
+
+	updated, err := h.materializer.MaterializeCity(city, country, start, end)
+	if err != nil {
+		log.Printf("Failed to materialize pricing of %s: %v", city, err)
+		response["warning"] = err.Error()
+	}
+	response["pricing_rows_updated"] = updated
+}
+
+// loadDestinationEvent loads the destination event referenced by the :id path parameter,
+// writing an error response and returning false if it cannot be loaded
+func (h *Handler) loadDestinationEvent(c *gin.Context) (*models.DestinationEvent, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("destination event"))
+		return nil, false
+	}
+
+	event, err := h.destinationRepo.GetDestinationEventByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Destination event"))
+			return nil, false
+		}
+		c.Error(apierror.Internal("Failed to retrieve destination event"))
+		return nil, false
+	}
+	return event, true
+}
+
+// applyDestinationEventRequest validates a destination event payload and copies it onto
+// the event, writing an error response and returning false if it is invalid
+func applyDestinationEventRequest(c *gin.Context, event *models.DestinationEvent, req DestinationEventRequest) bool {
+	start, end, ok := parseSeasonDates(c, req.StartDate, req.EndDate)
+	if !ok {
+		return false
+	}
+	if req.DemandMultiplier == 0 {
+		req.DemandMultiplier = 1
+	}
+	event.Name = req.Name
+	event.Country = req.Country
+	event.Type = req.Type
+	event.StartDate = start
+	event.EndDate = end
+	event.DemandMultiplier = req.DemandMultiplier
+	return true
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"channelmanager/apierror"
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// MergePropertiesRequest represents the payload for merging a duplicate listing into a
+// surviving property
+type MergePropertiesRequest struct {
+	SurvivorID  uint `json:"survivor_id" binding:"required"`
+	DuplicateID uint `json:"duplicate_id" binding:"required,nefield=SurvivorID"`
+}
+
+// ListDuplicateCandidates retrieves the review queue of likely duplicate listings,
+// pending pairs unless another status is asked for
+func (h *Handler) ListDuplicateCandidates(c *gin.Context) {
+	page := parsePage(c, 50, 200)
+
+	status := c.DefaultQuery("status", models.DuplicateStatusPending)
+	switch status {
+	case "all":
+		status = ""
+	case models.DuplicateStatusPending, models.DuplicateStatusMerged, models.DuplicateStatusDismissed:
+	default:
+		c.Error(apierror.Validation("status must be pending, merged, dismissed or all"))
+		return
+	}
+
+	candidates, total, err := h.duplicateRepo.ListDuplicateCandidates(status, page.Limit, page.Offset())
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve duplicate candidates"))
+		return
+	}
+
+	c.JSON(http.StatusOK, paginated(c, candidates, total, page))
+}
+
+// DismissDuplicateCandidate records that a queued pair are distinct properties, so the
+// pair is not flagged again
+func (h *Handler) DismissDuplicateCandidate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("duplicate candidate"))
+		return
+	}
+
+	candidate, err := h.duplicateRepo.GetDuplicateCandidateByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Duplicate candidate"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve duplicate candidate"))
+		return
+	}
+	if candidate.Status != models.DuplicateStatusPending {
+		c.Error(apierror.InvalidState("Duplicate candidate is already " + candidate.Status))
+		return
+	}
+
+	if err := h.duplicateRepo.ResolveDuplicateCandidate(candidate, models.DuplicateStatusDismissed); err != nil {
+		c.Error(apierror.Internal("Failed to dismiss duplicate candidate"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": candidate})
+}
+
+// MergeProperties consolidates a duplicate listing's channel mappings, bookings and
+// reviews onto a surviving property and removes the duplicate
+func (h *Handler) MergeProperties(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req MergePropertiesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	merge, err := h.duplicateRepo.MergeProperties(req.SurvivorID, req.DuplicateID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		if errors.Is(err, database.ErrMergeConflict) {
+			c.Error(apierror.InvalidState("Bookings of the two properties overlap; cancel or move one of them before merging"))
+			return
+		}
+		log.Printf("Failed to merge property %d into %d: %v", req.DuplicateID, req.SurvivorID, err)
+		c.Error(apierror.Internal("Failed to merge properties"))
+		return
+	}
+
+	for _, id := range []uint{req.SurvivorID, req.DuplicateID} {
+		if err := h.redis.InvalidatePropertyCache(ctx, id); err != nil {
+			log.Printf("Failed to invalidate property cache: %v", err)
+		}
+	}
+	h.invalidateSearchCache(ctx)
+
+	c.JSON(http.StatusOK, gin.H{"data": merge})
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"channelmanager/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondWithETag writes body as JSON tagged with an ETag hashed from data, or an empty
+// 304 Not Modified when the request's If-None-Match already names that ETag. The
+// hash covers only data, so cache hits and misses of the same content share an ETag.
+func respondWithETag(c *gin.Context, data interface{}, body gin.H) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Failed to compute ETag: %v", err)
+		c.JSON(http.StatusOK, body)
+		return
+	}
+
+	sum := sha256.Sum256(encoded)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+
+	if middleware.ETagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+
+	c.JSON(http.StatusOK, body)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"channelmanager/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxReplayWindow is the longest time range of events replayed at once
+const maxReplayWindow = 31 * 24 * time.Hour
+
+// ReplayEventsRequest selects the change events to process again
+type ReplayEventsRequest struct {
+	From   time.Time  `json:"from" binding:"required"`
+	To     *time.Time `json:"to"` // defaults to now
+	Tables []string   `json:"tables" binding:"omitempty,dive,oneof=properties availabilities pricing amenities conditions property_amenities property_conditions bookings calendar_blocks"`
+}
+
+// ReplayEvents resets the change events of a time range, optionally of some tables only,
+// to unprocessed so caches, live subscribers and channels are brought up to date again
+// after a bug or outage
+func (h *Handler) ReplayEvents(c *gin.Context) {
+	var req ReplayEventsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	to := time.Now()
+	if req.To != nil {
+		to = *req.To
+	}
+	if !to.After(req.From) {
+		c.Error(apierror.InvalidDateRange("to must be after from"))
+		return
+	}
+	if to.Sub(req.From) > maxReplayWindow {
+		c.Error(apierror.InvalidDateRange("Replay range cannot exceed 31 days"))
+		return
+	}
+
+	replayed, err := h.eventRepo.ReplayEvents(req.From, to, req.Tables)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to replay events"))
+		return
+	}
+	log.Printf("Replaying %d events from %s to %s (tables: %v)",
+		replayed, req.From.Format(time.RFC3339), to.Format(time.RFC3339), req.Tables)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"data": gin.H{
+			"replayed": replayed,
+			"from":     req.From,
+			"to":       to,
+			"tables":   req.Tables,
+		},
+	})
+}
@@ -7,29 +7,43 @@ import (
 	"time"
 
 	"channelmanager/cache"
+	"channelmanager/channels"
 	"channelmanager/database"
+	"channelmanager/feed"
 	"channelmanager/models"
 
 	"gorm.io/gorm"
 )
 
-// EventListener handles database change events for cache invalidation
+// eventClaimLease is how long a replica has to process a claimed batch before
+// another replica may take it over
+const eventClaimLease = 2 * time.Minute
+
+// EventListener handles database change events for cache invalidation and the change
+// history of nightly rates and availability. Every replica runs a listener; each claims
+// its own batches so an event is processed once.
 type EventListener struct {
-	db        *gorm.DB
-	redis     *cache.RedisClient
-	eventRepo *database.EventRepository
-	ticker    *time.Ticker
-	done      chan bool
+	instance    string
+	db          *gorm.DB
+	redis       *cache.RedisClient
+	eventRepo   *database.EventRepository
+	historyRepo *database.HistoryRepository
+	ariPush     *channels.ARIPushService
+	ticker      *time.Ticker
+	done        chan bool
 }
 
 // NewEventListener creates a new event listener
-func NewEventListener(db *gorm.DB, redis *cache.RedisClient) *EventListener {
+func NewEventListener(db *gorm.DB, redis *cache.RedisClient, ariPush *channels.ARIPushService, instance string) *EventListener {
 	return &EventListener{
-		db:        db,
-		redis:     redis,
-		eventRepo: database.NewEventRepository(db),
-		ticker:    time.NewTicker(5 * time.Second), // Check for events every 5 seconds
-		done:      make(chan bool),
+		instance:    instance,
+		db:          db,
+		redis:       redis,
+		eventRepo:   database.NewEventRepository(db),
+		historyRepo: database.NewHistoryRepository(db),
+		ariPush:     ariPush,
+		ticker:      time.NewTicker(5 * time.Second), // Check for events every 5 seconds
+		done:        make(chan bool),
 	}
 }
 
@@ -59,10 +73,10 @@ func (el *EventListener) Stop() {
 func (el *EventListener) processUnprocessedEvents() {
 	ctx := context.Background()
 
-	// Get unprocessed events
-	events, err := el.eventRepo.GetUnprocessedEvents(100)
+	// Claim a batch of unprocessed events not being processed by another replica
+	events, err := el.eventRepo.ClaimUnprocessedEvents(el.instance, 100, eventClaimLease)
 	if err != nil {
-		log.Printf("Failed to get unprocessed events: %v", err)
+		log.Printf("Failed to claim unprocessed events: %v", err)
 		return
 	}
 
@@ -99,6 +113,10 @@ func (el *EventListener) handleEvent(ctx context.Context, event models.Event) {
 		el.handleConditionEvent(ctx, event)
 	case "property_amenities", "property_conditions":
 		el.handlePropertyRelationEvent(ctx, event)
+	case "bookings":
+		el.handleBookingEvent(ctx, event)
+	case "calendar_blocks":
+		// The nights a block closes or reopens arrive as availability events
 	default:
 		log.Printf("Unknown event table: %s", event.TableName)
 	}
@@ -112,18 +130,23 @@ func (el *EventListener) handlePropertyEvent(ctx context.Context, event models.E
 	if err := el.redis.InvalidatePropertyCache(ctx, propertyID); err != nil {
 		log.Printf("Failed to invalidate property cache: %v", err)
 	}
+	el.recordPropertyUpdate(ctx, propertyID)
 
 	// Invalidate search cache (broad invalidation)
 	if err := el.redis.InvalidateSearchCache(ctx, "", ""); err != nil {
 		log.Printf("Failed to invalidate search cache: %v", err)
 	}
 
-	// Invalidate availability cache
-	if err := el.redis.InvalidateAvailabilityCache(ctx, propertyID); err != nil {
-		log.Printf("Failed to invalidate availability cache: %v", err)
+	// Invalidate calendar views
+	if err := el.redis.InvalidateCalendarCache(ctx, propertyID); err != nil {
+		log.Printf("Failed to invalidate calendar cache: %v", err)
 	}
 
 	log.Printf("Invalidated caches for property %d", propertyID)
+
+	// Notify partner subscribers of the content change
+	el.publishChange(ctx, event, propertyID, time.Time{})
+	el.markFeedStale(ctx, propertyID)
 }
 
 // handleAvailabilityEvent handles availability-related events
@@ -136,9 +159,27 @@ func (el *EventListener) handleAvailabilityEvent(ctx context.Context, event mode
 
 	propertyID := availability.PropertyID
 
-	// Invalidate availability cache
-	if err := el.redis.InvalidateAvailabilityCache(ctx, propertyID); err != nil {
-		log.Printf("Failed to invalidate availability cache: %v", err)
+	// Keep the revision for the night's change log
+	revision := &models.AvailabilityRevision{
+		EventID:    event.ID,
+		PropertyID: propertyID,
+		Date:       availability.Date,
+		EventType:  event.EventType,
+		Source:     event.Source,
+		Available:  availability.Available,
+		MinStay:    availability.MinStay,
+		MaxGuests:  availability.MaxGuests,
+		BookingID:  availability.BookingID,
+		BlockID:    availability.BlockID,
+		ChangedAt:  event.CreatedAt,
+	}
+	if err := el.historyRepo.RecordAvailabilityRevision(revision); err != nil {
+		log.Printf("Failed to record availability revision of event %d: %v", event.ID, err)
+	}
+
+	// Invalidate calendar views
+	if err := el.redis.InvalidateCalendarCache(ctx, propertyID); err != nil {
+		log.Printf("Failed to invalidate calendar cache: %v", err)
 	}
 
 	// Invalidate search cache (availability affects search results)
@@ -146,7 +187,17 @@ func (el *EventListener) handleAvailabilityEvent(ctx context.Context, event mode
 		log.Printf("Failed to invalidate search cache: %v", err)
 	}
 
+	// Invalidate analytics (inventory nights changed)
+	if err := el.redis.InvalidatePropertyAnalyticsCache(ctx, propertyID); err != nil {
+		log.Printf("Failed to invalidate analytics cache: %v", err)
+	}
+
 	log.Printf("Invalidated availability cache for property %d", propertyID)
+
+	// Notify live subscribers, then push the changed night to mapped channels
+	el.publishChange(ctx, event, propertyID, availability.Date)
+	el.pushARI(ctx, propertyID, availability.Date)
+	el.markFeedStale(ctx, propertyID)
 }
 
 // handlePricingEvent handles pricing-related events
@@ -159,6 +210,23 @@ func (el *EventListener) handlePricingEvent(ctx context.Context, event models.Ev
 
 	propertyID := pricing.PropertyID
 
+	// Keep the revision for the night's price history
+	revision := &models.PricingRevision{
+		EventID:    event.ID,
+		PropertyID: propertyID,
+		Date:       pricing.Date,
+		EventType:  event.EventType,
+		Source:     event.Source,
+		BasePrice:  pricing.BasePrice,
+		Taxes:      pricing.Taxes,
+		Fees:       pricing.Fees,
+		Discount:   pricing.Discount,
+		ChangedAt:  event.CreatedAt,
+	}
+	if err := el.historyRepo.RecordPricingRevision(revision); err != nil {
+		log.Printf("Failed to record pricing revision of event %d: %v", event.ID, err)
+	}
+
 	// Invalidate search cache (pricing affects search results)
 	if err := el.redis.InvalidateSearchCache(ctx, "", ""); err != nil {
 		log.Printf("Failed to invalidate search cache: %v", err)
@@ -168,8 +236,24 @@ func (el *EventListener) handlePricingEvent(ctx context.Context, event models.Ev
 	if err := el.redis.InvalidatePropertyCache(ctx, propertyID); err != nil {
 		log.Printf("Failed to invalidate property cache: %v", err)
 	}
+	el.recordPropertyUpdate(ctx, propertyID)
+
+	// Invalidate calendar views (they list the nightly rates)
+	if err := el.redis.InvalidateCalendarCache(ctx, propertyID); err != nil {
+		log.Printf("Failed to invalidate calendar cache: %v", err)
+	}
+
+	// Invalidate analytics (listed rates changed)
+	if err := el.redis.InvalidatePropertyAnalyticsCache(ctx, propertyID); err != nil {
+		log.Printf("Failed to invalidate analytics cache: %v", err)
+	}
 
 	log.Printf("Invalidated pricing-related cache for property %d", propertyID)
+
+	// Notify live subscribers, then push the repriced night to mapped channels
+	el.publishChange(ctx, event, propertyID, pricing.Date)
+	el.pushARI(ctx, propertyID, pricing.Date)
+	el.markFeedStale(ctx, propertyID)
 }
 
 // handleAmenityEvent handles amenity-related events
@@ -213,6 +297,76 @@ func (el *EventListener) handlePropertyRelationEvent(ctx context.Context, event
 	if err := el.redis.InvalidatePropertyCache(ctx, event.RecordID); err != nil {
 		log.Printf("Failed to invalidate property cache: %v", err)
 	}
+	el.recordPropertyUpdate(ctx, event.RecordID)
 
 	log.Printf("Invalidated cache for property relationship change")
 }
+
+// handleBookingEvent handles booking-related events
+func (el *EventListener) handleBookingEvent(ctx context.Context, event models.Event) {
+	var booking models.Booking
+	if err := json.Unmarshal(event.Data, &booking); err != nil {
+		log.Printf("Failed to unmarshal booking data: %v", err)
+		return
+	}
+
+	propertyID := booking.PropertyID
+
+	// Invalidate analytics (occupancy and revenue changed)
+	if err := el.redis.InvalidatePropertyAnalyticsCache(ctx, propertyID); err != nil {
+		log.Printf("Failed to invalidate analytics cache: %v", err)
+	}
+
+	// Invalidate channel performance reports
+	if err := el.redis.InvalidateChannelPerformanceCache(ctx); err != nil {
+		log.Printf("Failed to invalidate channel performance cache: %v", err)
+	}
+
+	log.Printf("Invalidated booking-related cache for property %d", propertyID)
+}
+
+// pushARI pushes availability and rates for a single night to the property's channels
+func (el *EventListener) pushARI(ctx context.Context, propertyID uint, date time.Time) {
+	if el.ariPush == nil || date.IsZero() {
+		return
+	}
+
+	if err := el.ariPush.PushProperty(ctx, propertyID, date, date); err != nil {
+		log.Printf("Failed to push ARI for property %d: %v", propertyID, err)
+	}
+}
+
+// markFeedStale queues a changed property for the next incremental refresh of the
+// Google feed
+func (el *EventListener) markFeedStale(ctx context.Context, propertyID uint) {
+	if err := el.redis.MarkFeedStale(ctx, feed.GoogleVariant, propertyID); err != nil {
+		log.Printf("Failed to mark feed entry of property %d stale: %v", propertyID, err)
+	}
+}
+
+// recordPropertyUpdate counts a change to a property's cached details towards the
+// volatility its cache TTL follows
+func (el *EventListener) recordPropertyUpdate(ctx context.Context, propertyID uint) {
+	if err := el.redis.RecordPropertyUpdate(ctx, propertyID); err != nil {
+		log.Printf("Failed to record update of property %d: %v", propertyID, err)
+	}
+}
+
+// publishChange notifies live subscribers of a processed property, availability or pricing change
+func (el *EventListener) publishChange(ctx context.Context, event models.Event, propertyID uint, date time.Time) {
+	change := models.PropertyChange{
+		EventID:    event.ID,
+		PropertyID: propertyID,
+		Table:      event.TableName,
+		EventType:  event.EventType,
+		Record:     event.Data,
+		OccurredAt: event.CreatedAt,
+	}
+	if !date.IsZero() {
+		change.Date = date.Format("2006-01-02")
+	}
+
+	if err := el.redis.PublishPropertyChange(ctx, &change); err != nil {
+		log.Printf("Failed to publish change for property %d: %v", propertyID, err)
+	}
+}
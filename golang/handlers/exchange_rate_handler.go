@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"channelmanager/apierror"
+	"channelmanager/fx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListExchangeRates retrieves the latest rate of every currency against the currency
+// prices are set in, flagging those published longer ago than the maximum age
+func (h *Handler) ListExchangeRates(c *gin.Context) {
+	rates, err := h.exchangeRates.Rates()
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve exchange rates"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"base": fx.BaseCurrency,
+		"data": rates,
+	})
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportChunkSize is the number of records read from the database per chunk
+const exportChunkSize = 500
+
+// exportChunk reads the chunk after a cursor, returning the records and the cursor after them
+type exportChunk func(filter database.ExportFilter) ([]interface{}, uint, error)
+
+// ExportData streams properties, bookings or pricing as newline-delimited JSON, reading
+// the table in ID order in chunks. updated_since (RFC 3339 or YYYY-MM-DD) restricts the
+// export to recently changed records; cursor resumes after the ID of the last record received.
+func (h *Handler) ExportData(c *gin.Context) {
+	entity := c.Param("entity")
+	next := h.exportSource(entity)
+	if next == nil {
+		c.Error(apierror.New(http.StatusNotFound, apierror.CodeNotFound, fmt.Sprintf("Unknown export %q, expected properties, bookings or pricing", entity)))
+		return
+	}
+
+	filter := database.ExportFilter{Limit: exportChunkSize}
+	if param := c.Query("cursor"); param != "" {
+		cursor, err := strconv.ParseUint(param, 10, 32)
+		if err != nil {
+			c.Error(apierror.InvalidField("cursor", "numeric", "must be the ID of the last record received"))
+			return
+		}
+		filter.AfterID = uint(cursor)
+	}
+	if param := c.Query("updated_since"); param != "" {
+		since, err := time.Parse(time.RFC3339, param)
+		if err != nil {
+			if since, err = time.Parse("2006-01-02", param); err != nil {
+				c.Error(apierror.InvalidDate("updated_since must be an RFC 3339 timestamp or YYYY-MM-DD"))
+				return
+			}
+		}
+		filter.UpdatedSince = &since
+	}
+
+	// Read the first chunk before committing to a 200 response
+	records, cursor, err := next(filter)
+	if err != nil {
+		log.Printf("Failed to export %s: %v", entity, err)
+		c.Error(apierror.Internal("Failed to export " + entity))
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-%s.jsonl", entity, time.Now().UTC().Format("20060102T150405Z")))
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	exported := 0
+	for len(records) > 0 {
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				log.Printf("Export of %s interrupted after %d records: %v", entity, exported, err)
+				return
+			}
+			exported++
+		}
+		c.Writer.Flush()
+
+		if len(records) < filter.Limit || c.Request.Context().Err() != nil {
+			break
+		}
+		filter.AfterID = cursor
+		if records, cursor, err = next(filter); err != nil {
+			// Headers are sent, so the truncated stream is the only signal left to the client
+			log.Printf("Export of %s failed after %d records: %v", entity, exported, err)
+			return
+		}
+	}
+}
+
+// exportSource returns the chunk reader of an exportable entity, or nil if unknown
+func (h *Handler) exportSource(entity string) exportChunk {
+	switch entity {
+	case "properties":
+		return func(filter database.ExportFilter) ([]interface{}, uint, error) {
+			rows, err := h.exportRepo.ExportProperties(filter)
+			if err != nil || len(rows) == 0 {
+				return nil, 0, err
+			}
+			records := make([]interface{}, len(rows))
+			for i := range rows {
+				records[i] = rows[i]
+			}
+			return records, rows[len(rows)-1].ID, nil
+		}
+	case "bookings":
+		return func(filter database.ExportFilter) ([]interface{}, uint, error) {
+			rows, err := h.exportRepo.ExportBookings(filter)
+			if err != nil || len(rows) == 0 {
+				return nil, 0, err
+			}
+			records := make([]interface{}, len(rows))
+			for i := range rows {
+				records[i] = rows[i]
+			}
+			return records, rows[len(rows)-1].ID, nil
+		}
+	case "pricing":
+		return func(filter database.ExportFilter) ([]interface{}, uint, error) {
+			rows, err := h.exportRepo.ExportPricing(filter)
+			if err != nil || len(rows) == 0 {
+				return nil, 0, err
+			}
+			records := make([]interface{}, len(rows))
+			for i := range rows {
+				records[i] = rows[i]
+			}
+			return records, rows[len(rows)-1].ID, nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// favoritesCacheTTL is how long a user's favorites stay cached in Redis
+const favoritesCacheTTL = time.Hour
+
+// maxUserIDLength matches the width of the user_id column
+const maxUserIDLength = 100
+
+// ListFavorites retrieves a user's favorite properties
+func (h *Handler) ListFavorites(c *gin.Context) {
+	userID, ok := favoritesUserID(c)
+	if !ok {
+		return
+	}
+
+	ids, err := h.favoritePropertyIDs(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve favorites"))
+		return
+	}
+
+	properties := []models.Property{}
+	if len(ids) > 0 {
+		if properties, err = h.propertyRepo.GetPropertiesWithContent(ids); err != nil {
+			c.Error(apierror.Internal("Failed to retrieve favorites"))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id": userID,
+		"data":    properties,
+		"total":   len(properties),
+	})
+}
+
+// AddFavorite adds a property to a user's favorites
+func (h *Handler) AddFavorite(c *gin.Context) {
+	userID, ok := favoritesUserID(c)
+	if !ok {
+		return
+	}
+
+	propertyID, err := strconv.ParseUint(c.Param("property_id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	if _, err := h.propertyRepo.GetPropertyByID(uint(propertyID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	if err := h.favoriteRepo.AddFavorite(userID, uint(propertyID)); err != nil {
+		c.Error(apierror.Internal("Failed to add favorite"))
+		return
+	}
+	h.invalidateFavorites(c.Request.Context(), userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":     userID,
+		"property_id": propertyID,
+		"favorite":    true,
+	})
+}
+
+// RemoveFavorite removes a property from a user's favorites
+func (h *Handler) RemoveFavorite(c *gin.Context) {
+	userID, ok := favoritesUserID(c)
+	if !ok {
+		return
+	}
+
+	propertyID, err := strconv.ParseUint(c.Param("property_id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	removed, err := h.favoriteRepo.RemoveFavorite(userID, uint(propertyID))
+	if err != nil {
+		c.Error(apierror.Internal("Failed to remove favorite"))
+		return
+	}
+	if !removed {
+		c.Error(apierror.NotFound("Favorite"))
+		return
+	}
+	h.invalidateFavorites(c.Request.Context(), userID)
+
+	c.Status(http.StatusNoContent)
+}
+
+// favoritesUserID reads the user ID path parameter, reporting an error when it is invalid
+func favoritesUserID(c *gin.Context) (string, bool) {
+	userID := strings.TrimSpace(c.Param("user_id"))
+	if userID == "" || len(userID) > maxUserIDLength {
+		c.Error(apierror.InvalidID("user"))
+		return "", false
+	}
+	return userID, true
+}
+
+// favoritePropertyIDs returns a user's favorite property IDs, from Redis when cached
+func (h *Handler) favoritePropertyIDs(ctx context.Context, userID string) ([]uint, error) {
+	ids, found, err := h.redis.GetFavoritesCache(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to read favorites cache for user %s: %v", userID, err)
+	} else if found {
+		return ids, nil
+	}
+
+	ids, err = h.favoriteRepo.GetFavoritePropertyIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.redis.SetFavoritesCache(ctx, userID, ids, favoritesCacheTTL); err != nil {
+		log.Printf("Failed to cache favorites for user %s: %v", userID, err)
+	}
+	return ids, nil
+}
+
+// invalidateFavorites drops a user's cached favorites after a change
+func (h *Handler) invalidateFavorites(ctx context.Context, userID string) {
+	if err := h.redis.InvalidateFavoritesCache(ctx, userID); err != nil {
+		log.Printf("Failed to invalidate favorites cache for user %s: %v", userID, err)
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"channelmanager/apierror"
+	"channelmanager/feed"
+	"channelmanager/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPropertyFeed serves the last generated catalog feed of a variant, e.g.
+// /feeds/default/properties.xml or /feeds/<channel_id>/properties.json
+func (h *Handler) GetPropertyFeed(c *gin.Context) {
+	variant := c.Param("variant")
+	format := strings.TrimPrefix(c.Param("file"), "properties.")
+	if format != "json" && format != "xml" {
+		c.Error(apierror.New(http.StatusNotFound, apierror.CodeNotFound, "Feed not found, expected properties.json or properties.xml"))
+		return
+	}
+	if !h.validFeedVariant(c, variant) {
+		return
+	}
+
+	data, err := h.store.Get(c.Request.Context(), feed.Key(variant, format))
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			c.Error(apierror.NotFound("Feed"))
+			return
+		}
+		log.Printf("Failed to load feed %s: %v", feed.Key(variant, format), err)
+		c.Error(apierror.Internal("Failed to load feed"))
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Data(http.StatusOK, feed.ContentType(format), data)
+}
+
+// GetGoogleFeed serves a file of the last generated Google Vacation Rentals feed, e.g.
+// /feeds/google/transaction.xml
+func (h *Handler) GetGoogleFeed(c *gin.Context) {
+	file := c.Param("file")
+	if h.googleFeed == nil || !slices.Contains(feed.GoogleFiles, file) {
+		c.Error(apierror.NotFound("Feed"))
+		return
+	}
+
+	data, err := h.store.Get(c.Request.Context(), feed.GoogleKey(file))
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			c.Error(apierror.NotFound("Feed"))
+			return
+		}
+		log.Printf("Failed to load feed %s: %v", feed.GoogleKey(file), err)
+		c.Error(apierror.Internal("Failed to load feed"))
+		return
+	}
+
+	// Prices change with every booking, so partners are not told to cache them for long
+	c.Header("Cache-Control", "public, max-age=60")
+	c.Data(http.StatusOK, feed.ContentType("xml"), data)
+}
+
+// GetGoogleFeedReport returns the validation report of the last generated Google feed
+func (h *Handler) GetGoogleFeedReport(c *gin.Context) {
+	if h.googleFeed == nil {
+		c.Error(apierror.NotFound("Feed"))
+		return
+	}
+
+	report, err := h.googleFeed.Report(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			c.Error(apierror.NotFound("Feed report"))
+			return
+		}
+		log.Printf("Failed to load Google feed report: %v", err)
+		c.Error(apierror.Internal("Failed to load feed report"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+// GenerateFeeds regenerates catalog feeds now, every variant or only the one given
+// by the variant query parameter. The google variant renders the Google Vacation
+// Rentals feed in full and returns its validation report.
+func (h *Handler) GenerateFeeds(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if c.Query("variant") == feed.GoogleVariant {
+		if h.googleFeed == nil {
+			c.Error(apierror.NotFound("Feed"))
+			return
+		}
+		report, err := h.googleFeed.Generate(ctx)
+		if err != nil {
+			log.Printf("Failed to generate Google feed: %v", err)
+			c.Error(apierror.Internal("Failed to generate feed"))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": report})
+		return
+	}
+
+	if variant := c.Query("variant"); variant != "" {
+		if !h.validFeedVariant(c, variant) {
+			return
+		}
+		info, err := h.feeds.GenerateVariant(ctx, variant)
+		if err != nil {
+			log.Printf("Failed to generate feed %s: %v", variant, err)
+			c.Error(apierror.Internal("Failed to generate feed"))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": []feed.Info{*info}})
+		return
+	}
+
+	feeds, err := h.feeds.Generate(ctx)
+	if err != nil {
+		log.Printf("Failed to generate feeds: %v", err)
+		c.Error(apierror.Internal("Failed to generate feeds"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": feeds})
+}
+
+// validFeedVariant checks that a variant is the default feed or an active channel,
+// writing an error response and returning false otherwise
+func (h *Handler) validFeedVariant(c *gin.Context, variant string) bool {
+	if variant == feed.DefaultVariant {
+		return true
+	}
+	if channel, err := h.channelRepo.GetChannelByID(variant); err != nil || !channel.Active {
+		c.Error(apierror.NotFound("Feed"))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"channelmanager/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EraseGuestRequest represents the optional reason recorded with a guest erasure
+type EraseGuestRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// ExportGuestData returns all personal data stored for a guest, identified by email address
+func (h *Handler) ExportGuestData(c *gin.Context) {
+	email, ok := guestEmail(c)
+	if !ok {
+		return
+	}
+
+	export, err := h.guestRepo.ExportGuestData(email)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to export guest data"))
+		return
+	}
+	if export == nil {
+		c.Error(apierror.NotFound("Guest"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": export})
+}
+
+// EraseGuestData anonymizes a guest's bookings and change events while keeping stay
+// dates and amounts for reporting, and returns the erasure audit record
+func (h *Handler) EraseGuestData(c *gin.Context) {
+	email, ok := guestEmail(c)
+	if !ok {
+		return
+	}
+
+	var req EraseGuestRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(apierror.FromBinding(err))
+			return
+		}
+	}
+
+	erasure, err := h.guestRepo.EraseGuest(email, req.Reason)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to erase guest data"))
+		return
+	}
+	if erasure == nil {
+		c.Error(apierror.NotFound("Guest"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": erasure})
+}
+
+// guestEmail reads the guest's email address from the path, reporting an error when it is invalid
+func guestEmail(c *gin.Context) (string, bool) {
+	email := strings.TrimSpace(c.Param("id"))
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		c.Error(apierror.InvalidID("guest"))
+		return "", false
+	}
+	return email, true
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"channelmanager/apierror"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetPropertyPriceHistory lists every revision of a night's rate, oldest first, each
+// with the base price it replaced, so hosts and auditors can see how the rate evolved
+func (h *Handler) GetPropertyPriceHistory(c *gin.Context) {
+	propertyID, date, ok := h.parseNightHistory(c)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.historyRepo.GetPriceHistory(propertyID, date)
+	if err != nil {
+		log.Printf("Failed to retrieve price history of property %d: %v", propertyID, err)
+		c.Error(apierror.Internal("Failed to retrieve price history"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": propertyID,
+		"date":        date.Format("2006-01-02"),
+		"data":        revisions,
+	})
+}
+
+// GetPropertyAvailabilityHistory lists every change of a night's availability, oldest
+// first, with what made it (an ARI update, a booking, a calendar block, ...) and the
+// booking or block involved, so support can tell why a night was closed on a channel
+func (h *Handler) GetPropertyAvailabilityHistory(c *gin.Context) {
+	propertyID, date, ok := h.parseNightHistory(c)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.historyRepo.GetAvailabilityHistory(propertyID, date)
+	if err != nil {
+		log.Printf("Failed to retrieve availability history of property %d: %v", propertyID, err)
+		c.Error(apierror.Internal("Failed to retrieve availability history"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": propertyID,
+		"date":        date.Format("2006-01-02"),
+		"data":        revisions,
+	})
+}
+
+// parseNightHistory reads the property and the date query parameter of a night's
+// history. It writes an error response and returns false if either is invalid or the
+// property does not exist.
+func (h *Handler) parseNightHistory(c *gin.Context) (uint, time.Time, bool) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return 0, time.Time{}, false
+	}
+
+	dateParam := c.Query("date")
+	if dateParam == "" {
+		c.Error(apierror.Validation("date is required"))
+		return 0, time.Time{}, false
+	}
+	date, err := time.Parse("2006-01-02", dateParam)
+	if err != nil {
+		c.Error(apierror.InvalidDate("date must be in YYYY-MM-DD format"))
+		return 0, time.Time{}, false
+	}
+
+	if _, err := h.propertyRepo.GetPropertyByID(uint(propertyID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return 0, time.Time{}, false
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return 0, time.Time{}, false
+	}
+	return uint(propertyID), date, true
+}
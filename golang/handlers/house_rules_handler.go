@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"channelmanager/apierror"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetPropertyHouseRules retrieves a property's structured house rules, the defaults when
+// it has not set any
+func (h *Handler) GetPropertyHouseRules(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": property.ID,
+		"data":        property.EffectiveRules(),
+	})
+}
+
+// UpdatePropertyHouseRules replaces a property's structured house rules. Channels get them
+// with the next content push.
+func (h *Handler) UpdatePropertyHouseRules(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var rules models.HouseRules
+	if err := c.ShouldBindJSON(&rules); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+	if rules.QuietHoursStart != "" && rules.QuietHoursStart == rules.QuietHoursEnd {
+		c.Error(apierror.InvalidField("quiet_hours_end", "nefield", "must differ from quiet_hours_start"))
+		return
+	}
+	if !rules.AdditionalGuestsAllowed {
+		rules.MaxAdditionalGuests = 0
+	}
+	if !rules.ChildrenAllowed {
+		rules.MinChildAge = 0
+	}
+
+	if _, err := h.propertyRepo.GetPropertyByID(uint(propertyID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	if err := h.propertyRepo.UpdateHouseRules(uint(propertyID), rules); err != nil {
+		c.Error(apierror.Internal("Failed to update house rules"))
+		return
+	}
+
+	if err := h.redis.InvalidatePropertyCache(c.Request.Context(), uint(propertyID)); err != nil {
+		log.Printf("Failed to invalidate property cache: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": propertyID,
+		"data":        rules,
+	})
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"channelmanager/apierror"
+	"channelmanager/invoice"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetBookingInvoice returns the PDF invoice or receipt for a booking, generating and
+// storing it on first request from the prices the booking was made at. It needs an API
+// key, permitted to view the property when the property has an owner.
+func (h *Handler) GetBookingInvoice(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	bookingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("booking"))
+		return
+	}
+
+	invoiceType := c.DefaultQuery("type", models.InvoiceTypeInvoice)
+	if invoiceType != models.InvoiceTypeInvoice && invoiceType != models.InvoiceTypeReceipt {
+		c.Error(apierror.Validation("type must be invoice or receipt"))
+		return
+	}
+	if !requireAPIKey(c) {
+		return
+	}
+
+	booking, err := h.bookingRepo.GetBookingByID(uint(bookingID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Booking"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve booking"))
+		return
+	}
+	if apiErr := h.authorizeProperty(c, booking.PropertyID, models.PermissionViewOnly); apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+
+	if invoiceType == models.InvoiceTypeReceipt && booking.Released() {
+		c.Error(apierror.InvalidState("Receipts are not available for cancelled, declined or expired bookings"))
+		return
+	}
+	if invoiceType == models.InvoiceTypeReceipt && booking.Status == models.BookingStatusPending {
+		c.Error(apierror.InvalidState("Receipts are not available until the host approves the booking"))
+		return
+	}
+
+	// Serve the previously generated document if we have one
+	existing, err := h.invoiceRepo.GetInvoiceForBooking(booking.ID, invoiceType)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		c.Error(apierror.Internal("Failed to retrieve invoice"))
+		return
+	}
+	if existing != nil {
+		data, err := h.store.Get(ctx, existing.StorageKey)
+		if err == nil {
+			h.writeInvoicePDF(c, existing.Number, data)
+			return
+		}
+		log.Printf("Failed to load stored invoice %s, regenerating: %v", existing.StorageKey, err)
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(booking.PropertyID)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	seller := models.SellerDetails{SellerLegalName: property.Name, InvoicePrefix: "INV"}
+	if property.OrganizationID != nil {
+		organization, err := h.organizationRepo.GetOrganizationByID(*property.OrganizationID)
+		if err != nil && err != gorm.ErrRecordNotFound {
+			c.Error(apierror.Internal("Failed to retrieve organization"))
+			return
+		}
+		if organization != nil {
+			seller = organization.Seller()
+		}
+	}
+
+	settings, err := h.propertySettings(ctx, property)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve organization settings"))
+		return
+	}
+
+	// A regenerated document keeps the number it was issued under
+	number := invoiceNumber(seller.InvoicePrefix, invoiceType, booking.ID)
+	if existing != nil {
+		number = existing.Number
+	}
+	doc := invoice.NewDocument(invoiceType, number, *booking, *property, seller)
+	doc.Branding = settings.Branding
+	if doc.CancelPolicy == "" {
+		doc.CancelPolicy = settings.CancellationPolicy
+	}
+	data := invoice.Render(doc)
+
+	storageKey := fmt.Sprintf("invoices/%d/%s.pdf", booking.ID, number)
+	if err := h.store.Put(ctx, storageKey, data, "application/pdf"); err != nil {
+		log.Printf("Failed to store invoice %s: %v", storageKey, err)
+		c.Error(apierror.Internal("Failed to store invoice"))
+		return
+	}
+
+	if existing == nil {
+		record := &models.Invoice{
+			BookingID:  booking.ID,
+			Type:       invoiceType,
+			Number:     number,
+			StorageKey: storageKey,
+			Currency:   doc.Currency,
+			Total:      doc.Total,
+			IssuedAt:   doc.IssuedAt,
+		}
+		if err := h.invoiceRepo.CreateInvoice(record); err != nil {
+			log.Printf("Failed to record invoice %s: %v", number, err)
+		}
+	} else if existing.StorageKey != storageKey {
+		if err := h.invoiceRepo.UpdateStorageKey(existing, storageKey); err != nil {
+			log.Printf("Failed to record storage of invoice %s: %v", number, err)
+		}
+	}
+
+	h.writeInvoicePDF(c, number, data)
+}
+
+// UpdateOrganizationSellerDetails updates the seller details printed on an organization's invoices
+func (h *Handler) UpdateOrganizationSellerDetails(c *gin.Context) {
+	organizationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("organization"))
+		return
+	}
+
+	var details models.SellerDetails
+	if err := c.ShouldBindJSON(&details); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+	if details.InvoicePrefix == "" {
+		details.InvoicePrefix = "INV"
+	}
+
+	if _, err := h.organizationRepo.GetOrganizationByID(uint(organizationID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Organization"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve organization"))
+		return
+	}
+
+	if err := h.organizationRepo.UpdateSellerDetails(uint(organizationID), details); err != nil {
+		c.Error(apierror.Internal("Failed to update seller details"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"organization_id": organizationID,
+		"data":            details,
+	})
+}
+
+// writeInvoicePDF writes a PDF document to the response
+func (h *Handler) writeInvoicePDF(c *gin.Context, number string, data []byte) {
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", number+".pdf"))
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
+// invoiceNumber builds a document number such as INV-000042 or INV-R-000042
+func invoiceNumber(prefix string, invoiceType string, bookingID uint) string {
+	if prefix == "" {
+		prefix = "INV"
+	}
+	if invoiceType == models.InvoiceTypeReceipt {
+		return fmt.Sprintf("%s-R-%06d", prefix, bookingID)
+	}
+	return fmt.Sprintf("%s-%06d", prefix, bookingID)
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"channelmanager/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetBookingInvoiceAnonymous(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/bookings/1/invoice", nil)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+	(&Handler{}).GetBookingInvoice(c)
+
+	var apiErr *apierror.APIError
+	if len(c.Errors) != 1 || !errors.As(c.Errors[0].Err, &apiErr) || apiErr.Status != http.StatusUnauthorized {
+		t.Fatalf("GetBookingInvoice() errors = %v, want one 401", c.Errors)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"channelmanager/apierror"
+	"channelmanager/jobs"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JobStatus describes a registered background job and its latest run
+type JobStatus struct {
+	jobs.Info
+	LastRun *models.JobRun `json:"last_run"`
+}
+
+// ListJobs lists the registered background jobs with their schedule and latest run
+func (h *Handler) ListJobs(c *gin.Context) {
+	lastRuns, err := h.jobRunRepo.GetLastRuns()
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve job runs"))
+		return
+	}
+
+	registered := h.scheduler.Jobs()
+	statuses := make([]JobStatus, 0, len(registered))
+	for _, info := range registered {
+		status := JobStatus{Info: info}
+		if run, ok := lastRuns[info.Name]; ok {
+			status.LastRun = &run
+		}
+		statuses = append(statuses, status)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": statuses})
+}
+
+// ListJobRuns lists the run history of a background job, newest first
+func (h *Handler) ListJobRuns(c *gin.Context) {
+	name := c.Param("name")
+	if !h.jobRegistered(name) {
+		c.Error(apierror.NotFound("Job"))
+		return
+	}
+
+	page := parsePage(c, 20, 100)
+
+	runs, total, err := h.jobRunRepo.ListRuns(name, page.Limit, page.Offset())
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve job runs"))
+		return
+	}
+
+	c.JSON(http.StatusOK, paginated(c, runs, total, page))
+}
+
+// TriggerJob runs a background job now and returns its run, which completes in the background
+func (h *Handler) TriggerJob(c *gin.Context) {
+	run, err := h.scheduler.Trigger(c.Param("name"))
+	if err != nil {
+		switch {
+		case errors.Is(err, jobs.ErrUnknownJob):
+			c.Error(apierror.NotFound("Job"))
+		case errors.Is(err, jobs.ErrJobRunning):
+			c.Error(apierror.InvalidState("Job is already running"))
+		default:
+			c.Error(apierror.Internal("Failed to start job"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": run})
+}
+
+// jobRegistered reports whether a background job of that name exists
+func (h *Handler) jobRegistered(name string) bool {
+	for _, info := range h.scheduler.Jobs() {
+		if info.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"channelmanager/apierror"
+	"channelmanager/database"
+	"channelmanager/models"
+	"channelmanager/pii"
+	"channelmanager/quote"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoyaltyProgramRequest represents the payload replacing the loyalty program
+type LoyaltyProgramRequest struct {
+	Enabled         bool    `json:"enabled"`
+	PointsPerNight  float64 `json:"points_per_night" binding:"min=0,max=10000"`
+	PointsPerAmount float64 `json:"points_per_amount" binding:"min=0,max=1000"`
+	MinNights       int     `json:"min_nights" binding:"min=0,max=365"`
+	DirectOnly      bool    `json:"direct_only"`
+	PointValue      float64 `json:"point_value" binding:"min=0,max=1000"`
+	Currency        string  `json:"currency" binding:"required,len=3,uppercase"`
+}
+
+// LoyaltyAdjustmentRequest represents a manual change to a guest's points
+type LoyaltyAdjustmentRequest struct {
+	Points      int    `json:"points" binding:"required,min=-1000000,max=1000000"`
+	Description string `json:"description" binding:"required,max=255"`
+}
+
+// GetLoyaltyProgram retrieves the rules of guest loyalty points
+func (h *Handler) GetLoyaltyProgram(c *gin.Context) {
+	program, err := h.loyaltyRepo.GetLoyaltyProgram()
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve loyalty program"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": program})
+}
+
+// UpdateLoyaltyProgram replaces the rules of guest loyalty points. Changes apply to
+// stays completed and points redeemed from now on.
+func (h *Handler) UpdateLoyaltyProgram(c *gin.Context) {
+	var req LoyaltyProgramRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+	if req.Enabled && req.PointsPerNight == 0 && req.PointsPerAmount == 0 {
+		c.Error(apierror.Validation("An enabled program must award points per night or per amount"))
+		return
+	}
+
+	program := &models.LoyaltyProgram{
+		Enabled:         req.Enabled,
+		PointsPerNight:  req.PointsPerNight,
+		PointsPerAmount: req.PointsPerAmount,
+		MinNights:       req.MinNights,
+		DirectOnly:      req.DirectOnly,
+		PointValue:      req.PointValue,
+		Currency:        req.Currency,
+	}
+	if err := h.loyaltyRepo.SaveLoyaltyProgram(program); err != nil {
+		c.Error(apierror.Internal("Failed to update loyalty program"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": program})
+}
+
+// GetGuestLoyalty retrieves a guest's points balance, the credit it is worth and a page
+// of its changes, newest first
+func (h *Handler) GetGuestLoyalty(c *gin.Context) {
+	email, ok := guestEmail(c)
+	if !ok {
+		return
+	}
+	guestHash := pii.BlindIndex(email)
+
+	program, err := h.loyaltyRepo.GetLoyaltyProgram()
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve loyalty program"))
+		return
+	}
+	points, err := h.loyaltyRepo.GetPointsBalance(guestHash)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve loyalty points"))
+		return
+	}
+	page := parsePage(c, 20, 100)
+	entries, total, err := h.loyaltyRepo.ListLoyaltyEntries(guestHash, page.Limit, page.Offset())
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve loyalty points"))
+		return
+	}
+
+	body := paginated(c, entries, total, page)
+	body["data"] = models.GuestLoyalty{
+		Points:   points,
+		Credit:   roundTo(float64(points)*program.PointValue, 2),
+		Currency: program.Currency,
+		Entries:  entries,
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// AdjustGuestLoyalty grants or, with negative points, withdraws a guest's points
+func (h *Handler) AdjustGuestLoyalty(c *gin.Context) {
+	email, ok := guestEmail(c)
+	if !ok {
+		return
+	}
+
+	var req LoyaltyAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	entry, err := h.loyaltyRepo.AdjustPoints(pii.BlindIndex(email), req.Points, strings.TrimSpace(req.Description))
+	if err != nil {
+		if errors.Is(err, database.ErrInsufficientPoints) {
+			c.Error(apierror.InvalidField("points", "min", "would leave the guest with a negative balance"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to adjust loyalty points"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": entry})
+}
+
+// redeemPoints pays part of a quote with a guest's loyalty points, reporting an error
+// when the points cannot be redeemed
+func (h *Handler) redeemPoints(c *gin.Context, q *quote.Quote, email string, points int) bool {
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		c.Error(apierror.InvalidField("guest_email", "email", "a valid guest email is required to redeem points"))
+		return false
+	}
+
+	program, err := h.loyaltyRepo.GetLoyaltyProgram()
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve loyalty program"))
+		return false
+	}
+	if !program.Enabled || program.PointValue <= 0 {
+		c.Error(apierror.Unprocessable("Loyalty points cannot be redeemed"))
+		return false
+	}
+	if q.Currency != program.Currency {
+		c.Error(apierror.Unprocessable(fmt.Sprintf("Loyalty credit is in %s and cannot pay a stay priced in %s", program.Currency, q.Currency)))
+		return false
+	}
+
+	balance, err := h.loyaltyRepo.GetPointsBalance(pii.BlindIndex(email))
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve loyalty points"))
+		return false
+	}
+	if points > balance {
+		c.Error(apierror.InvalidField("redeem_points", "max", fmt.Sprintf("exceeds the guest's balance of %d points", balance)))
+		return false
+	}
+
+	q.RedeemPoints(points, program.PointValue)
+	return true
+}
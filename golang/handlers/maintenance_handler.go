@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaintenanceRequest represents the payload for starting maintenance mode
+type MaintenanceRequest struct {
+	Message           string `json:"message" binding:"max=500"`
+	RetryAfterSeconds int    `json:"retry_after_seconds" binding:"min=0,max=86400"` // defaults to 300
+	DurationMinutes   int    `json:"duration_minutes" binding:"min=0,max=10080"`    // ends maintenance automatically; zero keeps it on
+}
+
+// GetMaintenanceMode reports whether maintenance mode is on
+func (h *Handler) GetMaintenanceMode(c *gin.Context) {
+	mode, err := h.redis.GetMaintenanceMode(c.Request.Context())
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve maintenance mode"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"enabled": mode != nil, "maintenance": mode}})
+}
+
+// StartMaintenance turns maintenance mode on for every replica
+func (h *Handler) StartMaintenance(c *gin.Context) {
+	var req MaintenanceRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(apierror.FromBinding(err))
+			return
+		}
+	}
+
+	mode := &models.MaintenanceMode{
+		Message:           req.Message,
+		RetryAfterSeconds: req.RetryAfterSeconds,
+		StartedAt:         time.Now(),
+	}
+	if mode.RetryAfterSeconds == 0 {
+		mode.RetryAfterSeconds = 300
+	}
+	ttl := time.Duration(req.DurationMinutes) * time.Minute
+	if ttl > 0 {
+		endsAt := mode.StartedAt.Add(ttl)
+		mode.EndsAt = &endsAt
+	}
+
+	if err := h.redis.SetMaintenanceMode(c.Request.Context(), mode, ttl); err != nil {
+		c.Error(apierror.Internal("Failed to start maintenance mode"))
+		return
+	}
+	log.Printf("Maintenance mode started: %q", mode.Message)
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"enabled": true, "maintenance": mode}})
+}
+
+// EndMaintenance turns maintenance mode off
+func (h *Handler) EndMaintenance(c *gin.Context) {
+	if err := h.redis.ClearMaintenanceMode(c.Request.Context()); err != nil {
+		c.Error(apierror.Internal("Failed to end maintenance mode"))
+		return
+	}
+	log.Println("Maintenance mode ended")
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"enabled": false}})
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"channelmanager/apierror"
+	"channelmanager/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTimeoutStats reports the requests of this replica that ran past their route's timeout
+func (h *Handler) GetTimeoutStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": middleware.TimeoutStats()})
+}
+
+// GetCacheConsistency reports the latest check of cached properties and searches
+// against the database; data is null until the first check runs
+func (h *Handler) GetCacheConsistency(c *gin.Context) {
+	report, err := h.redis.GetConsistencyReport(c.Request.Context())
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve cache consistency report"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+// GetRedisMemory reports Redis's memory limit and eviction policy, with the memory used
+// by each type of key estimated from a sample of random keys (samples, default 200)
+func (h *Handler) GetRedisMemory(c *gin.Context) {
+	samples, err := strconv.Atoi(c.DefaultQuery("samples", "200"))
+	if err != nil || samples < 0 {
+		c.Error(apierror.InvalidField("samples", "numeric", "must be a non-negative number"))
+		return
+	}
+
+	report, err := h.redis.MemoryReport(c.Request.Context(), samples)
+	if err != nil {
+		log.Printf("Failed to report Redis memory: %v", err)
+		c.Error(apierror.Internal("Failed to retrieve Redis memory usage"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
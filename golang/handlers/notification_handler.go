@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"channelmanager/apierror"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListPropertyNotifications retrieves a page of a property's host notifications, newest
+// first; pass unread=true for those not read yet
+func (h *Handler) ListPropertyNotifications(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+	unreadOnly := c.Query("unread") == "true"
+
+	if _, err := h.propertyRepo.GetPropertyByID(uint(propertyID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	page := parsePage(c, 20, 100)
+	notifications, total, err := h.notificationRepo.ListNotifications(uint(propertyID), unreadOnly, page.Limit, page.Offset())
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve notifications"))
+		return
+	}
+
+	c.JSON(http.StatusOK, paginated(c, notifications, total, page))
+}
+
+// MarkPropertyNotificationRead marks one of a property's host notifications read
+func (h *Handler) MarkPropertyNotificationRead(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+	notificationID, err := strconv.ParseUint(c.Param("notification_id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("notification"))
+		return
+	}
+
+	notification, err := h.notificationRepo.MarkNotificationRead(uint(propertyID), uint(notificationID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Notification"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to update notification"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": notification})
+}
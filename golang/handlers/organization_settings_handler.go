@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// organizationSettingsTTL is how long organization settings are cached
+const organizationSettingsTTL = time.Hour
+
+// NotificationPreferencesRequest selects the host notifications an organization wants
+type NotificationPreferencesRequest struct {
+	ReservationModified  *bool `json:"reservation_modified" binding:"required"`
+	ReservationCancelled *bool `json:"reservation_cancelled" binding:"required"`
+}
+
+// OrganizationSettingsRequest represents the payload replacing an organization's settings
+type OrganizationSettingsRequest struct {
+	DefaultCurrency    string                         `json:"default_currency" binding:"required,len=3,uppercase"`
+	CancellationPolicy string                         `json:"cancellation_policy" binding:"required,oneof=flexible moderate strict non_refundable"`
+	Notifications      NotificationPreferencesRequest `json:"notifications"`
+	InvoiceBranding    models.InvoiceBranding         `json:"invoice_branding"`
+}
+
+// GetOrganizationSettings retrieves an organization's settings, the defaults until it
+// saves its own
+func (h *Handler) GetOrganizationSettings(c *gin.Context) {
+	organizationID, ok := h.loadOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	settings, err := h.cachedOrganizationSettings(c.Request.Context(), organizationID)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve organization settings"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
+
+// UpdateOrganizationSettings replaces an organization's settings. New quotes, bookings,
+// invoices and host notifications of its properties follow them; bookings keep the
+// cancellation policy they were made under and issued invoices are not restyled.
+func (h *Handler) UpdateOrganizationSettings(c *gin.Context) {
+	ctx := c.Request.Context()
+	organizationID, ok := h.loadOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	var req OrganizationSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	settings := models.OrganizationSettings{
+		OrganizationID:     organizationID,
+		DefaultCurrency:    req.DefaultCurrency,
+		CancellationPolicy: req.CancellationPolicy,
+		Notifications: models.NotificationPreferences{
+			ReservationModified:  *req.Notifications.ReservationModified,
+			ReservationCancelled: *req.Notifications.ReservationCancelled,
+		},
+		Branding: req.InvoiceBranding,
+	}
+	if err := h.organizationRepo.SaveSettings(&settings); err != nil {
+		log.Printf("Failed to save settings of organization %d: %v", organizationID, err)
+		c.Error(apierror.Internal("Failed to update organization settings"))
+		return
+	}
+
+	if err := h.redis.InvalidateOrganizationSettingsCache(ctx, organizationID); err != nil {
+		log.Printf("Failed to invalidate organization settings cache: %v", err)
+	}
+	// Search results carry quotes in the organization's currency
+	h.invalidateSearchCache(ctx)
+
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
+
+// loadOrganizationID parses the :id path parameter and checks that the organization
+// exists, writing an error response and returning false otherwise
+func (h *Handler) loadOrganizationID(c *gin.Context) (uint, bool) {
+	organizationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("organization"))
+		return 0, false
+	}
+
+	if _, err := h.organizationRepo.GetOrganizationByID(uint(organizationID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Organization"))
+			return 0, false
+		}
+		c.Error(apierror.Internal("Failed to retrieve organization"))
+		return 0, false
+	}
+	return uint(organizationID), true
+}
+
+// propertySettings returns the settings of the organization owning a property; those of
+// properties without an organization are the defaults
+func (h *Handler) propertySettings(ctx context.Context, property *models.Property) (*models.OrganizationSettings, error) {
+	if property.OrganizationID == nil {
+		settings := models.DefaultOrganizationSettings(0)
+		return &settings, nil
+	}
+	return h.cachedOrganizationSettings(ctx, *property.OrganizationID)
+}
+
+// cachedOrganizationSettings returns the settings of an organization, from cache when
+// possible
+func (h *Handler) cachedOrganizationSettings(ctx context.Context, organizationID uint) (*models.OrganizationSettings, error) {
+	if cached, err := h.redis.GetOrganizationSettingsCache(ctx, organizationID); err == nil && cached != nil {
+		return cached, nil
+	}
+
+	settings, err := h.organizationRepo.GetSettings(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.redis.SetOrganizationSettingsCache(ctx, settings, organizationSettingsTTL); err != nil {
+		log.Printf("Failed to cache organization settings: %v", err)
+	}
+	return settings, nil
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"channelmanager/models"
+	"channelmanager/ota"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxOTAPayloadBytes limits the size of OpenTravel messages
+const maxOTAPayloadBytes = 5 << 20
+
+// IngestOTAMessage applies an OTA_HotelAvailNotifRQ or OTA_HotelRateAmountNotifRQ sent by
+// a PMS and answers with the matching OpenTravel acknowledgement. HotelCode is the
+// property ID. Owned properties need an API key permitted to make the changes, as for
+// PUT /properties/:id/ari. Errors are reported in the acknowledgement rather than the
+// JSON envelope.
+func (h *Handler) IngestOTAMessage(c *gin.Context) {
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxOTAPayloadBytes))
+	if err != nil {
+		h.respondOTA(c, http.StatusRequestEntityTooLarge, nil, &ota.Error{Code: ota.ErrCodeUnableToProcess, ShortText: "Payload too large"})
+		return
+	}
+
+	notification, err := ota.Parse(data)
+	if err != nil {
+		var otaErr *ota.Error
+		if !errors.As(err, &otaErr) {
+			otaErr = &ota.Error{Code: ota.ErrCodeSystemError, ShortText: "Failed to parse message"}
+		}
+		h.respondOTA(c, http.StatusOK, notification, otaErr)
+		return
+	}
+
+	for _, item := range notification.Changes {
+		if _, err := h.propertyRepo.GetPropertyByID(item.PropertyID); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				h.respondOTA(c, http.StatusOK, notification, &ota.Error{Code: ota.ErrCodeInvalidHotelCode, ShortText: "Unknown HotelCode"})
+				return
+			}
+			h.respondOTA(c, http.StatusOK, notification, &ota.Error{Code: ota.ErrCodeSystemError, ShortText: "Failed to retrieve property"})
+			return
+		}
+		for _, permission := range ariPermissions(item.Changes) {
+			if apiErr := h.authorizeProperty(c, item.PropertyID, permission); apiErr != nil {
+				code := ota.ErrCodeUnableToProcess
+				if apiErr.Status >= http.StatusInternalServerError {
+					code = ota.ErrCodeSystemError
+				}
+				h.respondOTA(c, apiErr.Status, notification, &ota.Error{Code: code, ShortText: apiErr.Message})
+				return
+			}
+		}
+	}
+
+	if _, err := h.ariRepo.ApplyBatch(notification.Changes, models.EventSourceOTA); err != nil {
+		log.Printf("Failed to apply %s: %v", notification.Type, err)
+		h.respondOTA(c, http.StatusOK, notification, &ota.Error{Code: ota.ErrCodeSystemError, ShortText: "Failed to apply updates"})
+		return
+	}
+
+	h.respondOTA(c, http.StatusOK, notification, nil)
+}
+
+// respondOTA writes an OpenTravel acknowledgement; messages of unknown type get an
+// OTA_ErrorRS with a Bad Request status
+func (h *Handler) respondOTA(c *gin.Context, status int, notification *ota.Notification, otaErr *ota.Error) {
+	requestType, echoToken, version := "OTA_ErrorRQ", "", ""
+	if notification != nil {
+		requestType, echoToken, version = notification.Type, notification.EchoToken, notification.Version
+	} else if status == http.StatusOK {
+		status = http.StatusBadRequest
+	}
+
+	body, err := ota.Acknowledge(requestType, echoToken, version, otaErr)
+	if err != nil {
+		log.Printf("Failed to render OTA acknowledgement: %v", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Data(status, "application/xml; charset=utf-8", body)
+}
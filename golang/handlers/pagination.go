@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Page is the requested page of a paginated list
+type Page struct {
+	Number int
+	Limit  int
+}
+
+// Offset returns the number of items before the page
+func (p Page) Offset() int {
+	return (p.Number - 1) * p.Limit
+}
+
+// parsePage reads the page and limit query parameters. A missing or invalid page is the
+// first one, and a missing or out-of-range limit is defaultLimit.
+func parsePage(c *gin.Context, defaultLimit, maxLimit int) Page {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > maxLimit {
+		limit = defaultLimit
+	}
+	return Page{Number: page, Limit: limit}
+}
+
+// PageLinks are the URLs of neighbouring pages of a list; empty links do not exist
+type PageLinks struct {
+	Self  string `json:"self"`
+	First string `json:"first"`
+	Last  string `json:"last"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+}
+
+// paginated returns the envelope of a page of a list of total items. It carries the
+// data with total, page, limit, total_pages and has_next, and for GET requests links to
+// other pages, made from the request URL. Handlers add their own keys to it.
+func paginated(c *gin.Context, data interface{}, total int64, page Page) gin.H {
+	totalPages := 0
+	if page.Limit > 0 {
+		totalPages = int((total + int64(page.Limit) - 1) / int64(page.Limit))
+	}
+
+	body := gin.H{
+		"data":        data,
+		"total":       total,
+		"page":        page.Number,
+		"limit":       page.Limit,
+		"total_pages": totalPages,
+		"has_next":    page.Number < totalPages,
+		"links":       nil,
+	}
+
+	// Pages of other methods, such as POST searches, are chosen in the request body
+	if c.Request.Method == http.MethodGet {
+		link := func(number int) string {
+			query := c.Request.URL.Query()
+			query.Set("page", strconv.Itoa(number))
+			query.Set("limit", strconv.Itoa(page.Limit))
+			return (&url.URL{Path: c.Request.URL.Path, RawQuery: query.Encode()}).String()
+		}
+		links := PageLinks{Self: link(page.Number), First: link(1), Last: link(max(totalPages, 1))}
+		if page.Number > 1 {
+			links.Prev = link(min(page.Number-1, max(totalPages, 1)))
+		}
+		if page.Number < totalPages {
+			links.Next = link(page.Number + 1)
+		}
+		body["links"] = links
+	}
+	return body
+}
+
+// pageOf returns one page of a fully loaded list
+func pageOf[T any](items []T, page Page) []T {
+	start := page.Offset()
+	if start >= len(items) {
+		return []T{}
+	}
+	return items[start:min(start+page.Limit, len(items))]
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/cache"
+	"channelmanager/middleware"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsPingInterval = 30 * time.Second
+	wsPongWait     = 60 * time.Second
+	wsWriteWait    = 10 * time.Second
+)
+
+// Partners authenticate with API keys rather than cookies, so cross-origin
+// connections carry no ambient credentials and every origin is accepted
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// PartnerCommand is a message sent by a partner over the WebSocket
+type PartnerCommand struct {
+	Action      string `json:"action"`       // subscribe or unsubscribe
+	PropertyIDs []uint `json:"property_ids"` // empty subscribes to every mapped property
+}
+
+// PartnerMessage is a message sent to a partner over the WebSocket
+type PartnerMessage struct {
+	Type        string                 `json:"type"` // subscribed, unsubscribed, change or error
+	PropertyIDs []uint                 `json:"property_ids,omitempty"`
+	Change      *models.PropertyChange `json:"change,omitempty"`
+	Error       *apierror.APIError     `json:"error,omitempty"`
+}
+
+// PartnerWebSocket upgrades to a WebSocket on which a channel partner subscribes to
+// property and ARI changes of the properties mapped to its channel
+func (h *Handler) PartnerWebSocket(c *gin.Context) {
+	channelID := middleware.PartnerChannelID(c)
+	if channel, err := h.channelRepo.GetChannelByID(channelID); err != nil || !channel.Active {
+		c.Error(apierror.New(http.StatusForbidden, apierror.CodeForbidden, "API key is not linked to an active channel"))
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already answered with an HTTP error
+		log.Printf("WebSocket upgrade failed for channel %s: %v", channelID, err)
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub, err := h.redis.SubscribePropertyChanges(ctx)
+	if err != nil {
+		log.Printf("Failed to open change subscription for channel %s: %v", channelID, err)
+		return
+	}
+	defer sub.Close()
+
+	// Read commands until the connection closes
+	commands := make(chan PartnerCommand)
+	go func() {
+		defer cancel()
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		})
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var cmd PartnerCommand
+			if err := json.Unmarshal(data, &cmd); err != nil {
+				cmd = PartnerCommand{} // answered as an invalid command
+			}
+			select {
+			case commands <- cmd:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	subscribed := make(map[uint]bool)
+	for {
+		var msg PartnerMessage
+		select {
+		case cmd := <-commands:
+			msg = h.applyPartnerCommand(ctx, sub, channelID, subscribed, cmd)
+		case change, ok := <-sub.Changes():
+			if !ok {
+				return
+			}
+			if !subscribed[change.PropertyID] {
+				continue
+			}
+			msg = PartnerMessage{Type: "change", Change: &change}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+			continue
+		case <-ctx.Done():
+			return
+		}
+
+		conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		if err := conn.WriteJSON(msg); err != nil {
+			return
+		}
+	}
+}
+
+// applyPartnerCommand updates the partner's subscriptions and returns the reply
+func (h *Handler) applyPartnerCommand(ctx context.Context, sub *cache.ChangeSubscription, channelID string, subscribed map[uint]bool, cmd PartnerCommand) PartnerMessage {
+	switch cmd.Action {
+	case "subscribe":
+		mapped, err := h.mappedPropertyIDs(channelID)
+		if err != nil {
+			return partnerError(apierror.Internal("Failed to retrieve channel mappings"))
+		}
+
+		propertyIDs := cmd.PropertyIDs
+		if len(propertyIDs) == 0 {
+			for propertyID := range mapped {
+				propertyIDs = append(propertyIDs, propertyID)
+			}
+			sort.Slice(propertyIDs, func(i, j int) bool { return propertyIDs[i] < propertyIDs[j] })
+		}
+		for _, propertyID := range propertyIDs {
+			if !mapped[propertyID] {
+				return partnerError(apierror.New(http.StatusForbidden, apierror.CodeForbidden,
+					fmt.Sprintf("Property %d is not mapped to channel %s", propertyID, channelID)))
+			}
+		}
+
+		if err := sub.Add(ctx, propertyIDs...); err != nil {
+			return partnerError(apierror.Internal("Failed to subscribe"))
+		}
+		for _, propertyID := range propertyIDs {
+			subscribed[propertyID] = true
+		}
+		return PartnerMessage{Type: "subscribed", PropertyIDs: propertyIDs}
+
+	case "unsubscribe":
+		propertyIDs := cmd.PropertyIDs
+		if len(propertyIDs) == 0 {
+			for propertyID := range subscribed {
+				propertyIDs = append(propertyIDs, propertyID)
+			}
+			sort.Slice(propertyIDs, func(i, j int) bool { return propertyIDs[i] < propertyIDs[j] })
+		}
+		if len(propertyIDs) > 0 {
+			if err := sub.Remove(ctx, propertyIDs...); err != nil {
+				return partnerError(apierror.Internal("Failed to unsubscribe"))
+			}
+		}
+		for _, propertyID := range propertyIDs {
+			delete(subscribed, propertyID)
+		}
+		return PartnerMessage{Type: "unsubscribed", PropertyIDs: propertyIDs}
+
+	default:
+		return partnerError(apierror.InvalidRequest("Invalid command, expected action subscribe or unsubscribe"))
+	}
+}
+
+// mappedPropertyIDs returns the properties actively mapped to a channel
+func (h *Handler) mappedPropertyIDs(channelID string) (map[uint]bool, error) {
+	mappings, err := h.channelRepo.GetMappingsForChannel(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	mapped := make(map[uint]bool, len(mappings))
+	for _, mapping := range mappings {
+		if mapping.Active {
+			mapped[mapping.PropertyID] = true
+		}
+	}
+	return mapped, nil
+}
+
+// partnerError wraps an API error in a WebSocket message
+func partnerError(apiErr *apierror.APIError) PartnerMessage {
+	return PartnerMessage{Type: "error", Error: apiErr}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/database"
+	"channelmanager/ledger"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GeneratePayoutStatementsRequest represents the payload for generating payout statements
+type GeneratePayoutStatementsRequest struct {
+	Period string `json:"period" binding:"required,datetime=2006-01"` // YYYY-MM
+}
+
+// ExecutePayoutRequest represents the payload for marking a payout as executed
+type ExecutePayoutRequest struct {
+	PayoutReference string `json:"payout_reference" binding:"required,max=100"`
+}
+
+// GeneratePayoutStatements records ledger entries and refreshes payout statements for a period
+func (h *Handler) GeneratePayoutStatements(c *gin.Context) {
+	var req GeneratePayoutStatementsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	if _, _, err := ledger.PeriodBounds(req.Period); err != nil {
+		c.Error(apierror.InvalidRequest(err.Error()))
+		return
+	}
+
+	statements, err := h.ledgerService.GenerateStatements(req.Period)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to generate payout statements"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"period": req.Period,
+		"data":   statements,
+	})
+}
+
+// ListPayoutStatements lists payout statements filtered by owner, period and status
+func (h *Handler) ListPayoutStatements(c *gin.Context) {
+	page := parsePage(c, 20, 100)
+
+	if !payoutStatusValid(c.Query("status")) {
+		c.Error(apierror.Validation("status must be pending or executed"))
+		return
+	}
+
+	filter := database.PayoutStatementFilter{
+		Period: c.Query("period"),
+		Status: c.Query("status"),
+		Limit:  page.Limit,
+		Offset: page.Offset(),
+	}
+	if orgID := c.Query("organization_id"); orgID != "" {
+		id, err := strconv.ParseUint(orgID, 10, 32)
+		if err != nil {
+			c.Error(apierror.InvalidID("organization"))
+			return
+		}
+		filter.OrganizationID = uint(id)
+	}
+
+	statements, total, err := h.ledgerRepo.ListStatements(filter)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve payout statements"))
+		return
+	}
+
+	c.JSON(http.StatusOK, paginated(c, statements, total, page))
+}
+
+// GetPayoutStatement retrieves a payout statement with its ledger entries
+func (h *Handler) GetPayoutStatement(c *gin.Context) {
+	statementID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("statement"))
+		return
+	}
+
+	statement, err := h.ledgerRepo.GetStatementByID(uint(statementID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Payout statement"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve payout statement"))
+		return
+	}
+
+	entries, err := h.ledgerRepo.GetEntries(statement.OrganizationID, statement.Period)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve ledger entries"))
+		return
+	}
+	statement.Entries = entries
+
+	c.JSON(http.StatusOK, gin.H{"data": statement})
+}
+
+// ExecutePayoutStatement marks a pending payout statement as paid out
+func (h *Handler) ExecutePayoutStatement(c *gin.Context) {
+	statementID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("statement"))
+		return
+	}
+
+	var req ExecutePayoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	statement, err := h.ledgerRepo.GetStatementByID(uint(statementID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Payout statement"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve payout statement"))
+		return
+	}
+
+	updated, err := h.ledgerRepo.MarkStatementExecuted(statement.ID, req.PayoutReference, time.Now())
+	if err != nil {
+		c.Error(apierror.Internal("Failed to mark payout as executed"))
+		return
+	}
+	if !updated {
+		c.Error(apierror.InvalidState("Payout statement has already been executed"))
+		return
+	}
+
+	statement, err = h.ledgerRepo.GetStatementByID(statement.ID)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve payout statement"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": statement})
+}
+
+// payoutStatusValid reports whether a payout status filter value is known
+func payoutStatusValid(status string) bool {
+	return status == "" || status == models.PayoutStatusPending || status == models.PayoutStatusExecuted
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"channelmanager/apierror"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PhotoRequest represents one photo of a property
+type PhotoRequest struct {
+	URL     string `json:"url" binding:"required,url,max=1024"`
+	Caption string `json:"caption" binding:"max=255"`
+}
+
+// ReplacePhotosRequest represents the payload replacing a property's photos, in display order
+type ReplacePhotosRequest struct {
+	Photos []PhotoRequest `json:"photos" binding:"max=50,dive"`
+}
+
+// ListPropertyPhotos retrieves a property's photos in display order
+func (h *Handler) ListPropertyPhotos(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	photos, err := h.photoRepo.GetPhotosForProperty(uint(propertyID))
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve photos"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": propertyID,
+		"data":        photos,
+	})
+}
+
+// ReplacePropertyPhotos replaces a property's photos; their order in the request is the display order
+func (h *Handler) ReplacePropertyPhotos(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var req ReplacePhotosRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	if _, err := h.propertyRepo.GetPropertyByID(uint(propertyID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	photos := make([]models.PropertyPhoto, 0, len(req.Photos))
+	for i, photo := range req.Photos {
+		photos = append(photos, models.PropertyPhoto{
+			PropertyID: uint(propertyID),
+			URL:        photo.URL,
+			Caption:    strings.TrimSpace(photo.Caption),
+			SortOrder:  i,
+		})
+	}
+
+	if err := h.photoRepo.ReplacePhotos(uint(propertyID), photos); err != nil {
+		c.Error(apierror.Internal("Failed to save photos"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": propertyID,
+		"data":        photos,
+	})
+}
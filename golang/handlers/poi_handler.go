@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"channelmanager/apierror"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PointOfInterestRequest represents the payload for creating or updating a point of interest
+type PointOfInterestRequest struct {
+	Name      string   `json:"name" binding:"required,max=255"`
+	Type      string   `json:"type" binding:"required,oneof=beach airport attraction"`
+	City      string   `json:"city" binding:"max=100"`
+	Country   string   `json:"country" binding:"max=100"`
+	Latitude  *float64 `json:"latitude" binding:"required,min=-90,max=90"`
+	Longitude *float64 `json:"longitude" binding:"required,min=-180,max=180"`
+}
+
+// ListPointsOfInterest retrieves points of interest, optionally of one type or in one city
+func (h *Handler) ListPointsOfInterest(c *gin.Context) {
+	page := parsePage(c, 50, 200)
+
+	poiType := c.Query("type")
+	switch poiType {
+	case "", models.POITypeBeach, models.POITypeAirport, models.POITypeAttraction:
+	default:
+		c.Error(apierror.Validation("type must be beach, airport or attraction"))
+		return
+	}
+
+	pois, total, err := h.poiRepo.ListPointsOfInterest(poiType, c.Query("city"), page.Limit, page.Offset())
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve points of interest"))
+		return
+	}
+
+	c.JSON(http.StatusOK, paginated(c, pois, total, page))
+}
+
+// GetPointOfInterest retrieves a single point of interest
+func (h *Handler) GetPointOfInterest(c *gin.Context) {
+	poi, ok := h.loadPointOfInterest(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": poi})
+}
+
+// CreatePointOfInterest creates a point of interest
+func (h *Handler) CreatePointOfInterest(c *gin.Context) {
+	var req PointOfInterestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	var poi models.PointOfInterest
+	applyPointOfInterestRequest(&poi, req)
+
+	if err := h.poiRepo.CreatePointOfInterest(&poi); err != nil {
+		c.Error(apierror.Internal("Failed to create point of interest"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": poi})
+}
+
+// UpdatePointOfInterest updates a point of interest
+func (h *Handler) UpdatePointOfInterest(c *gin.Context) {
+	poi, ok := h.loadPointOfInterest(c)
+	if !ok {
+		return
+	}
+
+	var req PointOfInterestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+	applyPointOfInterestRequest(poi, req)
+
+	if err := h.poiRepo.UpdatePointOfInterest(poi); err != nil {
+		c.Error(apierror.Internal("Failed to update point of interest"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": poi})
+}
+
+// DeletePointOfInterest deletes a point of interest
+func (h *Handler) DeletePointOfInterest(c *gin.Context) {
+	poi, ok := h.loadPointOfInterest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.poiRepo.DeletePointOfInterest(poi.ID); err != nil {
+		c.Error(apierror.Internal("Failed to delete point of interest"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": true, "id": poi.ID})
+}
+
+// nearbyPointsOfInterest returns the closest points of interest of each type around a
+// property; it is empty for properties without coordinates
+func (h *Handler) nearbyPointsOfInterest(property *models.Property) ([]models.NearbyPointOfInterest, error) {
+	if property.Latitude == 0 && property.Longitude == 0 {
+		return []models.NearbyPointOfInterest{}, nil
+	}
+	nearby, err := h.poiRepo.NearbyPointsOfInterest(property.Latitude, property.Longitude, h.search.NearbyRadiusKm, h.search.NearbyPerType)
+	if err != nil {
+		return nil, err
+	}
+	if nearby == nil {
+		nearby = []models.NearbyPointOfInterest{}
+	}
+	return nearby, nil
+}
+
+// loadPointOfInterest loads the point of interest referenced by the :id path parameter,
+// writing an error response and returning false if it cannot be loaded
+func (h *Handler) loadPointOfInterest(c *gin.Context) (*models.PointOfInterest, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("point of interest"))
+		return nil, false
+	}
+
+	poi, err := h.poiRepo.GetPointOfInterestByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Point of interest"))
+			return nil, false
+		}
+		c.Error(apierror.Internal("Failed to retrieve point of interest"))
+		return nil, false
+	}
+	return poi, true
+}
+
+// applyPointOfInterestRequest copies a point of interest payload onto the point of interest
+func applyPointOfInterestRequest(poi *models.PointOfInterest, req PointOfInterestRequest) {
+	poi.Name = strings.TrimSpace(req.Name)
+	poi.Type = req.Type
+	poi.City = strings.TrimSpace(req.City)
+	poi.Country = strings.TrimSpace(req.Country)
+	poi.Latitude = *req.Latitude
+	poi.Longitude = *req.Longitude
+}
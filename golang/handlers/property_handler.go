@@ -4,15 +4,32 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"channelmanager/apierror"
 	"channelmanager/cache"
+	"channelmanager/channels"
+	"channelmanager/coalesce"
 	"channelmanager/database"
+	"channelmanager/feed"
+	"channelmanager/fx"
+	"channelmanager/jobs"
+	"channelmanager/ledger"
+	"channelmanager/middleware"
 	"channelmanager/models"
+	"channelmanager/quote"
+	"channelmanager/ranking"
+	"channelmanager/rates"
+	"channelmanager/storage"
+	"channelmanager/weather"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -27,12 +44,82 @@ type Handler struct {
 	pricingRepo      *database.PricingRepository
 	amenityRepo      *database.AmenityRepository
 	conditionRepo    *database.ConditionRepository
+	bookingRepo      *database.BookingRepository
+	invoiceRepo      *database.InvoiceRepository
+	organizationRepo *database.OrganizationRepository
+	ledgerRepo       *database.LedgerRepository
+	analyticsRepo    *database.AnalyticsRepository
+	parityRepo       *database.ParityRepository
+	channelRepo      *database.ChannelRepository
+	store            storage.ObjectStore
+	ledgerService    *ledger.Service
+	ariPush          *channels.ARIPushService
+	quoteEngine      *quote.Engine
+	discountRepo     *database.DiscountRepository
+	feeRepo          *database.FeeRepository
+	taxRuleRepo      *database.TaxRuleRepository
+	seasonRepo       *database.SeasonRepository
+	materializer     *rates.Materializer
+	eventRepo        *database.EventRepository
+	contentCodeRepo  *database.ContentCodeRepository
+	contentPush      *channels.ContentPushService
+	translationRepo  *database.TranslationRepository
+	ariRepo          *database.ARIRepository
+	search           SearchConfig
+	exportRepo       *database.ExportRepository
+	photoRepo        *database.PhotoRepository
+	feeds            *feed.Generator
+	googleFeed       *feed.GoogleFeed // nil when the Google feed is disabled
+	favoriteRepo     *database.FavoriteRepository
+	blockRepo        *database.BlockRepository
+	conflictRepo     *database.ConflictRepository
+	syncLogRepo      *database.SyncLogRepository
+	resync           *channels.ResyncService
+	resyncRepo       *database.ResyncRepository
+	dryRunRepo       *database.DryRunRepository
+	scheduler        *jobs.Scheduler
+	jobRunRepo       *database.JobRunRepository
+	guestRepo        *database.GuestRepository
+	statsRepo        *database.StatsRepository
+	historyRepo      *database.HistoryRepository
+	credentials      *channels.CredentialVault
+	credentialRepo   *database.CredentialRepository
+	reservations     *channels.ReservationService
+	notificationRepo *database.NotificationRepository
+	calendarFeedRepo *database.CalendarFeedRepository
+	loyaltyRepo      *database.LoyaltyRepository
+	voucherRepo      *database.VoucherRepository
+	destinationRepo  *database.DestinationEventRepository
+	poiRepo          *database.POIRepository
+	duplicateRepo    *database.DuplicateRepository
+	trashRepo        *database.TrashRepository
+	teamRepo         *database.TeamRepository
+	propertyLoads    coalesce.Group[*models.Property]
+	batchLoads       coalesce.Group[map[uint][]models.Availability]
+	vouchers         VoucherConfig
+	bookings         BookingConfig
+	exchangeRates    *fx.Service
+	weather          *weather.Service // nil when the integration is disabled
 }
 
 // NewHandler creates a new handler instance
 func NewHandler(
 	db *gorm.DB,
 	redis *cache.RedisClient,
+	store storage.ObjectStore,
+	ledgerService *ledger.Service,
+	ariPush *channels.ARIPushService,
+	contentPush *channels.ContentPushService,
+	search SearchConfig,
+	feeds *feed.Generator,
+	googleFeed *feed.GoogleFeed,
+	scheduler *jobs.Scheduler,
+	credentials *channels.CredentialVault,
+	reservations *channels.ReservationService,
+	vouchers VoucherConfig,
+	bookings BookingConfig,
+	exchangeRates *fx.Service,
+	weather *weather.Service,
 ) *Handler {
 	return &Handler{
 		db:               db,
@@ -42,6 +129,60 @@ func NewHandler(
 		pricingRepo:      database.NewPricingRepository(db),
 		amenityRepo:      database.NewAmenityRepository(db),
 		conditionRepo:    database.NewConditionRepository(db),
+		bookingRepo:      database.NewBookingRepository(db),
+		invoiceRepo:      database.NewInvoiceRepository(db),
+		organizationRepo: database.NewOrganizationRepository(db),
+		ledgerRepo:       database.NewLedgerRepository(db),
+		analyticsRepo:    database.NewAnalyticsRepository(db),
+		parityRepo:       database.NewParityRepository(db),
+		channelRepo:      database.NewChannelRepository(db),
+		store:            store,
+		ledgerService:    ledgerService,
+		ariPush:          ariPush,
+		quoteEngine:      quote.NewEngine(db, exchangeRates),
+		discountRepo:     database.NewDiscountRepository(db),
+		feeRepo:          database.NewFeeRepository(db),
+		taxRuleRepo:      database.NewTaxRuleRepository(db),
+		seasonRepo:       database.NewSeasonRepository(db),
+		materializer:     rates.NewMaterializer(db),
+		eventRepo:        database.NewEventRepository(db),
+		contentCodeRepo:  database.NewContentCodeRepository(db),
+		contentPush:      contentPush,
+		translationRepo:  database.NewTranslationRepository(db),
+		ariRepo:          database.NewARIRepository(db),
+		search:           search,
+		exportRepo:       database.NewExportRepository(db),
+		photoRepo:        database.NewPhotoRepository(db),
+		feeds:            feeds,
+		googleFeed:       googleFeed,
+		favoriteRepo:     database.NewFavoriteRepository(db),
+		blockRepo:        database.NewBlockRepository(db),
+		conflictRepo:     database.NewConflictRepository(db),
+		syncLogRepo:      database.NewSyncLogRepository(db),
+		resync:           channels.NewResyncService(db, ariPush, contentPush),
+		resyncRepo:       database.NewResyncRepository(db),
+		dryRunRepo:       database.NewDryRunRepository(db),
+		scheduler:        scheduler,
+		jobRunRepo:       database.NewJobRunRepository(db),
+		guestRepo:        database.NewGuestRepository(db),
+		statsRepo:        database.NewStatsRepository(db),
+		historyRepo:      database.NewHistoryRepository(db),
+		credentials:      credentials,
+		credentialRepo:   database.NewCredentialRepository(db),
+		reservations:     reservations,
+		notificationRepo: database.NewNotificationRepository(db),
+		calendarFeedRepo: database.NewCalendarFeedRepository(db),
+		loyaltyRepo:      database.NewLoyaltyRepository(db),
+		voucherRepo:      database.NewVoucherRepository(db),
+		destinationRepo:  database.NewDestinationEventRepository(db),
+		poiRepo:          database.NewPOIRepository(db),
+		duplicateRepo:    database.NewDuplicateRepository(db),
+		trashRepo:        database.NewTrashRepository(db),
+		teamRepo:         database.NewTeamRepository(db),
+		vouchers:         vouchers,
+		bookings:         bookings,
+		exchangeRates:    exchangeRates,
+		weather:          weather,
 	}
 }
 
@@ -52,73 +193,250 @@ func (h *Handler) SearchProperties(c *gin.Context) {
 	// Parse search filter from request
 	filter := models.SearchFilter{}
 	if err := c.ShouldBindJSON(&filter); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.FromBinding(err))
 		return
 	}
+	filter.LocationSimilarity = h.search.LocationSimilarity
+	if filter.Children+filter.Infants > filter.NumberOfGuests {
+		c.Error(apierror.InvalidField("children", "ltefield", "children and infants must be counted in number_of_guests"))
+		return
+	}
+
+	// Favorites are read with an admin API key, like the favorites routes
+	if filter.OnlyFavorites && !middleware.IsAdmin(c) {
+		if middleware.APIKey(c) == "" {
+			c.Error(apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "Missing API key"))
+			return
+		}
+		c.Error(apierror.New(http.StatusForbidden, apierror.CodeForbidden, "only_favorites requires an admin API key"))
+		return
+	}
+	if filter.OnlyFavorites {
+		ids, err := h.favoritePropertyIDs(ctx, filter.UserID)
+		if err != nil {
+			c.Error(apierror.Internal("Failed to retrieve favorites"))
+			return
+		}
+		filter.FavoriteIDs = ids
+	}
 
 	// Validate pagination
 	if filter.Page < 1 {
 		filter.Page = 1
 	}
-	if filter.Limit < 1 || filter.Limit > 100 {
+	if filter.Limit < 1 {
 		filter.Limit = 20
 	}
+	if h.search.MaxPageSize > 0 && filter.Limit > h.search.MaxPageSize {
+		c.Error(apierror.InvalidField("limit", "max", "must be at most "+strconv.Itoa(h.search.MaxPageSize)))
+		return
+	}
+
+	// Sparse field selection
+	fields, apiErr := parseSearchFields(c.Query("fields"))
+	if apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+	include, apiErr := parseInclude(c.Query("include"), "weather")
+	if apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+
+	// Debug mode lists properties left out by the minimum stay, and explain mode (for
+	// admins) how every filter treats candidate properties; both bypass the cache
+	debug := c.Query("debug") == "true"
+	explain := c.Query("explain") == "true"
+	var explainIDs []uint
+	if explain {
+		ids, apiErr := parseIDList("explain_ids", c.Query("explain_ids"))
+		if apiErr != nil {
+			c.Error(apiErr)
+			return
+		}
+		explainIDs = ids
+	}
+
+	// Equivalent searches share the cache key of their result set, whose first pages
+	// are cached together and paginated from the cache
+	normalizeSearchFilter(&filter)
+	page := Page{Number: filter.Page, Limit: filter.Limit}
+	setKey := h.generateSearchCacheKey(filter)
+	window, cacheKey := h.searchWindow(setKey, page)
 
-	// Generate cache key
-	cacheKey := h.generateSearchCacheKey(filter)
+	// Relax mode suggests alternatives to a search without results
+	relax := c.Query("relax") == "true" && filter.Page == 1
 	log.Printf("Cache key: %s", cacheKey)
 
 	// Try to get from cache
-	cachedResults, err := h.redis.GetSearchResultsCache(ctx, cacheKey)
-	if err != nil {
-		log.Printf("Cache retrieval error: %v", err)
+	var cachedResults *models.SearchResultsCache
+	var err error
+	if !debug && !explain {
+		cachedResults, err = h.redis.GetSearchResultsCache(ctx, cacheKey)
+		if err != nil {
+			log.Printf("Cache retrieval error: %v", err)
+		}
 	}
 
 	if cachedResults != nil {
-		log.Println("Cache HIT for search results")
-		c.JSON(http.StatusOK, gin.H{
-			"data":      cachedResults.Results,
-			"total":     cachedResults.Total,
-			"page":      cachedResults.Page,
-			"limit":     cachedResults.Limit,
-			"cached":    true,
-			"cache_age": time.Since(cachedResults.UpdatedAt).Seconds(),
-		})
-		return
+		// A window cached for a smaller page size may not reach this page
+		if results, ok := cachedPage(cachedResults, page); ok {
+			log.Println("Cache HIT for search results")
+			h.recordImpressions(ctx, results)
+			h.localizeSearchResults(c, results)
+			data, truncated := h.shapeSearchResults(results, fields)
+			response := paginated(c, data, int64(cachedResults.Total), page)
+			response["truncated"] = truncated
+			response["price_range"] = cachedResults.Prices
+			response["cached"] = true
+			response["cache_age"] = time.Since(cachedResults.UpdatedAt).Seconds()
+			if include["weather"] {
+				response["weather"] = h.searchWeather(ctx, results)
+			}
+			if relax && cachedResults.Total == 0 {
+				if relaxed := h.relaxedMatches(c, filter, setKey, fields, true); relaxed != nil {
+					response["relaxed_matches"] = relaxed
+				}
+			}
+			c.JSON(http.StatusOK, response)
+			return
+		}
 	}
 
 	log.Println("Cache MISS for search results, fetching from database")
 
-	// Fetch from database
-	properties, total, err := h.propertyRepo.SearchProperties(filter)
+	// Fetch the whole window from database
+	windowFilter := filter
+	windowFilter.Page, windowFilter.Limit = window.Number, window.Limit
+	windowResults, total, err := h.searchPage(ctx, windowFilter)
 	if err != nil {
 		log.Printf("Database search error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search properties"})
+		c.Error(apierror.Internal("Failed to search properties"))
+		return
+	}
+	// Stop between phases once the request timed out or the client went away
+	if ctx.Err() != nil {
+		c.Error(apierror.Timeout())
 		return
 	}
 
-	// Convert to search results
-	results := h.convertPropertiesToSearchResults(ctx, properties, filter)
+	// Price slider bounds across every match, whatever its price
+	priceRange, err := h.propertyRepo.PriceRange(filter)
+	if err != nil {
+		log.Printf("Failed to aggregate search prices: %v", err)
+	}
 
 	// Cache the results (5 minute TTL for search results)
 	cacheResults := &models.SearchResultsCache{
-		Results: results,
+		Results: windowResults,
 		Total:   int(total),
-		Page:    filter.Page,
-		Limit:   filter.Limit,
+		Page:    window.Number,
+		Limit:   window.Limit,
+		Prices:  priceRange,
 	}
 
 	if err := h.redis.SetSearchResultsCache(ctx, cacheKey, cacheResults, 5*time.Minute); err != nil {
 		log.Printf("Failed to cache search results: %v", err)
 	}
+	results, _ := cachedPage(cacheResults, page)
+	if ctx.Err() != nil {
+		c.Error(apierror.Timeout())
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data":   results,
-		"total":  total,
-		"page":   filter.Page,
-		"limit":  filter.Limit,
-		"cached": false,
-	})
+	if !debug && !explain {
+		h.recordImpressions(ctx, results)
+	}
+	h.localizeSearchResults(c, results)
+	data, truncated := h.shapeSearchResults(results, fields)
+
+	response := paginated(c, data, total, page)
+	response["truncated"] = truncated
+	response["price_range"] = priceRange
+	response["cached"] = false
+	if include["weather"] {
+		response["weather"] = h.searchWeather(ctx, results)
+	}
+	if relax && total == 0 {
+		if relaxed := h.relaxedMatches(c, filter, setKey, fields, !debug && !explain); relaxed != nil {
+			response["relaxed_matches"] = relaxed
+		}
+	}
+	if debug {
+		excluded, err := h.propertyRepo.MinStayExclusions(filter, maxSearchExclusions)
+		if err != nil {
+			log.Printf("Failed to list search exclusions: %v", err)
+			c.Error(apierror.Internal("Failed to search properties"))
+			return
+		}
+		response["excluded"] = excluded
+	}
+	if explain {
+		explanation, err := h.propertyRepo.ExplainSearch(filter, explainIDs, maxExplainedCandidates)
+		if err != nil {
+			log.Printf("Failed to explain search: %v", err)
+			c.Error(apierror.Internal("Failed to search properties"))
+			return
+		}
+		response["explain"] = explanation
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// searchPage returns the results of the page of filter and the number of matches
+func (h *Handler) searchPage(ctx context.Context, filter models.SearchFilter) ([]models.SearchResult, int64, error) {
+	var properties []models.Property
+	var total int64
+	var err error
+	if filter.SortBy == "relevance" {
+		properties, total, err = h.propertyRepo.SearchCandidates(filter, h.search.MaxRankedCandidates)
+	} else {
+		properties, total, err = h.propertyRepo.SearchProperties(filter)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Convert to search results
+	results := h.convertPropertiesToSearchResults(ctx, properties, filter)
+
+	// Relevance needs prices and distances, so candidates are ranked here and then paginated
+	if filter.SortBy == "relevance" {
+		baseRates := make(map[uint]float64, len(properties))
+		for _, prop := range properties {
+			baseRates[prop.ID] = prop.BaseNightlyRate
+		}
+		h.rankSearchResults(results, baseRates)
+		results = pageOf(results, Page{Number: filter.Page, Limit: filter.Limit})
+	}
+	return results, total, nil
+}
+
+// maxSearchExclusions is the number of excluded properties listed in debug mode
+const maxSearchExclusions = 100
+
+// maxExplainedCandidates is the number of candidate properties an explained search reports on
+const maxExplainedCandidates = 50
+
+// parseIDList parses a comma-separated list of IDs from the query parameter name
+func parseIDList(name, value string) ([]uint, *apierror.APIError) {
+	var ids []uint
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || id == 0 {
+			return nil, apierror.InvalidField(name, "numeric", "must be a comma-separated list of IDs")
+		}
+		ids = append(ids, uint(id))
+	}
+	if len(ids) > maxExplainedCandidates {
+		return nil, apierror.InvalidField(name, "max", "must list at most "+strconv.Itoa(maxExplainedCandidates)+" IDs")
+	}
+	return ids, nil
 }
 
 // GetProperty retrieves a single property by ID
@@ -127,7 +445,13 @@ func (h *Handler) GetProperty(c *gin.Context) {
 
 	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid property ID"})
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	include, apiErr := parseInclude(c.Query("include"), "nearby")
+	if apiErr != nil {
+		c.Error(apiErr)
 		return
 	}
 
@@ -139,44 +463,78 @@ func (h *Handler) GetProperty(c *gin.Context) {
 
 	if cachedProperty != nil {
 		log.Println("Cache HIT for property")
-		c.JSON(http.StatusOK, gin.H{
-			"data":   cachedProperty,
-			"cached": true,
-		})
+		h.recordView(c, cachedProperty.ID)
+		h.respondWithProperty(c, cachedProperty, include, true)
 		return
 	}
 
 	log.Println("Cache MISS for property, fetching from database")
 
-	// Fetch from database
-	property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+	// Concurrent misses for the same property share one database read
+	property, shared, err := h.propertyLoads.Do(strconv.FormatUint(propertyID, 10), func() (*models.Property, error) {
+		property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+		if err != nil {
+			return nil, err
+		}
+
+		// Cache the property for longer the less often it changes; the cache outlives the
+		// request that loaded it
+		ctx := context.WithoutCancel(ctx)
+		ttl, err := h.redis.PropertyCacheTTL(ctx, uint(propertyID))
+		if err != nil {
+			log.Printf("Failed to read volatility of property %d: %v", propertyID, err)
+		}
+		if err := h.redis.SetPropertyCache(ctx, uint(propertyID), property, ttl); err != nil {
+			log.Printf("Failed to cache property: %v", err)
+		}
+		return property, nil
+	})
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+			c.Error(apierror.NotFound("Property"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve property"})
+		c.Error(apierror.Internal("Failed to retrieve property"))
 		return
 	}
-
-	// Cache the property (1 hour TTL)
-	if err := h.redis.SetPropertyCache(ctx, uint(propertyID), property, 1*time.Hour); err != nil {
-		log.Printf("Failed to cache property: %v", err)
+	if shared {
+		// Localizing rewrites the name, description and house rules in place
+		copied := *property
+		property = &copied
 	}
+	h.recordView(c, property.ID)
+	h.respondWithProperty(c, property, include, false)
+}
 
-	c.JSON(http.StatusOK, gin.H{
+// respondWithProperty writes a localized property with the sections requested by include,
+// tagged with an ETag covering them
+func (h *Handler) respondWithProperty(c *gin.Context, property *models.Property, include map[string]bool, cached bool) {
+	locale := h.localizeProperty(c, property)
+	body := gin.H{
 		"data":   property,
-		"cached": false,
-	})
+		"locale": locale,
+		"cached": cached,
+	}
+	if !include["nearby"] {
+		respondWithETag(c, property, body)
+		return
+	}
+
+	nearby, err := h.nearbyPointsOfInterest(property)
+	if err != nil {
+		log.Printf("Failed to find points of interest near property %d: %v", property.ID, err)
+		c.Error(apierror.Internal("Failed to retrieve nearby points of interest"))
+		return
+	}
+	body["nearby"] = nearby
+	respondWithETag(c, gin.H{"data": property, "nearby": nearby}, body)
 }
 
 // GetPropertyAvailability retrieves availability for a property in a date range
 func (h *Handler) GetPropertyAvailability(c *gin.Context) {
-	ctx := c.Request.Context()
-
 	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid property ID"})
+		c.Error(apierror.InvalidID("property"))
 		return
 	}
 
@@ -184,14 +542,14 @@ func (h *Handler) GetPropertyAvailability(c *gin.Context) {
 	endDate := c.Query("end_date")
 
 	if startDate == "" || endDate == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date are required"})
+		c.Error(apierror.Validation("start_date and end_date are required"))
 		return
 	}
 
 	// Fetch from database
 	availabilities, err := h.availabilityRepo.GetAvailabilityForDateRange(uint(propertyID), startDate, endDate)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve availability"})
+		c.Error(apierror.Internal("Failed to retrieve availability"))
 		return
 	}
 
@@ -201,23 +559,49 @@ func (h *Handler) GetPropertyAvailability(c *gin.Context) {
 	})
 }
 
-// GetAmenities retrieves all amenities
+// GetAmenities retrieves a page of the amenities, named in the language of the
+// Accept-Language header where translated
 func (h *Handler) GetAmenities(c *gin.Context) {
-	ctx := c.Request.Context()
+	page := parsePage(c, 100, 500)
+	tag := h.referenceLocale(c)
+
+	amenities, err := h.amenityList(c.Request.Context(), tag)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve amenities"))
+		return
+	}
+
+	items := pageOf(amenities, page)
+	respondWithETag(c, items, paginated(c, items, int64(len(amenities)), page))
+}
+
+// GetConditions retrieves a page of the conditions, named in the language of the
+// Accept-Language header where translated
+func (h *Handler) GetConditions(c *gin.Context) {
+	page := parsePage(c, 100, 500)
+	tag := h.referenceLocale(c)
+
+	conditions, err := h.conditionList(c.Request.Context(), tag)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve conditions"))
+		return
+	}
+
+	items := pageOf(conditions, page)
+	respondWithETag(c, items, paginated(c, items, int64(len(conditions)), page))
+}
 
+// amenityList returns every amenity named in a locale, or as entered when the locale is
+// empty
+func (h *Handler) amenityList(ctx context.Context, tag string) ([]models.Amenity, error) {
 	// Try to get from cache
-	cachedAmenities, err := h.redis.GetAmenitiesCache(ctx)
+	cachedAmenities, err := h.redis.GetAmenitiesCache(ctx, tag)
 	if err != nil {
 		log.Printf("Cache retrieval error: %v", err)
 	}
-
 	if len(cachedAmenities) > 0 {
 		log.Println("Cache HIT for amenities")
-		c.JSON(http.StatusOK, gin.H{
-			"data":   cachedAmenities,
-			"cached": true,
-		})
-		return
+		return cachedAmenities, nil
 	}
 
 	log.Println("Cache MISS for amenities, fetching from database")
@@ -225,38 +609,38 @@ func (h *Handler) GetAmenities(c *gin.Context) {
 	// Fetch from database
 	amenities, err := h.amenityRepo.GetAllAmenities()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve amenities"})
-		return
+		return nil, err
+	}
+	if tag != "" {
+		names, err := h.translationRepo.GetReferenceNames(models.ReferenceAmenity, tag)
+		if err != nil {
+			return nil, err
+		}
+		for i := range amenities {
+			if name, ok := names[amenities[i].ID]; ok {
+				amenities[i].Name = name
+			}
+		}
 	}
 
 	// Cache amenities (24 hour TTL)
-	if err := h.redis.SetAmenitiesCache(ctx, amenities, 24*time.Hour); err != nil {
+	if err := h.redis.SetAmenitiesCache(ctx, tag, amenities, 24*time.Hour); err != nil {
 		log.Printf("Failed to cache amenities: %v", err)
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"data":   amenities,
-		"cached": false,
-	})
+	return amenities, nil
 }
 
-// GetConditions retrieves all conditions
-func (h *Handler) GetConditions(c *gin.Context) {
-	ctx := c.Request.Context()
-
+// conditionList returns every condition named in a locale, or as entered when the
+// locale is empty
+func (h *Handler) conditionList(ctx context.Context, tag string) ([]models.Condition, error) {
 	// Try to get from cache
-	cachedConditions, err := h.redis.GetConditionsCache(ctx)
+	cachedConditions, err := h.redis.GetConditionsCache(ctx, tag)
 	if err != nil {
 		log.Printf("Cache retrieval error: %v", err)
 	}
-
 	if len(cachedConditions) > 0 {
 		log.Println("Cache HIT for conditions")
-		c.JSON(http.StatusOK, gin.H{
-			"data":   cachedConditions,
-			"cached": true,
-		})
-		return
+		return cachedConditions, nil
 	}
 
 	log.Println("Cache MISS for conditions, fetching from database")
@@ -264,19 +648,25 @@ func (h *Handler) GetConditions(c *gin.Context) {
 	// Fetch from database
 	conditions, err := h.conditionRepo.GetAllConditions()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve conditions"})
-		return
+		return nil, err
+	}
+	if tag != "" {
+		names, err := h.translationRepo.GetReferenceNames(models.ReferenceCondition, tag)
+		if err != nil {
+			return nil, err
+		}
+		for i := range conditions {
+			if name, ok := names[conditions[i].ID]; ok {
+				conditions[i].Name = name
+			}
+		}
 	}
 
 	// Cache conditions (24 hour TTL)
-	if err := h.redis.SetConditionsCache(ctx, conditions, 24*time.Hour); err != nil {
+	if err := h.redis.SetConditionsCache(ctx, tag, conditions, 24*time.Hour); err != nil {
 		log.Printf("Failed to cache conditions: %v", err)
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"data":   conditions,
-		"cached": false,
-	})
+	return conditions, nil
 }
 
 // HealthCheck checks API health
@@ -305,34 +695,141 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 
 // HELPER METHODS
 
-// generateSearchCacheKey generates a cache key for search results
+// searchCacheFilter is the canonical form of the filters of a search, which equivalent
+// searches share whatever their formatting and the order of their IDs
+type searchCacheFilter struct {
+	Location          string   `json:"location,omitempty"`
+	City              string   `json:"city,omitempty"`
+	CheckinDate       string   `json:"checkin,omitempty"`
+	CheckoutDate      string   `json:"checkout,omitempty"`
+	NumberOfGuests    int      `json:"guests,omitempty"`
+	Children          int      `json:"children,omitempty"`
+	Infants           int      `json:"infants,omitempty"`
+	PetFriendly       bool     `json:"pets,omitempty"`
+	SmokingFriendly   bool     `json:"smoking,omitempty"`
+	StarRatings       []int64  `json:"stars,omitempty"`
+	AmenityIDs        []int64  `json:"amenities,omitempty"`
+	MatchAllAmenities bool     `json:"all_amenities,omitempty"`
+	ConditionIDs      []int64  `json:"conditions,omitempty"`
+	MinRating         float32  `json:"min_rating,omitempty"`
+	MinPrice          float64  `json:"min_price,omitempty"`
+	MaxPrice          float64  `json:"max_price,omitempty"`
+	Latitude          *float64 `json:"lat,omitempty"`
+	Longitude         *float64 `json:"lng,omitempty"`
+	RadiusKm          float64  `json:"radius,omitempty"`
+	FavoriteIDs       []int64  `json:"favorites,omitempty"`
+	SortBy            string   `json:"sort"`
+}
+
+// normalizeSearchFilter trims the searched location and city and truncates the stay
+// dates to calendar days, so searches differing only in those share results
+func normalizeSearchFilter(filter *models.SearchFilter) {
+	filter.Location = strings.TrimSpace(filter.Location)
+	filter.City = strings.TrimSpace(filter.City)
+	filter.CheckinDate = dateOnly(filter.CheckinDate)
+	filter.CheckoutDate = dateOnly(filter.CheckoutDate)
+}
+
+// dateOnly returns the calendar day of t at midnight UTC, or the zero time
+func dateOnly(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// generateSearchCacheKey generates the cache key of a search's result set from its
+// canonical filters: matching is case-insensitive, IDs are sorted and deduplicated,
+// and filters that cannot change the results are left out. Pagination is not part of
+// it; see searchWindow.
 func (h *Handler) generateSearchCacheKey(filter models.SearchFilter) string {
-	// Create a hash of the search parameters for the cache key
-	hash := md5.New()
-	hashStr := fmt.Sprintf(
-		"%s:%s:%s:%s:%d:%t:%t:%v:%v:%f:%f:%f:%f:%s:%d:%d",
-		filter.Location,
-		filter.City,
-		filter.CheckinDate.String(),
-		filter.CheckoutDate.String(),
-		filter.NumberOfGuests,
-		filter.PetFriendly,
-		filter.SmokingFriendly,
-		filter.AmenityIDs,
-		filter.ConditionIDs,
-		filter.MinRating,
-		filter.MaxPrice,
-		filter.MinPrice,
-		filter.RadiusKm,
-		filter.SortBy,
-		filter.Page,
-		filter.Limit,
-	)
-
-	hash.Write([]byte(hashStr))
-	hashHex := hex.EncodeToString(hash.Sum(nil))
-
-	return fmt.Sprintf("search:%s", hashHex)
+	canonical := searchCacheFilter{
+		Location:        strings.ToLower(filter.Location),
+		City:            strings.ToLower(filter.City),
+		NumberOfGuests:  filter.NumberOfGuests,
+		Children:        filter.Children,
+		Infants:         filter.Infants,
+		PetFriendly:     filter.PetFriendly != nil && *filter.PetFriendly,
+		SmokingFriendly: filter.SmokingFriendly != nil && *filter.SmokingFriendly,
+		StarRatings:     make([]int64, 0, len(filter.StarRatings)),
+		AmenityIDs:      sortedIDs(filter.AmenityIDs),
+		ConditionIDs:    sortedIDs(filter.ConditionIDs),
+		MinRating:       filter.MinRating,
+		MinPrice:        filter.MinPrice,
+		MaxPrice:        filter.MaxPrice,
+		Latitude:        filter.Latitude,
+		Longitude:       filter.Longitude,
+		RadiusKm:        filter.RadiusKm,
+		SortBy:          filter.SortBy,
+	}
+	if !filter.CheckinDate.IsZero() {
+		canonical.CheckinDate = filter.CheckinDate.Format("2006-01-02")
+	}
+	if !filter.CheckoutDate.IsZero() {
+		canonical.CheckoutDate = filter.CheckoutDate.Format("2006-01-02")
+	}
+	for _, stars := range filter.StarRatings {
+		canonical.StarRatings = append(canonical.StarRatings, int64(stars))
+	}
+	canonical.StarRatings = sortedIDs(canonical.StarRatings)
+	// Requiring all of a single amenity is requiring any of it
+	canonical.MatchAllAmenities = filter.MatchAllAmenities && len(canonical.AmenityIDs) > 1
+	if filter.OnlyFavorites {
+		favorites := make([]int64, 0, len(filter.FavoriteIDs))
+		for _, id := range filter.FavoriteIDs {
+			favorites = append(favorites, int64(id))
+		}
+		// Searching no favorites is kept apart from not searching favorites
+		canonical.FavoriteIDs = append(sortedIDs(favorites), 0)
+	}
+	if canonical.SortBy == "" {
+		canonical.SortBy = "rating"
+	}
+
+	data, _ := json.Marshal(canonical)
+	hash := md5.Sum(data)
+	return "search:" + hex.EncodeToString(hash[:])
+}
+
+// searchWindow returns the results to load and cache for a page of a search, and their
+// cache key. The first CachedPages pages are cached together under the result set's
+// key; later pages are cached one by one.
+func (h *Handler) searchWindow(setKey string, page Page) (Page, string) {
+	if page.Number <= h.search.CachedPages {
+		return Page{Number: 1, Limit: h.search.CachedPages * page.Limit}, setKey
+	}
+	return page, fmt.Sprintf("%s:page:%d:%d", setKey, page.Number, page.Limit)
+}
+
+// cachedPage slices a page out of the cached results of a search window. It returns
+// false when the page extends past the window while more results exist.
+func cachedPage(cached *models.SearchResultsCache, page Page) ([]models.SearchResult, bool) {
+	window := Page{Number: cached.Page, Limit: cached.Limit}
+	start := page.Offset() - window.Offset()
+	if start < 0 {
+		return nil, false
+	}
+	// A window holding fewer results than it could hold has every remaining result
+	if start+page.Limit > len(cached.Results) && len(cached.Results) >= window.Limit {
+		return nil, false
+	}
+	if start >= len(cached.Results) {
+		return []models.SearchResult{}, true
+	}
+	return cached.Results[start:min(start+page.Limit, len(cached.Results))], true
+}
+
+// sortedIDs returns the IDs sorted without duplicates
+func sortedIDs(ids []int64) []int64 {
+	sorted := append([]int64(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	unique := sorted[:0]
+	for i, id := range sorted {
+		if i == 0 || id != sorted[i-1] {
+			unique = append(unique, id)
+		}
+	}
+	return unique
 }
 
 // convertPropertiesToSearchResults converts Property models to SearchResult models
@@ -340,25 +837,37 @@ func (h *Handler) convertPropertiesToSearchResults(ctx context.Context, properti
 	results := make([]models.SearchResult, 0, len(properties))
 
 	for _, prop := range properties {
-		// Get pricing information for the date range
-		pricing, err := h.pricingRepo.GetPricingForDateRange(
-			prop.ID,
-			filter.CheckinDate.Format("2006-01-02"),
-			filter.CheckoutDate.Format("2006-01-02"),
-		)
-		if err != nil {
-			log.Printf("Failed to get pricing for property %d: %v", prop.ID, err)
-			continue
-		}
-
-		// Calculate total price
+		// Price the stay for the requested dates
 		totalPrice := 0.0
 		avgPrice := 0.0
-		if len(pricing) > 0 {
-			for _, p := range pricing {
-				totalPrice += p.TotalPrice
+		discount := 0.0
+		extraGuestFees := 0.0
+		var breakdown []models.PriceLineItem
+		if !filter.CheckinDate.IsZero() && filter.CheckoutDate.After(filter.CheckinDate) {
+			settings, err := h.propertySettings(ctx, &prop)
+			if err != nil {
+				log.Printf("Failed to get organization settings for property %d: %v", prop.ID, err)
+				continue
+			}
+			q, err := h.quoteEngine.Quote(quote.Request{
+				PropertyID:   prop.ID,
+				Property:     &prop,
+				CheckinDate:  filter.CheckinDate,
+				CheckoutDate: filter.CheckoutDate,
+				Guests:       filter.NumberOfGuests,
+				Children:     filter.Children,
+				Infants:      filter.Infants,
+				Settings:     settings,
+			})
+			if err != nil {
+				log.Printf("Failed to get pricing for property %d: %v", prop.ID, err)
+				continue
 			}
-			avgPrice = totalPrice / float64(len(pricing))
+			totalPrice = q.Total
+			avgPrice = q.AveragePerNight
+			discount = q.Discounts
+			extraGuestFees = q.ExtraGuestFees
+			breakdown = q.LineItems
 		}
 
 		// Extract amenity and condition names
@@ -372,6 +881,11 @@ func (h *Handler) convertPropertiesToSearchResults(ctx context.Context, properti
 			conditionNames = append(conditionNames, cond.Name)
 		}
 
+		stars := 0
+		if prop.StarRating != nil {
+			stars = prop.StarRating.Stars
+		}
+
 		// Calculate distance if coordinates provided
 		var distance *float64
 		if filter.Latitude != nil && filter.Longitude != nil {
@@ -379,25 +893,44 @@ func (h *Handler) convertPropertiesToSearchResults(ctx context.Context, properti
 			distance = &dist
 		}
 
+		// Score how closely the location or city matched the searched text
+		var matchScore *float64
+		if filter.Location != "" || filter.City != "" {
+			score := 0.0
+			if filter.Location != "" {
+				score = ranking.Similarity(filter.Location, prop.Location)
+			}
+			if filter.City != "" {
+				score = math.Max(score, ranking.Similarity(filter.City, prop.City))
+			}
+			matchScore = &score
+		}
+
 		result := models.SearchResult{
-			ID:            prop.ID,
-			Name:          prop.Name,
-			Description:   prop.Description,
-			Location:      prop.Location,
-			City:          prop.City,
-			State:         prop.State,
-			Country:       prop.Country,
-			Rating:        prop.Rating,
-			ReviewCount:   prop.ReviewCount,
-			MaxGuests:     prop.MaxGuests,
-			Bedrooms:      prop.Bedrooms,
-			Bathrooms:     prop.Bathrooms,
-			PricePerNight: avgPrice,
-			TotalPrice:    totalPrice,
-			Amenities:     amenityNames,
-			Conditions:    conditionNames,
-			Distance:      distance,
-			Available:     true, // Simplified, should check availability in real scenario
+			ID:             prop.ID,
+			Name:           prop.Name,
+			Description:    prop.Description,
+			Location:       prop.Location,
+			City:           prop.City,
+			State:          prop.State,
+			Country:        prop.Country,
+			Rating:         prop.Rating,
+			ReviewCount:    prop.ReviewCount,
+			Stars:          stars,
+			MaxGuests:      prop.MaxGuests,
+			Bedrooms:       prop.Bedrooms,
+			Bathrooms:      prop.Bathrooms,
+			PricePerNight:  avgPrice,
+			TotalPrice:     totalPrice,
+			Discount:       discount,
+			ExtraGuestFees: extraGuestFees,
+			PriceBreakdown: breakdown,
+			Amenities:      amenityNames,
+			Conditions:     conditionNames,
+			Distance:       distance,
+			Available:      true, // Simplified, should check availability in real scenario
+			Locale:         prop.DefaultLocale,
+			MatchScore:     matchScore,
 		}
 
 		results = append(results, result)
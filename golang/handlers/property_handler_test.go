@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"testing"
+
+	"channelmanager/loadtest"
+	"channelmanager/models"
+	"channelmanager/utils"
+)
+
+// benchmarkFilters returns n searches drawn like production traffic, with the
+// repeats of popular searches
+func benchmarkFilters(n int) []models.SearchFilter {
+	generator := loadtest.NewFilterGenerator(utils.SyntheticCities, 1000, 1)
+	filters := make([]models.SearchFilter, n)
+	for i := range filters {
+		filters[i] = generator.Next()
+	}
+	return filters
+}
+
+func BenchmarkNormalizeSearchFilter(b *testing.B) {
+	filters := benchmarkFilters(1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		filter := filters[i%len(filters)]
+		normalizeSearchFilter(&filter)
+	}
+}
+
+func BenchmarkGenerateSearchCacheKey(b *testing.B) {
+	filters := benchmarkFilters(1024)
+	for i := range filters {
+		normalizeSearchFilter(&filters[i])
+	}
+	h := &Handler{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.generateSearchCacheKey(filters[i%len(filters)])
+	}
+}
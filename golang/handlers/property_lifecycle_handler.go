@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// lifecyclePushDays is how far ahead channels are told a property opened or closed
+const lifecyclePushDays = 365
+
+// PropertyTransitionRequest represents the optional payload of a property status change
+type PropertyTransitionRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// SubmitProperty sends a draft property for review before it is listed
+func (h *Handler) SubmitProperty(c *gin.Context) {
+	h.transitionProperty(c, models.PropertyStatusPendingReview)
+}
+
+// ApproveProperty lists a property pending review, making it searchable and opening its
+// inventory on its channels
+func (h *Handler) ApproveProperty(c *gin.Context) {
+	h.transitionProperty(c, models.PropertyStatusActive)
+}
+
+// RejectProperty sends a property pending review back to its host as a draft; the reason
+// tells the host what to change
+func (h *Handler) RejectProperty(c *gin.Context) {
+	h.transitionProperty(c, models.PropertyStatusDraft)
+}
+
+// SuspendProperty takes an active property down, closing its inventory on its channels.
+// Its bookings are kept.
+func (h *Handler) SuspendProperty(c *gin.Context) {
+	h.transitionProperty(c, models.PropertyStatusSuspended)
+}
+
+// ReactivateProperty lists a suspended property again
+func (h *Handler) ReactivateProperty(c *gin.Context) {
+	h.transitionProperty(c, models.PropertyStatusActive)
+}
+
+// ArchiveProperty retires a property, closing its inventory on its channels
+func (h *Handler) ArchiveProperty(c *gin.Context) {
+	h.transitionProperty(c, models.PropertyStatusArchived)
+}
+
+// UnarchiveProperty brings an archived property back as a draft, to be submitted again
+func (h *Handler) UnarchiveProperty(c *gin.Context) {
+	h.transitionProperty(c, models.PropertyStatusDraft)
+}
+
+// transitionProperty moves a property to another lifecycle status. When it is listed or
+// delisted, its channels are sent the coming year of inventory, closed unless it is
+// active; a failed push is reported as a warning with 202 Accepted.
+func (h *Handler) transitionProperty(c *gin.Context, to string) {
+	ctx := c.Request.Context()
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var req PropertyTransitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+	if !models.CanTransitionProperty(property.Status, to) {
+		c.Error(apierror.InvalidState(fmt.Sprintf("A %s property cannot become %s", property.Status, to)))
+		return
+	}
+
+	wasActive := property.IsActive()
+	if err := h.propertyRepo.TransitionProperty(property, to, req.Reason, time.Now()); err != nil {
+		if errors.Is(err, database.ErrPropertyStatusChanged) {
+			c.Error(apierror.InvalidState("Property status changed meanwhile"))
+			return
+		}
+		log.Printf("Failed to update property %d to %s: %v", property.ID, to, err)
+		c.Error(apierror.Internal("Failed to update property"))
+		return
+	}
+
+	if err := h.redis.InvalidatePropertyCache(ctx, property.ID); err != nil {
+		log.Printf("Failed to invalidate property cache: %v", err)
+	}
+	h.invalidateSearchCache(ctx)
+
+	if wasActive != property.IsActive() {
+		start := property.Today()
+		if err := h.ariPush.PushProperty(ctx, property.ID, start, start.AddDate(0, 0, lifecyclePushDays)); err != nil {
+			c.JSON(http.StatusAccepted, gin.H{"data": property, "warning": "Status changed but the ARI push to channels failed"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": property})
+}
@@ -0,0 +1,380 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/fx"
+	"channelmanager/models"
+	"channelmanager/quote"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DiscountTierRequest represents a single length-of-stay discount tier
+type DiscountTierRequest struct {
+	MinNights int     `json:"min_nights" binding:"required,min=2"`
+	Percent   float64 `json:"percent" binding:"required,gt=0,lt=100"`
+	Name      string  `json:"name" binding:"max=100"`
+}
+
+// UpdateDiscountsRequest represents the payload replacing a property's discount tiers
+type UpdateDiscountsRequest struct {
+	Tiers []DiscountTierRequest `json:"tiers" binding:"max=20,dive"`
+}
+
+// FeeRequest represents a single fee charged on a property's stays
+type FeeRequest struct {
+	Name      string  `json:"name" binding:"required,max=100"`
+	Type      string  `json:"type" binding:"required,oneof=cleaning resort pet service other"`
+	Amount    float64 `json:"amount" binding:"required,gt=0"`
+	Basis     string  `json:"basis" binding:"required,oneof=per_stay per_night per_guest per_guest_per_night"`
+	Taxable   bool    `json:"taxable"`
+	Mandatory *bool   `json:"mandatory" binding:"required"`
+}
+
+// UpdateFeesRequest represents the payload replacing a property's fees
+type UpdateFeesRequest struct {
+	Fees []FeeRequest `json:"fees" binding:"max=20,dive"`
+}
+
+// GetPropertyQuote prices a stay with its itemized breakdown
+func (h *Handler) GetPropertyQuote(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	checkin, err := time.Parse("2006-01-02", c.Query("checkin_date"))
+	if err != nil {
+		c.Error(apierror.InvalidDate("checkin_date is required in YYYY-MM-DD format"))
+		return
+	}
+	checkout, err := time.Parse("2006-01-02", c.Query("checkout_date"))
+	if err != nil {
+		c.Error(apierror.InvalidDate("checkout_date is required in YYYY-MM-DD format"))
+		return
+	}
+	if !checkout.After(checkin) {
+		c.Error(apierror.InvalidDateRange("checkout_date must be after checkin_date"))
+		return
+	}
+
+	guests, _ := strconv.Atoi(c.DefaultQuery("guests", "1"))
+	children, _ := strconv.Atoi(c.DefaultQuery("children", "0"))
+	infants, _ := strconv.Atoi(c.DefaultQuery("infants", "0"))
+	if children < 0 || infants < 0 || children+infants > guests {
+		c.Error(apierror.InvalidField("children", "ltefield", "children and infants must be counted in guests"))
+		return
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+	if !property.IsActive() {
+		c.Error(apierror.InvalidState("The property is not accepting bookings"))
+		return
+	}
+	if apiErr := bookingWindowError(property, checkin); apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+	if apiErr, err := h.minStayError(property.ID, checkin, checkout); err != nil {
+		c.Error(apierror.Internal("Failed to retrieve availability"))
+		return
+	} else if apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+
+	settings, err := h.propertySettings(c.Request.Context(), property)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve organization settings"))
+		return
+	}
+
+	q, err := h.quoteEngine.Quote(quote.Request{
+		PropertyID:   uint(propertyID),
+		Property:     property,
+		CheckinDate:  checkin,
+		CheckoutDate: checkout,
+		Guests:       guests,
+		Children:     children,
+		Infants:      infants,
+		Settings:     settings,
+	})
+	if errors.Is(err, fx.ErrNoRate) {
+		c.Error(apierror.Unprocessable(fmt.Sprintf("No exchange rate is known for %s", settings.DefaultCurrency)))
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to compute quote: %v", err)
+		c.Error(apierror.Internal("Failed to compute quote"))
+		return
+	}
+
+	// Loyalty credit is quoted for the guest named in guest_email
+	if raw := c.Query("redeem_points"); raw != "" {
+		points, err := strconv.Atoi(raw)
+		if err != nil || points < 1 {
+			c.Error(apierror.InvalidField("redeem_points", "min", "redeem_points must be a positive number of points"))
+			return
+		}
+		if !h.redeemPoints(c, q, strings.TrimSpace(c.Query("guest_email")), points) {
+			return
+		}
+	}
+	if codes := c.QueryArray("voucher_code"); len(codes) > 0 && !h.applyVouchers(c, q, codes) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     q,
+		"bookable": q.Complete(),
+	})
+}
+
+// GetPropertyDiscounts retrieves a property's length-of-stay discount tiers
+func (h *Handler) GetPropertyDiscounts(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	discounts, err := h.discountRepo.GetDiscountsForProperty(uint(propertyID))
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve discounts"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": propertyID,
+		"data":        discounts,
+	})
+}
+
+// UpdatePropertyDiscounts replaces a property's length-of-stay discount tiers
+func (h *Handler) UpdatePropertyDiscounts(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var req UpdateDiscountsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	seen := make(map[int]bool)
+	discounts := make([]models.LengthOfStayDiscount, 0, len(req.Tiers))
+	for i, tier := range req.Tiers {
+		if seen[tier.MinNights] {
+			c.Error(apierror.InvalidField("tiers["+strconv.Itoa(i)+"].min_nights", "unique", "duplicates another tier"))
+			return
+		}
+		seen[tier.MinNights] = true
+		discounts = append(discounts, models.LengthOfStayDiscount{
+			MinNights: tier.MinNights,
+			Percent:   tier.Percent,
+			Name:      tier.Name,
+		})
+	}
+
+	if _, err := h.propertyRepo.GetPropertyByID(uint(propertyID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	if err := h.discountRepo.ReplaceDiscountsForProperty(uint(propertyID), discounts); err != nil {
+		c.Error(apierror.Internal("Failed to update discounts"))
+		return
+	}
+
+	// Search totals include discounts
+	h.invalidateSearchCache(c.Request.Context())
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": propertyID,
+		"data":        discounts,
+	})
+}
+
+// GetPropertyFees retrieves the fees charged on a property's stays
+func (h *Handler) GetPropertyFees(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	fees, err := h.feeRepo.GetFeesForProperty(uint(propertyID))
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve fees"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": propertyID,
+		"data":        fees,
+	})
+}
+
+// UpdatePropertyFees replaces the fees charged on a property's stays
+func (h *Handler) UpdatePropertyFees(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var req UpdateFeesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	fees := make([]models.Fee, 0, len(req.Fees))
+	for _, fee := range req.Fees {
+		fees = append(fees, models.Fee{
+			Name:      fee.Name,
+			Type:      fee.Type,
+			Amount:    fee.Amount,
+			Basis:     fee.Basis,
+			Taxable:   fee.Taxable,
+			Mandatory: *fee.Mandatory,
+		})
+	}
+
+	if _, err := h.propertyRepo.GetPropertyByID(uint(propertyID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	if err := h.feeRepo.ReplaceFeesForProperty(uint(propertyID), fees); err != nil {
+		c.Error(apierror.Internal("Failed to update fees"))
+		return
+	}
+
+	// Search totals include mandatory fees
+	h.invalidateSearchCache(c.Request.Context())
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": propertyID,
+		"data":        fees,
+	})
+}
+
+// OccupancyPricingRequest represents the payload setting a property's extra guest fees
+type OccupancyPricingRequest struct {
+	BaseOccupancy int     `json:"base_occupancy" binding:"min=0,max=50"` // 0 includes every guest in the rate
+	ExtraAdultFee float64 `json:"extra_adult_fee" binding:"min=0"`
+	ExtraChildFee float64 `json:"extra_child_fee" binding:"min=0"`
+}
+
+// GetPropertyOccupancyPricing retrieves a property's base occupancy and extra guest fees
+func (h *Handler) GetPropertyOccupancyPricing(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": property.ID,
+		"data":        property.OccupancyPricing,
+	})
+}
+
+// UpdatePropertyOccupancyPricing sets a property's base occupancy and the nightly fees of
+// each adult and child beyond it; infants are always free
+func (h *Handler) UpdatePropertyOccupancyPricing(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var req OccupancyPricingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+	if property.MaxGuests > 0 && req.BaseOccupancy > property.MaxGuests {
+		c.Error(apierror.InvalidField("base_occupancy", "max", "exceeds the property's maximum of guests"))
+		return
+	}
+
+	pricing := models.OccupancyPricing{
+		BaseOccupancy: req.BaseOccupancy,
+		ExtraAdultFee: req.ExtraAdultFee,
+		ExtraChildFee: req.ExtraChildFee,
+	}
+	if err := h.propertyRepo.UpdateOccupancyPricing(property.ID, pricing); err != nil {
+		c.Error(apierror.Internal("Failed to update occupancy pricing"))
+		return
+	}
+
+	if err := h.redis.InvalidatePropertyCache(c.Request.Context(), property.ID); err != nil {
+		log.Printf("Failed to invalidate property cache: %v", err)
+	}
+	// Search totals include extra guest fees
+	h.invalidateSearchCache(c.Request.Context())
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": property.ID,
+		"data":        pricing,
+	})
+}
+
+// invalidateSearchCache drops cached search results after a pricing rule change
+func (h *Handler) invalidateSearchCache(ctx context.Context) {
+	if err := h.redis.InvalidateSearchCache(ctx, "", ""); err != nil {
+		log.Printf("Failed to invalidate search cache: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"channelmanager/apierror"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxSimilarProperties is the number of similar properties cached and the largest limit
+const maxSimilarProperties = 20
+
+// GetSimilarProperties recommends properties near a property with overlapping amenities,
+// similar capacity and comparable price, for cross-selling on detail pages
+func (h *Handler) GetSimilarProperties(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "6"))
+	if err != nil || limit < 1 || limit > maxSimilarProperties {
+		c.Error(apierror.InvalidField("limit", "range", "limit must be between 1 and "+strconv.Itoa(maxSimilarProperties)))
+		return
+	}
+
+	similar, cached, err := h.redis.GetSimilarCache(ctx, uint(propertyID))
+	if err != nil {
+		log.Printf("Cache retrieval error: %v", err)
+	}
+	if !cached {
+		if _, err := h.propertyRepo.GetPropertyByID(uint(propertyID)); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.Error(apierror.NotFound("Property"))
+				return
+			}
+			c.Error(apierror.Internal("Failed to retrieve property"))
+			return
+		}
+
+		similar, err = h.propertyRepo.SimilarProperties(uint(propertyID), h.search.SimilarRadiusKm, maxSimilarProperties)
+		if err != nil {
+			log.Printf("Failed to score properties similar to %d: %v", propertyID, err)
+			c.Error(apierror.Internal("Failed to retrieve similar properties"))
+			return
+		}
+		if err := h.redis.SetSimilarCache(ctx, uint(propertyID), similar, h.search.SimilarTTL); err != nil {
+			log.Printf("Failed to cache similar properties: %v", err)
+		}
+	}
+	if len(similar) > limit {
+		similar = similar[:limit]
+	}
+
+	data := []models.SimilarProperty{}
+	if len(similar) > 0 {
+		ids := make([]uint, len(similar))
+		for i, entry := range similar {
+			ids[i] = entry.PropertyID
+		}
+		properties, err := h.propertyRepo.GetPropertiesWithContent(ids)
+		if err != nil {
+			c.Error(apierror.Internal("Failed to retrieve similar properties"))
+			return
+		}
+		byID := make(map[uint]*models.Property, len(properties))
+		for i := range properties {
+			byID[properties[i].ID] = &properties[i]
+		}
+		// Properties deleted since the list was cached are left out
+		for _, entry := range similar {
+			if property, ok := byID[entry.PropertyID]; ok {
+				entry.Property = property
+				data = append(data, entry)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": propertyID,
+		"data":        data,
+		"cached":      cached,
+	})
+}
+
+// GetRecentlyViewed lists the properties the visitor's session viewed last, newest first.
+// A request without a session token gets an empty list.
+func (h *Handler) GetRecentlyViewed(c *gin.Context) {
+	properties := []models.Property{}
+	session := sessionToken(c)
+	if session == "" {
+		c.JSON(http.StatusOK, gin.H{"data": properties})
+		return
+	}
+
+	ids, err := h.redis.GetRecentlyViewed(c.Request.Context(), session)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve recently viewed properties"))
+		return
+	}
+	if len(ids) > 0 {
+		found, err := h.propertyRepo.GetPropertiesWithContent(ids)
+		if err != nil {
+			c.Error(apierror.Internal("Failed to retrieve recently viewed properties"))
+			return
+		}
+		byID := make(map[uint]models.Property, len(found))
+		for _, property := range found {
+			byID[property.ID] = property
+		}
+		// Properties deleted since they were viewed are left out
+		for _, id := range ids {
+			if property, ok := byID[id]; ok {
+				properties = append(properties, property)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": properties})
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"channelmanager/apierror"
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRateParityReport lists rate parity violations filtered by channel, property and severity
+func (h *Handler) GetRateParityReport(c *gin.Context) {
+	page := parsePage(c, 50, 200)
+
+	severity := c.Query("severity")
+	switch severity {
+	case "", models.ParitySeverityMinor, models.ParitySeverityMajor, models.ParitySeverityCritical:
+	default:
+		c.Error(apierror.Validation("severity must be minor, major or critical"))
+		return
+	}
+
+	filter := database.ParityViolationFilter{
+		ChannelID:       c.Query("channel_id"),
+		Severity:        severity,
+		IncludeResolved: c.Query("include_resolved") == "true",
+		Limit:           page.Limit,
+		Offset:          page.Offset(),
+	}
+	if param := c.Query("property_id"); param != "" {
+		id, err := strconv.ParseUint(param, 10, 32)
+		if err != nil {
+			c.Error(apierror.InvalidID("property"))
+			return
+		}
+		filter.PropertyID = uint(id)
+	}
+
+	violations, total, err := h.parityRepo.ListViolations(filter)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve rate parity report"))
+		return
+	}
+
+	c.JSON(http.StatusOK, paginated(c, violations, total, page))
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSearchRadiusKm is the widest radius a relaxed search looks within, the largest a
+// search may send
+const maxSearchRadiusKm = 500
+
+// relaxedRadiusFactor is how much a relaxed search widens the radius sent
+const relaxedRadiusFactor = 3
+
+// flexDateShifts are the days a relaxed search moves the stay by, nearest first
+var flexDateShifts = []int{1, -1, 2, -2, 3, -3}
+
+// filterDrop loosens one optional filter of a search, reporting what it changed or
+// nothing when the search does not use the filter
+type filterDrop func(filter *models.SearchFilter) []models.SearchRelaxation
+
+// filterDrops are the optional filters a relaxed search drops one at a time, the ones
+// guests give up most readily first. Location, guests and favorites are never dropped.
+var filterDrops = []filterDrop{
+	func(filter *models.SearchFilter) []models.SearchRelaxation {
+		if len(filter.StarRatings) == 0 {
+			return nil
+		}
+		change := models.SearchRelaxation{Filter: "star_ratings", From: filter.StarRatings}
+		filter.StarRatings = nil
+		return []models.SearchRelaxation{change}
+	},
+	func(filter *models.SearchFilter) []models.SearchRelaxation {
+		if filter.MinRating <= 0 {
+			return nil
+		}
+		change := models.SearchRelaxation{Filter: "min_rating", From: filter.MinRating}
+		filter.MinRating = 0
+		return []models.SearchRelaxation{change}
+	},
+	func(filter *models.SearchFilter) []models.SearchRelaxation {
+		if !filter.MatchAllAmenities || len(filter.AmenityIDs) < 2 {
+			return nil
+		}
+		filter.MatchAllAmenities = false
+		return []models.SearchRelaxation{{Filter: "match_all_amenities", From: true, To: false}}
+	},
+	func(filter *models.SearchFilter) []models.SearchRelaxation {
+		if len(filter.AmenityIDs) == 0 {
+			return nil
+		}
+		change := models.SearchRelaxation{Filter: "amenity_ids", From: filter.AmenityIDs}
+		filter.AmenityIDs, filter.MatchAllAmenities = nil, false
+		return []models.SearchRelaxation{change}
+	},
+	func(filter *models.SearchFilter) []models.SearchRelaxation {
+		if len(filter.ConditionIDs) == 0 {
+			return nil
+		}
+		change := models.SearchRelaxation{Filter: "condition_ids", From: filter.ConditionIDs}
+		filter.ConditionIDs = nil
+		return []models.SearchRelaxation{change}
+	},
+	func(filter *models.SearchFilter) []models.SearchRelaxation {
+		if filter.SmokingFriendly == nil {
+			return nil
+		}
+		change := models.SearchRelaxation{Filter: "smoking_friendly", From: *filter.SmokingFriendly}
+		filter.SmokingFriendly = nil
+		return []models.SearchRelaxation{change}
+	},
+	func(filter *models.SearchFilter) []models.SearchRelaxation {
+		if filter.MinPrice <= 0 && filter.MaxPrice <= 0 {
+			return nil
+		}
+		var changes []models.SearchRelaxation
+		if filter.MinPrice > 0 {
+			changes = append(changes, models.SearchRelaxation{Filter: "min_price", From: filter.MinPrice})
+		}
+		if filter.MaxPrice > 0 {
+			changes = append(changes, models.SearchRelaxation{Filter: "max_price", From: filter.MaxPrice})
+		}
+		filter.MinPrice, filter.MaxPrice = 0, 0
+		return changes
+	},
+	func(filter *models.SearchFilter) []models.SearchRelaxation {
+		if filter.PetFriendly == nil {
+			return nil
+		}
+		change := models.SearchRelaxation{Filter: "pet_friendly", From: *filter.PetFriendly}
+		filter.PetFriendly = nil
+		return []models.SearchRelaxation{change}
+	},
+}
+
+// relaxedMatches returns the first page of alternatives to a search without results, from
+// cache when possible: gin.H with the results shaped like the search's, their total and
+// the filters loosened to find them
+func (h *Handler) relaxedMatches(c *gin.Context, filter models.SearchFilter, setKey string, fields map[string]bool, track bool) gin.H {
+	ctx := c.Request.Context()
+	page := Page{Number: 1, Limit: filter.Limit}
+	key := setKey + ":relaxed"
+
+	cached, err := h.redis.GetSearchResultsCache(ctx, key)
+	if err != nil {
+		log.Printf("Cache retrieval error: %v", err)
+	}
+	results, ok := []models.SearchResult(nil), false
+	if cached != nil {
+		results, ok = cachedPage(cached, page)
+	}
+	if !ok {
+		if cached, err = h.relaxSearch(ctx, filter); err != nil {
+			log.Printf("Failed to relax search: %v", err)
+			return nil
+		}
+		if err := h.redis.SetSearchResultsCache(ctx, key, cached, 5*time.Minute); err != nil {
+			log.Printf("Failed to cache relaxed search results: %v", err)
+		}
+		results = cached.Results
+	}
+
+	if track {
+		h.recordImpressions(ctx, results)
+	}
+	h.localizeSearchResults(c, results)
+	data, truncated := h.shapeSearchResults(results, fields)
+	relaxed := cached.Relaxed
+	if relaxed == nil {
+		relaxed = []models.SearchRelaxation{}
+	}
+	return gin.H{
+		"data":      data,
+		"total":     cached.Total,
+		"relaxed":   relaxed,
+		"truncated": truncated,
+	}
+}
+
+// relaxSearch loosens a search without results until it finds some: it widens the
+// radius, then moves the stay by up to three days with the other filters as sent, then
+// drops optional filters one at a time, keeping each step's loosened filters. It returns
+// the first page found, empty when even the loosest search finds nothing.
+func (h *Handler) relaxSearch(ctx context.Context, filter models.SearchFilter) (*models.SearchResultsCache, error) {
+	filter.Page = 1
+	relaxed := []models.SearchRelaxation{}
+	found := func(candidate models.SearchFilter, changes []models.SearchRelaxation) (*models.SearchResultsCache, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		results, total, err := h.searchPage(ctx, candidate)
+		if err != nil || total == 0 {
+			return nil, err
+		}
+		return &models.SearchResultsCache{
+			Results: results,
+			Total:   int(total),
+			Page:    1,
+			Limit:   candidate.Limit,
+			Relaxed: changes,
+		}, nil
+	}
+
+	if filter.Latitude != nil && filter.Longitude != nil && filter.RadiusKm > 0 && filter.RadiusKm < maxSearchRadiusKm {
+		radius := min(filter.RadiusKm*relaxedRadiusFactor, maxSearchRadiusKm)
+		relaxed = append(relaxed, models.SearchRelaxation{Filter: "radius_km", From: filter.RadiusKm, To: radius})
+		filter.RadiusKm = radius
+		if result, err := found(filter, relaxed); err != nil || result != nil {
+			return result, err
+		}
+	}
+
+	if filter.Nights() > 0 {
+		earliest := models.EarliestToday()
+		for _, shift := range flexDateShifts {
+			shifted := filter
+			shifted.CheckinDate = filter.CheckinDate.AddDate(0, 0, shift)
+			shifted.CheckoutDate = filter.CheckoutDate.AddDate(0, 0, shift)
+			if shifted.CheckinDate.Before(earliest) {
+				continue
+			}
+			changes := append(relaxed[:len(relaxed):len(relaxed)],
+				models.SearchRelaxation{Filter: "checkin_date", From: filter.CheckinDate, To: shifted.CheckinDate},
+				models.SearchRelaxation{Filter: "checkout_date", From: filter.CheckoutDate, To: shifted.CheckoutDate})
+			if result, err := found(shifted, changes); err != nil || result != nil {
+				return result, err
+			}
+		}
+	}
+
+	for _, drop := range filterDrops {
+		changes := drop(&filter)
+		if len(changes) == 0 {
+			continue
+		}
+		relaxed = append(relaxed, changes...)
+		if result, err := found(filter, relaxed); err != nil || result != nil {
+			return result, err
+		}
+	}
+
+	return &models.SearchResultsCache{Results: []models.SearchResult{}, Page: 1, Limit: filter.Limit}, nil
+}
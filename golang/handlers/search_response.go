@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/models"
+	"channelmanager/ranking"
+	"channelmanager/weather"
+)
+
+// SearchConfig holds payload size limits and ranking settings for search, and the
+// settings of trending, similar and recently viewed properties and of nearby points
+// of interest
+type SearchConfig struct {
+	MaxPageSize      int // largest accepted limit
+	MaxResponseBytes int // results beyond this encoded size are dropped from the page
+
+	// sort_by=relevance ranks up to MaxRankedCandidates matches with these weights
+	Relevance           ranking.Weights
+	MaxRankedCandidates int
+
+	// The first CachedPages pages of a search are loaded and cached together, so paging
+	// through them is served from the cache; 0 caches every page on its own
+	CachedPages int
+
+	// Minimum trigram similarity for a location or city to match; 0 disables fuzzy matching
+	LocationSimilarity float64
+
+	// Trending properties are scored on the views and bookings of the last
+	// TrendingWindowDays, a booking weighing TrendingBookingWeight views and activity
+	// losing half its weight every TrendingHalfLifeDays; rankings are cached for TrendingTTL
+	TrendingWindowDays    int
+	TrendingHalfLifeDays  float64
+	TrendingBookingWeight float64
+	TrendingTTL           time.Duration
+
+	// Similar properties are looked for within SimilarRadiusKm and cached for SimilarTTL
+	SimilarRadiusKm float64
+	SimilarTTL      time.Duration
+
+	// include=nearby lists the NearbyPerType closest points of interest of each type
+	// within NearbyRadiusKm of a property
+	NearbyRadiusKm float64
+	NearbyPerType  int
+
+	// Sessions remember their RecentlyViewedMax latest viewed properties for
+	// RecentlyViewedRetention after their last view
+	RecentlyViewedMax       int
+	RecentlyViewedRetention time.Duration
+}
+
+// searchResultFields lists the JSON keys of SearchResult accepted by the fields parameter
+var searchResultFields = jsonFieldNames(reflect.TypeOf(models.SearchResult{}))
+
+// parseSearchFields parses a comma-separated fields parameter; the id is always included.
+// It returns nil when every field is requested.
+func parseSearchFields(param string) (map[string]bool, *apierror.APIError) {
+	if strings.TrimSpace(param) == "" {
+		return nil, nil
+	}
+
+	fields := map[string]bool{"id": true}
+	for _, field := range strings.Split(param, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !searchResultFields[field] {
+			return nil, apierror.InvalidField("fields", "oneof", "unknown field "+field)
+		}
+		fields[field] = true
+	}
+	return fields, nil
+}
+
+// parseInclude parses a comma-separated include parameter naming optional sections of a
+// response
+func parseInclude(param string, allowed ...string) (map[string]bool, *apierror.APIError) {
+	include := make(map[string]bool)
+	for _, section := range strings.Split(param, ",") {
+		section = strings.TrimSpace(section)
+		if section == "" {
+			continue
+		}
+		known := false
+		for _, name := range allowed {
+			known = known || section == name
+		}
+		if !known {
+			return nil, apierror.InvalidField("include", "oneof", "unknown section "+section+", expected one of "+strings.Join(allowed, ", "))
+		}
+		include[section] = true
+	}
+	return include, nil
+}
+
+// searchWeather returns the forecasts of the cities of a page of results; it is empty
+// when the weather integration is disabled
+func (h *Handler) searchWeather(ctx context.Context, results []models.SearchResult) []models.WeatherForecast {
+	if h.weather == nil {
+		return []models.WeatherForecast{}
+	}
+	locations := make([]weather.Location, len(results))
+	for i, result := range results {
+		locations[i] = weather.Location{City: result.City, Country: result.Country}
+	}
+	return h.weather.Forecasts(ctx, locations)
+}
+
+// shapeSearchResults applies sparse field selection and the response size limit to a
+// page of results. It reports whether results were dropped to respect the limit.
+func (h *Handler) shapeSearchResults(results []models.SearchResult, fields map[string]bool) (interface{}, bool) {
+	if fields == nil && h.search.MaxResponseBytes <= 0 {
+		return results, false
+	}
+
+	shaped := make([]json.RawMessage, 0, len(results))
+	size := 0
+	for _, result := range results {
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			log.Printf("Failed to encode search result %d: %v", result.ID, err)
+			continue
+		}
+
+		if fields != nil {
+			var all map[string]json.RawMessage
+			if err := json.Unmarshal(encoded, &all); err != nil {
+				continue
+			}
+			selected := make(map[string]json.RawMessage, len(fields))
+			for field := range fields {
+				if value, ok := all[field]; ok {
+					selected[field] = value
+				}
+			}
+			if encoded, err = json.Marshal(selected); err != nil {
+				continue
+			}
+		}
+
+		// Always return at least one result so clients can make progress
+		if h.search.MaxResponseBytes > 0 && size+len(encoded) > h.search.MaxResponseBytes && len(shaped) > 0 {
+			return shaped, true
+		}
+		size += len(encoded)
+		shaped = append(shaped, encoded)
+	}
+	return shaped, false
+}
+
+// jsonFieldNames returns the JSON keys of a struct type
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.SplitN(t.Field(i).Tag.Get("json"), ",", 2)[0]
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// rankSearchResults scores results by relevance and sorts them best first. Prices are
+// the nightly price of the requested stay, or the base nightly rate without dates.
+func (h *Handler) rankSearchResults(results []models.SearchResult, baseRates map[uint]float64) {
+	candidates := make([]ranking.Candidate, len(results))
+	for i, result := range results {
+		price := result.PricePerNight
+		if price <= 0 {
+			price = baseRates[result.ID]
+		}
+		candidates[i] = ranking.Candidate{
+			ID:          result.ID,
+			Rating:      float64(result.Rating),
+			ReviewCount: result.ReviewCount,
+			Price:       price,
+			Distance:    result.Distance,
+		}
+	}
+
+	// Ties are broken on the scores as reported
+	scores := ranking.Scores(candidates, h.search.Relevance)
+	for i := range scores {
+		scores[i] = math.Round(scores[i]*10000) / 10000
+	}
+
+	ranked := make([]models.SearchResult, len(results))
+	for i, index := range ranking.Order(candidates, scores) {
+		ranked[i] = results[index]
+		ranked[i].RelevanceScore = &scores[index]
+	}
+	copy(results, ranked)
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"math/rand"
+	"testing"
+
+	"channelmanager/models"
+	"channelmanager/ranking"
+)
+
+func BenchmarkRankSearchResults(b *testing.B) {
+	random := rand.New(rand.NewSource(1))
+	results := make([]models.SearchResult, 500)
+	baseRates := make(map[uint]float64, len(results))
+	for i := range results {
+		distance := random.Float64() * 20
+		results[i] = models.SearchResult{
+			ID:          uint(i + 1),
+			Rating:      float32(random.Intn(21)+30) / 10,
+			ReviewCount: random.Intn(400),
+			Distance:    &distance,
+		}
+		if i%4 != 0 {
+			results[i].PricePerNight = float64(80 + random.Intn(400))
+		}
+		baseRates[results[i].ID] = float64(100 + random.Intn(300))
+	}
+	h := &Handler{search: SearchConfig{Relevance: ranking.Weights{Rating: 0.4, Reviews: 0.2, Price: 0.25, Distance: 0.15}}}
+
+	ranked := make([]models.SearchResult, len(results))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(ranked, results)
+		h.rankSearchResults(ranked, baseRates)
+	}
+}
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SeasonRequest represents the payload for creating or updating a season
+type SeasonRequest struct {
+	Name       string  `json:"name" binding:"required,max=100"`
+	StartDate  string  `json:"start_date" binding:"required,datetime=2006-01-02"`
+	EndDate    string  `json:"end_date" binding:"required,datetime=2006-01-02"`
+	Multiplier float64 `json:"multiplier" binding:"required,gt=0,lte=10"`
+	Priority   int     `json:"priority" binding:"min=0,max=100"`
+}
+
+// PropertyRatesRequest represents the payload for updating a property's default rates
+type PropertyRatesRequest struct {
+	BaseNightlyRate   float64 `json:"base_nightly_rate" binding:"required,gt=0"`
+	WeekendMultiplier float64 `json:"weekend_multiplier" binding:"omitempty,gt=0,lte=10"`
+	MaterializeDays   int     `json:"materialize_days" binding:"omitempty,min=1,max=730"` // nights from today to regenerate, default 365
+}
+
+// MaterializePricingRequest represents the payload for regenerating pricing over a range
+type MaterializePricingRequest struct {
+	StartDate string `json:"start_date" binding:"required,datetime=2006-01-02"`
+	EndDate   string `json:"end_date" binding:"required,datetime=2006-01-02"`
+}
+
+// ListSeasons retrieves the seasons of a property
+func (h *Handler) ListSeasons(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	seasons, err := h.seasonRepo.GetSeasonsForProperty(uint(propertyID))
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve seasons"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": propertyID,
+		"data":        seasons,
+	})
+}
+
+// CreateSeason creates a season and materializes pricing for its nights
+func (h *Handler) CreateSeason(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var req SeasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	season := models.Season{PropertyID: uint(propertyID)}
+	if !applySeasonRequest(c, &season, req) {
+		return
+	}
+
+	if _, err := h.propertyRepo.GetPropertyByID(uint(propertyID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	if err := h.seasonRepo.CreateSeason(&season); err != nil {
+		c.Error(apierror.Internal("Failed to create season"))
+		return
+	}
+
+	updated, err := h.materializer.MaterializeSeason(season)
+	if err != nil {
+		log.Printf("Failed to materialize season %d: %v", season.ID, err)
+		c.JSON(http.StatusCreated, gin.H{"data": season, "warning": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":                 season,
+		"pricing_rows_updated": updated,
+	})
+}
+
+// UpdateSeason updates a season and materializes pricing for its old and new nights
+func (h *Handler) UpdateSeason(c *gin.Context) {
+	season, ok := h.loadSeason(c)
+	if !ok {
+		return
+	}
+
+	var req SeasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	previous := *season
+	if !applySeasonRequest(c, season, req) {
+		return
+	}
+
+	if err := h.seasonRepo.UpdateSeason(season); err != nil {
+		c.Error(apierror.Internal("Failed to update season"))
+		return
+	}
+
+	// Nights that left the season fall back to the base rate
+	updated := 0
+	for _, s := range []models.Season{previous, *season} {
+		count, err := h.materializer.MaterializeSeason(s)
+		if err != nil {
+			log.Printf("Failed to materialize season %d: %v", season.ID, err)
+			c.JSON(http.StatusOK, gin.H{"data": season, "warning": err.Error()})
+			return
+		}
+		updated += count
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":                 season,
+		"pricing_rows_updated": updated,
+	})
+}
+
+// DeleteSeason deletes a season and restores the base rate on its nights
+func (h *Handler) DeleteSeason(c *gin.Context) {
+	season, ok := h.loadSeason(c)
+	if !ok {
+		return
+	}
+
+	if err := h.seasonRepo.DeleteSeason(season.ID); err != nil {
+		c.Error(apierror.Internal("Failed to delete season"))
+		return
+	}
+
+	updated, err := h.materializer.MaterializeSeason(*season)
+	if err != nil {
+		log.Printf("Failed to materialize after deleting season %d: %v", season.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deleted":              true,
+		"id":                   season.ID,
+		"pricing_rows_updated": updated,
+	})
+}
+
+// UpdatePropertyRates sets a property's base nightly rate and weekend multiplier
+// and regenerates its upcoming pricing
+func (h *Handler) UpdatePropertyRates(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var req PropertyRatesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+	if req.WeekendMultiplier == 0 {
+		req.WeekendMultiplier = 1
+	}
+	if req.MaterializeDays == 0 {
+		req.MaterializeDays = 365
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	if err := h.propertyRepo.UpdatePropertyRates(uint(propertyID), req.BaseNightlyRate, req.WeekendMultiplier); err != nil {
+		c.Error(apierror.Internal("Failed to update property rates"))
+		return
+	}
+
+	if err := h.redis.InvalidatePropertyCache(ctx, uint(propertyID)); err != nil {
+		log.Printf("Failed to invalidate property cache: %v", err)
+	}
+
+	start := property.Today()
+	updated, err := h.materializer.MaterializeRange(uint(propertyID), start, start.AddDate(0, 0, req.MaterializeDays-1))
+	if err != nil {
+		c.Error(apierror.Internal("Failed to materialize pricing"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id":          propertyID,
+		"base_nightly_rate":    req.BaseNightlyRate,
+		"weekend_multiplier":   req.WeekendMultiplier,
+		"pricing_rows_updated": updated,
+	})
+}
+
+// MaterializePropertyPricing regenerates a property's pricing over a date range
+func (h *Handler) MaterializePropertyPricing(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var req MaterializePricingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	start, end, ok := parseSeasonDates(c, req.StartDate, req.EndDate)
+	if !ok {
+		return
+	}
+
+	updated, err := h.materializer.MaterializeRange(uint(propertyID), start, end)
+	if err != nil {
+		c.Error(apierror.Unprocessable(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id":          propertyID,
+		"pricing_rows_updated": updated,
+	})
+}
+
+// loadSeason loads the season referenced by the :id and :season_id path parameters,
+// writing an error response and returning false if it cannot be loaded
+func (h *Handler) loadSeason(c *gin.Context) (*models.Season, bool) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return nil, false
+	}
+	seasonID, err := strconv.ParseUint(c.Param("season_id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("season"))
+		return nil, false
+	}
+
+	season, err := h.seasonRepo.GetSeasonByID(uint(propertyID), uint(seasonID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Season"))
+			return nil, false
+		}
+		c.Error(apierror.Internal("Failed to retrieve season"))
+		return nil, false
+	}
+	return season, true
+}
+
+// applySeasonRequest validates a season payload and copies it onto the season,
+// writing an error response and returning false if it is invalid
+func applySeasonRequest(c *gin.Context, season *models.Season, req SeasonRequest) bool {
+	start, end, ok := parseSeasonDates(c, req.StartDate, req.EndDate)
+	if !ok {
+		return false
+	}
+	season.Name = req.Name
+	season.StartDate = start
+	season.EndDate = end
+	season.Multiplier = req.Multiplier
+	season.Priority = req.Priority
+	return true
+}
+
+// parseSeasonDates parses an inclusive date range of at most two years,
+// writing an error response and returning false if it is invalid
+func parseSeasonDates(c *gin.Context, startParam, endParam string) (time.Time, time.Time, bool) {
+	start, err := time.Parse("2006-01-02", startParam)
+	if err != nil {
+		c.Error(apierror.InvalidDate("start_date must be in YYYY-MM-DD format"))
+		return time.Time{}, time.Time{}, false
+	}
+	end, err := time.Parse("2006-01-02", endParam)
+	if err != nil {
+		c.Error(apierror.InvalidDate("end_date must be in YYYY-MM-DD format"))
+		return time.Time{}, time.Time{}, false
+	}
+	if end.Before(start) {
+		c.Error(apierror.InvalidDateRange("end_date must not be before start_date"))
+		return time.Time{}, time.Time{}, false
+	}
+	if end.Sub(start) > 2*366*24*time.Hour {
+		c.Error(apierror.InvalidDateRange("date range must not exceed two years"))
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
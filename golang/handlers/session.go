@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Anonymous visitors are identified by a session token, sent back as a cookie or in the
+// X-Session-Token header
+const (
+	sessionCookie = "session_token"
+	sessionHeader = "X-Session-Token"
+)
+
+// sessionTokenLength is the length of a session token in hex characters
+const sessionTokenLength = 32
+
+// sessionToken returns the visitor's session token, or "" when the request carries none
+// or a malformed one
+func sessionToken(c *gin.Context) string {
+	token := c.GetHeader(sessionHeader)
+	if token == "" {
+		token, _ = c.Cookie(sessionCookie)
+	}
+	if len(token) != sessionTokenLength {
+		return ""
+	}
+	if _, err := hex.DecodeString(token); err != nil {
+		return ""
+	}
+	return token
+}
+
+// ensureSessionToken returns the visitor's session token, issuing a new one in a cookie
+// and the X-Session-Token response header when the request has none. The cookie lasts
+// as long as what is remembered of the session.
+func (h *Handler) ensureSessionToken(c *gin.Context) string {
+	if token := sessionToken(c); token != "" {
+		return token
+	}
+
+	buf := make([]byte, sessionTokenLength/2)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	token := hex.EncodeToString(buf)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(sessionCookie, token, int(h.search.RecentlyViewedRetention.Seconds()), "/", "", c.Request.TLS != nil, true)
+	c.Header(sessionHeader, token)
+	return token
+}
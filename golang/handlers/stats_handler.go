@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/cache"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultStatsDays is the period of property statistics when no dates are given
+const defaultStatsDays = 30
+
+// maxTrendingProperties is the length of cached trending rankings and the largest limit
+const maxTrendingProperties = 50
+
+// recordView counts a detail view of a property for its statistics and remembers it
+// among the visitor's recently viewed properties
+func (h *Handler) recordView(c *gin.Context, propertyID uint) {
+	ctx := c.Request.Context()
+	if err := h.redis.RecordPropertyView(ctx, propertyID); err != nil {
+		log.Printf("Failed to record view of property %d: %v", propertyID, err)
+	}
+
+	if h.search.RecentlyViewedMax <= 0 {
+		return
+	}
+	if session := h.ensureSessionToken(c); session != "" {
+		err := h.redis.RecordRecentlyViewed(ctx, session, propertyID, h.search.RecentlyViewedMax, h.search.RecentlyViewedRetention)
+		if err != nil {
+			log.Printf("Failed to record recently viewed property %d: %v", propertyID, err)
+		}
+	}
+}
+
+// recordImpressions counts the appearance of each result of a search results page
+func (h *Handler) recordImpressions(ctx context.Context, results []models.SearchResult) {
+	ids := make([]uint, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+	if err := h.redis.RecordSearchImpressions(ctx, ids); err != nil {
+		log.Printf("Failed to record search impressions: %v", err)
+	}
+}
+
+// GetPropertyStats returns a property's detail views, search impressions and bookings
+// over a date range, by default the last 30 days, with their daily trend and the change
+// against the period before. Views and impressions lag by up to the flush interval.
+func (h *Handler) GetPropertyStats(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var startDate, endDate time.Time
+	if c.Query("start_date") == "" && c.Query("end_date") == "" {
+		endDate = time.Now().UTC().Truncate(24 * time.Hour)
+		startDate = endDate.AddDate(0, 0, 1-defaultStatsDays)
+	} else {
+		var ok bool
+		if startDate, endDate, ok = parseAnalyticsRange(c); !ok {
+			return
+		}
+	}
+
+	if _, err := h.propertyRepo.GetPropertyByID(uint(propertyID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	// The end date is inclusive, and the previous period has the same number of days
+	endExclusive := endDate.AddDate(0, 0, 1)
+	days := int(endExclusive.Sub(startDate).Hours() / 24)
+	previousStart := startDate.AddDate(0, 0, -days)
+
+	statDays, err := h.statsRepo.GetPropertyStatDays(uint(propertyID), previousStart, endExclusive)
+	if err != nil {
+		log.Printf("Failed to retrieve statistics of property %d: %v", propertyID, err)
+		c.Error(apierror.Internal("Failed to compute statistics"))
+		return
+	}
+	bookings, err := h.statsRepo.CountBookingsByDay(uint(propertyID), previousStart, endExclusive)
+	if err != nil {
+		log.Printf("Failed to count bookings of property %d: %v", propertyID, err)
+		c.Error(apierror.Internal("Failed to compute statistics"))
+		return
+	}
+
+	byDate := make(map[string]models.PropertyStatDay, len(statDays))
+	var previousViews, previousBookings int64
+	for _, day := range statDays {
+		if day.Date.Before(startDate) {
+			previousViews += day.Views
+			continue
+		}
+		byDate[day.Date.Format("2006-01-02")] = day
+	}
+
+	stats := &models.PropertyStats{
+		PropertyID:  uint(propertyID),
+		StartDate:   startDate.Format("2006-01-02"),
+		EndDate:     endDate.Format("2006-01-02"),
+		Trend:       make([]models.PropertyStatsPoint, 0, days),
+		GeneratedAt: time.Now(),
+	}
+	for date := previousStart; date.Before(startDate); date = date.AddDate(0, 0, 1) {
+		previousBookings += bookings[date.Format("2006-01-02")]
+	}
+	for date := startDate; date.Before(endExclusive); date = date.AddDate(0, 0, 1) {
+		key := date.Format("2006-01-02")
+		point := models.PropertyStatsPoint{
+			Date:        key,
+			Views:       byDate[key].Views,
+			Impressions: byDate[key].Impressions,
+			Bookings:    bookings[key],
+		}
+		stats.Views += point.Views
+		stats.Impressions += point.Impressions
+		stats.Bookings += point.Bookings
+		stats.Trend = append(stats.Trend, point)
+	}
+
+	if stats.Impressions > 0 {
+		stats.ClickThrough = roundTo(float64(stats.Views)/float64(stats.Impressions), 4)
+	}
+	if stats.Views > 0 {
+		stats.ConversionRate = roundTo(float64(stats.Bookings)/float64(stats.Views), 4)
+	}
+	stats.ViewsChange = relativeChange(stats.Views, previousViews)
+	stats.BookingsChange = relativeChange(stats.Bookings, previousBookings)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": stats,
+	})
+}
+
+// relativeChange returns the change from previous to current as a fraction of previous,
+// or nil when there is nothing to compare with
+func relativeChange(current, previous int64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := roundTo(float64(current-previous)/float64(previous), 4)
+	return &change
+}
+
+// TrendingProperty is a property in the trending ranking
+type TrendingProperty struct {
+	Score    float64          `json:"score"`
+	Property *models.Property `json:"property"`
+}
+
+// GetTrendingProperties lists the properties with the most recent views and bookings,
+// optionally of one city, for homepage carousels. Scores decay with the age of the
+// activity, and rankings are cached in Redis sorted sets.
+func (h *Handler) GetTrendingProperties(c *gin.Context) {
+	ctx := c.Request.Context()
+	city := strings.TrimSpace(c.Query("city"))
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > maxTrendingProperties {
+		c.Error(apierror.InvalidField("limit", "range", "limit must be between 1 and "+strconv.Itoa(maxTrendingProperties)))
+		return
+	}
+
+	entries, found, err := h.redis.GetTrendingCache(ctx, city, limit)
+	if err != nil {
+		log.Printf("Cache retrieval error: %v", err)
+	}
+	cached := found
+	if !found {
+		since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -h.search.TrendingWindowDays)
+		scores, err := h.statsRepo.TrendingProperties(city, since, h.search.TrendingHalfLifeDays,
+			h.search.TrendingBookingWeight, maxTrendingProperties)
+		if err != nil {
+			log.Printf("Failed to score trending properties: %v", err)
+			c.Error(apierror.Internal("Failed to retrieve trending properties"))
+			return
+		}
+		entries = make([]cache.TrendingEntry, len(scores))
+		for i, score := range scores {
+			entries[i] = cache.TrendingEntry{PropertyID: score.PropertyID, Score: roundTo(score.Score, 4)}
+		}
+		if err := h.redis.SetTrendingCache(ctx, city, entries, h.search.TrendingTTL); err != nil {
+			log.Printf("Failed to cache trending properties: %v", err)
+		}
+		if len(entries) > limit {
+			entries = entries[:limit]
+		}
+	}
+
+	trending := []TrendingProperty{}
+	if len(entries) > 0 {
+		ids := make([]uint, len(entries))
+		for i, entry := range entries {
+			ids[i] = entry.PropertyID
+		}
+		properties, err := h.propertyRepo.GetPropertiesWithContent(ids)
+		if err != nil {
+			c.Error(apierror.Internal("Failed to retrieve trending properties"))
+			return
+		}
+		byID := make(map[uint]*models.Property, len(properties))
+		for i := range properties {
+			byID[properties[i].ID] = &properties[i]
+		}
+		// Properties deleted since the ranking was cached are left out
+		for _, entry := range entries {
+			if property, ok := byID[entry.PropertyID]; ok {
+				trending = append(trending, TrendingProperty{Score: entry.Score, Property: property})
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"city":   city,
+		"data":   trending,
+		"cached": cached,
+	})
+}
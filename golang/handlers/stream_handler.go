@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"channelmanager/apierror"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// streamHeartbeat is how often an idle stream sends a keep-alive comment
+const streamHeartbeat = 15 * time.Second
+
+// StreamPropertyAvailability streams availability and rate changes of a property as
+// Server-Sent Events until the client disconnects
+func (h *Handler) StreamPropertyAvailability(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	if _, err := h.propertyRepo.GetPropertyByID(uint(propertyID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	sub, err := h.redis.SubscribePropertyChanges(ctx, uint(propertyID))
+	if err != nil {
+		log.Printf("Failed to subscribe to property %d changes: %v", propertyID, err)
+		c.Error(apierror.Internal("Failed to open availability stream"))
+		return
+	}
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // disable proxy buffering
+	c.Render(http.StatusOK, sse.Event{Event: "ready", Data: gin.H{"property_id": propertyID}})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case change, ok := <-sub.Changes():
+			if !ok {
+				return false
+			}
+			if change.Table != "availabilities" && change.Table != "pricing" {
+				return true
+			}
+			c.Render(-1, sse.Event{
+				Id:    strconv.FormatUint(uint64(change.EventID), 10),
+				Event: change.Table,
+				Data:  change,
+			})
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		case <-ctx.Done():
+			return false
+		}
+	})
+}
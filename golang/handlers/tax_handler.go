@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"channelmanager/apierror"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TaxRuleRequest represents the payload for creating or updating a tax rule
+type TaxRuleRequest struct {
+	Name    string  `json:"name" binding:"required,max=100"`
+	Country string  `json:"country" binding:"required,max=100"`
+	State   string  `json:"state" binding:"required_with=City,max=100"`
+	City    string  `json:"city" binding:"max=100"`
+	TaxType string  `json:"tax_type" binding:"required,max=50"`
+	Kind    string  `json:"kind" binding:"required,oneof=percentage per_night"`
+	Rate    float64 `json:"rate" binding:"gt=0"`
+	Active  *bool   `json:"active"`
+}
+
+// ListTaxRules lists tax rules, optionally filtered by country
+func (h *Handler) ListTaxRules(c *gin.Context) {
+	rules, err := h.taxRuleRepo.GetAllTaxRules(c.Query("country"))
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve tax rules"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rules})
+}
+
+// GetTaxRule retrieves a single tax rule
+func (h *Handler) GetTaxRule(c *gin.Context) {
+	rule, ok := h.loadTaxRule(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rule})
+}
+
+// CreateTaxRule creates a tax rule for a jurisdiction
+func (h *Handler) CreateTaxRule(c *gin.Context) {
+	var req TaxRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	rule := models.TaxRule{Active: true}
+	if !applyTaxRuleRequest(c, &rule, req) {
+		return
+	}
+
+	if err := h.taxRuleRepo.CreateTaxRule(&rule); err != nil {
+		c.Error(apierror.Internal("Failed to create tax rule"))
+		return
+	}
+
+	h.invalidateSearchCache(c.Request.Context())
+
+	c.JSON(http.StatusCreated, gin.H{"data": rule})
+}
+
+// UpdateTaxRule updates a tax rule
+func (h *Handler) UpdateTaxRule(c *gin.Context) {
+	rule, ok := h.loadTaxRule(c)
+	if !ok {
+		return
+	}
+
+	var req TaxRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	if !applyTaxRuleRequest(c, rule, req) {
+		return
+	}
+
+	if err := h.taxRuleRepo.UpdateTaxRule(rule); err != nil {
+		c.Error(apierror.Internal("Failed to update tax rule"))
+		return
+	}
+
+	h.invalidateSearchCache(c.Request.Context())
+
+	c.JSON(http.StatusOK, gin.H{"data": rule})
+}
+
+// DeleteTaxRule deletes a tax rule
+func (h *Handler) DeleteTaxRule(c *gin.Context) {
+	rule, ok := h.loadTaxRule(c)
+	if !ok {
+		return
+	}
+
+	if err := h.taxRuleRepo.DeleteTaxRule(rule.ID); err != nil {
+		c.Error(apierror.Internal("Failed to delete tax rule"))
+		return
+	}
+
+	h.invalidateSearchCache(c.Request.Context())
+
+	c.JSON(http.StatusOK, gin.H{"deleted": true, "id": rule.ID})
+}
+
+// loadTaxRule loads the tax rule referenced by the :id path parameter,
+// writing an error response and returning false if it cannot be loaded
+func (h *Handler) loadTaxRule(c *gin.Context) (*models.TaxRule, bool) {
+	ruleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("tax rule"))
+		return nil, false
+	}
+
+	rule, err := h.taxRuleRepo.GetTaxRuleByID(uint(ruleID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Tax rule"))
+			return nil, false
+		}
+		c.Error(apierror.Internal("Failed to retrieve tax rule"))
+		return nil, false
+	}
+	return rule, true
+}
+
+// applyTaxRuleRequest validates a tax rule payload and copies it onto the rule,
+// writing an error response and returning false if it is invalid
+func applyTaxRuleRequest(c *gin.Context, rule *models.TaxRule, req TaxRuleRequest) bool {
+	if req.Kind == models.TaxKindPercentage && req.Rate >= 100 {
+		c.Error(apierror.InvalidField("rate", "lt", "must be less than 100 for percentage taxes"))
+		return false
+	}
+
+	rule.Name = req.Name
+	rule.Country = req.Country
+	rule.State = req.State
+	rule.City = req.City
+	rule.TaxType = req.TaxType
+	rule.Kind = req.Kind
+	rule.Rate = req.Rate
+	if req.Active != nil {
+		rule.Active = *req.Active
+	}
+	return true
+}
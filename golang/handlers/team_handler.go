@@ -0,0 +1,518 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/database"
+	"channelmanager/middleware"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// invitationTTL is how long a team invitation can be accepted
+const invitationTTL = 7 * 24 * time.Hour
+
+// teamTokenBytes is the length of API and invitation tokens before hex encoding
+const teamTokenBytes = 32
+
+// OwnerRequest represents the payload for creating a property owner
+type OwnerRequest struct {
+	Name  string `json:"name" binding:"required,max=200"`
+	Email string `json:"email" binding:"required,email,max=255"`
+}
+
+// PropertyOwnerRequest represents the payload assigning a property to an owner; a null
+// owner_id releases it
+type PropertyOwnerRequest struct {
+	OwnerID *uint `json:"owner_id"`
+}
+
+// TeamGrantRequest holds the permissions granted on one property
+type TeamGrantRequest struct {
+	PropertyID  uint     `json:"property_id" binding:"required"`
+	Permissions []string `json:"permissions" binding:"required,min=1,dive,oneof=manage_rates manage_calendar view_only"`
+}
+
+// TeamInvitationRequest represents the payload inviting someone to an owner's team
+type TeamInvitationRequest struct {
+	Email  string             `json:"email" binding:"required,email,max=255"`
+	Name   string             `json:"name" binding:"max=200"`
+	Grants []TeamGrantRequest `json:"grants" binding:"required,min=1,max=500,dive"`
+}
+
+// TeamGrantsRequest represents the payload replacing a team member's grants
+type TeamGrantsRequest struct {
+	Grants []TeamGrantRequest `json:"grants" binding:"required,max=500,dive"`
+}
+
+// AcceptInvitationRequest represents the payload accepting a team invitation
+type AcceptInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ListOwners retrieves the property owners
+func (h *Handler) ListOwners(c *gin.Context) {
+	page := parsePage(c, 50, 200)
+
+	owners, total, err := h.teamRepo.ListOwners(page.Limit, page.Offset())
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve owners"))
+		return
+	}
+
+	c.JSON(http.StatusOK, paginated(c, owners, total, page))
+}
+
+// CreateOwner creates a property owner and returns its API token, which is shown only
+// this once
+func (h *Handler) CreateOwner(c *gin.Context) {
+	var req OwnerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	taken, err := h.teamRepo.OwnerEmailTaken(email)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to validate owner email"))
+		return
+	}
+	if taken {
+		c.Error(apierror.AlreadyExists("An owner with this email already exists"))
+		return
+	}
+
+	token, err := newTeamToken()
+	if err != nil {
+		c.Error(apierror.Internal("Failed to generate API token"))
+		return
+	}
+	owner := models.Owner{Name: strings.TrimSpace(req.Name), Email: email, TokenHash: models.TeamTokenHash(token)}
+	if err := h.teamRepo.CreateOwner(&owner); err != nil {
+		c.Error(apierror.Internal("Failed to create owner"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": owner, "api_key": token})
+}
+
+// RotateOwnerToken issues a new API token to an owner, revoking the previous one
+func (h *Handler) RotateOwnerToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("owner"))
+		return
+	}
+
+	owner, err := h.teamRepo.GetOwnerByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Owner"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve owner"))
+		return
+	}
+
+	token, err := newTeamToken()
+	if err != nil {
+		c.Error(apierror.Internal("Failed to generate API token"))
+		return
+	}
+	if err := h.teamRepo.UpdateOwnerToken(owner, models.TeamTokenHash(token)); err != nil {
+		c.Error(apierror.Internal("Failed to update owner"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": owner, "api_key": token})
+}
+
+// SetPropertyOwner assigns a property to an owner, restricting changes to it to the
+// owner's team, or releases it. Grants of the previous owner's team on it are removed.
+func (h *Handler) SetPropertyOwner(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	var req PropertyOwnerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	property, err := h.propertyRepo.GetPropertyByID(uint(propertyID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+	if req.OwnerID != nil {
+		if _, err := h.teamRepo.GetOwnerByID(*req.OwnerID); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.Error(apierror.NotFound("Owner"))
+				return
+			}
+			c.Error(apierror.Internal("Failed to retrieve owner"))
+			return
+		}
+	}
+
+	if err := h.teamRepo.SetPropertyOwner(property, req.OwnerID); err != nil {
+		log.Printf("Failed to set owner of property %d: %v", property.ID, err)
+		c.Error(apierror.Internal("Failed to update property"))
+		return
+	}
+	if err := h.redis.InvalidatePropertyCache(c.Request.Context(), property.ID); err != nil {
+		log.Printf("Failed to invalidate property cache: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"property_id": property.ID, "owner_id": property.OwnerID})
+}
+
+// ListTeamMembers retrieves the calling owner's team members with their grants
+func (h *Handler) ListTeamMembers(c *gin.Context) {
+	owner, ok := h.callingOwner(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.teamRepo.ListTeamMembers(owner.ID)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve team members"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": members})
+}
+
+// InviteTeamMember invites someone to the calling owner's team with permissions on some
+// of its properties. The invitation token is returned only this once, for the owner to
+// pass on; inviting a pending or revoked member again reissues the invitation.
+func (h *Handler) InviteTeamMember(c *gin.Context) {
+	owner, ok := h.callingOwner(c)
+	if !ok {
+		return
+	}
+
+	var req TeamInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+	grants, ok := h.teamGrants(c, owner.ID, req.Grants)
+	if !ok {
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	member, err := h.teamRepo.GetTeamMemberByEmail(owner.ID, email)
+	switch {
+	case err == gorm.ErrRecordNotFound:
+		member = &models.TeamMember{OwnerID: owner.ID, Email: email}
+	case err != nil:
+		c.Error(apierror.Internal("Failed to retrieve team member"))
+		return
+	case member.Status == models.TeamMemberActive:
+		c.Error(apierror.AlreadyExists("This person is already on the team; update their grants instead"))
+		return
+	}
+
+	token, err := newTeamToken()
+	if err != nil {
+		c.Error(apierror.Internal("Failed to generate invitation token"))
+		return
+	}
+	expiresAt := time.Now().Add(invitationTTL)
+	member.Name = strings.TrimSpace(req.Name)
+	member.Status = models.TeamMemberInvited
+	member.TokenHash = ""
+	member.InvitationHash = models.TeamTokenHash(token)
+	member.InvitationExpiresAt = &expiresAt
+	member.RevokedAt = nil
+
+	if err := h.teamRepo.SaveInvitation(member, grants); err != nil {
+		log.Printf("Failed to save invitation of %s to team of owner %d: %v", email, owner.ID, err)
+		c.Error(apierror.Internal("Failed to save invitation"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": member, "invitation_token": token})
+}
+
+// AcceptTeamInvitation accepts a team invitation and returns the member's API token,
+// which is shown only this once
+func (h *Handler) AcceptTeamInvitation(c *gin.Context) {
+	var req AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	token, err := newTeamToken()
+	if err != nil {
+		c.Error(apierror.Internal("Failed to generate API token"))
+		return
+	}
+	member, err := h.teamRepo.AcceptInvitation(models.TeamTokenHash(req.Token), models.TeamTokenHash(token), time.Now())
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Invitation"))
+			return
+		}
+		if errors.Is(err, database.ErrInvitationExpired) {
+			c.Error(apierror.InvalidState("The invitation has expired; ask the owner for a new one"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to accept invitation"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": member, "api_key": token})
+}
+
+// UpdateTeamMemberGrants replaces a team member's permissions on the calling owner's
+// properties; no grants leaves the member on the team without access
+func (h *Handler) UpdateTeamMemberGrants(c *gin.Context) {
+	owner, ok := h.callingOwner(c)
+	if !ok {
+		return
+	}
+	member, ok := h.loadTeamMember(c, owner.ID)
+	if !ok {
+		return
+	}
+
+	var req TeamGrantsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+	grants, ok := h.teamGrants(c, owner.ID, req.Grants)
+	if !ok {
+		return
+	}
+
+	if err := h.teamRepo.UpdateGrants(member, grants); err != nil {
+		c.Error(apierror.Internal("Failed to update team member"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": member})
+}
+
+// RevokeTeamMember removes a team member's access and cancels a pending invitation
+func (h *Handler) RevokeTeamMember(c *gin.Context) {
+	owner, ok := h.callingOwner(c)
+	if !ok {
+		return
+	}
+	member, ok := h.loadTeamMember(c, owner.ID)
+	if !ok {
+		return
+	}
+	if member.Status == models.TeamMemberRevoked {
+		c.Error(apierror.InvalidState("The team member is already revoked"))
+		return
+	}
+
+	if err := h.teamRepo.RevokeTeamMember(member, time.Now()); err != nil {
+		c.Error(apierror.Internal("Failed to revoke team member"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": member})
+}
+
+// RequirePropertyPermission lets a request through only if it may make a change
+// requiring permission on the property of the :id path parameter
+func (h *Handler) RequirePropertyPermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			// The handler reports the invalid ID
+			c.Next()
+			return
+		}
+		if apiErr := h.authorizeProperty(c, uint(propertyID), permission); apiErr != nil {
+			c.Error(apiErr)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// authorizeProperty checks that the request may make a change requiring permission on a
+// property. Properties without an owner are open to everyone; those with one accept
+// admin API keys, the owner's API token and those of active team members granted the
+// permission. Unknown properties pass, for the handler to report.
+func (h *Handler) authorizeProperty(c *gin.Context, propertyID uint, permission string) *apierror.APIError {
+	if middleware.IsAdmin(c) {
+		return nil
+	}
+
+	ownerID, err := h.teamRepo.GetPropertyOwnerID(propertyID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return apierror.Internal("Failed to retrieve property")
+	}
+	if ownerID == nil {
+		return nil
+	}
+
+	key := middleware.APIKey(c)
+	if key == "" {
+		return apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "Missing API key")
+	}
+	hash := models.TeamTokenHash(key)
+
+	owner, err := h.teamRepo.GetOwnerByTokenHash(hash)
+	if err == nil && owner.ID == *ownerID {
+		return nil
+	}
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return apierror.Internal("Failed to verify API key")
+	}
+	member, err := h.teamRepo.GetMemberGrant(hash, propertyID)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return apierror.Internal("Failed to verify API key")
+	}
+	if err == nil && member.OwnerID == *ownerID && len(member.Grants) > 0 && member.Grants[0].Allows(permission) {
+		return nil
+	}
+	return apierror.New(http.StatusForbidden, apierror.CodeForbidden, "The API key does not permit this change to the property")
+}
+
+// requireAPIKey writes an UNAUTHORIZED response and returns false for a request without
+// an API key, so reads of guest details are never anonymous even for properties without
+// an owner
+func requireAPIKey(c *gin.Context) bool {
+	if middleware.IsAdmin(c) || middleware.APIKey(c) != "" {
+		return true
+	}
+	c.Error(apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "Missing API key"))
+	return false
+}
+
+// callingOwner loads the owner whose API token the request carries, writing an error
+// response and returning false if it carries none or another token
+func (h *Handler) callingOwner(c *gin.Context) (*models.Owner, bool) {
+	key := middleware.APIKey(c)
+	if key == "" {
+		c.Error(apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "Missing API key"))
+		return nil, false
+	}
+
+	owner, err := h.teamRepo.GetOwnerByTokenHash(models.TeamTokenHash(key))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.New(http.StatusForbidden, apierror.CodeForbidden, "Only property owners can manage their team"))
+			return nil, false
+		}
+		c.Error(apierror.Internal("Failed to verify API key"))
+		return nil, false
+	}
+	return owner, true
+}
+
+// loadTeamMember loads the owner's team member referenced by the :id path parameter,
+// writing an error response and returning false if it cannot be loaded
+func (h *Handler) loadTeamMember(c *gin.Context, ownerID uint) (*models.TeamMember, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("team member"))
+		return nil, false
+	}
+
+	member, err := h.teamRepo.GetTeamMember(ownerID, uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Team member"))
+			return nil, false
+		}
+		c.Error(apierror.Internal("Failed to retrieve team member"))
+		return nil, false
+	}
+	return member, true
+}
+
+// teamGrants validates requested grants, which must cover distinct properties of the
+// owner and grant view_only alone or any of the manage permissions, writing an error
+// response and returning false if they are invalid
+func (h *Handler) teamGrants(c *gin.Context, ownerID uint, requested []TeamGrantRequest) ([]models.TeamGrant, bool) {
+	grants := make([]models.TeamGrant, 0, len(requested))
+	propertyIDs := make([]uint, 0, len(requested))
+	seen := make(map[uint]bool, len(requested))
+	for i, req := range requested {
+		if seen[req.PropertyID] {
+			c.Error(apierror.InvalidField("grants["+strconv.Itoa(i)+"].property_id", "unique", "property is granted twice"))
+			return nil, false
+		}
+		seen[req.PropertyID] = true
+
+		permissions := make([]string, 0, len(req.Permissions))
+		granted := make(map[string]bool, len(req.Permissions))
+		for _, permission := range req.Permissions {
+			if !granted[permission] {
+				granted[permission] = true
+				permissions = append(permissions, permission)
+			}
+		}
+		if granted[models.PermissionViewOnly] && len(permissions) > 1 {
+			c.Error(apierror.InvalidField("grants["+strconv.Itoa(i)+"].permissions", "view_only", "view_only cannot be combined with other permissions"))
+			return nil, false
+		}
+
+		grants = append(grants, models.TeamGrant{PropertyID: req.PropertyID, Permissions: permissions})
+		propertyIDs = append(propertyIDs, req.PropertyID)
+	}
+	if len(propertyIDs) == 0 {
+		return grants, true
+	}
+
+	owned, err := h.teamRepo.GetOwnedPropertyIDs(ownerID, propertyIDs)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve properties"))
+		return nil, false
+	}
+	if len(owned) < len(propertyIDs) {
+		isOwned := make(map[uint]bool, len(owned))
+		for _, id := range owned {
+			isOwned[id] = true
+		}
+		for i, id := range propertyIDs {
+			if !isOwned[id] {
+				c.Error(apierror.InvalidField("grants["+strconv.Itoa(i)+"].property_id", "owned", "property does not belong to the owner"))
+				return nil, false
+			}
+		}
+	}
+	return grants, true
+}
+
+// newTeamToken returns a random API or invitation token
+func newTeamToken() (string, error) {
+	b := make([]byte, teamTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
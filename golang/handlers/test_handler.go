@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"channelmanager/apierror"
+	"channelmanager/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResetTestData empties the database and cache and reloads the test fixtures, so each
+// contract test starts from the same state. The route only exists in test mode.
+func (h *Handler) ResetTestData(c *gin.Context) {
+	if err := utils.ResetTestData(c.Request.Context(), h.db, h.redis); err != nil {
+		log.Printf("Failed to reset test data: %v", err)
+		c.Error(apierror.Internal("Failed to reset test data"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Test data reset"})
+}
@@ -0,0 +1,451 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/locale"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// referenceLocale is the locale amenity and condition names are entered in
+const referenceLocale = "en"
+
+// PropertyTranslationRequest represents the payload for a property's content in one locale
+type PropertyTranslationRequest struct {
+	Name        string `json:"name" binding:"required,max=255"`
+	Description string `json:"description"`
+	HouseRules  string `json:"house_rules"`
+}
+
+// ReferenceTranslationRequest represents the payload for an amenity or condition name in
+// one locale
+type ReferenceTranslationRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// ListPropertyTranslations retrieves all translations of a property
+func (h *Handler) ListPropertyTranslations(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	translations, err := h.translationRepo.GetTranslationsForProperty(uint(propertyID))
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve translations"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": propertyID,
+		"data":        translations,
+	})
+}
+
+// SavePropertyTranslation creates or replaces a property's content for a locale
+func (h *Handler) SavePropertyTranslation(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	tag := locale.Normalize(c.Param("locale"))
+	if !locale.Valid(tag) {
+		c.Error(apierror.Validation("Invalid locale, expected a language tag such as en or pt-BR"))
+		return
+	}
+
+	var req PropertyTranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	if _, err := h.propertyRepo.GetPropertyByID(uint(propertyID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Property"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve property"))
+		return
+	}
+
+	translation := models.PropertyTranslation{
+		PropertyID:  uint(propertyID),
+		Locale:      tag,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		HouseRules:  req.HouseRules,
+	}
+	if err := h.translationRepo.UpsertTranslation(&translation); err != nil {
+		c.Error(apierror.Internal("Failed to save translation"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": translation})
+}
+
+// DeletePropertyTranslation deletes a property's content for a locale
+func (h *Handler) DeletePropertyTranslation(c *gin.Context) {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("property"))
+		return
+	}
+
+	tag := locale.Normalize(c.Param("locale"))
+	deleted, err := h.translationRepo.DeleteTranslation(uint(propertyID), tag)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to delete translation"))
+		return
+	}
+	if !deleted {
+		c.Error(apierror.NotFound("Translation"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deleted":     true,
+		"property_id": propertyID,
+		"locale":      tag,
+	})
+}
+
+// localizeProperty replaces a property's content with the translation best matching
+// the request's Accept-Language header and returns the locale served. Translations
+// are applied after caching so cached entries stay locale-neutral.
+func (h *Handler) localizeProperty(c *gin.Context, property *models.Property) string {
+	c.Header("Vary", "Accept-Language")
+
+	preferred := locale.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	if len(preferred) == 0 {
+		return property.DefaultLocale
+	}
+
+	translations, err := h.translationRepo.GetTranslationsForProperty(property.ID)
+	if err != nil {
+		log.Printf("Failed to load translations for property %d: %v", property.ID, err)
+		return property.DefaultLocale
+	}
+
+	served := applyTranslation(preferred, property.DefaultLocale, translations, func(t models.PropertyTranslation) {
+		property.Name = t.Name
+		property.Description = t.Description
+		property.HouseRules = t.HouseRules
+	})
+	if served != "" {
+		c.Header("Content-Language", served)
+	}
+	return served
+}
+
+// localizeSearchResults replaces each result's name and description with the
+// translation best matching the request's Accept-Language header
+func (h *Handler) localizeSearchResults(c *gin.Context, results []models.SearchResult) {
+	c.Header("Vary", "Accept-Language")
+
+	preferred := locale.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	if len(preferred) == 0 || len(results) == 0 {
+		return
+	}
+
+	propertyIDs := make([]uint, 0, len(results))
+	for _, result := range results {
+		propertyIDs = append(propertyIDs, result.ID)
+	}
+
+	translations, err := h.translationRepo.GetTranslationsForProperties(propertyIDs)
+	if err != nil {
+		log.Printf("Failed to load translations for search results: %v", err)
+		return
+	}
+
+	for i := range results {
+		result := &results[i]
+		result.Locale = applyTranslation(preferred, result.Locale, translations[result.ID], func(t models.PropertyTranslation) {
+			result.Name = t.Name
+			result.Description = t.Description
+		})
+	}
+
+	h.localizeReferenceNames(c, results)
+}
+
+// localizeReferenceNames replaces the amenity and condition names of search results with
+// their translations to the language of the request's Accept-Language header. Names are
+// mapped through the cached amenity and condition lists, so cached results stay
+// locale-neutral.
+func (h *Handler) localizeReferenceNames(c *gin.Context, results []models.SearchResult) {
+	tag := h.referenceLocale(c)
+	if tag == "" {
+		return
+	}
+	ctx := c.Request.Context()
+
+	amenities, err := h.amenityList(ctx, "")
+	if err != nil {
+		log.Printf("Failed to load amenities: %v", err)
+		return
+	}
+	localizedAmenities, err := h.amenityList(ctx, tag)
+	if err != nil {
+		log.Printf("Failed to load %s amenity names: %v", tag, err)
+		return
+	}
+	conditions, err := h.conditionList(ctx, "")
+	if err != nil {
+		log.Printf("Failed to load conditions: %v", err)
+		return
+	}
+	localizedConditions, err := h.conditionList(ctx, tag)
+	if err != nil {
+		log.Printf("Failed to load %s condition names: %v", tag, err)
+		return
+	}
+
+	amenityNames := make(map[uint]string, len(localizedAmenities))
+	for _, a := range localizedAmenities {
+		amenityNames[a.ID] = a.Name
+	}
+	renamed := make(map[string]string, len(amenities)+len(conditions))
+	for _, a := range amenities {
+		if name, ok := amenityNames[a.ID]; ok && name != a.Name {
+			renamed["amenity:"+a.Name] = name
+		}
+	}
+	conditionNames := make(map[uint]string, len(localizedConditions))
+	for _, cond := range localizedConditions {
+		conditionNames[cond.ID] = cond.Name
+	}
+	for _, cond := range conditions {
+		if name, ok := conditionNames[cond.ID]; ok && name != cond.Name {
+			renamed["condition:"+cond.Name] = name
+		}
+	}
+	if len(renamed) == 0 {
+		return
+	}
+
+	for i := range results {
+		for j, name := range results[i].Amenities {
+			if translated, ok := renamed["amenity:"+name]; ok {
+				results[i].Amenities[j] = translated
+			}
+		}
+		for j, name := range results[i].Conditions {
+			if translated, ok := renamed["condition:"+name]; ok {
+				results[i].Conditions[j] = translated
+			}
+		}
+	}
+}
+
+// referenceLocale resolves the request's Accept-Language header against the locales
+// amenity and condition names are translated to. It returns an empty string when the
+// names are served as entered.
+func (h *Handler) referenceLocale(c *gin.Context) string {
+	c.Header("Vary", "Accept-Language")
+
+	preferred := locale.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	if len(preferred) == 0 {
+		return ""
+	}
+
+	ctx := c.Request.Context()
+	locales, cached, err := h.redis.GetReferenceLocalesCache(ctx)
+	if err != nil {
+		log.Printf("Cache retrieval error: %v", err)
+	}
+	if !cached {
+		if locales, err = h.translationRepo.GetReferenceLocales(); err != nil {
+			log.Printf("Failed to load translation locales: %v", err)
+			return ""
+		}
+		if err := h.redis.SetReferenceLocalesCache(ctx, locales, 24*time.Hour); err != nil {
+			log.Printf("Failed to cache translation locales: %v", err)
+		}
+	}
+
+	match, ok := locale.Match(preferred, append([]string{referenceLocale}, locales...))
+	if !ok || match == referenceLocale {
+		return ""
+	}
+	return match
+}
+
+// applyTranslation applies the translation best matching the preferred locales and
+// returns the locale served; the default locale wins when it matches first
+func applyTranslation(preferred []string, defaultLocale string, translations []models.PropertyTranslation, apply func(models.PropertyTranslation)) string {
+	available := make([]string, 0, len(translations)+1)
+	if defaultLocale != "" {
+		available = append(available, locale.Normalize(defaultLocale))
+	}
+	for _, t := range translations {
+		available = append(available, t.Locale)
+	}
+
+	match, ok := locale.Match(preferred, available)
+	if !ok {
+		return defaultLocale
+	}
+	for _, t := range translations {
+		if t.Locale == match && (defaultLocale == "" || match != locale.Normalize(defaultLocale)) {
+			apply(t)
+			break
+		}
+	}
+	return match
+}
+
+// ListAmenityTranslations retrieves all translations of an amenity's name
+func (h *Handler) ListAmenityTranslations(c *gin.Context) {
+	amenity, ok := h.loadAmenity(c)
+	if !ok {
+		return
+	}
+	h.listReferenceTranslations(c, models.ReferenceAmenity, amenity.ID)
+}
+
+// SaveAmenityTranslation creates or replaces an amenity's name for a locale
+func (h *Handler) SaveAmenityTranslation(c *gin.Context) {
+	amenity, ok := h.loadAmenity(c)
+	if !ok {
+		return
+	}
+	h.saveReferenceTranslation(c, models.ReferenceAmenity, amenity.ID)
+}
+
+// DeleteAmenityTranslation deletes an amenity's name for a locale
+func (h *Handler) DeleteAmenityTranslation(c *gin.Context) {
+	amenity, ok := h.loadAmenity(c)
+	if !ok {
+		return
+	}
+	h.deleteReferenceTranslation(c, models.ReferenceAmenity, amenity.ID)
+}
+
+// ListConditionTranslations retrieves all translations of a condition's name
+func (h *Handler) ListConditionTranslations(c *gin.Context) {
+	condition, ok := h.loadCondition(c)
+	if !ok {
+		return
+	}
+	h.listReferenceTranslations(c, models.ReferenceCondition, condition.ID)
+}
+
+// SaveConditionTranslation creates or replaces a condition's name for a locale
+func (h *Handler) SaveConditionTranslation(c *gin.Context) {
+	condition, ok := h.loadCondition(c)
+	if !ok {
+		return
+	}
+	h.saveReferenceTranslation(c, models.ReferenceCondition, condition.ID)
+}
+
+// DeleteConditionTranslation deletes a condition's name for a locale
+func (h *Handler) DeleteConditionTranslation(c *gin.Context) {
+	condition, ok := h.loadCondition(c)
+	if !ok {
+		return
+	}
+	h.deleteReferenceTranslation(c, models.ReferenceCondition, condition.ID)
+}
+
+// listReferenceTranslations responds with the translations of an amenity or condition's name
+func (h *Handler) listReferenceTranslations(c *gin.Context, kind string, referenceID uint) {
+	translations, err := h.translationRepo.GetReferenceTranslations(kind, referenceID)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve translations"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":   referenceID,
+		"data": translations,
+	})
+}
+
+// saveReferenceTranslation creates or replaces the name of an amenity or condition for
+// the :locale path parameter
+func (h *Handler) saveReferenceTranslation(c *gin.Context, kind string, referenceID uint) {
+	tag := locale.Normalize(c.Param("locale"))
+	if !locale.Valid(tag) {
+		c.Error(apierror.Validation("Invalid locale, expected a language tag such as en or pt-BR"))
+		return
+	}
+
+	var req ReferenceTranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.Error(apierror.Validation("name must be between 1 and 100 characters"))
+		return
+	}
+
+	translation := models.ReferenceTranslation{
+		Kind:        kind,
+		ReferenceID: referenceID,
+		Locale:      tag,
+		Name:        name,
+	}
+	if err := h.translationRepo.UpsertReferenceTranslation(&translation); err != nil {
+		c.Error(apierror.Internal("Failed to save translation"))
+		return
+	}
+	h.invalidateReferenceTranslations(c.Request.Context(), kind)
+
+	c.JSON(http.StatusOK, gin.H{"data": translation})
+}
+
+// deleteReferenceTranslation deletes the name of an amenity or condition for the
+// :locale path parameter
+func (h *Handler) deleteReferenceTranslation(c *gin.Context, kind string, referenceID uint) {
+	tag := locale.Normalize(c.Param("locale"))
+	deleted, err := h.translationRepo.DeleteReferenceTranslation(kind, referenceID, tag)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to delete translation"))
+		return
+	}
+	if !deleted {
+		c.Error(apierror.NotFound("Translation"))
+		return
+	}
+	h.invalidateReferenceTranslations(c.Request.Context(), kind)
+
+	c.JSON(http.StatusOK, gin.H{
+		"deleted": true,
+		"id":      referenceID,
+		"locale":  tag,
+	})
+}
+
+// invalidateReferenceTranslations drops the cached amenity or condition lists and the
+// cached translation locales after a name's translation changed
+func (h *Handler) invalidateReferenceTranslations(ctx context.Context, kind string) {
+	var err error
+	if kind == models.ReferenceAmenity {
+		err = h.redis.InvalidateAmenitiesCache(ctx)
+	} else {
+		err = h.redis.InvalidateConditionsCache(ctx)
+	}
+	if err == nil {
+		err = h.redis.InvalidateReferenceLocalesCache(ctx)
+	}
+	if err != nil {
+		log.Printf("Failed to invalidate %s translations cache: %v", kind, err)
+	}
+}
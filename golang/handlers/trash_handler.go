@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"channelmanager/apierror"
+	"channelmanager/database"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// deletedResources names a record of each kind that can be restored or purged
+var deletedResources = map[string]string{
+	database.DeletedProperties: "Property",
+	database.DeletedAmenities:  "Amenity",
+	database.DeletedConditions: "Condition",
+}
+
+// ListDeletedRecords retrieves the soft-deleted properties, amenities or conditions,
+// most recently deleted first
+func (h *Handler) ListDeletedRecords(c *gin.Context) {
+	kind, _, ok := deletedKind(c)
+	if !ok {
+		return
+	}
+	page := parsePage(c, 50, 200)
+
+	records, total, err := h.trashRepo.ListDeleted(kind, page.Limit, page.Offset())
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve deleted records"))
+		return
+	}
+
+	c.JSON(http.StatusOK, paginated(c, records, total, page))
+}
+
+// RestoreDeletedRecord undeletes a soft-deleted property, amenity or condition. A
+// restored amenity or condition shows again on the properties it was attached to.
+func (h *Handler) RestoreDeletedRecord(c *gin.Context) {
+	kind, id, ok := deletedRecordID(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	propertyIDs, err := h.trashRepo.Restore(kind, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound(deletedResources[kind]))
+			return
+		}
+		log.Printf("Failed to restore %s %d: %v", kind, id, err)
+		c.Error(apierror.Internal(fmt.Sprintf("Failed to restore %s", deletedResources[kind])))
+		return
+	}
+
+	if kind == database.DeletedProperties {
+		if err := h.redis.InvalidatePropertyCache(ctx, id); err != nil {
+			log.Printf("Failed to invalidate property cache: %v", err)
+		}
+		h.invalidateSearchCache(ctx)
+	} else {
+		h.recordReferenceChange(ctx, "INSERT", kind, id, gin.H{"id": id}, propertyIDs)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"restored":            true,
+		"id":                  id,
+		"properties_affected": len(propertyIDs),
+	})
+}
+
+// PurgeDeletedRecord permanently deletes a soft-deleted property, amenity or condition
+// and everything that refers to it. Properties that bookings refer to cannot be purged.
+func (h *Handler) PurgeDeletedRecord(c *gin.Context) {
+	kind, id, ok := deletedRecordID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.trashRepo.Purge(kind, id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound(deletedResources[kind]))
+			return
+		}
+		if errors.Is(err, database.ErrPurgeBlocked) {
+			c.Error(apierror.InvalidState("Bookings refer to this property; it can only be restored"))
+			return
+		}
+		log.Printf("Failed to purge %s %d: %v", kind, id, err)
+		c.Error(apierror.Internal(fmt.Sprintf("Failed to purge %s", deletedResources[kind])))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"purged": true, "id": id})
+}
+
+// deletedKind reads the :kind path parameter, writing an error response and returning
+// false if it names no records that can be restored or purged
+func deletedKind(c *gin.Context) (string, string, bool) {
+	kind := c.Param("kind")
+	resource, ok := deletedResources[kind]
+	if !ok {
+		c.Error(apierror.New(http.StatusNotFound, apierror.CodeNotFound, fmt.Sprintf("Unknown kind %q, expected properties, amenities or conditions", kind)))
+		return "", "", false
+	}
+	return kind, resource, true
+}
+
+// deletedRecordID reads the :kind and :id path parameters, writing an error response
+// and returning false if they are invalid
+func deletedRecordID(c *gin.Context) (string, uint, bool) {
+	kind, resource, ok := deletedKind(c)
+	if !ok {
+		return "", 0, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID(strings.ToLower(resource)))
+		return "", 0, false
+	}
+	return kind, uint(id), true
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/database"
+	"channelmanager/models"
+	"channelmanager/pii"
+	"channelmanager/quote"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// VoucherConfig holds the limits protecting vouchers from fraud
+type VoucherConfig struct {
+	MaxPerBooking       int     // vouchers one booking or quote may spend
+	MaxAmountPerBooking float64 // voucher value one booking may spend; 0 is unlimited
+	MaxAmount           float64 // largest voucher issued; 0 is unlimited
+
+	// A client trying MaxFailedAttempts unknown codes within FailureWindow is refused
+	// voucher lookups until the window ends, so codes cannot be guessed
+	MaxFailedAttempts int
+	FailureWindow     time.Duration
+}
+
+// voucherCodeAlphabet leaves out characters easily mistaken for others
+const voucherCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// voucherCodeLength is the number of characters of a voucher code, shown in groups of four
+const voucherCodeLength = 16
+
+// IssueVoucherRequest represents the payload for issuing a voucher
+type IssueVoucherRequest struct {
+	Amount    float64    `json:"amount" binding:"required,gt=0"`
+	Currency  string     `json:"currency" binding:"required,len=3,uppercase"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	Note      string     `json:"note" binding:"max=255"`
+}
+
+// VoucherBalanceRequest represents a guest's voucher balance check
+type VoucherBalanceRequest struct {
+	Code string `json:"code" binding:"required,max=40"`
+}
+
+// IssueVoucher issues a voucher worth an amount. The response holds its code, which is
+// not stored and cannot be retrieved again.
+func (h *Handler) IssueVoucher(c *gin.Context) {
+	var req IssueVoucherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+	if h.vouchers.MaxAmount > 0 && req.Amount > h.vouchers.MaxAmount {
+		c.Error(apierror.InvalidField("amount", "max", fmt.Sprintf("must not exceed %.2f", h.vouchers.MaxAmount)))
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.Error(apierror.InvalidField("expires_at", "gt", "must be in the future"))
+		return
+	}
+
+	code, err := newVoucherCode()
+	if err != nil {
+		c.Error(apierror.Internal("Failed to issue voucher"))
+		return
+	}
+	normalized := normalizeVoucherCode(code)
+	voucher := models.Voucher{
+		CodeHash:      pii.BlindIndex(normalized),
+		CodeSuffix:    normalized[len(normalized)-4:],
+		Currency:      req.Currency,
+		InitialAmount: roundTo(req.Amount, 2),
+		ExpiresAt:     req.ExpiresAt,
+		Note:          strings.TrimSpace(req.Note),
+	}
+	if err := h.voucherRepo.CreateVoucher(&voucher); err != nil {
+		log.Printf("Failed to issue voucher: %v", err)
+		c.Error(apierror.Internal("Failed to issue voucher"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": models.IssuedVoucher{Voucher: voucher, Code: code}})
+}
+
+// ListVouchers retrieves vouchers, newest first, optionally of one status
+func (h *Handler) ListVouchers(c *gin.Context) {
+	page := parsePage(c, 50, 200)
+
+	status := c.Query("status")
+	switch status {
+	case "", models.VoucherStatusActive, models.VoucherStatusVoid:
+	default:
+		c.Error(apierror.Validation("status must be active or void"))
+		return
+	}
+
+	vouchers, total, err := h.voucherRepo.ListVouchers(status, page.Limit, page.Offset())
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve vouchers"))
+		return
+	}
+
+	c.JSON(http.StatusOK, paginated(c, vouchers, total, page))
+}
+
+// GetVoucher retrieves a voucher with its balance changes
+func (h *Handler) GetVoucher(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("voucher"))
+		return
+	}
+
+	voucher, err := h.voucherRepo.GetVoucherByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Voucher"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to retrieve voucher"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": voucher})
+}
+
+// VoidVoucher withdraws a voucher, e.g. when its code leaked; its remaining balance can
+// no longer be spent
+func (h *Handler) VoidVoucher(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apierror.InvalidID("voucher"))
+		return
+	}
+
+	voucher, err := h.voucherRepo.VoidVoucher(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierror.NotFound("Voucher"))
+			return
+		}
+		if errors.Is(err, database.ErrVoucherUnavailable) {
+			c.Error(apierror.InvalidState("Voucher is already void"))
+			return
+		}
+		c.Error(apierror.Internal("Failed to void voucher"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": voucher})
+}
+
+// GetVoucherBalance lets a guest check what a voucher code is worth. The code is sent
+// in the body so it does not end up in access logs.
+func (h *Handler) GetVoucherBalance(c *gin.Context) {
+	var req VoucherBalanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBinding(err))
+		return
+	}
+
+	voucher, apiErr := h.lookupVoucher(c, req.Code, "code")
+	if apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"code_suffix": voucher.CodeSuffix,
+		"balance":     voucher.Balance,
+		"currency":    voucher.Currency,
+		"expires_at":  voucher.ExpiresAt,
+		"redeemable":  voucher.Redeemable(time.Now()),
+	}})
+}
+
+// applyVouchers pays part of a quote with vouchers, within the per-booking limits,
+// reporting an error when one cannot be spent
+func (h *Handler) applyVouchers(c *gin.Context, q *quote.Quote, codes []string) bool {
+	if len(codes) > h.vouchers.MaxPerBooking {
+		c.Error(apierror.InvalidField("voucher_codes", "max", fmt.Sprintf("at most %d vouchers can be spent on a booking", h.vouchers.MaxPerBooking)))
+		return false
+	}
+
+	seen := make(map[uint]bool, len(codes))
+	for _, code := range codes {
+		voucher, apiErr := h.lookupVoucher(c, code, "voucher_codes")
+		if apiErr != nil {
+			c.Error(apiErr)
+			return false
+		}
+		if seen[voucher.ID] {
+			c.Error(apierror.InvalidField("voucher_codes", "unique", "each voucher can be spent once per booking"))
+			return false
+		}
+		seen[voucher.ID] = true
+
+		if !voucher.Redeemable(time.Now()) {
+			c.Error(apierror.Unprocessable(fmt.Sprintf("Voucher ending %s is void, expired or spent", voucher.CodeSuffix)))
+			return false
+		}
+		if voucher.Currency != q.Currency {
+			c.Error(apierror.Unprocessable(fmt.Sprintf("Voucher ending %s is in %s and cannot pay a stay priced in %s", voucher.CodeSuffix, voucher.Currency, q.Currency)))
+			return false
+		}
+
+		limit := 0.0
+		if h.vouchers.MaxAmountPerBooking > 0 {
+			limit = h.vouchers.MaxAmountPerBooking - q.VoucherAmount
+			if limit <= 0 {
+				break
+			}
+		}
+		q.ApplyVoucher(*voucher, limit)
+	}
+	return true
+}
+
+// lookupVoucher finds the voucher of a code. Unknown codes count against the client, which
+// is refused lookups once it tried too many.
+func (h *Handler) lookupVoucher(c *gin.Context, code, field string) (*models.Voucher, *apierror.APIError) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	client := c.ClientIP()
+	if failures, err := h.redis.GetVoucherFailures(ctx, client); err != nil {
+		log.Printf("Failed to read voucher attempts of %s: %v", client, err)
+	} else if failures >= int64(h.vouchers.MaxFailedAttempts) {
+		return nil, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited, "Too many unknown voucher codes, try again later")
+	}
+
+	normalized := normalizeVoucherCode(code)
+	if len(normalized) != voucherCodeLength {
+		return nil, h.unknownVoucher(ctx, client, field)
+	}
+	voucher, err := h.voucherRepo.GetVoucherByCodeHash(pii.BlindIndex(normalized))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, h.unknownVoucher(ctx, client, field)
+		}
+		return nil, apierror.Internal("Failed to retrieve voucher")
+	}
+	return voucher, nil
+}
+
+// unknownVoucher counts an attempt with an unknown code against the client
+func (h *Handler) unknownVoucher(ctx context.Context, client, field string) *apierror.APIError {
+	if _, err := h.redis.RecordVoucherFailure(ctx, client, h.vouchers.FailureWindow); err != nil {
+		log.Printf("Failed to record voucher attempt of %s: %v", client, err)
+	}
+	return apierror.InvalidField(field, "exists", "unknown voucher code")
+}
+
+// newVoucherCode returns a random voucher code in groups of four characters
+func newVoucherCode() (string, error) {
+	b := make([]byte, voucherCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	var code strings.Builder
+	for i, v := range b {
+		if i > 0 && i%4 == 0 {
+			code.WriteByte('-')
+		}
+		// 256 is a multiple of the alphabet's 32 characters, so each is equally likely
+		code.WriteByte(voucherCodeAlphabet[int(v)%len(voucherCodeAlphabet)])
+	}
+	return code.String(), nil
+}
+
+// normalizeVoucherCode drops the separators and spaces guests type with a code
+func normalizeVoucherCode(code string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code))
+}
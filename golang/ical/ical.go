@@ -0,0 +1,192 @@
+// Package ical reads the events of iCalendar (RFC 5545) documents, such as the public
+// holiday calendars destinations are imported from, and writes the calendars hosts
+// subscribe to
+package ical
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxDocumentSize bounds the documents Fetch reads
+const maxDocumentSize = 4 << 20
+
+// Event is a VEVENT of a calendar. Recurrence rules are not expanded; public calendars
+// list every occurrence.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Status      string // e.g. CONFIRMED or CANCELLED; empty when not given
+	Start       time.Time
+	End         time.Time // exclusive
+	AllDay      bool      // Start and End are dates
+	Stamp       time.Time // when the event last changed; written as DTSTAMP
+}
+
+// Days returns the first and last calendar day the event covers, inclusive
+func (e Event) Days() (time.Time, time.Time) {
+	first := day(e.Start)
+	last := first
+	if e.End.After(e.Start) {
+		// End is exclusive, so an event ending at midnight does not cover that day
+		last = day(e.End.Add(-time.Nanosecond))
+	}
+	return first, last
+}
+
+// Cancelled reports whether the event was called off
+func (e Event) Cancelled() bool {
+	return strings.EqualFold(e.Status, "CANCELLED")
+}
+
+// Parse reads the events of a calendar document
+func Parse(r io.Reader) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var current *Event
+	var hasEnd bool
+	for n, line := range lines {
+		name, params, value, ok := property(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			current = &Event{}
+			hasEnd = false
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if current == nil {
+				return nil, fmt.Errorf("line %d: END:VEVENT without BEGIN", n+1)
+			}
+			if current.Start.IsZero() {
+				return nil, fmt.Errorf("line %d: event %q has no DTSTART", n+1, current.UID)
+			}
+			if !hasEnd {
+				// Without an end, a date event lasts the day and a timed one is an instant
+				current.End = current.Start
+				if current.AllDay {
+					current.End = current.Start.AddDate(0, 0, 1)
+				}
+			}
+			events = append(events, *current)
+			current = nil
+		case current == nil:
+		case name == "UID":
+			current.UID = value
+		case name == "SUMMARY":
+			current.Summary = unescape(value)
+		case name == "DESCRIPTION":
+			current.Description = unescape(value)
+		case name == "STATUS":
+			current.Status = strings.ToUpper(value)
+		case name == "DTSTART":
+			start, allDay, err := parseTime(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid DTSTART: %w", n+1, err)
+			}
+			current.Start, current.AllDay = start, allDay
+		case name == "DTEND":
+			end, _, err := parseTime(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid DTEND: %w", n+1, err)
+			}
+			current.End, hasEnd = end, true
+		}
+	}
+	return events, nil
+}
+
+// Fetch downloads a calendar and reads its events
+func Fetch(ctx context.Context, client *http.Client, url string) ([]Event, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/calendar")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("calendar returned %d", resp.StatusCode)
+	}
+	return Parse(io.LimitReader(resp.Body, maxDocumentSize))
+}
+
+// unfold joins the lines the document folded onto continuation lines
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxDocumentSize)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// property splits a content line into its upper-cased name, parameters and value
+func property(line string) (string, map[string]string, string, bool) {
+	colon := strings.IndexByte(line, ':')
+	if colon < 0 {
+		return "", nil, "", false
+	}
+	parts := strings.Split(line[:colon], ";")
+
+	params := make(map[string]string, len(parts)-1)
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:], true
+}
+
+// parseTime reads a DATE or DATE-TIME value; floating times without a TZID are read as UTC
+func parseTime(value string, params map[string]string) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.Parse("20060102", value)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	loc := time.UTC
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// unescape resolves the escapes of a TEXT value
+func unescape(value string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, " ", `\N`, " ").Replace(value)
+}
+
+// day returns the calendar day of a time in its own location, as midnight UTC
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
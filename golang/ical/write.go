@@ -0,0 +1,92 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxLineOctets is the longest content line before it is folded
+const maxLineOctets = 75
+
+// Calendar is a document of events to write
+type Calendar struct {
+	ProductID string // PRODID, e.g. -//Channel Manager//Bookings//EN
+	Name      string // shown by calendar applications as the calendar's name
+	Events    []Event
+}
+
+// Write writes a calendar document with CRLF line endings, folding long lines
+func Write(w io.Writer, calendar Calendar) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", calendar.ProductID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if calendar.Name != "" {
+		line("X-WR-CALNAME", escape(calendar.Name))
+	}
+	for _, event := range calendar.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", event.UID)
+		line("DTSTAMP", formatUTC(event.Stamp))
+		if event.AllDay {
+			line("DTSTART;VALUE=DATE", event.Start.Format("20060102"))
+			line("DTEND;VALUE=DATE", event.End.Format("20060102"))
+		} else {
+			line("DTSTART", formatUTC(event.Start))
+			line("DTEND", formatUTC(event.End))
+		}
+		line("SUMMARY", escape(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", escape(event.Description))
+		}
+		if event.Status != "" {
+			line("STATUS", event.Status)
+		}
+		line("TRANSP", "OPAQUE")
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// writeFolded writes a content line, continuing it on lines starting with a space
+// wherever it would pass maxLineOctets, without splitting a UTF-8 character
+func writeFolded(w *bufio.Writer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space of a continuation line counts towards its length
+		limit = maxLineOctets - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+// isRuneStart reports whether b begins a UTF-8 character
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// escape escapes a TEXT value
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(value)
+}
+
+// formatUTC formats a DATE-TIME value in UTC
+func formatUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
@@ -0,0 +1,225 @@
+package invoice
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"channelmanager/models"
+)
+
+// Document holds everything needed to render an invoice or receipt
+type Document struct {
+	Type     string
+	Number   string
+	IssuedAt time.Time
+	Currency string
+	Seller   models.SellerDetails
+	Booking  models.Booking
+	Property models.Property
+	Items    []models.PriceLineItem
+	Subtotal float64
+	Taxes    float64
+	Fees     float64
+	Discount float64
+	Total    float64
+
+	Branding     models.InvoiceBranding // optional styling of the organization
+	CancelPolicy string                 // the booking's cancellation policy, printed with its terms
+}
+
+// NewDocument builds an invoice document for a booking from the prices it was booked at,
+// so the document matches what the guest was charged even after rates change. Bookings
+// without a stored breakdown, such as channel-priced reservations, get one stay line
+// followed by the credit and vouchers paid. The items always add up to the total.
+func NewDocument(docType, number string, booking models.Booking, property models.Property, seller models.SellerDetails) *Document {
+	doc := &Document{
+		Type:         docType,
+		Number:       number,
+		IssuedAt:     time.Now(),
+		Currency:     booking.Currency,
+		Seller:       seller,
+		Booking:      booking,
+		Property:     property,
+		CancelPolicy: booking.CancelPolicy,
+	}
+
+	pricing := booking.Pricing
+	if pricing == nil {
+		pricing = channelPricing(booking)
+	}
+	doc.Subtotal = pricing.Subtotal
+	doc.Taxes = pricing.Taxes
+	doc.Fees = pricing.Fees
+	doc.Discount = pricing.Discounts
+	doc.Total = pricing.Total
+	doc.Items = pricing.Items
+	return doc
+}
+
+// channelPricing breaks down the total of a booking priced by its channel
+func channelPricing(booking models.Booking) *models.BookingPricing {
+	charged := booking.TotalPrice + booking.LoyaltyCredit + booking.VoucherAmount
+	pricing := &models.BookingPricing{
+		Subtotal:      charged,
+		Credit:        booking.LoyaltyCredit,
+		VoucherAmount: booking.VoucherAmount,
+		Total:         booking.TotalPrice,
+		Items: []models.PriceLineItem{{
+			Type: models.LineItemAccommodation,
+			Description: fmt.Sprintf("Stay %s to %s", booking.CheckinDate.Format("2006-01-02"),
+				booking.CheckoutDate.Format("2006-01-02")),
+			Amount: charged,
+		}},
+	}
+	if booking.LoyaltyCredit > 0 {
+		pricing.Items = append(pricing.Items, models.PriceLineItem{
+			Type: models.LineItemCredit, Description: "Loyalty credit", Amount: -booking.LoyaltyCredit,
+		})
+	}
+	if booking.VoucherAmount > 0 {
+		pricing.Items = append(pricing.Items, models.PriceLineItem{
+			Type: models.LineItemVoucher, Description: "Vouchers", Amount: -booking.VoucherAmount,
+		})
+	}
+	return pricing
+}
+
+// Render renders the document as a PDF
+func Render(doc *Document) []byte {
+	pdf := newPDFDocument()
+
+	title := "INVOICE"
+	if doc.Type == models.InvoiceTypeReceipt {
+		title = "RECEIPT"
+	}
+
+	// Branding heads the document and colors its title and headings
+	accent := parseColor(doc.Branding.AccentColor)
+	pdf.setColor(accent)
+	if doc.Branding.BrandName != "" {
+		pdf.text(50, 35, 12, true, doc.Branding.BrandName)
+	}
+	pdf.setColor(rgb{})
+	if doc.Branding.Website != "" {
+		pdf.text(350, 35, 9, false, doc.Branding.Website)
+	}
+
+	y := 60.0
+	pdf.setColor(accent)
+	pdf.text(50, y, 20, true, title)
+	pdf.setColor(rgb{})
+	pdf.text(350, y, 10, false, "Number: "+doc.Number)
+	pdf.text(350, y+14, 10, false, "Issued: "+doc.IssuedAt.Format("2006-01-02"))
+
+	// Seller block
+	y += 50
+	heading(pdf, y, accent, "Seller")
+	y += 14
+	for _, line := range []string{
+		doc.Seller.SellerLegalName,
+		doc.Seller.SellerAddress,
+		doc.Seller.SellerEmail,
+		doc.Seller.SellerPhone,
+	} {
+		if line == "" {
+			continue
+		}
+		pdf.text(50, y, 10, false, line)
+		y += 13
+	}
+	if doc.Seller.SellerTaxID != "" {
+		pdf.text(50, y, 10, false, "Tax ID: "+doc.Seller.SellerTaxID)
+		y += 13
+	}
+
+	// Booking block
+	y += 15
+	heading(pdf, y, accent, "Booking")
+	y += 14
+	for _, line := range []string{
+		fmt.Sprintf("Reference: #%d %s", doc.Booking.ID, doc.Booking.ExternalReference),
+		"Guest: " + doc.Booking.GuestName,
+		"Property: " + doc.Property.Name,
+		fmt.Sprintf("Stay: %s to %s (%d nights, %d guests)",
+			doc.Booking.CheckinDate.Format("2006-01-02"),
+			doc.Booking.CheckoutDate.Format("2006-01-02"),
+			doc.Booking.Nights(),
+			doc.Booking.NumberOfGuests,
+		),
+	} {
+		pdf.text(50, y, 10, false, line)
+		y += 13
+	}
+
+	// Line items
+	y += 20
+	pdf.text(50, y, 10, true, "Description")
+	pdf.text(450, y, 10, true, "Amount ("+doc.Currency+")")
+	y += 16
+	for _, item := range doc.Items {
+		if y > pageHeight-80 {
+			pdf.addPage()
+			y = 60
+		}
+		pdf.text(50, y, 10, false, item.Description)
+		pdf.text(450, y, 10, false, formatAmount(item.Amount))
+		y += 13
+	}
+
+	// Totals
+	if y > pageHeight-160 {
+		pdf.addPage()
+		y = 60
+	}
+	y += 15
+	pdf.text(330, y, 10, false, "Accommodation subtotal")
+	pdf.text(450, y, 10, false, formatAmount(doc.Subtotal))
+	y += 18
+	totalLabel := "Total due"
+	if doc.Type == models.InvoiceTypeReceipt {
+		totalLabel = "Total paid"
+	}
+	pdf.text(330, y, 11, true, totalLabel)
+	pdf.text(450, y, 11, true, formatAmount(doc.Total))
+
+	if terms := models.CancellationTerms(doc.CancelPolicy); terms != "" {
+		y += 30
+		heading(pdf, y, accent, "Cancellation policy")
+		pdf.text(50, y+14, 9, false, terms)
+	}
+
+	if doc.Seller.InvoiceFooterNotes != "" {
+		pdf.text(50, pageHeight-50, 9, false, doc.Seller.InvoiceFooterNotes)
+	}
+
+	return pdf.bytes()
+}
+
+// heading places a section heading in the accent color
+func heading(pdf *pdfDocument, y float64, accent rgb, text string) {
+	pdf.setColor(accent)
+	pdf.text(50, y, 11, true, text)
+	pdf.setColor(rgb{})
+}
+
+// parseColor parses a #RRGGBB color, returning black for anything else
+func parseColor(hex string) rgb {
+	if len(hex) != 7 || hex[0] != '#' {
+		return rgb{}
+	}
+	value, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		return rgb{}
+	}
+	return rgb{
+		r: float64(value>>16&0xff) / 255,
+		g: float64(value>>8&0xff) / 255,
+		b: float64(value&0xff) / 255,
+	}
+}
+
+// formatAmount formats a monetary amount with two decimals
+func formatAmount(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
@@ -0,0 +1,79 @@
+package invoice
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"channelmanager/models"
+	"channelmanager/quote"
+)
+
+// nightlyPricing prices each night of a stay at rate
+func nightlyPricing(checkin time.Time, nights int, rate float64) []models.Pricing {
+	pricing := make([]models.Pricing, nights)
+	for i := range pricing {
+		pricing[i] = models.Pricing{Date: checkin.AddDate(0, 0, i), BasePrice: rate}
+	}
+	return pricing
+}
+
+// itemsTotal adds up a document's line items
+func itemsTotal(doc *Document) float64 {
+	total := 0.0
+	for _, item := range doc.Items {
+		total += item.Amount
+	}
+	return math.Round(total*100) / 100
+}
+
+func TestNewDocumentKeepsBookedPrices(t *testing.T) {
+	checkin := time.Date(2030, 5, 1, 0, 0, 0, 0, time.UTC)
+	req := quote.Request{PropertyID: 1, CheckinDate: checkin, CheckoutDate: checkin.AddDate(0, 0, 3), Guests: 2}
+	rules := quote.Rules{
+		TaxRules: []models.TaxRule{{Name: "VAT", TaxType: "vat", Kind: models.TaxKindPercentage, Rate: 10}},
+		Fees:     []models.Fee{{Name: "Cleaning", Type: models.FeeTypeCleaning, Basis: models.FeePerStay, Amount: 50, Mandatory: true}},
+	}
+
+	booked := quote.Compute(req, nightlyPricing(checkin, 3, 100), rules)
+	booked.RedeemPoints(1000, 0.01)
+	booked.ApplyVoucher(models.Voucher{ID: 1, CodeSuffix: "ABCD", Balance: 20}, booked.Total)
+	booking := models.Booking{
+		ID: 42, PropertyID: 1, CheckinDate: checkin, CheckoutDate: checkin.AddDate(0, 0, 3), NumberOfGuests: 2,
+		Currency: "USD", TotalPrice: booked.Total, LoyaltyCredit: booked.Credit, VoucherAmount: booked.VoucherAmount,
+		Pricing: booked.Breakdown(),
+	}
+
+	// The rates change after the booking was made
+	repriced := quote.Compute(req, nightlyPricing(checkin, 3, 150), rules)
+	if repriced.Total == booking.TotalPrice {
+		t.Fatalf("repriced total %v should differ from the booked total", repriced.Total)
+	}
+
+	doc := NewDocument(models.InvoiceTypeInvoice, "INV-000042", booking, models.Property{ID: 1}, models.SellerDetails{})
+	if doc.Total != booking.TotalPrice {
+		t.Errorf("document total = %v, want the booked total %v", doc.Total, booking.TotalPrice)
+	}
+	if got := itemsTotal(doc); got != doc.Total {
+		t.Errorf("line items add up to %v, want %v", got, doc.Total)
+	}
+}
+
+func TestNewDocumentChannelPricedBooking(t *testing.T) {
+	checkin := time.Date(2030, 5, 1, 0, 0, 0, 0, time.UTC)
+	booking := models.Booking{
+		ID: 7, ChannelID: "vrbo", CheckinDate: checkin, CheckoutDate: checkin.AddDate(0, 0, 2),
+		Currency: "EUR", TotalPrice: 312.40, LoyaltyCredit: 10, VoucherAmount: 5,
+	}
+
+	doc := NewDocument(models.InvoiceTypeReceipt, "INV-R-000007", booking, models.Property{}, models.SellerDetails{})
+	if doc.Total != booking.TotalPrice || doc.Currency != "EUR" {
+		t.Errorf("document total = %v %s, want %v EUR", doc.Total, doc.Currency, booking.TotalPrice)
+	}
+	if doc.Subtotal != 327.40 {
+		t.Errorf("document subtotal = %v, want 327.40", doc.Subtotal)
+	}
+	if got := itemsTotal(doc); got != doc.Total {
+		t.Errorf("line items add up to %v, want %v", got, doc.Total)
+	}
+}
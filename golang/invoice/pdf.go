@@ -0,0 +1,136 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Page dimensions for A4 in PDF points
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+)
+
+// rgb is a fill color with components between 0 and 1; the zero value is black
+type rgb struct {
+	r, g, b float64
+}
+
+// pdfText is a single line of text placed on a page
+type pdfText struct {
+	x     float64
+	y     float64
+	size  float64
+	bold  bool
+	color rgb
+	text  string
+}
+
+// pdfDocument renders simple text-only PDF documents using the standard
+// Helvetica fonts, which every PDF reader ships with
+type pdfDocument struct {
+	pages [][]pdfText
+	color rgb // of text placed from now on
+}
+
+// newPDFDocument creates a document with a single empty page
+func newPDFDocument() *pdfDocument {
+	return &pdfDocument{pages: [][]pdfText{{}}}
+}
+
+// addPage starts a new page
+func (d *pdfDocument) addPage() {
+	d.pages = append(d.pages, []pdfText{})
+}
+
+// setColor sets the color of text placed from now on
+func (d *pdfDocument) setColor(color rgb) {
+	d.color = color
+}
+
+// text places a line of text on the current page; y is measured from the top
+func (d *pdfDocument) text(x, y, size float64, bold bool, text string) {
+	last := len(d.pages) - 1
+	d.pages[last] = append(d.pages[last], pdfText{
+		x:     x,
+		y:     pageHeight - y,
+		size:  size,
+		bold:  bold,
+		color: d.color,
+		text:  text,
+	})
+}
+
+// bytes serializes the document into PDF 1.4
+func (d *pdfDocument) bytes() []byte {
+	var buf bytes.Buffer
+	var offsets []int
+
+	writeObject := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	// Objects 1-4 are fixed: catalog, page tree, regular and bold fonts.
+	// Each page then takes two objects: the page and its content stream.
+	kids := make([]string, 0, len(d.pages))
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 5+i*2))
+	}
+
+	writeObject("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range d.pages {
+		writeObject(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+i*2,
+		))
+
+		var content bytes.Buffer
+		for _, t := range page {
+			font := "F1"
+			if t.bold {
+				font = "F2"
+			}
+			color := ""
+			if t.color != (rgb{}) {
+				color = fmt.Sprintf("%.3f %.3f %.3f rg ", t.color.r, t.color.g, t.color.b)
+			}
+			fmt.Fprintf(&content, "BT %s/%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", color, font, t.size, t.x, t.y, escapePDFString(t.text))
+		}
+		writeObject(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xrefOffset)
+
+	return buf.Bytes()
+}
+
+// escapePDFString escapes a string for use in a PDF literal string,
+// replacing characters outside printable ASCII
+func escapePDFString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteRune('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
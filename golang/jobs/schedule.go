@@ -0,0 +1,206 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule determines when a job runs
+type Schedule interface {
+	// Next returns the first run time after the given time
+	Next(after time.Time) time.Time
+	// String returns the schedule in the form it is parsed from
+	String() string
+}
+
+// Every returns a schedule that runs at a fixed interval
+func Every(interval time.Duration) Schedule {
+	return intervalSchedule(interval)
+}
+
+// intervalSchedule runs at the multiples of a fixed duration since the Unix epoch, so
+// replicas started at different times agree on the run times
+type intervalSchedule time.Duration
+
+func (s intervalSchedule) Next(after time.Time) time.Time {
+	interval := int64(s)
+	slot := after.UnixNano()/interval*interval + interval
+	return time.Unix(0, slot).In(after.Location())
+}
+
+func (s intervalSchedule) String() string {
+	return "@every " + time.Duration(s).String()
+}
+
+// Shorthands accepted in place of the five cron fields
+var cronShorthands = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// cronField describes the range of one of the five cron fields
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// cronSchedule is a standard five-field cron expression evaluated in UTC
+type cronSchedule struct {
+	spec                     string
+	minute, hour, dom, month uint64 // bit n is set when value n matches
+	dow                      uint64
+	domAny, dowAny           bool
+}
+
+// ParseSchedule parses "@every <duration>", a shorthand such as "@daily", or a
+// five-field cron expression ("minute hour day-of-month month day-of-week") whose
+// fields accept *, values, ranges, lists and steps, e.g. "*/15 6-22 * * 1-5".
+// Cron expressions are evaluated in UTC.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || interval < time.Second {
+			return nil, fmt.Errorf("invalid interval in schedule %q", spec)
+		}
+		return Every(interval), nil
+	}
+
+	expr := spec
+	if expanded, ok := cronShorthands[spec]; ok {
+		expr = expanded
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("schedule %q must have %d fields", spec, len(cronFields))
+	}
+
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", spec, err)
+		}
+		sets[i] = set
+	}
+
+	return &cronSchedule{
+		spec:   spec,
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: strings.HasPrefix(fields[2], "*"),
+		dowAny: strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// parseCronField parses a comma-separated list of *, n, a-b, */s, a-b/s or a/s
+func parseCronField(field string, spec cronField) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q in %s", stepPart, spec.name)
+			}
+		}
+
+		low, high := spec.min, spec.max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid %s %q", spec.name, rangePart)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid %s %q", spec.name, rangePart)
+				}
+			} else if hasStep {
+				high = spec.max
+			}
+		}
+
+		// Sunday may be written as 7
+		if spec.name == "day of week" && high == 7 {
+			set |= 1
+			if low == 7 {
+				continue
+			}
+			high = 6
+		}
+		if low < spec.min || high > spec.max || low > high {
+			return 0, fmt.Errorf("%s %q out of range %d-%d", spec.name, rangePart, spec.min, spec.max)
+		}
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func (s *cronSchedule) String() string {
+	return s.spec
+}
+
+// Next returns the first matching minute after the given time, or the zero time when
+// the expression never matches (e.g. February 30)
+func (s *cronSchedule) Next(after time.Time) time.Time {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches applies cron's day rule: when both day of month and day of week are
+// restricted, a day matching either runs
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dowMatch
+	case s.dowAny:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
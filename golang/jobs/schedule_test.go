@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIntervalScheduleAlignsReplicas(t *testing.T) {
+	schedule := Every(15 * time.Minute)
+	want := time.Date(2030, 6, 3, 10, 15, 0, 0, time.UTC)
+
+	// Replicas started at different times agree on the next run
+	for _, after := range []time.Time{
+		time.Date(2030, 6, 3, 10, 0, 0, 0, time.UTC),
+		time.Date(2030, 6, 3, 10, 3, 17, 0, time.UTC),
+		time.Date(2030, 6, 3, 10, 14, 59, 999, time.UTC),
+	} {
+		if got := schedule.Next(after); !got.Equal(want) {
+			t.Errorf("Next(%v) = %v, want %v", after, got, want)
+		}
+	}
+	if got := schedule.Next(want); !got.Equal(want.Add(15 * time.Minute)) {
+		t.Errorf("Next(%v) = %v, want the following slot", want, got)
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	after := time.Date(2030, 6, 3, 10, 7, 30, 0, time.UTC) // a Monday
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"@every 1h", time.Date(2030, 6, 3, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2030, 6, 4, 0, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2030, 6, 3, 10, 15, 0, 0, time.UTC)},
+		{"30 6 * * 0", time.Date(2030, 6, 9, 6, 30, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q) error = %v", tt.spec, err)
+		}
+		if got := schedule.Next(after); !got.Equal(tt.want) {
+			t.Errorf("ParseSchedule(%q).Next() = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"@every 10ms", "* * * *", "60 * * * *", "*/0 * * * *"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want an error", spec)
+		}
+	}
+}
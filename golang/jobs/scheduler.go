@@ -0,0 +1,357 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"channelmanager/cache"
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// Config holds background job scheduling configuration
+type Config struct {
+	Instance string        // identifies this replica in job locks, run history and event claims
+	LockTTL  time.Duration // how long a job lock outlives a replica that stopped renewing it
+}
+
+// DefaultLockTTL is used when no lock TTL is configured
+const DefaultLockTTL = 5 * time.Minute
+
+// DefaultInstance returns the host name, which identifies a replica across restarts
+func DefaultInstance() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "unknown"
+	}
+	return host
+}
+
+// Func is the work of a job; ctx is cancelled when the scheduler stops or the job's
+// lock is lost
+type Func func(ctx context.Context) error
+
+var (
+	// ErrUnknownJob is returned when triggering a job that is not registered
+	ErrUnknownJob = errors.New("unknown job")
+	// ErrJobRunning is returned when triggering a job that is running on any replica
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// Info describes a registered job
+type Info struct {
+	Name     string     `json:"name"`
+	Schedule string     `json:"schedule"`
+	NextRun  *time.Time `json:"next_run"`
+	Running  bool       `json:"running"` // running on this replica
+}
+
+// job is a registered job and its scheduling state
+type job struct {
+	name     string
+	schedule Schedule
+	run      Func
+	next     time.Time
+	running  bool
+}
+
+// Scheduler runs registered jobs on their schedules. Every replica runs a scheduler;
+// the first replica to claim a scheduled run time in Redis runs it, a Redis lock per
+// job ensures only one replica runs a job at a time, and every run is recorded in the
+// run history.
+type Scheduler struct {
+	config Config
+	redis  *cache.RedisClient
+	runs   *database.JobRunRepository
+
+	mu   sync.Mutex
+	jobs map[string]*job
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	ticker *time.Ticker
+	done   chan bool
+}
+
+// NewScheduler creates a new job scheduler
+func NewScheduler(db *gorm.DB, redis *cache.RedisClient, config Config) *Scheduler {
+	if config.LockTTL <= 0 {
+		config.LockTTL = DefaultLockTTL
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		config: config,
+		redis:  redis,
+		runs:   database.NewJobRunRepository(db),
+		jobs:   make(map[string]*job),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan bool),
+	}
+}
+
+// Register adds a job; jobs must be registered before Start
+func (s *Scheduler) Register(name string, schedule Schedule, run Func) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.jobs[name]; exists {
+		return fmt.Errorf("job %s is already registered", name)
+	}
+	s.jobs[name] = &job{name: name, schedule: schedule, run: run}
+	return nil
+}
+
+// Jobs lists the registered jobs by name
+func (s *Scheduler) Jobs() []Info {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := make([]Info, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		info := Info{Name: j.name, Schedule: j.schedule.String(), Running: j.running}
+		if !j.next.IsZero() {
+			next := j.next
+			info.NextRun = &next
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, k int) bool { return infos[i].Name < infos[k].Name })
+	return infos
+}
+
+// Start begins running jobs on their schedules
+func (s *Scheduler) Start() {
+	if abandoned, err := s.runs.AbandonRuns(s.config.Instance); err != nil {
+		log.Printf("Failed to close interrupted job runs: %v", err)
+	} else if abandoned > 0 {
+		log.Printf("Marked %d interrupted job runs as failed", abandoned)
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	for _, j := range s.jobs {
+		j.next = j.schedule.Next(now)
+	}
+	s.mu.Unlock()
+
+	s.ticker = time.NewTicker(time.Second)
+	go func() {
+		log.Printf("Job scheduler started with %d jobs", len(s.jobs))
+		for {
+			select {
+			case now := <-s.ticker.C:
+				s.dispatch(now)
+			case <-s.done:
+				log.Println("Job scheduler stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops scheduling, cancels running jobs and waits for them to return
+func (s *Scheduler) Stop() {
+	if s.ticker != nil {
+		s.ticker.Stop()
+		s.done <- true
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+// Trigger runs a job now on this replica and returns its run, unless the job is
+// already running on any replica
+func (s *Scheduler) Trigger(name string) (*models.JobRun, error) {
+	s.mu.Lock()
+	j, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrUnknownJob
+	}
+
+	run, err := s.begin(j, models.JobTriggerManual)
+	if err != nil {
+		return nil, err
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.execute(j, run)
+	}()
+	return run, nil
+}
+
+// dispatch starts the jobs that are due
+func (s *Scheduler) dispatch(now time.Time) {
+	type dueJob struct {
+		job        *job
+		slot, next time.Time
+	}
+	var due []dueJob
+	s.mu.Lock()
+	for _, j := range s.jobs {
+		if j.next.IsZero() || now.Before(j.next) {
+			continue
+		}
+		slot := j.next
+		j.next = j.schedule.Next(now)
+		due = append(due, dueJob{job: j, slot: slot, next: j.next})
+	}
+	s.mu.Unlock()
+
+	for _, d := range due {
+		s.wg.Add(1)
+		go func(d dueJob) {
+			defer s.wg.Done()
+			claimed, err := s.claimSlot(d.job, d.slot, d.next)
+			if err != nil {
+				log.Printf("Failed to claim scheduled run of job %s: %v", d.job.name, err)
+				return
+			}
+			if !claimed {
+				return
+			}
+			run, err := s.begin(d.job, models.JobTriggerSchedule)
+			if err != nil {
+				if !errors.Is(err, ErrJobRunning) {
+					log.Printf("Failed to start job %s: %v", d.job.name, err)
+				}
+				return
+			}
+			s.execute(d.job, run)
+		}(d)
+	}
+}
+
+// claimSlot claims the run scheduled at slot for this replica. The claim is kept until
+// the next scheduled run, so replicas that reach the slot later leave it alone.
+func (s *Scheduler) claimSlot(j *job, slot, next time.Time) (bool, error) {
+	ttl := time.Until(next)
+	if next.IsZero() || ttl <= 0 {
+		ttl = s.config.LockTTL
+	}
+	return s.redis.AcquireLock(s.ctx, slotLockName(j.name, slot), s.config.Instance, ttl)
+}
+
+// begin takes the job's lock and records the start of a run
+func (s *Scheduler) begin(j *job, trigger string) (*models.JobRun, error) {
+	s.mu.Lock()
+	if j.running {
+		s.mu.Unlock()
+		return nil, ErrJobRunning
+	}
+	j.running = true
+	s.mu.Unlock()
+
+	acquired, err := s.redis.AcquireLock(s.ctx, lockName(j.name), s.config.Instance, s.config.LockTTL)
+	if err != nil || !acquired {
+		s.setRunning(j, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire job lock: %w", err)
+		}
+		return nil, ErrJobRunning
+	}
+
+	run := &models.JobRun{
+		Job:       j.name,
+		Trigger:   trigger,
+		Instance:  s.config.Instance,
+		Status:    models.JobRunRunning,
+		StartedAt: time.Now(),
+	}
+	if err := s.runs.CreateRun(run); err != nil {
+		s.release(j)
+		return nil, fmt.Errorf("failed to record job run: %w", err)
+	}
+	return run, nil
+}
+
+// execute runs a started job, renewing its lock while it runs, and records the outcome
+func (s *Scheduler) execute(j *job, run *models.JobRun) {
+	defer s.release(j)
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	stopRenewal := make(chan struct{})
+	go s.renewLock(j, stopRenewal, cancel)
+
+	err := s.call(ctx, j)
+	close(stopRenewal)
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.DurationMs = finished.Sub(run.StartedAt).Milliseconds()
+	run.Status = models.JobRunSucceeded
+	if err != nil {
+		run.Status = models.JobRunFailed
+		run.Error = err.Error()
+		log.Printf("Job %s failed: %v", j.name, err)
+	}
+	if err := s.runs.FinishRun(run); err != nil {
+		log.Printf("Failed to record outcome of job %s run %d: %v", j.name, run.ID, err)
+	}
+}
+
+// call runs the job's function, turning a panic into an error
+func (s *Scheduler) call(ctx context.Context, j *job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return j.run(ctx)
+}
+
+// renewLock extends the job's lock until stop is closed, so long runs keep it. When the
+// lock is lost another replica may start the job, so the run is cancelled.
+func (s *Scheduler) renewLock(j *job, stop <-chan struct{}, cancel context.CancelFunc) {
+	ticker := time.NewTicker(s.config.LockTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			held, err := s.redis.ExtendLock(context.Background(), lockName(j.name), s.config.Instance, s.config.LockTTL)
+			if err != nil {
+				log.Printf("Failed to renew lock of job %s: %v", j.name, err)
+			} else if !held {
+				log.Printf("Lock of job %s expired while it was running, cancelling the run", j.name)
+				cancel()
+				return
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// release frees the job's lock and marks it idle on this replica
+func (s *Scheduler) release(j *job) {
+	if err := s.redis.ReleaseLock(context.Background(), lockName(j.name), s.config.Instance); err != nil {
+		log.Printf("Failed to release lock of job %s: %v", j.name, err)
+	}
+	s.setRunning(j, false)
+}
+
+func (s *Scheduler) setRunning(j *job, running bool) {
+	s.mu.Lock()
+	j.running = running
+	s.mu.Unlock()
+}
+
+func lockName(job string) string {
+	return "job:" + job
+}
+
+func slotLockName(job string, slot time.Time) string {
+	return fmt.Sprintf("job:%s:%d", job, slot.Unix())
+}
@@ -0,0 +1,153 @@
+package ledger
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// Config holds payout ledger configuration
+type Config struct {
+	PlatformFeePercent       float64
+	ChannelCommissionPercent float64 // default for OTA bookings whose channel has no commission configured
+}
+
+// Service records ledger entries for bookings and builds payout statements
+type Service struct {
+	config      Config
+	ledgerRepo  *database.LedgerRepository
+	channelRepo *database.ChannelRepository
+}
+
+// NewService creates a new ledger service
+func NewService(db *gorm.DB, config Config) *Service {
+	return &Service{
+		config:      config,
+		ledgerRepo:  database.NewLedgerRepository(db),
+		channelRepo: database.NewChannelRepository(db),
+	}
+}
+
+// PeriodBounds parses a YYYY-MM period into its [start, end) date range
+func PeriodBounds(period string) (time.Time, time.Time, error) {
+	start, err := time.Parse("2006-01", period)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid period %q, expected YYYY-MM", period)
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// CommissionPercent returns the commission taken by the channel a booking came from
+func (s *Service) CommissionPercent(channelID string, channels map[string]models.Channel) float64 {
+	if channelID == "" || channelID == "direct" {
+		return 0
+	}
+	if channel, ok := channels[channelID]; ok && channel.CommissionPercent > 0 {
+		return channel.CommissionPercent
+	}
+	return s.config.ChannelCommissionPercent
+}
+
+// EntriesForBooking computes the ledger entries for a single booking
+func (s *Service) EntriesForBooking(booking models.Booking, organizationID uint, period string, commissionPercent float64) []models.LedgerEntry {
+	base := models.LedgerEntry{
+		OrganizationID: organizationID,
+		Period:         period,
+		PropertyID:     booking.PropertyID,
+		BookingID:      booking.ID,
+		ChannelID:      booking.ChannelID,
+		Currency:       booking.Currency,
+	}
+
+	// Vouchers were paid for when they were bought, so what they cover is revenue too
+	revenue := base
+	revenue.EntryType = models.LedgerEntryBookingRevenue
+	revenue.Amount = roundAmount(booking.TotalPrice + booking.VoucherAmount)
+	entries := []models.LedgerEntry{revenue}
+
+	if commissionPercent > 0 {
+		commission := base
+		commission.EntryType = models.LedgerEntryChannelCommission
+		commission.Amount = -roundAmount(booking.TotalPrice * commissionPercent / 100)
+		entries = append(entries, commission)
+	}
+
+	if s.config.PlatformFeePercent > 0 {
+		fee := base
+		fee.EntryType = models.LedgerEntryPlatformFee
+		fee.Amount = -roundAmount((booking.TotalPrice + booking.VoucherAmount) * s.config.PlatformFeePercent / 100)
+		entries = append(entries, fee)
+	}
+
+	return entries
+}
+
+// GenerateStatements records ledger entries for all bookings checking out in the
+// period and refreshes the pending payout statement of every owner
+func (s *Service) GenerateStatements(period string) ([]models.PayoutStatement, error) {
+	start, end, err := PeriodBounds(period)
+	if err != nil {
+		return nil, err
+	}
+
+	bookings, err := s.ledgerRepo.GetBookingsCheckingOutBetween(start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bookings: %w", err)
+	}
+
+	channels, err := s.channelRepo.GetChannelsByID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load channels: %w", err)
+	}
+
+	var entries []models.LedgerEntry
+	for _, booking := range bookings {
+		if booking.Property == nil || booking.Property.OrganizationID == nil {
+			log.Printf("Skipping booking %d in ledger: property has no owner", booking.ID)
+			continue
+		}
+		commission := s.CommissionPercent(booking.ChannelID, channels)
+		entries = append(entries, s.EntriesForBooking(booking, *booking.Property.OrganizationID, period, commission)...)
+	}
+
+	if err := s.ledgerRepo.CreateEntries(entries); err != nil {
+		return nil, fmt.Errorf("failed to record ledger entries: %w", err)
+	}
+
+	summaries, err := s.ledgerRepo.SummarizePeriod(period)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize ledger: %w", err)
+	}
+
+	statements := make([]models.PayoutStatement, 0, len(summaries))
+	for _, summary := range summaries {
+		statement := models.PayoutStatement{
+			OrganizationID:     summary.OrganizationID,
+			Period:             period,
+			GrossRevenue:       roundAmount(summary.GrossRevenue),
+			ChannelCommissions: roundAmount(-summary.ChannelCommissions),
+			PlatformFees:       roundAmount(-summary.PlatformFees),
+			NetPayout:          roundAmount(summary.GrossRevenue + summary.ChannelCommissions + summary.PlatformFees),
+			Currency:           summary.Currency,
+			BookingCount:       summary.BookingCount,
+		}
+		if err := s.ledgerRepo.SaveStatement(&statement); err != nil {
+			return nil, fmt.Errorf("failed to save payout statement: %w", err)
+		}
+		statements = append(statements, statement)
+	}
+
+	log.Printf("Generated %d payout statements for %s", len(statements), period)
+	return statements, nil
+}
+
+// roundAmount rounds a monetary amount to cents
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
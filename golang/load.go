@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"channelmanager/loadtest"
+	"channelmanager/utils"
+)
+
+// runLoadTest implements the loadtest subcommand, which replays realistic searches
+// against a running server, typically one seeded with "channelmanager seed":
+//
+//	channelmanager loadtest -url http://localhost:8080 -concurrency 20 -duration 1m
+//
+// The search code it exercises also has Go benchmarks, which need no server:
+//
+//	go test -run '^$' -bench . ./ranking ./handlers ./database
+func runLoadTest(args []string) error {
+	flags := flag.NewFlagSet("loadtest", flag.ExitOnError)
+	url := flags.String("url", "http://localhost:8080", "base URL of the API")
+	apiKey := flags.String("api-key", "", "API key sent as X-API-Key")
+	concurrency := flags.Int("concurrency", 10, "number of parallel clients")
+	duration := flags.Duration("duration", 30*time.Second, "how long to run")
+	requests := flags.Int("requests", 0, "total requests to send instead of running for -duration")
+	timeout := flags.Duration("timeout", 10*time.Second, "per-request timeout")
+	cities := flags.String("cities", "", "comma-separated seeded cities to search (default all)")
+	distinct := flags.Int("distinct", 500, "number of distinct searches; fewer means more cache hits")
+	randSeed := flags.Int64("rand-seed", time.Now().UnixNano(), "seed for the generated searches")
+	flags.Parse(args)
+
+	if *concurrency < 1 || *requests < 0 || (*requests == 0 && *duration <= 0) {
+		return fmt.Errorf("-concurrency must be positive and -requests or -duration must be set")
+	}
+
+	var searchCities []utils.SyntheticCity
+	for _, name := range strings.Split(*cities, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		found := false
+		for _, city := range utils.SyntheticCities {
+			if strings.EqualFold(city.Name, name) {
+				searchCities = append(searchCities, city)
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown city %q", name)
+		}
+	}
+
+	// Ctrl-C stops the run early and still prints the report
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := loadtest.Run(ctx, loadtest.Config{
+		BaseURL:     *url,
+		APIKey:      *apiKey,
+		Concurrency: *concurrency,
+		Duration:    *duration,
+		Requests:    *requests,
+		Timeout:     *timeout,
+	}, loadtest.NewFilterGenerator(searchCities, *distinct, *randSeed))
+	if err != nil {
+		return err
+	}
+	fmt.Print(report)
+	return nil
+}
@@ -0,0 +1,118 @@
+package loadtest
+
+import (
+	"math/rand"
+	"time"
+
+	"channelmanager/models"
+	"channelmanager/utils"
+)
+
+// FilterGenerator produces search filters shaped like production traffic. Most searches
+// are for a city and dates, fewer use free-text locations or a map area, and filters
+// such as guests, stars and price ranges are added with decreasing likelihood.
+//
+// Filters are drawn from a fixed pool with a Zipf distribution, so popular searches
+// repeat and the cache hit rate resembles real traffic instead of being all misses.
+type FilterGenerator struct {
+	random *rand.Rand
+	pool   []models.SearchFilter
+	zipf   *rand.Zipf
+}
+
+// NewFilterGenerator creates a generator over a pool of distinct filters for the given
+// cities, which should match the seeded data; seed makes the sequence reproducible
+func NewFilterGenerator(cities []utils.SyntheticCity, poolSize int, seed int64) *FilterGenerator {
+	if len(cities) == 0 {
+		cities = utils.SyntheticCities
+	}
+	if poolSize < 2 {
+		poolSize = 2
+	}
+
+	random := rand.New(rand.NewSource(seed))
+	pool := make([]models.SearchFilter, poolSize)
+	for i := range pool {
+		pool[i] = randomFilter(random, cities[random.Intn(len(cities))])
+	}
+	return &FilterGenerator{
+		random: random,
+		pool:   pool,
+		zipf:   rand.NewZipf(random, 1.1, 1, uint64(poolSize-1)),
+	}
+}
+
+// Next returns the next filter; it is not safe for concurrent use
+func (g *FilterGenerator) Next() models.SearchFilter {
+	return g.pool[g.zipf.Uint64()]
+}
+
+// randomFilter builds one search in city
+func randomFilter(random *rand.Rand, city utils.SyntheticCity) models.SearchFilter {
+	var filter models.SearchFilter
+
+	switch roll := random.Float64(); {
+	case roll < 0.6:
+		filter.City = city.Name
+	case roll < 0.8:
+		filter.Location = city.Name
+		if city.State != "" {
+			filter.Location += ", " + city.State
+		}
+	default:
+		latitude := city.Latitude + (random.Float64()-0.5)*0.1
+		longitude := city.Longitude + (random.Float64()-0.5)*0.1
+		filter.Latitude, filter.Longitude = &latitude, &longitude
+		filter.RadiusKm = float64(2 + random.Intn(4)*3)
+	}
+
+	// Most searches have dates, mostly in the next two months for a few nights
+	if random.Float64() < 0.85 {
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		filter.CheckinDate = today.AddDate(0, 0, 1+int(random.ExpFloat64()*14)%60)
+		filter.CheckoutDate = filter.CheckinDate.AddDate(0, 0, 1+random.Intn(6))
+	}
+
+	guests := []int{1, 2, 2, 2, 2, 3, 4, 4, 5, 6}
+	filter.NumberOfGuests = guests[random.Intn(len(guests))]
+
+	if random.Float64() < 0.25 {
+		filter.StarRatings = []int{4, 5}
+		if random.Intn(2) == 0 {
+			filter.StarRatings = []int{3, 4, 5}
+		}
+	}
+	if random.Float64() < 0.2 {
+		filter.MinRating = 4
+	}
+	if random.Float64() < 0.3 {
+		filter.MaxPrice = float64(100 + random.Intn(8)*50)
+	}
+	if random.Float64() < 0.1 {
+		petFriendly := true
+		filter.PetFriendly = &petFriendly
+	}
+	if random.Float64() < 0.15 {
+		filter.AmenityIDs = []int64{int64(1 + random.Intn(10))}
+	}
+
+	switch roll := random.Float64(); {
+	case roll < 0.5:
+	case roll < 0.7:
+		filter.SortBy = "price"
+	case roll < 0.85:
+		filter.SortBy = "rating"
+	default:
+		filter.SortBy = "relevance"
+	}
+	if filter.Latitude != nil && random.Intn(2) == 0 {
+		filter.SortBy = "distance"
+	}
+
+	// Few users page past the first results
+	filter.Page = 1
+	if random.Float64() < 0.15 {
+		filter.Page = 2 + random.Intn(3)
+	}
+	return filter
+}
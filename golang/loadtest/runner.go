@@ -0,0 +1,191 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config holds the settings of a load test run
+type Config struct {
+	BaseURL     string        // API root, e.g. http://localhost:8080
+	APIKey      string        // sent as X-API-Key when set
+	Concurrency int           // number of parallel clients
+	Duration    time.Duration // how long to run; ignored when Requests is set
+	Requests    int           // total number of requests to send; zero runs for Duration
+	Timeout     time.Duration // per-request client timeout
+}
+
+// Report summarizes a load test run
+type Report struct {
+	Requests   int
+	Errors     int         // transport failures and non-2xx responses
+	StatusCode map[int]int // responses by HTTP status
+	CacheHits  int         // successful searches answered from the cache
+	Elapsed    time.Duration
+	P50        time.Duration
+	P95        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+}
+
+// CacheHitRate is the share of successful searches answered from the cache
+func (r Report) CacheHitRate() float64 {
+	successful := r.Requests - r.Errors
+	if successful == 0 {
+		return 0
+	}
+	return float64(r.CacheHits) / float64(successful)
+}
+
+// String formats the report for the terminal
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "requests:   %d in %s (%.1f req/s)\n", r.Requests, r.Elapsed.Round(time.Millisecond),
+		float64(r.Requests)/r.Elapsed.Seconds())
+	fmt.Fprintf(&b, "errors:     %d\n", r.Errors)
+	codes := make([]int, 0, len(r.StatusCode))
+	for code := range r.StatusCode {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		fmt.Fprintf(&b, "  %d:      %d\n", code, r.StatusCode[code])
+	}
+	fmt.Fprintf(&b, "latency:    p50 %s, p95 %s, p99 %s, max %s\n", r.P50, r.P95, r.P99, r.Max)
+	fmt.Fprintf(&b, "cache hits: %.1f%%\n", r.CacheHitRate()*100)
+	return b.String()
+}
+
+// result is the outcome of one request
+type result struct {
+	latency time.Duration
+	status  int // 0 for transport failures
+	cached  bool
+}
+
+// Run replays searches from the generator against POST /api/v1/properties/search and
+// reports latency percentiles, errors and the cache hit rate
+func Run(ctx context.Context, cfg Config, generator *FilterGenerator) (Report, error) {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	if cfg.Requests == 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+	url := strings.TrimSuffix(cfg.BaseURL, "/") + "/api/v1/properties/search"
+	client := &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: &http.Transport{MaxIdleConnsPerHost: cfg.Concurrency},
+	}
+
+	// Bodies are generated up front by one goroutine, the generator is not concurrent
+	bodies := make(chan []byte, cfg.Concurrency)
+	go func() {
+		defer close(bodies)
+		for sent := 0; cfg.Requests == 0 || sent < cfg.Requests; sent++ {
+			body, err := json.Marshal(generator.Next())
+			if err != nil {
+				return
+			}
+			select {
+			case bodies <- body:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		mu      sync.Mutex
+		results []result
+		wg      sync.WaitGroup
+	)
+	started := time.Now()
+	for i := 0; i < cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for body := range bodies {
+				res, ok := search(ctx, client, url, cfg.APIKey, body)
+				if !ok {
+					return
+				}
+				mu.Lock()
+				results = append(results, res)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(results) == 0 {
+		return Report{}, fmt.Errorf("no requests completed against %s", url)
+	}
+	return summarize(results, time.Since(started)), nil
+}
+
+// search sends one search; ok is false when the run ended before it completed
+func search(ctx context.Context, client *http.Client, url, apiKey string, body []byte) (result, bool) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return result{}, false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+
+	started := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return result{}, false
+		}
+		return result{latency: time.Since(started)}, true
+	}
+	defer resp.Body.Close()
+
+	var page struct {
+		Cached bool `json:"cached"`
+	}
+	decodeErr := json.NewDecoder(resp.Body).Decode(&page)
+	io.Copy(io.Discard, resp.Body)
+	latency := time.Since(started)
+	if decodeErr != nil && ctx.Err() != nil {
+		return result{}, false
+	}
+	return result{latency: latency, status: resp.StatusCode, cached: page.Cached}, true
+}
+
+// summarize computes the report of a run
+func summarize(results []result, elapsed time.Duration) Report {
+	report := Report{Requests: len(results), StatusCode: make(map[int]int), Elapsed: elapsed}
+	latencies := make([]time.Duration, len(results))
+	for i, res := range results {
+		latencies[i] = res.latency
+		report.StatusCode[res.status]++
+		if res.status < 200 || res.status > 299 {
+			report.Errors++
+		} else if res.cached {
+			report.CacheHits++
+		}
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	percentile := func(p float64) time.Duration {
+		return latencies[int(p*float64(len(latencies)-1))].Round(10 * time.Microsecond)
+	}
+	report.P50, report.P95, report.P99 = percentile(0.50), percentile(0.95), percentile(0.99)
+	report.Max = latencies[len(latencies)-1].Round(10 * time.Microsecond)
+	return report
+}
@@ -0,0 +1,86 @@
+package locale
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var tagPattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// Normalize lowercases a language tag and converts underscores to hyphens ("pt_BR" -> "pt-br")
+func Normalize(tag string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(tag)), "_", "-")
+}
+
+// Valid reports whether a normalized tag looks like a BCP 47 language tag
+func Valid(tag string) bool {
+	return tagPattern.MatchString(tag)
+}
+
+// Base returns the primary language subtag ("fr-ca" -> "fr")
+func Base(tag string) string {
+	if i := strings.IndexByte(tag, '-'); i >= 0 {
+		return tag[:i]
+	}
+	return tag
+}
+
+// ParseAcceptLanguage returns the normalized tags of an Accept-Language header,
+// most preferred first. Wildcards and tags with q=0 are dropped.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := Normalize(fields[0])
+		if tag == "" || tag == "*" || !Valid(tag) {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if value, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = value
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag, q})
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	result := make([]string, 0, len(tags))
+	for _, t := range tags {
+		result = append(result, t.tag)
+	}
+	return result
+}
+
+// Match picks the best available locale for the preferred tags. Each preference is
+// tried as an exact match first, then by its primary language.
+func Match(preferred, available []string) (string, bool) {
+	for _, want := range preferred {
+		for _, have := range available {
+			if have == want {
+				return have, true
+			}
+		}
+		for _, have := range available {
+			if Base(have) == Base(want) {
+				return have, true
+			}
+		}
+	}
+	return "", false
+}
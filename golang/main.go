@@ -1,21 +1,90 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+	_ "time/tzdata" // property time zones must resolve on hosts without a zone database
 
+	"channelmanager/apierror"
+	"channelmanager/archive"
 	"channelmanager/cache"
+	"channelmanager/channels"
 	"channelmanager/config"
+	"channelmanager/consistency"
 	"channelmanager/database"
+	"channelmanager/feed"
+	"channelmanager/fx"
 	"channelmanager/handlers"
+	"channelmanager/jobs"
+	"channelmanager/ledger"
+	"channelmanager/middleware"
+	"channelmanager/models"
+	"channelmanager/parity"
+	"channelmanager/payments"
+	"channelmanager/pii"
+	"channelmanager/reconcile"
+	"channelmanager/stats"
+	"channelmanager/storage"
+	"channelmanager/testenv"
+	"channelmanager/utils"
+	"channelmanager/validation"
+	"channelmanager/weather"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func main() {
 	// Load configuration
 	cfg := config.LoadConfig()
+	if err := cfg.Server.CORS.Validate(); err != nil {
+		log.Fatalf("Invalid CORS configuration: %v", err)
+	}
+	if err := cfg.Bookings.Validate(); err != nil {
+		log.Fatalf("Invalid booking configuration: %v", err)
+	}
 	log.Println("Configuration loaded")
 
+	// "channelmanager seed [flags]" seeds the database and "channelmanager loadtest
+	// [flags]" runs a search load test instead of serving
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		if err := runSeed(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Failed to seed database: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "loadtest" {
+		if err := runLoadTest(os.Args[2:]); err != nil {
+			log.Fatalf("Load test failed: %v", err)
+		}
+		return
+	}
+
+	// Configure encryption of personal data at rest
+	if err := pii.Configure(cfg.PII); err != nil {
+		log.Fatalf("Failed to configure personal data encryption: %v", err)
+	}
+	if !pii.Enabled() {
+		log.Println("Warning: PII_ENCRYPTION_KEYS is not set, guest contact details are stored unencrypted")
+	}
+
+	// Test mode may run against containers started for the run
+	if cfg.Server.Env == envTest && cfg.Server.TestContainers {
+		env, err := startTestContainers(cfg)
+		if err != nil {
+			log.Fatalf("Failed to start test containers: %v", err)
+		}
+		defer env.Close()
+	}
+
 	// Initialize database
 	db, err := database.InitializeDatabase(cfg.Database)
 	if err != nil {
@@ -30,56 +99,820 @@ func main() {
 	}
 	defer redis.Close()
 	log.Println("Redis initialized")
+	if err := redis.CheckMemoryPolicy(context.Background()); err != nil {
+		log.Fatalf("Failed to check Redis memory policy: %v", err)
+	}
+
+	// Test mode starts every run from the same fixtures
+	if cfg.Server.Env == envTest {
+		if err := utils.ResetTestData(context.Background(), db, redis); err != nil {
+			log.Fatalf("Failed to load test fixtures: %v", err)
+		}
+		log.Println("Test fixtures loaded")
+	}
+
+	// Initialize object storage
+	store, err := storage.NewObjectStore(cfg.Storage)
+	if err != nil {
+		log.Fatalf("Failed to initialize object storage: %v", err)
+	}
+	log.Println("Object storage initialized")
 
 	// Initialize Gin router
 	if cfg.Server.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	if err := validation.Register(); err != nil {
+		log.Fatalf("Failed to register request validation: %v", err)
+	}
+
 	router := gin.Default()
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	router.Use(middleware.RequestID(), middleware.CORS(cfg.Server.CORS), middleware.Gzip(cfg.Auth.GzipLevel), middleware.ErrorHandler(), middleware.Maintenance(redis),
+		middleware.Timeout(cfg.Auth.RequestTimeout, routeTimeouts(cfg)))
+
+	// Initialize channel distribution
+	credentials := channels.NewCredentialVault(db, cfg.Credentials)
+	registry := channels.NewRegistry()
+	registry.SetFallback(channels.NewGenericAdapter(db, credentials))
+	if cfg.Expedia.Enabled {
+		registry.Register(channels.NewExpediaAdapter(cfg.Expedia, credentials))
+	}
+	if cfg.Vrbo.Enabled {
+		registry.Register(channels.NewVrboAdapter(cfg.Vrbo, credentials))
+	}
+	reservations := channels.NewReservationService(db, registry)
+	exchangeRates := fx.NewService(db, cfg.FX)
+	ariPush := channels.NewARIPushService(db, registry, exchangeRates)
+	contentPush := channels.NewContentPushService(db, registry)
+	feeds := feed.NewGenerator(db, contentPush, store, cfg.Feed)
+	var googleFeed *feed.GoogleFeed
+	if cfg.GoogleFeed.Enabled {
+		googleFeed = feed.NewGoogleFeed(db, redis, store, cfg.GoogleFeed)
+	}
+
+	// Initialize background jobs
+	scheduler := jobs.NewScheduler(db, redis, cfg.Jobs)
+	if err := registerJobs(scheduler, db, redis, store, feeds, googleFeed, credentials, reservations, exchangeRates, cfg); err != nil {
+		log.Fatalf("Failed to register background jobs: %v", err)
+	}
 
 	// Initialize handlers
-	handler := handlers.NewHandler(db, redis)
+	handler := handlers.NewHandler(db, redis, store, ledger.NewService(db, cfg.Ledger), ariPush, contentPush, cfg.Search, feeds, googleFeed, scheduler, credentials, reservations, cfg.Vouchers, cfg.Bookings, exchangeRates, weather.NewService(cfg.Weather, redis))
 
 	// Setup routes
-	setupRoutes(router, handler)
+	setupRoutes(router, handler, redis, cfg)
 
 	// Initialize and start event listener for cache invalidation
-	eventListener := handlers.NewEventListener(db, redis)
+	eventListener := handlers.NewEventListener(db, redis, ariPush, cfg.Jobs.Instance)
 	eventListener.Start()
 	defer eventListener.Stop()
 
 	log.Println("Event listener started")
 
+	// Start scheduled jobs; feeds are generated right away as well. In test mode jobs
+	// only run when triggered, so they cannot change data under a test.
+	if cfg.Server.Env != envTest {
+		scheduler.Start()
+		defer scheduler.Stop()
+		if cfg.Feed.Interval > 0 {
+			if _, err := scheduler.Trigger(jobFeeds); err != nil && !errors.Is(err, jobs.ErrJobRunning) {
+				log.Printf("Failed to start feed generation: %v", err)
+			}
+		}
+	}
+
 	// Start server
-	log.Printf("Starting server on %s:%s", cfg.Server.Host, cfg.Server.Port)
-	if err := router.Run(cfg.Server.Host + ":" + cfg.Server.Port); err != nil {
+	if err := serve(router, cfg.Server); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
 
+// serve runs the HTTP server with the configured connection limits, over TLS when a
+// certificate and key are configured
+func serve(router http.Handler, cfg config.ServerConfig) error {
+	server := &http.Server{
+		Addr:              cfg.Host + ":" + cfg.Port,
+		Handler:           router,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if cfg.TLSCertFile != "" {
+		server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		log.Printf("Starting server on %s (TLS)", server.Addr)
+		return server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+	}
+
+	log.Printf("Starting server on %s", server.Addr)
+	return server.ListenAndServe()
+}
+
+// envTest is the ENV value of test mode, which loads deterministic fixtures at startup
+// and exposes POST /test/reset
+const envTest = "test"
+
+// startTestContainers starts PostgreSQL and Redis in Docker and points the database and
+// Redis configuration at them. The containers are removed when the server exits or is
+// interrupted.
+func startTestContainers(cfg *config.Config) (*testenv.Env, error) {
+	env, err := testenv.Start()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Database.Host, cfg.Database.Port = env.PostgresHost, env.PostgresPort
+	cfg.Database.User, cfg.Database.Password = testenv.PostgresUser, testenv.PostgresPassword
+	cfg.Database.DBName, cfg.Database.SSLMode = testenv.PostgresDB, "disable"
+	cfg.Redis.Host, cfg.Redis.Port = env.RedisHost, env.RedisPort
+	cfg.Redis.Password, cfg.Redis.DB = "", 0
+	log.Printf("Test containers started: postgres on %s:%d, redis on %s:%d", env.PostgresHost, env.PostgresPort, env.RedisHost, env.RedisPort)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		if err := env.Close(); err != nil {
+			log.Printf("Failed to remove test containers: %v", err)
+		}
+		os.Exit(1)
+	}()
+	return env, nil
+}
+
+// routeTimeouts returns the configured per-route timeouts; streaming routes and
+// downloads have none unless configured, as the timeout would buffer them whole
+func routeTimeouts(cfg *config.Config) map[string]time.Duration {
+	timeouts := map[string]time.Duration{
+		"GET /ws": 0,
+		"GET /api/v1/properties/:id/availability/stream": 0,
+		"GET /api/v1/admin/export/:entity":               0,
+		"GET /api/v1/bookings/export":                    0,
+		"GET /api/v1/properties/:id/calendar/export":     0,
+		"GET /api/v1/calendars/bookings/:file":           0,
+	}
+	for route, timeout := range cfg.Auth.RouteTimeouts {
+		timeouts[route] = timeout
+	}
+	return timeouts
+}
+
+// Background job names
+const (
+	jobFeeds     = "feeds"
+	jobReconcile = "reconcile"
+	jobParity    = "parity"
+	jobArchive   = "archive_events"
+	jobEncrypt   = "encrypt_pii"
+	jobDeposits  = "deposits"
+	jobStats     = "flush_stats"
+	jobTokens    = "refresh_channel_tokens"
+	jobBookings  = "retrieve_channel_bookings"
+	jobGoogle    = "refresh_google_feed"
+	jobFX        = "sync_exchange_rates"
+	jobDupes     = "flag_duplicate_properties"
+	jobPurge     = "purge_deleted_records"
+	jobCacheScan = "verify_cache_consistency"
+	jobRequests  = "expire_booking_requests"
+)
+
+// registerJobs registers the periodic background jobs with the scheduler
+func registerJobs(scheduler *jobs.Scheduler, db *gorm.DB, redis *cache.RedisClient, store storage.ObjectStore, feeds *feed.Generator, googleFeed *feed.GoogleFeed,
+	credentials *channels.CredentialVault, reservations *channels.ReservationService, exchangeRates *fx.Service, cfg *config.Config) error {
+	// Catalog feeds for metasearch and advertising partners
+	if cfg.Feed.Interval > 0 {
+		err := scheduler.Register(jobFeeds, jobs.Every(cfg.Feed.Interval), func(ctx context.Context) error {
+			generated, err := feeds.Generate(ctx)
+			if err != nil {
+				return err
+			}
+			log.Printf("Generated %d property feeds", len(generated))
+			// The Google feed is rendered in full as well, so its prices move on with the calendar
+			if googleFeed != nil {
+				report, err := googleFeed.Generate(ctx)
+				if err != nil {
+					return err
+				}
+				log.Printf("Generated Google feed: %d of %d properties listed, %d errors", report.Listed, report.Properties, report.Errors)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		log.Println("Feed generation schedule disabled")
+	}
+
+	// Google feed entries of the properties changed by events
+	if googleFeed != nil && cfg.GoogleFeed.Interval > 0 {
+		err := scheduler.Register(jobGoogle, jobs.Every(cfg.GoogleFeed.Interval), func(ctx context.Context) error {
+			refreshed, err := googleFeed.Refresh(ctx)
+			if refreshed > 0 {
+				log.Printf("Refreshed Google feed entries of %d properties", refreshed)
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Inventory reconciliation
+	reconciler := reconcile.NewReconciler(db, cfg.Reconcile)
+	err := scheduler.Register(jobReconcile, jobs.Every(cfg.Reconcile.Interval), func(ctx context.Context) error {
+		_, err := reconciler.Reconcile()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Rate parity monitoring
+	parityMonitor := parity.NewMonitor(db, cfg.Parity, exchangeRates)
+	err = scheduler.Register(jobParity, jobs.Every(cfg.Parity.CheckInterval), func(ctx context.Context) error {
+		return parityMonitor.Check()
+	})
+	if err != nil {
+		return err
+	}
+
+	// Exchange rates of the currencies channels are priced in
+	schedule, err := jobs.ParseSchedule(cfg.FX.Schedule)
+	if err != nil {
+		return err
+	}
+	err = scheduler.Register(jobFX, schedule, func(ctx context.Context) error {
+		result, err := exchangeRates.Sync(ctx)
+		if result.Source != "" {
+			log.Printf("Stored %d exchange rates from %s", result.Currencies, result.Source)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Likely duplicate listings of the same property on different channels
+	if schedule, err = jobs.ParseSchedule(cfg.Duplicates.Schedule); err != nil {
+		return err
+	}
+	duplicateRepo := database.NewDuplicateRepository(db)
+	err = scheduler.Register(jobDupes, schedule, func(ctx context.Context) error {
+		flagged, err := duplicateRepo.FlagDuplicates(cfg.Duplicates.MaxDistanceMeters, cfg.Duplicates.MinNameSimilarity)
+		if flagged > 0 {
+			log.Printf("Flagged %d likely duplicate property pairs for review", flagged)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Permanent removal of properties, amenities and conditions deleted past their retention
+	if cfg.Purge.RetentionDays > 0 {
+		if schedule, err = jobs.ParseSchedule(cfg.Purge.Schedule); err != nil {
+			return err
+		}
+		trashRepo := database.NewTrashRepository(db)
+		err = scheduler.Register(jobPurge, schedule, func(ctx context.Context) error {
+			purged, err := trashRepo.PurgeDeletedBefore(time.Now().AddDate(0, 0, -cfg.Purge.RetentionDays))
+			if purged.Properties+purged.Amenities+purged.Conditions > 0 {
+				log.Printf("Purged %d properties, %d amenities and %d conditions", purged.Properties, purged.Amenities, purged.Conditions)
+			}
+			if purged.Skipped > 0 {
+				log.Printf("Kept %d deleted properties that bookings refer to", purged.Skipped)
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		log.Println("Purge of deleted records disabled")
+	}
+
+	// Sampled cache entries compared with the database, repairing missed invalidations
+	if cfg.Consistency.Interval > 0 && cfg.Consistency.SampleSize > 0 {
+		checker := consistency.NewChecker(db, redis, cfg.Consistency)
+		err = scheduler.Register(jobCacheScan, jobs.Every(cfg.Consistency.Interval), func(ctx context.Context) error {
+			report, err := checker.Check(ctx)
+			if report != nil && report.PropertiesStale+report.SearchesStale > 0 {
+				log.Printf("Repaired %d stale property and %d stale search cache entries", report.PropertiesStale, report.SearchesStale)
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		log.Println("Cache consistency check disabled")
+	}
+
+	// Encryption of guest details stored in plaintext or sealed with a retired key
+	if schedule, err = jobs.ParseSchedule(cfg.PII.Schedule); err != nil {
+		return err
+	}
+	bookingRepo := database.NewBookingRepository(db)
+	err = scheduler.Register(jobEncrypt, schedule, func(ctx context.Context) error {
+		total := 0
+		for ctx.Err() == nil {
+			saved, err := bookingRepo.EncryptGuestDetails(500)
+			total += saved
+			if err != nil {
+				return err
+			}
+			if saved < 500 {
+				break
+			}
+		}
+		if total > 0 {
+			log.Printf("Encrypted guest details of %d bookings", total)
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return err
+	}
+
+	// Booking requests the host did not answer in time, their nights reopened
+	if cfg.Bookings.ExpiryInterval > 0 {
+		err = scheduler.Register(jobRequests, jobs.Every(cfg.Bookings.ExpiryInterval), func(ctx context.Context) error {
+			total := 0
+			for ctx.Err() == nil {
+				expired, err := bookingRepo.ExpireBookingRequests(time.Now(), 100)
+				total += expired
+				if err != nil {
+					return err
+				}
+				if expired < 100 {
+					break
+				}
+			}
+			if total > 0 {
+				log.Printf("Expired %d unanswered booking requests", total)
+			}
+			return ctx.Err()
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		log.Println("Booking request expiry disabled")
+	}
+
+	// Security deposits placed before check-in and released after check-out
+	gateway, err := payments.NewGateway(cfg.Payments)
+	if err != nil {
+		return err
+	}
+	if schedule, err = jobs.ParseSchedule(cfg.Payments.DepositSchedule); err != nil {
+		return err
+	}
+	deposits := payments.NewDepositService(db, gateway, cfg.Payments)
+	err = scheduler.Register(jobDeposits, schedule, func(ctx context.Context) error {
+		run, err := deposits.ProcessDue(ctx, time.Now())
+		if run.Placed+run.Released+run.Failed > 0 {
+			log.Printf("Deposits: %d placed, %d released, %d failed", run.Placed, run.Released, run.Failed)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Property views and search impressions counted in Redis
+	if cfg.Stats.FlushInterval > 0 {
+		flusher := stats.NewFlusher(db, redis)
+		err = scheduler.Register(jobStats, jobs.Every(cfg.Stats.FlushInterval), func(ctx context.Context) error {
+			_, err := flusher.Flush(ctx)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		log.Println("Statistics flush disabled")
+	}
+
+	// Channel OAuth tokens refreshed before they expire, and rotated-out credentials removed
+	if cfg.Credentials.RefreshInterval > 0 {
+		err = scheduler.Register(jobTokens, jobs.Every(cfg.Credentials.RefreshInterval), func(ctx context.Context) error {
+			refreshed, err := credentials.RefreshExpiring(ctx)
+			if refreshed > 0 {
+				log.Printf("Refreshed %d channel access tokens", refreshed)
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		log.Println("Channel token refresh disabled")
+	}
+
+	// Reservations of channels that are polled rather than calling a webhook
+	if cfg.Reservations.PollInterval > 0 {
+		err = scheduler.Register(jobBookings, jobs.Every(cfg.Reservations.PollInterval), func(ctx context.Context) error {
+			confirmed, err := reservations.Poll(ctx)
+			if confirmed > 0 {
+				log.Printf("Stored %d channel reservations", confirmed)
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		log.Println("Channel reservation retrieval disabled")
+	}
+
+	// Archival of processed change events past their retention
+	if cfg.Archive.EventRetentionDays <= 0 {
+		log.Println("Event archival disabled")
+		return nil
+	}
+	if schedule, err = jobs.ParseSchedule(cfg.Archive.Schedule); err != nil {
+		return err
+	}
+	archiver := archive.NewEventArchiver(db, store, cfg.Archive)
+	return scheduler.Register(jobArchive, schedule, func(ctx context.Context) error {
+		_, err := archiver.Archive(ctx)
+		return err
+	})
+}
+
+// calendarTag tags cached calendar views with the property of the :id path parameter
+func calendarTag(c *gin.Context) string {
+	propertyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return ""
+	}
+	return cache.CalendarTag(uint(propertyID))
+}
+
 // setupRoutes sets up all API routes
-func setupRoutes(router *gin.Engine, handler *handlers.Handler) {
+func setupRoutes(router *gin.Engine, handler *handlers.Handler, redis *cache.RedisClient, cfg *config.Config) {
+	// Replays responses of retried writes carrying an Idempotency-Key
+	idempotent := middleware.Idempotency(redis, cfg.Auth.IdempotencyTTL)
+
+	// Changes to owned properties need the owner's, a permitted team member's or an admin API key
+	manageRates := handler.RequirePropertyPermission(models.PermissionManageRates)
+	manageCalendar := handler.RequirePropertyPermission(models.PermissionManageCalendar)
+	viewOnly := handler.RequirePropertyPermission(models.PermissionViewOnly)
+	ownerOnly := handler.RequirePropertyPermission(models.PermissionOwner)
+	adminOnly := middleware.AdminAuth(cfg.Auth)
+
+	// Whole GET responses cached until the entities they show change
+	cachedAmenities := middleware.CacheResponses(redis, 24*time.Hour, middleware.StaticTag(cache.AmenitiesTag))
+	cachedConditions := middleware.CacheResponses(redis, 24*time.Hour, middleware.StaticTag(cache.ConditionsTag))
+	cachedCalendar := middleware.CacheResponses(redis, 5*time.Minute, calendarTag)
+
 	// Health check
 	router.GET("/health", handler.HealthCheck)
 
+	// Fixture reset for end-to-end tests
+	if cfg.Server.Env == envTest {
+		router.POST("/test/reset", handler.ResetTestData)
+	}
+
+	// Unknown routes use the error envelope too
+	router.NoRoute(func(c *gin.Context) {
+		c.Error(apierror.New(http.StatusNotFound, apierror.CodeNotFound, "Route not found"))
+	})
+
+	// Change notifications for channel partners
+	router.GET("/ws", middleware.PartnerAuth(cfg.Auth), handler.PartnerWebSocket)
+
+	// Signed webhooks from channel and payment partners
+	webhooks := router.Group("/webhooks", middleware.WebhookSignature(cfg.Auth.WebhookSecrets, cfg.Auth.WebhookTolerance))
+	{
+		webhooks.POST("/channels/bookings", handler.ReceiveChannelBooking)
+		webhooks.POST("/channels/bookings/:reference/cancel", handler.CancelChannelBooking)
+		webhooks.POST("/vrbo/reservations", handler.ReceiveVrboReservation)
+	}
+
 	// Property search and retrieval
-	api := router.Group("/api/v1")
+	api := router.Group("/api/v1", middleware.RecognizeAdmin(cfg.Auth))
 	{
 		// Search properties
-		api.POST("/properties/search", handler.SearchProperties)
+		api.POST("/properties/search", middleware.AdminAuthForQuery(cfg.Auth, "explain"), handler.SearchProperties)
+
+		// Trending properties for homepage carousels
+		api.GET("/properties/trending", handler.GetTrendingProperties)
+
+		// Properties the visitor's session viewed last
+		api.GET("/me/recently-viewed", handler.GetRecentlyViewed)
 
 		// Get single property
 		api.GET("/properties/:id", handler.GetProperty)
+		api.GET("/properties/:id/similar", handler.GetSimilarProperties)
 
 		// Get property availability
-		api.GET("/properties/:id/availability", handler.GetPropertyAvailability)
+		api.GET("/properties/:id/availability", cachedCalendar, handler.GetPropertyAvailability)
+		api.GET("/properties/:id/availability/stream", handler.StreamPropertyAvailability)
+		api.POST("/availability/batch", handler.GetAvailabilityBatch)
+
+		// Revisions of a night's rate and availability
+		api.GET("/properties/:id/price-history", handler.GetPropertyPriceHistory)
+		api.GET("/properties/:id/availability-history", handler.GetPropertyAvailabilityHistory)
+
+		// Stay quotes, length-of-stay discounts, fees and extra guest pricing
+		api.GET("/properties/:id/quote", handler.GetPropertyQuote)
+		api.GET("/properties/:id/discounts", handler.GetPropertyDiscounts)
+		api.PUT("/properties/:id/discounts", manageRates, handler.UpdatePropertyDiscounts)
+		api.GET("/properties/:id/fees", handler.GetPropertyFees)
+		api.PUT("/properties/:id/fees", manageRates, handler.UpdatePropertyFees)
+		api.GET("/properties/:id/occupancy-pricing", handler.GetPropertyOccupancyPricing)
+		api.PUT("/properties/:id/occupancy-pricing", manageRates, handler.UpdatePropertyOccupancyPricing)
+
+		// Base rates, seasons and pricing materialization
+		api.PUT("/properties/:id/rates", manageRates, handler.UpdatePropertyRates)
+		api.PUT("/properties/:id/turnover", manageCalendar, handler.UpdatePropertyTurnover)
+		api.GET("/properties/:id/booking-window", handler.GetPropertyBookingWindow)
+		api.PUT("/properties/:id/booking-window", manageCalendar, handler.UpdatePropertyBookingWindow)
+		api.GET("/properties/:id/timezone", handler.GetPropertyTimezone)
+		api.PUT("/properties/:id/timezone", ownerOnly, handler.UpdatePropertyTimezone)
+		api.GET("/properties/:id/checkin-times", handler.GetPropertyCheckinTimes)
+		api.PUT("/properties/:id/checkin-times", manageCalendar, handler.UpdatePropertyCheckinTimes)
+		api.GET("/properties/:id/deposit", handler.GetPropertyDeposit)
+		api.PUT("/properties/:id/deposit", manageRates, handler.UpdatePropertyDeposit)
+		api.GET("/properties/:id/booking-mode", handler.GetPropertyBookingMode)
+		api.PUT("/properties/:id/booking-mode", ownerOnly, handler.UpdatePropertyBookingMode)
+		api.GET("/properties/:id/house-rules", handler.GetPropertyHouseRules)
+		api.PUT("/properties/:id/house-rules", ownerOnly, handler.UpdatePropertyHouseRules)
+
+		// Property lifecycle; listing, rejecting and suspending are for admins
+		api.POST("/properties/:id/submit", ownerOnly, idempotent, handler.SubmitProperty)
+		api.POST("/properties/:id/archive", ownerOnly, idempotent, handler.ArchiveProperty)
+		api.POST("/properties/:id/unarchive", ownerOnly, idempotent, handler.UnarchiveProperty)
+
+		// Calendar blocks
+		api.GET("/properties/:id/blocks", handler.ListCalendarBlocks)
+		api.POST("/properties/:id/blocks", manageCalendar, handler.CreateCalendarBlock)
+		api.DELETE("/properties/:id/blocks/:block_id", manageCalendar, handler.DeleteCalendarBlock)
+		api.POST("/properties/:id/pricing/materialize", manageRates, handler.MaterializePropertyPricing)
+		api.GET("/properties/:id/seasons", handler.ListSeasons)
+		api.POST("/properties/:id/seasons", manageRates, handler.CreateSeason)
+		api.PUT("/properties/:id/seasons/:season_id", manageRates, handler.UpdateSeason)
+		api.DELETE("/properties/:id/seasons/:season_id", manageRates, handler.DeleteSeason)
+
+		// Holidays and local events of destinations, priced in as demand multipliers
+		api.GET("/destinations/:city/events", handler.ListDestinationEvents)
 
 		// Get amenities
-		api.GET("/amenities", handler.GetAmenities)
+		api.GET("/amenities", cachedAmenities, handler.GetAmenities)
 
 		// Get conditions
-		api.GET("/conditions", handler.GetConditions)
+		api.GET("/conditions", cachedConditions, handler.GetConditions)
+
+		// Bookings
+		api.GET("/bookings", handler.ListBookings)
+		api.GET("/bookings/export", handler.ExportBookingsCSV)
+		api.POST("/bookings", idempotent, handler.CreateBooking)
+		api.GET("/bookings/:id", handler.GetBooking)
+		api.POST("/bookings/:id/cancel", idempotent, handler.CancelBooking)
+		api.POST("/bookings/:id/approve", idempotent, handler.ApproveBooking)
+		api.POST("/bookings/:id/decline", idempotent, handler.DeclineBooking)
+		api.POST("/bookings/:id/check-in", idempotent, handler.CheckInBooking)
+		api.POST("/bookings/:id/check-out", idempotent, handler.CheckOutBooking)
+		api.POST("/bookings/:id/no-show", idempotent, handler.MarkBookingNoShow)
+
+		// Bulk availability and rate updates
+		api.PUT("/properties/:id/ari", idempotent, handler.UpdatePropertyARI)
+		api.POST("/batch", idempotent, handler.ExecuteBatch)
+
+		// Calendar spreadsheets
+		api.GET("/properties/:id/calendar/export", viewOnly, cachedCalendar, handler.ExportPropertyCalendarCSV)
+		api.POST("/properties/:id/calendar/import", manageCalendar, handler.ImportPropertyCalendarCSV)
+
+		// OpenTravel ARI notifications from legacy PMS systems
+		api.POST("/ota/ari", handler.IngestOTAMessage)
+
+		// Booking invoices and receipts
+		api.GET("/bookings/:id/invoice", handler.GetBookingInvoice)
+
+		// Organization seller details for invoices; organizations are managed by admins
+		api.PUT("/organizations/:id/seller-details", adminOnly, handler.UpdateOrganizationSellerDetails)
+
+		// Organization defaults for quotes, bookings, invoices and host notifications
+		api.GET("/organizations/:id/settings", handler.GetOrganizationSettings)
+		api.PUT("/organizations/:id/settings", adminOnly, handler.UpdateOrganizationSettings)
+
+		// Owner payout ledger, kept by admins
+		api.POST("/payouts/statements/generate", adminOnly, handler.GeneratePayoutStatements)
+		api.GET("/payouts/statements", adminOnly, handler.ListPayoutStatements)
+		api.GET("/payouts/statements/:id", adminOnly, handler.GetPayoutStatement)
+		api.POST("/payouts/statements/:id/execute", adminOnly, handler.ExecutePayoutStatement)
+
+		// Occupancy and revenue analytics
+		api.GET("/analytics/properties/:id", viewOnly, handler.GetPropertyAnalytics)
+		api.GET("/analytics/properties/:id/pickup", viewOnly, handler.GetPropertyPickup)
+		api.GET("/analytics/channels", handler.GetChannelPerformance)
+
+		// Views, search impressions and bookings for hosts
+		api.GET("/properties/:id/stats", viewOnly, handler.GetPropertyStats)
+
+		// Signed iCalendar feeds of a property's bookings for the host's calendar
+		api.GET("/properties/:id/calendar-feeds", manageCalendar, handler.ListCalendarFeeds)
+		api.POST("/properties/:id/calendar-feeds", manageCalendar, handler.CreateCalendarFeed)
+		api.POST("/properties/:id/calendar-feeds/:feed_id/revoke", manageCalendar, handler.RevokeCalendarFeed)
+		api.GET("/calendars/bookings/:file", handler.GetBookingCalendar)
+
+		// Host notifications of channel reservation changes
+		api.GET("/properties/:id/notifications", viewOnly, handler.ListPropertyNotifications)
+		api.POST("/properties/:id/notifications/:notification_id/read", viewOnly, handler.MarkPropertyNotificationRead)
+
+		// Rate parity report
+		api.GET("/reports/rate-parity", handler.GetRateParityReport)
+
+		// Channels, pricing rules and property mappings; channels are configured by admins
+		// and owned properties mapped by their owners
+		api.GET("/channels", handler.ListChannels)
+		api.POST("/channels", adminOnly, handler.CreateChannel)
+		api.GET("/channels/:id", handler.GetChannel)
+		api.PUT("/channels/:id/pricing-rules", adminOnly, handler.UpdateChannelPricingRules)
+		api.GET("/channels/:id/rate-preview", handler.GetChannelRatePreview)
+		api.GET("/channels/:id/mappings", handler.ListChannelMappings)
+		api.PUT("/channels/:id/mappings", handler.SaveChannelMapping)
+		api.GET("/channels/:id/content-preview", handler.GetChannelContentPreview)
+		api.GET("/channels/:id/sync-status", handler.GetChannelSyncStatus)
+		api.POST("/channels/:id/resync", adminOnly, handler.ResyncChannel)
+		api.GET("/channels/:id/resync/:job_id", handler.GetResyncJob)
+		api.PUT("/channels/:id/dry-run", adminOnly, handler.UpdateChannelDryRun)
+		api.GET("/channels/:id/dry-run-payloads", adminOnly, handler.ListDryRunPayloads)
+
+		// Localized property content
+		api.GET("/properties/:id/translations", handler.ListPropertyTranslations)
+		api.PUT("/properties/:id/translations/:locale", ownerOnly, handler.SavePropertyTranslation)
+		api.DELETE("/properties/:id/translations/:locale", ownerOnly, handler.DeletePropertyTranslation)
+
+		// Property photos
+		api.GET("/properties/:id/photos", handler.ListPropertyPhotos)
+		api.PUT("/properties/:id/photos", ownerOnly, handler.ReplacePropertyPhotos)
+
+		// Property catalog feeds for metasearch and advertising partners
+		api.GET("/feeds/:variant/:file", handler.GetPropertyFeed)
+		api.GET("/feeds/google/:file", handler.GetGoogleFeed)
+
+		// Property content distribution
+		api.POST("/properties/:id/content/push", ownerOnly, handler.PushPropertyContent)
+
+		// Favorites, kept by the frontend with an admin API key on behalf of its users
+		api.GET("/users/:user_id/favorites", adminOnly, handler.ListFavorites)
+		api.PUT("/users/:user_id/favorites/:property_id", adminOnly, handler.AddFavorite)
+		api.DELETE("/users/:user_id/favorites/:property_id", adminOnly, handler.RemoveFavorite)
+
+		// Guest personal data (GDPR access and erasure), identified by email address
+		api.GET("/guests/:id/export", adminOnly, handler.ExportGuestData)
+		api.DELETE("/guests/:id", adminOnly, handler.EraseGuestData)
+
+		// Guest loyalty points
+		api.GET("/guests/:id/loyalty", adminOnly, handler.GetGuestLoyalty)
+		api.POST("/guests/:id/loyalty/adjustments", adminOnly, handler.AdjustGuestLoyalty)
+
+		// Voucher balance checks by guests
+		api.POST("/vouchers/balance", handler.GetVoucherBalance)
+
+		// Property owners' teams and their permissions
+		api.GET("/team/members", handler.ListTeamMembers)
+		api.POST("/team/invitations", handler.InviteTeamMember)
+		api.POST("/team/invitations/accept", handler.AcceptTeamInvitation)
+		api.PUT("/team/members/:id/grants", handler.UpdateTeamMemberGrants)
+		api.DELETE("/team/members/:id", handler.RevokeTeamMember)
+	}
+
+	// Administration (requires an admin API key)
+	if len(cfg.Auth.AdminAPIKeys) == 0 {
+		log.Println("Warning: ADMIN_API_KEYS is not set, admin routes will reject every request")
+	}
+	admin := router.Group("/api/v1/admin", middleware.AdminAuth(cfg.Auth))
+	{
+		// Amenities and amenity categories
+		admin.POST("/amenities", handler.CreateAmenity)
+		admin.PUT("/amenities/:id", handler.UpdateAmenity)
+		admin.DELETE("/amenities/:id", handler.DeleteAmenity)
+		admin.GET("/amenities/:id/translations", handler.ListAmenityTranslations)
+		admin.PUT("/amenities/:id/translations/:locale", handler.SaveAmenityTranslation)
+		admin.DELETE("/amenities/:id/translations/:locale", handler.DeleteAmenityTranslation)
+		admin.GET("/amenity-categories", handler.ListAmenityCategories)
+		admin.PUT("/amenity-categories/:category", handler.RenameAmenityCategory)
+
+		// Conditions and condition types
+		admin.POST("/conditions", handler.CreateCondition)
+		admin.PUT("/conditions/:id", handler.UpdateCondition)
+		admin.DELETE("/conditions/:id", handler.DeleteCondition)
+		admin.GET("/conditions/:id/translations", handler.ListConditionTranslations)
+		admin.PUT("/conditions/:id/translations/:locale", handler.SaveConditionTranslation)
+		admin.DELETE("/conditions/:id/translations/:locale", handler.DeleteConditionTranslation)
+		admin.GET("/condition-types", handler.ListConditionTypes)
+		admin.PUT("/condition-types/:type", handler.RenameConditionType)
+
+		// OTA and per-channel amenity/condition codes
+		admin.GET("/content-codes", handler.ListContentCodes)
+		admin.PUT("/content-codes", handler.SaveContentCode)
+		admin.DELETE("/content-codes/:id", handler.DeleteContentCode)
+
+		// Tax rules per jurisdiction
+		admin.GET("/tax-rules", handler.ListTaxRules)
+		admin.POST("/tax-rules", handler.CreateTaxRule)
+		admin.GET("/tax-rules/:id", handler.GetTaxRule)
+		admin.PUT("/tax-rules/:id", handler.UpdateTaxRule)
+		admin.DELETE("/tax-rules/:id", handler.DeleteTaxRule)
+
+		// Catalog feed regeneration
+		admin.POST("/feeds/generate", handler.GenerateFeeds)
+		admin.GET("/feeds/google/report", handler.GetGoogleFeedReport)
+
+		// Newline-delimited JSON exports for data warehousing
+		admin.GET("/export/:entity", handler.ExportData)
+
+		// Inventory conflicts
+		admin.GET("/conflicts", handler.ListConflicts)
+		admin.POST("/conflicts/:id/resolve", handler.ResolveConflict)
+
+		// Background jobs
+		admin.GET("/jobs", handler.ListJobs)
+		admin.GET("/jobs/:name/runs", handler.ListJobRuns)
+		admin.POST("/jobs/:name/run", handler.TriggerJob)
+
+		// Change event replay
+		admin.POST("/events/replay", handler.ReplayEvents)
+
+		// Channel API credentials
+		admin.GET("/channels/:id/credentials", handler.GetChannelCredentials)
+		admin.PUT("/channels/:id/credentials", handler.RotateChannelCredentials)
+		admin.GET("/channels/:id/adapter", handler.GetChannelAdapterSpec)
+		admin.PUT("/channels/:id/adapter", handler.SaveChannelAdapterSpec)
+		admin.DELETE("/channels/:id/adapter", handler.DeleteChannelAdapterSpec)
+
+		// Request timeouts recorded by this replica
+		admin.GET("/metrics/timeouts", handler.GetTimeoutStats)
+		// Stale cache entries found by the latest consistency check
+		admin.GET("/metrics/cache-consistency", handler.GetCacheConsistency)
+		// Redis memory limit, eviction policy and estimated use by key type
+		admin.GET("/metrics/redis-memory", handler.GetRedisMemory)
+
+		// Guest loyalty program
+		admin.GET("/loyalty/program", handler.GetLoyaltyProgram)
+		admin.PUT("/loyalty/program", handler.UpdateLoyaltyProgram)
+
+		// Gift cards and vouchers
+		admin.GET("/vouchers", handler.ListVouchers)
+		admin.POST("/vouchers", handler.IssueVoucher)
+		admin.GET("/vouchers/:id", handler.GetVoucher)
+		admin.POST("/vouchers/:id/void", handler.VoidVoucher)
+
+		// Exchange rates converting prices to channel currencies
+		admin.GET("/exchange-rates", handler.ListExchangeRates)
+
+		// Destination holidays and events
+		admin.POST("/destinations/:city/events", handler.CreateDestinationEvent)
+		admin.POST("/destinations/:city/events/import", handler.ImportDestinationEvents)
+		admin.PUT("/destination-events/:id", handler.UpdateDestinationEvent)
+		admin.DELETE("/destination-events/:id", handler.DeleteDestinationEvent)
+
+		// Points of interest listed near properties
+		admin.GET("/points-of-interest", handler.ListPointsOfInterest)
+		admin.POST("/points-of-interest", handler.CreatePointOfInterest)
+		admin.GET("/points-of-interest/:id", handler.GetPointOfInterest)
+		admin.PUT("/points-of-interest/:id", handler.UpdatePointOfInterest)
+		admin.DELETE("/points-of-interest/:id", handler.DeletePointOfInterest)
+
+		// Review queue of likely duplicate listings
+		admin.GET("/properties/duplicates", handler.ListDuplicateCandidates)
+		admin.POST("/properties/duplicates/:id/dismiss", handler.DismissDuplicateCandidate)
+		admin.POST("/properties/merge", handler.MergeProperties)
+
+		// Soft-deleted properties, amenities and conditions
+		admin.GET("/deleted/:kind", handler.ListDeletedRecords)
+		admin.POST("/deleted/:kind/:id/restore", handler.RestoreDeletedRecord)
+		admin.DELETE("/deleted/:kind/:id", handler.PurgeDeletedRecord)
+
+		// Property review and moderation
+		admin.POST("/properties/:id/approve", handler.ApproveProperty)
+		admin.POST("/properties/:id/reject", handler.RejectProperty)
+		admin.POST("/properties/:id/suspend", handler.SuspendProperty)
+		admin.POST("/properties/:id/reactivate", handler.ReactivateProperty)
+
+		// Property owners and the properties they own
+		admin.GET("/owners", handler.ListOwners)
+		admin.POST("/owners", handler.CreateOwner)
+		admin.POST("/owners/:id/api-key", handler.RotateOwnerToken)
+		admin.PUT("/properties/:id/owner", handler.SetPropertyOwner)
+
+		// Maintenance mode
+		admin.GET("/maintenance", handler.GetMaintenanceMode)
+		admin.PUT("/maintenance", handler.StartMaintenance)
+		admin.DELETE("/maintenance", handler.EndMaintenance)
 	}
 
 	log.Println("Routes configured")
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"channelmanager/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Config holds API middleware configuration
+type Config struct {
+	AdminAPIKeys   []string          // keys accepted on admin routes; none configured rejects every request
+	IdempotencyTTL time.Duration     // how long responses are replayed for a repeated Idempotency-Key
+	GzipLevel      int               // gzip compression level, 0 disables compression
+	PartnerAPIKeys map[string]string // partner API key -> channel ID
+
+	// Request timeouts; routes are keyed as "METHOD /path/:param"
+	RequestTimeout time.Duration
+	RouteTimeouts  map[string]time.Duration
+
+	// Signed webhooks from channel and payment partners
+	WebhookSecrets   map[string]string // partner ID -> shared signing secret
+	WebhookTolerance time.Duration     // how far a webhook timestamp may be from now
+}
+
+const (
+	partnerChannelKey = "partner_channel_id"
+	adminKey          = "admin"
+)
+
+// AdminAuth requires a valid admin API key, sent either as
+// "Authorization: Bearer <key>" or in the X-API-Key header
+func AdminAuth(cfg Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := requestAPIKey(c)
+		if key == "" {
+			abortWithError(c, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "Missing API key"))
+			return
+		}
+
+		if validAdminKey(cfg, key) {
+			c.Set(adminKey, true)
+			c.Next()
+			return
+		}
+
+		abortWithError(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "Invalid API key"))
+	}
+}
+
+// RecognizeAdmin marks requests carrying a valid admin API key, so handlers can let
+// admins make changes otherwise reserved to property owners; other requests go through
+// unmarked
+func RecognizeAdmin(cfg Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key := requestAPIKey(c); key != "" && validAdminKey(cfg, key) {
+			c.Set(adminKey, true)
+		}
+		c.Next()
+	}
+}
+
+// IsAdmin reports whether the request carries a valid admin API key, as recognized by
+// AdminAuth or RecognizeAdmin
+func IsAdmin(c *gin.Context) bool {
+	return c.GetBool(adminKey)
+}
+
+// APIKey returns the API key the request carries, or "" when it carries none
+func APIKey(c *gin.Context) string {
+	return requestAPIKey(c)
+}
+
+// validAdminKey reports whether key is one of the admin API keys
+func validAdminKey(cfg Config, key string) bool {
+	for _, allowed := range cfg.AdminAPIKeys {
+		if allowed != "" && subtle.ConstantTimeCompare([]byte(key), []byte(allowed)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminAuthForQuery requires a valid admin API key on requests setting the query
+// parameter param to true, such as explained searches, and lets other requests through
+func AdminAuthForQuery(cfg Config, param string) gin.HandlerFunc {
+	adminAuth := AdminAuth(cfg)
+	return func(c *gin.Context) {
+		if c.Query(param) == "true" {
+			adminAuth(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// PartnerAuth requires the API key of a channel partner, sent like admin keys or, for
+// clients unable to set headers such as browser WebSockets, as the api_key query parameter.
+// The partner's channel ID is available to handlers through PartnerChannelID.
+func PartnerAuth(cfg Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := requestAPIKey(c)
+		if key == "" {
+			key = c.Query("api_key")
+		}
+
+		if key == "" {
+			abortWithError(c, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "Missing API key"))
+			return
+		}
+
+		for allowed, channelID := range cfg.PartnerAPIKeys {
+			if subtle.ConstantTimeCompare([]byte(key), []byte(allowed)) == 1 {
+				c.Set(partnerChannelKey, channelID)
+				c.Next()
+				return
+			}
+		}
+
+		abortWithError(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "Invalid API key"))
+	}
+}
+
+// PartnerChannelID returns the channel of the partner authenticated by PartnerAuth
+func PartnerChannelID(c *gin.Context) string {
+	return c.GetString(partnerChannelKey)
+}
+
+// requestAPIKey reads the API key from the X-API-Key or Authorization header
+func requestAPIKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return ""
+}
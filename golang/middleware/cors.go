@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfig holds the cross-origin rules for browser clients
+type CORSConfig struct {
+	AllowedOrigins   []string      // exact origins, "*", or a wildcard subdomain such as "https://*.example.com"
+	AllowedMethods   []string      // methods allowed in preflighted requests
+	AllowedHeaders   []string      // request headers allowed in preflighted requests
+	ExposedHeaders   []string      // response headers readable by the browser
+	AllowCredentials bool          // whether cookies and Authorization headers may be sent
+	MaxAge           time.Duration // how long browsers may cache a preflight response
+}
+
+// Validate rejects rules letting every origin send credentials: the response would echo
+// any site's origin with Access-Control-Allow-Credentials, exposing the API to pages
+// the user merely visits
+func (cfg CORSConfig) Validate() error {
+	if !cfg.AllowCredentials {
+		return nil
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			return errors.New(`allowed origins may not include "*" when credentials are allowed`)
+		}
+	}
+	return nil
+}
+
+// CORS adds the cross-origin headers for allowed origins and answers preflight requests
+// itself. Requests from other origins pass through without CORS headers, so the browser
+// blocks them; server-to-server clients are unaffected. With no allowed origins the
+// middleware does nothing.
+func CORS(cfg CORSConfig) gin.HandlerFunc {
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	exposed := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	anyOrigin := false
+	for _, origin := range cfg.AllowedOrigins {
+		anyOrigin = anyOrigin || origin == "*"
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" || len(cfg.AllowedOrigins) == 0 {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Origin")
+		if !originAllowed(cfg.AllowedOrigins, origin) {
+			c.Next()
+			return
+		}
+
+		// Credentials are never allowed with "*", see Validate
+		if anyOrigin {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if cfg.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if !preflight {
+			if exposed != "" {
+				c.Header("Access-Control-Expose-Headers", exposed)
+			}
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+		c.Header("Access-Control-Allow-Methods", methods)
+		c.Header("Access-Control-Allow-Headers", headers)
+		if cfg.MaxAge > 0 {
+			c.Header("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// originAllowed reports whether origin matches one of the allowed origins
+func originAllowed(allowed []string, origin string) bool {
+	for _, pattern := range allowed {
+		if pattern == "*" || strings.EqualFold(pattern, origin) {
+			return true
+		}
+		scheme, host, ok := strings.Cut(pattern, "://*.")
+		if !ok {
+			continue
+		}
+		prefix := scheme + "://"
+		if len(origin) > len(prefix) && strings.EqualFold(origin[:len(prefix)], prefix) &&
+			strings.HasSuffix(strings.ToLower(origin), "."+strings.ToLower(host)) {
+			return true
+		}
+	}
+	return false
+}
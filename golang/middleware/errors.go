@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+
+	"channelmanager/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the request ID between clients, the API and logs
+const RequestIDHeader = "X-Request-ID"
+
+const requestIDKey = "request_id"
+
+// RequestID assigns every request an ID, reusing the client's X-Request-ID when sent
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > 64 {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// GetRequestID returns the ID assigned to the request by RequestID
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// ErrorHandler renders errors recorded with c.Error as the uniform API error envelope.
+// Errors that are not APIErrors are logged and reported as internal errors.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		apiErr := apierror.From(err)
+		if apiErr.Code == apierror.CodeInternal && apiErr != err {
+			log.Printf("Request %s failed: %v", GetRequestID(c), err)
+		}
+
+		abortWithError(c, apiErr)
+	}
+}
+
+// abortWithError writes the error envelope and stops the handler chain
+func abortWithError(c *gin.Context, apiErr *apierror.APIError) {
+	body := *apiErr
+	body.RequestID = GetRequestID(c)
+	c.AbortWithStatusJSON(apiErr.Status, gin.H{"error": body})
+}
+
+// newRequestID generates a random request ID
+func newRequestID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(buf)
+}
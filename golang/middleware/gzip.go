@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Gzip compresses responses for clients sending Accept-Encoding: gzip. A level of
+// zero disables compression. Bodiless responses such as 304 are left untouched.
+func Gzip(level int) gin.HandlerFunc {
+	if level == gzip.NoCompression {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	pool := sync.Pool{New: func() interface{} {
+		writer, err := gzip.NewWriterLevel(nil, level)
+		if err != nil {
+			writer = gzip.NewWriter(nil)
+		}
+		return writer
+	}}
+
+	return func(c *gin.Context) {
+		c.Header("Vary", "Accept-Encoding")
+		if !strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+			c.Next()
+			return
+		}
+
+		writer := &gzipWriter{ResponseWriter: c.Writer, pool: &pool}
+		c.Writer = writer
+		defer writer.close()
+
+		c.Next()
+	}
+}
+
+// gzipWriter compresses the response body, starting the gzip stream on the first write
+type gzipWriter struct {
+	gin.ResponseWriter
+	pool *sync.Pool
+	gz   *gzip.Writer
+}
+
+// Write compresses response bytes
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	if w.gz == nil {
+		if w.Header().Get("Content-Encoding") != "" {
+			return w.ResponseWriter.Write(data)
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Del("Content-Length")
+		w.gz = w.pool.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+	return w.gz.Write(data)
+}
+
+// WriteString compresses a response string
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush flushes compressed bytes to the client, e.g. for streamed responses
+func (w *gzipWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// close finishes the gzip stream and returns the compressor to the pool
+func (w *gzipWriter) close() {
+	if w.gz == nil {
+		return
+	}
+	w.gz.Close()
+	w.pool.Put(w.gz)
+	w.gz = nil
+}
@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/cache"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader is the request header carrying the client's idempotency key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyLockTTL bounds how long a crashed request can block retries of its key
+const idempotencyLockTTL = 30 * time.Second
+
+// Idempotency replays the stored response when a request repeats an Idempotency-Key,
+// so retried writes are applied once. Keys are scoped to the method, the path and the
+// caller, so one client cannot replay another's response; reusing a key with a
+// different body is rejected. Only successful responses are stored, so
+// failed requests can be retried with the same key. Requests without the header are
+// passed through, and Redis failures fall back to processing the request.
+func Idempotency(redis *cache.RedisClient, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			abortWithError(c, apierror.InvalidRequest("Idempotency-Key must be at most 255 characters"))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortWithError(c, apierror.InvalidRequest("Failed to read request body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		scope := hashHex(c.Request.Method + " " + c.Request.URL.Path + " " + idempotencyCaller(c) + " " + key)
+		fingerprint := hashHex(string(body))
+
+		stored, err := redis.GetIdempotentResponse(ctx, scope)
+		if err != nil {
+			log.Printf("Idempotency lookup failed, processing request: %v", err)
+			c.Next()
+			return
+		}
+		if stored != nil {
+			replay(c, stored, fingerprint)
+			return
+		}
+
+		acquired, err := redis.AcquireIdempotencyLock(ctx, scope, idempotencyLockTTL)
+		if err != nil {
+			log.Printf("Idempotency lock failed, processing request: %v", err)
+			c.Next()
+			return
+		}
+		if !acquired {
+			abortWithError(c, apierror.New(http.StatusConflict, apierror.CodeIdempotencyInProgress,
+				"A request with this Idempotency-Key is still being processed"))
+			return
+		}
+		// Release with a fresh context so a cancelled request still frees the key
+		defer func() {
+			if err := redis.ReleaseIdempotencyLock(context.Background(), scope); err != nil {
+				log.Printf("Failed to release idempotency lock: %v", err)
+			}
+		}()
+
+		// A request holding the lock may have stored its response and released the key
+		// between the lookup above and the lock, so look again before processing
+		stored, err = redis.GetIdempotentResponse(ctx, scope)
+		if err != nil {
+			log.Printf("Idempotency lookup failed, processing request: %v", err)
+		} else if stored != nil {
+			replay(c, stored, fingerprint)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if len(c.Errors) > 0 || status < 200 || status >= 300 {
+			return
+		}
+
+		response := &models.IdempotentResponse{
+			Fingerprint: fingerprint,
+			StatusCode:  status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+			CreatedAt:   time.Now(),
+		}
+		if err := redis.SetIdempotentResponse(context.Background(), scope, response, ttl); err != nil {
+			log.Printf("Failed to store idempotent response: %v", err)
+		}
+	}
+}
+
+// replay writes a stored response, rejecting keys reused with a different body
+func replay(c *gin.Context, stored *models.IdempotentResponse, fingerprint string) {
+	if stored.Fingerprint != fingerprint {
+		abortWithError(c, apierror.New(http.StatusUnprocessableEntity, apierror.CodeIdempotencyKeyReused,
+			"Idempotency-Key was already used with a different request body"))
+		return
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(stored.StatusCode, stored.ContentType, stored.Body)
+	c.Abort()
+}
+
+// responseRecorder keeps a copy of the response body while writing it to the client
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write records and writes response bytes
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString records and writes a response string
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotencyCaller identifies who sent a request: the hash of its API key, or the
+// client address of requests without one
+func idempotencyCaller(c *gin.Context) string {
+	if key := requestAPIKey(c); key != "" {
+		return "key:" + hashHex(key)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// hashHex returns the hex SHA-256 of a string
+func hashHex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
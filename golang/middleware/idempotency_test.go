@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"channelmanager/cache"
+	"channelmanager/testenv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testRedis connects to a Redis container started for the test, skipping the test when
+// Docker is not available
+func testRedis(t *testing.T) *cache.RedisClient {
+	t.Helper()
+	env, err := testenv.Start()
+	if errors.Is(err, testenv.ErrDockerUnavailable) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("failed to start test containers: %v", err)
+	}
+	t.Cleanup(func() { env.Close() })
+
+	client, err := cache.NewRedisClient(cache.Config{Host: env.RedisHost, Port: env.RedisPort})
+	if err != nil {
+		t.Fatalf("failed to connect to Redis: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestIdempotencyConcurrentRequests(t *testing.T) {
+	redis := testRedis(t)
+	gin.SetMode(gin.TestMode)
+
+	var bookings atomic.Int32
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.POST("/bookings", Idempotency(redis, time.Hour), func(c *gin.Context) {
+		time.Sleep(10 * time.Millisecond)
+		c.JSON(http.StatusCreated, gin.H{"id": bookings.Add(1)})
+	})
+
+	const requests = 50
+	var wg sync.WaitGroup
+	statuses := make([]int, requests)
+	for i := range statuses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(`{"property_id":1}`))
+			req.Header.Set(IdempotencyKeyHeader, "booking-1")
+			req.Header.Set("X-API-Key", "key")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			statuses[i] = w.Code
+		}(i)
+	}
+	wg.Wait()
+
+	if got := bookings.Load(); got != 1 {
+		t.Fatalf("created %d bookings for one Idempotency-Key, want 1", got)
+	}
+	for i, status := range statuses {
+		if status != http.StatusCreated && status != http.StatusConflict {
+			t.Errorf("request %d status = %d, want %d or %d", i, status, http.StatusCreated, http.StatusConflict)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(`{"property_id":1}`))
+	req.Header.Set(IdempotencyKeyHeader, "booking-1")
+	req.Header.Set("X-API-Key", "key")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry status = %d, replayed = %q, want a replayed %d", w.Code, w.Header().Get("Idempotent-Replayed"), http.StatusCreated)
+	}
+	if got := bookings.Load(); got != 1 {
+		t.Errorf("retry created another booking, %d in total", got)
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"channelmanager/apierror"
+	"channelmanager/cache"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maintenanceRefresh is how long a replica reuses the maintenance flag read from Redis
+const maintenanceRefresh = 2 * time.Second
+
+// Maintenance answers every request with 503 and a Retry-After header while maintenance
+// mode is on, except the health check and admin routes so operators can end it. Only
+// HTTP traffic is stopped: event processing and channel pushes keep running. The flag
+// is read from Redis at most every two seconds per replica, and a Redis failure leaves
+// the API up.
+func Maintenance(redis *cache.RedisClient) gin.HandlerFunc {
+	var (
+		mu      sync.Mutex
+		mode    *models.MaintenanceMode
+		checked time.Time
+	)
+	current := func() *models.MaintenanceMode {
+		mu.Lock()
+		defer mu.Unlock()
+		if time.Since(checked) < maintenanceRefresh {
+			return mode
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		latest, err := redis.GetMaintenanceMode(ctx)
+		if err != nil {
+			log.Printf("Failed to read maintenance mode: %v", err)
+			latest = nil
+		}
+		mode, checked = latest, time.Now()
+		return mode
+	}
+
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if path == "/health" || strings.HasPrefix(path, "/api/v1/admin/") {
+			c.Next()
+			return
+		}
+
+		active := current()
+		if active == nil {
+			c.Next()
+			return
+		}
+
+		message := active.Message
+		if message == "" {
+			message = "Service is under maintenance"
+		}
+		if active.RetryAfterSeconds > 0 {
+			c.Header("Retry-After", strconv.Itoa(active.RetryAfterSeconds))
+		}
+		abortWithError(c, apierror.New(http.StatusServiceUnavailable, apierror.CodeMaintenance, message))
+	}
+}
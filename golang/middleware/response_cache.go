@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"channelmanager/cache"
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResponseTag names the cache tag of a request's response; an empty tag leaves the
+// response uncached
+type ResponseTag func(c *gin.Context) string
+
+// StaticTag caches every response of a route under the same tag
+func StaticTag(tag string) ResponseTag {
+	return func(*gin.Context) string {
+		return tag
+	}
+}
+
+// CacheResponses caches successful GET responses for ttl, keyed by path, query and
+// Accept-Language, under the tag of the request. Invalidating the tag drops them all.
+// Responses carry an X-Cache header of HIT or MISS; cached ones keep the ETag of the
+// handler, or one hashed from the body, and answer a matching If-None-Match with 304
+// Not Modified. Redis failures fall back to the handler.
+func CacheResponses(redis *cache.RedisClient, ttl time.Duration, tag ResponseTag) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := tag(c)
+		if c.Request.Method != http.MethodGet || name == "" {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		generation, err := redis.ResponseGeneration(ctx, name)
+		if err != nil {
+			log.Printf("Response cache lookup failed, processing request: %v", err)
+			c.Next()
+			return
+		}
+		fingerprint := hashHex(c.Request.URL.Path + "?" + c.Request.URL.Query().Encode() +
+			"|" + strings.ToLower(strings.TrimSpace(c.GetHeader("Accept-Language"))))
+
+		stored, err := redis.GetCachedResponse(ctx, name, generation, fingerprint)
+		if err != nil {
+			log.Printf("Response cache lookup failed, processing request: %v", err)
+		}
+		if stored != nil {
+			serveCached(c, stored)
+			return
+		}
+
+		c.Header("X-Cache", "MISS")
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if len(c.Errors) > 0 || recorder.Status() != http.StatusOK {
+			return
+		}
+		body := recorder.body.Bytes()
+		etag := recorder.Header().Get("ETag")
+		if etag == "" {
+			etag = `"` + hashHex(string(body))[:32] + `"`
+		}
+		response := &models.CachedResponse{
+			StatusCode:  http.StatusOK,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Disposition: recorder.Header().Get("Content-Disposition"),
+			ETag:        etag,
+			Body:        body,
+			CreatedAt:   time.Now(),
+		}
+		if err := redis.SetCachedResponse(context.Background(), name, generation, fingerprint, response, ttl); err != nil {
+			log.Printf("Failed to cache response: %v", err)
+		}
+	}
+}
+
+// serveCached writes a cached response, or 304 Not Modified when the request already
+// holds its ETag
+func serveCached(c *gin.Context, stored *models.CachedResponse) {
+	c.Header("X-Cache", "HIT")
+	c.Header("ETag", stored.ETag)
+	if ETagMatches(c.GetHeader("If-None-Match"), stored.ETag) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		c.Abort()
+		return
+	}
+	if stored.Disposition != "" {
+		c.Header("Content-Disposition", stored.Disposition)
+	}
+	c.Data(stored.StatusCode, stored.ContentType, stored.Body)
+	c.Abort()
+}
+
+// ETagMatches reports whether an If-None-Match header names the ETag, using the weak
+// comparison required for GET requests
+func ETagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
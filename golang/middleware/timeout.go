@@ -0,0 +1,284 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"channelmanager/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteTimeout counts the requests of a route that ran past their timeout
+type RouteTimeout struct {
+	Route         string        `json:"route"` // "METHOD /path/:param"
+	Timeout       time.Duration `json:"timeout_ns"`
+	Timeouts      int64         `json:"timeouts"`       // answered with 504
+	LateResponses int64         `json:"late_responses"` // responded before noticing the deadline
+	LastAt        time.Time     `json:"last_at"`
+}
+
+var timeoutStats = struct {
+	sync.Mutex
+	routes map[string]*RouteTimeout
+}{routes: make(map[string]*RouteTimeout)}
+
+// TimeoutStats returns the timeouts recorded by this replica since it started, by route
+func TimeoutStats() []RouteTimeout {
+	timeoutStats.Lock()
+	defer timeoutStats.Unlock()
+
+	stats := make([]RouteTimeout, 0, len(timeoutStats.routes))
+	for _, stat := range timeoutStats.routes {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Route < stats[j].Route })
+	return stats
+}
+
+func recordTimeout(route string, timeout time.Duration, answered bool) {
+	timeoutStats.Lock()
+	defer timeoutStats.Unlock()
+
+	stat, ok := timeoutStats.routes[route]
+	if !ok {
+		stat = &RouteTimeout{Route: route}
+		timeoutStats.routes[route] = stat
+	}
+	stat.Timeout = timeout
+	stat.LastAt = time.Now()
+	if answered {
+		stat.Timeouts++
+	} else {
+		stat.LateResponses++
+	}
+}
+
+// Timeout cancels the request context once a request has run for its route's timeout,
+// so Redis calls and other work honouring c.Request.Context() stop early, and answers
+// 504 TIMEOUT at the deadline. The handler runs in its own goroutine writing to a
+// buffer: its response is sent if it finishes in time and discarded otherwise. Routes
+// are keyed as "METHOD /path/:param"; those missing from routes use the default, and
+// a zero timeout (e.g. for streaming routes) disables the limit.
+//
+// The 504 is sent whole, with its length and Connection: close, so the client is free
+// at the deadline; the middleware still waits for the handler to return before the
+// request's context is released.
+func Timeout(defaultTimeout time.Duration, routes map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		timeout, ok := routes[route]
+		if !ok {
+			timeout = defaultTimeout
+		}
+		if timeout <= 0 || c.FullPath() == "" {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		writer := c.Writer
+		buffer := newBufferedWriter(writer)
+		requestID := GetRequestID(c)
+		c.Writer = buffer
+
+		done := make(chan interface{}, 1)
+		go func() {
+			defer func() { done <- recover() }()
+			c.Next()
+		}()
+
+		var panicked interface{}
+		select {
+		case panicked = <-done:
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded && buffer.expire() {
+				recordTimeout(route, timeout, true)
+				log.Printf("Request %s %s exceeded its %s timeout", requestID, route, timeout)
+				writeTimeout(writer, requestID)
+			}
+			panicked = <-done
+		}
+
+		c.Writer = writer
+		if panicked != nil {
+			panic(panicked)
+		}
+		if buffer.expired() {
+			c.Abort()
+			return
+		}
+
+		// The handler noticed the deadline before it was answered: a response it wrote
+		// is sent late, otherwise the 504 is
+		if ctx.Err() == context.DeadlineExceeded {
+			recordTimeout(route, timeout, !buffer.Written())
+			log.Printf("Request %s %s exceeded its %s timeout", requestID, route, timeout)
+			if !buffer.Written() {
+				abortWithError(c, apierror.Timeout())
+				return
+			}
+		}
+		buffer.commit()
+	}
+}
+
+// writeTimeout sends the 504 envelope uncompressed and with its length, so the client
+// has the whole response while the handler winds down
+func writeTimeout(w gin.ResponseWriter, requestID string) {
+	body := *apierror.Timeout()
+	body.RequestID = requestID
+	data, err := json.Marshal(gin.H{"error": body})
+	if err != nil {
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	header.Set("Content-Encoding", "identity")
+	header.Set("Content-Length", strconv.Itoa(len(data)))
+	header.Set("Connection", "close")
+	w.WriteHeader(http.StatusGatewayTimeout)
+	w.Write(data)
+	w.Flush()
+}
+
+// bufferedWriter holds a handler's response until the handler finishes within its
+// timeout, and discards it once the request timed out
+type bufferedWriter struct {
+	gin.ResponseWriter
+	mu        sync.Mutex
+	header    http.Header
+	status    int
+	body      bytes.Buffer
+	written   bool // a body or the header was sent
+	hasStatus bool // a status was set
+	timeout   bool
+}
+
+func newBufferedWriter(w gin.ResponseWriter) *bufferedWriter {
+	return &bufferedWriter{ResponseWriter: w, header: w.Header().Clone(), status: http.StatusOK}
+}
+
+// Header returns the buffered response headers
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader buffers the response status; only the first one counts
+func (w *bufferedWriter) WriteHeader(status int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.written && status > 0 {
+		w.status, w.hasStatus = status, true
+	}
+}
+
+// WriteHeaderNow marks the buffered response as written
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.written = true
+}
+
+// Write buffers response bytes, discarding them once the request timed out
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.written = true
+	if w.timeout {
+		return len(data), nil
+	}
+	return w.body.Write(data)
+}
+
+// WriteString buffers a response string
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Status returns the buffered response status
+func (w *bufferedWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+// Size returns the number of buffered body bytes, or -1 before anything was written
+func (w *bufferedWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+// Written reports whether the handler wrote a response
+func (w *bufferedWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.written
+}
+
+// Flush does nothing: the response is sent when the handler finishes
+func (w *bufferedWriter) Flush() {}
+
+// Hijack is not supported on buffered responses
+func (w *bufferedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("connections of requests with a timeout cannot be hijacked")
+}
+
+// expire discards the handler's response from now on, reporting false if the
+// request had already timed out
+func (w *bufferedWriter) expire() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timeout {
+		return false
+	}
+	w.timeout = true
+	w.body.Reset()
+	return true
+}
+
+// expired reports whether the handler's response was discarded
+func (w *bufferedWriter) expired() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.timeout
+}
+
+// commit passes the buffered headers, status and body on to the client's writer
+func (w *bufferedWriter) commit() {
+	header := w.ResponseWriter.Header()
+	for key := range header {
+		if _, ok := w.header[key]; !ok {
+			header.Del(key)
+		}
+	}
+	for key, values := range w.header {
+		header[key] = values
+	}
+	if w.hasStatus || w.written {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.body.Len() > 0 {
+		w.ResponseWriter.Write(w.body.Bytes())
+	} else if w.written {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// ChannelAdapterSpec is the declarative definition of how the generic REST adapter
+// talks to a channel without an adapter of its own: endpoints and the JSON templates
+// fields are mapped into
+type ChannelAdapterSpec struct {
+	ChannelID string         `gorm:"primaryKey;type:varchar(50)" json:"channel_id"`
+	Spec      datatypes.JSON `json:"spec"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (ChannelAdapterSpec) TableName() string {
+	return "channel_adapter_specs"
+}
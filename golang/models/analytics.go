@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// PropertyAnalytics represents occupancy and revenue KPIs for a property over a date range
+type PropertyAnalytics struct {
+	PropertyID        uint      `json:"property_id"`
+	StartDate         string    `json:"start_date"`
+	EndDate           string    `json:"end_date"`
+	AvailableNights   int       `json:"available_nights"`
+	BookedNights      int       `json:"booked_nights"`
+	BlockedNights     int       `json:"blocked_nights"` // closed by calendar blocks rather than bookings
+	NoShowNights      int       `json:"no_show_nights"` // booked by guests who never arrived, not counted as booked
+	BookingCount      int       `json:"booking_count"`
+	Revenue           float64   `json:"revenue"`
+	OccupancyRate     float64   `json:"occupancy_rate"`      // booked nights / available nights
+	ADR               float64   `json:"adr"`                 // average daily rate: revenue / booked nights
+	RevPAR            float64   `json:"revpar"`              // revenue per available night
+	AverageListedRate float64   `json:"average_listed_rate"` // mean base price from pricing
+	GeneratedAt       time.Time `json:"generated_at"`
+
+	// Blocked nights per block reason, e.g. maintenance or owner_stay
+	BlockedNightsByReason map[string]int `json:"blocked_nights_by_reason,omitempty"`
+}
+
+// ChannelPerformance represents booking KPIs for a single channel over a period
+type ChannelPerformance struct {
+	ChannelID        string  `json:"channel_id"`
+	Bookings         int     `json:"bookings"`
+	CancelledCount   int     `json:"cancelled_count"`
+	NoShowCount      int     `json:"no_show_count"`
+	Revenue          float64 `json:"revenue"`
+	CancellationRate float64 `json:"cancellation_rate"`
+	NoShowRate       float64 `json:"no_show_rate"`
+	AverageLeadTime  float64 `json:"average_lead_time_days"` // days between booking and check-in
+	RevenueShare     float64 `json:"revenue_share"`
+}
+
+// ChannelPerformanceReport represents the per-channel breakdown for a period
+type ChannelPerformanceReport struct {
+	StartDate   string               `json:"start_date"`
+	EndDate     string               `json:"end_date"`
+	PropertyID  uint                 `json:"property_id,omitempty"`
+	Channels    []ChannelPerformance `json:"channels"`
+	GeneratedAt time.Time            `json:"generated_at"`
+}
+
+// PickupDate represents the booking position of one stay date
+type PickupDate struct {
+	Date     string     `json:"date"`
+	DaysOut  int        `json:"days_out"`          // days from the as-of date to the stay date
+	Booked   bool       `json:"booked"`            // on the books as of the as-of date
+	Closed   bool       `json:"closed"`            // closed without a booking
+	Blocked  string     `json:"blocked,omitempty"` // reason of the calendar block covering the date
+	BookedAt *time.Time `json:"booked_at,omitempty"`
+	PickedUp bool       `json:"picked_up"` // booked within the pickup window
+
+	// Probability between 0 and 1 that the night ends up booked, from the historical booking curve
+	ProjectedOccupancy float64 `json:"projected_occupancy"`
+
+	// Open, unbooked and closer than the median lead time, so later than most bookings arrive
+	Slow bool `json:"slow"`
+}
+
+// PickupReport represents the booking pace of a property's stay dates as of a given day
+type PickupReport struct {
+	PropertyID         uint         `json:"property_id"`
+	StartDate          string       `json:"start_date"`
+	EndDate            string       `json:"end_date"`
+	AsOf               string       `json:"as_of"`
+	WindowDays         int          `json:"window_days"`
+	OnTheBooks         int          `json:"on_the_books"` // booked nights
+	PickupNights       int          `json:"pickup_nights"`
+	OnTheBooksRate     float64      `json:"on_the_books_rate"`     // booked nights / open nights
+	ProjectedOccupancy float64      `json:"projected_occupancy"`   // expected final booked nights / open nights
+	HistoricOccupancy  float64      `json:"historic_occupancy"`    // occupancy over the year before as_of
+	MedianLeadTimeDays int          `json:"median_lead_time_days"` // of the nights booked in that year
+	SlowDates          int          `json:"slow_dates"`
+	Dates              []PickupDate `json:"dates"`
+	GeneratedAt        time.Time    `json:"generated_at"`
+}
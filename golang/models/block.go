@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Calendar block reasons
+const (
+	BlockReasonMaintenance = "maintenance"
+	BlockReasonOwnerStay   = "owner_stay"
+	BlockReasonOther       = "other"
+)
+
+// CalendarBlock closes a property's nights for a reason other than a booking, such as
+// maintenance or an owner stay
+type CalendarBlock struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	PropertyID uint      `gorm:"index:idx_block_property_dates" json:"property_id"`
+	StartDate  time.Time `gorm:"index:idx_block_property_dates;type:date" json:"start_date"`
+	EndDate    time.Time `gorm:"index:idx_block_property_dates;type:date" json:"end_date"` // inclusive
+	Reason     string    `gorm:"type:varchar(20)" json:"reason"`
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	// Relationship
+	Property *Property `gorm:"foreignKey:PropertyID" json:"-"`
+}
+
+// TableName specifies the table name
+func (CalendarBlock) TableName() string {
+	return "calendar_blocks"
+}
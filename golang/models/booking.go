@@ -0,0 +1,188 @@
+package models
+
+import (
+	"time"
+
+	"channelmanager/pii"
+
+	"gorm.io/gorm"
+)
+
+// Booking statuses
+const (
+	BookingStatusPending    = "pending" // requested and awaiting the host's approval; the nights are held
+	BookingStatusConfirmed  = "confirmed"
+	BookingStatusCancelled  = "cancelled"
+	BookingStatusCheckedIn  = "checked_in"
+	BookingStatusCheckedOut = "checked_out"
+	BookingStatusNoShow     = "no_show"  // the guest never arrived; the nights stay booked
+	BookingStatusDeclined   = "declined" // the host turned the request down
+	BookingStatusExpired    = "expired"  // the host did not answer the request in time
+)
+
+// Booking modes of a property
+const (
+	BookingModeInstant = "instant" // bookings are confirmed at once
+	BookingModeRequest = "request" // bookings are requests the host approves or declines
+)
+
+// BookingHoldingStatuses are the statuses of bookings whose nights stay closed, pending
+// requests included
+var BookingHoldingStatuses = []string{BookingStatusPending, BookingStatusConfirmed, BookingStatusCheckedIn,
+	BookingStatusCheckedOut, BookingStatusNoShow}
+
+// BookingAcceptedStatuses are the statuses of bookings holding their nights that the host
+// accepted, whose revenue and nights count in payouts and analytics
+var BookingAcceptedStatuses = []string{BookingStatusConfirmed, BookingStatusCheckedIn, BookingStatusCheckedOut,
+	BookingStatusNoShow}
+
+// BookingStayStatuses are the statuses of bookings whose nights count as occupied
+var BookingStayStatuses = []string{BookingStatusConfirmed, BookingStatusCheckedIn, BookingStatusCheckedOut}
+
+// BookingReleasedStatuses are the statuses of bookings that gave their nights back
+var BookingReleasedStatuses = []string{BookingStatusCancelled, BookingStatusDeclined, BookingStatusExpired}
+
+// bookingTransitions lists the statuses each status can move to
+var bookingTransitions = map[string][]string{
+	BookingStatusPending:   {BookingStatusConfirmed, BookingStatusDeclined, BookingStatusExpired},
+	BookingStatusConfirmed: {BookingStatusCheckedIn, BookingStatusNoShow, BookingStatusCancelled},
+	BookingStatusCheckedIn: {BookingStatusCheckedOut},
+}
+
+// ValidBookingStatus reports whether status is a booking status
+func ValidBookingStatus(status string) bool {
+	switch status {
+	case BookingStatusPending, BookingStatusConfirmed, BookingStatusCancelled, BookingStatusCheckedIn,
+		BookingStatusCheckedOut, BookingStatusNoShow, BookingStatusDeclined, BookingStatusExpired:
+		return true
+	}
+	return false
+}
+
+// CanTransitionBooking reports whether a booking can move from one status to another
+func CanTransitionBooking(from, to string) bool {
+	for _, status := range bookingTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Booking represents a guest reservation for a property
+type Booking struct {
+	ID                uint            `gorm:"primaryKey;index:idx_booking_property_created,priority:3;index:idx_booking_status_created,priority:3" json:"id"`
+	PropertyID        uint            `gorm:"index:idx_booking_property_dates;index:idx_booking_property_created,priority:1" json:"property_id"`
+	ChannelID         string          `gorm:"index;index:idx_booking_channel_created" json:"channel_id"`
+	ExternalReference string          `gorm:"index" json:"external_reference"`
+	GuestName         string          `json:"guest_name"`
+	GuestEmail        string          `gorm:"serializer:encrypted" json:"guest_email"`
+	GuestEmailHash    string          `gorm:"index;type:varchar(64)" json:"-"` // blind index for lookups by email
+	GuestPhone        string          `gorm:"serializer:encrypted" json:"guest_phone"`
+	CheckinDate       time.Time       `gorm:"index:idx_booking_property_dates;type:date" json:"checkin_date"`
+	CheckoutDate      time.Time       `gorm:"index:idx_booking_property_dates;type:date" json:"checkout_date"`
+	NumberOfGuests    int             `json:"number_of_guests"`
+	NumberOfChildren  int             `gorm:"default:0" json:"number_of_children"` // of number_of_guests
+	NumberOfInfants   int             `gorm:"default:0" json:"number_of_infants"`  // of number_of_guests
+	Status            string          `gorm:"index;index:idx_booking_status_created,priority:1;default:confirmed" json:"status"`
+	CheckedInAt       *time.Time      `json:"checked_in_at,omitempty"`
+	CheckedOutAt      *time.Time      `json:"checked_out_at,omitempty"`
+	NoShowAt          *time.Time      `json:"no_show_at,omitempty"`
+	ApprovalDeadline  *time.Time      `gorm:"index" json:"approval_deadline,omitempty"` // when a pending request expires
+	DecidedAt         *time.Time      `json:"decided_at,omitempty"`                     // when the host approved or declined it
+	DeclineReason     string          `json:"decline_reason,omitempty"`
+	Currency          string          `gorm:"type:varchar(3);default:USD" json:"currency"`
+	CancelPolicy      string          `gorm:"type:varchar(20)" json:"cancellation_policy,omitempty"`
+	TotalPrice        float64         `json:"total_price"`
+	LoyaltyCredit     float64         `gorm:"default:0" json:"loyalty_credit,omitempty"`           // paid with loyalty points, not in TotalPrice
+	VoucherAmount     float64         `gorm:"default:0" json:"voucher_amount,omitempty"`           // paid with vouchers, not in TotalPrice
+	Pricing           *BookingPricing `gorm:"serializer:json;type:jsonb" json:"pricing,omitempty"` // as quoted when booked; none for channel-priced reservations
+	CreatedAt         time.Time       `gorm:"index:idx_booking_channel_created;index:idx_booking_property_created,priority:2;index:idx_booking_status_created,priority:2" json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt  `gorm:"index" json:"-"`
+
+	// Security deposit taken for the stay
+	BookingDeposit `gorm:"embedded"`
+
+	// Relationships
+	Property      *Property             `gorm:"foreignKey:PropertyID" json:"-"`
+	Modifications []BookingModification `gorm:"foreignKey:BookingID" json:"modifications,omitempty"`
+}
+
+// BookingPricing is the price breakdown a booking was made at, kept so its documents show
+// what the guest was charged whatever the rates become
+type BookingPricing struct {
+	Subtotal      float64         `json:"subtotal"`
+	Taxes         float64         `json:"taxes"`
+	Fees          float64         `json:"fees"`
+	Discounts     float64         `json:"discounts"`
+	Credit        float64         `json:"credit,omitempty"`
+	VoucherAmount float64         `json:"voucher_amount,omitempty"`
+	Total         float64         `json:"total"`
+	Items         []PriceLineItem `json:"items"` // each night, then the discounts, taxes, fees and payments
+}
+
+// TableName specifies the table name
+func (Booking) TableName() string {
+	return "bookings"
+}
+
+// BeforeSave keeps the email blind index in step with the encrypted email
+func (b *Booking) BeforeSave(tx *gorm.DB) error {
+	b.GuestEmailHash = pii.BlindIndex(b.GuestEmail)
+	return nil
+}
+
+// WithoutGuestDetails returns a copy of the booking without the guest's contact
+// details, for snapshots such as change events that are stored unencrypted
+func (b Booking) WithoutGuestDetails() Booking {
+	b.GuestName = ""
+	b.GuestEmail = ""
+	b.GuestEmailHash = ""
+	b.GuestPhone = ""
+	return b
+}
+
+// Released reports whether the booking gave its nights back: cancelled, declined or
+// expired
+func (b Booking) Released() bool {
+	for _, status := range BookingReleasedStatuses {
+		if b.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Nights returns the number of nights covered by the booking
+func (b Booking) Nights() int {
+	return int(b.CheckoutDate.Sub(b.CheckinDate).Hours() / 24)
+}
+
+// Invoice document types
+const (
+	InvoiceTypeInvoice = "invoice"
+	InvoiceTypeReceipt = "receipt"
+)
+
+// Invoice represents a generated invoice or receipt document for a booking
+type Invoice struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	BookingID  uint      `gorm:"uniqueIndex:idx_invoice_booking_type" json:"booking_id"`
+	Type       string    `gorm:"uniqueIndex:idx_invoice_booking_type;type:varchar(20)" json:"type"`
+	Number     string    `gorm:"uniqueIndex;type:varchar(50)" json:"number"`
+	StorageKey string    `json:"storage_key"`
+	Currency   string    `gorm:"type:varchar(3)" json:"currency"`
+	Total      float64   `json:"total"`
+	IssuedAt   time.Time `json:"issued_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	// Relationship
+	Booking *Booking `gorm:"foreignKey:BookingID" json:"-"`
+}
+
+// TableName specifies the table name
+func (Invoice) TableName() string {
+	return "invoices"
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Booking modification types
+const (
+	ModificationModified  = "modified"
+	ModificationCancelled = "cancelled"
+)
+
+// BookingModification records a change a channel made to a reservation after booking it
+type BookingModification struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	BookingID uint           `gorm:"index" json:"booking_id"`
+	ChannelID string         `gorm:"type:varchar(50)" json:"channel_id"`
+	Type      string         `gorm:"type:varchar(20)" json:"type"`
+	Changes   datatypes.JSON `json:"changes"` // FieldChange by field name
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (BookingModification) TableName() string {
+	return "booking_modifications"
+}
+
+// FieldChange is the previous and new value of a changed booking field. The values of
+// guest contact details are left out, as modifications are stored unencrypted.
+type FieldChange struct {
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// CacheMismatch is a cache entry found to disagree with the database, and repaired
+type CacheMismatch struct {
+	Key        string `json:"key"`
+	PropertyID uint   `json:"property_id"`
+	Reason     string `json:"reason"`
+}
+
+// CacheConsistencyReport is the outcome of the latest check comparing sampled property
+// and search cache entries with the database
+type CacheConsistencyReport struct {
+	CheckedAt         time.Time       `json:"checked_at"`
+	PropertiesChecked int             `json:"properties_checked"`
+	PropertiesStale   int             `json:"properties_stale"`
+	SearchesChecked   int             `json:"searches_checked"`
+	SearchesStale     int             `json:"searches_stale"`
+	Skipped           int             `json:"skipped"`     // entries with change events still to process
+	StaleRatio        float64         `json:"stale_ratio"` // stale entries among those checked
+	Mismatches        []CacheMismatch `json:"mismatches"`
+
+	// Entries checked and found stale by every check so far
+	TotalChecked int64 `json:"total_checked"`
+	TotalStale   int64 `json:"total_stale"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// CalendarFeed is a host's subscription to the bookings of a property as an iCalendar
+// feed, e.g. for Google Calendar. Its URL is signed, so it works without an API key,
+// and stops working once the feed is revoked.
+type CalendarFeed struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	PropertyID    uint       `gorm:"index" json:"property_id"`
+	Name          string     `gorm:"type:varchar(100)" json:"name"`
+	URL           string     `gorm:"-" json:"url,omitempty"` // signed feed URL; empty once revoked
+	LastFetchedAt *time.Time `json:"last_fetched_at,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (CalendarFeed) TableName() string {
+	return "calendar_feeds"
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// PropertyChange notifies subscribers that a processed event changed a property's
+// details, availability or rates
+type PropertyChange struct {
+	EventID    uint           `json:"event_id"`
+	PropertyID uint           `json:"property_id"`
+	Table      string         `json:"table"`            // properties, availabilities or pricing
+	EventType  string         `json:"event_type"`       // CREATE, UPDATE, DELETE
+	Date       string         `json:"date,omitempty"`   // changed night, for availability and pricing
+	Record     datatypes.JSON `json:"record,omitempty"` // the changed row as recorded by the event
+	OccurredAt time.Time      `json:"occurred_at"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Channel represents a distribution channel (OTA) such as Booking.com or Airbnb.
+// The ID is the channel code referenced as channel_id throughout the system.
+type Channel struct {
+	ID        string         `gorm:"primaryKey;type:varchar(50)" json:"id"`
+	Name      string         `json:"name"`
+	Active    bool           `gorm:"default:true" json:"active"`
+	Currency  string         `gorm:"type:varchar(3);default:USD" json:"currency"`
+	DryRun    bool           `json:"dry_run"` // render and store payloads instead of sending them
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Pricing rules applied when pushing rates to the channel
+	MarkupPercent     float64 `json:"markup_percent"`
+	FixedFee          float64 `json:"fixed_fee"`           // added per night after the markup
+	CommissionPercent float64 `json:"commission_percent"`  // taken by the channel from each booking
+	GrossUpCommission bool    `json:"gross_up_commission"` // raise rates so the net after commission is unchanged
+}
+
+// TableName specifies the table name
+func (Channel) TableName() string {
+	return "channels"
+}
+
+// ApplyPricingRules converts a base nightly price into the rate pushed to the channel
+func (ch Channel) ApplyPricingRules(basePrice float64) float64 {
+	rate := basePrice*(1+ch.MarkupPercent/100) + ch.FixedFee
+	if ch.GrossUpCommission && ch.CommissionPercent > 0 && ch.CommissionPercent < 100 {
+		rate = rate / (1 - ch.CommissionPercent/100)
+	}
+	return math.Round(rate*100) / 100
+}
+
+// ChannelMapping links a property to its listing on a channel
+type ChannelMapping struct {
+	ID                 uint           `gorm:"primaryKey" json:"id"`
+	ChannelID          string         `gorm:"uniqueIndex:idx_channel_mapping;type:varchar(50)" json:"channel_id"`
+	PropertyID         uint           `gorm:"uniqueIndex:idx_channel_mapping" json:"property_id"`
+	ExternalPropertyID string         `json:"external_property_id"`
+	ExternalRoomID     string         `json:"external_room_id"`
+	ExternalRatePlanID string         `json:"external_rate_plan_id"`
+	Active             bool           `gorm:"default:true" json:"active"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Channel  *Channel  `gorm:"foreignKey:ChannelID" json:"-"`
+	Property *Property `gorm:"foreignKey:PropertyID" json:"-"`
+}
+
+// TableName specifies the table name
+func (ChannelMapping) TableName() string {
+	return "channel_mappings"
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Inventory conflict types
+const (
+	ConflictOverbooking   = "overbooking"    // two confirmed bookings hold the same night
+	ConflictOpenInventory = "open_inventory" // a booked night is still open for sale
+)
+
+// Inventory conflict statuses
+const (
+	ConflictStatusOpen     = "open"
+	ConflictStatusResolved = "resolved"
+)
+
+// Conflict resolutions
+const (
+	ConflictResolutionRelocate  = "relocate"   // a booking was moved to another property
+	ConflictResolutionCancel    = "cancel"     // a booking was cancelled
+	ConflictResolutionForceSync = "force_sync" // the nights were closed and pushed to channels
+	ConflictResolutionCleared   = "cleared"    // the reconciler no longer found the conflict
+)
+
+// Conflict records an inconsistency between bookings and inventory found by the reconciler
+type Conflict struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Type           string     `gorm:"uniqueIndex:idx_conflict_bookings;type:varchar(20)" json:"type"`
+	PropertyID     uint       `gorm:"index" json:"property_id"`
+	BookingID      uint       `gorm:"uniqueIndex:idx_conflict_bookings" json:"booking_id"`
+	OtherBookingID uint       `gorm:"uniqueIndex:idx_conflict_bookings" json:"other_booking_id,omitempty"` // the overlapping booking of an overbooking
+	ChannelID      string     `gorm:"type:varchar(50)" json:"channel_id,omitempty"`                        // channel that reported the later booking
+	Date           time.Time  `gorm:"type:date" json:"date"`                                               // first affected night
+	Nights         int        `json:"nights"`
+	Status         string     `gorm:"index;type:varchar(20)" json:"status"`
+	Resolution     string     `gorm:"type:varchar(20)" json:"resolution,omitempty"`
+	DetectedAt     time.Time  `json:"detected_at"`
+	LastCheckedAt  time.Time  `json:"last_checked_at"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+}
+
+// TableName specifies the table name
+func (Conflict) TableName() string {
+	return "conflicts"
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Record types that can carry channel content codes
+const (
+	ContentRecordAmenity   = "amenity"
+	ContentRecordCondition = "condition"
+)
+
+// ContentCodeMapping maps an amenity or condition to the code a channel expects when
+// receiving property content. Rows with an empty ChannelID hold the OpenTravel (OTA)
+// standard code, used for every channel without a code of its own.
+type ContentCodeMapping struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	RecordType string    `gorm:"uniqueIndex:idx_content_code_mapping;type:varchar(20)" json:"record_type"`
+	RecordID   uint      `gorm:"uniqueIndex:idx_content_code_mapping" json:"record_id"`
+	ChannelID  string    `gorm:"uniqueIndex:idx_content_code_mapping;type:varchar(50)" json:"channel_id"` // empty for the OTA standard code
+	CodeList   string    `gorm:"type:varchar(20)" json:"code_list"`                                       // e.g. OTA "HAC" (hotel amenity) or "RMA" (room amenity)
+	Code       string    `gorm:"type:varchar(50)" json:"code"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (ContentCodeMapping) TableName() string {
+	return "content_code_mappings"
+}
+
+// IsStandard reports whether the mapping holds the OTA standard code
+func (m ContentCodeMapping) IsStandard() bool {
+	return m.ChannelID == ""
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Channel credential types
+const (
+	CredentialOAuth2 = "oauth2"  // client credentials exchanged for expiring access tokens
+	CredentialBasic  = "basic"   // username and password, e.g. for XML APIs
+	CredentialAPIKey = "api_key" // a static key sent with every request
+)
+
+// Channel credential statuses
+const (
+	CredentialStatusActive   = "active"   // used for new requests
+	CredentialStatusPrevious = "previous" // replaced by a rotation, still accepted until RetiresAt
+)
+
+// ChannelCredential holds the secrets used to call a channel's API. Secrets and tokens
+// are encrypted at rest and never returned by the API.
+type ChannelCredential struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	ChannelID string `gorm:"index:idx_channel_credential;type:varchar(50)" json:"channel_id"`
+	Version   int    `json:"version"` // increases with every rotation of the channel's credentials
+	Type      string `gorm:"type:varchar(20)" json:"type"`
+	Status    string `gorm:"index:idx_channel_credential;type:varchar(20)" json:"status"`
+
+	Username     string `json:"username,omitempty"`
+	Secret       string `gorm:"serializer:encrypted" json:"-"` // password or API key
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `gorm:"serializer:encrypted" json:"-"`
+	TokenURL     string `json:"token_url,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+
+	// OAuth tokens, refreshed before they expire
+	AccessToken     string     `gorm:"serializer:encrypted" json:"-"`
+	RefreshToken    string     `gorm:"serializer:encrypted" json:"-"`
+	TokenExpiresAt  *time.Time `json:"token_expires_at,omitempty"`
+	LastRefreshedAt *time.Time `json:"last_refreshed_at,omitempty"`
+	RefreshError    string     `gorm:"type:text" json:"refresh_error,omitempty"`
+
+	RetiresAt *time.Time `json:"retires_at,omitempty"` // set when the credential is rotated out
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (ChannelCredential) TableName() string {
+	return "channel_credentials"
+}
+
+// TokenExpiring reports whether the OAuth access token is missing or expires within margin
+func (c ChannelCredential) TokenExpiring(now time.Time, margin time.Duration) bool {
+	if c.Type != CredentialOAuth2 {
+		return false
+	}
+	return c.AccessToken == "" || (c.TokenExpiresAt != nil && !c.TokenExpiresAt.After(now.Add(margin)))
+}
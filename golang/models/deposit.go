@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Security deposit modes
+const (
+	DepositModeHold   = "hold"   // authorized on the guest's card and released after the stay
+	DepositModeCharge = "charge" // charged before arrival and refunded after the stay
+)
+
+// Security deposit states of a booking
+const (
+	DepositStatusNone      = "none"      // the property required no deposit
+	DepositStatusPending   = "pending"   // due, not yet placed
+	DepositStatusHeld      = "held"      // authorized or charged
+	DepositStatusReleased  = "released"  // hold released or charge refunded
+	DepositStatusCancelled = "cancelled" // booking cancelled before the deposit was placed
+	DepositStatusFailed    = "failed"    // the payment provider declined it
+)
+
+// DepositTerms describe the security deposit a stay requires. It is refundable and not
+// part of the stay's total.
+type DepositTerms struct {
+	Amount float64 `json:"amount"`
+	Mode   string  `json:"mode"`
+}
+
+// Deposit returns the property's security deposit terms, or nil when it requires none
+func (p Property) Deposit() *DepositTerms {
+	if p.DepositAmount <= 0 {
+		return nil
+	}
+	mode := p.DepositMode
+	if mode == "" {
+		mode = DepositModeHold
+	}
+	return &DepositTerms{Amount: p.DepositAmount, Mode: mode}
+}
+
+// BookingDeposit holds the security deposit state of a booking
+type BookingDeposit struct {
+	DepositAmount     float64    `json:"deposit_amount"`
+	DepositMode       string     `gorm:"type:varchar(10)" json:"deposit_mode,omitempty"`
+	DepositStatus     string     `gorm:"type:varchar(20);index;default:none" json:"deposit_status"`
+	DepositReference  string     `gorm:"type:varchar(100)" json:"deposit_reference,omitempty"`
+	DepositError      string     `json:"deposit_error,omitempty"`
+	DepositPlacedAt   *time.Time `json:"deposit_placed_at,omitempty"`
+	DepositReleasedAt *time.Time `json:"deposit_released_at,omitempty"`
+}
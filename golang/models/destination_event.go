@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Destination event types
+const (
+	DestinationEventHoliday = "holiday"
+	DestinationEventEvent   = "event" // festivals, fairs, concerts, conferences
+)
+
+// Destination event sources
+const (
+	DestinationEventSourceManual = "manual"
+	DestinationEventSourceICal   = "ical" // imported from a public calendar
+)
+
+// DestinationEvent is a holiday or local event in a city. Nights it covers are priced
+// with its demand multiplier on top of the property's rate and seasons.
+type DestinationEvent struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	City             string    `gorm:"index:idx_destination_event_city_dates;type:varchar(100)" json:"city"`
+	Country          string    `gorm:"type:varchar(100)" json:"country,omitempty"` // empty applies to the city in every country
+	Name             string    `json:"name"`
+	Type             string    `gorm:"type:varchar(20)" json:"type"`
+	StartDate        time.Time `gorm:"index:idx_destination_event_city_dates;type:date" json:"start_date"`
+	EndDate          time.Time `gorm:"index:idx_destination_event_city_dates;type:date" json:"end_date"` // inclusive
+	DemandMultiplier float64   `gorm:"default:1" json:"demand_multiplier"`
+	Source           string    `gorm:"type:varchar(20)" json:"source"`
+	SourceURL        string    `gorm:"index" json:"source_url,omitempty"` // calendar an imported event came from
+	ExternalUID      string    `json:"-"`                                 // UID of the event in that calendar
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (DestinationEvent) TableName() string {
+	return "destination_events"
+}
+
+// Covers reports whether the event includes the given night
+func (e DestinationEvent) Covers(date time.Time) bool {
+	day := date.Format("2006-01-02")
+	return day >= e.StartDate.Format("2006-01-02") && day <= e.EndDate.Format("2006-01-02")
+}
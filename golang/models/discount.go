@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// LengthOfStayDiscount represents a discount tier applied to stays of at least MinNights,
+// e.g. 10% for weekly stays or 25% for monthly stays
+type LengthOfStayDiscount struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	PropertyID uint      `gorm:"uniqueIndex:idx_los_property_nights" json:"property_id"`
+	MinNights  int       `gorm:"uniqueIndex:idx_los_property_nights" json:"min_nights"`
+	Percent    float64   `json:"percent"`
+	Name       string    `json:"name"` // e.g. "Weekly discount"
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (LengthOfStayDiscount) TableName() string {
+	return "length_of_stay_discounts"
+}
+
+// Price line item types
+const (
+	LineItemAccommodation = "accommodation"
+	LineItemTax           = "tax"
+	LineItemFee           = "fee"
+	LineItemDiscount      = "discount"
+	LineItemExtraGuest    = "extra_guest"
+	LineItemCredit        = "credit"  // loyalty points redeemed
+	LineItemVoucher       = "voucher" // voucher balance spent
+)
+
+// PriceLineItem represents one itemized component of a stay price
+type PriceLineItem struct {
+	Type        string  `json:"type"`
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"` // discounts are negative
+}
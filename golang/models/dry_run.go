@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// DryRunPayload is a payload rendered for a channel in dry-run mode instead of being sent
+type DryRunPayload struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	ChannelID  string         `gorm:"index:idx_dry_run_channel;type:varchar(50)" json:"channel_id"`
+	PropertyID uint           `gorm:"index" json:"property_id"`
+	Operation  string         `gorm:"type:varchar(20)" json:"operation"` // a SyncOperation* value
+	Payload    datatypes.JSON `json:"payload"`
+	CreatedAt  time.Time      `gorm:"index:idx_dry_run_channel" json:"created_at"`
+}
+
+// TableName specifies the table name
+func (DryRunPayload) TableName() string {
+	return "dry_run_payloads"
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Duplicate candidate statuses
+const (
+	DuplicateStatusPending   = "pending"
+	DuplicateStatusMerged    = "merged"
+	DuplicateStatusDismissed = "dismissed" // reviewed and found to be distinct properties
+)
+
+// DuplicateCandidate is a pair of listings from different channels that are likely the
+// same property: close together with similar names. Pairs wait in a review queue until
+// an admin merges or dismisses them; dismissed pairs are not flagged again.
+type DuplicateCandidate struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	PropertyID     uint       `gorm:"uniqueIndex:idx_duplicate_pair" json:"property_id"`  // the lower ID of the pair
+	DuplicateID    uint       `gorm:"uniqueIndex:idx_duplicate_pair" json:"duplicate_id"` // the higher ID
+	DistanceMeters float64    `json:"distance_meters"`
+	NameSimilarity float64    `json:"name_similarity"` // trigram similarity between 0 and 1
+	Status         string     `gorm:"type:varchar(20);index;default:pending" json:"status"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relationships; a merged-away property is no longer loaded
+	Property  *Property `gorm:"foreignKey:PropertyID" json:"property,omitempty"`
+	Duplicate *Property `gorm:"foreignKey:DuplicateID" json:"duplicate,omitempty"`
+}
+
+// TableName specifies the table name
+func (DuplicateCandidate) TableName() string {
+	return "duplicate_candidates"
+}
+
+// PropertyMerge summarizes what merging a duplicate onto a surviving property moved
+type PropertyMerge struct {
+	SurvivorID      uint    `json:"survivor_id"`
+	DuplicateID     uint    `json:"duplicate_id"`
+	BookingsMoved   int64   `json:"bookings_moved"`
+	NightsClosed    int     `json:"nights_closed"`    // survivor nights closed for the moved bookings
+	MappingsMoved   int64   `json:"mappings_moved"`   // channel mappings now on the survivor
+	MappingsDropped int64   `json:"mappings_dropped"` // mappings to channels the survivor was already mapped to
+	Rating          float32 `json:"rating"`           // the survivor's rating over the reviews of both
+	ReviewCount     int     `json:"review_count"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// ExchangeRate is the latest known rate of a currency against the currency property
+// prices are set in
+type ExchangeRate struct {
+	Currency  string    `gorm:"primaryKey;type:varchar(3)" json:"currency"`
+	Rate      float64   `json:"rate"`                           // units of Currency per unit of the base currency
+	Source    string    `gorm:"type:varchar(30)" json:"source"` // provider the rate was fetched from
+	AsOf      time.Time `json:"as_of"`                          // when the provider published the rate
+	FetchedAt time.Time `json:"fetched_at"`
+	Stale     bool      `gorm:"-" json:"stale"` // older than the configured maximum age
+}
+
+// TableName specifies the table name
+func (ExchangeRate) TableName() string {
+	return "exchange_rates"
+}
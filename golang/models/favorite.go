@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Favorite is a property on a user's wishlist
+type Favorite struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     string    `gorm:"uniqueIndex:idx_user_favorite;type:varchar(100)" json:"user_id"`
+	PropertyID uint      `gorm:"uniqueIndex:idx_user_favorite;index" json:"property_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (Favorite) TableName() string {
+	return "favorites"
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Fee types
+const (
+	FeeTypeCleaning = "cleaning"
+	FeeTypeResort   = "resort"
+	FeeTypePet      = "pet"
+	FeeTypeService  = "service"
+	FeeTypeOther    = "other"
+)
+
+// Fee bases: how a fee's amount is multiplied over the stay
+const (
+	FeePerStay          = "per_stay"
+	FeePerNight         = "per_night"
+	FeePerGuest         = "per_guest"
+	FeePerGuestPerNight = "per_guest_per_night"
+)
+
+// Fee represents a charge added to a property's stays on top of the nightly rates,
+// e.g. a cleaning fee per stay or a resort fee per night. Mandatory fees are part of
+// every quote; optional ones, such as a pet fee, are listed for the guest to add.
+type Fee struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	PropertyID uint      `gorm:"index" json:"property_id"`
+	Name       string    `json:"name"` // e.g. "Final cleaning"
+	Type       string    `gorm:"type:varchar(20)" json:"type"`
+	Amount     float64   `json:"amount"`
+	Basis      string    `gorm:"type:varchar(30)" json:"basis"`
+	Taxable    bool      `json:"taxable"` // percentage taxes apply to the fee
+	Mandatory  bool      `json:"mandatory"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (Fee) TableName() string {
+	return "fees"
+}
+
+// Total returns the fee for a stay of nights nights and guests guests
+func (f Fee) Total(nights, guests int) float64 {
+	switch f.Basis {
+	case FeePerNight:
+		return f.Amount * float64(nights)
+	case FeePerGuest:
+		return f.Amount * float64(guests)
+	case FeePerGuestPerNight:
+		return f.Amount * float64(nights*guests)
+	default:
+		return f.Amount
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// ErasedGuestName replaces the name on the bookings of an erased guest
+const ErasedGuestName = "Erased guest"
+
+// GuestErasure is the audit record of a guest's personal data being erased. It keeps
+// a hash of the guest's email address, never the address itself.
+type GuestErasure struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	SubjectHash        string    `gorm:"index;type:varchar(64)" json:"subject_hash"`
+	Reason             string    `json:"reason,omitempty"`
+	BookingsAnonymized int       `json:"bookings_anonymized"`
+	EventsScrubbed     int       `json:"events_scrubbed"`
+	InvoicesRetained   int       `json:"invoices_retained"` // accounting documents kept as legally required
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (GuestErasure) TableName() string {
+	return "guest_erasures"
+}
+
+// GuestSubjectHash returns the hash identifying a guest's email address in erasure records
+func GuestSubjectHash(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
+
+// GuestDataExport holds all personal data stored for a guest
+type GuestDataExport struct {
+	Email        string         `json:"email"`
+	ExportedAt   time.Time      `json:"exported_at"`
+	Bookings     []Booking      `json:"bookings"`
+	Invoices     []Invoice      `json:"invoices"`
+	ChangeEvents []Event        `json:"change_events"` // booking snapshots kept for change processing
+	Loyalty      []LoyaltyEntry `json:"loyalty"`
+	Erasures     []GuestErasure `json:"erasures"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// PricingRevision records the rate of a night as written by one pricing change event
+type PricingRevision struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	EventID    uint      `gorm:"uniqueIndex" json:"event_id"`
+	PropertyID uint      `gorm:"index:idx_pricing_revision_night" json:"property_id"`
+	Date       time.Time `gorm:"index:idx_pricing_revision_night;type:date" json:"date"`
+	EventType  string    `gorm:"type:varchar(10)" json:"event_type"` // CREATE, UPDATE, DELETE
+	Source     string    `gorm:"type:varchar(100)" json:"source"`    // see the EventSource constants; empty when unknown
+	BasePrice  float64   `json:"base_price"`
+	Taxes      float64   `json:"taxes"`
+	Fees       float64   `json:"fees"`
+	Discount   float64   `json:"discount"`
+	ChangedAt  time.Time `gorm:"index" json:"changed_at"` // when the change event was recorded
+	CreatedAt  time.Time `json:"-"`
+
+	// Base price of the night before this revision; unset for its first revision
+	PreviousBasePrice *float64 `gorm:"-" json:"previous_base_price"`
+}
+
+// TableName specifies the table name
+func (PricingRevision) TableName() string {
+	return "pricing_history"
+}
+
+// AvailabilityRevision records a night's availability as written by one availability
+// change event, with what made the change
+type AvailabilityRevision struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	EventID    uint      `gorm:"uniqueIndex" json:"event_id"`
+	PropertyID uint      `gorm:"index:idx_availability_revision_night" json:"property_id"`
+	Date       time.Time `gorm:"index:idx_availability_revision_night;type:date" json:"date"`
+	EventType  string    `gorm:"type:varchar(10)" json:"event_type"` // CREATE, UPDATE, DELETE
+	Source     string    `gorm:"type:varchar(100)" json:"source"`    // see the EventSource constants; empty when unknown
+	Available  bool      `json:"available"`
+	MinStay    int       `json:"min_stay"`
+	MaxGuests  int       `json:"max_guests"`
+	BookingID  *uint     `json:"booking_id,omitempty"`
+	BlockID    *uint     `json:"block_id,omitempty"`
+	ChangedAt  time.Time `gorm:"index" json:"changed_at"` // when the change event was recorded
+	CreatedAt  time.Time `json:"-"`
+
+	// Whether the night was available before this revision; unset for its first revision
+	PreviouslyAvailable *bool `gorm:"-" json:"previously_available"`
+}
+
+// TableName specifies the table name
+func (AvailabilityRevision) TableName() string {
+	return "availability_history"
+}
@@ -0,0 +1,26 @@
+package models
+
+// HouseRules are a property's structured rules for guests. They replace rules written
+// into the free-text HouseRules notes, which remain for anything the structure does not
+// cover.
+type HouseRules struct {
+	// Quiet hours as HH:MM in the property's time zone; both empty when there are none
+	QuietHoursStart string `json:"quiet_hours_start,omitempty" binding:"required_with=QuietHoursEnd,omitempty,datetime=15:04"`
+	QuietHoursEnd   string `json:"quiet_hours_end,omitempty" binding:"required_with=QuietHoursStart,omitempty,datetime=15:04"`
+
+	PartiesAllowed bool `json:"parties_allowed"`
+
+	// Visitors beyond the booked guests, up to MaxAdditionalGuests at a time (0 for any)
+	AdditionalGuestsAllowed bool `json:"additional_guests_allowed"`
+	MaxAdditionalGuests     int  `json:"max_additional_guests" binding:"min=0,max=50"`
+
+	// Child policy: children and infants (under 2) welcome, and the minimum age of a
+	// child guest when children are allowed (0 for any age)
+	ChildrenAllowed bool `json:"children_allowed"`
+	InfantsAllowed  bool `json:"infants_allowed"`
+	MinChildAge     int  `json:"min_child_age" binding:"min=0,max=17"`
+	CribsAvailable  bool `json:"cribs_available"`
+}
+
+// DefaultHouseRules are the rules of properties that have not set any
+var DefaultHouseRules = HouseRules{ChildrenAllowed: true, InfantsAllowed: true}
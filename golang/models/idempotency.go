@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// IdempotentResponse is a stored response replayed for requests repeating an Idempotency-Key
+type IdempotentResponse struct {
+	Fingerprint string    `json:"fingerprint"` // hash of the original request body
+	StatusCode  int       `json:"status_code"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"body"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// InboundReservation records a reservation received from a channel, keyed by the
+// channel's reference, so redelivered reservations update the booking they were stored
+// as instead of creating another one
+type InboundReservation struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	ChannelID         string    `gorm:"uniqueIndex:idx_inbound_reservation;type:varchar(50)" json:"channel_id"`
+	ExternalReference string    `gorm:"uniqueIndex:idx_inbound_reservation;type:varchar(100)" json:"external_reference"`
+	BookingID         *uint     `gorm:"index" json:"booking_id,omitempty"`
+	ContentHash       string    `gorm:"type:varchar(64)" json:"content_hash"` // of the last delivery stored
+	Deliveries        int       `gorm:"default:0" json:"deliveries"`
+	LastReceivedAt    time.Time `json:"last_received_at"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (InboundReservation) TableName() string {
+	return "inbound_reservations"
+}
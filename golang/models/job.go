@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Job run triggers
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+)
+
+// Job run statuses
+const (
+	JobRunRunning   = "running"
+	JobRunSucceeded = "succeeded"
+	JobRunFailed    = "failed"
+)
+
+// JobRun records one run of a scheduled background job
+type JobRun struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Job        string     `gorm:"index:idx_job_run_job;type:varchar(100)" json:"job"`
+	Trigger    string     `gorm:"type:varchar(20)" json:"trigger"`
+	Instance   string     `gorm:"type:varchar(255)" json:"instance"` // replica that ran the job
+	Status     string     `gorm:"type:varchar(20)" json:"status"`
+	Error      string     `gorm:"type:text" json:"error,omitempty"`
+	StartedAt  time.Time  `gorm:"index:idx_job_run_job" json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	DurationMs int64      `json:"duration_ms"`
+}
+
+// TableName specifies the table name
+func (JobRun) TableName() string {
+	return "job_runs"
+}
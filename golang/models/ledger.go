@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+)
+
+// Ledger entry types
+const (
+	LedgerEntryBookingRevenue    = "booking_revenue"
+	LedgerEntryChannelCommission = "channel_commission"
+	LedgerEntryPlatformFee       = "platform_fee"
+)
+
+// Payout statement statuses
+const (
+	PayoutStatusPending  = "pending"
+	PayoutStatusExecuted = "executed"
+)
+
+// LedgerEntry represents a single signed money movement attributed to a property owner.
+// Revenue is positive; commissions and fees are negative.
+type LedgerEntry struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"index:idx_ledger_org_period" json:"organization_id"`
+	Period         string    `gorm:"index:idx_ledger_org_period;type:varchar(7)" json:"period"` // YYYY-MM
+	PropertyID     uint      `gorm:"index" json:"property_id"`
+	BookingID      uint      `gorm:"uniqueIndex:idx_ledger_booking_type" json:"booking_id"`
+	EntryType      string    `gorm:"uniqueIndex:idx_ledger_booking_type;type:varchar(30)" json:"entry_type"`
+	ChannelID      string    `json:"channel_id"`
+	Amount         float64   `json:"amount"`
+	Currency       string    `gorm:"type:varchar(3)" json:"currency"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (LedgerEntry) TableName() string {
+	return "ledger_entries"
+}
+
+// PayoutStatement summarizes what is owed to a property owner for a period
+type PayoutStatement struct {
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	OrganizationID     uint       `gorm:"uniqueIndex:idx_payout_org_period" json:"organization_id"`
+	Period             string     `gorm:"uniqueIndex:idx_payout_org_period;type:varchar(7)" json:"period"`
+	GrossRevenue       float64    `json:"gross_revenue"`
+	ChannelCommissions float64    `json:"channel_commissions"`
+	PlatformFees       float64    `json:"platform_fees"`
+	NetPayout          float64    `json:"net_payout"`
+	Currency           string     `gorm:"type:varchar(3)" json:"currency"`
+	BookingCount       int        `json:"booking_count"`
+	Status             string     `gorm:"index;default:pending" json:"status"`
+	PayoutReference    string     `json:"payout_reference"`
+	ExecutedAt         *time.Time `json:"executed_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+
+	// Relationship
+	Entries []LedgerEntry `gorm:"-" json:"entries,omitempty"`
+}
+
+// TableName specifies the table name
+func (PayoutStatement) TableName() string {
+	return "payout_statements"
+}
@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// Loyalty ledger entry types
+const (
+	LoyaltyAccrual    = "accrual"    // earned by a completed stay
+	LoyaltyRedemption = "redemption" // spent as credit on a booking
+	LoyaltyRefund     = "refund"     // returned when the booking they were spent on was cancelled
+	LoyaltyAdjustment = "adjustment" // granted or withdrawn by hand
+)
+
+// LoyaltyProgram holds the rules of guest loyalty points; there is one program, and
+// without one no points are earned or redeemed
+type LoyaltyProgram struct {
+	ID              uint      `gorm:"primaryKey" json:"-"`
+	Enabled         bool      `json:"enabled"`
+	PointsPerNight  float64   `json:"points_per_night"`
+	PointsPerAmount float64   `json:"points_per_amount"` // per unit of Currency paid for the stay
+	MinNights       int       `json:"min_nights"`        // shortest stay that earns points
+	DirectOnly      bool      `json:"direct_only"`       // channel bookings earn no points
+	PointValue      float64   `json:"point_value"`       // credit one point is worth, in Currency
+	Currency        string    `gorm:"type:varchar(3);default:USD" json:"currency"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (LoyaltyProgram) TableName() string {
+	return "loyalty_programs"
+}
+
+// PointsFor returns the points a completed booking earns
+func (p LoyaltyProgram) PointsFor(booking Booking) int {
+	if !p.Enabled || booking.Nights() < max(p.MinNights, 1) || (p.DirectOnly && booking.ChannelID != "") {
+		return 0
+	}
+	points := p.PointsPerNight * float64(booking.Nights())
+	if booking.Currency == p.Currency {
+		points += p.PointsPerAmount * booking.TotalPrice
+	}
+	return int(points)
+}
+
+// LoyaltyAccount holds a guest's points balance. Guests are identified by the blind
+// index of their email address, so the account holds no personal data of its own.
+type LoyaltyAccount struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	GuestHash string    `gorm:"uniqueIndex;type:varchar(64)" json:"-"`
+	Points    int       `json:"points"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (LoyaltyAccount) TableName() string {
+	return "loyalty_accounts"
+}
+
+// LoyaltyEntry is a change to a guest's points balance
+type LoyaltyEntry struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	GuestHash   string    `gorm:"index;type:varchar(64)" json:"-"`
+	BookingID   *uint     `gorm:"uniqueIndex:idx_loyalty_entry_booking" json:"booking_id,omitempty"`
+	Type        string    `gorm:"uniqueIndex:idx_loyalty_entry_booking;type:varchar(20)" json:"type"`
+	Points      int       `json:"points"` // negative when spent
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (LoyaltyEntry) TableName() string {
+	return "loyalty_entries"
+}
+
+// GuestLoyalty is a guest's points balance, the credit it is worth and recent changes
+type GuestLoyalty struct {
+	Points   int            `json:"points"`
+	Credit   float64        `json:"credit"`
+	Currency string         `json:"currency"`
+	Entries  []LoyaltyEntry `json:"entries"`
+}
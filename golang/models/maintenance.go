@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// MaintenanceMode describes an active maintenance window during which the API
+// answers 503 while background processing continues
+type MaintenanceMode struct {
+	Message           string     `json:"message"`
+	RetryAfterSeconds int        `json:"retry_after_seconds"`
+	StartedAt         time.Time  `json:"started_at"`
+	EndsAt            *time.Time `json:"ends_at,omitempty"` // maintenance ends by itself at this time
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/lib/pq"
@@ -31,11 +32,69 @@ type Property struct {
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Ownership; changes to a property with an owner are restricted to its owner's team
+	OrganizationID *uint `gorm:"index" json:"organization_id,omitempty"`
+	OwnerID        *uint `gorm:"index" json:"owner_id,omitempty"`
+
+	// Lifecycle; only active properties are searchable and open on channels.
+	// StatusReason explains the last change, e.g. why a property was suspended.
+	Status          string     `gorm:"type:varchar(20);default:active;index" json:"status"`
+	StatusReason    string     `json:"status_reason,omitempty"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
+
+	// Star classification
+	RatingID *uint `gorm:"index" json:"rating_id,omitempty"`
+
+	// Default rates used to materialize nightly pricing
+	BaseNightlyRate   float64 `json:"base_nightly_rate"`
+	WeekendMultiplier float64 `gorm:"default:1" json:"weekend_multiplier"` // applied to Friday and Saturday nights
+
+	// Nightly surcharges for guests beyond the base occupancy
+	OccupancyPricing `gorm:"embedded"`
+
+	// Nights closed after each checkout to prepare the property
+	TurnoverDays int `gorm:"default:0" json:"turnover_days"`
+
+	// Booking window: check-in at least MinAdvanceDays and at most MaxAdvanceDays
+	// from the booking day; 0 leaves the bound open
+	MinAdvanceDays int `gorm:"default:0" json:"min_advance_days"`
+	MaxAdvanceDays int `gorm:"default:0" json:"max_advance_days"`
+
+	// IANA time zone, e.g. Europe/Lisbon; check-in dates, nightly calendar days and
+	// "today" are all calendar days in this zone
+	Timezone string `gorm:"type:varchar(64);default:UTC" json:"timezone"`
+
+	// Arrival and departure times as HH:MM in the property's time zone: check-in from
+	// CheckinFrom until CheckinUntil ("" for no latest time), check-out until
+	// CheckoutUntil. With SameDayTurnover a stay may arrive on the day the previous one
+	// leaves; without it that night stays closed as well.
+	CheckinFrom     string `gorm:"type:varchar(5);default:15:00" json:"checkin_from"`
+	CheckinUntil    string `gorm:"type:varchar(5)" json:"checkin_until"`
+	CheckoutUntil   string `gorm:"type:varchar(5);default:11:00" json:"checkout_until"`
+	SameDayTurnover bool   `gorm:"default:true" json:"same_day_turnover"`
+
+	// Security deposit required of each stay, 0 for none; DepositMode is hold or charge
+	DepositAmount float64 `gorm:"default:0" json:"deposit_amount"`
+	DepositMode   string  `gorm:"type:varchar(10);default:hold" json:"deposit_mode"`
+
+	// Whether guests book instantly or send requests the host approves
+	BookingMode string `gorm:"type:varchar(10);default:instant" json:"booking_mode"`
+
+	// Localized content; Name, Description and HouseRules are in DefaultLocale
+	DefaultLocale string `gorm:"type:varchar(20);default:en" json:"default_locale"`
+	HouseRules    string `gorm:"type:text" json:"house_rules"`
+
+	// Structured house rules; nil until the property sets them
+	Rules *HouseRules `gorm:"serializer:json;type:jsonb" json:"rules"`
+
 	// Relationships
-	Amenities      []Amenity      `gorm:"many2many:property_amenities" json:"amenities"`
-	Conditions     []Condition    `gorm:"many2many:property_conditions" json:"conditions"`
-	Availabilities []Availability `gorm:"foreignKey:PropertyID" json:"availabilities,omitempty"`
-	Pricing        []Pricing      `gorm:"foreignKey:PropertyID" json:"pricing,omitempty"`
+	Amenities      []Amenity       `gorm:"many2many:property_amenities" json:"amenities"`
+	Conditions     []Condition     `gorm:"many2many:property_conditions" json:"conditions"`
+	Availabilities []Availability  `gorm:"foreignKey:PropertyID" json:"availabilities,omitempty"`
+	Pricing        []Pricing       `gorm:"foreignKey:PropertyID" json:"pricing,omitempty"`
+	Photos         []PropertyPhoto `gorm:"foreignKey:PropertyID" json:"photos"`
+	Organization   *Organization   `gorm:"foreignKey:OrganizationID" json:"-"`
+	StarRating     *PropertyRating `gorm:"foreignKey:RatingID" json:"star_rating,omitempty"`
 }
 
 // TableName specifies the table name
@@ -43,6 +102,66 @@ func (Property) TableName() string {
 	return "properties"
 }
 
+// TurnoverNights returns the nights closed after each checkout: the turnover days, and at
+// least the checkout night itself when same-day turnover is not allowed
+func (p Property) TurnoverNights() int {
+	if !p.SameDayTurnover && p.TurnoverDays < 1 {
+		return 1
+	}
+	return p.TurnoverDays
+}
+
+// RequiresApproval reports whether the property's bookings are requests awaiting the
+// host's approval
+func (p Property) RequiresApproval() bool {
+	return p.BookingMode == BookingModeRequest
+}
+
+// EffectiveRules returns the property's house rules, or DefaultHouseRules when unset
+func (p Property) EffectiveRules() HouseRules {
+	if p.Rules == nil {
+		return DefaultHouseRules
+	}
+	return *p.Rules
+}
+
+// CheckinTimes holds a property's arrival and departure times, as sent with quotes and
+// channel content
+type CheckinTimes struct {
+	Timezone        string `json:"timezone"`
+	CheckinFrom     string `json:"checkin_from"`
+	CheckinUntil    string `json:"checkin_until,omitempty"`
+	CheckoutUntil   string `json:"checkout_until"`
+	SameDayTurnover bool   `json:"same_day_turnover"`
+}
+
+// CheckinTimes returns the property's arrival and departure times
+func (p Property) CheckinTimes() CheckinTimes {
+	return CheckinTimes{
+		Timezone:        p.TimeLocation().String(),
+		CheckinFrom:     p.CheckinFrom,
+		CheckinUntil:    p.CheckinUntil,
+		CheckoutUntil:   p.CheckoutUntil,
+		SameDayTurnover: p.SameDayTurnover,
+	}
+}
+
+// BookingWindowViolation explains why a stay checking in on checkin cannot be booked
+// on today, the property's current calendar day, or returns "" when it can
+func (p Property) BookingWindowViolation(checkin, today time.Time) string {
+	daysAhead := int(checkin.Sub(today).Hours() / 24)
+	if daysAhead < 0 {
+		return "check-in has already passed in the property's time zone"
+	}
+	if p.MinAdvanceDays > 0 && daysAhead < p.MinAdvanceDays {
+		return fmt.Sprintf("check-in must be booked at least %d days ahead", p.MinAdvanceDays)
+	}
+	if p.MaxAdvanceDays > 0 && daysAhead > p.MaxAdvanceDays {
+		return fmt.Sprintf("check-in can be booked at most %d days ahead", p.MaxAdvanceDays)
+	}
+	return ""
+}
+
 // Amenity represents amenities like AC, WiFi, Pool, etc.
 type Amenity struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
@@ -103,6 +222,8 @@ type Availability struct {
 	Available  bool           `gorm:"index" json:"available"`
 	MinStay    int            `json:"min_stay"`
 	MaxGuests  int            `json:"max_guests"`
+	BookingID  *uint          `gorm:"index" json:"booking_id,omitempty"` // booking that closed the night, for its stay or turnover
+	BlockID    *uint          `gorm:"index" json:"block_id,omitempty"`   // calendar block that closed the night
 	CreatedAt  time.Time      `json:"created_at"`
 	UpdatedAt  time.Time      `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
@@ -139,26 +260,52 @@ func (Pricing) TableName() string {
 	return "pricing"
 }
 
+// BeforeSave keeps TotalPrice in sync with its components
+func (p *Pricing) BeforeSave(tx *gorm.DB) error {
+	p.TotalPrice = p.BasePrice + p.Taxes + p.Fees - p.Discount
+	return nil
+}
+
 // SearchFilter represents the search criteria for property search
 type SearchFilter struct {
-	Location        string        `json:"location"`
-	City            string        `json:"city"`
-	CheckinDate     time.Time     `json:"checkin_date"`
-	CheckoutDate    time.Time     `json:"checkout_date"`
-	NumberOfGuests  int           `json:"number_of_guests"`
-	PetFriendly     *bool         `json:"pet_friendly"`
-	SmokingFriendly *bool         `json:"smoking_friendly"`
-	AmenityIDs      pq.Int64Array `json:"amenity_ids"`
-	ConditionIDs    pq.Int64Array `json:"condition_ids"`
-	MinRating       float32       `json:"min_rating"`
-	MaxPrice        float64       `json:"max_price"`
-	MinPrice        float64       `json:"min_price"`
-	Latitude        *float64      `json:"latitude"`
-	Longitude       *float64      `json:"longitude"`
-	RadiusKm        float64       `json:"radius_km"`
-	SortBy          string        `json:"sort_by"` // price, rating, distance
-	Page            int           `json:"page"`
-	Limit           int           `json:"limit"`
+	Location          string        `json:"location" binding:"max=255"`
+	City              string        `json:"city" binding:"max=100"`
+	CheckinDate       time.Time     `json:"checkin_date" binding:"required_with=CheckoutDate,omitempty,notpast"`
+	CheckoutDate      time.Time     `json:"checkout_date" binding:"required_with=CheckinDate,omitempty,gtfield=CheckinDate"`
+	NumberOfGuests    int           `json:"number_of_guests" binding:"min=0,max=50"`
+	Children          int           `json:"children" binding:"min=0,max=50"` // of number_of_guests
+	Infants           int           `json:"infants" binding:"min=0,max=50"`  // of number_of_guests
+	PetFriendly       *bool         `json:"pet_friendly"`
+	SmokingFriendly   *bool         `json:"smoking_friendly"`
+	StarRatings       []int         `json:"star_ratings" binding:"max=5,dive,min=1,max=5"` // star classes, e.g. [3,4,5]
+	AmenityIDs        pq.Int64Array `json:"amenity_ids" binding:"max=50"`
+	MatchAllAmenities bool          `json:"match_all_amenities"` // require every amenity instead of any
+	ConditionIDs      pq.Int64Array `json:"condition_ids" binding:"max=50"`
+	MinRating         float32       `json:"min_rating" binding:"min=0,max=5"`
+	MaxPrice          float64       `json:"max_price" binding:"min=0,omitempty,gtefield=MinPrice"`
+	MinPrice          float64       `json:"min_price" binding:"min=0"`
+	Latitude          *float64      `json:"latitude" binding:"required_with=Longitude,omitempty,min=-90,max=90"`
+	Longitude         *float64      `json:"longitude" binding:"required_with=Latitude,omitempty,min=-180,max=180"`
+	RadiusKm          float64       `json:"radius_km" binding:"min=0,max=500"`
+	UserID            string        `json:"user_id" binding:"required_if=OnlyFavorites true,max=100"`
+	OnlyFavorites     bool          `json:"only_favorites"` // restrict to the favorites of user_id
+	SortBy            string        `json:"sort_by" binding:"omitempty,oneof=price rating distance relevance"`
+	Page              int           `json:"page" binding:"min=0"`
+	Limit             int           `json:"limit" binding:"min=0"` // 0 selects the default page size; the maximum is configurable
+
+	// Minimum trigram similarity for fuzzy location and city matches; set from configuration
+	LocationSimilarity float64 `json:"-"`
+
+	// Favorite property IDs of UserID, resolved when OnlyFavorites is set
+	FavoriteIDs []uint `json:"-"`
+}
+
+// Nights returns the number of nights of the searched stay, 0 without dates
+func (s SearchFilter) Nights() int {
+	if s.CheckinDate.IsZero() || !s.CheckoutDate.After(s.CheckinDate) {
+		return 0
+	}
+	return int(s.CheckoutDate.Sub(s.CheckinDate).Hours() / 24)
 }
 
 // Scan implements the sql.Scanner interface
@@ -186,6 +333,7 @@ type SearchResult struct {
 	Country       string   `json:"country"`
 	Rating        float32  `json:"rating"`
 	ReviewCount   int      `json:"review_count"`
+	Stars         int      `json:"stars,omitempty"` // star class, 0 when unclassified
 	MaxGuests     int      `json:"max_guests"`
 	Bedrooms      int      `json:"bedrooms"`
 	Bathrooms     int      `json:"bathrooms"`
@@ -195,15 +343,63 @@ type SearchResult struct {
 	Conditions    []string `json:"conditions"`
 	Distance      *float64 `json:"distance,omitempty"`
 	Available     bool     `json:"available"`
+
+	// Itemized price for the requested stay
+	Discount       float64         `json:"discount"`
+	ExtraGuestFees float64         `json:"extra_guest_fees"`
+	PriceBreakdown []PriceLineItem `json:"price_breakdown,omitempty"`
+
+	// Locale of Name and Description
+	Locale string `json:"locale,omitempty"`
+
+	// Composite score between 0 and 1, set when sorting by relevance
+	RelevanceScore *float64 `json:"relevance_score,omitempty"`
+
+	// Trigram similarity between 0 and 1 of the location or city to the searched text,
+	// set when searching by location or city
+	MatchScore *float64 `json:"match_score,omitempty"`
 }
 
-// PropertyAvailabilityCache represents cached availability data in Redis
-type PropertyAvailabilityCache struct {
-	PropertyID uint      `json:"property_id"`
-	Available  bool      `json:"available"`
-	MinStay    int       `json:"min_stay"`
-	MaxGuests  int       `json:"max_guests"`
-	UpdatedAt  time.Time `json:"updated_at"`
+// Search exclusion reasons
+const (
+	ExclusionMinStay = "min_stay" // the stay is shorter than the arrival night's minimum stay
+)
+
+// SearchExclusion explains why a property matching the other filters of a search was
+// left out of its results
+type SearchExclusion struct {
+	PropertyID uint                   `json:"property_id"`
+	Reason     string                 `json:"reason"`
+	Message    string                 `json:"message"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+}
+
+// SearchExplanation describes how an explained search was evaluated
+type SearchExplanation struct {
+	SQL        string                 `json:"sql"`     // page query with its values inlined
+	Filters    []string               `json:"filters"` // applied, in order
+	Candidates []CandidateExplanation `json:"candidates"`
+}
+
+// CandidateExplanation tells whether a property matched an explained search and, when
+// it did not, which filters it failed
+type CandidateExplanation struct {
+	PropertyID        uint     `json:"property_id"`
+	Name              string   `json:"name"`
+	Included          bool     `json:"included"`
+	FailedFilters     []string `json:"failed_filters,omitempty"`
+	UnavailableNights []string `json:"unavailable_nights,omitempty"`  // closed or without an availability row
+	MinStay           int      `json:"min_stay,omitempty"`            // of the arrival night
+	PricesOutOfRange  []string `json:"prices_out_of_range,omitempty"` // nights priced outside min_price..max_price
+}
+
+// PriceRange bounds the nightly prices of a search's matching properties, for price
+// sliders; Properties is the number of priced properties aggregated
+type PriceRange struct {
+	Min        float64 `json:"min"`
+	Max        float64 `json:"max"`
+	Average    float64 `json:"average"`
+	Properties int64   `json:"properties"`
 }
 
 // SearchResultsCache represents cached search results in Redis
@@ -212,10 +408,34 @@ type SearchResultsCache struct {
 	Total     int            `json:"total"`
 	Page      int            `json:"page"`
 	Limit     int            `json:"limit"`
+	Prices    *PriceRange    `json:"price_range"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	ExpiresAt time.Time      `json:"expires_at"`
+
+	// How a relaxed search loosened the filters sent to find these results
+	Relaxed []SearchRelaxation `json:"relaxed,omitempty"`
 }
 
+// SearchRelaxation is one filter a relaxed search loosened; To is nil when the filter
+// was dropped
+type SearchRelaxation struct {
+	Filter string      `json:"filter"`
+	From   interface{} `json:"from"`
+	To     interface{} `json:"to"`
+}
+
+// Sources of change events. Booking and block sources carry the ID of the booking or
+// block, e.g. booking:42.
+const (
+	EventSourceARI            = "ari"             // PUT /properties/:id/ari
+	EventSourceBatch          = "batch"           // POST /batch
+	EventSourceOTA            = "ota"             // OpenTravel notification from a PMS
+	EventSourceCalendarImport = "calendar_import" // calendar spreadsheet upload
+	EventSourceRates          = "rates"           // pricing materialized from the property's rates and seasons
+	EventSourceBooking        = "booking"         // nights closed or reopened for a booking
+	EventSourceBlock          = "block"           // nights closed or reopened for a calendar block
+)
+
 // Event represents database change events for cache invalidation
 type Event struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
@@ -225,9 +445,11 @@ type Event struct {
 	Data      datatypes.JSON `json:"data"`
 	CreatedAt time.Time      `json:"created_at"`
 	Processed bool           `gorm:"index" json:"processed"`
-}
 
-// TableName specifies the table name
-func (Event) TableName() string {
-	return "events"
+	// What made the change, e.g. ari or booking:42; see the EventSource constants
+	Source string `gorm:"type:varchar(100)" json:"source,omitempty"`
+
+	// Claim by the replica processing the event; expired claims are taken over
+	ClaimedBy    string     `gorm:"type:varchar(255)" json:"-"`
+	ClaimedUntil *time.Time `json:"-"`
 }
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Host notification types
+const (
+	NotificationReservationModified  = "reservation_modified"
+	NotificationReservationCancelled = "reservation_cancelled"
+)
+
+// HostNotification tells a property's host about a change made without them, such as a
+// channel modifying or cancelling a reservation
+type HostNotification struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	PropertyID uint       `gorm:"index:idx_host_notification_property" json:"property_id"`
+	BookingID  *uint      `gorm:"index" json:"booking_id,omitempty"`
+	ChannelID  string     `gorm:"type:varchar(50)" json:"channel_id,omitempty"`
+	Type       string     `gorm:"type:varchar(40)" json:"type"`
+	Message    string     `json:"message"`
+	ReadAt     *time.Time `json:"read_at"`
+	CreatedAt  time.Time  `gorm:"index:idx_host_notification_property" json:"created_at"`
+}
+
+// TableName specifies the table name
+func (HostNotification) TableName() string {
+	return "host_notifications"
+}
@@ -0,0 +1,29 @@
+package models
+
+// OccupancyPricing charges guests beyond a property's base occupancy per night. Adults
+// fill the base occupancy first, then children; infants are free and do not count
+// towards it.
+type OccupancyPricing struct {
+	BaseOccupancy int     `gorm:"default:0" json:"base_occupancy"` // guests included in the nightly rate, 0 for all
+	ExtraAdultFee float64 `gorm:"default:0" json:"extra_adult_fee"`
+	ExtraChildFee float64 `gorm:"default:0" json:"extra_child_fee"`
+}
+
+// Occupancy describes who is staying: Guests in total, of whom Children are children
+// and Infants are infants
+type Occupancy struct {
+	Guests   int
+	Children int
+	Infants  int
+}
+
+// ExtraGuests returns the adults and children beyond the base occupancy
+func (p OccupancyPricing) ExtraGuests(occupancy Occupancy) (adults, children int) {
+	if p.BaseOccupancy <= 0 {
+		return 0, 0
+	}
+	adultCount := max(occupancy.Guests-occupancy.Children-occupancy.Infants, 0)
+	adults = max(adultCount-p.BaseOccupancy, 0)
+	children = max(occupancy.Children-max(p.BaseOccupancy-adultCount, 0), 0)
+	return adults, children
+}
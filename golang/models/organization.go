@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Organization represents a host company or property manager owning properties
+type Organization struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Name      string         `gorm:"type:varchar(200)" json:"name"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Seller details printed on invoices and receipts
+	SellerLegalName    string `json:"seller_legal_name"`
+	SellerTaxID        string `json:"seller_tax_id"`
+	SellerAddress      string `json:"seller_address"`
+	SellerEmail        string `json:"seller_email"`
+	SellerPhone        string `json:"seller_phone"`
+	InvoicePrefix      string `gorm:"type:varchar(20);default:INV" json:"invoice_prefix"`
+	InvoiceFooterNotes string `json:"invoice_footer_notes"`
+}
+
+// TableName specifies the table name
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// SellerDetails represents the seller block printed on invoices
+type SellerDetails struct {
+	SellerLegalName    string `json:"seller_legal_name" binding:"max=255"`
+	SellerTaxID        string `json:"seller_tax_id" binding:"max=50"`
+	SellerAddress      string `json:"seller_address" binding:"max=500"`
+	SellerEmail        string `json:"seller_email" binding:"omitempty,email"`
+	SellerPhone        string `json:"seller_phone" binding:"max=50"`
+	InvoicePrefix      string `json:"invoice_prefix" binding:"max=10"`
+	InvoiceFooterNotes string `json:"invoice_footer_notes" binding:"max=1000"`
+}
+
+// Seller returns the seller details of the organization
+func (o Organization) Seller() SellerDetails {
+	return SellerDetails{
+		SellerLegalName:    o.SellerLegalName,
+		SellerTaxID:        o.SellerTaxID,
+		SellerAddress:      o.SellerAddress,
+		SellerEmail:        o.SellerEmail,
+		SellerPhone:        o.SellerPhone,
+		InvoicePrefix:      o.InvoicePrefix,
+		InvoiceFooterNotes: o.InvoiceFooterNotes,
+	}
+}
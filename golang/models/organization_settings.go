@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+// Cancellation policies offered to guests
+const (
+	CancellationFlexible      = "flexible"
+	CancellationModerate      = "moderate"
+	CancellationStrict        = "strict"
+	CancellationNonRefundable = "non_refundable"
+)
+
+// cancellationTerms describes each cancellation policy to guests
+var cancellationTerms = map[string]string{
+	CancellationFlexible:      "Full refund for cancellations up to 1 day before check-in",
+	CancellationModerate:      "Full refund for cancellations up to 5 days before check-in",
+	CancellationStrict:        "50% refund for cancellations up to 7 days before check-in",
+	CancellationNonRefundable: "No refund after booking",
+}
+
+// CancellationTerms describes a cancellation policy to guests, or returns "" for an
+// unknown policy
+func CancellationTerms(policy string) string {
+	return cancellationTerms[policy]
+}
+
+// NotificationPreferences selects the host notifications recorded for an organization's
+// properties
+type NotificationPreferences struct {
+	ReservationModified  bool `json:"reservation_modified"`
+	ReservationCancelled bool `json:"reservation_cancelled"`
+}
+
+// Allows reports whether host notifications of a type are wanted
+func (p NotificationPreferences) Allows(notificationType string) bool {
+	switch notificationType {
+	case NotificationReservationModified:
+		return p.ReservationModified
+	case NotificationReservationCancelled:
+		return p.ReservationCancelled
+	}
+	return true
+}
+
+// InvoiceBranding styles an organization's invoices and receipts
+type InvoiceBranding struct {
+	BrandName   string `gorm:"type:varchar(100)" json:"brand_name" binding:"max=100"`
+	AccentColor string `gorm:"type:varchar(7)" json:"accent_color" binding:"omitempty,len=7,hexcolor"` // #RRGGBB of the title and headings
+	Website     string `gorm:"type:varchar(255)" json:"website" binding:"omitempty,url,max=255"`
+}
+
+// OrganizationSettings holds the defaults an organization applies to its properties'
+// quotes, bookings, invoices and notifications
+type OrganizationSettings struct {
+	OrganizationID     uint                    `gorm:"primaryKey;autoIncrement:false" json:"organization_id"`
+	DefaultCurrency    string                  `gorm:"type:varchar(3)" json:"default_currency"`
+	CancellationPolicy string                  `gorm:"type:varchar(20)" json:"cancellation_policy"`
+	Notifications      NotificationPreferences `gorm:"embedded;embeddedPrefix:notify_" json:"notifications"`
+	Branding           InvoiceBranding         `gorm:"embedded;embeddedPrefix:invoice_" json:"invoice_branding"`
+	UpdatedAt          time.Time               `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (OrganizationSettings) TableName() string {
+	return "organization_settings"
+}
+
+// DefaultOrganizationSettings returns the settings of organizations that saved none,
+// which also apply to properties without an organization
+func DefaultOrganizationSettings(organizationID uint) OrganizationSettings {
+	return OrganizationSettings{
+		OrganizationID:     organizationID,
+		DefaultCurrency:    "USD",
+		CancellationPolicy: CancellationModerate,
+		Notifications: NotificationPreferences{
+			ReservationModified:  true,
+			ReservationCancelled: true,
+		},
+	}
+}
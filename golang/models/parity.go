@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Rate parity severities
+const (
+	ParitySeverityMinor    = "minor"
+	ParitySeverityMajor    = "major"
+	ParitySeverityCritical = "critical"
+)
+
+// ChannelRate records a nightly rate as it was pushed to a channel
+type ChannelRate struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ChannelID  string    `gorm:"index:idx_channel_rate_lookup;type:varchar(50)" json:"channel_id"`
+	PropertyID uint      `gorm:"index:idx_channel_rate_lookup" json:"property_id"`
+	Date       time.Time `gorm:"index:idx_channel_rate_lookup;type:date" json:"date"`
+	Rate       float64   `json:"rate"`
+	Currency   string    `gorm:"type:varchar(3)" json:"currency"`
+	PushedAt   time.Time `gorm:"index" json:"pushed_at"`
+}
+
+// TableName specifies the table name
+func (ChannelRate) TableName() string {
+	return "channel_rates"
+}
+
+// RateParityViolation records a channel rate that deviates from the expected rate
+type RateParityViolation struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	ChannelID         string     `gorm:"uniqueIndex:idx_parity_channel_property_date;type:varchar(50)" json:"channel_id"`
+	PropertyID        uint       `gorm:"uniqueIndex:idx_parity_channel_property_date" json:"property_id"`
+	Date              time.Time  `gorm:"uniqueIndex:idx_parity_channel_property_date;type:date" json:"date"`
+	ExpectedRate      float64    `json:"expected_rate"`
+	ChannelRate       float64    `json:"channel_rate"`
+	DifferencePercent float64    `json:"difference_percent"`
+	Severity          string     `gorm:"index;type:varchar(20)" json:"severity"`
+	DetectedAt        time.Time  `json:"detected_at"`
+	LastCheckedAt     time.Time  `json:"last_checked_at"`
+	ResolvedAt        *time.Time `gorm:"index" json:"resolved_at"`
+}
+
+// TableName specifies the table name
+func (RateParityViolation) TableName() string {
+	return "rate_parity_violations"
+}
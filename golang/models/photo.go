@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// PropertyPhoto is a photo of a property, shown in ascending SortOrder
+type PropertyPhoto struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	PropertyID uint      `gorm:"index" json:"property_id"`
+	URL        string    `gorm:"type:varchar(1024)" json:"url"`
+	Caption    string    `json:"caption"`
+	SortOrder  int       `json:"sort_order"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (PropertyPhoto) TableName() string {
+	return "property_photos"
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Point of interest types
+const (
+	POITypeBeach      = "beach"
+	POITypeAirport    = "airport"
+	POITypeAttraction = "attraction"
+)
+
+// PointOfInterest is a place guests look for near a property, such as a beach, an
+// airport or an attraction
+type PointOfInterest struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Name      string         `json:"name"`
+	Type      string         `gorm:"type:varchar(20);index" json:"type"`
+	City      string         `gorm:"type:varchar(100);index" json:"city,omitempty"`
+	Country   string         `gorm:"type:varchar(100)" json:"country,omitempty"`
+	Latitude  float64        `json:"latitude"`
+	Longitude float64        `json:"longitude"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName specifies the table name
+func (PointOfInterest) TableName() string {
+	return "points_of_interest"
+}
+
+// NearbyPointOfInterest is a point of interest with its distance from a property
+type NearbyPointOfInterest struct {
+	ID         uint    `json:"id"`
+	Name       string  `json:"name"`
+	Type       string  `json:"type"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	DistanceKm float64 `json:"distance_km"` // great-circle distance
+}
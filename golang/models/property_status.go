@@ -0,0 +1,35 @@
+package models
+
+// Property statuses
+const (
+	PropertyStatusDraft         = "draft"          // being set up by its host
+	PropertyStatusPendingReview = "pending_review" // submitted, awaiting an admin's approval
+	PropertyStatusActive        = "active"         // listed, searchable and open on channels
+	PropertyStatusSuspended     = "suspended"      // taken down by an admin
+	PropertyStatusArchived      = "archived"       // retired by its host
+)
+
+// propertyTransitions lists the statuses each status can move to
+var propertyTransitions = map[string][]string{
+	PropertyStatusDraft:         {PropertyStatusPendingReview, PropertyStatusArchived},
+	PropertyStatusPendingReview: {PropertyStatusActive, PropertyStatusDraft, PropertyStatusArchived},
+	PropertyStatusActive:        {PropertyStatusSuspended, PropertyStatusArchived},
+	PropertyStatusSuspended:     {PropertyStatusActive, PropertyStatusArchived},
+	PropertyStatusArchived:      {PropertyStatusDraft},
+}
+
+// CanTransitionProperty reports whether a property can move from one status to another
+func CanTransitionProperty(from, to string) bool {
+	for _, status := range propertyTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActive reports whether the property is listed: searchable, bookable and open on its
+// channels
+func (p Property) IsActive() bool {
+	return p.Status == PropertyStatusActive
+}
@@ -0,0 +1,11 @@
+package models
+
+// SimilarProperty is a property recommended alongside another one
+type SimilarProperty struct {
+	PropertyID      uint     `json:"property_id"`
+	Score           float64  `json:"score"`                 // between 0 and 1
+	DistanceKm      *float64 `json:"distance_km,omitempty"` // unset when the property has no coordinates
+	SharedAmenities int      `json:"shared_amenities"`
+
+	Property *Property `json:"property,omitempty"`
+}
@@ -0,0 +1,31 @@
+package models
+
+// RedisMemoryReport describes Redis's memory limit and eviction policy and estimates the
+// memory used by each type of key
+type RedisMemoryReport struct {
+	UsedBytes int64   `json:"used_bytes"`
+	MaxBytes  int64   `json:"max_bytes"` // 0 when maxmemory is not set
+	Policy    string  `json:"policy"`    // maxmemory-policy
+	UsedRatio float64 `json:"used_ratio"`
+
+	// Estimates from the MEMORY USAGE of randomly drawn keys, largest first
+	Keys        int64           `json:"keys"`
+	SampledKeys int             `json:"sampled_keys"`
+	KeyTypes    []KeyTypeMemory `json:"key_types"`
+
+	// Size of the cached search results and the cap they are held to, 0 for none
+	SearchCacheBytes    int64 `json:"search_cache_bytes"`
+	SearchCacheMaxBytes int64 `json:"search_cache_max_bytes"`
+
+	Warnings []string `json:"warnings"`
+}
+
+// KeyTypeMemory estimates the keys of one type, named by the prefix before their first
+// colon, and the memory they use
+type KeyTypeMemory struct {
+	Type           string `json:"type"`
+	SampledKeys    int    `json:"sampled_keys"`
+	AverageBytes   int64  `json:"average_bytes"`
+	EstimatedKeys  int64  `json:"estimated_keys"`
+	EstimatedBytes int64  `json:"estimated_bytes"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// CachedResponse is a stored GET response served again until its tag is invalidated
+type CachedResponse struct {
+	StatusCode  int       `json:"status_code"`
+	ContentType string    `json:"content_type"`
+	Disposition string    `json:"disposition,omitempty"` // Content-Disposition of downloads
+	ETag        string    `json:"etag"`
+	Body        []byte    `json:"body"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Resync job statuses
+const (
+	ResyncStatusQueued    = "queued"
+	ResyncStatusRunning   = "running"
+	ResyncStatusCompleted = "completed"
+	ResyncStatusFailed    = "failed" // at least one property failed to push
+)
+
+// ResyncJob tracks a full ARI and content push of a channel's mapped properties
+type ResyncJob struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	ChannelID  string     `gorm:"index;type:varchar(50)" json:"channel_id"`
+	PropertyID *uint      `json:"property_id,omitempty"` // set when only one property is resynced
+	Status     string     `gorm:"type:varchar(20)" json:"status"`
+	Total      int        `json:"total"` // mapped properties to push
+	Completed  int        `json:"completed"`
+	Failed     int        `json:"failed"`
+	LastError  string     `gorm:"type:text" json:"last_error,omitempty"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (ResyncJob) TableName() string {
+	return "resync_jobs"
+}
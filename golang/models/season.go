@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Season represents a named date range with a price multiplier for a property,
+// e.g. "Summer peak" at 1.3x the base nightly rate
+type Season struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	PropertyID uint           `gorm:"index:idx_season_property_dates" json:"property_id"`
+	Name       string         `json:"name"`
+	StartDate  time.Time      `gorm:"index:idx_season_property_dates;type:date" json:"start_date"`
+	EndDate    time.Time      `gorm:"index:idx_season_property_dates;type:date" json:"end_date"` // inclusive
+	Multiplier float64        `json:"multiplier"`
+	Priority   int            `json:"priority"` // higher wins when seasons overlap
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationship
+	Property *Property `gorm:"foreignKey:PropertyID" json:"-"`
+}
+
+// TableName specifies the table name
+func (Season) TableName() string {
+	return "seasons"
+}
+
+// Covers reports whether the season includes the given night
+func (s Season) Covers(date time.Time) bool {
+	day := date.Format("2006-01-02")
+	return day >= s.StartDate.Format("2006-01-02") && day <= s.EndDate.Format("2006-01-02")
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// PropertyStatDay holds the views and search impressions a property had on one day,
+// flushed from the Redis counters
+type PropertyStatDay struct {
+	ID          uint      `gorm:"primaryKey" json:"-"`
+	PropertyID  uint      `gorm:"uniqueIndex:idx_property_stat_day" json:"property_id"`
+	Date        time.Time `gorm:"uniqueIndex:idx_property_stat_day;type:date" json:"date"`
+	Views       int64     `gorm:"default:0" json:"views"`
+	Impressions int64     `gorm:"default:0" json:"impressions"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (PropertyStatDay) TableName() string {
+	return "property_stat_days"
+}
+
+// PropertyStatsPoint holds the statistics of one day of a period
+type PropertyStatsPoint struct {
+	Date        string `json:"date"`
+	Views       int64  `json:"views"`
+	Impressions int64  `json:"impressions"`
+	Bookings    int64  `json:"bookings"`
+}
+
+// PropertyStats represents how guests found and booked a property over a period
+type PropertyStats struct {
+	PropertyID     uint    `json:"property_id"`
+	StartDate      string  `json:"start_date"`
+	EndDate        string  `json:"end_date"`
+	Views          int64   `json:"views"`
+	Impressions    int64   `json:"impressions"`
+	Bookings       int64   `json:"bookings"`
+	ClickThrough   float64 `json:"click_through_rate"` // views / impressions
+	ConversionRate float64 `json:"conversion_rate"`    // bookings / views
+
+	// Change of views and bookings against the period of the same length before
+	ViewsChange    *float64 `json:"views_change"`
+	BookingsChange *float64 `json:"bookings_change"`
+
+	Trend       []PropertyStatsPoint `json:"trend"` // one point per day
+	GeneratedAt time.Time            `json:"generated_at"`
+}
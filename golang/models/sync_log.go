@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// Sync directions
+const (
+	SyncOutbound = "outbound" // pushed to a channel
+	SyncInbound  = "inbound"  // received from a channel
+)
+
+// Sync operations
+const (
+	SyncOperationARI         = "ari"         // availability, rates and inventory
+	SyncOperationContent     = "content"     // descriptive content
+	SyncOperationReservation = "reservation" // a booking reported by the channel
+	SyncOperationNoShow      = "no_show"     // a guest who never arrived, reported to the channel
+)
+
+// Sync results
+const (
+	SyncResultSuccess = "success"
+	SyncResultFailure = "failure"
+)
+
+// Sync error codes, translated from each channel's own error codes
+const (
+	SyncErrorAuthentication = "authentication" // credentials missing, wrong or not allowed for the property
+	SyncErrorMapping        = "mapping"        // external property, room or rate plan unknown to the channel
+	SyncErrorValidation     = "validation"     // payload rejected as malformed or out of range
+	SyncErrorRejected       = "rejected"       // payload valid but refused by a channel business rule
+	SyncErrorRateLimited    = "rate_limited"   // too many requests; retry later
+	SyncErrorUnavailable    = "unavailable"    // channel failed or could not be reached; retry later
+)
+
+// SyncLog records one exchange with a channel
+type SyncLog struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ChannelID  string    `gorm:"index:idx_sync_log_channel;type:varchar(50)" json:"channel_id"`
+	PropertyID uint      `gorm:"index" json:"property_id"`
+	Direction  string    `gorm:"type:varchar(10)" json:"direction"`
+	Operation  string    `gorm:"type:varchar(20)" json:"operation"`
+	Summary    string    `json:"summary"` // what was exchanged, e.g. "30 nights 2026-01-01..2026-01-30"
+	Items      int       `json:"items"`
+	DurationMs int64     `json:"duration_ms"`
+	Result     string    `gorm:"type:varchar(10)" json:"result"`
+	Error      string    `gorm:"type:text" json:"error,omitempty"`
+	ErrorCode  string    `gorm:"type:varchar(30)" json:"error_code,omitempty"` // one of the sync error codes, when the channel reported one
+	CreatedAt  time.Time `gorm:"index:idx_sync_log_channel" json:"created_at"`
+}
+
+// TableName specifies the table name
+func (SyncLog) TableName() string {
+	return "sync_logs"
+}
+
+// SyncOperationStatus summarizes the exchanges of one direction and operation
+type SyncOperationStatus struct {
+	Direction     string     `json:"direction"`
+	Operation     string     `json:"operation"`
+	LastSuccessAt *time.Time `json:"last_success_at"`
+	LastFailureAt *time.Time `json:"last_failure_at"`
+	Successes24h  int        `json:"successes_24h"`
+	Failures24h   int        `json:"failures_24h"`
+}
+
+// PendingDeltas counts the upcoming availability and pricing rows of a mapped property
+// changed since its last successful ARI push
+type PendingDeltas struct {
+	PropertyID   uint `json:"property_id"`
+	Availability int  `json:"availability"`
+	Pricing      int  `json:"pricing"`
+}
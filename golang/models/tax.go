@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tax rule kinds
+const (
+	TaxKindPercentage = "percentage" // percentage of the discounted accommodation price
+	TaxKindPerNight   = "per_night"  // flat amount per night
+)
+
+// TaxRule represents a tax levied in a jurisdiction. Empty State or City match any value,
+// so a rule can apply country-wide, state-wide or to a single city.
+type TaxRule struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Name      string         `json:"name"` // e.g. "NYC Hotel Room Occupancy Tax"
+	Country   string         `gorm:"index:idx_tax_jurisdiction;type:varchar(100)" json:"country"`
+	State     string         `gorm:"index:idx_tax_jurisdiction;type:varchar(100)" json:"state"`
+	City      string         `gorm:"index:idx_tax_jurisdiction;type:varchar(100)" json:"city"`
+	TaxType   string         `gorm:"type:varchar(50)" json:"tax_type"` // e.g. "vat", "sales", "occupancy", "tourist"
+	Kind      string         `gorm:"type:varchar(20)" json:"kind"`
+	Rate      float64        `json:"rate"` // percent for percentage rules, amount for per-night rules
+	Active    bool           `gorm:"default:true" json:"active"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName specifies the table name
+func (TaxRule) TableName() string {
+	return "tax_rules"
+}
+
+// Specificity ranks how narrowly a rule targets a location: city > state > country
+func (t TaxRule) Specificity() int {
+	specificity := 0
+	if t.State != "" {
+		specificity++
+	}
+	if t.City != "" {
+		specificity += 2
+	}
+	return specificity
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// Permissions a team member can be granted on a property
+const (
+	PermissionManageRates    = "manage_rates"    // rates, seasons, discounts, fees and deposits
+	PermissionManageCalendar = "manage_calendar" // availability, blocks and stay rules
+	PermissionViewOnly       = "view_only"       // analytics, statistics and notifications
+
+	// PermissionOwner is required for changes only the owner may make, such as content,
+	// listing status and team management; it cannot be granted
+	PermissionOwner = "owner"
+)
+
+// Team member statuses
+const (
+	TeamMemberInvited = "invited"
+	TeamMemberActive  = "active"
+	TeamMemberRevoked = "revoked"
+)
+
+// Owner is a host or agency owning properties. Once a property has an owner, changes to
+// it require the owner's API token, a team member's token granting the change, or an
+// admin API key.
+type Owner struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Name      string         `gorm:"type:varchar(200)" json:"name"`
+	Email     string         `gorm:"uniqueIndex;type:varchar(255)" json:"email"`
+	TokenHash string         `gorm:"uniqueIndex;type:varchar(64)" json:"-"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName specifies the table name
+func (Owner) TableName() string {
+	return "owners"
+}
+
+// TeamMember is someone an owner invited to manage some of its properties. Invited
+// members accept with the invitation token and receive their own API token.
+type TeamMember struct {
+	ID                  uint       `gorm:"primaryKey" json:"id"`
+	OwnerID             uint       `gorm:"uniqueIndex:idx_team_member_email" json:"owner_id"`
+	Email               string     `gorm:"uniqueIndex:idx_team_member_email;type:varchar(255)" json:"email"`
+	Name                string     `gorm:"type:varchar(200)" json:"name"`
+	Status              string     `gorm:"type:varchar(20);default:invited;index" json:"status"`
+	TokenHash           string     `gorm:"index;type:varchar(64)" json:"-"`
+	InvitationHash      string     `gorm:"index;type:varchar(64)" json:"-"`
+	InvitationExpiresAt *time.Time `json:"invitation_expires_at,omitempty"`
+	AcceptedAt          *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt           *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+
+	Grants []TeamGrant `gorm:"foreignKey:TeamMemberID" json:"grants"`
+}
+
+// TableName specifies the table name
+func (TeamMember) TableName() string {
+	return "team_members"
+}
+
+// TeamGrant holds the permissions of a team member on one property
+type TeamGrant struct {
+	ID           uint           `gorm:"primaryKey" json:"-"`
+	TeamMemberID uint           `gorm:"uniqueIndex:idx_team_grant" json:"-"`
+	PropertyID   uint           `gorm:"uniqueIndex:idx_team_grant;index" json:"property_id"`
+	Permissions  pq.StringArray `gorm:"type:text[]" json:"permissions"`
+}
+
+// TableName specifies the table name
+func (TeamGrant) TableName() string {
+	return "team_grants"
+}
+
+// Allows reports whether the grant permits what permission requires. Any grant allows
+// viewing, and no grant allows what only owners may do.
+func (g TeamGrant) Allows(permission string) bool {
+	if permission == PermissionViewOnly {
+		return true
+	}
+	for _, granted := range g.Permissions {
+		if granted == permission && granted != PermissionViewOnly {
+			return true
+		}
+	}
+	return false
+}
+
+// TeamTokenHash returns the hash API and invitation tokens are stored and looked up by
+func TeamTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
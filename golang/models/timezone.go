@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// DefaultTimezone is the time zone of properties that have none set
+const DefaultTimezone = "UTC"
+
+// earliestZone is the time zone where each calendar day begins last (UTC-12)
+var earliestZone = time.FixedZone("UTC-12", -12*60*60)
+
+// LocalDate returns the calendar day t falls on in loc, as midnight UTC like the values
+// of date columns and parsed YYYY-MM-DD dates
+func LocalDate(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// EarliestToday returns the earliest calendar day that is still today somewhere. Windows
+// spanning properties in several time zones start here so no property's today is missed.
+func EarliestToday() time.Time {
+	return LocalDate(time.Now(), earliestZone)
+}
+
+// TimeLocation returns the property's time zone, UTC when unset or unknown
+func (p Property) TimeLocation() *time.Location {
+	if p.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Today returns the current calendar day at the property
+func (p Property) Today() time.Time {
+	return LocalDate(time.Now(), p.TimeLocation())
+}
+
+// StartOfDay returns the instant the calendar day date begins at the property
+func (p Property) StartOfDay(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, p.TimeLocation())
+}
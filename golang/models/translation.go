@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// PropertyTranslation holds a property's descriptive content in one locale
+type PropertyTranslation struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	PropertyID  uint      `gorm:"uniqueIndex:idx_property_translation" json:"property_id"`
+	Locale      string    `gorm:"uniqueIndex:idx_property_translation;type:varchar(20)" json:"locale"` // normalized BCP 47 tag, e.g. "fr" or "pt-br"
+	Name        string    `json:"name"`
+	Description string    `gorm:"type:text" json:"description"`
+	HouseRules  string    `gorm:"type:text" json:"house_rules"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (PropertyTranslation) TableName() string {
+	return "property_translations"
+}
+
+// Reference data with translated names
+const (
+	ReferenceAmenity   = "amenity"
+	ReferenceCondition = "condition"
+)
+
+// ReferenceTranslation holds the name of an amenity or condition in one locale
+type ReferenceTranslation struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Kind        string    `gorm:"uniqueIndex:idx_reference_translation;type:varchar(20)" json:"kind"` // ReferenceAmenity or ReferenceCondition
+	ReferenceID uint      `gorm:"uniqueIndex:idx_reference_translation" json:"reference_id"`
+	Locale      string    `gorm:"uniqueIndex:idx_reference_translation;index;type:varchar(20)" json:"locale"` // normalized BCP 47 tag
+	Name        string    `gorm:"type:varchar(100)" json:"name"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (ReferenceTranslation) TableName() string {
+	return "reference_translations"
+}
@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// Voucher statuses
+const (
+	VoucherStatusActive = "active"
+	VoucherStatusVoid   = "void" // withdrawn, e.g. when its code leaked
+)
+
+// Voucher ledger entry types
+const (
+	VoucherIssue      = "issue"      // value the voucher was issued with
+	VoucherRedemption = "redemption" // spent on a booking
+	VoucherRefund     = "refund"     // returned when the booking it was spent on was cancelled
+	VoucherVoiding    = "void"       // remaining balance withdrawn when the voucher was voided
+)
+
+// Voucher is a gift card or voucher worth an amount that guests spend on direct
+// bookings. Only a keyed hash of its code is stored; the code itself is shown once,
+// when the voucher is issued.
+type Voucher struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	CodeHash      string     `gorm:"uniqueIndex;type:varchar(64)" json:"-"`
+	CodeSuffix    string     `gorm:"type:varchar(4)" json:"code_suffix"` // last characters of the code
+	Currency      string     `gorm:"type:varchar(3);default:USD" json:"currency"`
+	InitialAmount float64    `json:"initial_amount"`
+	Balance       float64    `json:"balance"`
+	Status        string     `gorm:"index;type:varchar(20);default:active" json:"status"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	Note          string     `json:"note,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// Relationships
+	Entries []VoucherEntry `gorm:"foreignKey:VoucherID" json:"entries,omitempty"`
+}
+
+// TableName specifies the table name
+func (Voucher) TableName() string {
+	return "vouchers"
+}
+
+// Redeemable reports whether the voucher can be spent at now
+func (v Voucher) Redeemable(now time.Time) bool {
+	return v.Status == VoucherStatusActive && v.Balance > 0 && (v.ExpiresAt == nil || now.Before(*v.ExpiresAt))
+}
+
+// VoucherEntry is a change to a voucher's balance
+type VoucherEntry struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	VoucherID   uint      `gorm:"uniqueIndex:idx_voucher_entry_booking,priority:1" json:"voucher_id"`
+	BookingID   *uint     `gorm:"uniqueIndex:idx_voucher_entry_booking,priority:2;index" json:"booking_id,omitempty"`
+	Type        string    `gorm:"uniqueIndex:idx_voucher_entry_booking,priority:3;type:varchar(20)" json:"type"`
+	Amount      float64   `json:"amount"` // negative when spent
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (VoucherEntry) TableName() string {
+	return "voucher_entries"
+}
+
+// IssuedVoucher is a newly issued voucher with its code
+type IssuedVoucher struct {
+	Voucher
+	Code string `json:"code"`
+}
+
+// AppliedVoucher is the part of a voucher's balance spent on a stay
+type AppliedVoucher struct {
+	VoucherID  uint    `json:"voucher_id"`
+	CodeSuffix string  `json:"code_suffix"`
+	Amount     float64 `json:"amount"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// WeatherForecast is the daily forecast of a city attached to search responses
+type WeatherForecast struct {
+	City      string       `json:"city"`
+	Country   string       `json:"country,omitempty"`
+	Provider  string       `json:"provider"`
+	FetchedAt time.Time    `json:"fetched_at"`
+	Days      []WeatherDay `json:"days"`
+}
+
+// WeatherDay summarizes the weather of one day
+type WeatherDay struct {
+	Date                string  `json:"date"`      // YYYY-MM-DD, local to the city
+	Condition           string  `json:"condition"` // clear, partly_cloudy, cloudy, fog, drizzle, rain, snow, thunderstorm or unknown
+	TempMinC            float64 `json:"temp_min_c"`
+	TempMaxC            float64 `json:"temp_max_c"`
+	PrecipitationChance int     `json:"precipitation_chance"` // percent
+}
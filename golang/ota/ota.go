@@ -0,0 +1,338 @@
+package ota
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"channelmanager/database"
+)
+
+// Message types accepted from PMS systems
+const (
+	HotelAvailNotif      = "OTA_HotelAvailNotifRQ"
+	HotelRateAmountNotif = "OTA_HotelRateAmountNotifRQ"
+)
+
+// OpenTravel error codes used in acknowledgements
+const (
+	ErrCodeRequiredFieldMissing = "321"
+	ErrCodeInvalidHotelCode     = "392"
+	ErrCodeInvalidDate          = "15"
+	ErrCodeInvalidValue         = "320"
+	ErrCodeUnableToProcess      = "450"
+	ErrCodeSystemError          = "448"
+)
+
+const otaNamespace = "http://www.opentravel.org/OTA/2003/05"
+
+// Error is a message-level error reported in the acknowledgement
+type Error struct {
+	Code      string
+	ShortText string
+}
+
+func (e *Error) Error() string {
+	return e.ShortText
+}
+
+// Notification is a parsed ARI notification
+type Notification struct {
+	Type      string // HotelAvailNotif or HotelRateAmountNotif
+	EchoToken string
+	Version   string
+	Changes   []database.PropertyARIChanges
+}
+
+// statusApplicationControl selects the dates, and optionally weekdays, a message applies to
+type statusApplicationControl struct {
+	Start string `xml:"Start,attr"`
+	End   string `xml:"End,attr"`
+	Mon   string `xml:"Mon,attr"`
+	Tue   string `xml:"Tue,attr"`
+	Weds  string `xml:"Weds,attr"`
+	Thur  string `xml:"Thur,attr"`
+	Fri   string `xml:"Fri,attr"`
+	Sat   string `xml:"Sat,attr"`
+	Sun   string `xml:"Sun,attr"`
+}
+
+type availNotifRQ struct {
+	EchoToken string `xml:"EchoToken,attr"`
+	Version   string `xml:"Version,attr"`
+	Messages  struct {
+		HotelCode string `xml:"HotelCode,attr"`
+		Messages  []struct {
+			BookingLimit  *int                     `xml:"BookingLimit,attr"`
+			Control       statusApplicationControl `xml:"StatusApplicationControl"`
+			LengthsOfStay []lengthOfStay           `xml:"LengthsOfStay>LengthOfStay"`
+			Restriction   *struct {
+				Status      string `xml:"Status,attr"`
+				Restriction string `xml:"Restriction,attr"`
+			} `xml:"RestrictionStatus"`
+		} `xml:"AvailStatusMessage"`
+	} `xml:"AvailStatusMessages"`
+}
+
+type lengthOfStay struct {
+	Time              int    `xml:"Time,attr"`
+	TimeUnit          string `xml:"TimeUnit,attr"`
+	MinMaxMessageType string `xml:"MinMaxMessageType,attr"`
+}
+
+type rateAmountNotifRQ struct {
+	EchoToken string `xml:"EchoToken,attr"`
+	Version   string `xml:"Version,attr"`
+	Messages  struct {
+		HotelCode string `xml:"HotelCode,attr"`
+		Messages  []struct {
+			Control statusApplicationControl `xml:"StatusApplicationControl"`
+			Amounts []struct {
+				AmountAfterTax  string `xml:"AmountAfterTax,attr"`
+				AmountBeforeTax string `xml:"AmountBeforeTax,attr"`
+			} `xml:"Rates>Rate>BaseByGuestAmts>BaseByGuestAmt"`
+		} `xml:"RateAmountMessage"`
+	} `xml:"RateAmountMessages"`
+}
+
+// Parse parses an OTA_HotelAvailNotifRQ or OTA_HotelRateAmountNotifRQ payload into
+// ARI changes. Problems with the content are returned as *Error; the notification is
+// returned alongside them whenever the message type could be read, for acknowledging.
+func Parse(data []byte) (*Notification, error) {
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, &Error{Code: ErrCodeUnableToProcess, ShortText: "Malformed XML: " + err.Error()}
+	}
+
+	n := &Notification{Type: root}
+	switch root {
+	case HotelAvailNotif:
+		var rq availNotifRQ
+		if err := xml.Unmarshal(data, &rq); err != nil {
+			return n, &Error{Code: ErrCodeUnableToProcess, ShortText: "Malformed XML: " + err.Error()}
+		}
+		n.EchoToken, n.Version = rq.EchoToken, rq.Version
+		n.Changes, err = parseAvailNotif(&rq)
+	case HotelRateAmountNotif:
+		var rq rateAmountNotifRQ
+		if err := xml.Unmarshal(data, &rq); err != nil {
+			return n, &Error{Code: ErrCodeUnableToProcess, ShortText: "Malformed XML: " + err.Error()}
+		}
+		n.EchoToken, n.Version = rq.EchoToken, rq.Version
+		n.Changes, err = parseRateAmountNotif(&rq)
+	default:
+		return nil, &Error{Code: ErrCodeUnableToProcess, ShortText: "Unsupported message " + root}
+	}
+	return n, err
+}
+
+// parseAvailNotif converts availability status messages to changes
+func parseAvailNotif(rq *availNotifRQ) ([]database.PropertyARIChanges, error) {
+	propertyID, err := parseHotelCode(rq.Messages.HotelCode)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []database.ARIChange
+	for i, msg := range rq.Messages.Messages {
+		var change database.ARIChange
+
+		if msg.BookingLimit != nil {
+			available := *msg.BookingLimit > 0
+			change.Available = &available
+		}
+		if msg.Restriction != nil && (msg.Restriction.Restriction == "" || msg.Restriction.Restriction == "Master") {
+			switch msg.Restriction.Status {
+			case "Open":
+				available := true
+				change.Available = &available
+			case "Close":
+				available := false
+				change.Available = &available
+			default:
+				return nil, &Error{Code: ErrCodeInvalidValue, ShortText: fmt.Sprintf("AvailStatusMessage %d: invalid RestrictionStatus %q", i+1, msg.Restriction.Status)}
+			}
+		}
+		for _, los := range msg.LengthsOfStay {
+			if los.MinMaxMessageType != "SetMinLOS" {
+				continue
+			}
+			if los.Time < 1 || (los.TimeUnit != "" && los.TimeUnit != "Day") {
+				return nil, &Error{Code: ErrCodeInvalidValue, ShortText: fmt.Sprintf("AvailStatusMessage %d: minimum stay must be a positive number of days", i+1)}
+			}
+			minStay := los.Time
+			change.MinStay = &minStay
+		}
+
+		if change.Available == nil && change.MinStay == nil {
+			return nil, &Error{Code: ErrCodeRequiredFieldMissing, ShortText: fmt.Sprintf("AvailStatusMessage %d: no BookingLimit, RestrictionStatus or SetMinLOS to apply", i+1)}
+		}
+
+		expanded, err := expand(msg.Control, change, fmt.Sprintf("AvailStatusMessage %d", i+1))
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, expanded...)
+	}
+
+	return propertyChanges(propertyID, changes)
+}
+
+// parseRateAmountNotif converts rate amount messages to changes
+func parseRateAmountNotif(rq *rateAmountNotifRQ) ([]database.PropertyARIChanges, error) {
+	propertyID, err := parseHotelCode(rq.Messages.HotelCode)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []database.ARIChange
+	for i, msg := range rq.Messages.Messages {
+		if len(msg.Amounts) == 0 {
+			return nil, &Error{Code: ErrCodeRequiredFieldMissing, ShortText: fmt.Sprintf("RateAmountMessage %d: BaseByGuestAmt is required", i+1)}
+		}
+
+		amount := msg.Amounts[0].AmountAfterTax
+		if amount == "" {
+			amount = msg.Amounts[0].AmountBeforeTax
+		}
+		price, err := strconv.ParseFloat(amount, 64)
+		if err != nil || price <= 0 {
+			return nil, &Error{Code: ErrCodeInvalidValue, ShortText: fmt.Sprintf("RateAmountMessage %d: invalid amount %q", i+1, amount)}
+		}
+
+		expanded, err := expand(msg.Control, database.ARIChange{BasePrice: &price}, fmt.Sprintf("RateAmountMessage %d", i+1))
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, expanded...)
+	}
+
+	return propertyChanges(propertyID, changes)
+}
+
+// propertyChanges groups the parsed changes of a message's property
+func propertyChanges(propertyID uint, changes []database.ARIChange) ([]database.PropertyARIChanges, error) {
+	if len(changes) == 0 {
+		return nil, &Error{Code: ErrCodeRequiredFieldMissing, ShortText: "Message contains no updates"}
+	}
+	return []database.PropertyARIChanges{{PropertyID: propertyID, Changes: changes}}, nil
+}
+
+// expand applies a change to the control's date range, split into single nights when
+// the control restricts weekdays
+func expand(control statusApplicationControl, change database.ARIChange, context string) ([]database.ARIChange, error) {
+	start, err := time.Parse("2006-01-02", control.Start)
+	if err != nil {
+		return nil, &Error{Code: ErrCodeInvalidDate, ShortText: context + ": invalid Start date"}
+	}
+	end, err := time.Parse("2006-01-02", control.End)
+	if err != nil {
+		return nil, &Error{Code: ErrCodeInvalidDate, ShortText: context + ": invalid End date"}
+	}
+	if end.Before(start) || end.Sub(start) > 366*24*time.Hour {
+		return nil, &Error{Code: ErrCodeInvalidDate, ShortText: context + ": End must be within one year after Start"}
+	}
+
+	weekdays := map[time.Weekday]string{
+		time.Monday: control.Mon, time.Tuesday: control.Tue, time.Wednesday: control.Weds,
+		time.Thursday: control.Thur, time.Friday: control.Fri, time.Saturday: control.Sat, time.Sunday: control.Sun,
+	}
+	restricted := false
+	for _, flag := range weekdays {
+		if flag != "" {
+			restricted = true
+		}
+	}
+
+	if !restricted {
+		change.StartDate, change.EndDate = start, end
+		return []database.ARIChange{change}, nil
+	}
+
+	var nights []database.ARIChange
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if flag := weekdays[d.Weekday()]; flag == "true" || flag == "1" {
+			night := change
+			night.StartDate, night.EndDate = d, d
+			nights = append(nights, night)
+		}
+	}
+	return nights, nil
+}
+
+// parseHotelCode maps a HotelCode to a property ID
+func parseHotelCode(code string) (uint, error) {
+	id, err := strconv.ParseUint(strings.TrimSpace(code), 10, 32)
+	if err != nil || id == 0 {
+		return 0, &Error{Code: ErrCodeInvalidHotelCode, ShortText: fmt.Sprintf("Invalid HotelCode %q, expected a property ID", code)}
+	}
+	return uint(id), nil
+}
+
+// rootElement returns the name of the document's root element
+func rootElement(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+// acknowledgement is the OTA response envelope
+type acknowledgement struct {
+	XMLName   xml.Name
+	Xmlns     string     `xml:"xmlns,attr"`
+	EchoToken string     `xml:"EchoToken,attr,omitempty"`
+	TimeStamp string     `xml:"TimeStamp,attr"`
+	Version   string     `xml:"Version,attr"`
+	Success   *struct{}  `xml:"Success"`
+	Errors    *ackErrors `xml:"Errors"`
+}
+
+type ackErrors struct {
+	Errors []ackError `xml:"Error"`
+}
+
+type ackError struct {
+	Type      string `xml:"Type,attr"`
+	Code      string `xml:"Code,attr"`
+	ShortText string `xml:"ShortText,attr"`
+}
+
+// Acknowledge renders the response to a request of the given type; a nil ackErr reports success
+func Acknowledge(requestType, echoToken, version string, ackErr *Error) ([]byte, error) {
+	if version == "" {
+		version = "1.0"
+	}
+	ack := acknowledgement{
+		XMLName:   xml.Name{Local: strings.TrimSuffix(requestType, "RQ") + "RS"},
+		Xmlns:     otaNamespace,
+		EchoToken: echoToken,
+		TimeStamp: time.Now().UTC().Format(time.RFC3339),
+		Version:   version,
+	}
+	if ackErr == nil {
+		ack.Success = &struct{}{}
+	} else {
+		// Type 3 is a business rule error, 12 a processing error
+		errType := "3"
+		if ackErr.Code == ErrCodeSystemError || ackErr.Code == ErrCodeUnableToProcess {
+			errType = "12"
+		}
+		ack.Errors = &ackErrors{Errors: []ackError{{Type: errType, Code: ackErr.Code, ShortText: ackErr.ShortText}}}
+	}
+
+	body, err := xml.MarshalIndent(ack, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,132 @@
+package parity
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"channelmanager/database"
+	"channelmanager/fx"
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// Config holds rate parity monitoring configuration
+type Config struct {
+	CheckInterval    time.Duration
+	LookaheadDays    int
+	TolerancePercent float64 // differences at or below this are in parity
+	MajorPercent     float64 // differences above this are major
+	CriticalPercent  float64 // differences above this are critical
+}
+
+// Monitor compares the rates pushed to channels against base pricing
+type Monitor struct {
+	config        Config
+	parityRepo    *database.ParityRepository
+	exchangeRates *fx.Service
+}
+
+// NewMonitor creates a new rate parity monitor
+func NewMonitor(db *gorm.DB, config Config, exchangeRates *fx.Service) *Monitor {
+	return &Monitor{
+		config:        config,
+		parityRepo:    database.NewParityRepository(db),
+		exchangeRates: exchangeRates,
+	}
+}
+
+// Check compares upcoming channel rates with their expected rates and records
+// new violations or resolves violations that are back in parity
+func (m *Monitor) Check() error {
+	now := time.Now()
+	start := models.EarliestToday()
+	end := start.AddDate(0, 0, m.config.LookaheadDays)
+
+	comparisons, err := m.parityRepo.GetParityComparisons(start, end)
+	if err != nil {
+		return err
+	}
+
+	violations := 0
+	for _, comparison := range comparisons {
+		// Rates pushed in another currency are compared with base prices converted to it
+		if comparison.Currency != "" && comparison.Currency != fx.BaseCurrency {
+			converted, err := m.exchangeRates.Convert(comparison.BaseRate, fx.BaseCurrency, comparison.Currency)
+			if err != nil {
+				log.Printf("Skipped parity check of %s for property %d: %v", comparison.ChannelID, comparison.PropertyID, err)
+				continue
+			}
+			comparison.BaseRate = converted
+		}
+		expected := ExpectedRate(comparison)
+		diff := DifferencePercent(expected, comparison.ChannelRate)
+		severity := m.Severity(diff)
+
+		if severity == "" {
+			if err := m.parityRepo.ResolveViolation(comparison.ChannelID, comparison.PropertyID, comparison.Date, now); err != nil {
+				log.Printf("Failed to resolve parity violation: %v", err)
+			}
+			continue
+		}
+
+		violations++
+		violation := &models.RateParityViolation{
+			ChannelID:         comparison.ChannelID,
+			PropertyID:        comparison.PropertyID,
+			Date:              comparison.Date,
+			ExpectedRate:      expected,
+			ChannelRate:       comparison.ChannelRate,
+			DifferencePercent: diff,
+			Severity:          severity,
+			DetectedAt:        now,
+			LastCheckedAt:     now,
+		}
+		if err := m.parityRepo.UpsertViolation(violation); err != nil {
+			log.Printf("Failed to record parity violation: %v", err)
+		}
+	}
+
+	log.Printf("Rate parity check compared %d rates, %d violations", len(comparisons), violations)
+	return nil
+}
+
+// Severity classifies a percentage difference; an empty string means in parity
+func (m *Monitor) Severity(differencePercent float64) string {
+	diff := math.Abs(differencePercent)
+	switch {
+	case diff <= m.config.TolerancePercent:
+		return ""
+	case diff > m.config.CriticalPercent:
+		return models.ParitySeverityCritical
+	case diff > m.config.MajorPercent:
+		return models.ParitySeverityMajor
+	default:
+		return models.ParitySeverityMinor
+	}
+}
+
+// ExpectedRate returns the rate a channel should be showing for a night,
+// i.e. the base price with the channel's pricing rules applied
+func ExpectedRate(comparison database.ParityComparison) float64 {
+	channel := models.Channel{
+		ID:                comparison.ChannelID,
+		MarkupPercent:     comparison.MarkupPercent,
+		FixedFee:          comparison.FixedFee,
+		CommissionPercent: comparison.CommissionPercent,
+		GrossUpCommission: comparison.GrossUpCommission,
+	}
+	return channel.ApplyPricingRules(comparison.BaseRate)
+}
+
+// DifferencePercent returns how far the channel rate deviates from the expected rate
+func DifferencePercent(expected, actual float64) float64 {
+	if expected == 0 {
+		if actual == 0 {
+			return 0
+		}
+		return 100
+	}
+	return math.Round((actual-expected)/expected*10000) / 100
+}
@@ -0,0 +1,157 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// depositBatchSize is the number of bookings handled per query
+const depositBatchSize = 200
+
+// DepositRun summarizes one pass over due security deposits
+type DepositRun struct {
+	Placed    int
+	Released  int
+	Cancelled int
+	Failed    int
+}
+
+// DepositService places security deposits ahead of check-in and releases them after
+// check-out. Holds are authorized on the guest's card, so they are placed close to
+// arrival; card authorizations expire after about a week.
+type DepositService struct {
+	config      Config
+	gateway     Gateway
+	bookingRepo *database.BookingRepository
+}
+
+// NewDepositService creates a new deposit service
+func NewDepositService(db *gorm.DB, gateway Gateway, config Config) *DepositService {
+	return &DepositService{
+		config:      config,
+		gateway:     gateway,
+		bookingRepo: database.NewBookingRepository(db),
+	}
+}
+
+// ProcessDue places the deposits of stays checking in within the lead time and releases
+// those of stays that checked out long enough ago or were cancelled
+func (s *DepositService) ProcessDue(ctx context.Context, now time.Time) (DepositRun, error) {
+	var run DepositRun
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	cancelled, err := s.bookingRepo.CancelPendingDeposits()
+	if err != nil {
+		return run, fmt.Errorf("failed to cancel pending deposits: %w", err)
+	}
+	run.Cancelled = int(cancelled)
+
+	for ctx.Err() == nil {
+		bookings, err := s.bookingRepo.GetDepositsToPlace(today.AddDate(0, 0, s.config.HoldLeadDays), today, depositBatchSize)
+		if err != nil {
+			return run, fmt.Errorf("failed to load deposits to place: %w", err)
+		}
+		placed := 0
+		for i := range bookings {
+			if err := s.Place(ctx, &bookings[i]); err != nil {
+				log.Printf("Failed to place deposit of booking %d: %v", bookings[i].ID, err)
+				run.Failed++
+				continue
+			}
+			placed++
+		}
+		run.Placed += placed
+		if len(bookings) < depositBatchSize || placed == 0 {
+			break
+		}
+	}
+
+	for ctx.Err() == nil {
+		bookings, err := s.bookingRepo.GetDepositsToRelease(today.AddDate(0, 0, -s.config.ReleaseAfterDays), depositBatchSize)
+		if err != nil {
+			return run, fmt.Errorf("failed to load deposits to release: %w", err)
+		}
+		released := 0
+		for i := range bookings {
+			if err := s.Release(ctx, &bookings[i]); err != nil {
+				log.Printf("Failed to release deposit of booking %d: %v", bookings[i].ID, err)
+				run.Failed++
+				continue
+			}
+			released++
+		}
+		run.Released += released
+		// Failed releases stay held and would be selected again
+		if len(bookings) < depositBatchSize || released == 0 {
+			break
+		}
+	}
+
+	return run, ctx.Err()
+}
+
+// Place authorizes or charges a booking's pending deposit. A declined payment marks the
+// deposit failed, for the host to follow up with the guest.
+func (s *DepositService) Place(ctx context.Context, booking *models.Booking) error {
+	payment := Payment{
+		Amount:         booking.DepositAmount,
+		Currency:       booking.Currency,
+		Description:    fmt.Sprintf("Security deposit for booking %d", booking.ID),
+		IdempotencyKey: fmt.Sprintf("deposit-%d", booking.ID),
+	}
+
+	var reference string
+	var err error
+	if booking.DepositMode == models.DepositModeCharge {
+		reference, err = s.gateway.Charge(ctx, payment)
+	} else {
+		reference, err = s.gateway.Authorize(ctx, payment)
+	}
+
+	deposit := booking.BookingDeposit
+	now := time.Now()
+	if err != nil {
+		deposit.DepositStatus = models.DepositStatusFailed
+		deposit.DepositError = err.Error()
+	} else {
+		deposit.DepositStatus = models.DepositStatusHeld
+		deposit.DepositReference = reference
+		deposit.DepositError = ""
+		deposit.DepositPlacedAt = &now
+	}
+	if _, updateErr := s.bookingRepo.UpdateDeposit(booking.ID, models.DepositStatusPending, deposit); updateErr != nil {
+		return fmt.Errorf("failed to record deposit: %w", updateErr)
+	}
+	booking.BookingDeposit = deposit
+	return err
+}
+
+// Release voids or refunds a booking's held deposit
+func (s *DepositService) Release(ctx context.Context, booking *models.Booking) error {
+	var err error
+	if booking.DepositMode == models.DepositModeCharge {
+		err = s.gateway.Refund(ctx, booking.DepositReference, booking.DepositAmount)
+	} else {
+		err = s.gateway.Void(ctx, booking.DepositReference)
+	}
+	if err != nil {
+		return err
+	}
+
+	deposit := booking.BookingDeposit
+	now := time.Now()
+	deposit.DepositStatus = models.DepositStatusReleased
+	deposit.DepositReleasedAt = &now
+	if _, err := s.bookingRepo.UpdateDeposit(booking.ID, models.DepositStatusHeld, deposit); err != nil {
+		return fmt.Errorf("failed to record deposit release: %w", err)
+	}
+	booking.BookingDeposit = deposit
+	return nil
+}
@@ -0,0 +1,81 @@
+package payments
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+// Config holds payments configuration
+type Config struct {
+	Provider         string // payment provider; "simulated" is built in
+	HoldLeadDays     int    // days before check-in security deposits are placed
+	ReleaseAfterDays int    // days after check-out security deposits are released
+	DepositSchedule  string // when the deposit job runs
+}
+
+// Payment describes an amount to take from a guest
+type Payment struct {
+	Amount      float64
+	Currency    string
+	Description string
+	// IdempotencyKey lets the provider recognize a retried request
+	IdempotencyKey string
+}
+
+// Gateway is a payment provider
+type Gateway interface {
+	// Authorize places a hold for the payment and returns its reference
+	Authorize(ctx context.Context, payment Payment) (string, error)
+	// Void releases a hold
+	Void(ctx context.Context, reference string) error
+	// Charge takes the payment and returns its reference
+	Charge(ctx context.Context, payment Payment) (string, error)
+	// Refund returns amount of a charge
+	Refund(ctx context.Context, reference string, amount float64) error
+}
+
+// NewGateway creates the gateway of the configured provider
+func NewGateway(cfg Config) (Gateway, error) {
+	switch cfg.Provider {
+	case "", "simulated":
+		return SimulatedGateway{}, nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", cfg.Provider)
+	}
+}
+
+// SimulatedGateway accepts every request without moving money, for development and
+// test environments
+type SimulatedGateway struct{}
+
+func (SimulatedGateway) Authorize(ctx context.Context, payment Payment) (string, error) {
+	reference := simulatedReference("auth")
+	log.Printf("Simulated authorization %s of %.2f %s: %s", reference, payment.Amount, payment.Currency, payment.Description)
+	return reference, nil
+}
+
+func (SimulatedGateway) Void(ctx context.Context, reference string) error {
+	log.Printf("Simulated void of %s", reference)
+	return nil
+}
+
+func (SimulatedGateway) Charge(ctx context.Context, payment Payment) (string, error) {
+	reference := simulatedReference("ch")
+	log.Printf("Simulated charge %s of %.2f %s: %s", reference, payment.Amount, payment.Currency, payment.Description)
+	return reference, nil
+}
+
+func (SimulatedGateway) Refund(ctx context.Context, reference string, amount float64) error {
+	log.Printf("Simulated refund of %.2f on %s", amount, reference)
+	return nil
+}
+
+// simulatedReference returns a random reference with the given prefix
+func simulatedReference(prefix string) string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "sim_" + prefix + "_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,215 @@
+// Package pii encrypts personal data at rest. Fields tagged
+// `gorm:"serializer:encrypted"` are sealed with AES-256-GCM when written and opened
+// transparently when read, so handlers and JSON responses see plaintext.
+//
+// Stored values have the form "enc:<key id>:<base64 nonce and ciphertext>". Keys are
+// kept by ID so that values sealed with a retired key stay readable after rotation;
+// new values always use the current key. Values without the prefix, written before
+// encryption was enabled, are read as they are.
+package pii
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+// prefix marks an encrypted value
+const prefix = "enc:"
+
+// Config holds personal data encryption configuration
+type Config struct {
+	Keys         map[string]string // key ID -> base64-encoded 32-byte key, e.g. from a KMS-backed secret
+	CurrentKeyID string            // key that seals new values
+	IndexKey     string            // HMAC key of the blind indexes used to look up encrypted values
+	Schedule     string            // cron schedule of the job re-encrypting values not sealed with the current key
+}
+
+// keyring holds the configured ciphers
+type keyring struct {
+	current  string
+	ciphers  map[string]cipher.AEAD
+	indexKey []byte
+}
+
+var (
+	mu     sync.RWMutex
+	active *keyring
+)
+
+func init() {
+	schema.RegisterSerializer("encrypted", Serializer{})
+}
+
+// Configure loads the encryption keys. Without keys values are stored in plaintext.
+func Configure(config Config) error {
+	ring := &keyring{ciphers: make(map[string]cipher.AEAD), indexKey: []byte(config.IndexKey)}
+	for id, encoded := range config.Keys {
+		if strings.Contains(id, ":") {
+			return fmt.Errorf("key ID %q must not contain ':'", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("key %s is not valid base64: %w", id, err)
+		}
+		if len(key) != 32 {
+			return fmt.Errorf("key %s must be 32 bytes, got %d", id, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return err
+		}
+		ring.ciphers[id] = aead
+	}
+
+	if len(ring.ciphers) > 0 {
+		if _, ok := ring.ciphers[config.CurrentKeyID]; !ok {
+			return fmt.Errorf("current key %q is not configured", config.CurrentKeyID)
+		}
+		if len(ring.indexKey) == 0 {
+			return errors.New("an index key is required when encryption is enabled")
+		}
+		ring.current = config.CurrentKeyID
+	}
+
+	mu.Lock()
+	active = ring
+	mu.Unlock()
+	return nil
+}
+
+// Enabled reports whether new values are encrypted
+func Enabled() bool {
+	ring := current()
+	return ring != nil && ring.current != ""
+}
+
+// CurrentPrefix returns the prefix of values sealed with the current key, or an empty
+// string when encryption is disabled
+func CurrentPrefix() string {
+	ring := current()
+	if ring == nil || ring.current == "" {
+		return ""
+	}
+	return prefix + ring.current + ":"
+}
+
+func current() *keyring {
+	mu.RLock()
+	defer mu.RUnlock()
+	return active
+}
+
+// Encrypt seals a value with the current key. Empty values and values when encryption
+// is disabled are returned unchanged.
+func Encrypt(plaintext string) (string, error) {
+	ring := current()
+	if plaintext == "" || ring == nil || ring.current == "" {
+		return plaintext, nil
+	}
+
+	aead := ring.ciphers[ring.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + ring.current + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value sealed by Encrypt with any configured key; values without the
+// encryption prefix are returned unchanged
+func Decrypt(stored string) (string, error) {
+	if !strings.HasPrefix(stored, prefix) {
+		return stored, nil
+	}
+
+	keyID, encoded, ok := strings.Cut(strings.TrimPrefix(stored, prefix), ":")
+	if !ok {
+		return "", errors.New("malformed encrypted value")
+	}
+	ring := current()
+	if ring == nil {
+		return "", errors.New("encryption keys are not configured")
+	}
+	aead, ok := ring.ciphers[keyID]
+	if !ok {
+		return "", fmt.Errorf("unknown encryption key %q", keyID)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// BlindIndex returns a keyed hash of a normalized value, stored next to an encrypted
+// column so exact-match lookups work without decrypting. Empty values index as empty.
+func BlindIndex(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return ""
+	}
+
+	var key []byte
+	if ring := current(); ring != nil {
+		key = ring.indexKey
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Serializer is the GORM serializer of encrypted string fields
+type Serializer struct{}
+
+// Scan decrypts a stored value into the field
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("unsupported value %T for encrypted field %s", dbValue, field.Name)
+	}
+
+	plaintext, err := Decrypt(stored)
+	if err != nil {
+		return fmt.Errorf("field %s: %w", field.Name, err)
+	}
+	return field.Set(ctx, dst, plaintext)
+}
+
+// Value encrypts the field's value for storage
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("encrypted field %s must be a string", field.Name)
+	}
+	return Encrypt(plaintext)
+}
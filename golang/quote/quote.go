@@ -0,0 +1,537 @@
+package quote
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"channelmanager/database"
+	"channelmanager/fx"
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// NightlyRate represents the price of a single night of a stay
+type NightlyRate struct {
+	Date      string  `json:"date"`
+	BasePrice float64 `json:"base_price"`
+}
+
+// Quote represents the full price of a stay with its itemized breakdown
+type Quote struct {
+	PropertyID      uint                    `json:"property_id"`
+	CheckinDate     string                  `json:"checkin_date"`
+	CheckoutDate    string                  `json:"checkout_date"`
+	Nights          int                     `json:"nights"`
+	Guests          int                     `json:"guests"`
+	Children        int                     `json:"children"`
+	Infants         int                     `json:"infants"`
+	Currency        string                  `json:"currency"`
+	NightlyRates    []NightlyRate           `json:"nightly_rates"`
+	Subtotal        float64                 `json:"subtotal"` // includes ExtraGuestFees
+	ExtraGuestFees  float64                 `json:"extra_guest_fees"`
+	Taxes           float64                 `json:"taxes"`
+	Fees            float64                 `json:"fees"`
+	Discounts       float64                 `json:"discounts"`
+	Credit          float64                 `json:"credit,omitempty"`          // loyalty credit, after taxes
+	PointsRedeemed  int                     `json:"points_redeemed,omitempty"` // loyalty points the credit costs
+	VoucherAmount   float64                 `json:"voucher_amount,omitempty"`  // paid with vouchers, after taxes
+	Vouchers        []models.AppliedVoucher `json:"vouchers,omitempty"`
+	Total           float64                 `json:"total"`
+	AveragePerNight float64                 `json:"average_per_night"`
+	MissingNights   []string                `json:"missing_nights,omitempty"`
+	LineItems       []models.PriceLineItem  `json:"line_items"`
+	OptionalFees    []models.PriceLineItem  `json:"optional_fees,omitempty"` // not in Total
+	CheckinTimes    models.CheckinTimes     `json:"checkin_times"`
+	Deposit         *models.DepositTerms    `json:"deposit,omitempty"` // refundable, not in Total
+	Cancellation    Cancellation            `json:"cancellation"`
+}
+
+// Cancellation describes the cancellation policy a stay is booked under
+type Cancellation struct {
+	Policy string `json:"policy"`
+	Terms  string `json:"terms"`
+}
+
+// Complete reports whether every night of the stay has a price
+func (q *Quote) Complete() bool {
+	return len(q.MissingNights) == 0 && q.Nights > 0
+}
+
+// Request describes the stay being priced
+type Request struct {
+	PropertyID   uint
+	Property     *models.Property // optional, loaded by the engine when nil
+	CheckinDate  time.Time
+	CheckoutDate time.Time
+	Guests       int
+	Children     int // of Guests
+	Infants      int // of Guests
+
+	// Settings of the property's organization, setting the currency and cancellation
+	// policy; the defaults apply when nil
+	Settings *models.OrganizationSettings
+}
+
+// currency returns the currency the stay is quoted in
+func (r Request) currency() string {
+	if r.Settings != nil && r.Settings.DefaultCurrency != "" {
+		return r.Settings.DefaultCurrency
+	}
+	return models.DefaultOrganizationSettings(0).DefaultCurrency
+}
+
+// Rules holds the property-level pricing rules applied on top of nightly pricing
+type Rules struct {
+	Discounts    []models.LengthOfStayDiscount
+	TaxRules     []models.TaxRule
+	Fees         []models.Fee
+	Occupancy    models.OccupancyPricing
+	CheckinTimes models.CheckinTimes  // returned with the quote
+	Deposit      *models.DepositTerms // returned with the quote
+
+	// ExchangeRate converts the prices, which are set in fx.BaseCurrency, to the quote
+	// currency; prices are not converted when it is zero
+	ExchangeRate float64
+}
+
+// Nights returns the number of nights between check-in and check-out
+func (r Request) Nights() int {
+	return int(r.CheckoutDate.Sub(r.CheckinDate).Hours() / 24)
+}
+
+// Engine computes stay quotes from nightly pricing and property pricing rules
+type Engine struct {
+	propertyRepo *database.PropertyRepository
+	pricingRepo  *database.PricingRepository
+	discountRepo *database.DiscountRepository
+	taxRuleRepo  *database.TaxRuleRepository
+	feeRepo      *database.FeeRepository
+	rates        *fx.Service
+}
+
+// NewEngine creates a new quote engine converting prices with exchangeRates
+func NewEngine(db *gorm.DB, exchangeRates *fx.Service) *Engine {
+	return &Engine{
+		rates:        exchangeRates,
+		propertyRepo: database.NewPropertyRepository(db),
+		pricingRepo:  database.NewPricingRepository(db),
+		discountRepo: database.NewDiscountRepository(db),
+		taxRuleRepo:  database.NewTaxRuleRepository(db),
+		feeRepo:      database.NewFeeRepository(db),
+	}
+}
+
+// Quote prices a stay, loading the nightly pricing of the property
+func (e *Engine) Quote(req Request) (*Quote, error) {
+	if req.Nights() < 1 {
+		return nil, fmt.Errorf("checkout_date must be after checkin_date")
+	}
+
+	// Nights run from check-in up to the day before check-out
+	pricing, err := e.pricingRepo.GetPricingForDateRange(
+		req.PropertyID,
+		req.CheckinDate.Format("2006-01-02"),
+		req.CheckoutDate.AddDate(0, 0, -1).Format("2006-01-02"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pricing: %w", err)
+	}
+
+	return e.QuoteWithPricing(req, pricing)
+}
+
+// QuoteWithPricing prices a stay from already loaded nightly pricing
+func (e *Engine) QuoteWithPricing(req Request, pricing []models.Pricing) (*Quote, error) {
+	rules, err := e.LoadRules(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return Compute(req, pricing, rules), nil
+}
+
+// LoadRules loads the discount tiers, the fees and the tax rules of the property's
+// jurisdiction, and the exchange rate into the quote currency. It returns an error
+// wrapping fx.ErrNoRate when the quote currency has no rate.
+func (e *Engine) LoadRules(req Request) (Rules, error) {
+	var rules Rules
+	if currency := req.currency(); currency != fx.BaseCurrency {
+		rate, err := e.rates.Rate(fx.BaseCurrency, currency)
+		if err != nil {
+			return rules, fmt.Errorf("failed to convert prices to %s: %w", currency, err)
+		}
+		rules.ExchangeRate = rate
+	}
+
+	property := req.Property
+	if property == nil {
+		loaded, err := e.propertyRepo.GetPropertyByID(req.PropertyID)
+		if err != nil {
+			return rules, fmt.Errorf("failed to load property: %w", err)
+		}
+		property = loaded
+	}
+
+	discounts, err := e.discountRepo.GetDiscountsForProperty(req.PropertyID)
+	if err != nil {
+		return rules, fmt.Errorf("failed to load discounts: %w", err)
+	}
+	rules.Discounts = discounts
+
+	taxRules, err := e.taxRuleRepo.GetTaxRulesForLocation(property.Country, property.State, property.City)
+	if err != nil {
+		return rules, fmt.Errorf("failed to load tax rules: %w", err)
+	}
+	rules.TaxRules = ResolveTaxRules(taxRules)
+
+	fees, err := e.feeRepo.GetFeesForProperty(req.PropertyID)
+	if err != nil {
+		return rules, fmt.Errorf("failed to load fees: %w", err)
+	}
+	rules.Fees = fees
+	rules.Occupancy = property.OccupancyPricing
+	rules.CheckinTimes = property.CheckinTimes()
+	rules.Deposit = property.Deposit()
+
+	return rules, nil
+}
+
+// Compute builds a quote from nightly pricing and the property's pricing rules, in the
+// currency of the organization's settings
+func Compute(req Request, pricing []models.Pricing, rules Rules) *Quote {
+	settings := models.DefaultOrganizationSettings(0)
+	if req.Settings != nil {
+		settings = *req.Settings
+	}
+	if rules.ExchangeRate > 0 {
+		pricing, rules = convert(pricing, rules, rules.ExchangeRate)
+	}
+
+	q := &Quote{
+		PropertyID:   req.PropertyID,
+		CheckinDate:  req.CheckinDate.Format("2006-01-02"),
+		CheckoutDate: req.CheckoutDate.Format("2006-01-02"),
+		Nights:       req.Nights(),
+		Guests:       req.Guests,
+		Children:     req.Children,
+		Infants:      req.Infants,
+		Currency:     req.currency(),
+		NightlyRates: make([]NightlyRate, 0, len(pricing)),
+		CheckinTimes: rules.CheckinTimes,
+		Deposit:      rules.Deposit,
+		Cancellation: Cancellation{
+			Policy: settings.CancellationPolicy,
+			Terms:  models.CancellationTerms(settings.CancellationPolicy),
+		},
+	}
+
+	byDate := make(map[string]models.Pricing, len(pricing))
+	for _, p := range pricing {
+		byDate[p.Date.Format("2006-01-02")] = p
+	}
+
+	nightlyDiscounts := 0.0
+	nightlyTaxes := 0.0
+	nightlyFees := 0.0
+	for d := req.CheckinDate; d.Before(req.CheckoutDate); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		p, ok := byDate[date]
+		if !ok {
+			q.MissingNights = append(q.MissingNights, date)
+			continue
+		}
+		q.NightlyRates = append(q.NightlyRates, NightlyRate{Date: date, BasePrice: p.BasePrice})
+		q.Subtotal += p.BasePrice
+		nightlyFees += p.Fees
+		nightlyTaxes += p.Taxes
+		nightlyDiscounts += p.Discount
+	}
+
+	q.LineItems = append(q.LineItems, models.PriceLineItem{
+		Type:        models.LineItemAccommodation,
+		Description: fmt.Sprintf("%d nights", len(q.NightlyRates)),
+		Amount:      round(q.Subtotal),
+	})
+
+	// Guests beyond the base occupancy pay per priced night
+	nights := float64(len(q.NightlyRates))
+	extraAdults, extraChildren := rules.Occupancy.ExtraGuests(models.Occupancy{
+		Guests: req.Guests, Children: req.Children, Infants: req.Infants,
+	})
+	if amount := float64(extraAdults) * rules.Occupancy.ExtraAdultFee * nights; amount > 0 {
+		q.LineItems = append(q.LineItems, models.PriceLineItem{
+			Type:        models.LineItemExtraGuest,
+			Description: fmt.Sprintf("%d extra adults (%.2f per night)", extraAdults, rules.Occupancy.ExtraAdultFee),
+			Amount:      round(amount),
+		})
+		q.ExtraGuestFees += amount
+	}
+	if amount := float64(extraChildren) * rules.Occupancy.ExtraChildFee * nights; amount > 0 {
+		q.LineItems = append(q.LineItems, models.PriceLineItem{
+			Type:        models.LineItemExtraGuest,
+			Description: fmt.Sprintf("%d extra children (%.2f per night)", extraChildren, rules.Occupancy.ExtraChildFee),
+			Amount:      round(amount),
+		})
+		q.ExtraGuestFees += amount
+	}
+	q.Subtotal += q.ExtraGuestFees
+
+	if nightlyDiscounts > 0 {
+		q.LineItems = append(q.LineItems, models.PriceLineItem{
+			Type:        models.LineItemDiscount,
+			Description: "Nightly promotions",
+			Amount:      -round(nightlyDiscounts),
+		})
+		q.Discounts += nightlyDiscounts
+	}
+
+	if tier := bestDiscountTier(q.Nights, rules.Discounts); tier != nil {
+		amount := q.Subtotal * tier.Percent / 100
+		description := tier.Name
+		if description == "" {
+			description = fmt.Sprintf("%d+ night stay discount", tier.MinNights)
+		}
+		q.LineItems = append(q.LineItems, models.PriceLineItem{
+			Type:        models.LineItemDiscount,
+			Description: fmt.Sprintf("%s (%g%%)", description, tier.Percent),
+			Amount:      -round(amount),
+		})
+		q.Discounts += amount
+	}
+
+	// Catalog fees; percentage taxes apply to the taxable ones
+	taxableFees := 0.0
+	for _, fee := range rules.Fees {
+		item := models.PriceLineItem{
+			Type:        models.LineItemFee,
+			Description: fee.Name,
+			Amount:      round(fee.Total(q.Nights, req.Guests)),
+		}
+		if !fee.Mandatory {
+			q.OptionalFees = append(q.OptionalFees, item)
+			continue
+		}
+		if item.Amount <= 0 {
+			continue
+		}
+		q.LineItems = append(q.LineItems, item)
+		q.Fees += item.Amount
+		if fee.Taxable {
+			taxableFees += item.Amount
+		}
+	}
+
+	if len(rules.TaxRules) > 0 {
+		// Jurisdiction rules replace the legacy per-night tax amounts
+		taxable := q.Subtotal - q.Discounts + taxableFees
+		for _, rule := range rules.TaxRules {
+			amount := 0.0
+			description := rule.Name
+			switch rule.Kind {
+			case models.TaxKindPercentage:
+				amount = taxable * rule.Rate / 100
+				description = fmt.Sprintf("%s (%g%%)", rule.Name, rule.Rate)
+			case models.TaxKindPerNight:
+				amount = rule.Rate * nights
+				description = fmt.Sprintf("%s (%.2f per night)", rule.Name, rule.Rate)
+			}
+			if amount <= 0 {
+				continue
+			}
+			q.LineItems = append(q.LineItems, models.PriceLineItem{
+				Type:        models.LineItemTax,
+				Description: description,
+				Amount:      round(amount),
+			})
+			q.Taxes += amount
+		}
+	} else if nightlyTaxes > 0 {
+		q.Taxes = nightlyTaxes
+		q.LineItems = append(q.LineItems, models.PriceLineItem{
+			Type:        models.LineItemTax,
+			Description: "Taxes",
+			Amount:      round(nightlyTaxes),
+		})
+	}
+
+	if nightlyFees > 0 {
+		q.Fees += nightlyFees
+		q.LineItems = append(q.LineItems, models.PriceLineItem{
+			Type:        models.LineItemFee,
+			Description: "Nightly fees",
+			Amount:      round(nightlyFees),
+		})
+	}
+
+	q.finalize()
+	return q
+}
+
+// convert returns copies of nightly pricing and pricing rules with their amounts
+// converted at rate and rounded to cents; percentages are kept
+func convert(pricing []models.Pricing, rules Rules, rate float64) ([]models.Pricing, Rules) {
+	converted := make([]models.Pricing, len(pricing))
+	for i, p := range pricing {
+		p.BasePrice = round(p.BasePrice * rate)
+		p.Taxes = round(p.Taxes * rate)
+		p.Fees = round(p.Fees * rate)
+		p.Discount = round(p.Discount * rate)
+		converted[i] = p
+	}
+
+	rules.Occupancy.ExtraAdultFee = round(rules.Occupancy.ExtraAdultFee * rate)
+	rules.Occupancy.ExtraChildFee = round(rules.Occupancy.ExtraChildFee * rate)
+	fees := make([]models.Fee, len(rules.Fees))
+	for i, fee := range rules.Fees {
+		fee.Amount = round(fee.Amount * rate)
+		fees[i] = fee
+	}
+	rules.Fees = fees
+	taxRules := make([]models.TaxRule, len(rules.TaxRules))
+	for i, rule := range rules.TaxRules {
+		if rule.Kind == models.TaxKindPerNight {
+			rule.Rate = round(rule.Rate * rate)
+		}
+		taxRules[i] = rule
+	}
+	rules.TaxRules = taxRules
+	if rules.Deposit != nil {
+		deposit := *rules.Deposit
+		deposit.Amount = round(deposit.Amount * rate)
+		rules.Deposit = &deposit
+	}
+	return converted, rules
+}
+
+// ResolveTaxRules keeps the most specific rule per tax type, so a city rate
+// overrides the state rate of the same type while different types stack
+func ResolveTaxRules(rules []models.TaxRule) []models.TaxRule {
+	byType := make(map[string]models.TaxRule)
+	var order []string
+	for _, rule := range rules {
+		existing, ok := byType[rule.TaxType]
+		if !ok {
+			order = append(order, rule.TaxType)
+		}
+		if !ok || rule.Specificity() > existing.Specificity() {
+			byType[rule.TaxType] = rule
+		}
+	}
+
+	sort.Strings(order)
+	resolved := make([]models.TaxRule, 0, len(order))
+	for _, taxType := range order {
+		resolved = append(resolved, byType[taxType])
+	}
+	return resolved
+}
+
+// Breakdown returns the quote's prices to keep with a booking: each night as a line item
+// followed by the other line items, so the items add up to the total
+func (q *Quote) Breakdown() *models.BookingPricing {
+	pricing := &models.BookingPricing{
+		Subtotal:      q.Subtotal,
+		Taxes:         q.Taxes,
+		Fees:          q.Fees,
+		Discounts:     q.Discounts,
+		Credit:        q.Credit,
+		VoucherAmount: q.VoucherAmount,
+		Total:         q.Total,
+	}
+	for _, night := range q.NightlyRates {
+		pricing.Items = append(pricing.Items, models.PriceLineItem{
+			Type:        models.LineItemAccommodation,
+			Description: fmt.Sprintf("Nightly rate %s", night.Date),
+			Amount:      night.BasePrice,
+		})
+	}
+	for _, item := range q.LineItems {
+		if item.Type != models.LineItemAccommodation {
+			pricing.Items = append(pricing.Items, item)
+		}
+	}
+	return pricing
+}
+
+// finalize rounds the totals and derives the nightly average. Discounts and payments
+// never take the total below zero.
+func (q *Quote) finalize() {
+	q.Subtotal = round(q.Subtotal)
+	q.Taxes = round(q.Taxes)
+	q.Fees = round(q.Fees)
+	q.ExtraGuestFees = round(q.ExtraGuestFees)
+	q.Discounts = round(q.Discounts)
+	q.Credit = round(q.Credit)
+	q.VoucherAmount = round(q.VoucherAmount)
+	q.Total = math.Max(round(q.Subtotal+q.Taxes+q.Fees-q.Discounts-q.Credit-q.VoucherAmount), 0)
+	if len(q.NightlyRates) > 0 {
+		q.AveragePerNight = round(q.Total / float64(len(q.NightlyRates)))
+	}
+}
+
+// RedeemPoints pays part of the quote with up to points loyalty points worth pointValue
+// each, never more than the total, and returns the points used. The credit is taken
+// off after taxes, like a payment.
+func (q *Quote) RedeemPoints(points int, pointValue float64) int {
+	if points <= 0 || pointValue <= 0 || q.Total <= 0 {
+		return 0
+	}
+	if covering := int(math.Ceil(q.Total / pointValue)); points > covering {
+		points = covering
+	}
+	credit := math.Min(float64(points)*pointValue, q.Total)
+
+	q.Credit += credit
+	q.PointsRedeemed += points
+	q.LineItems = append(q.LineItems, models.PriceLineItem{
+		Type:        models.LineItemCredit,
+		Description: fmt.Sprintf("Loyalty credit (%d points)", points),
+		Amount:      -round(credit),
+	})
+	q.finalize()
+	return points
+}
+
+// ApplyVoucher pays part of the quote with a voucher's balance, never more than limit,
+// which is ignored when zero, nor than the total, and returns the amount applied. Like
+// loyalty credit, it is taken off after taxes.
+func (q *Quote) ApplyVoucher(voucher models.Voucher, limit float64) float64 {
+	amount := math.Min(voucher.Balance, q.Total)
+	if limit > 0 {
+		amount = math.Min(amount, limit)
+	}
+	amount = round(amount)
+	if amount <= 0 {
+		return 0
+	}
+
+	q.VoucherAmount += amount
+	q.Vouchers = append(q.Vouchers, models.AppliedVoucher{VoucherID: voucher.ID, CodeSuffix: voucher.CodeSuffix, Amount: amount})
+	q.LineItems = append(q.LineItems, models.PriceLineItem{
+		Type:        models.LineItemVoucher,
+		Description: fmt.Sprintf("Voucher ending %s", voucher.CodeSuffix),
+		Amount:      -amount,
+	})
+	q.finalize()
+	return amount
+}
+
+// bestDiscountTier returns the tier with the highest minimum nights the stay qualifies for
+func bestDiscountTier(nights int, discounts []models.LengthOfStayDiscount) *models.LengthOfStayDiscount {
+	var best *models.LengthOfStayDiscount
+	for i := range discounts {
+		tier := &discounts[i]
+		if nights >= tier.MinNights && (best == nil || tier.MinNights > best.MinNights) {
+			best = tier
+		}
+	}
+	return best
+}
+
+// round rounds a monetary amount to cents
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
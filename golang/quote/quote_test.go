@@ -0,0 +1,256 @@
+package quote
+
+import (
+	"testing"
+	"time"
+
+	"channelmanager/models"
+)
+
+var checkin = time.Date(2030, 6, 3, 0, 0, 0, 0, time.UTC)
+
+// stay requests nights nights from checkin for guests guests
+func stay(nights, guests int) Request {
+	return Request{PropertyID: 1, CheckinDate: checkin, CheckoutDate: checkin.AddDate(0, 0, nights), Guests: guests}
+}
+
+// nights prices nights nights from checkin like template
+func nights(n int, template models.Pricing) []models.Pricing {
+	pricing := make([]models.Pricing, n)
+	for i := range pricing {
+		pricing[i] = template
+		pricing[i].Date = checkin.AddDate(0, 0, i)
+	}
+	return pricing
+}
+
+// totals are the amounts of a quote a test checks
+type totals struct {
+	Subtotal, ExtraGuestFees, Taxes, Fees, Discounts, Total, AveragePerNight float64
+}
+
+func totalsOf(q *Quote) totals {
+	return totals{q.Subtotal, q.ExtraGuestFees, q.Taxes, q.Fees, q.Discounts, q.Total, q.AveragePerNight}
+}
+
+func TestCompute(t *testing.T) {
+	cleaning := models.Fee{Name: "Cleaning", Amount: 50, Basis: models.FeePerStay, Mandatory: true, Taxable: true}
+	vat := models.TaxRule{Name: "VAT", TaxType: "vat", Kind: models.TaxKindPercentage, Rate: 10}
+
+	tests := []struct {
+		name     string
+		req      Request
+		pricing  []models.Pricing
+		rules    Rules
+		want     totals
+		missing  int
+		optional int
+	}{
+		{
+			name:    "nightly rates",
+			req:     stay(3, 2),
+			pricing: nights(3, models.Pricing{BasePrice: 100}),
+			want:    totals{Subtotal: 300, Total: 300, AveragePerNight: 100},
+		},
+		{
+			name:    "nights without a price are reported",
+			req:     stay(3, 2),
+			pricing: nights(2, models.Pricing{BasePrice: 100}),
+			want:    totals{Subtotal: 200, Total: 200, AveragePerNight: 100},
+			missing: 1,
+		},
+		{
+			name:    "best length of stay tier",
+			req:     stay(7, 2),
+			pricing: nights(7, models.Pricing{BasePrice: 100}),
+			rules: Rules{Discounts: []models.LengthOfStayDiscount{
+				{MinNights: 5, Percent: 10}, {MinNights: 7, Percent: 15}, {MinNights: 14, Percent: 25},
+			}},
+			want: totals{Subtotal: 700, Discounts: 105, Total: 595, AveragePerNight: 85},
+		},
+		{
+			name:    "stay shorter than every tier",
+			req:     stay(3, 2),
+			pricing: nights(3, models.Pricing{BasePrice: 100}),
+			rules:   Rules{Discounts: []models.LengthOfStayDiscount{{MinNights: 5, Percent: 10}}},
+			want:    totals{Subtotal: 300, Total: 300, AveragePerNight: 100},
+		},
+		{
+			name:    "nightly promotions",
+			req:     stay(2, 2),
+			pricing: nights(2, models.Pricing{BasePrice: 100, Discount: 20}),
+			want:    totals{Subtotal: 200, Discounts: 40, Total: 160, AveragePerNight: 80},
+		},
+		{
+			name:    "extra adults and children beyond the base occupancy",
+			req:     Request{PropertyID: 1, CheckinDate: checkin, CheckoutDate: checkin.AddDate(0, 0, 2), Guests: 5, Children: 1},
+			pricing: nights(2, models.Pricing{BasePrice: 100}),
+			rules:   Rules{Occupancy: models.OccupancyPricing{BaseOccupancy: 2, ExtraAdultFee: 25, ExtraChildFee: 10}},
+			want:    totals{Subtotal: 320, ExtraGuestFees: 120, Total: 320, AveragePerNight: 160},
+		},
+		{
+			name:    "length of stay discount applies to extra guest fees",
+			req:     stay(4, 3),
+			pricing: nights(4, models.Pricing{BasePrice: 100}),
+			rules: Rules{
+				Occupancy: models.OccupancyPricing{BaseOccupancy: 2, ExtraAdultFee: 25},
+				Discounts: []models.LengthOfStayDiscount{{MinNights: 4, Percent: 10}},
+			},
+			want: totals{Subtotal: 500, ExtraGuestFees: 100, Discounts: 50, Total: 450, AveragePerNight: 112.5},
+		},
+		{
+			name:    "percentage taxes after discounts and on taxable fees",
+			req:     stay(4, 2),
+			pricing: nights(4, models.Pricing{BasePrice: 100}),
+			rules: Rules{
+				Discounts: []models.LengthOfStayDiscount{{MinNights: 4, Percent: 10}},
+				Fees:      []models.Fee{cleaning},
+				TaxRules:  []models.TaxRule{vat},
+			},
+			want: totals{Subtotal: 400, Taxes: 41, Fees: 50, Discounts: 40, Total: 451, AveragePerNight: 112.75},
+		},
+		{
+			name:    "untaxed fees and per-night taxes",
+			req:     stay(2, 2),
+			pricing: nights(2, models.Pricing{BasePrice: 100}),
+			rules: Rules{
+				Fees: []models.Fee{{Name: "Resort", Amount: 30, Basis: models.FeePerNight, Mandatory: true}},
+				TaxRules: []models.TaxRule{
+					vat,
+					{Name: "City tax", TaxType: "tourist", Kind: models.TaxKindPerNight, Rate: 5},
+				},
+			},
+			want: totals{Subtotal: 200, Taxes: 30, Fees: 60, Total: 290, AveragePerNight: 145},
+		},
+		{
+			name:     "optional fees are listed but not charged",
+			req:      stay(2, 2),
+			pricing:  nights(2, models.Pricing{BasePrice: 100}),
+			rules:    Rules{Fees: []models.Fee{{Name: "Pet", Amount: 40, Basis: models.FeePerStay}}},
+			want:     totals{Subtotal: 200, Total: 200, AveragePerNight: 100},
+			optional: 1,
+		},
+		{
+			name:    "nightly taxes and fees without tax rules",
+			req:     stay(2, 2),
+			pricing: nights(2, models.Pricing{BasePrice: 100, Taxes: 12, Fees: 8}),
+			want:    totals{Subtotal: 200, Taxes: 24, Fees: 16, Total: 240, AveragePerNight: 120},
+		},
+		{
+			name:    "amounts are rounded to cents",
+			req:     stay(3, 2),
+			pricing: nights(3, models.Pricing{BasePrice: 33.333}),
+			rules:   Rules{TaxRules: []models.TaxRule{{Name: "Sales", TaxType: "sales", Kind: models.TaxKindPercentage, Rate: 7.5}}},
+			want:    totals{Subtotal: 100, Taxes: 7.5, Total: 107.5, AveragePerNight: 35.83},
+		},
+		{
+			name:    "no nights",
+			req:     stay(0, 2),
+			pricing: nil,
+			want:    totals{},
+		},
+		{
+			name:    "discounts beyond the price leave nothing to pay",
+			req:     stay(1, 2),
+			pricing: nights(1, models.Pricing{BasePrice: 50, Discount: 80}),
+			want:    totals{Subtotal: 50, Discounts: 80, Total: 0, AveragePerNight: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := Compute(tt.req, tt.pricing, tt.rules)
+			if got := totalsOf(q); got != tt.want {
+				t.Errorf("Compute() = %+v, want %+v", got, tt.want)
+			}
+			if len(q.MissingNights) != tt.missing {
+				t.Errorf("Compute() missing nights = %v, want %d", q.MissingNights, tt.missing)
+			}
+			if len(q.OptionalFees) != tt.optional {
+				t.Errorf("Compute() optional fees = %v, want %d", q.OptionalFees, tt.optional)
+			}
+		})
+	}
+}
+
+func TestComputeUsesSettings(t *testing.T) {
+	settings := models.DefaultOrganizationSettings(1)
+	settings.CancellationPolicy = models.CancellationStrict
+
+	q := Compute(Request{PropertyID: 1, CheckinDate: checkin, CheckoutDate: checkin.AddDate(0, 0, 1), Settings: &settings},
+		nights(1, models.Pricing{BasePrice: 100}), Rules{})
+	if q.Cancellation.Policy != models.CancellationStrict || q.Cancellation.Terms == "" {
+		t.Errorf("Compute() cancellation = %+v, want the strict policy with its terms", q.Cancellation)
+	}
+}
+
+func TestPayments(t *testing.T) {
+	tests := []struct {
+		name          string
+		points        int
+		voucher       float64
+		limit         float64
+		wantPoints    int
+		wantCredit    float64
+		wantVoucher   float64
+		wantTotal     float64
+		wantLineItems int // after the accommodation line
+	}{
+		{name: "points then voucher", points: 5000, voucher: 30, wantPoints: 5000, wantCredit: 50, wantVoucher: 30, wantTotal: 120, wantLineItems: 2},
+		{name: "points are capped at the total", points: 100000, voucher: 30, wantPoints: 20000, wantCredit: 200, wantTotal: 0, wantLineItems: 1},
+		{name: "voucher is capped at the total", voucher: 500, wantVoucher: 200, wantTotal: 0, wantLineItems: 1},
+		{name: "voucher is capped at the limit", voucher: 500, limit: 75, wantVoucher: 75, wantTotal: 125, wantLineItems: 1},
+		{name: "no payment", wantTotal: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := Compute(stay(2, 2), nights(2, models.Pricing{BasePrice: 100}), Rules{})
+			if used := q.RedeemPoints(tt.points, 0.01); used != tt.wantPoints {
+				t.Errorf("RedeemPoints() = %d, want %d", used, tt.wantPoints)
+			}
+			if tt.voucher > 0 {
+				q.ApplyVoucher(models.Voucher{ID: 1, CodeSuffix: "WXYZ", Balance: tt.voucher}, tt.limit)
+			}
+			if q.Credit != tt.wantCredit || q.VoucherAmount != tt.wantVoucher || q.Total != tt.wantTotal {
+				t.Errorf("credit, voucher, total = %v, %v, %v, want %v, %v, %v",
+					q.Credit, q.VoucherAmount, q.Total, tt.wantCredit, tt.wantVoucher, tt.wantTotal)
+			}
+			if got := len(q.LineItems) - 1; got != tt.wantLineItems {
+				t.Errorf("payment line items = %d, want %d", got, tt.wantLineItems)
+			}
+		})
+	}
+}
+
+func TestComputeConvertsToOrganizationCurrency(t *testing.T) {
+	settings := models.DefaultOrganizationSettings(1)
+	settings.DefaultCurrency = "EUR"
+	req := stay(2, 3)
+	req.Settings = &settings
+	rules := Rules{
+		Occupancy: models.OccupancyPricing{BaseOccupancy: 2, ExtraAdultFee: 25},
+		Fees:      []models.Fee{{Name: "Cleaning", Amount: 50, Basis: models.FeePerStay, Mandatory: true, Taxable: true}},
+		TaxRules: []models.TaxRule{
+			{Name: "VAT", TaxType: "vat", Kind: models.TaxKindPercentage, Rate: 10},
+			{Name: "City tax", TaxType: "tourist", Kind: models.TaxKindPerNight, Rate: 5},
+		},
+		Deposit:      &models.DepositTerms{Amount: 200, Mode: models.DepositModeHold},
+		ExchangeRate: 0.9, // euros per US dollar
+	}
+
+	q := Compute(req, nights(2, models.Pricing{BasePrice: 100}), rules)
+	if q.Currency != "EUR" {
+		t.Errorf("Compute() currency = %q, want EUR", q.Currency)
+	}
+	want := totals{Subtotal: 225, ExtraGuestFees: 45, Taxes: 36, Fees: 45, Total: 306, AveragePerNight: 153}
+	if got := totalsOf(q); got != want {
+		t.Errorf("Compute() = %+v, want %+v", got, want)
+	}
+	if q.NightlyRates[0].BasePrice != 90 || q.Deposit.Amount != 180 {
+		t.Errorf("Compute() nightly rate %v and deposit %v, want 90 and 180", q.NightlyRates[0].BasePrice, q.Deposit.Amount)
+	}
+	if rules.Fees[0].Amount != 50 || rules.TaxRules[1].Rate != 5 || rules.Deposit.Amount != 200 {
+		t.Error("Compute() changed the rules it was given")
+	}
+}
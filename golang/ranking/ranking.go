@@ -0,0 +1,132 @@
+package ranking
+
+import (
+	"math"
+	"sort"
+)
+
+// Weights sets how much each signal contributes to the relevance score. Weights are
+// relative: they are normalized over the signals available for a search.
+type Weights struct {
+	Rating   float64
+	Reviews  float64
+	Price    float64
+	Distance float64
+}
+
+// Candidate holds the ranking signals of one search result
+type Candidate struct {
+	ID          uint     // breaks ties between equally scored candidates
+	Rating      float64  // 0-5 stars
+	ReviewCount int      // number of reviews
+	Price       float64  // nightly price; zero when unknown
+	Distance    *float64 // km from the searched point; nil when no point was given
+}
+
+// maxRating is the top of the rating scale
+const maxRating = 5.0
+
+// Scores returns a relevance score between 0 and 1 for every candidate.
+//
+// Signals are normalized across the candidates: rating against the 5-star scale,
+// review count logarithmically against the most reviewed candidate, price as how
+// far below the most expensive candidate it is, and distance as how much closer
+// than the farthest candidate. Candidates with an unknown price get a neutral
+// price score, and distance only counts when every candidate has one.
+func Scores(candidates []Candidate, weights Weights) []float64 {
+	scores := make([]float64, len(candidates))
+	if len(candidates) == 0 {
+		return scores
+	}
+
+	maxReviews := 0
+	minPrice, maxPrice := math.Inf(1), math.Inf(-1)
+	maxDistance := 0.0
+	hasDistance := true
+	for _, c := range candidates {
+		if c.ReviewCount > maxReviews {
+			maxReviews = c.ReviewCount
+		}
+		if c.Price > 0 {
+			minPrice = math.Min(minPrice, c.Price)
+			maxPrice = math.Max(maxPrice, c.Price)
+		}
+		if c.Distance == nil {
+			hasDistance = false
+		} else {
+			maxDistance = math.Max(maxDistance, *c.Distance)
+		}
+	}
+
+	distanceWeight := weights.Distance
+	if !hasDistance {
+		distanceWeight = 0
+	}
+	total := weights.Rating + weights.Reviews + weights.Price + distanceWeight
+	if total <= 0 {
+		return scores
+	}
+
+	for i, c := range candidates {
+		score := weights.Rating * clamp(c.Rating/maxRating)
+
+		if maxReviews > 0 {
+			score += weights.Reviews * math.Log1p(float64(c.ReviewCount)) / math.Log1p(float64(maxReviews))
+		}
+
+		score += weights.Price * priceScore(c.Price, minPrice, maxPrice)
+
+		if distanceWeight > 0 {
+			if maxDistance > 0 {
+				score += distanceWeight * (1 - *c.Distance/maxDistance)
+			} else {
+				score += distanceWeight
+			}
+		}
+
+		scores[i] = score / total
+	}
+	return scores
+}
+
+// Order returns the indexes of the candidates from the highest score to the lowest.
+// Equal scores go to the candidate with more reviews, then the better rated one, then
+// the lower ID, so pages of a ranked search do not depend on the order candidates were
+// loaded in.
+func Order(candidates []Candidate, scores []float64) []int {
+	order := make([]int, len(candidates))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		switch {
+		case scores[a] != scores[b]:
+			return scores[a] > scores[b]
+		case candidates[a].ReviewCount != candidates[b].ReviewCount:
+			return candidates[a].ReviewCount > candidates[b].ReviewCount
+		case candidates[a].Rating != candidates[b].Rating:
+			return candidates[a].Rating > candidates[b].Rating
+		default:
+			return candidates[a].ID < candidates[b].ID
+		}
+	})
+	return order
+}
+
+// priceScore rates a price from 1 (cheapest candidate) to 0 (most expensive)
+func priceScore(price, minPrice, maxPrice float64) float64 {
+	switch {
+	case price <= 0:
+		return 0.5
+	case maxPrice <= minPrice:
+		return 1
+	default:
+		return (maxPrice - price) / (maxPrice - minPrice)
+	}
+}
+
+// clamp limits a value to the range 0-1
+func clamp(value float64) float64 {
+	return math.Max(0, math.Min(1, value))
+}
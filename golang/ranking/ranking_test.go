@@ -0,0 +1,238 @@
+package ranking
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func km(d float64) *float64 {
+	return &d
+}
+
+func TestScores(t *testing.T) {
+	tests := []struct {
+		name       string
+		weights    Weights
+		candidates []Candidate
+		want       []float64
+	}{
+		{
+			name:    "rating against the 5-star scale",
+			weights: Weights{Rating: 1},
+			candidates: []Candidate{
+				{Rating: 5},
+				{Rating: 2.5},
+				{Rating: 7},
+			},
+			want: []float64{1, 0.5, 1},
+		},
+		{
+			name:    "missing ratings score zero",
+			weights: Weights{Rating: 1, Reviews: 1},
+			candidates: []Candidate{
+				{Rating: 4},
+				{Rating: 0},
+			},
+			want: []float64{0.4, 0},
+		},
+		{
+			name:    "review count is logarithmic",
+			weights: Weights{Reviews: 1},
+			candidates: []Candidate{
+				{ReviewCount: 99},
+				{ReviewCount: 9},
+				{ReviewCount: 0},
+			},
+			want: []float64{1, 0.5, 0},
+		},
+		{
+			name:    "weights are relative",
+			weights: Weights{Rating: 3, Reviews: 1},
+			candidates: []Candidate{
+				{Rating: 5, ReviewCount: 0},
+				{Rating: 0, ReviewCount: 10},
+			},
+			want: []float64{0.75, 0.25},
+		},
+		{
+			name:    "cheapest price scores highest and unknown prices are neutral",
+			weights: Weights{Price: 1},
+			candidates: []Candidate{
+				{Price: 100},
+				{Price: 150},
+				{Price: 200},
+				{Price: 0},
+			},
+			want: []float64{1, 0.5, 0, 0.5},
+		},
+		{
+			name:    "equal prices all score highest",
+			weights: Weights{Price: 1},
+			candidates: []Candidate{
+				{Price: 120},
+				{Price: 120},
+			},
+			want: []float64{1, 1},
+		},
+		{
+			name:    "closer is better",
+			weights: Weights{Distance: 1},
+			candidates: []Candidate{
+				{Distance: km(0)},
+				{Distance: km(5)},
+				{Distance: km(10)},
+			},
+			want: []float64{1, 0.5, 0},
+		},
+		{
+			name:    "candidates at the searched point all score highest",
+			weights: Weights{Distance: 1},
+			candidates: []Candidate{
+				{Distance: km(0)},
+				{Distance: km(0)},
+			},
+			want: []float64{1, 1},
+		},
+		{
+			name:    "distance is ignored unless every candidate has one",
+			weights: Weights{Rating: 1, Distance: 1},
+			candidates: []Candidate{
+				{Rating: 5, Distance: km(10)},
+				{Rating: 2.5},
+			},
+			want: []float64{1, 0.5},
+		},
+		{
+			name:    "all signals",
+			weights: Weights{Rating: 0.4, Reviews: 0.2, Price: 0.2, Distance: 0.2},
+			candidates: []Candidate{
+				{Rating: 5, ReviewCount: 99, Price: 100, Distance: km(0)},
+				{Rating: 2.5, ReviewCount: 9, Price: 200, Distance: km(4)},
+			},
+			want: []float64{1, 0.4*0.5 + 0.2*0.5},
+		},
+		{
+			name:    "no weights",
+			weights: Weights{},
+			candidates: []Candidate{
+				{Rating: 5, ReviewCount: 10, Price: 100},
+			},
+			want: []float64{0},
+		},
+		{
+			name:       "no candidates",
+			weights:    Weights{Rating: 1},
+			candidates: nil,
+			want:       []float64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Scores(tt.candidates, tt.weights)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Scores() returned %d scores, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Errorf("Scores()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []Candidate
+		scores     []float64
+		want       []int
+	}{
+		{
+			name:       "highest score first",
+			candidates: []Candidate{{ID: 1}, {ID: 2}, {ID: 3}},
+			scores:     []float64{0.2, 0.9, 0.5},
+			want:       []int{1, 2, 0},
+		},
+		{
+			name:       "ties go to more reviews",
+			candidates: []Candidate{{ID: 1, ReviewCount: 3}, {ID: 2, ReviewCount: 8}},
+			scores:     []float64{0.5, 0.5},
+			want:       []int{1, 0},
+		},
+		{
+			name:       "then to the better rating",
+			candidates: []Candidate{{ID: 1, ReviewCount: 8, Rating: 4.1}, {ID: 2, ReviewCount: 8, Rating: 4.6}},
+			scores:     []float64{0.5, 0.5},
+			want:       []int{1, 0},
+		},
+		{
+			name:       "then to the lower ID whatever the input order",
+			candidates: []Candidate{{ID: 9, Rating: 4}, {ID: 4, Rating: 4}, {ID: 7, Rating: 4}},
+			scores:     []float64{0.5, 0.5, 0.5},
+			want:       []int{1, 2, 0},
+		},
+		{
+			name:       "score outweighs reviews and rating",
+			candidates: []Candidate{{ID: 1, ReviewCount: 500, Rating: 5}, {ID: 2}},
+			scores:     []float64{0.4, 0.6},
+			want:       []int{1, 0},
+		},
+		{
+			name:       "no candidates",
+			candidates: nil,
+			scores:     nil,
+			want:       []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Order(tt.candidates, tt.scores); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Order() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// benchmarkCandidates returns n candidates with signals spread like a city's listings
+func benchmarkCandidates(n int) []Candidate {
+	random := rand.New(rand.NewSource(1))
+	candidates := make([]Candidate, n)
+	for i := range candidates {
+		candidates[i] = Candidate{
+			ID:          uint(i + 1),
+			Rating:      float64(random.Intn(21)+30) / 10,
+			ReviewCount: random.Intn(400),
+			Price:       float64(80 + random.Intn(400)),
+			Distance:    km(random.Float64() * 20),
+		}
+	}
+	return candidates
+}
+
+var benchmarkWeights = Weights{Rating: 0.4, Reviews: 0.2, Price: 0.25, Distance: 0.15}
+
+func BenchmarkScores(b *testing.B) {
+	candidates := benchmarkCandidates(500)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Scores(candidates, benchmarkWeights)
+	}
+}
+
+func BenchmarkOrder(b *testing.B) {
+	candidates := benchmarkCandidates(500)
+	scores := Scores(candidates, benchmarkWeights)
+	// Rounded like reported scores, so ties go to the tie-breaks
+	for i := range scores {
+		scores[i] = math.Round(scores[i]*100) / 100
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Order(candidates, scores)
+	}
+}
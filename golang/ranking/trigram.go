@@ -0,0 +1,40 @@
+package ranking
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Similarity returns the trigram similarity between 0 and 1 of two strings, computed
+// the way PostgreSQL's pg_trgm similarity() does: both strings are lower-cased and
+// split into words, each word is padded with two leading spaces and one trailing
+// space, and the result is the shared trigrams over all distinct trigrams.
+func Similarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// trigrams returns the set of padded word trigrams of s
+func trigrams(s string) map[string]bool {
+	set := make(map[string]bool)
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
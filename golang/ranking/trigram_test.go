@@ -0,0 +1,17 @@
+package ranking
+
+import "testing"
+
+func BenchmarkSimilarity(b *testing.B) {
+	pairs := [][2]string{
+		{"new york", "New York, NY"},
+		{"san fransisco", "San Francisco, CA"},
+		{"malibu beach", "Malibu, CA"},
+		{"lake tahoe cabin", "South Lake Tahoe, CA"},
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		pair := pairs[i%len(pairs)]
+		Similarity(pair[0], pair[1])
+	}
+}
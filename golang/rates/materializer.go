@@ -0,0 +1,187 @@
+package rates
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// Materializer generates nightly Pricing rows from a property's base rate, seasons and
+// the holidays and events of its destination
+type Materializer struct {
+	propertyRepo    *database.PropertyRepository
+	pricingRepo     *database.PricingRepository
+	seasonRepo      *database.SeasonRepository
+	eventRepo       *database.EventRepository
+	destinationRepo *database.DestinationEventRepository
+}
+
+// NewMaterializer creates a new pricing materializer
+func NewMaterializer(db *gorm.DB) *Materializer {
+	return &Materializer{
+		propertyRepo:    database.NewPropertyRepository(db),
+		pricingRepo:     database.NewPricingRepository(db),
+		seasonRepo:      database.NewSeasonRepository(db),
+		eventRepo:       database.NewEventRepository(db),
+		destinationRepo: database.NewDestinationEventRepository(db),
+	}
+}
+
+// MaterializeSeason regenerates pricing for every night covered by a season
+func (m *Materializer) MaterializeSeason(season models.Season) (int, error) {
+	return m.MaterializeRange(season.PropertyID, season.StartDate, season.EndDate)
+}
+
+// MaterializeRange regenerates the base price of every night in [start, end] for a property.
+// Existing rows keep their taxes, fees and discounts; only changed rows are written and
+// each one emits a pricing event so caches and channels pick up the new rate.
+func (m *Materializer) MaterializeRange(propertyID uint, start, end time.Time) (int, error) {
+	property, err := m.propertyRepo.GetPropertyByID(propertyID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load property: %w", err)
+	}
+	if property.BaseNightlyRate <= 0 {
+		return 0, fmt.Errorf("property %d has no base nightly rate", propertyID)
+	}
+
+	seasons, err := m.seasonRepo.GetSeasonsOverlapping(propertyID, start, end)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load seasons: %w", err)
+	}
+
+	demand, err := m.destinationRepo.GetDestinationEventsForProperty(*property, start, end)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load destination events: %w", err)
+	}
+
+	existing, err := m.pricingRepo.GetPricingForDateRange(propertyID, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to load pricing: %w", err)
+	}
+	existingByDate := make(map[string]models.Pricing, len(existing))
+	for _, p := range existing {
+		existingByDate[p.Date.Format("2006-01-02")] = p
+	}
+
+	var changed []models.Pricing
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		price := NightlyRate(*property, seasons, demand, d)
+
+		row, ok := existingByDate[d.Format("2006-01-02")]
+		if ok && row.BasePrice == price {
+			continue
+		}
+		if !ok {
+			row = models.Pricing{PropertyID: propertyID, Date: d}
+		}
+		row.BasePrice = price
+		changed = append(changed, row)
+	}
+
+	if err := m.pricingRepo.SavePricing(changed); err != nil {
+		return 0, fmt.Errorf("failed to save pricing: %w", err)
+	}
+
+	events := make([]models.Event, 0, len(changed))
+	for _, row := range changed {
+		data, err := json.Marshal(row)
+		if err != nil {
+			continue
+		}
+		events = append(events, models.Event{
+			EventType: "UPDATE",
+			TableName: "pricing",
+			RecordID:  row.ID,
+			Data:      data,
+			Source:    models.EventSourceRates,
+		})
+	}
+	if err := m.eventRepo.CreateEvents(events); err != nil {
+		log.Printf("Failed to record pricing events for property %d: %v", propertyID, err)
+	}
+
+	log.Printf("Materialized %d pricing rows for property %d", len(changed), propertyID)
+	return len(changed), nil
+}
+
+// MaterializeCity regenerates the base price of every night in [start, end] for the
+// rated properties of a city, e.g. after its holidays and events changed. A property
+// failing to materialize does not stop the others; the first error is returned.
+func (m *Materializer) MaterializeCity(city, country string, start, end time.Time) (int, error) {
+	ids, err := m.propertyRepo.GetRatedPropertyIDsInCity(city, country)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load properties: %w", err)
+	}
+
+	var firstErr error
+	total := 0
+	for _, id := range ids {
+		count, err := m.MaterializeRange(id, start, end)
+		if err != nil {
+			log.Printf("Failed to materialize pricing for property %d: %v", id, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		total += count
+	}
+	return total, firstErr
+}
+
+// NightlyRate computes the base price of a night from the property's base rate,
+// the weekend multiplier, the highest-priority season covering the night and the
+// strongest demand multiplier of the destination's events that night
+func NightlyRate(property models.Property, seasons []models.Season, events []models.DestinationEvent, date time.Time) float64 {
+	rate := property.BaseNightlyRate
+
+	if weekday := date.Weekday(); (weekday == time.Friday || weekday == time.Saturday) && property.WeekendMultiplier > 0 {
+		rate *= property.WeekendMultiplier
+	}
+
+	if season := activeSeason(seasons, date); season != nil {
+		rate *= season.Multiplier
+	}
+
+	if multiplier := demandMultiplier(events, date); multiplier > 0 {
+		rate *= multiplier
+	}
+
+	return math.Round(rate*100) / 100
+}
+
+// activeSeason returns the season governing a night; on equal priority the shorter season wins
+func activeSeason(seasons []models.Season, date time.Time) *models.Season {
+	var active *models.Season
+	for i := range seasons {
+		season := &seasons[i]
+		if !season.Covers(date) {
+			continue
+		}
+		if active == nil ||
+			season.Priority > active.Priority ||
+			(season.Priority == active.Priority && season.EndDate.Sub(season.StartDate) < active.EndDate.Sub(active.StartDate)) {
+			active = season
+		}
+	}
+	return active
+}
+
+// demandMultiplier returns the highest demand multiplier of the events covering a night;
+// overlapping events do not compound. It is 0 when no event covers the night.
+func demandMultiplier(events []models.DestinationEvent, date time.Time) float64 {
+	multiplier := 0.0
+	for _, event := range events {
+		if event.Covers(date) && event.DemandMultiplier > multiplier {
+			multiplier = event.DemandMultiplier
+		}
+	}
+	return multiplier
+}
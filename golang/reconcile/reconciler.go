@@ -0,0 +1,75 @@
+package reconcile
+
+import (
+	"log"
+	"time"
+
+	"channelmanager/database"
+	"channelmanager/models"
+
+	"gorm.io/gorm"
+)
+
+// Config holds inventory reconciliation configuration
+type Config struct {
+	Interval      time.Duration
+	LookaheadDays int
+}
+
+// Reconciler cross-checks confirmed bookings, including those reported by
+// channels, against each other and against availability, recording conflicts
+type Reconciler struct {
+	config       Config
+	conflictRepo *database.ConflictRepository
+}
+
+// NewReconciler creates a new inventory reconciler
+func NewReconciler(db *gorm.DB, config Config) *Reconciler {
+	return &Reconciler{
+		config:       config,
+		conflictRepo: database.NewConflictRepository(db),
+	}
+}
+
+// Reconcile records overbookings and booked nights that are still open for the upcoming
+// nights, resolves conflicts that are gone, and returns the number of open conflicts
+func (r *Reconciler) Reconcile() (int, error) {
+	now := time.Now()
+	start := models.EarliestToday()
+	end := start.AddDate(0, 0, r.config.LookaheadDays)
+
+	overbookings, err := r.conflictRepo.FindOverbookings(start, end)
+	if err != nil {
+		return 0, err
+	}
+	openNights, err := r.conflictRepo.FindOpenBookedNights(start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	found := 0
+	record := func(conflicts []models.Conflict, conflictType string) {
+		for i := range conflicts {
+			conflict := &conflicts[i]
+			conflict.Type = conflictType
+			conflict.Status = models.ConflictStatusOpen
+			conflict.DetectedAt = now
+			conflict.LastCheckedAt = now
+			if err := r.conflictRepo.UpsertConflict(conflict); err != nil {
+				log.Printf("Failed to record %s conflict for booking %d: %v", conflictType, conflict.BookingID, err)
+				continue
+			}
+			found++
+		}
+	}
+	record(overbookings, models.ConflictOverbooking)
+	record(openNights, models.ConflictOpenInventory)
+
+	cleared, err := r.conflictRepo.ClearStaleConflicts(now)
+	if err != nil {
+		return found, err
+	}
+
+	log.Printf("Inventory reconciliation found %d conflicts, cleared %d", found, cleared)
+	return found, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"channelmanager/config"
+	"channelmanager/database"
+	"channelmanager/utils"
+)
+
+// runSeed implements the seed subcommand, which fills the configured database with the
+// sample data and optionally a volume of synthetic properties:
+//
+//	channelmanager seed -properties 10000 -cities "Paris,Lisbon" -days 180
+func runSeed(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	properties := flags.Int("properties", 0, "number of synthetic properties to generate")
+	cities := flags.String("cities", "", "comma-separated cities to generate properties in (default all)")
+	days := flags.Int("days", 90, "days of availability and pricing to generate from today")
+	batchSize := flags.Int("batch-size", 1000, "rows per insert statement")
+	randSeed := flags.Int64("rand-seed", time.Now().UnixNano(), "seed for the generated content")
+	flags.Parse(args)
+
+	if *properties < 0 || *days < 1 || *batchSize < 1 {
+		return fmt.Errorf("-properties must not be negative, -days and -batch-size must be positive")
+	}
+
+	db, err := database.InitializeDatabase(cfg.Database)
+	if err != nil {
+		return err
+	}
+	if *properties == 0 {
+		return utils.SeedDatabase(db)
+	}
+
+	var cityNames []string
+	for _, city := range strings.Split(*cities, ",") {
+		if city = strings.TrimSpace(city); city != "" {
+			cityNames = append(cityNames, city)
+		}
+	}
+	return utils.SeedSynthetic(db, utils.SeedOptions{
+		Properties:  *properties,
+		Cities:      cityNames,
+		HorizonDays: *days,
+		BatchSize:   *batchSize,
+		RandSeed:    *randSeed,
+	})
+}
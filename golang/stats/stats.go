@@ -0,0 +1,52 @@
+package stats
+
+import (
+	"context"
+	"time"
+
+	"channelmanager/cache"
+	"channelmanager/database"
+
+	"gorm.io/gorm"
+)
+
+// Config holds property statistics configuration
+type Config struct {
+	FlushInterval time.Duration // how often Redis counters are written to Postgres
+}
+
+// Flusher writes the property view and impression counters kept in Redis to Postgres
+type Flusher struct {
+	redis     *cache.RedisClient
+	statsRepo *database.StatsRepository
+}
+
+// NewFlusher creates a new statistics flusher
+func NewFlusher(db *gorm.DB, redis *cache.RedisClient) *Flusher {
+	return &Flusher{
+		redis:     redis,
+		statsRepo: database.NewStatsRepository(db),
+	}
+}
+
+// Flush adds the counters to the daily statistics and returns the number of property
+// days written. Counters are only deleted once stored, so those of a failed flush are
+// written by the next one.
+func (f *Flusher) Flush(ctx context.Context) (int, error) {
+	claimed, err := f.redis.ClaimStatCounters(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	for _, counters := range claimed {
+		if err := f.statsRepo.AddPropertyStats(counters.Stat, counters.Date, counters.Counts); err != nil {
+			return written, err
+		}
+		if err := f.redis.ReleaseStatCounters(ctx, counters); err != nil {
+			return written, err
+		}
+		written += len(counters.Counts)
+	}
+	return written, nil
+}
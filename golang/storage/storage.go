@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrObjectNotFound is returned when an object does not exist in the store
+var ErrObjectNotFound = errors.New("object not found")
+
+// Config holds object storage configuration
+type Config struct {
+	Driver    string // currently only "local" is supported
+	LocalPath string
+}
+
+// ObjectStore stores and retrieves binary objects such as invoices and feeds
+type ObjectStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewObjectStore creates an object store for the configured driver
+func NewObjectStore(config Config) (ObjectStore, error) {
+	switch config.Driver {
+	case "", "local":
+		return NewLocalStore(config.LocalPath)
+	default:
+		return nil, fmt.Errorf("unsupported storage driver: %s", config.Driver)
+	}
+}
+
+// LocalStore is an ObjectStore backed by the local filesystem
+type LocalStore struct {
+	basePath string
+}
+
+// NewLocalStore creates a filesystem-backed object store rooted at basePath
+func NewLocalStore(basePath string) (*LocalStore, error) {
+	if err := os.MkdirAll(basePath, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &LocalStore{basePath: basePath}, nil
+}
+
+// Put writes an object to disk, creating intermediate directories
+func (s *LocalStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Get reads an object from disk
+func (s *LocalStore) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// Delete removes an object from disk
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// resolve maps an object key to a path inside the base directory
+func (s *LocalStore) resolve(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if cleaned == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid object key: %s", key)
+	}
+	return filepath.Join(s.basePath, cleaned), nil
+}
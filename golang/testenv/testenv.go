@@ -0,0 +1,163 @@
+// Package testenv starts disposable PostgreSQL and Redis containers in Docker for test
+// mode and for tests that need the real services
+package testenv
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrDockerUnavailable is returned by Start when no Docker daemon can be reached
+var ErrDockerUnavailable = errors.New("docker is not available")
+
+// Images of the containers, matching the test profile of docker-compose.yml
+const (
+	postgresImage = "postgres"
+	redisImage    = "redis"
+	imageTag      = "alpine"
+)
+
+// Credentials of the PostgreSQL container
+const (
+	PostgresUser     = "postgres"
+	PostgresPassword = "postgres123"
+	PostgresDB       = "channel_manager_test"
+)
+
+// startTimeout bounds how long the containers may take to accept connections
+const startTimeout = 2 * time.Minute
+
+// Env is a PostgreSQL and Redis pair running in containers that are removed on Close.
+// Their data lives in memory, so every Env starts empty.
+type Env struct {
+	PostgresHost string
+	PostgresPort int
+	RedisHost    string
+	RedisPort    int
+
+	pool      *dockertest.Pool
+	resources []*dockertest.Resource
+}
+
+// Start runs the containers and waits until both accept connections. It returns
+// ErrDockerUnavailable when Docker cannot be reached, so callers can skip instead.
+func Start() (*Env, error) {
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDockerUnavailable, err)
+	}
+	if err := pool.Client.Ping(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDockerUnavailable, err)
+	}
+	pool.MaxWait = startTimeout
+
+	env := &Env{pool: pool}
+	postgres, err := env.run(&dockertest.RunOptions{
+		Repository: postgresImage,
+		Tag:        imageTag,
+		Env: []string{
+			"POSTGRES_USER=" + PostgresUser,
+			"POSTGRES_PASSWORD=" + PostgresPassword,
+			"POSTGRES_DB=" + PostgresDB,
+		},
+	}, "/var/lib/postgresql/data")
+	if err != nil {
+		return nil, err
+	}
+	if env.PostgresHost, env.PostgresPort, err = hostPort(postgres, "5432/tcp"); err != nil {
+		env.Close()
+		return nil, err
+	}
+
+	redisContainer, err := env.run(&dockertest.RunOptions{Repository: redisImage, Tag: imageTag}, "/data")
+	if err != nil {
+		env.Close()
+		return nil, err
+	}
+	if env.RedisHost, env.RedisPort, err = hostPort(redisContainer, "6379/tcp"); err != nil {
+		env.Close()
+		return nil, err
+	}
+
+	if err := pool.Retry(env.pingPostgres); err != nil {
+		env.Close()
+		return nil, fmt.Errorf("postgres did not start: %w", err)
+	}
+	if err := pool.Retry(env.pingRedis); err != nil {
+		env.Close()
+		return nil, fmt.Errorf("redis did not start: %w", err)
+	}
+	return env, nil
+}
+
+// PostgresDSN returns the connection string of the PostgreSQL container's database
+func (e *Env) PostgresDSN() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+		e.PostgresHost, e.PostgresPort, PostgresUser, PostgresPassword, PostgresDB)
+}
+
+// Close removes the containers and their data
+func (e *Env) Close() error {
+	var errs []error
+	for _, resource := range e.resources {
+		errs = append(errs, e.pool.Purge(resource))
+	}
+	e.resources = nil
+	return errors.Join(errs...)
+}
+
+// run starts a container removed once stopped, keeping its data directory in memory
+func (e *Env) run(options *dockertest.RunOptions, dataDir string) (*dockertest.Resource, error) {
+	resource, err := e.pool.RunWithOptions(options, func(config *docker.HostConfig) {
+		config.AutoRemove = true
+		config.RestartPolicy = docker.RestartPolicy{Name: "no"}
+		config.Tmpfs = map[string]string{dataDir: ""}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", options.Repository, err)
+	}
+	e.resources = append(e.resources, resource)
+	return resource, nil
+}
+
+// pingPostgres checks that the PostgreSQL container accepts connections
+func (e *Env) pingPostgres() error {
+	db, err := sql.Open("postgres", e.PostgresDSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Ping()
+}
+
+// pingRedis checks that the Redis container accepts connections
+func (e *Env) pingRedis() error {
+	client := redis.NewClient(&redis.Options{Addr: net.JoinHostPort(e.RedisHost, strconv.Itoa(e.RedisPort))})
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return client.Ping(ctx).Err()
+}
+
+// hostPort returns where a container's port is published
+func hostPort(resource *dockertest.Resource, port string) (string, int, error) {
+	host, published, err := net.SplitHostPort(resource.GetHostPort(port))
+	if err != nil {
+		return "", 0, fmt.Errorf("port %s of %s is not published: %w", port, resource.Container.Name, err)
+	}
+	number, err := strconv.Atoi(published)
+	if err != nil {
+		return "", 0, fmt.Errorf("port %s of %s is not published: %w", port, resource.Container.Name, err)
+	}
+	return host, number, nil
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"context"
+
+	"channelmanager/cache"
+	"channelmanager/database"
+
+	"gorm.io/gorm"
+)
+
+// fixtureRandSeed fixes the generated fixture content across runs
+const fixtureRandSeed = 20240101
+
+// FixtureOptions are the synthetic properties loaded as test fixtures
+var FixtureOptions = SeedOptions{
+	Properties:  20,
+	Cities:      []string{"New York", "Malibu", "Paris", "Lisbon"},
+	HorizonDays: 90,
+	BatchSize:   1000,
+	RandSeed:    fixtureRandSeed,
+}
+
+// ResetTestData empties the database and the Redis cache and loads the test fixtures:
+// the sample data of SeedDatabase followed by FixtureOptions. IDs and content are the
+// same after every reset; dates are relative to today. It refuses a database whose name
+// does not end in _test and a Redis database holding data it did not put there.
+func ResetTestData(ctx context.Context, db *gorm.DB, redis *cache.RedisClient) error {
+	if err := database.ResetDatabase(db); err != nil {
+		return err
+	}
+	if err := redis.FlushTestDB(ctx); err != nil {
+		return err
+	}
+	if err := SeedDatabase(db); err != nil {
+		return err
+	}
+	return SeedSynthetic(db, FixtureOptions)
+}
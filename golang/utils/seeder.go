@@ -1,19 +1,18 @@
 package utils
 
 import (
-	"context"
+	"fmt"
 	"log"
 	"time"
 
 	"channelmanager/models"
+	"channelmanager/rates"
 
 	"gorm.io/gorm"
 )
 
 // SeedDatabase seeds initial data for development/testing
 func SeedDatabase(db *gorm.DB) error {
-	ctx := context.Background()
-
 	// Check if data already exists
 	var count int64
 	if err := db.Model(&models.Amenity{}).Count(&count).Error; err != nil {
@@ -63,27 +62,56 @@ func SeedDatabase(db *gorm.DB) error {
 	}
 	log.Println("Created conditions")
 
+	// Create star classes
+	starRatings := make([]models.PropertyRating, 0, 5)
+	for stars := 1; stars <= 5; stars++ {
+		starRatings = append(starRatings, models.PropertyRating{Name: fmt.Sprintf("%d-star", stars), Stars: stars})
+	}
+	if err := db.Create(&starRatings).Error; err != nil {
+		return err
+	}
+	log.Println("Created star ratings")
+
 	// Create sample properties
 	properties := []models.Amenity{}
 	if err := db.Find(&properties).Error; err != nil {
 		return err
 	}
 
+	// Create the organization owning the sample properties
+	organization := models.Organization{
+		Name:            "Sample Hosts",
+		SellerLegalName: "Sample Hosts LLC",
+		SellerAddress:   "1 Market Street, San Francisco, CA",
+		SellerEmail:     "billing@samplehosts.example",
+		InvoicePrefix:   "SH",
+	}
+	if err := db.Create(&organization).Error; err != nil {
+		return err
+	}
+	log.Printf("Created organization: %s", organization.Name)
+
 	prop1 := models.Property{
-		ChannelID:   "ch_001",
-		Name:        "Luxury Beach Villa",
-		Description: "Beautiful beachfront villa with stunning ocean views",
-		Location:    "Malibu, CA",
-		City:        "Malibu",
-		State:       "CA",
-		Country:     "USA",
-		Latitude:    34.0195,
-		Longitude:   -118.6819,
-		MaxGuests:   8,
-		Bedrooms:    4,
-		Bathrooms:   3,
-		Rating:      4.8,
-		ReviewCount: 125,
+		ChannelID:      "ch_001",
+		OrganizationID: &organization.ID,
+		Name:           "Luxury Beach Villa",
+		Description:    "Beautiful beachfront villa with stunning ocean views",
+		Location:       "Malibu, CA",
+		City:           "Malibu",
+		State:          "CA",
+		Country:        "USA",
+		Timezone:       "America/Los_Angeles",
+		Latitude:       34.0195,
+		Longitude:      -118.6819,
+		MaxGuests:      8,
+		Bedrooms:       4,
+		Bathrooms:      3,
+		Rating:         4.8,
+		ReviewCount:    125,
+		RatingID:       &starRatings[4].ID,
+
+		BaseNightlyRate:   500,
+		WeekendMultiplier: 1.4,
 	}
 
 	if err := db.Create(&prop1).Error; err != nil {
@@ -92,20 +120,26 @@ func SeedDatabase(db *gorm.DB) error {
 	log.Printf("Created property: %s", prop1.Name)
 
 	prop2 := models.Property{
-		ChannelID:   "ch_002",
-		Name:        "Downtown Apartment",
-		Description: "Modern apartment in the heart of the city",
-		Location:    "New York, NY",
-		City:        "New York",
-		State:       "NY",
-		Country:     "USA",
-		Latitude:    40.7128,
-		Longitude:   -74.0060,
-		MaxGuests:   4,
-		Bedrooms:    2,
-		Bathrooms:   2,
-		Rating:      4.5,
-		ReviewCount: 89,
+		ChannelID:      "ch_002",
+		OrganizationID: &organization.ID,
+		Name:           "Downtown Apartment",
+		Description:    "Modern apartment in the heart of the city",
+		Location:       "New York, NY",
+		City:           "New York",
+		State:          "NY",
+		Country:        "USA",
+		Timezone:       "America/New_York",
+		Latitude:       40.7128,
+		Longitude:      -74.0060,
+		MaxGuests:      4,
+		Bedrooms:       2,
+		Bathrooms:      2,
+		Rating:         4.5,
+		ReviewCount:    89,
+		RatingID:       &starRatings[3].ID,
+
+		BaseNightlyRate:   200,
+		WeekendMultiplier: 1.4,
 	}
 
 	if err := db.Create(&prop2).Error; err != nil {
@@ -141,21 +175,32 @@ func SeedDatabase(db *gorm.DB) error {
 	}
 	log.Println("Created availability records")
 
-	// Create pricing for next 90 days
+	// Summer high season for the beach villa
+	summer := models.Season{
+		PropertyID: prop1.ID,
+		Name:       "Summer",
+		StartDate:  time.Date(now.Year(), time.June, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:    time.Date(now.Year(), time.August, 31, 0, 0, 0, 0, time.UTC),
+		Multiplier: 1.25,
+		Priority:   1,
+	}
+	if err := db.Create(&summer).Error; err != nil {
+		return err
+	}
+	log.Printf("Created season: %s", summer.Name)
+
+	// Create pricing for next 90 days from base rates and seasons
 	for i := 0; i < 90; i++ {
 		date := now.AddDate(0, 0, i)
 
 		// Pricing for property 1
-		basePrice := 500.0
-		if date.Weekday() == 0 || date.Weekday() == 6 { // Weekend
-			basePrice = 700.0
-		}
+		basePrice := rates.NightlyRate(prop1, []models.Season{summer}, nil, date)
 
 		pricing1 := models.Pricing{
 			PropertyID: prop1.ID,
 			Date:       date,
 			BasePrice:  basePrice,
-			Taxes:      basePrice * 0.1,
+			Taxes:      0,
 			Fees:       basePrice * 0.05,
 			Discount:   0,
 		}
@@ -164,16 +209,13 @@ func SeedDatabase(db *gorm.DB) error {
 		}
 
 		// Pricing for property 2
-		basePrice2 := 200.0
-		if date.Weekday() == 0 || date.Weekday() == 6 { // Weekend
-			basePrice2 = 280.0
-		}
+		basePrice2 := rates.NightlyRate(prop2, nil, nil, date)
 
 		pricing2 := models.Pricing{
 			PropertyID: prop2.ID,
 			Date:       date,
 			BasePrice:  basePrice2,
-			Taxes:      basePrice2 * 0.1,
+			Taxes:      0,
 			Fees:       basePrice2 * 0.05,
 			Discount:   0,
 		}
@@ -183,6 +225,19 @@ func SeedDatabase(db *gorm.DB) error {
 	}
 	log.Println("Created pricing records")
 
+	// Taxes are resolved per jurisdiction at quote time
+	taxRules := []models.TaxRule{
+		{Name: "California Transient Occupancy Tax", Country: "USA", State: "CA", TaxType: "occupancy", Kind: models.TaxKindPercentage, Rate: 10, Active: true},
+		{Name: "Malibu Transient Occupancy Tax", Country: "USA", State: "CA", City: "Malibu", TaxType: "occupancy", Kind: models.TaxKindPercentage, Rate: 12, Active: true},
+		{Name: "New York State Sales Tax", Country: "USA", State: "NY", TaxType: "sales", Kind: models.TaxKindPercentage, Rate: 8.875, Active: true},
+		{Name: "NYC Hotel Room Occupancy Tax", Country: "USA", State: "NY", City: "New York", TaxType: "occupancy", Kind: models.TaxKindPercentage, Rate: 5.875, Active: true},
+		{Name: "NYC Hotel Unit Fee", Country: "USA", State: "NY", City: "New York", TaxType: "unit_fee", Kind: models.TaxKindPerNight, Rate: 1.50, Active: true},
+	}
+	if err := db.Create(&taxRules).Error; err != nil {
+		return err
+	}
+	log.Println("Created tax rules")
+
 	// Associate amenities with properties
 	amenityList, err := getAmenities(db)
 	if err != nil {
@@ -221,6 +276,31 @@ func SeedDatabase(db *gorm.DB) error {
 	}
 	log.Println("Associated conditions with properties")
 
+	// Create channels with their pricing rules and map both properties
+	channels := []models.Channel{
+		{ID: "booking_com", Name: "Booking.com", Active: true, Currency: "USD", MarkupPercent: 10, CommissionPercent: 15},
+		{ID: "airbnb", Name: "Airbnb", Active: true, Currency: "USD", MarkupPercent: 5, CommissionPercent: 3},
+		{ID: "expedia", Name: "Expedia", Active: true, Currency: "USD", CommissionPercent: 18, GrossUpCommission: true},
+	}
+	if err := db.Create(&channels).Error; err != nil {
+		return err
+	}
+
+	for _, channel := range channels {
+		for _, prop := range []models.Property{prop1, prop2} {
+			mapping := models.ChannelMapping{
+				ChannelID:          channel.ID,
+				PropertyID:         prop.ID,
+				ExternalPropertyID: fmt.Sprintf("%s-%d", channel.ID, prop.ID),
+				Active:             true,
+			}
+			if err := db.Create(&mapping).Error; err != nil {
+				return err
+			}
+		}
+	}
+	log.Println("Created channels and channel mappings")
+
 	log.Println("Database seed completed successfully")
 	return nil
 }
@@ -0,0 +1,291 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"time"
+
+	"channelmanager/models"
+	"channelmanager/rates"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// SeedOptions controls the volume of synthetic data created by SeedSynthetic
+type SeedOptions struct {
+	Properties  int      // number of properties to generate
+	Cities      []string // cities to spread them over, from SyntheticCities; empty uses all
+	HorizonDays int      // days of availability and pricing from today
+	BatchSize   int      // rows per INSERT statement
+	RandSeed    int64    // seed for the generated content, so runs can be reproduced
+}
+
+// SyntheticCity is a city properties can be generated in
+type SyntheticCity struct {
+	Name      string
+	State     string
+	Country   string
+	Latitude  float64
+	Longitude float64
+	Timezone  string
+	BaseRate  float64 // typical nightly rate in the city
+}
+
+// SyntheticCities lists the cities SeedSynthetic can generate properties in
+var SyntheticCities = []SyntheticCity{
+	{Name: "New York", State: "NY", Country: "USA", Latitude: 40.7128, Longitude: -74.0060, Timezone: "America/New_York", BaseRate: 220},
+	{Name: "Malibu", State: "CA", Country: "USA", Latitude: 34.0259, Longitude: -118.7798, Timezone: "America/Los_Angeles", BaseRate: 450},
+	{Name: "San Francisco", State: "CA", Country: "USA", Latitude: 37.7749, Longitude: -122.4194, Timezone: "America/Los_Angeles", BaseRate: 240},
+	{Name: "Miami", State: "FL", Country: "USA", Latitude: 25.7617, Longitude: -80.1918, Timezone: "America/New_York", BaseRate: 210},
+	{Name: "Austin", State: "TX", Country: "USA", Latitude: 30.2672, Longitude: -97.7431, Timezone: "America/Chicago", BaseRate: 160},
+	{Name: "Chicago", State: "IL", Country: "USA", Latitude: 41.8781, Longitude: -87.6298, Timezone: "America/Chicago", BaseRate: 170},
+	{Name: "Seattle", State: "WA", Country: "USA", Latitude: 47.6062, Longitude: -122.3321, Timezone: "America/Los_Angeles", BaseRate: 180},
+	{Name: "New Orleans", State: "LA", Country: "USA", Latitude: 29.9511, Longitude: -90.0715, Timezone: "America/Chicago", BaseRate: 150},
+	{Name: "London", Country: "United Kingdom", Latitude: 51.5072, Longitude: -0.1276, Timezone: "Europe/London", BaseRate: 230},
+	{Name: "Paris", Country: "France", Latitude: 48.8566, Longitude: 2.3522, Timezone: "Europe/Paris", BaseRate: 210},
+	{Name: "Barcelona", Country: "Spain", Latitude: 41.3874, Longitude: 2.1686, Timezone: "Europe/Madrid", BaseRate: 160},
+	{Name: "Lisbon", Country: "Portugal", Latitude: 38.7223, Longitude: -9.1393, Timezone: "Europe/Lisbon", BaseRate: 130},
+	{Name: "Rome", Country: "Italy", Latitude: 41.9028, Longitude: 12.4964, Timezone: "Europe/Rome", BaseRate: 170},
+	{Name: "Amsterdam", Country: "Netherlands", Latitude: 52.3676, Longitude: 4.9041, Timezone: "Europe/Amsterdam", BaseRate: 200},
+	{Name: "Tokyo", Country: "Japan", Latitude: 35.6762, Longitude: 139.6503, Timezone: "Asia/Tokyo", BaseRate: 150},
+	{Name: "Sydney", State: "NSW", Country: "Australia", Latitude: -33.8688, Longitude: 151.2093, Timezone: "Australia/Sydney", BaseRate: 190},
+}
+
+var (
+	syntheticAdjectives = []string{"Sunny", "Cozy", "Modern", "Charming", "Spacious", "Quiet", "Elegant", "Rustic",
+		"Bright", "Stylish", "Historic", "Secluded", "Airy", "Renovated", "Peaceful", "Vibrant"}
+	syntheticKinds = []string{"Apartment", "Loft", "Studio", "Townhouse", "Villa", "Cottage", "Penthouse",
+		"Bungalow", "Flat", "Guesthouse", "Suite", "Cabin"}
+	syntheticFeatures = []string{"with a private terrace", "near the old town", "close to the beach", "with city views",
+		"steps from the metro", "with a garden", "by the river", "in a lively neighborhood", "with a rooftop pool",
+		"next to the park"}
+	syntheticSentences = []string{
+		"Recently renovated with plenty of natural light.",
+		"A short walk from cafes, restaurants and shops.",
+		"Fully equipped kitchen and fast WiFi for remote work.",
+		"Comfortable beds and blackout curtains for a restful stay.",
+		"Self check-in with a smart lock, any time after 3pm.",
+		"Ideal for families, couples and business travelers.",
+		"Quiet building with an elevator and secure entrance.",
+		"Public transport and bike rental right outside the door.",
+		"Relax on the balcony after a day of sightseeing.",
+		"Host lives nearby and is happy to share local tips.",
+	}
+)
+
+// SeedSynthetic adds opts.Properties generated properties with availability, pricing,
+// amenities and conditions for opts.HorizonDays, on top of the sample data from
+// SeedDatabase. Rows are written with multi-row inserts inside one transaction, so a
+// failed run leaves nothing behind. Running it again adds more properties.
+func SeedSynthetic(db *gorm.DB, opts SeedOptions) error {
+	if opts.Properties <= 0 {
+		return nil
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+	cities, err := syntheticCities(opts.Cities)
+	if err != nil {
+		return err
+	}
+
+	// Logging every statement of a large seed is slow and unreadable
+	db = db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Warn)})
+	if err := SeedDatabase(db); err != nil {
+		return err
+	}
+
+	started := time.Now()
+	random := rand.New(rand.NewSource(opts.RandSeed))
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var amenities []models.Amenity
+		var conditions []models.Condition
+		var ratings []models.PropertyRating
+		var organization models.Organization
+		if err := tx.Find(&amenities).Error; err != nil {
+			return err
+		}
+		if err := tx.Find(&conditions).Error; err != nil {
+			return err
+		}
+		if err := tx.Order("stars").Find(&ratings).Error; err != nil {
+			return err
+		}
+		if err := tx.Order("id").First(&organization).Error; err != nil {
+			return err
+		}
+
+		var existing int64
+		if err := tx.Model(&models.Property{}).Unscoped().Count(&existing).Error; err != nil {
+			return err
+		}
+
+		for offset := 0; offset < opts.Properties; offset += opts.BatchSize {
+			count := min(opts.BatchSize, opts.Properties-offset)
+			properties := make([]models.Property, count)
+			for i := range properties {
+				properties[i] = syntheticProperty(random, cities[random.Intn(len(cities))], int(existing)+offset+i+1, organization.ID, ratings)
+			}
+			// Associations are written separately in bulk below
+			if err := tx.Omit("Amenities", "Conditions").CreateInBatches(properties, opts.BatchSize).Error; err != nil {
+				return err
+			}
+			if err := seedSyntheticCalendar(tx, random, properties, opts); err != nil {
+				return err
+			}
+			if err := seedSyntheticFeatures(tx, random, properties, amenities, conditions, opts.BatchSize); err != nil {
+				return err
+			}
+			log.Printf("Seeded %d of %d synthetic properties", offset+count, opts.Properties)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Seeded %d synthetic properties with %d days of calendar in %s",
+		opts.Properties, opts.HorizonDays, time.Since(started).Round(time.Millisecond))
+	return nil
+}
+
+// syntheticCities resolves city names against SyntheticCities
+func syntheticCities(names []string) ([]SyntheticCity, error) {
+	if len(names) == 0 {
+		return SyntheticCities, nil
+	}
+	cities := make([]SyntheticCity, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, city := range SyntheticCities {
+			if strings.EqualFold(city.Name, name) {
+				cities = append(cities, city)
+				found = true
+				break
+			}
+		}
+		if !found {
+			known := make([]string, len(SyntheticCities))
+			for i, city := range SyntheticCities {
+				known[i] = city.Name
+			}
+			return nil, fmt.Errorf("unknown city %q, expected one of: %s", name, strings.Join(known, ", "))
+		}
+	}
+	return cities, nil
+}
+
+// syntheticProperty generates a property in city with randomized content
+func syntheticProperty(random *rand.Rand, city SyntheticCity, number int, organizationID uint, ratings []models.PropertyRating) models.Property {
+	kind := syntheticKinds[random.Intn(len(syntheticKinds))]
+	bedrooms := 1 + random.Intn(4)
+	if kind == "Studio" {
+		bedrooms = 1
+	}
+
+	sentences := random.Perm(len(syntheticSentences))[:3]
+	description := make([]string, len(sentences))
+	for i, index := range sentences {
+		description[i] = syntheticSentences[index]
+	}
+
+	property := models.Property{
+		ChannelID:      fmt.Sprintf("syn_%06d", number),
+		OrganizationID: &organizationID,
+		Name: fmt.Sprintf("%s %s %s", syntheticAdjectives[random.Intn(len(syntheticAdjectives))], kind,
+			syntheticFeatures[random.Intn(len(syntheticFeatures))]),
+		Description: strings.Join(description, " "),
+		Location:    strings.TrimSuffix(city.Name+", "+city.State, ", "),
+		City:        city.Name,
+		State:       city.State,
+		Country:     city.Country,
+		Timezone:    city.Timezone,
+		// Spread properties up to roughly 10km around the city center
+		Latitude:    city.Latitude + (random.Float64()-0.5)*0.18,
+		Longitude:   city.Longitude + (random.Float64()-0.5)*0.18,
+		MaxGuests:   bedrooms*2 + random.Intn(2),
+		Bedrooms:    bedrooms,
+		Bathrooms:   1 + random.Intn(bedrooms),
+		Rating:      float32(35+random.Intn(16)) / 10,
+		ReviewCount: random.Intn(400),
+
+		BaseNightlyRate:   float64(int(city.BaseRate * (0.6 + random.Float64()) * (1 + 0.25*float64(bedrooms-1)))),
+		WeekendMultiplier: 1 + float64(random.Intn(5))/10,
+	}
+	if len(ratings) > 0 {
+		property.RatingID = &ratings[random.Intn(len(ratings))].ID
+	}
+	return property
+}
+
+// seedSyntheticCalendar creates availability and pricing for the horizon from each
+// property's today, with about one night in ten already closed
+func seedSyntheticCalendar(tx *gorm.DB, random *rand.Rand, properties []models.Property, opts SeedOptions) error {
+	availability := make([]models.Availability, 0, len(properties)*opts.HorizonDays)
+	pricing := make([]models.Pricing, 0, len(properties)*opts.HorizonDays)
+	for _, property := range properties {
+		today := property.Today()
+		minStay := 1 + random.Intn(3)
+		for day := 0; day < opts.HorizonDays; day++ {
+			date := today.AddDate(0, 0, day)
+			availability = append(availability, models.Availability{
+				PropertyID: property.ID,
+				Date:       date,
+				Available:  random.Intn(10) != 0,
+				MinStay:    minStay,
+				MaxGuests:  property.MaxGuests,
+			})
+
+			basePrice := rates.NightlyRate(property, nil, nil, date)
+			pricing = append(pricing, models.Pricing{
+				PropertyID: property.ID,
+				Date:       date,
+				BasePrice:  basePrice,
+				Fees:       basePrice * 0.05,
+			})
+		}
+	}
+
+	if err := tx.CreateInBatches(availability, opts.BatchSize).Error; err != nil {
+		return err
+	}
+	return tx.CreateInBatches(pricing, opts.BatchSize).Error
+}
+
+// seedSyntheticFeatures links each property to a random set of amenities and to one pets
+// and one smoking condition
+func seedSyntheticFeatures(tx *gorm.DB, random *rand.Rand, properties []models.Property, amenities []models.Amenity, conditions []models.Condition, batchSize int) error {
+	conditionsByType := make(map[string][]models.Condition)
+	for _, condition := range conditions {
+		conditionsByType[condition.Type] = append(conditionsByType[condition.Type], condition)
+	}
+
+	var propertyAmenities, propertyConditions []map[string]interface{}
+	for _, property := range properties {
+		for _, index := range random.Perm(len(amenities))[:random.Intn(len(amenities)+1)] {
+			propertyAmenities = append(propertyAmenities, map[string]interface{}{
+				"property_id": property.ID, "amenity_id": amenities[index].ID,
+			})
+		}
+		for _, conditionType := range []string{"pets", "smoking"} {
+			if options := conditionsByType[conditionType]; len(options) > 0 {
+				propertyConditions = append(propertyConditions, map[string]interface{}{
+					"property_id": property.ID, "condition_id": options[random.Intn(len(options))].ID,
+				})
+			}
+		}
+	}
+
+	if len(propertyAmenities) > 0 {
+		if err := tx.Table("property_amenities").CreateInBatches(propertyAmenities, batchSize).Error; err != nil {
+			return err
+		}
+	}
+	if len(propertyConditions) > 0 {
+		return tx.Table("property_conditions").CreateInBatches(propertyConditions, batchSize).Error
+	}
+	return nil
+}
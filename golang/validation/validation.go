@@ -0,0 +1,60 @@
+// Package validation customizes the validator behind gin's binding tags: errors
+// name fields by their JSON key and custom rules cover API-specific checks.
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"channelmanager/models"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Register installs the JSON field naming and custom rules on gin's validator.
+// It must run before the router serves requests.
+func Register() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return fmt.Errorf("unexpected validator engine %T", binding.Validator.Engine())
+	}
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	if err := v.RegisterValidation("notpast", notPast); err != nil {
+		return err
+	}
+	return nil
+}
+
+// notPast accepts dates (time.Time or YYYY-MM-DD strings) that are still today or later
+// in some time zone; handlers check against the property's own today once it is known
+func notPast(fl validator.FieldLevel) bool {
+	var date time.Time
+	switch value := fl.Field().Interface().(type) {
+	case time.Time:
+		date = value
+	case string:
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return false
+		}
+		date = parsed
+	default:
+		return false
+	}
+
+	return !date.UTC().Before(models.EarliestToday())
+}
@@ -0,0 +1,179 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"channelmanager/models"
+)
+
+// ErrUnknownLocation is returned by providers that cannot find a city
+var ErrUnknownLocation = errors.New("unknown location")
+
+// Location is a city whose weather is forecast
+type Location struct {
+	City    string
+	Country string // name or ISO code; disambiguates cities sharing a name
+}
+
+// Provider is a source of weather forecasts
+type Provider interface {
+	// Name identifies the provider in forecasts and logs
+	Name() string
+	// Forecast fetches the daily forecast of a city for the coming days
+	Forecast(ctx context.Context, location Location, days int) ([]models.WeatherDay, error)
+}
+
+// OpenMeteoProvider forecasts with Open-Meteo, finding cities with its geocoding API
+type OpenMeteoProvider struct {
+	forecastURL  string
+	geocodingURL string
+	client       *http.Client
+}
+
+// NewOpenMeteoProvider creates an Open-Meteo provider
+func NewOpenMeteoProvider(forecastURL, geocodingURL string, timeout time.Duration) *OpenMeteoProvider {
+	return &OpenMeteoProvider{
+		forecastURL:  forecastURL,
+		geocodingURL: geocodingURL,
+		client:       &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *OpenMeteoProvider) Name() string {
+	return "openmeteo"
+}
+
+func (p *OpenMeteoProvider) Forecast(ctx context.Context, location Location, days int) ([]models.WeatherDay, error) {
+	latitude, longitude, err := p.geocode(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("latitude", strconv.FormatFloat(latitude, 'f', 4, 64))
+	query.Set("longitude", strconv.FormatFloat(longitude, 'f', 4, 64))
+	query.Set("daily", "weather_code,temperature_2m_max,temperature_2m_min,precipitation_probability_max")
+	query.Set("timezone", "auto")
+	query.Set("forecast_days", strconv.Itoa(days))
+
+	var forecast struct {
+		Daily struct {
+			Time                        []string   `json:"time"`
+			WeatherCode                 []int      `json:"weather_code"`
+			TemperatureMax              []float64  `json:"temperature_2m_max"`
+			TemperatureMin              []float64  `json:"temperature_2m_min"`
+			PrecipitationProbabilityMax []*float64 `json:"precipitation_probability_max"`
+		} `json:"daily"`
+	}
+	if err := p.get(ctx, p.forecastURL, query, &forecast); err != nil {
+		return nil, err
+	}
+
+	daily := forecast.Daily
+	n := len(daily.Time)
+	if len(daily.WeatherCode) < n || len(daily.TemperatureMax) < n || len(daily.TemperatureMin) < n {
+		return nil, fmt.Errorf("forecast series have different lengths")
+	}
+	result := make([]models.WeatherDay, n)
+	for i := range daily.Time {
+		result[i] = models.WeatherDay{
+			Date:      daily.Time[i],
+			Condition: condition(daily.WeatherCode[i]),
+			TempMinC:  daily.TemperatureMin[i],
+			TempMaxC:  daily.TemperatureMax[i],
+		}
+		if i < len(daily.PrecipitationProbabilityMax) && daily.PrecipitationProbabilityMax[i] != nil {
+			result[i].PrecipitationChance = int(math.Round(*daily.PrecipitationProbabilityMax[i]))
+		}
+	}
+	return result, nil
+}
+
+// geocode finds the coordinates of a city, preferring a match of its country
+func (p *OpenMeteoProvider) geocode(ctx context.Context, location Location) (float64, float64, error) {
+	query := url.Values{}
+	query.Set("name", location.City)
+	query.Set("count", "10")
+
+	var found struct {
+		Results []struct {
+			Latitude    float64 `json:"latitude"`
+			Longitude   float64 `json:"longitude"`
+			Country     string  `json:"country"`
+			CountryCode string  `json:"country_code"`
+		} `json:"results"`
+	}
+	if err := p.get(ctx, p.geocodingURL, query, &found); err != nil {
+		return 0, 0, err
+	}
+	if len(found.Results) == 0 {
+		return 0, 0, ErrUnknownLocation
+	}
+
+	best := found.Results[0]
+	for _, result := range found.Results {
+		if strings.EqualFold(result.Country, location.Country) || strings.EqualFold(result.CountryCode, location.Country) {
+			best = result
+			break
+		}
+	}
+	return best.Latitude, best.Longitude, nil
+}
+
+// get requests a JSON document
+func (p *OpenMeteoProvider) get(ctx context.Context, endpoint string, query url.Values, into interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("provider returned %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, into); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// condition names a WMO weather interpretation code
+func condition(code int) string {
+	switch {
+	case code == 0:
+		return "clear"
+	case code <= 2:
+		return "partly_cloudy"
+	case code == 3:
+		return "cloudy"
+	case code == 45 || code == 48:
+		return "fog"
+	case code >= 51 && code <= 57:
+		return "drizzle"
+	case (code >= 61 && code <= 67) || (code >= 80 && code <= 82):
+		return "rain"
+	case (code >= 71 && code <= 77) || code == 85 || code == 86:
+		return "snow"
+	case code >= 95:
+		return "thunderstorm"
+	}
+	return "unknown"
+}
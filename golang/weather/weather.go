@@ -0,0 +1,122 @@
+// Package weather attaches city forecasts to search responses. Forecasts come from a
+// swappable Provider and are cached per city in Redis.
+package weather
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"channelmanager/cache"
+	"channelmanager/models"
+)
+
+// Config holds weather integration configuration
+type Config struct {
+	Provider     string // "openmeteo"; empty disables the integration
+	ForecastURL  string
+	GeocodingURL string
+	Timeout      time.Duration // of each provider request
+	TTL          time.Duration // how long a city's forecast is cached
+	Days         int           // days forecast
+}
+
+// Service serves cached city forecasts
+type Service struct {
+	config   Config
+	provider Provider
+	redis    *cache.RedisClient
+}
+
+// NewService creates a weather service with the configured provider. It returns nil when
+// the integration is disabled.
+func NewService(config Config, redis *cache.RedisClient) *Service {
+	var provider Provider
+	switch config.Provider {
+	case "":
+		return nil
+	case "openmeteo":
+		provider = NewOpenMeteoProvider(config.ForecastURL, config.GeocodingURL, config.Timeout)
+	default:
+		log.Printf("Warning: unknown weather provider %q, weather is disabled", config.Provider)
+		return nil
+	}
+	return NewServiceWithProvider(config, provider, redis)
+}
+
+// NewServiceWithProvider creates a weather service forecasting with any provider
+func NewServiceWithProvider(config Config, provider Provider, redis *cache.RedisClient) *Service {
+	return &Service{config: config, provider: provider, redis: redis}
+}
+
+// Forecasts returns the forecasts of distinct cities in the order given. Cities the
+// provider cannot forecast are left out; forecasts missing from the cache are fetched
+// concurrently.
+func (s *Service) Forecasts(ctx context.Context, locations []Location) []models.WeatherForecast {
+	var distinct []Location
+	seen := make(map[string]bool, len(locations))
+	for _, location := range locations {
+		key := strings.ToLower(location.Country) + ":" + strings.ToLower(location.City)
+		if location.City == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		distinct = append(distinct, location)
+	}
+
+	forecasts := make([]*models.WeatherForecast, len(distinct))
+	var wg sync.WaitGroup
+	for i, location := range distinct {
+		cached, ok, err := s.redis.GetWeatherCache(ctx, location.City, location.Country)
+		if err != nil {
+			log.Printf("Failed to read cached weather of %s: %v", location.City, err)
+		}
+		if ok {
+			forecasts[i] = cached
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, location Location) {
+			defer wg.Done()
+			forecasts[i] = s.fetch(ctx, location)
+		}(i, location)
+	}
+	wg.Wait()
+
+	result := make([]models.WeatherForecast, 0, len(forecasts))
+	for _, forecast := range forecasts {
+		// Unknown cities are cached without days so they are not looked up on every search
+		if forecast != nil && len(forecast.Days) > 0 {
+			result = append(result, *forecast)
+		}
+	}
+	return result
+}
+
+// fetch forecasts a city with the provider and caches the forecast
+func (s *Service) fetch(ctx context.Context, location Location) *models.WeatherForecast {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	days, err := s.provider.Forecast(ctx, location, s.config.Days)
+	if err != nil && !errors.Is(err, ErrUnknownLocation) {
+		log.Printf("Weather provider %s failed for %s: %v", s.provider.Name(), location.City, err)
+		return nil
+	}
+
+	forecast := models.WeatherForecast{
+		City:      location.City,
+		Country:   location.Country,
+		Provider:  s.provider.Name(),
+		FetchedAt: time.Now(),
+		Days:      days,
+	}
+	if err := s.redis.SetWeatherCache(context.Background(), forecast, s.config.TTL); err != nil {
+		log.Printf("Failed to cache weather of %s: %v", location.City, err)
+	}
+	return &forecast
+}